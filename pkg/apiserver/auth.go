@@ -0,0 +1,316 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/kubecost/opencost/pkg/log"
+	"github.com/kubecost/opencost/pkg/util/json"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// AuthenticationConfigMapNamespace and AuthenticationConfigMapName locate the
+// ConfigMap in which the kube-apiserver publishes the client CA and headers
+// with which it proxies requests to aggregated APIs.
+const (
+	AuthenticationConfigMapNamespace = "kube-system"
+	AuthenticationConfigMapName      = "extension-apiserver-authentication"
+)
+
+// UserInfo is the user of an authenticated request.
+type UserInfo struct {
+	Name   string
+	UID    string
+	Groups []string
+	Extra  map[string][]string
+}
+
+// Authenticator authenticates requests. It returns false, and no error, if a
+// request carries no credentials it recognises.
+type Authenticator interface {
+	AuthenticateRequest(r *http.Request) (*UserInfo, bool, error)
+}
+
+// Attributes are the attributes of a request which are authorized.
+type Attributes struct {
+	User      *UserInfo
+	Verb      string
+	Resource  string
+	Namespace string
+	Name      string
+}
+
+// Authorizer authorizes requests. It returns false, and the reason, if the
+// request is denied.
+type Authorizer interface {
+	Authorize(ctx context.Context, attrs Attributes) (bool, string, error)
+}
+
+// unionAuthenticator authenticates requests with the first of its
+// authenticators which recognises their credentials.
+type unionAuthenticator []Authenticator
+
+// NewUnionAuthenticator returns an Authenticator which tries each of the given
+// authenticators in order. Nil authenticators are skipped.
+func NewUnionAuthenticator(authenticators ...Authenticator) Authenticator {
+	union := unionAuthenticator{}
+	for _, a := range authenticators {
+		if a != nil {
+			union = append(union, a)
+		}
+	}
+	return union
+}
+
+func (ua unionAuthenticator) AuthenticateRequest(r *http.Request) (*UserInfo, bool, error) {
+	var errs []string
+	for _, a := range ua {
+		user, ok, err := a.AuthenticateRequest(r)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if ok {
+			return user, true, nil
+		}
+	}
+	if len(errs) > 0 {
+		return nil, false, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil, false, nil
+}
+
+// RequestHeaderAuthenticator authenticates the requests which the
+// kube-apiserver proxies to aggregated APIs, by verifying their client
+// certificate and taking the user from their headers.
+type RequestHeaderAuthenticator struct {
+	clientCAs      *x509.CertPool
+	allowedNames   []string
+	usernameHeader []string
+	groupHeaders   []string
+	extraPrefixes  []string
+}
+
+// NewRequestHeaderAuthenticator creates a RequestHeaderAuthenticator from the
+// given PEM-encoded client CA and request header configuration, as published
+// in the extension-apiserver-authentication ConfigMap. An empty list of
+// allowed names allows any client certificate signed by the CA.
+func NewRequestHeaderAuthenticator(clientCA []byte, allowedNames, usernameHeaders, groupHeaders, extraPrefixes []string) (*RequestHeaderAuthenticator, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(clientCA) {
+		return nil, fmt.Errorf("no certificates found in request header client CA")
+	}
+	if len(usernameHeaders) == 0 {
+		return nil, fmt.Errorf("no request header username headers")
+	}
+
+	return &RequestHeaderAuthenticator{
+		clientCAs:      pool,
+		allowedNames:   allowedNames,
+		usernameHeader: usernameHeaders,
+		groupHeaders:   groupHeaders,
+		extraPrefixes:  extraPrefixes,
+	}, nil
+}
+
+// NewRequestHeaderAuthenticatorFromCluster creates a RequestHeaderAuthenticator
+// from the extension-apiserver-authentication ConfigMap. It returns nil, and
+// no error, if the cluster does not configure request header authentication.
+func NewRequestHeaderAuthenticatorFromCluster(ctx context.Context, client kubernetes.Interface) (*RequestHeaderAuthenticator, error) {
+	cm, err := client.CoreV1().ConfigMaps(AuthenticationConfigMapNamespace).Get(ctx, AuthenticationConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting ConfigMap %s/%s: %s", AuthenticationConfigMapNamespace, AuthenticationConfigMapName, err)
+	}
+
+	clientCA := cm.Data["requestheader-client-ca-file"]
+	if clientCA == "" {
+		return nil, nil
+	}
+
+	lists := map[string][]string{}
+	for _, key := range []string{"requestheader-allowed-names", "requestheader-username-headers", "requestheader-group-headers", "requestheader-extra-headers-prefix"} {
+		value, ok := cm.Data[key]
+		if !ok || value == "" {
+			continue
+		}
+		var list []string
+		if err := json.Unmarshal([]byte(value), &list); err != nil {
+			return nil, fmt.Errorf("error parsing %s of ConfigMap %s/%s: %s", key, AuthenticationConfigMapNamespace, AuthenticationConfigMapName, err)
+		}
+		lists[key] = list
+	}
+
+	return NewRequestHeaderAuthenticator(
+		[]byte(clientCA),
+		lists["requestheader-allowed-names"],
+		lists["requestheader-username-headers"],
+		lists["requestheader-group-headers"],
+		lists["requestheader-extra-headers-prefix"],
+	)
+}
+
+func (rha *RequestHeaderAuthenticator) AuthenticateRequest(r *http.Request) (*UserInfo, bool, error) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil, false, nil
+	}
+
+	certs := r.TLS.PeerCertificates
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         rha.clientCAs,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		// The certificate may be for another authenticator.
+		return nil, false, nil
+	}
+
+	if len(rha.allowedNames) > 0 && !contains(rha.allowedNames, certs[0].Subject.CommonName) {
+		return nil, false, fmt.Errorf("request header client certificate %q is not allowed", certs[0].Subject.CommonName)
+	}
+
+	name := firstHeader(r.Header, rha.usernameHeader)
+	if name == "" {
+		return nil, false, nil
+	}
+
+	user := &UserInfo{
+		Name:  name,
+		Extra: map[string][]string{},
+	}
+	for _, header := range rha.groupHeaders {
+		user.Groups = append(user.Groups, r.Header.Values(header)...)
+	}
+	for key, values := range r.Header {
+		for _, prefix := range rha.extraPrefixes {
+			if len(key) > len(prefix) && strings.EqualFold(key[:len(prefix)], prefix) {
+				extraKey := strings.ToLower(key[len(prefix):])
+				user.Extra[extraKey] = append(user.Extra[extraKey], values...)
+			}
+		}
+	}
+
+	return user, true, nil
+}
+
+// TokenReviewAuthenticator authenticates requests by their bearer token, with
+// a TokenReview.
+type TokenReviewAuthenticator struct {
+	client kubernetes.Interface
+}
+
+// NewTokenReviewAuthenticator creates a new TokenReviewAuthenticator.
+func NewTokenReviewAuthenticator(client kubernetes.Interface) *TokenReviewAuthenticator {
+	return &TokenReviewAuthenticator{
+		client: client,
+	}
+}
+
+func (tra *TokenReviewAuthenticator) AuthenticateRequest(r *http.Request) (*UserInfo, bool, error) {
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "bearer ") {
+		return nil, false, nil
+	}
+	token := strings.TrimSpace(auth[7:])
+	if token == "" {
+		return nil, false, nil
+	}
+
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}
+	review, err := tra.client.AuthenticationV1().TokenReviews().Create(r.Context(), review, metav1.CreateOptions{})
+	if err != nil {
+		return nil, false, fmt.Errorf("error reviewing token: %s", err)
+	}
+	if !review.Status.Authenticated {
+		if review.Status.Error != "" {
+			log.Debugf("API server: token not authenticated: %s", review.Status.Error)
+		}
+		return nil, false, nil
+	}
+
+	user := &UserInfo{
+		Name:   review.Status.User.Username,
+		UID:    review.Status.User.UID,
+		Groups: review.Status.User.Groups,
+		Extra:  map[string][]string{},
+	}
+	for key, values := range review.Status.User.Extra {
+		user.Extra[key] = values
+	}
+
+	return user, true, nil
+}
+
+// SubjectAccessReviewAuthorizer authorizes requests with a SubjectAccessReview,
+// so that access to the cost resources is granted with RBAC, as for any other.
+type SubjectAccessReviewAuthorizer struct {
+	client kubernetes.Interface
+}
+
+// NewSubjectAccessReviewAuthorizer creates a new SubjectAccessReviewAuthorizer.
+func NewSubjectAccessReviewAuthorizer(client kubernetes.Interface) *SubjectAccessReviewAuthorizer {
+	return &SubjectAccessReviewAuthorizer{
+		client: client,
+	}
+}
+
+func (sara *SubjectAccessReviewAuthorizer) Authorize(ctx context.Context, attrs Attributes) (bool, string, error) {
+	extra := map[string]authorizationv1.ExtraValue{}
+	for key, values := range attrs.User.Extra {
+		extra[key] = values
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   attrs.User.Name,
+			UID:    attrs.User.UID,
+			Groups: attrs.User.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Group:     GroupName,
+				Version:   Version,
+				Resource:  attrs.Resource,
+				Namespace: attrs.Namespace,
+				Name:      attrs.Name,
+				Verb:      attrs.Verb,
+			},
+		},
+	}
+	review, err := sara.client.AuthorizationV1().SubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return false, "", fmt.Errorf("error reviewing access: %s", err)
+	}
+
+	return review.Status.Allowed && !review.Status.Denied, review.Status.Reason, nil
+}
+
+func firstHeader(header http.Header, names []string) string {
+	for _, name := range names {
+		if value := strings.TrimSpace(header.Get(name)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,307 @@
+package apiserver
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/env"
+	"github.com/kubecost/opencost/pkg/kubecost"
+	"github.com/kubecost/opencost/pkg/log"
+	"github.com/kubecost/opencost/pkg/util/json"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/cert"
+)
+
+// Server serves the cost resources as a read-only Kubernetes aggregated API,
+// so that they may be listed with kubectl, and access to them granted with
+// RBAC. The kube-apiserver proxies requests under /apis/cost.opencost.io to
+// it, once registered with an APIService.
+type Server struct {
+	allocations   AllocationComputer
+	authenticator Authenticator
+	authorizer    Authorizer
+	resolution    time.Duration
+	defaultWindow string
+}
+
+// NewServer creates a new Server, which computes the costs of get requests,
+// and of list requests which do not select a window, over defaultWindow.
+func NewServer(allocations AllocationComputer, authenticator Authenticator, authorizer Authorizer, resolution time.Duration, defaultWindow string) *Server {
+	return &Server{
+		allocations:   allocations,
+		authenticator: authenticator,
+		authorizer:    authorizer,
+		resolution:    resolution,
+		defaultWindow: defaultWindow,
+	}
+}
+
+// ListenAndServeTLS serves the API on the given address with the given
+// certificate and key files, or a self-signed certificate if they are empty.
+// Client certificates are requested, but verified by the authenticator.
+func (s *Server) ListenAndServeTLS(addr, certFile, keyFile string) error {
+	var certificate tls.Certificate
+	var err error
+	if certFile != "" && keyFile != "" {
+		certificate, err = tls.LoadX509KeyPair(certFile, keyFile)
+	} else {
+		log.Infof("API server: no certificate configured, generating a self-signed certificate")
+		var certPEM, keyPEM []byte
+		certPEM, keyPEM, err = cert.GenerateSelfSignedCertKey("opencost", nil, nil)
+		if err == nil {
+			certificate, err = tls.X509KeyPair(certPEM, keyPEM)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("error loading API server certificate: %s", err)
+	}
+
+	server := &http.Server{
+		Addr:    addr,
+		Handler: s,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			ClientAuth:   tls.RequestClientCert,
+			MinVersion:   tls.VersionTLS12,
+		},
+	}
+
+	log.Infof("API server: serving %s on %s", SchemeGroupVersion, addr)
+	return server.ListenAndServeTLS("", "")
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+
+	switch path {
+	case "/healthz", "/livez", "/readyz":
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("ok"))
+		return
+	}
+
+	user, ok, err := s.authenticator.AuthenticateRequest(r)
+	if err != nil {
+		log.Warnf("API server: error authenticating request: %s", err)
+	}
+	if !ok {
+		writeStatus(w, apierrors.NewUnauthorized("Unauthorized"))
+		return
+	}
+
+	groupPath := "/apis/" + GroupName
+	versionPath := groupPath + "/" + Version
+
+	switch {
+	case path == "/apis":
+		writeObject(w, http.StatusOK, &metav1.APIGroupList{
+			TypeMeta: metav1.TypeMeta{Kind: "APIGroupList", APIVersion: "v1"},
+			Groups:   []metav1.APIGroup{apiGroup()},
+		})
+	case path == groupPath:
+		group := apiGroup()
+		group.TypeMeta = metav1.TypeMeta{Kind: "APIGroup", APIVersion: "v1"}
+		writeObject(w, http.StatusOK, &group)
+	case path == versionPath:
+		writeObject(w, http.StatusOK, apiResourceList())
+	case strings.HasPrefix(path, versionPath+"/"):
+		s.serveResource(w, r, user, strings.Split(strings.TrimPrefix(path, versionPath+"/"), "/"))
+	default:
+		writeStatus(w, apierrors.NewNotFound(schema.GroupResource{}, path))
+	}
+}
+
+// serveResource serves the get or list request of the given path segments,
+// which follow the path of the version.
+func (s *Server) serveResource(w http.ResponseWriter, r *http.Request, user *UserInfo, parts []string) {
+	namespace := ""
+	if len(parts) >= 3 && parts[0] == "namespaces" {
+		namespace = parts[1]
+		parts = parts[2:]
+	}
+
+	res, ok := resourceByName(parts[0])
+	if !ok || len(parts) > 2 || (namespace != "" && !res.namespaced) {
+		writeStatus(w, apierrors.NewNotFound(schema.GroupResource{Group: GroupName, Resource: parts[0]}, ""))
+		return
+	}
+	gr := schema.GroupResource{Group: GroupName, Resource: res.name}
+
+	name := ""
+	if len(parts) == 2 {
+		name = parts[1]
+		// Namespaced objects are only addressed within their namespace.
+		if res.namespaced && namespace == "" {
+			writeStatus(w, apierrors.NewNotFound(gr, name))
+			return
+		}
+	}
+
+	verb := "list"
+	if name != "" {
+		verb = "get"
+	}
+	qp := r.URL.Query()
+	if qp.Get("watch") == "true" || qp.Get("watch") == "1" {
+		verb = "watch"
+	}
+	if (r.Method != http.MethodGet && r.Method != http.MethodHead) || verb == "watch" {
+		if verb != "watch" {
+			verb = strings.ToLower(r.Method)
+		}
+		writeStatus(w, apierrors.NewMethodNotSupported(gr, verb))
+		return
+	}
+
+	allowed, reason, err := s.authorizer.Authorize(r.Context(), Attributes{
+		User:      user,
+		Verb:      verb,
+		Resource:  res.name,
+		Namespace: namespace,
+		Name:      name,
+	})
+	if err != nil {
+		log.Warnf("API server: error authorizing request: %s", err)
+	}
+	if !allowed {
+		msg := fmt.Sprintf("user %q cannot %s resource %q in API group %q", user.Name, verb, res.name, GroupName)
+		if namespace != "" {
+			msg += fmt.Sprintf(" in the namespace %q", namespace)
+		}
+		if reason != "" {
+			msg += ": " + reason
+		}
+		writeStatus(w, apierrors.NewForbidden(gr, name, fmt.Errorf("%s", msg)))
+		return
+	}
+
+	opts := &listOptions{}
+	if verb == "list" {
+		opts, err = parseListOptions(qp.Get("fieldSelector"), qp.Get("labelSelector"))
+		if err != nil {
+			writeStatus(w, apierrors.NewBadRequest(err.Error()))
+			return
+		}
+	}
+	if opts.window == "" {
+		opts.window = s.defaultWindow
+	}
+
+	window, err := kubecost.ParseWindowWithOffset(opts.window, env.GetParsedUTCOffset())
+	if err != nil {
+		writeStatus(w, apierrors.NewBadRequest(fmt.Sprintf("invalid window %q: %s", opts.window, err)))
+		return
+	}
+	if window.IsOpen() {
+		writeStatus(w, apierrors.NewBadRequest(fmt.Sprintf("invalid window %q: window must be closed", opts.window)))
+		return
+	}
+
+	objects, err := computeObjects(s.allocations, res, window, s.resolution, namespace)
+	if err != nil {
+		writeStatus(w, apierrors.NewInternalError(err))
+		return
+	}
+
+	if name != "" {
+		var found metav1.Object
+		for _, obj := range objects {
+			if obj.GetName() == name {
+				found = obj
+				break
+			}
+		}
+		if found == nil {
+			writeStatus(w, apierrors.NewNotFound(gr, name))
+			return
+		}
+		objects = []metav1.Object{found}
+	} else {
+		selected := []metav1.Object{}
+		for _, obj := range objects {
+			if opts.matches(obj) {
+				selected = append(selected, obj)
+			}
+		}
+		objects = selected
+	}
+
+	if wantsTable(r) {
+		table, err := newTable(res, objects, qp.Get("includeObject") != "None")
+		if err != nil {
+			writeStatus(w, apierrors.NewInternalError(err))
+			return
+		}
+		w.Header().Set("Content-Type", tableContentType)
+		writeJSON(w, http.StatusOK, table)
+		return
+	}
+
+	if name != "" {
+		writeObject(w, http.StatusOK, objects[0])
+		return
+	}
+	writeObject(w, http.StatusOK, res.newList(objects))
+}
+
+func apiGroup() metav1.APIGroup {
+	version := metav1.GroupVersionForDiscovery{
+		GroupVersion: SchemeGroupVersion.String(),
+		Version:      Version,
+	}
+	return metav1.APIGroup{
+		Name:             GroupName,
+		Versions:         []metav1.GroupVersionForDiscovery{version},
+		PreferredVersion: version,
+	}
+}
+
+func apiResourceList() *metav1.APIResourceList {
+	list := &metav1.APIResourceList{
+		TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: SchemeGroupVersion.String(),
+	}
+	for _, res := range resources {
+		list.APIResources = append(list.APIResources, metav1.APIResource{
+			Name:         res.name,
+			SingularName: res.singularName,
+			Namespaced:   res.namespaced,
+			Kind:         res.kind,
+			Verbs:        metav1.Verbs{"get", "list"},
+			ShortNames:   res.shortNames,
+			Categories:   []string{"costs"},
+		})
+	}
+	return list
+}
+
+func writeObject(w http.ResponseWriter, code int, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, code, obj)
+}
+
+func writeJSON(w http.ResponseWriter, code int, obj interface{}) {
+	resp, err := json.Marshal(obj)
+	if err != nil {
+		log.Errorf("API server: failed to marshal response: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(code)
+	w.Write(resp)
+}
+
+// writeStatus writes the Status of the given error, as the kube-apiserver
+// does, so that kubectl prints its message.
+func writeStatus(w http.ResponseWriter, err *apierrors.StatusError) {
+	status := err.Status()
+	status.TypeMeta = metav1.TypeMeta{Kind: "Status", APIVersion: "v1"}
+	writeObject(w, int(status.Code), &status)
+}
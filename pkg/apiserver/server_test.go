@@ -0,0 +1,395 @@
+package apiserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/kubecost"
+	"github.com/kubecost/opencost/pkg/util/json"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testWindow = "2022-06-01T00:00:00Z,2022-06-02T00:00:00Z"
+
+type fakeAllocations struct {
+	start, end time.Time
+}
+
+func (fa *fakeAllocations) ComputeAllocation(start, end time.Time, resolution time.Duration) (*kubecost.AllocationSet, error) {
+	fa.start, fa.end = start, end
+
+	props := func(ns, controller, pod string) *kubecost.AllocationProperties {
+		return &kubecost.AllocationProperties{
+			Cluster:        "cluster1",
+			Node:           "node1",
+			ProviderID:     "i-1",
+			Namespace:      ns,
+			ControllerKind: "deployment",
+			Controller:     controller,
+			Pod:            pod,
+			Container:      "container1",
+		}
+	}
+	idle := kubecost.NewMockUnitAllocation("cluster1/__idle__", start, end.Sub(start), &kubecost.AllocationProperties{Cluster: "cluster1", Node: "node1"})
+	idle.Name = "cluster1/" + kubecost.IdleSuffix
+
+	return kubecost.NewAllocationSet(start, end,
+		kubecost.NewMockUnitAllocation("cluster1/ns1/pod1/container1", start, end.Sub(start), props("ns1", "api", "pod1")),
+		kubecost.NewMockUnitAllocation("cluster1/ns1/pod2/container1", start, end.Sub(start), props("ns1", "api", "pod2")),
+		kubecost.NewMockUnitAllocation("cluster1/ns2/pod3/container1", start, end.Sub(start), props("ns2", "web", "pod3")),
+		idle,
+	), nil
+}
+
+// fakeAuthenticator authenticates requests by their X-Test-User header.
+type fakeAuthenticator struct{}
+
+func (fakeAuthenticator) AuthenticateRequest(r *http.Request) (*UserInfo, bool, error) {
+	name := r.Header.Get("X-Test-User")
+	if name == "" {
+		return nil, false, nil
+	}
+	return &UserInfo{Name: name}, true, nil
+}
+
+// fakeAuthorizer allows users named after a namespace access to it, and
+// "admin" access to everything.
+type fakeAuthorizer struct{}
+
+func (fakeAuthorizer) Authorize(ctx context.Context, attrs Attributes) (bool, string, error) {
+	if attrs.User.Name == "admin" || (attrs.Namespace != "" && attrs.User.Name == attrs.Namespace) {
+		return true, "", nil
+	}
+	return false, "not allowed", nil
+}
+
+func newTestServer() (*Server, *fakeAllocations) {
+	allocations := &fakeAllocations{}
+	return NewServer(allocations, fakeAuthenticator{}, fakeAuthorizer{}, time.Minute, testWindow), allocations
+}
+
+func get(t *testing.T, server *Server, user, path string, header map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+func TestServer_Discovery(t *testing.T) {
+	server, _ := newTestServer()
+
+	w := get(t, server, "", "/apis/cost.opencost.io/v1alpha1", nil)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected %d without authentication, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	w = get(t, server, "nobody", "/apis/cost.opencost.io/v1alpha1", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var list metav1.APIResourceList
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if list.GroupVersion != "cost.opencost.io/v1alpha1" || len(list.APIResources) != 3 {
+		t.Fatalf("unexpected resource list: %+v", list)
+	}
+	for _, res := range list.APIResources {
+		if res.Name == "nodecosts" && res.Namespaced {
+			t.Errorf("expected nodecosts to be cluster-scoped")
+		}
+		if res.Name == "namespacecosts" && !res.Namespaced {
+			t.Errorf("expected namespacecosts to be namespaced")
+		}
+	}
+
+	w = get(t, server, "nobody", "/apis/cost.opencost.io", nil)
+	var group metav1.APIGroup
+	if err := json.Unmarshal(w.Body.Bytes(), &group); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if group.PreferredVersion.GroupVersion != "cost.opencost.io/v1alpha1" {
+		t.Fatalf("unexpected group: %+v", group)
+	}
+}
+
+func TestServer_List(t *testing.T) {
+	server, allocations := newTestServer()
+
+	w := get(t, server, "admin", "/apis/cost.opencost.io/v1alpha1/namespacecosts", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var list NamespaceCostList
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if list.Kind != "NamespaceCostList" || list.APIVersion != "cost.opencost.io/v1alpha1" {
+		t.Fatalf("unexpected type: %s %s", list.APIVersion, list.Kind)
+	}
+	// The idle allocation is not a namespace
+	if len(list.Items) != 2 || list.Items[0].Name != "ns1" || list.Items[1].Name != "ns2" {
+		t.Fatalf("unexpected items: %+v", list.Items)
+	}
+	if list.Items[0].Costs.TotalCost <= list.Items[1].Costs.TotalCost {
+		t.Errorf("expected ns1, of two pods, to cost more than ns2, of one: %f <= %f", list.Items[0].Costs.TotalCost, list.Items[1].Costs.TotalCost)
+	}
+	if !list.Items[0].Window.Start.Time.Equal(allocations.start) {
+		t.Errorf("expected window start %s, got %s", allocations.start, list.Items[0].Window.Start)
+	}
+
+	w = get(t, server, "admin", "/apis/cost.opencost.io/v1alpha1/namespaces/ns1/workloadcosts", nil)
+	var workloads WorkloadCostList
+	if err := json.Unmarshal(w.Body.Bytes(), &workloads); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(workloads.Items) != 1 || workloads.Items[0].Name != "deployment.api" || workloads.Items[0].Namespace != "ns1" || workloads.Items[0].Workload.Name != "api" {
+		t.Fatalf("unexpected items: %+v", workloads.Items)
+	}
+
+	w = get(t, server, "admin", "/apis/cost.opencost.io/v1alpha1/nodecosts", nil)
+	var nodes NodeCostList
+	if err := json.Unmarshal(w.Body.Bytes(), &nodes); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(nodes.Items) != 1 || nodes.Items[0].Name != "node1" || nodes.Items[0].ProviderID != "i-1" || nodes.Items[0].Namespace != "" {
+		t.Fatalf("unexpected items: %+v", nodes.Items)
+	}
+}
+
+func TestServer_ListWindowAndSelectors(t *testing.T) {
+	server, allocations := newTestServer()
+
+	path := "/apis/cost.opencost.io/v1alpha1/namespacecosts?fieldSelector=" +
+		"window%3D2022-06-03T00:00:00Z%5C,2022-06-05T00:00:00Z,metadata.name%21%3Dns1"
+	w := get(t, server, "admin", path, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if allocations.start != time.Date(2022, 6, 3, 0, 0, 0, 0, time.UTC) || allocations.end != time.Date(2022, 6, 5, 0, 0, 0, 0, time.UTC) {
+		t.Fatalf("unexpected window: %s, %s", allocations.start, allocations.end)
+	}
+	var list NamespaceCostList
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Name != "ns2" {
+		t.Fatalf("unexpected items: %+v", list.Items)
+	}
+
+	w = get(t, server, "admin", "/apis/cost.opencost.io/v1alpha1/namespacecosts?fieldSelector=window%3Dforever", nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d for an invalid window, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestServer_Get(t *testing.T) {
+	server, _ := newTestServer()
+
+	w := get(t, server, "ns1", "/apis/cost.opencost.io/v1alpha1/namespaces/ns1/workloadcosts/deployment.api", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var workload WorkloadCost
+	if err := json.Unmarshal(w.Body.Bytes(), &workload); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if workload.Kind != "WorkloadCost" || workload.Name != "deployment.api" {
+		t.Fatalf("unexpected object: %+v", workload)
+	}
+
+	w = get(t, server, "ns1", "/apis/cost.opencost.io/v1alpha1/namespaces/ns1/workloadcosts/deployment.web", nil)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+	var status metav1.Status
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if status.Kind != "Status" || status.Reason != metav1.StatusReasonNotFound {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+}
+
+func TestServer_Authorization(t *testing.T) {
+	server, _ := newTestServer()
+
+	cases := map[string]struct {
+		user string
+		path string
+		code int
+	}{
+		"own namespace": {
+			user: "ns1",
+			path: "/apis/cost.opencost.io/v1alpha1/namespaces/ns1/namespacecosts",
+			code: http.StatusOK,
+		},
+		"other namespace": {
+			user: "ns1",
+			path: "/apis/cost.opencost.io/v1alpha1/namespaces/ns2/namespacecosts",
+			code: http.StatusForbidden,
+		},
+		"all namespaces": {
+			user: "ns1",
+			path: "/apis/cost.opencost.io/v1alpha1/namespacecosts",
+			code: http.StatusForbidden,
+		},
+		"cluster-scoped": {
+			user: "ns1",
+			path: "/apis/cost.opencost.io/v1alpha1/nodecosts",
+			code: http.StatusForbidden,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := get(t, server, tc.user, tc.path, nil)
+			if w.Code != tc.code {
+				t.Fatalf("expected %d, got %d: %s", tc.code, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_MethodNotSupported(t *testing.T) {
+	server, _ := newTestServer()
+
+	w := get(t, server, "admin", "/apis/cost.opencost.io/v1alpha1/namespacecosts?watch=true", nil)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected %d for watch, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/apis/cost.opencost.io/v1alpha1/nodecosts/node1", nil)
+	req.Header.Set("X-Test-User", "admin")
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected %d for delete, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+func TestServer_Table(t *testing.T) {
+	server, _ := newTestServer()
+
+	header := map[string]string{"Accept": "application/json;as=Table;v=v1;g=meta.k8s.io,application/json"}
+	w := get(t, server, "admin", "/apis/cost.opencost.io/v1alpha1/namespaces/ns1/workloadcosts", header)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Header().Get("Content-Type"), "as=Table") {
+		t.Fatalf("unexpected content type: %s", w.Header().Get("Content-Type"))
+	}
+
+	var table metav1.Table
+	if err := json.Unmarshal(w.Body.Bytes(), &table); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if table.ColumnDefinitions[0].Name != "Name" || table.ColumnDefinitions[1].Name != "Kind" || table.ColumnDefinitions[2].Name != "Window" {
+		t.Fatalf("unexpected columns: %+v", table.ColumnDefinitions)
+	}
+	if len(table.Rows) != 1 {
+		t.Fatalf("expected 1 row, got %d", len(table.Rows))
+	}
+	row := table.Rows[0]
+	if len(row.Cells) != len(table.ColumnDefinitions) || row.Cells[0] != "deployment.api" || row.Cells[1] != "deployment" || row.Cells[2] != "1d" {
+		t.Fatalf("unexpected cells: %v", row.Cells)
+	}
+
+	var partial metav1.PartialObjectMetadata
+	if err := json.Unmarshal(row.Object.Raw, &partial); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if partial.Name != "deployment.api" || partial.Namespace != "ns1" {
+		t.Fatalf("unexpected row object: %+v", partial)
+	}
+}
+
+func TestParseListOptions(t *testing.T) {
+	opts, err := parseListOptions("", "window=7d,team=a")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if opts.window != "7d" {
+		t.Errorf("expected window 7d, got %q", opts.window)
+	}
+	if opts.labels.String() != "team=a" {
+		t.Errorf("expected remaining label selector team=a, got %q", opts.labels.String())
+	}
+
+	if _, err := parseListOptions("window=1d", "window=7d"); err == nil {
+		t.Errorf("expected error for conflicting windows")
+	}
+	if _, err := parseListOptions("window!=1d", ""); err == nil {
+		t.Errorf("expected error for window !=")
+	}
+
+	opts, err = parseListOptions("metadata.namespace=ns1", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if opts.window != "" {
+		t.Errorf("expected no window, got %q", opts.window)
+	}
+	if !opts.matches(&NamespaceCost{ObjectMeta: metav1.ObjectMeta{Name: "ns1", Namespace: "ns1"}}) {
+		t.Errorf("expected ns1 to match")
+	}
+	if opts.matches(&NamespaceCost{ObjectMeta: metav1.ObjectMeta{Name: "ns2", Namespace: "ns2"}}) {
+		t.Errorf("expected ns2 not to match")
+	}
+}
+
+func TestSubjectAccessReviewAuthorizer(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	var spec authorizationv1.SubjectAccessReviewSpec
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		spec = review.Spec
+		review.Status.Allowed = review.Spec.User == "alice"
+		return true, review, nil
+	})
+
+	authorizer := NewSubjectAccessReviewAuthorizer(client)
+	allowed, _, err := authorizer.Authorize(context.Background(), Attributes{
+		User:      &UserInfo{Name: "alice", Groups: []string{"team-a"}},
+		Verb:      "list",
+		Resource:  "workloadcosts",
+		Namespace: "ns1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !allowed {
+		t.Errorf("expected alice to be allowed")
+	}
+
+	attrs := spec.ResourceAttributes
+	if attrs == nil || attrs.Group != GroupName || attrs.Version != Version || attrs.Resource != "workloadcosts" || attrs.Namespace != "ns1" || attrs.Verb != "list" {
+		t.Fatalf("unexpected resource attributes: %+v", attrs)
+	}
+	if len(spec.Groups) != 1 || spec.Groups[0] != "team-a" {
+		t.Fatalf("unexpected groups: %v", spec.Groups)
+	}
+
+	allowed, _, _ = authorizer.Authorize(context.Background(), Attributes{User: &UserInfo{Name: "bob"}, Verb: "get", Resource: "nodecosts"})
+	if allowed {
+		t.Errorf("expected bob to be denied")
+	}
+}
@@ -0,0 +1,298 @@
+package apiserver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/kubecost"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// WindowSelectorKey is the field, or label, of list selectors which selects
+// the window of the listed costs, e.g. --field-selector window=7d. Windows
+// containing commas must have them escaped in field selectors.
+const WindowSelectorKey = "window"
+
+// AllocationComputer computes the unaggregated AllocationSet of a window.
+type AllocationComputer interface {
+	ComputeAllocation(start, end time.Time, resolution time.Duration) (*kubecost.AllocationSet, error)
+}
+
+// resource is a read-only cost resource, of which the objects are the
+// allocations of a window, aggregated by the properties of the resource.
+type resource struct {
+	name         string
+	singularName string
+	kind         string
+	namespaced   bool
+	shortNames   []string
+	aggregateBy  []string
+	// newObject returns the object of the given aggregated allocation, or
+	// nil if it is not one of the resource, e.g. if it is idle.
+	newObject func(a *kubecost.Allocation, window CostWindow) metav1.Object
+	// newList returns the list of the given objects.
+	newList func(objects []metav1.Object) interface{}
+	// columns and cells are the resource-specific columns of tables,
+	// preceding the columns of windows and costs.
+	columns []metav1.TableColumnDefinition
+	cells   func(obj metav1.Object) []interface{}
+}
+
+var resources = []*resource{
+	{
+		name:         "namespacecosts",
+		singularName: "namespacecost",
+		kind:         "NamespaceCost",
+		namespaced:   true,
+		shortNames:   []string{"nscost"},
+		aggregateBy:  []string{kubecost.AllocationNamespaceProp},
+		newObject: func(a *kubecost.Allocation, window CostWindow) metav1.Object {
+			ns := a.Properties.Namespace
+			if ns == "" || isSpecialName(ns) {
+				return nil
+			}
+			return &NamespaceCost{
+				TypeMeta:   typeMeta("NamespaceCost"),
+				ObjectMeta: metav1.ObjectMeta{Name: ns, Namespace: ns},
+				Window:     window,
+				Costs:      toCosts(a),
+			}
+		},
+		newList: func(objects []metav1.Object) interface{} {
+			list := &NamespaceCostList{TypeMeta: typeMeta("NamespaceCostList"), Items: []NamespaceCost{}}
+			for _, obj := range objects {
+				list.Items = append(list.Items, *obj.(*NamespaceCost))
+			}
+			return list
+		},
+	},
+	{
+		name:         "workloadcosts",
+		singularName: "workloadcost",
+		kind:         "WorkloadCost",
+		namespaced:   true,
+		shortNames:   []string{"wlcost"},
+		aggregateBy:  []string{kubecost.AllocationNamespaceProp, kubecost.AllocationControllerKindProp, kubecost.AllocationControllerProp},
+		newObject: func(a *kubecost.Allocation, window CostWindow) metav1.Object {
+			props := a.Properties
+			if props.Namespace == "" || props.ControllerKind == "" || props.Controller == "" || isSpecialName(props.Controller) {
+				return nil
+			}
+			return &WorkloadCost{
+				TypeMeta:   typeMeta("WorkloadCost"),
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s.%s", props.ControllerKind, props.Controller), Namespace: props.Namespace},
+				Workload:   WorkloadReference{Kind: props.ControllerKind, Name: props.Controller},
+				Window:     window,
+				Costs:      toCosts(a),
+			}
+		},
+		newList: func(objects []metav1.Object) interface{} {
+			list := &WorkloadCostList{TypeMeta: typeMeta("WorkloadCostList"), Items: []WorkloadCost{}}
+			for _, obj := range objects {
+				list.Items = append(list.Items, *obj.(*WorkloadCost))
+			}
+			return list
+		},
+		columns: []metav1.TableColumnDefinition{
+			{Name: "Kind", Type: "string", Description: "The kind of the controller of the workload."},
+		},
+		cells: func(obj metav1.Object) []interface{} {
+			return []interface{}{obj.(*WorkloadCost).Workload.Kind}
+		},
+	},
+	{
+		name:         "nodecosts",
+		singularName: "nodecost",
+		kind:         "NodeCost",
+		namespaced:   false,
+		shortNames:   []string{"nodecost"},
+		aggregateBy:  []string{kubecost.AllocationNodeProp},
+		newObject: func(a *kubecost.Allocation, window CostWindow) metav1.Object {
+			node := a.Properties.Node
+			if node == "" || isSpecialName(node) {
+				return nil
+			}
+			return &NodeCost{
+				TypeMeta:   typeMeta("NodeCost"),
+				ObjectMeta: metav1.ObjectMeta{Name: node},
+				ProviderID: a.Properties.ProviderID,
+				Window:     window,
+				Costs:      toCosts(a),
+			}
+		},
+		newList: func(objects []metav1.Object) interface{} {
+			list := &NodeCostList{TypeMeta: typeMeta("NodeCostList"), Items: []NodeCost{}}
+			for _, obj := range objects {
+				list.Items = append(list.Items, *obj.(*NodeCost))
+			}
+			return list
+		},
+	},
+}
+
+// resourceByName returns the resource of the given plural name.
+func resourceByName(name string) (*resource, bool) {
+	for _, r := range resources {
+		if r.name == name {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+func typeMeta(kind string) metav1.TypeMeta {
+	return metav1.TypeMeta{Kind: kind, APIVersion: SchemeGroupVersion.String()}
+}
+
+// isSpecialName returns true if the given name is one of the names which
+// allocations are given when they are idle, unallocated or unmounted, rather
+// than a name of an object.
+func isSpecialName(name string) bool {
+	return strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__")
+}
+
+func toCosts(a *kubecost.Allocation) Costs {
+	return Costs{
+		CPUCost:          a.CPUTotalCost(),
+		GPUCost:          a.GPUTotalCost(),
+		RAMCost:          a.RAMTotalCost(),
+		PVCost:           a.PVTotalCost(),
+		NetworkCost:      a.NetworkTotalCost(),
+		LoadBalancerCost: a.LoadBalancerTotalCost(),
+		SharedCost:       a.SharedTotalCost(),
+		ExternalCost:     a.ExternalCost,
+		TotalCost:        a.TotalCost(),
+		CPUEfficiency:    a.CPUEfficiency(),
+		RAMEfficiency:    a.RAMEfficiency(),
+		TotalEfficiency:  a.TotalEfficiency(),
+	}
+}
+
+// listOptions are the options of a list request, parsed from its selectors.
+type listOptions struct {
+	window string
+	fields fields.Selector
+	labels labels.Selector
+}
+
+// parseListOptions parses the field and label selectors of a list request,
+// taking the window from either, and leaving the rest to select objects.
+func parseListOptions(fieldSelector, labelSelector string) (*listOptions, error) {
+	opts := &listOptions{
+		fields: fields.Everything(),
+		labels: labels.Everything(),
+	}
+
+	if fieldSelector != "" {
+		fs, err := fields.ParseSelector(fieldSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field selector: %s", err)
+		}
+
+		rest := []fields.Selector{}
+		for _, req := range fs.Requirements() {
+			if req.Field != WindowSelectorKey {
+				if req.Operator == selection.NotEquals {
+					rest = append(rest, fields.OneTermNotEqualSelector(req.Field, req.Value))
+				} else {
+					rest = append(rest, fields.OneTermEqualSelector(req.Field, req.Value))
+				}
+				continue
+			}
+			if req.Operator != selection.Equals && req.Operator != selection.DoubleEquals {
+				return nil, fmt.Errorf("invalid field selector: %s only supports =", WindowSelectorKey)
+			}
+			opts.window = req.Value
+		}
+		opts.fields = fields.AndSelectors(rest...)
+	}
+
+	if labelSelector != "" {
+		ls, err := labels.Parse(labelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector: %s", err)
+		}
+
+		reqs, _ := ls.Requirements()
+		rest := labels.NewSelector()
+		for _, req := range reqs {
+			if req.Key() != WindowSelectorKey {
+				rest = rest.Add(req)
+				continue
+			}
+			values := req.Values().List()
+			if (req.Operator() != selection.Equals && req.Operator() != selection.DoubleEquals) || len(values) != 1 {
+				return nil, fmt.Errorf("invalid label selector: %s only supports =", WindowSelectorKey)
+			}
+			if opts.window != "" && opts.window != values[0] {
+				return nil, fmt.Errorf("conflicting %s selectors: %s and %s", WindowSelectorKey, opts.window, values[0])
+			}
+			opts.window = values[0]
+		}
+		opts.labels = rest
+	}
+
+	return opts, nil
+}
+
+// matches returns true if the given object is selected by the options.
+func (opts *listOptions) matches(obj metav1.Object) bool {
+	if !opts.labels.Matches(labels.Set(obj.GetLabels())) {
+		return false
+	}
+	return opts.fields.Matches(fields.Set{
+		"metadata.name":      obj.GetName(),
+		"metadata.namespace": obj.GetNamespace(),
+	})
+}
+
+// computeObjects returns the objects of the given resource in the given
+// namespace, or in all namespaces, of the given window, by namespace and name.
+func computeObjects(allocations AllocationComputer, res *resource, window kubecost.Window, resolution time.Duration, namespace string) ([]metav1.Object, error) {
+	as, err := allocations.ComputeAllocation(*window.Start(), *window.End(), resolution)
+	if err != nil {
+		return nil, fmt.Errorf("error computing allocations for %s: %s", window, err)
+	}
+
+	options := &kubecost.AllocationAggregationOptions{}
+	if namespace != "" {
+		options.FilterFuncs = append(options.FilterFuncs, func(a *kubecost.Allocation) bool {
+			return a.Properties != nil && a.Properties.Namespace == namespace
+		})
+	}
+
+	err = as.AggregateBy(res.aggregateBy, options)
+	if err != nil {
+		return nil, fmt.Errorf("error aggregating allocations by %s: %s", strings.Join(res.aggregateBy, ","), err)
+	}
+
+	costWindow := CostWindow{
+		Start: metav1.NewTime(*window.Start()),
+		End:   metav1.NewTime(*window.End()),
+	}
+
+	objects := []metav1.Object{}
+	as.Each(func(_ string, a *kubecost.Allocation) {
+		if a.Properties == nil || a.IsIdle() || a.IsUnmounted() {
+			return
+		}
+		if obj := res.newObject(a, costWindow); obj != nil {
+			objects = append(objects, obj)
+		}
+	})
+
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].GetNamespace() != objects[j].GetNamespace() {
+			return objects[i].GetNamespace() < objects[j].GetNamespace()
+		}
+		return objects[i].GetName() < objects[j].GetName()
+	})
+
+	return objects, nil
+}
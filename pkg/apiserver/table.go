@@ -0,0 +1,113 @@
+package apiserver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/kubecost/opencost/pkg/util/json"
+	"github.com/kubecost/opencost/pkg/util/timeutil"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// tableContentType is the content type of Table responses, which kubectl get
+// requests in order to print the columns of the server.
+const tableContentType = "application/json;as=Table;v=v1;g=meta.k8s.io"
+
+// wantsTable returns true if the request accepts the Table representation of
+// objects.
+func wantsTable(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if strings.Contains(accept, "as=Table") {
+			return true
+		}
+	}
+	return false
+}
+
+// costColumns are the columns of the costs of tables, following the columns
+// of the name, those of the resource, and the window.
+var costColumns = []metav1.TableColumnDefinition{
+	{Name: "CPU", Type: "string", Description: "The cost of CPU."},
+	{Name: "RAM", Type: "string", Description: "The cost of RAM."},
+	{Name: "GPU", Type: "string", Description: "The cost of GPUs.", Priority: 1},
+	{Name: "PV", Type: "string", Description: "The cost of persistent volumes."},
+	{Name: "Network", Type: "string", Description: "The cost of network egress.", Priority: 1},
+	{Name: "Shared", Type: "string", Description: "The shared cost.", Priority: 1},
+	{Name: "Total", Type: "string", Description: "The total cost."},
+	{Name: "Efficiency", Type: "string", Description: "The efficiency of the CPU and RAM requests."},
+}
+
+// newTable returns the Table of the given objects of the given resource.
+func newTable(res *resource, objects []metav1.Object, includeObject bool) (*metav1.Table, error) {
+	table := &metav1.Table{
+		TypeMeta: metav1.TypeMeta{Kind: "Table", APIVersion: "meta.k8s.io/v1"},
+		ColumnDefinitions: []metav1.TableColumnDefinition{
+			{Name: "Name", Type: "string", Format: "name", Description: "The name of the object."},
+		},
+		Rows: []metav1.TableRow{},
+	}
+	table.ColumnDefinitions = append(table.ColumnDefinitions, res.columns...)
+	table.ColumnDefinitions = append(table.ColumnDefinitions, metav1.TableColumnDefinition{
+		Name: "Window", Type: "string", Description: "The duration of the window of the costs.",
+	})
+	table.ColumnDefinitions = append(table.ColumnDefinitions, costColumns...)
+
+	for _, obj := range objects {
+		window, costs := windowAndCosts(obj)
+
+		cells := []interface{}{obj.GetName()}
+		if res.cells != nil {
+			cells = append(cells, res.cells(obj)...)
+		}
+		cells = append(cells,
+			timeutil.DurationString(window.End.Sub(window.Start.Time)),
+			formatCost(costs.CPUCost),
+			formatCost(costs.RAMCost),
+			formatCost(costs.GPUCost),
+			formatCost(costs.PVCost),
+			formatCost(costs.NetworkCost),
+			formatCost(costs.SharedCost),
+			formatCost(costs.TotalCost),
+			fmt.Sprintf("%.1f%%", costs.TotalEfficiency*100),
+		)
+
+		row := metav1.TableRow{Cells: cells}
+		if includeObject {
+			partial := &metav1.PartialObjectMetadata{
+				TypeMeta: metav1.TypeMeta{Kind: "PartialObjectMetadata", APIVersion: "meta.k8s.io/v1"},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      obj.GetName(),
+					Namespace: obj.GetNamespace(),
+					Labels:    obj.GetLabels(),
+				},
+			}
+			raw, err := json.Marshal(partial)
+			if err != nil {
+				return nil, err
+			}
+			row.Object = runtime.RawExtension{Raw: raw}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	return table, nil
+}
+
+func windowAndCosts(obj metav1.Object) (CostWindow, Costs) {
+	switch o := obj.(type) {
+	case *NamespaceCost:
+		return o.Window, o.Costs
+	case *WorkloadCost:
+		return o.Window, o.Costs
+	case *NodeCost:
+		return o.Window, o.Costs
+	}
+	return CostWindow{}, Costs{}
+}
+
+func formatCost(cost float64) string {
+	return fmt.Sprintf("%.2f", cost)
+}
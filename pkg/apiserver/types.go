@@ -0,0 +1,99 @@
+package apiserver
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupName is the name of the API group of the cost resources.
+const GroupName = "cost.opencost.io"
+
+// Version is the version of the cost resources.
+const Version = "v1alpha1"
+
+// SchemeGroupVersion is the group version of the cost resources.
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: Version}
+
+// CostWindow is the window over which costs are computed.
+type CostWindow struct {
+	Start metav1.Time `json:"start"`
+	End   metav1.Time `json:"end"`
+}
+
+// Costs are the costs of the resources of an object over a window, in the
+// currency of the configured pricing.
+type Costs struct {
+	CPUCost          float64 `json:"cpuCost"`
+	GPUCost          float64 `json:"gpuCost"`
+	RAMCost          float64 `json:"ramCost"`
+	PVCost           float64 `json:"pvCost"`
+	NetworkCost      float64 `json:"networkCost"`
+	LoadBalancerCost float64 `json:"loadBalancerCost"`
+	SharedCost       float64 `json:"sharedCost"`
+	ExternalCost     float64 `json:"externalCost"`
+	TotalCost        float64 `json:"totalCost"`
+	CPUEfficiency    float64 `json:"cpuEfficiency"`
+	RAMEfficiency    float64 `json:"ramEfficiency"`
+	TotalEfficiency  float64 `json:"totalEfficiency"`
+}
+
+// NamespaceCost is the cost of a namespace over a window. It is named after,
+// and is in, its namespace.
+type NamespaceCost struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Window CostWindow `json:"window"`
+	Costs  Costs      `json:"costs"`
+}
+
+// NamespaceCostList is a list of NamespaceCosts.
+type NamespaceCostList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []NamespaceCost `json:"items"`
+}
+
+// WorkloadReference identifies the controller of a workload.
+type WorkloadReference struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// WorkloadCost is the cost of the pods of a controller, e.g. a Deployment,
+// over a window. It is named "<kind>.<name>", e.g. "deployment.api".
+type WorkloadCost struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Workload WorkloadReference `json:"workload"`
+	Window   CostWindow        `json:"window"`
+	Costs    Costs             `json:"costs"`
+}
+
+// WorkloadCostList is a list of WorkloadCosts.
+type WorkloadCostList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []WorkloadCost `json:"items"`
+}
+
+// NodeCost is the cost allocated to the pods of a node over a window.
+type NodeCost struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	ProviderID string     `json:"providerID,omitempty"`
+	Window     CostWindow `json:"window"`
+	Costs      Costs      `json:"costs"`
+}
+
+// NodeCostList is a list of NodeCosts.
+type NodeCostList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []NodeCost `json:"items"`
+}
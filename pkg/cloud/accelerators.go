@@ -0,0 +1,138 @@
+package cloud
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kubecost/opencost/pkg/prom"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Accelerator types, by vendor or device family.
+const (
+	AcceleratorNVIDIA = "nvidia"
+	AcceleratorAMD    = "amd"
+	AcceleratorIntel  = "intel"
+	AcceleratorTPU    = "tpu"
+	AcceleratorNeuron = "neuron"
+)
+
+// AcceleratorResource is an extended resource, advertised by a device plugin, which allocates accelerators of a type.
+type AcceleratorResource struct {
+	Name string
+	Type string
+}
+
+// DefaultAcceleratorResources are the extended resources of the device plugins of NVIDIA, AMD and Intel GPUs, Google
+// TPUs and AWS Neuron (Inferentia and Trainium) devices.
+var DefaultAcceleratorResources = []AcceleratorResource{
+	{Name: "nvidia.com/gpu", Type: AcceleratorNVIDIA},
+	{Name: "amd.com/gpu", Type: AcceleratorAMD},
+	{Name: "gpu.intel.com/i915", Type: AcceleratorIntel},
+	{Name: "google.com/tpu", Type: AcceleratorTPU},
+	{Name: "aws.amazon.com/neuron", Type: AcceleratorNeuron},
+}
+
+// Accelerators discovers the accelerators of nodes and containers by a set of extended resources, and prices them
+// by their type. Accelerators are counted and priced as GPUs.
+type Accelerators struct {
+	resources []AcceleratorResource
+	prices    map[string]float64
+}
+
+// NewAccelerators returns the DefaultAcceleratorResources, extended by the accelerator resources of the
+// CustomPricing, and the accelerator prices of the CustomPricing.
+func NewAccelerators(cp *CustomPricing) (*Accelerators, error) {
+	a := &Accelerators{
+		resources: append([]AcceleratorResource{}, DefaultAcceleratorResources...),
+		prices:    map[string]float64{},
+	}
+	if cp == nil {
+		return a, nil
+	}
+
+	for _, pair := range strings.Split(cp.AcceleratorResources, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" || strings.TrimSpace(kv[1]) == "" {
+			return nil, fmt.Errorf("invalid acceleratorResources entry \"%s\", expected resource:type", pair)
+		}
+		a.resources = append(a.resources, AcceleratorResource{
+			Name: strings.TrimSpace(kv[0]),
+			Type: strings.ToLower(strings.TrimSpace(kv[1])),
+		})
+	}
+
+	for _, pair := range strings.Split(cp.AcceleratorPrices, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, ":", 2)
+		accelType := strings.ToLower(strings.TrimSpace(kv[0]))
+		if len(kv) != 2 || accelType == "" {
+			return nil, fmt.Errorf("invalid acceleratorPrices entry \"%s\", expected type:price", pair)
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+		if err != nil || price < 0 {
+			return nil, fmt.Errorf("invalid acceleratorPrices entry \"%s\", expected type:price", pair)
+		}
+		a.prices[accelType] = price
+	}
+
+	return a, nil
+}
+
+// Resources returns the accelerator resources.
+func (a *Accelerators) Resources() []AcceleratorResource {
+	if a == nil {
+		return DefaultAcceleratorResources
+	}
+	return a.resources
+}
+
+// TypeOf returns the accelerator type of the given resource, which may be sanitized as by Prometheus, or an empty
+// string if the resource does not allocate accelerators.
+func (a *Accelerators) TypeOf(resource string) string {
+	for _, r := range a.Resources() {
+		if r.Name == resource || prom.SanitizeLabelName(r.Name) == resource {
+			return r.Type
+		}
+	}
+	return ""
+}
+
+// Count returns the type and number of the accelerators of the given resources, e.g. a node's capacity or a
+// container's requests, by the first accelerator resource of which there are any.
+func (a *Accelerators) Count(resources v1.ResourceList) (string, float64) {
+	for _, r := range a.Resources() {
+		if q, ok := resources[v1.ResourceName(r.Name)]; ok {
+			if count := q.AsApproximateFloat64(); count > 0 {
+				return r.Type, count
+			}
+		}
+	}
+	return "", 0
+}
+
+// Price returns the hourly price of one accelerator of the given type, if one is configured.
+func (a *Accelerators) Price(accelType string) (float64, bool) {
+	if a == nil {
+		return 0, false
+	}
+	price, ok := a.prices[accelType]
+	return price, ok
+}
+
+// ResourceRegex returns a Prometheus regex matching the accelerator resources, as sanitized in the resource label of
+// kube_pod_container_resource_requests.
+func (a *Accelerators) ResourceRegex() string {
+	names := []string{}
+	for _, r := range a.Resources() {
+		names = append(names, prom.SanitizeLabelName(r.Name))
+	}
+	return strings.Join(names, "|")
+}
@@ -0,0 +1,142 @@
+package cloud
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestAcceleratorsCount(t *testing.T) {
+	a, err := NewAccelerators(&CustomPricing{AcceleratorResources: "example.com/npu:npu"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cases := []struct {
+		name          string
+		resources     v1.ResourceList
+		expectedType  string
+		expectedCount float64
+	}{
+		{
+			name: "NVIDIA GPU node",
+			resources: v1.ResourceList{
+				v1.ResourceCPU:                    resource.MustParse("8"),
+				v1.ResourceName("nvidia.com/gpu"): resource.MustParse("4"),
+			},
+			expectedType:  AcceleratorNVIDIA,
+			expectedCount: 4,
+		},
+		{
+			name:          "AMD GPU node",
+			resources:     v1.ResourceList{v1.ResourceName("amd.com/gpu"): resource.MustParse("8")},
+			expectedType:  AcceleratorAMD,
+			expectedCount: 8,
+		},
+		{
+			name:          "Intel GPU node",
+			resources:     v1.ResourceList{v1.ResourceName("gpu.intel.com/i915"): resource.MustParse("1")},
+			expectedType:  AcceleratorIntel,
+			expectedCount: 1,
+		},
+		{
+			name:          "TPU node",
+			resources:     v1.ResourceList{v1.ResourceName("google.com/tpu"): resource.MustParse("4")},
+			expectedType:  AcceleratorTPU,
+			expectedCount: 4,
+		},
+		{
+			name:          "Inferentia node",
+			resources:     v1.ResourceList{v1.ResourceName("aws.amazon.com/neuron"): resource.MustParse("16")},
+			expectedType:  AcceleratorNeuron,
+			expectedCount: 16,
+		},
+		{
+			name:          "configured resource",
+			resources:     v1.ResourceList{v1.ResourceName("example.com/npu"): resource.MustParse("2")},
+			expectedType:  "npu",
+			expectedCount: 2,
+		},
+		{
+			name: "zero NVIDIA GPUs",
+			resources: v1.ResourceList{
+				v1.ResourceName("nvidia.com/gpu"): resource.MustParse("0"),
+				v1.ResourceName("google.com/tpu"): resource.MustParse("4"),
+			},
+			expectedType:  AcceleratorTPU,
+			expectedCount: 4,
+		},
+		{
+			name:      "CPU node",
+			resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")},
+		},
+	}
+
+	for _, c := range cases {
+		accelType, count := a.Count(c.resources)
+		if accelType != c.expectedType || count != c.expectedCount {
+			t.Errorf("%s: expected %d %q; got %f %q", c.name, int(c.expectedCount), c.expectedType, count, accelType)
+		}
+	}
+}
+
+func TestAcceleratorsTypeOf(t *testing.T) {
+	a, err := NewAccelerators(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]string{
+		"nvidia.com/gpu":        AcceleratorNVIDIA,
+		"nvidia_com_gpu":        AcceleratorNVIDIA,
+		"amd_com_gpu":           AcceleratorAMD,
+		"gpu_intel_com_i915":    AcceleratorIntel,
+		"google_com_tpu":        AcceleratorTPU,
+		"aws_amazon_com_neuron": AcceleratorNeuron,
+		"cpu":                   "",
+	}
+	for resource, accelType := range expected {
+		if actual := a.TypeOf(resource); actual != accelType {
+			t.Errorf("%s: expected %q; got %q", resource, accelType, actual)
+		}
+	}
+
+	regex := "nvidia_com_gpu|amd_com_gpu|gpu_intel_com_i915|google_com_tpu|aws_amazon_com_neuron"
+	if actual := a.ResourceRegex(); actual != regex {
+		t.Errorf("expected resource regex %q; got %q", regex, actual)
+	}
+}
+
+func TestNewAccelerators(t *testing.T) {
+	a, err := NewAccelerators(&CustomPricing{AcceleratorPrices: "AMD:1.80, tpu:1.20,neuron:0.76"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]float64{
+		AcceleratorAMD:    1.80,
+		AcceleratorTPU:    1.20,
+		AcceleratorNeuron: 0.76,
+	}
+	for accelType, price := range expected {
+		if actual, ok := a.Price(accelType); !ok || actual != price {
+			t.Errorf("%s: expected price %f; got %f", accelType, price, actual)
+		}
+	}
+	if _, ok := a.Price(AcceleratorNVIDIA); ok {
+		t.Errorf("%s: expected no price", AcceleratorNVIDIA)
+	}
+
+	for _, cp := range []*CustomPricing{
+		{AcceleratorPrices: "tpu"},
+		{AcceleratorPrices: "tpu:free"},
+		{AcceleratorPrices: "tpu:-1"},
+		{AcceleratorResources: "example.com/npu"},
+		{AcceleratorResources: ":npu"},
+	} {
+		if _, err := NewAccelerators(cp); err == nil {
+			t.Errorf("expected error for %+v", cp)
+		}
+	}
+}
@@ -0,0 +1,806 @@
+package cloud
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/clustercache"
+	"github.com/kubecost/opencost/pkg/env"
+	"github.com/kubecost/opencost/pkg/kubecost"
+	"github.com/kubecost/opencost/pkg/log"
+	"github.com/kubecost/opencost/pkg/util"
+	"github.com/kubecost/opencost/pkg/util/json"
+
+	"github.com/google/uuid"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	AlibabaDiskESSDStorageClass       = "cloud_essd"
+	AlibabaDiskSSDStorageClass        = "cloud_ssd"
+	AlibabaDiskEfficiencyStorageClass = "cloud_efficiency"
+
+	alibabaECSAPIVersion           = "2014-05-26"
+	alibabaDefaultSpotLabel        = "node.alibabacloud.com/spot"
+	alibabaDefaultSpotLabelValue   = "true"
+	alibabaACKClusterLabel         = "ack.aliyun.com"
+	alibabaNodePoolLabel           = "alibabacloud.com/nodepool-id"
+	alibabaDefaultPerformanceLevel = "PL1"
+	alibabaDiskPricingSizeGiB      = 100
+	alibabaPricingSource           = "DescribePrice API"
+	alibabaLoadBalancerHourlyCost  = 0.007
+)
+
+// ACK provider IDs are of the form <region>.<instance-id>, e.g. cn-hangzhou.i-bp1a2b3c4d5e6f7g8h9i
+var alibabaProviderIDRegex = regexp.MustCompile(`^([a-z]{2}-[a-z0-9-]+)\.(i-[a-z0-9]+)$`)
+
+// List obtained from https://www.alibabacloud.com/help/en/elastic-compute-service/latest/regions-and-zones
+var alibabaRegions = []string{
+	"cn-qingdao",
+	"cn-beijing",
+	"cn-zhangjiakou",
+	"cn-huhehaote",
+	"cn-wulanchabu",
+	"cn-hangzhou",
+	"cn-shanghai",
+	"cn-nanjing",
+	"cn-fuzhou",
+	"cn-shenzhen",
+	"cn-heyuan",
+	"cn-guangzhou",
+	"cn-chengdu",
+	"cn-hongkong",
+	"ap-northeast-1",
+	"ap-northeast-2",
+	"ap-southeast-1",
+	"ap-southeast-2",
+	"ap-southeast-3",
+	"ap-southeast-5",
+	"ap-southeast-6",
+	"ap-southeast-7",
+	"ap-south-1",
+	"us-east-1",
+	"us-west-1",
+	"eu-west-1",
+	"eu-central-1",
+	"me-east-1",
+	"me-central-1",
+}
+
+// AlibabaPricing holds the node or PV pricing for a single pricing key.
+type AlibabaPricing struct {
+	Node *Node
+	PV   *PV
+}
+
+// AlibabaAccessKey holds the credentials used to sign requests to the Alibaba Cloud OpenAPI.
+type AlibabaAccessKey struct {
+	AccessKeyID     string `json:"alibaba_access_key_id"`
+	AccessKeySecret string `json:"alibaba_secret_access_key"`
+}
+
+// IsValid returns true if both the access key id and secret are set.
+func (ak *AlibabaAccessKey) IsValid() bool {
+	return ak != nil && ak.AccessKeyID != "" && ak.AccessKeySecret != ""
+}
+
+// AlibabaDescribePriceResponse is the subset of the ECS DescribePrice response used for pricing.
+// https://www.alibabacloud.com/help/en/elastic-compute-service/latest/describeprice
+type AlibabaDescribePriceResponse struct {
+	RequestID string `json:"RequestId"`
+	Code      string `json:"Code"`
+	Message   string `json:"Message"`
+	PriceInfo struct {
+		Price struct {
+			OriginalPrice float64 `json:"OriginalPrice"`
+			DiscountPrice float64 `json:"DiscountPrice"`
+			TradePrice    float64 `json:"TradePrice"`
+			Currency      string  `json:"Currency"`
+			DetailInfos   struct {
+				DetailInfo []struct {
+					Resource      string  `json:"Resource"`
+					OriginalPrice float64 `json:"OriginalPrice"`
+					DiscountPrice float64 `json:"DiscountPrice"`
+					TradePrice    float64 `json:"TradePrice"`
+				} `json:"DetailInfo"`
+			} `json:"DetailInfos"`
+		} `json:"Price"`
+	} `json:"PriceInfo"`
+}
+
+// Alibaba is the Provider implementation for Alibaba Cloud Container Service for Kubernetes (ACK).
+type Alibaba struct {
+	Pricing                 map[string]*AlibabaPricing
+	DownloadPricingDataLock sync.RWMutex
+	Clientset               clustercache.ClusterCache
+	Config                  *ProviderConfig
+	PricingError            error
+	clusterRegion           string
+	clusterAccountId        string
+	clusterProvisioner      string
+	serviceAccountChecks    *ServiceAccountChecks
+	accessKey               *AlibabaAccessKey
+	// pricingEndpoint overrides the regional ECS endpoint. It is only set in tests.
+	pricingEndpoint string
+	httpClient      *http.Client
+}
+
+type alibabaKey struct {
+	Labels         map[string]string
+	ProviderID     string
+	SpotLabel      string
+	SpotLabelValue string
+}
+
+// Features returns a comma separated string of region, instance type and usage type.
+func (k *alibabaKey) Features() string {
+	region := k.Region()
+	instance, _ := util.GetInstanceType(k.Labels)
+	usageType := "ondemand"
+	if k.isSpot() {
+		usageType = "spot"
+	}
+	return fmt.Sprintf("%s,%s,%s", region, instance, usageType)
+}
+
+// Region returns the region of the node from its labels, falling back to the region encoded in the provider ID.
+func (k *alibabaKey) Region() string {
+	if r, ok := util.GetRegion(k.Labels); ok && r != "" {
+		return strings.ToLower(r)
+	}
+	region, _ := parseAlibabaProviderID(k.ProviderID)
+	return region
+}
+
+func (k *alibabaKey) isSpot() bool {
+	if k.SpotLabel == "" || k.SpotLabelValue == "" {
+		return false
+	}
+	return k.Labels[k.SpotLabel] == k.SpotLabelValue
+}
+
+// GPUType returns the GPU model from the ACK GPU label, if any.
+func (k *alibabaKey) GPUType() string {
+	if t, ok := k.Labels["aliyun.accelerator/nvidia_name"]; ok {
+		return t
+	}
+	return ""
+}
+
+// ID returns the ECS instance id parsed from the provider ID.
+func (k *alibabaKey) ID() string {
+	_, id := parseAlibabaProviderID(k.ProviderID)
+	return id
+}
+
+// GetGPUCount returns the number of GPUs advertised on the node by ACK.
+func (k *alibabaKey) GetGPUCount() string {
+	if c, ok := k.Labels["aliyun.accelerator/nvidia_count"]; ok {
+		return c
+	}
+	return ""
+}
+
+type alibabaPVKey struct {
+	Labels                 map[string]string
+	StorageClass           string
+	StorageClassParameters map[string]string
+	DefaultRegion          string
+	ProviderID             string
+}
+
+func (key *alibabaPVKey) ID() string {
+	return key.ProviderID
+}
+
+func (key *alibabaPVKey) GetStorageClass() string {
+	return key.StorageClass
+}
+
+// Features returns a comma separated string of region, disk category and, for ESSD, performance level.
+func (key *alibabaPVKey) Features() string {
+	region, ok := util.GetRegion(key.Labels)
+	if !ok || region == "" {
+		region = key.DefaultRegion
+	}
+	category, level := key.diskCategory()
+	if category == AlibabaDiskESSDStorageClass {
+		return fmt.Sprintf("%s,%s,%s", region, category, level)
+	}
+	return fmt.Sprintf("%s,%s", region, category)
+}
+
+// diskCategory resolves the disk category and ESSD performance level from the storage class parameters of
+// the Alibaba Cloud disk CSI driver. The "type" parameter may hold a comma separated list of fallbacks, in
+// which case the first one is used.
+func (key *alibabaPVKey) diskCategory() (string, string) {
+	category := strings.TrimSpace(strings.Split(key.StorageClassParameters["type"], ",")[0])
+	switch category {
+	case "available", "":
+		category = AlibabaDiskEfficiencyStorageClass
+	case AlibabaDiskESSDStorageClass, AlibabaDiskSSDStorageClass, AlibabaDiskEfficiencyStorageClass:
+	default:
+		log.DedupedWarningf(5, "Unknown Alibaba disk category %s, pricing as %s", category, AlibabaDiskEfficiencyStorageClass)
+		category = AlibabaDiskEfficiencyStorageClass
+	}
+	level := ""
+	if category == AlibabaDiskESSDStorageClass {
+		level = strings.ToUpper(key.StorageClassParameters["performanceLevel"])
+		if level == "" {
+			level = alibabaDefaultPerformanceLevel
+		}
+	}
+	return category, level
+}
+
+func (alibaba *Alibaba) GetKey(labels map[string]string, n *v1.Node) Key {
+	c, _ := alibaba.GetConfig()
+	key := &alibabaKey{
+		Labels: labels,
+	}
+	if c != nil {
+		key.SpotLabel = c.SpotLabel
+		key.SpotLabelValue = c.SpotLabelValue
+	}
+	if n != nil {
+		key.ProviderID = n.Spec.ProviderID
+	}
+	return key
+}
+
+func (alibaba *Alibaba) GetPVKey(pv *v1.PersistentVolume, parameters map[string]string, defaultRegion string) PVKey {
+	providerID := ""
+	if pv.Spec.CSI != nil {
+		providerID = pv.Spec.CSI.VolumeHandle
+	}
+	return &alibabaPVKey{
+		Labels:                 pv.Labels,
+		StorageClass:           pv.Spec.StorageClassName,
+		StorageClassParameters: parameters,
+		DefaultRegion:          defaultRegion,
+		ProviderID:             providerID,
+	}
+}
+
+func (alibaba *Alibaba) GetConfig() (*CustomPricing, error) {
+	c, err := alibaba.Config.GetCustomPricingData()
+	if err != nil {
+		return nil, err
+	}
+	if c.Discount == "" {
+		c.Discount = "0%"
+	}
+	if c.NegotiatedDiscount == "" {
+		c.NegotiatedDiscount = "0%"
+	}
+	if c.CurrencyCode == "" {
+		c.CurrencyCode = "USD"
+	}
+	if c.ShareTenancyCosts == "" {
+		c.ShareTenancyCosts = defaultShareTenancyCost
+	}
+	if c.SpotLabel == "" {
+		c.SpotLabel = alibabaDefaultSpotLabel
+	}
+	if c.SpotLabelValue == "" {
+		c.SpotLabelValue = alibabaDefaultSpotLabelValue
+	}
+	return c, nil
+}
+
+// getAlibabaAccessKey returns the access key from the custom pricing config, falling back to the
+// ALIBABA_ACCESS_KEY_ID and ALIBABA_SECRET_ACCESS_KEY environment variables.
+func (alibaba *Alibaba) getAlibabaAccessKey(cp *CustomPricing) *AlibabaAccessKey {
+	if cp.AlibabaServiceKeyName != "" && cp.AlibabaServiceKeySecret != "" {
+		return &AlibabaAccessKey{
+			AccessKeyID:     cp.AlibabaServiceKeyName,
+			AccessKeySecret: cp.AlibabaServiceKeySecret,
+		}
+	}
+	return &AlibabaAccessKey{
+		AccessKeyID:     env.GetAlibabaAccessKeyID(),
+		AccessKeySecret: env.GetAlibabaAccessKeySecret(),
+	}
+}
+
+// DownloadPricingData looks up the prices of every instance type and disk category in the cluster
+// through the ECS DescribePrice API.
+func (alibaba *Alibaba) DownloadPricingData() error {
+	alibaba.DownloadPricingDataLock.Lock()
+	defer alibaba.DownloadPricingDataLock.Unlock()
+
+	c, err := alibaba.GetConfig()
+	if err != nil {
+		alibaba.PricingError = err
+		return err
+	}
+
+	alibaba.accessKey = alibaba.getAlibabaAccessKey(c)
+	alibaba.serviceAccountChecks.set("hasKey", &ServiceAccountCheck{
+		Message:        "Alibaba Cloud access key is available",
+		Status:         alibaba.accessKey.IsValid(),
+		AdditionalInfo: "Set alibabaServiceKeyName and alibabaServiceKeySecret or the ALIBABA_ACCESS_KEY_ID and ALIBABA_SECRET_ACCESS_KEY environment variables",
+	})
+	if !alibaba.accessKey.IsValid() {
+		err := fmt.Errorf("no Alibaba Cloud access key configured")
+		alibaba.PricingError = err
+		return err
+	}
+
+	nodeKeys := make(map[string]*alibabaKey)
+	defaultRegion := alibaba.clusterRegion
+	for _, n := range alibaba.Clientset.GetAllNodes() {
+		labels := n.GetObjectMeta().GetLabels()
+		if _, ok := labels[alibabaACKClusterLabel]; ok {
+			alibaba.clusterProvisioner = "ACK"
+		}
+		key := alibaba.GetKey(labels, n).(*alibabaKey)
+		if r := key.Region(); r != "" {
+			defaultRegion = r
+		}
+		nodeKeys[key.Features()] = key
+	}
+
+	storageClassMap := make(map[string]map[string]string)
+	for _, storageClass := range alibaba.Clientset.GetAllStorageClasses() {
+		params := storageClass.Parameters
+		storageClassMap[storageClass.ObjectMeta.Name] = params
+		if storageClass.GetAnnotations()["storageclass.kubernetes.io/is-default-class"] == "true" || storageClass.GetAnnotations()["storageclass.beta.kubernetes.io/is-default-class"] == "true" {
+			storageClassMap["default"] = params
+			storageClassMap[""] = params
+		}
+	}
+
+	pvKeys := make(map[string]*alibabaPVKey)
+	for _, pv := range alibaba.Clientset.GetAllPersistentVolumes() {
+		params, ok := storageClassMap[pv.Spec.StorageClassName]
+		if !ok {
+			log.DedupedWarningf(5, "Unable to find params for storageClassName %s", pv.Name)
+			continue
+		}
+		key := alibaba.GetPVKey(pv, params, defaultRegion).(*alibabaPVKey)
+		pvKeys[key.Features()] = key
+	}
+
+	allPrices := make(map[string]*AlibabaPricing)
+	for features, key := range nodeKeys {
+		node, err := alibaba.instancePrice(key)
+		if err != nil {
+			log.Warnf("Alibaba: failed to price instance %s: %s", features, err)
+			continue
+		}
+		allPrices[features] = &AlibabaPricing{Node: node}
+	}
+	for features, key := range pvKeys {
+		pv, err := alibaba.diskPrice(key)
+		if err != nil {
+			log.Warnf("Alibaba: failed to price disk %s: %s", features, err)
+			continue
+		}
+		allPrices[features] = &AlibabaPricing{PV: pv}
+	}
+
+	alibaba.Pricing = allPrices
+	alibaba.PricingError = nil
+	return nil
+}
+
+// instancePrice fetches the hourly price of an ECS instance type, using the SpotAsPriceGo strategy for
+// preemptible instances.
+func (alibaba *Alibaba) instancePrice(key *alibabaKey) (*Node, error) {
+	region := key.Region()
+	instanceType, _ := util.GetInstanceType(key.Labels)
+	if region == "" || instanceType == "" {
+		return nil, fmt.Errorf("missing region or instance type")
+	}
+
+	params := map[string]string{
+		"RegionId":     region,
+		"ResourceType": "instance",
+		"InstanceType": instanceType,
+		"PriceUnit":    "Hour",
+		"Period":       "1",
+	}
+	usageType := "ondemand"
+	if key.isSpot() {
+		params["SpotStrategy"] = "SpotAsPriceGo"
+		usageType = "spot"
+	}
+
+	resp, err := alibaba.describePrice(region, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Node{
+		Cost:         fmt.Sprintf("%f", resp.resourcePrice("instanceType")),
+		InstanceType: instanceType,
+		Region:       region,
+		UsageType:    usageType,
+		GPU:          key.GetGPUCount(),
+		GPUName:      key.GPUType(),
+		PricingType:  Api,
+	}, nil
+}
+
+// diskPrice fetches the hourly price of a reference sized data disk and converts it to a GiB-hour rate.
+func (alibaba *Alibaba) diskPrice(key *alibabaPVKey) (*PV, error) {
+	region := strings.Split(key.Features(), ",")[0]
+	if region == "" {
+		return nil, fmt.Errorf("missing region")
+	}
+	category, level := key.diskCategory()
+	params := map[string]string{
+		"RegionId":            region,
+		"ResourceType":        "disk",
+		"PriceUnit":           "Hour",
+		"Period":              "1",
+		"DataDisk.1.Category": category,
+		"DataDisk.1.Size":     strconv.Itoa(alibabaDiskPricingSizeGiB),
+	}
+	if level != "" {
+		params["DataDisk.1.PerformanceLevel"] = level
+	}
+
+	resp, err := alibaba.describePrice(region, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PV{
+		Cost:   fmt.Sprintf("%f", resp.resourcePrice("dataDisk")/alibabaDiskPricingSizeGiB),
+		Class:  key.StorageClass,
+		Region: region,
+	}, nil
+}
+
+// resourcePrice returns the trade price of the named resource from the price breakdown, falling back to the
+// total trade price when no breakdown is present.
+func (r *AlibabaDescribePriceResponse) resourcePrice(resource string) float64 {
+	for _, detail := range r.PriceInfo.Price.DetailInfos.DetailInfo {
+		if detail.Resource == resource {
+			return detail.TradePrice
+		}
+	}
+	return r.PriceInfo.Price.TradePrice
+}
+
+func (alibaba *Alibaba) describePrice(region string, params map[string]string) (*AlibabaDescribePriceResponse, error) {
+	endpoint := alibaba.pricingEndpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://ecs.%s.aliyuncs.com", region)
+	}
+
+	query := map[string]string{
+		"Action":           "DescribePrice",
+		"Version":          alibabaECSAPIVersion,
+		"Format":           "JSON",
+		"AccessKeyId":      alibaba.accessKey.AccessKeyID,
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureVersion": "1.0",
+		"SignatureNonce":   uuid.New().String(),
+		"Timestamp":        time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	}
+	for k, v := range params {
+		query[k] = v
+	}
+	query["Signature"] = signAlibabaRequest(http.MethodGet, query, alibaba.accessKey.AccessKeySecret)
+
+	values := url.Values{}
+	for k, v := range query {
+		values.Set(k, v)
+	}
+
+	client := alibaba.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(endpoint + "/?" + values.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("bogus fetch of DescribePrice: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading DescribePrice response: %s", err)
+	}
+
+	return parseAlibabaDescribePrice(resp.StatusCode, body)
+}
+
+func parseAlibabaDescribePrice(statusCode int, body []byte) (*AlibabaDescribePriceResponse, error) {
+	out := &AlibabaDescribePriceResponse{}
+	if err := json.Unmarshal(body, out); err != nil {
+		return nil, fmt.Errorf("error unmarshalling DescribePrice response: %s", err)
+	}
+	if statusCode < 200 || statusCode > 299 {
+		return nil, fmt.Errorf("DescribePrice responded with status code %d: %s: %s", statusCode, out.Code, out.Message)
+	}
+	return out, nil
+}
+
+// signAlibabaRequest computes the RPC signature (version 1.0) for a set of query parameters.
+// https://www.alibabacloud.com/help/en/elastic-compute-service/latest/request-signatures
+func signAlibabaRequest(method string, query map[string]string, secret string) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, alibabaPercentEncode(k)+"="+alibabaPercentEncode(query[k]))
+	}
+	stringToSign := method + "&" + alibabaPercentEncode("/") + "&" + alibabaPercentEncode(strings.Join(pairs, "&"))
+
+	mac := hmac.New(sha1.New, []byte(secret+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func alibabaPercentEncode(s string) string {
+	s = url.QueryEscape(s)
+	s = strings.ReplaceAll(s, "+", "%20")
+	s = strings.ReplaceAll(s, "*", "%2A")
+	s = strings.ReplaceAll(s, "%7E", "~")
+	return s
+}
+
+// AllNodePricing returns the Alibaba pricing objects stored
+func (alibaba *Alibaba) AllNodePricing() (interface{}, error) {
+	alibaba.DownloadPricingDataLock.RLock()
+	defer alibaba.DownloadPricingDataLock.RUnlock()
+	return alibaba.Pricing, nil
+}
+
+// NodePricing returns Alibaba pricing data for a single node, falling back to the configured default prices.
+func (alibaba *Alibaba) NodePricing(key Key) (*Node, error) {
+	alibaba.DownloadPricingDataLock.RLock()
+	defer alibaba.DownloadPricingDataLock.RUnlock()
+
+	if p, ok := alibaba.Pricing[key.Features()]; ok && p.Node != nil {
+		log.Debugf("Returning pricing for node %s from key %s", key.ID(), key.Features())
+		n := *p.Node
+		n.ProviderID = key.ID()
+		return &n, nil
+	}
+
+	log.DedupedWarningf(5, "no pricing data found for %s", key.Features())
+	c, err := alibaba.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("No default pricing data available")
+	}
+	node := &Node{
+		VCPUCost:         c.CPU,
+		RAMCost:          c.RAM,
+		UsesBaseCPUPrice: true,
+		PricingType:      DefaultPrices,
+	}
+	if ak, ok := key.(*alibabaKey); ok {
+		if ak.isSpot() {
+			node.VCPUCost = c.SpotCPU
+			node.RAMCost = c.SpotRAM
+			node.UsageType = "spot"
+		}
+		if gpu := ak.GetGPUCount(); gpu != "" {
+			node.GPU = gpu
+			node.GPUCost = c.GPU
+		}
+	}
+	return node, nil
+}
+
+func (alibaba *Alibaba) PVPricing(pvk PVKey) (*PV, error) {
+	alibaba.DownloadPricingDataLock.RLock()
+	defer alibaba.DownloadPricingDataLock.RUnlock()
+
+	pricing, ok := alibaba.Pricing[pvk.Features()]
+	if !ok || pricing.PV == nil {
+		log.Debugf("Persistent Volume pricing not found for %s: %s", pvk.GetStorageClass(), pvk.Features())
+		return &PV{}, nil
+	}
+	return pricing.PV, nil
+}
+
+// NetworkPricing returns the egress prices from alibaba.json
+func (alibaba *Alibaba) NetworkPricing() (*Network, error) {
+	cpricing, err := alibaba.Config.GetCustomPricingData()
+	if err != nil {
+		return nil, err
+	}
+	znec, err := strconv.ParseFloat(cpricing.ZoneNetworkEgress, 64)
+	if err != nil {
+		return nil, err
+	}
+	rnec, err := strconv.ParseFloat(cpricing.RegionNetworkEgress, 64)
+	if err != nil {
+		return nil, err
+	}
+	inec, err := strconv.ParseFloat(cpricing.InternetNetworkEgress, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Network{
+		ZoneNetworkEgressCost:        znec,
+		RegionNetworkEgressCost:      rnec,
+		InternetNetworkEgressCost:    inec,
+		NATGatewayDataProcessingCost: natGatewayDataProcessingCost(cpricing),
+	}, nil
+}
+
+// LoadBalancerPricing returns the hourly instance fee of a pay-as-you-go Server Load Balancer. The
+// configured firstFiveForwardingRulesCost takes precedence over the list price.
+func (alibaba *Alibaba) LoadBalancerPricing() (*LoadBalancer, error) {
+	cost := alibabaLoadBalancerHourlyCost
+	cpricing, err := alibaba.Config.GetCustomPricingData()
+	if err == nil && cpricing.FirstFiveForwardingRulesCost != "" {
+		if c, err := strconv.ParseFloat(cpricing.FirstFiveForwardingRulesCost, 64); err == nil {
+			cost = c
+		}
+	}
+	return &LoadBalancer{
+		Cost: cost,
+	}, nil
+}
+
+func (*Alibaba) GetAddresses() ([]byte, error) {
+	return nil, nil
+}
+
+func (*Alibaba) GetDisks() ([]byte, error) {
+	return nil, nil
+}
+
+func (alibaba *Alibaba) ClusterInfo() (map[string]string, error) {
+	c, err := alibaba.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string)
+	m["name"] = "Alibaba Cluster #1"
+	if c.ClusterName != "" {
+		m["name"] = c.ClusterName
+	}
+	m["provider"] = kubecost.AlibabaProvider
+	m["account"] = alibaba.clusterAccountId
+	m["region"] = alibaba.clusterRegion
+	m["provisioner"] = alibaba.clusterProvisioner
+	m["remoteReadEnabled"] = strconv.FormatBool(env.IsRemoteEnabled())
+	m["id"] = env.GetClusterID()
+	return m, nil
+}
+
+func (alibaba *Alibaba) UpdateConfigFromConfigMap(a map[string]string) (*CustomPricing, error) {
+	return alibaba.Config.UpdateFromMap(a)
+}
+
+func (alibaba *Alibaba) UpdateConfig(r io.Reader, updateType string) (*CustomPricing, error) {
+	defer alibaba.DownloadPricingData()
+
+	return alibaba.Config.Update(func(c *CustomPricing) error {
+		a := make(map[string]interface{})
+		err := json.NewDecoder(r).Decode(&a)
+		if err != nil {
+			return err
+		}
+		for k, v := range a {
+			kUpper := strings.Title(k) // Just so we consistently supply / receive the same values, uppercase the first letter.
+			vstr, ok := v.(string)
+			if ok {
+				err := SetCustomPricingField(c, kUpper, vstr)
+				if err != nil {
+					return err
+				}
+			} else {
+				return fmt.Errorf("type error while updating config for %s", kUpper)
+			}
+		}
+
+		if env.IsRemoteEnabled() {
+			err := UpdateClusterMeta(env.GetClusterID(), c.ClusterName)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (alibaba *Alibaba) GetManagementPlatform() (string, error) {
+	nodes := alibaba.Clientset.GetAllNodes()
+	if len(nodes) > 0 {
+		n := nodes[0]
+		if _, ok := n.Labels[alibabaACKClusterLabel]; ok {
+			return "ack", nil
+		}
+	}
+	return "", nil
+}
+
+func (*Alibaba) GetLocalStorageQuery(window, offset time.Duration, rate bool, used bool) string {
+	return ""
+}
+
+func (*Alibaba) ApplyReservedInstancePricing(nodes map[string]*Node) {
+
+}
+
+func (alibaba *Alibaba) ServiceAccountStatus() *ServiceAccountStatus {
+	return alibaba.serviceAccountChecks.getStatus()
+}
+
+// PricingSourceStatus returns the status of the DescribePrice API
+func (alibaba *Alibaba) PricingSourceStatus() map[string]*PricingSource {
+	sources := make(map[string]*PricingSource)
+	errMsg := ""
+	if alibaba.PricingError != nil {
+		errMsg = alibaba.PricingError.Error()
+	}
+	ps := &PricingSource{
+		Name:    alibabaPricingSource,
+		Enabled: true,
+		Error:   errMsg,
+	}
+	if ps.Error != "" {
+		ps.Available = false
+	} else if len(alibaba.Pricing) == 0 {
+		ps.Error = "No Pricing Data Available"
+		ps.Available = false
+	} else {
+		ps.Available = true
+	}
+	sources[alibabaPricingSource] = ps
+	return sources
+}
+
+// ClusterManagementPricing returns the provisioner with no management fee. The ACK fee depends on the
+// cluster edition, which is not visible from within the cluster.
+func (alibaba *Alibaba) ClusterManagementPricing() (string, float64, error) {
+	return alibaba.clusterProvisioner, 0.0, nil
+}
+
+func (alibaba *Alibaba) CombinedDiscountForNode(instanceType string, isPreemptible bool, defaultDiscount, negotiatedDiscount float64) float64 {
+	return 1.0 - ((1.0 - defaultDiscount) * (1.0 - negotiatedDiscount))
+}
+
+func (alibaba *Alibaba) Regions() []string {
+	return alibabaRegions
+}
+
+// parseAlibabaProviderID splits an ACK provider ID into its region and ECS instance id, returning empty
+// strings if the provider ID is not in the ACK format.
+func parseAlibabaProviderID(id string) (string, string) {
+	match := alibabaProviderIDRegex.FindStringSubmatch(strings.ToLower(id))
+	if len(match) != 3 {
+		return "", ""
+	}
+	return match[1], match[2]
+}
+
+// isAlibabaNode returns true if the node was provisioned by ACK or carries an ACK style provider ID.
+func isAlibabaNode(node *v1.Node) bool {
+	if _, ok := node.Labels[alibabaACKClusterLabel]; ok {
+		return true
+	}
+	if _, ok := node.Labels[alibabaNodePoolLabel]; ok {
+		return true
+	}
+	region, _ := parseAlibabaProviderID(node.Spec.ProviderID)
+	return region != ""
+}
@@ -0,0 +1,344 @@
+package cloud
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kubecost/opencost/pkg/clustercache"
+	"github.com/kubecost/opencost/pkg/config"
+
+	v1 "k8s.io/api/core/v1"
+	stv1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Recorded ECS DescribePrice responses, trimmed to the fields used for pricing.
+const (
+	alibabaDescribePriceInstanceResponse = `{
+		"RequestId": "8E5C8C25-C2A1-4E5E-9B1B-5E6A3D7C2F10",
+		"PriceInfo": {
+			"Price": {
+				"OriginalPrice": 0.1255,
+				"DiscountPrice": 0,
+				"TradePrice": 0.1255,
+				"Currency": "USD",
+				"DetailInfos": {
+					"DetailInfo": [
+						{"Resource": "instanceType", "OriginalPrice": 0.1195, "DiscountPrice": 0, "TradePrice": 0.1195},
+						{"Resource": "systemDisk", "OriginalPrice": 0.006, "DiscountPrice": 0, "TradePrice": 0.006}
+					]
+				}
+			},
+			"Rules": {"Rule": []}
+		}
+	}`
+	alibabaDescribePriceSpotResponse = `{
+		"RequestId": "0D4D43C6-2E2E-49B2-A9C5-3A2B9A1F7E55",
+		"PriceInfo": {
+			"Price": {
+				"OriginalPrice": 0.0301,
+				"DiscountPrice": 0,
+				"TradePrice": 0.0301,
+				"Currency": "USD"
+			},
+			"Rules": {"Rule": []}
+		}
+	}`
+	alibabaDescribePriceDiskResponse = `{
+		"RequestId": "5C1B7A0E-8D47-4A58-93A5-7B5C4D3E2F11",
+		"PriceInfo": {
+			"Price": {
+				"OriginalPrice": 0.0288,
+				"DiscountPrice": 0,
+				"TradePrice": 0.0288,
+				"Currency": "USD",
+				"DetailInfos": {
+					"DetailInfo": [
+						{"Resource": "dataDisk", "OriginalPrice": 0.0288, "DiscountPrice": 0, "TradePrice": 0.0288}
+					]
+				}
+			},
+			"Rules": {"Rule": []}
+		}
+	}`
+	alibabaDescribePriceErrorResponse = `{
+		"RequestId": "A1B2C3D4-0000-0000-0000-000000000000",
+		"Code": "InvalidAccessKeyId.NotFound",
+		"Message": "Specified access key is not found."
+	}`
+)
+
+type alibabaFakeCache struct {
+	nodes          []*v1.Node
+	pvs            []*v1.PersistentVolume
+	storageClasses []*stv1.StorageClass
+	clustercache.ClusterCache
+}
+
+func (f alibabaFakeCache) GetAllNodes() []*v1.Node {
+	return f.nodes
+}
+
+func (f alibabaFakeCache) GetAllPersistentVolumes() []*v1.PersistentVolume {
+	return f.pvs
+}
+
+func (f alibabaFakeCache) GetAllStorageClasses() []*stv1.StorageClass {
+	return f.storageClasses
+}
+
+func TestParseAlibabaProviderID(t *testing.T) {
+	cases := []struct {
+		input          string
+		expectedRegion string
+		expectedID     string
+	}{
+		{
+			input:          "cn-hangzhou.i-bp1a2b3c4d5e6f7g8h9i",
+			expectedRegion: "cn-hangzhou",
+			expectedID:     "i-bp1a2b3c4d5e6f7g8h9i",
+		},
+		{
+			input:          "ap-southeast-1.i-t4n0abcdef123456",
+			expectedRegion: "ap-southeast-1",
+			expectedID:     "i-t4n0abcdef123456",
+		},
+		{
+			input:          "aws:///us-east-2a/i-0fea4fd46592d050b",
+			expectedRegion: "",
+			expectedID:     "",
+		},
+		{
+			input:          "",
+			expectedRegion: "",
+			expectedID:     "",
+		},
+	}
+
+	for _, test := range cases {
+		region, id := parseAlibabaProviderID(test.input)
+		if region != test.expectedRegion || id != test.expectedID {
+			t.Errorf("Input: %s, Expected: (%s, %s), Actual: (%s, %s)", test.input, test.expectedRegion, test.expectedID, region, id)
+		}
+	}
+}
+
+func TestAlibabaKeyFeatures(t *testing.T) {
+	cases := []struct {
+		name     string
+		labels   map[string]string
+		id       string
+		expected string
+	}{
+		{
+			name: "ondemand node with region label",
+			labels: map[string]string{
+				v1.LabelTopologyRegion:             "cn-hangzhou",
+				"node.kubernetes.io/instance-type": "ecs.g6.large",
+			},
+			id:       "cn-hangzhou.i-bp1a2b3c4d5e6f7g8h9i",
+			expected: "cn-hangzhou,ecs.g6.large,ondemand",
+		},
+		{
+			name: "spot node with region from provider id",
+			labels: map[string]string{
+				"node.kubernetes.io/instance-type": "ecs.c6.xlarge",
+				alibabaDefaultSpotLabel:            alibabaDefaultSpotLabelValue,
+			},
+			id:       "cn-shanghai.i-uf6abcdef123456",
+			expected: "cn-shanghai,ecs.c6.xlarge,spot",
+		},
+	}
+
+	for _, test := range cases {
+		key := &alibabaKey{
+			Labels:         test.labels,
+			ProviderID:     test.id,
+			SpotLabel:      alibabaDefaultSpotLabel,
+			SpotLabelValue: alibabaDefaultSpotLabelValue,
+		}
+		if key.Features() != test.expected {
+			t.Errorf("%s: Expected: %s, Actual: %s", test.name, test.expected, key.Features())
+		}
+	}
+}
+
+func TestAlibabaPVKeyFeatures(t *testing.T) {
+	cases := []struct {
+		params   map[string]string
+		expected string
+	}{
+		{
+			params:   map[string]string{"type": "cloud_essd", "performanceLevel": "pl2"},
+			expected: "cn-hangzhou,cloud_essd,PL2",
+		},
+		{
+			params:   map[string]string{"type": "cloud_essd"},
+			expected: "cn-hangzhou,cloud_essd,PL1",
+		},
+		{
+			params:   map[string]string{"type": "cloud_ssd,cloud_efficiency"},
+			expected: "cn-hangzhou,cloud_ssd",
+		},
+		{
+			params:   map[string]string{"type": "available"},
+			expected: "cn-hangzhou,cloud_efficiency",
+		},
+	}
+
+	for _, test := range cases {
+		key := &alibabaPVKey{
+			StorageClassParameters: test.params,
+			DefaultRegion:          "cn-hangzhou",
+		}
+		if key.Features() != test.expected {
+			t.Errorf("Input: %v, Expected: %s, Actual: %s", test.params, test.expected, key.Features())
+		}
+	}
+}
+
+func TestSignAlibabaRequest(t *testing.T) {
+	// Example from the Alibaba Cloud RPC signature documentation
+	query := map[string]string{
+		"AccessKeyId":      "testid",
+		"Action":           "DescribeRegions",
+		"Format":           "XML",
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureNonce":   "3ee8c1b8-83d3-44af-a94f-4e0ad82fd6cf",
+		"SignatureVersion": "1.0",
+		"Timestamp":        "2016-02-23T12:46:24Z",
+		"Version":          "2014-05-26",
+	}
+	expected := "OLeaidS1JvxuMvnyHOwuJ+uX5qY="
+	if actual := signAlibabaRequest(http.MethodGet, query, "testsecret"); actual != expected {
+		t.Errorf("Expected: %s, Actual: %s", expected, actual)
+	}
+}
+
+func TestParseAlibabaDescribePriceError(t *testing.T) {
+	_, err := parseAlibabaDescribePrice(http.StatusNotFound, []byte(alibabaDescribePriceErrorResponse))
+	if err == nil {
+		t.Errorf("Expected an error for a failed DescribePrice response")
+	}
+}
+
+func TestAlibabaDownloadPricingData(t *testing.T) {
+	t.Setenv("CONFIG_PATH", "../../configs/")
+	t.Setenv("ALIBABA_ACCESS_KEY_ID", "testid")
+	t.Setenv("ALIBABA_SECRET_ACCESS_KEY", "testsecret")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("Action") != "DescribePrice" || q.Get("Signature") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(alibabaDescribePriceErrorResponse))
+			return
+		}
+		switch {
+		case q.Get("ResourceType") == "disk" && q.Get("DataDisk.1.Category") == AlibabaDiskESSDStorageClass:
+			w.Write([]byte(alibabaDescribePriceDiskResponse))
+		case q.Get("ResourceType") == "instance" && q.Get("SpotStrategy") == "SpotAsPriceGo":
+			w.Write([]byte(alibabaDescribePriceSpotResponse))
+		case q.Get("ResourceType") == "instance":
+			w.Write([]byte(alibabaDescribePriceInstanceResponse))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(alibabaDescribePriceErrorResponse))
+		}
+	}))
+	defer server.Close()
+
+	ondemand := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cn-hangzhou.192.168.0.10",
+			Labels: map[string]string{
+				v1.LabelTopologyRegion:             "cn-hangzhou",
+				"node.kubernetes.io/instance-type": "ecs.g6.large",
+				alibabaACKClusterLabel:             "c1234567890",
+			},
+		},
+		Spec: v1.NodeSpec{ProviderID: "cn-hangzhou.i-bp1a2b3c4d5e6f7g8h9i"},
+	}
+	spot := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cn-hangzhou.192.168.0.11",
+			Labels: map[string]string{
+				v1.LabelTopologyRegion:             "cn-hangzhou",
+				"node.kubernetes.io/instance-type": "ecs.g6.large",
+				alibabaDefaultSpotLabel:            alibabaDefaultSpotLabelValue,
+			},
+		},
+		Spec: v1.NodeSpec{ProviderID: "cn-hangzhou.i-bp1z2y3x4w5v6u7t8s9r"},
+	}
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "d-bp1abcdef"},
+		Spec: v1.PersistentVolumeSpec{
+			StorageClassName: "alicloud-disk-essd",
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{VolumeHandle: "d-bp1abcdef"},
+			},
+		},
+	}
+	sc := &stv1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "alicloud-disk-essd"},
+		Parameters: map[string]string{"type": "cloud_essd", "performanceLevel": "PL1"},
+	}
+
+	if !isAlibabaNode(ondemand) {
+		t.Fatalf("Expected ACK node to be detected as Alibaba")
+	}
+
+	confMan := config.NewConfigFileManager(&config.ConfigFileManagerOpts{
+		LocalConfigPath: "./",
+	})
+	alibaba := &Alibaba{
+		Clientset: alibabaFakeCache{
+			nodes:          []*v1.Node{ondemand, spot},
+			pvs:            []*v1.PersistentVolume{pv},
+			storageClasses: []*stv1.StorageClass{sc},
+		},
+		Config:               NewProviderConfig(confMan, "alibaba.json"),
+		serviceAccountChecks: NewServiceAccountChecks(),
+		pricingEndpoint:      server.URL,
+	}
+
+	err := alibaba.DownloadPricingData()
+	if err != nil {
+		t.Fatalf("Unexpected error downloading pricing data: %s", err)
+	}
+
+	node, err := alibaba.NodePricing(alibaba.GetKey(ondemand.Labels, ondemand))
+	if err != nil {
+		t.Fatalf("Unexpected error pricing node: %s", err)
+	}
+	if node.Cost != "0.119500" || node.UsageType != "ondemand" || node.ProviderID != "i-bp1a2b3c4d5e6f7g8h9i" {
+		t.Errorf("Unexpected on-demand node pricing: %+v", node)
+	}
+
+	node, err = alibaba.NodePricing(alibaba.GetKey(spot.Labels, spot))
+	if err != nil {
+		t.Fatalf("Unexpected error pricing node: %s", err)
+	}
+	if node.Cost != "0.030100" || !node.IsSpot() {
+		t.Errorf("Unexpected spot node pricing: %+v", node)
+	}
+
+	pvPrice, err := alibaba.PVPricing(alibaba.GetPVKey(pv, sc.Parameters, "cn-hangzhou"))
+	if err != nil {
+		t.Fatalf("Unexpected error pricing pv: %s", err)
+	}
+	if pvPrice.Cost != "0.000288" {
+		t.Errorf("Unexpected pv pricing: %+v", pvPrice)
+	}
+
+	provisioner, _, _ := alibaba.ClusterManagementPricing()
+	if provisioner != "ACK" {
+		t.Errorf("Expected provisioner ACK, got %s", provisioner)
+	}
+
+	lb, _ := alibaba.LoadBalancerPricing()
+	if lb.Cost != 0.007 {
+		t.Errorf("Expected load balancer cost 0.007, got %f", lb.Cost)
+	}
+}
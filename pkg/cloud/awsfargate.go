@@ -0,0 +1,231 @@
+package cloud
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/util/json"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// FargateComputeTypeLabel is set by EKS on the virtual nodes that run Fargate pods
+	FargateComputeTypeLabel = "eks.amazonaws.com/compute-type"
+	FargateComputeType      = "fargate"
+	// FargateCapacityAnnotation is set by EKS on Fargate pods, e.g. "0.25vCPU 0.5GB"
+	FargateCapacityAnnotation = "CapacityProvisioned"
+	// FargatePodOverheadBytes is added by EKS to the memory request of every Fargate pod for the kubelet,
+	// kube-proxy and containerd.
+	FargatePodOverheadBytes = 256 * 1024 * 1024
+	// FargateMinimumBillingDuration is the minimum duration a Fargate pod is billed for.
+	FargateMinimumBillingDuration = time.Minute
+
+	fargateNodePrefix      = "fargate-ip-"
+	fargatePricingURLFmt   = "https://pricing.us-east-1.amazonaws.com/offers/v1.0/aws/AmazonECS/current/%s/index.json"
+	fargateVCPUUsageType   = "Fargate-vCPU-Hours:perCPU"
+	fargateMemoryUsageType = "Fargate-GB-Hours"
+	gib                    = 1024 * 1024 * 1024
+)
+
+var fargateInstanceTypeRegex = regexp.MustCompile(`^fargate-([0-9.]+)vCPU-([0-9.]+)GB$`)
+
+// FargateConfiguration is a supported Fargate pod size. Pods are billed for the smallest configuration which fits
+// their requests.
+type FargateConfiguration struct {
+	VCPU      float64
+	MemoryGiB float64
+}
+
+// InstanceType returns the instance type reported for Fargate nodes, e.g. fargate-0.25vCPU-0.5GB, which
+// encodes the configuration the node's pod is billed for.
+func (fc FargateConfiguration) InstanceType() string {
+	return fmt.Sprintf("fargate-%svCPU-%sGB", strconv.FormatFloat(fc.VCPU, 'f', -1, 64), strconv.FormatFloat(fc.MemoryGiB, 'f', -1, 64))
+}
+
+// RAMBytes returns the memory of the configuration in bytes
+func (fc FargateConfiguration) RAMBytes() float64 {
+	return fc.MemoryGiB * gib
+}
+
+// ParseFargateInstanceType returns the configuration encoded in a Fargate instance type, as produced by
+// FargateConfiguration.InstanceType.
+func ParseFargateInstanceType(instanceType string) (FargateConfiguration, bool) {
+	match := fargateInstanceTypeRegex.FindStringSubmatch(instanceType)
+	if len(match) != 3 {
+		return FargateConfiguration{}, false
+	}
+	vcpu, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return FargateConfiguration{}, false
+	}
+	mem, err := strconv.ParseFloat(match[2], 64)
+	if err != nil {
+		return FargateConfiguration{}, false
+	}
+	return FargateConfiguration{VCPU: vcpu, MemoryGiB: mem}, true
+}
+
+// fargateConfigurations lists the supported configurations, ordered by vCPU then memory.
+// https://docs.aws.amazon.com/eks/latest/userguide/fargate-pod-configuration.html
+var fargateConfigurations = buildFargateConfigurations()
+
+func buildFargateConfigurations() []FargateConfiguration {
+	configs := []FargateConfiguration{
+		{VCPU: 0.25, MemoryGiB: 0.5},
+		{VCPU: 0.25, MemoryGiB: 1},
+		{VCPU: 0.25, MemoryGiB: 2},
+	}
+	ranges := []struct {
+		vcpu, min, max, step float64
+	}{
+		{0.5, 1, 4, 1},
+		{1, 2, 8, 1},
+		{2, 4, 16, 1},
+		{4, 8, 30, 1},
+		{8, 16, 60, 4},
+		{16, 32, 120, 8},
+	}
+	for _, r := range ranges {
+		for mem := r.min; mem <= r.max; mem += r.step {
+			configs = append(configs, FargateConfiguration{VCPU: r.vcpu, MemoryGiB: mem})
+		}
+	}
+	return configs
+}
+
+// RoundFargateConfiguration returns the smallest supported configuration with at least the given vCPU and
+// memory. The memory should already include FargatePodOverheadBytes.
+func RoundFargateConfiguration(cpuCores, ramBytes float64) (FargateConfiguration, error) {
+	ramGiB := ramBytes / gib
+	for _, c := range fargateConfigurations {
+		if c.VCPU >= cpuCores && c.MemoryGiB >= ramGiB {
+			return c, nil
+		}
+	}
+	return FargateConfiguration{}, fmt.Errorf("no Fargate configuration fits %.3f vCPU and %.3f GiB", cpuCores, ramGiB)
+}
+
+// FargatePodConfiguration returns the configuration a Fargate pod is billed for. As EKS does, the pod's resources
+// are the larger of the sum of its containers' requests and its largest init container request, plus
+// FargatePodOverheadBytes of memory.
+func FargatePodConfiguration(pod *v1.Pod) (FargateConfiguration, error) {
+	var cpu, ram float64
+	for _, c := range pod.Spec.Containers {
+		cpu += c.Resources.Requests.Cpu().AsApproximateFloat64()
+		ram += c.Resources.Requests.Memory().AsApproximateFloat64()
+	}
+	for _, c := range pod.Spec.InitContainers {
+		cpu = math.Max(cpu, c.Resources.Requests.Cpu().AsApproximateFloat64())
+		ram = math.Max(ram, c.Resources.Requests.Memory().AsApproximateFloat64())
+	}
+	return RoundFargateConfiguration(cpu, ram+FargatePodOverheadBytes)
+}
+
+// FargateBillableHours returns the hours billed for a Fargate pod which ran for the given duration: per second,
+// rounded up, with a one minute minimum.
+func FargateBillableHours(d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	if d < FargateMinimumBillingDuration {
+		d = FargateMinimumBillingDuration
+	}
+	return math.Ceil(d.Seconds()) / 3600
+}
+
+// IsFargateNode returns true if the node labels or name identify an EKS Fargate virtual node.
+func IsFargateNode(labels map[string]string, name string) bool {
+	if labels[FargateComputeTypeLabel] == FargateComputeType {
+		return true
+	}
+	return strings.HasPrefix(name, fargateNodePrefix)
+}
+
+// FargatePrices are the hourly vCPU and GB prices of Fargate in a region. EKS runs Fargate pods on demand only:
+// Fargate Spot is a capacity provider of ECS, so its prices are not used.
+type FargatePrices struct {
+	VCPUHourly float64 `json:"vCPUHourly"`
+	GBHourly   float64 `json:"GBHourly"`
+}
+
+// fargateDefaultPrices are the published Linux/x86 Fargate prices, used for regions whose pricing could not be
+// downloaded.
+var fargateDefaultPrices = map[string]*FargatePrices{
+	"us-east-1":      {VCPUHourly: 0.04048, GBHourly: 0.004445},
+	"us-east-2":      {VCPUHourly: 0.04048, GBHourly: 0.004445},
+	"us-west-1":      {VCPUHourly: 0.04656, GBHourly: 0.00511},
+	"us-west-2":      {VCPUHourly: 0.04048, GBHourly: 0.004445},
+	"ca-central-1":   {VCPUHourly: 0.04456, GBHourly: 0.004865},
+	"eu-west-1":      {VCPUHourly: 0.04048, GBHourly: 0.004445},
+	"eu-west-2":      {VCPUHourly: 0.04656, GBHourly: 0.00511},
+	"eu-central-1":   {VCPUHourly: 0.04656, GBHourly: 0.00511},
+	"ap-south-1":     {VCPUHourly: 0.04256, GBHourly: 0.004655},
+	"ap-northeast-1": {VCPUHourly: 0.05056, GBHourly: 0.00553},
+	"ap-southeast-1": {VCPUHourly: 0.05056, GBHourly: 0.00553},
+	"ap-southeast-2": {VCPUHourly: 0.04856, GBHourly: 0.00532},
+	"sa-east-1":      {VCPUHourly: 0.0696, GBHourly: 0.0076},
+}
+
+// downloadFargatePricing fetches the Fargate prices of a region from the AmazonECS offer file, which also prices
+// EKS Fargate pods.
+func downloadFargatePricing(client *http.Client, pricingURL string) (*FargatePrices, error) {
+	resp, err := client.Get(pricingURL)
+	if err != nil {
+		return nil, fmt.Errorf("bogus fetch of \"%s\": %s", pricingURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("fetch of \"%s\" responded with status code %d", pricingURL, resp.StatusCode)
+	}
+
+	pricing := &AWSPricing{}
+	if err := json.NewDecoder(resp.Body).Decode(pricing); err != nil {
+		return nil, fmt.Errorf("error parsing response from \"%s\": %s", pricingURL, err)
+	}
+
+	prices := &FargatePrices{}
+	for sku, product := range pricing.Products {
+		usageType := product.Attributes.UsageType
+		// Only price on demand Linux/x86 pods, which are the only ones EKS runs on Fargate; the Fargate Spot
+		// usage types are only used by ECS
+		if strings.Contains(usageType, "ARM") || strings.Contains(usageType, "Windows") || strings.Contains(usageType, "SpotUsage") {
+			continue
+		}
+		price, ok := onDemandHourlyUSD(pricing.Terms.OnDemand[sku])
+		if !ok {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(usageType, fargateVCPUUsageType):
+			prices.VCPUHourly = price
+		case strings.HasSuffix(usageType, fargateMemoryUsageType):
+			prices.GBHourly = price
+		}
+	}
+	if prices.VCPUHourly == 0 || prices.GBHourly == 0 {
+		return nil, fmt.Errorf("no Fargate prices found in \"%s\"", pricingURL)
+	}
+	return prices, nil
+}
+
+// onDemandHourlyUSD returns the first USD price in a set of on demand offer terms
+func onDemandHourlyUSD(terms map[string]*AWSOfferTerm) (float64, bool) {
+	for _, term := range terms {
+		for _, dim := range term.PriceDimensions {
+			if dim.PricePerUnit.USD == "" {
+				continue
+			}
+			price, err := strconv.ParseFloat(dim.PricePerUnit.USD, 64)
+			if err == nil {
+				return price, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,456 @@
+package cloud
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/clustercache"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Recorded AmazonECS offer file for us-east-1, trimmed to the Fargate products.
+const fargateOfferFile = `{
+	"products": {
+		"8CESGAFWKAJ98PME": {"sku": "8CESGAFWKAJ98PME", "attributes": {"usagetype": "USE1-Fargate-vCPU-Hours:perCPU"}},
+		"PYYM5NGUCJ5ZK3JA": {"sku": "PYYM5NGUCJ5ZK3JA", "attributes": {"usagetype": "USE1-Fargate-GB-Hours"}},
+		"V8FDZGAQFQ6VXHAQ": {"sku": "V8FDZGAQFQ6VXHAQ", "attributes": {"usagetype": "USE1-SpotUsage-Fargate-vCPU-Hours:perCPU"}},
+		"3SJ5YPSZ2ZS9EGEB": {"sku": "3SJ5YPSZ2ZS9EGEB", "attributes": {"usagetype": "USE1-SpotUsage-Fargate-GB-Hours"}},
+		"DXTBZ9MH3FVTV4UP": {"sku": "DXTBZ9MH3FVTV4UP", "attributes": {"usagetype": "USE1-Fargate-ARM-vCPU-Hours:perCPU"}}
+	},
+	"terms": {
+		"OnDemand": {
+			"8CESGAFWKAJ98PME": {"8CESGAFWKAJ98PME.JRTCKXETXF": {"sku": "8CESGAFWKAJ98PME", "priceDimensions": {"8CESGAFWKAJ98PME.JRTCKXETXF.6YS6EN2CT7": {"unit": "hours", "pricePerUnit": {"USD": "0.0404800000"}}}}},
+			"PYYM5NGUCJ5ZK3JA": {"PYYM5NGUCJ5ZK3JA.JRTCKXETXF": {"sku": "PYYM5NGUCJ5ZK3JA", "priceDimensions": {"PYYM5NGUCJ5ZK3JA.JRTCKXETXF.6YS6EN2CT7": {"unit": "hours", "pricePerUnit": {"USD": "0.0044450000"}}}}},
+			"V8FDZGAQFQ6VXHAQ": {"V8FDZGAQFQ6VXHAQ.JRTCKXETXF": {"sku": "V8FDZGAQFQ6VXHAQ", "priceDimensions": {"V8FDZGAQFQ6VXHAQ.JRTCKXETXF.6YS6EN2CT7": {"unit": "hours", "pricePerUnit": {"USD": "0.0121500000"}}}}},
+			"3SJ5YPSZ2ZS9EGEB": {"3SJ5YPSZ2ZS9EGEB.JRTCKXETXF": {"sku": "3SJ5YPSZ2ZS9EGEB", "priceDimensions": {"3SJ5YPSZ2ZS9EGEB.JRTCKXETXF.6YS6EN2CT7": {"unit": "hours", "pricePerUnit": {"USD": "0.0013350000"}}}}},
+			"DXTBZ9MH3FVTV4UP": {"DXTBZ9MH3FVTV4UP.JRTCKXETXF": {"sku": "DXTBZ9MH3FVTV4UP", "priceDimensions": {"DXTBZ9MH3FVTV4UP.JRTCKXETXF.6YS6EN2CT7": {"unit": "hours", "pricePerUnit": {"USD": "0.0323800000"}}}}}
+		}
+	}
+}`
+
+type fargateFakeCache struct {
+	nodes []*v1.Node
+	pods  []*v1.Pod
+	// podLists counts the calls of GetAllPods, if set
+	podLists *int
+	clustercache.ClusterCache
+}
+
+func (f fargateFakeCache) GetAllNodes() []*v1.Node {
+	return f.nodes
+}
+
+func (f fargateFakeCache) GetAllPods() []*v1.Pod {
+	if f.podLists != nil {
+		*f.podLists++
+	}
+	return f.pods
+}
+
+func (f fargateFakeCache) GetAllPersistentVolumes() []*v1.PersistentVolume {
+	return nil
+}
+
+func fargateContainer(cpu, memory string) v1.Container {
+	requests := v1.ResourceList{}
+	if cpu != "" {
+		requests[v1.ResourceCPU] = resource.MustParse(cpu)
+	}
+	if memory != "" {
+		requests[v1.ResourceMemory] = resource.MustParse(memory)
+	}
+	return v1.Container{
+		Resources: v1.ResourceRequirements{Requests: requests},
+	}
+}
+
+func TestRoundFargateConfiguration(t *testing.T) {
+	cases := []struct {
+		name     string
+		cpu      float64
+		ram      float64
+		expected string
+	}{
+		{
+			name:     "no requests",
+			cpu:      0,
+			ram:      FargatePodOverheadBytes,
+			expected: "fargate-0.25vCPU-0.5GB",
+		},
+		{
+			name:     "exact smallest configuration",
+			cpu:      0.25,
+			ram:      0.5 * gib,
+			expected: "fargate-0.25vCPU-0.5GB",
+		},
+		{
+			name:     "overhead rounds memory up",
+			cpu:      0.25,
+			ram:      0.5*gib + FargatePodOverheadBytes,
+			expected: "fargate-0.25vCPU-1GB",
+		},
+		{
+			name:     "cpu rounds up to next vCPU size",
+			cpu:      0.3,
+			ram:      0.5 * gib,
+			expected: "fargate-0.5vCPU-1GB",
+		},
+		{
+			name:     "memory beyond vCPU size raises vCPU",
+			cpu:      0.25,
+			ram:      4.5 * gib,
+			expected: "fargate-1vCPU-5GB",
+		},
+		{
+			name:     "memory rounds up to whole GB",
+			cpu:      2,
+			ram:      4.01 * gib,
+			expected: "fargate-2vCPU-5GB",
+		},
+		{
+			name:     "memory rounds up to 4GB increments",
+			cpu:      8,
+			ram:      17 * gib,
+			expected: "fargate-8vCPU-20GB",
+		},
+		{
+			name:     "memory rounds up to 8GB increments",
+			cpu:      16,
+			ram:      33 * gib,
+			expected: "fargate-16vCPU-40GB",
+		},
+		{
+			name:     "largest configuration",
+			cpu:      16,
+			ram:      120 * gib,
+			expected: "fargate-16vCPU-120GB",
+		},
+		{
+			name:     "too much cpu",
+			cpu:      16.5,
+			ram:      gib,
+			expected: "",
+		},
+		{
+			name:     "too much memory",
+			cpu:      1,
+			ram:      121 * gib,
+			expected: "",
+		},
+	}
+
+	for _, test := range cases {
+		conf, err := RoundFargateConfiguration(test.cpu, test.ram)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("%s: Expected error, Actual: %s", test.name, conf.InstanceType())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if conf.InstanceType() != test.expected {
+			t.Errorf("Input: %s, Expected: %s, Actual: %s", test.name, test.expected, conf.InstanceType())
+		}
+	}
+}
+
+func TestFargatePodConfiguration(t *testing.T) {
+	cases := []struct {
+		name     string
+		pod      *v1.Pod
+		expected string
+	}{
+		{
+			name: "containers are summed",
+			pod: &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{
+				fargateContainer("250m", "512Mi"),
+				fargateContainer("250m", "1Gi"),
+			}}},
+			expected: "fargate-0.5vCPU-2GB",
+		},
+		{
+			name: "larger init container wins",
+			pod: &v1.Pod{Spec: v1.PodSpec{
+				InitContainers: []v1.Container{fargateContainer("2", "256Mi")},
+				Containers:     []v1.Container{fargateContainer("250m", "2Gi")},
+			}},
+			expected: "fargate-2vCPU-4GB",
+		},
+		{
+			name: "smaller init container is ignored",
+			pod: &v1.Pod{Spec: v1.PodSpec{
+				InitContainers: []v1.Container{fargateContainer("100m", "128Mi")},
+				Containers:     []v1.Container{fargateContainer("1", "1792Mi")},
+			}},
+			expected: "fargate-1vCPU-2GB",
+		},
+		{
+			name:     "no requests",
+			pod:      &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{fargateContainer("", "")}}},
+			expected: "fargate-0.25vCPU-0.5GB",
+		},
+	}
+
+	for _, test := range cases {
+		conf, err := FargatePodConfiguration(test.pod)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if conf.InstanceType() != test.expected {
+			t.Errorf("Input: %s, Expected: %s, Actual: %s", test.name, test.expected, conf.InstanceType())
+		}
+	}
+}
+
+func TestFargateBillableHours(t *testing.T) {
+	cases := []struct {
+		input    time.Duration
+		expected float64
+	}{
+		{
+			input:    0,
+			expected: 0,
+		},
+		{
+			input:    time.Second,
+			expected: 60.0 / 3600,
+		},
+		{
+			input:    59 * time.Second,
+			expected: 60.0 / 3600,
+		},
+		{
+			input:    61*time.Second + 200*time.Millisecond,
+			expected: 62.0 / 3600,
+		},
+		{
+			input:    time.Hour,
+			expected: 1,
+		},
+	}
+
+	for _, test := range cases {
+		result := FargateBillableHours(test.input)
+		if result != test.expected {
+			t.Errorf("Input: %s, Expected: %f, Actual: %f", test.input, test.expected, result)
+		}
+	}
+}
+
+func TestParseFargateInstanceType(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected FargateConfiguration
+		ok       bool
+	}{
+		{
+			input:    "fargate-0.25vCPU-0.5GB",
+			expected: FargateConfiguration{VCPU: 0.25, MemoryGiB: 0.5},
+			ok:       true,
+		},
+		{
+			input:    "fargate-16vCPU-120GB",
+			expected: FargateConfiguration{VCPU: 16, MemoryGiB: 120},
+			ok:       true,
+		},
+		{
+			input: "m5.large",
+			ok:    false,
+		},
+	}
+
+	for _, test := range cases {
+		result, ok := ParseFargateInstanceType(test.input)
+		if ok != test.ok || result != test.expected {
+			t.Errorf("Input: %s, Expected: %v, Actual: %v", test.input, test.expected, result)
+		}
+	}
+}
+
+func TestAWSFargateNodePricing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/us-east-1/index.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, fargateOfferFile)
+	}))
+	defer server.Close()
+
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "fargate-ip-192-168-101-45.ec2.internal",
+			Labels: map[string]string{
+				FargateComputeTypeLabel:         FargateComputeType,
+				"topology.kubernetes.io/region": "us-east-1",
+			},
+		},
+	}
+	spotLabelledNode := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "fargate-ip-192-168-101-46.ec2.internal",
+			Labels: map[string]string{
+				FargateComputeTypeLabel:          FargateComputeType,
+				"topology.kubernetes.io/region":  "us-east-1",
+				"eks.amazonaws.com/capacityType": "SPOT",
+			},
+		},
+	}
+	pods := []*v1.Pod{
+		{
+			Spec: v1.PodSpec{
+				NodeName:   node.Name,
+				Containers: []v1.Container{fargateContainer("500m", "1Gi")},
+			},
+		},
+		{
+			Spec: v1.PodSpec{
+				NodeName:   spotLabelledNode.Name,
+				Containers: []v1.Container{fargateContainer("250m", "256Mi")},
+			},
+		},
+	}
+
+	aws := &AWS{
+		Clientset:            fargateFakeCache{nodes: []*v1.Node{node, spotLabelledNode}, pods: pods},
+		SpotLabelName:        "eks.amazonaws.com/capacityType",
+		SpotLabelValue:       "SPOT",
+		fargatePricingURLFmt: server.URL + "/%s/index.json",
+	}
+	// The SpotUsage SKUs are Fargate Spot prices, which only apply to ECS and must not be picked up
+	aws.downloadFargatePricing(map[string]bool{"us-east-1": true, "eu-west-1": true, "xx-nowhere-1": true})
+
+	prices := aws.FargatePricing["us-east-1"]
+	if prices.VCPUHourly != 0.04048 || prices.GBHourly != 0.004445 {
+		t.Errorf("Unexpected us-east-1 Fargate prices: %+v", prices)
+	}
+	// Regions which fail to download keep the default prices
+	if aws.FargatePricing["eu-west-1"] != fargateDefaultPrices["eu-west-1"] {
+		t.Errorf("Expected default eu-west-1 Fargate prices, Actual: %+v", aws.FargatePricing["eu-west-1"])
+	}
+
+	cases := []struct {
+		node         *v1.Node
+		instanceType string
+		cost         string
+		ramBytes     string
+		usageType    string
+	}{
+		{
+			node:         node,
+			instanceType: "fargate-0.5vCPU-2GB",
+			cost:         "0.029130",
+			ramBytes:     "2147483648.000000",
+			usageType:    "ondemand",
+		},
+		// EKS has no Fargate spot capacity, so a spot label does not change the price
+		{
+			node:         spotLabelledNode,
+			instanceType: "fargate-0.25vCPU-0.5GB",
+			cost:         "0.012343",
+			ramBytes:     "536870912.000000",
+			usageType:    "ondemand",
+		},
+	}
+	for _, test := range cases {
+		n, err := aws.NodePricing(aws.GetKey(test.node.Labels, test.node))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", test.node.Name, err)
+		}
+		if n.InstanceType != test.instanceType {
+			t.Errorf("%s: Expected instance type: %s, Actual: %s", test.node.Name, test.instanceType, n.InstanceType)
+		}
+		if n.Cost != test.cost {
+			t.Errorf("%s: Expected cost: %s, Actual: %s", test.node.Name, test.cost, n.Cost)
+		}
+		if n.RAMBytes != test.ramBytes {
+			t.Errorf("%s: Expected RAM bytes: %s, Actual: %s", test.node.Name, test.ramBytes, n.RAMBytes)
+		}
+		if n.UsageType != test.usageType {
+			t.Errorf("%s: Expected usage type: %s, Actual: %s", test.node.Name, test.usageType, n.UsageType)
+		}
+		if n.PricingType != Fargate {
+			t.Errorf("%s: Expected pricing type: %s, Actual: %s", test.node.Name, Fargate, n.PricingType)
+		}
+	}
+}
+
+func TestAWSFargateNodePricing_PodIndex(t *testing.T) {
+	nodes := []*v1.Node{}
+	pods := []*v1.Pod{}
+	for i := 0; i < 3; i++ {
+		node := &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fmt.Sprintf("fargate-ip-192-168-101-%d.ec2.internal", i),
+				Labels: map[string]string{
+					FargateComputeTypeLabel:         FargateComputeType,
+					"topology.kubernetes.io/region": "us-east-1",
+				},
+			},
+		}
+		nodes = append(nodes, node)
+		pods = append(pods, &v1.Pod{
+			Spec: v1.PodSpec{
+				NodeName:   node.Name,
+				Containers: []v1.Container{fargateContainer("500m", "1Gi")},
+			},
+		})
+	}
+
+	podLists := 0
+	aws := &AWS{
+		Clientset:      fargateFakeCache{nodes: nodes, pods: pods, podLists: &podLists},
+		FargatePricing: fargateDefaultPrices,
+	}
+	aws.indexFargatePods()
+
+	for _, node := range nodes {
+		n, err := aws.NodePricing(aws.GetKey(node.Labels, node))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", node.Name, err)
+		}
+		if n.InstanceType != "fargate-0.5vCPU-2GB" {
+			t.Errorf("%s: Expected instance type: fargate-0.5vCPU-2GB, Actual: %s", node.Name, n.InstanceType)
+		}
+	}
+	if podLists != 1 {
+		t.Errorf("Expected pods to be listed once, Actual: %d", podLists)
+	}
+
+	// A node whose pod is gone is priced as the instance type it reported
+	gone := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "fargate-ip-192-168-101-99.ec2.internal",
+			Labels: map[string]string{
+				FargateComputeTypeLabel:            FargateComputeType,
+				"topology.kubernetes.io/region":    "us-east-1",
+				"node.kubernetes.io/instance-type": "fargate-1vCPU-2GB",
+			},
+		},
+	}
+	n, err := aws.NodePricing(aws.GetKey(gone.Labels, gone))
+	if err != nil {
+		t.Fatalf("%s: unexpected error: %s", gone.Name, err)
+	}
+	if n.InstanceType != "fargate-1vCPU-2GB" || n.Cost != "0.049370" {
+		t.Errorf("%s: Expected fargate-1vCPU-2GB at 0.049370, Actual: %s at %s", gone.Name, n.InstanceType, n.Cost)
+	}
+	if podLists != 1 {
+		t.Errorf("Expected pods not to be listed again within %s, Actual: %d lists", fargatePodIndexInterval, podLists)
+	}
+
+	delete(gone.Labels, "node.kubernetes.io/instance-type")
+	if _, err := aws.NodePricing(aws.GetKey(gone.Labels, gone)); err == nil {
+		t.Errorf("%s: Expected an error without a pod or instance type", gone.Name)
+	}
+}
@@ -163,6 +163,15 @@ type AWS struct {
 	clusterAccountId            string
 	clusterRegion               string
 	clusterProvisioner          string
+	// FargatePricing maps a region to its Fargate prices
+	FargatePricing map[string]*FargatePrices
+	// fargatePricingURLFmt overrides the Fargate offer file url. It is only set in tests.
+	fargatePricingURLFmt string
+	// fargatePods indexes the running pods by the name of their node, so that Fargate nodes are priced without
+	// listing every pod of the cluster per node. It is guarded by fargatePodsLock.
+	fargatePods        map[string]*v1.Pod
+	fargatePodsIndexed time.Time
+	fargatePodsLock    sync.Mutex
 	*CustomProvider
 }
 
@@ -275,6 +284,33 @@ type AWSProductTerms struct {
 	VCpu     string        `json:"vcpu"`
 	GPU      string        `json:"gpu"` // GPU represents the number of GPU on the instance
 	PV       *PV           `json:"pv"`
+
+	AcceleratorType string `json:"acceleratorType,omitempty"` // AcceleratorType is the type of the instance's accelerators, if they are not GPUs
+}
+
+// awsNeuronDevices are the number of AWS Neuron devices of the Inferentia and Trainium instance types, which the
+// pricing data does not count as GPUs.
+var awsNeuronDevices = map[string]string{
+	"inf1.xlarge":    "1",
+	"inf1.2xlarge":   "1",
+	"inf1.6xlarge":   "4",
+	"inf1.24xlarge":  "16",
+	"inf2.xlarge":    "1",
+	"inf2.8xlarge":   "1",
+	"inf2.24xlarge":  "6",
+	"inf2.48xlarge":  "12",
+	"trn1.2xlarge":   "1",
+	"trn1.32xlarge":  "16",
+	"trn1n.32xlarge": "16",
+}
+
+// awsAccelerators returns the number and type of the accelerators of the given product. GPUs are left untyped, as
+// their type is discovered from the capacity of the node.
+func awsAccelerators(attributes AWSProductAttributes) (string, string) {
+	if devices, ok := awsNeuronDevices[attributes.InstanceType]; ok {
+		return devices, AcceleratorNeuron
+	}
+	return attributes.GPU, ""
 }
 
 // ClusterIdEnvVar is the environment variable in which one can manually set the ClusterId
@@ -598,6 +634,12 @@ type awsKey struct {
 	SpotLabelValue string
 	Labels         map[string]string
 	ProviderID     string
+	Name           string
+}
+
+// IsFargate returns true if the key is for an EKS Fargate virtual node
+func (k *awsKey) IsFargate() bool {
+	return IsFargateNode(k.Labels, k.Name)
 }
 
 func (k *awsKey) GPUType() string {
@@ -622,6 +664,9 @@ func (k *awsKey) Features() string {
 	region, _ := util.GetRegion(k.Labels)
 
 	key := region + "," + instanceType + "," + operatingSystem
+	if k.IsFargate() {
+		key = region + "," + FargateComputeType + "," + operatingSystem
+	}
 	usageType := PreemptibleType
 	spotKey := key + "," + usageType
 	if l, ok := k.Labels["lifecycle"]; ok && l == "EC2Spot" {
@@ -697,12 +742,16 @@ func (key *awsPVKey) Features() string {
 
 // GetKey maps node labels to information needed to retrieve pricing data
 func (aws *AWS) GetKey(labels map[string]string, n *v1.Node) Key {
-	return &awsKey{
+	key := &awsKey{
 		SpotLabelName:  aws.SpotLabelName,
 		SpotLabelValue: aws.SpotLabelValue,
 		Labels:         labels,
 		ProviderID:     labels["providerID"],
 	}
+	if n != nil {
+		key.Name = n.Name
+	}
+	return key
 }
 
 func (aws *AWS) isPreemptible(key string) bool {
@@ -795,6 +844,7 @@ func (aws *AWS) DownloadPricingData() error {
 	nodeList := aws.Clientset.GetAllNodes()
 
 	inputkeys := make(map[string]bool)
+	fargateRegions := make(map[string]bool)
 	for _, n := range nodeList {
 		if _, ok := n.Labels["eks.amazonaws.com/nodegroup"]; ok {
 			aws.clusterManagementPrice = 0.10
@@ -805,8 +855,17 @@ func (aws *AWS) DownloadPricingData() error {
 
 		labels := n.GetObjectMeta().GetLabels()
 		key := aws.GetKey(labels, n)
+		if key.(*awsKey).IsFargate() {
+			region, _ := util.GetRegion(labels)
+			fargateRegions[region] = true
+			continue
+		}
 		inputkeys[key.Features()] = true
 	}
+	aws.downloadFargatePricing(fargateRegions)
+	if len(fargateRegions) > 0 {
+		aws.indexFargatePods()
+	}
 
 	pvList := aws.Clientset.GetAllPersistentVolumes()
 
@@ -916,12 +975,14 @@ func (aws *AWS) DownloadPricingData() error {
 					key := aws.KubeAttrConversion(product.Attributes.Location, product.Attributes.InstanceType, product.Attributes.OperatingSystem)
 					spotKey := key + ",preemptible"
 					if inputkeys[key] || inputkeys[spotKey] { // Just grab the sku even if spot, and change the price later.
+						gpu, acceleratorType := awsAccelerators(product.Attributes)
 						productTerms := &AWSProductTerms{
-							Sku:     product.Sku,
-							Memory:  product.Attributes.Memory,
-							Storage: product.Attributes.Storage,
-							VCpu:    product.Attributes.VCpu,
-							GPU:     product.Attributes.GPU,
+							Sku:             product.Sku,
+							Memory:          product.Attributes.Memory,
+							Storage:         product.Attributes.Storage,
+							VCpu:            product.Attributes.VCpu,
+							GPU:             gpu,
+							AcceleratorType: acceleratorType,
 						}
 						aws.Pricing[key] = productTerms
 						aws.Pricing[spotKey] = productTerms
@@ -1098,9 +1159,10 @@ func (aws *AWS) NetworkPricing() (*Network, error) {
 	}
 
 	return &Network{
-		ZoneNetworkEgressCost:     znec,
-		RegionNetworkEgressCost:   rnec,
-		InternetNetworkEgressCost: inec,
+		ZoneNetworkEgressCost:        znec,
+		RegionNetworkEgressCost:      rnec,
+		InternetNetworkEgressCost:    inec,
+		NATGatewayDataProcessingCost: natGatewayDataProcessingCost(cpricing),
 	}, nil
 }
 
@@ -1167,55 +1229,59 @@ func (aws *AWS) createNode(terms *AWSProductTerms, usageType string, k Key) (*No
 			log.Infof("Spot data for node %s is missing", k.ID())
 		}
 		return &Node{
-			Cost:         spotcost,
-			VCPU:         terms.VCpu,
-			RAM:          terms.Memory,
-			GPU:          terms.GPU,
-			Storage:      terms.Storage,
-			BaseCPUPrice: aws.BaseCPUPrice,
-			BaseRAMPrice: aws.BaseRAMPrice,
-			BaseGPUPrice: aws.BaseGPUPrice,
-			UsageType:    PreemptibleType,
+			Cost:            spotcost,
+			VCPU:            terms.VCpu,
+			RAM:             terms.Memory,
+			GPU:             terms.GPU,
+			AcceleratorType: terms.AcceleratorType,
+			Storage:         terms.Storage,
+			BaseCPUPrice:    aws.BaseCPUPrice,
+			BaseRAMPrice:    aws.BaseRAMPrice,
+			BaseGPUPrice:    aws.BaseGPUPrice,
+			UsageType:       PreemptibleType,
 		}, nil
 	} else if aws.isPreemptible(key) { // Preemptible but we don't have any data in the pricing report.
 		log.DedupedWarningf(5, "Node %s marked preemptible but we have no data in spot feed", k.ID())
 		return &Node{
-			VCPU:         terms.VCpu,
-			VCPUCost:     aws.BaseSpotCPUPrice,
-			RAM:          terms.Memory,
-			GPU:          terms.GPU,
-			Storage:      terms.Storage,
-			BaseCPUPrice: aws.BaseCPUPrice,
-			BaseRAMPrice: aws.BaseRAMPrice,
-			BaseGPUPrice: aws.BaseGPUPrice,
-			UsageType:    PreemptibleType,
+			VCPU:            terms.VCpu,
+			VCPUCost:        aws.BaseSpotCPUPrice,
+			RAM:             terms.Memory,
+			GPU:             terms.GPU,
+			AcceleratorType: terms.AcceleratorType,
+			Storage:         terms.Storage,
+			BaseCPUPrice:    aws.BaseCPUPrice,
+			BaseRAMPrice:    aws.BaseRAMPrice,
+			BaseGPUPrice:    aws.BaseGPUPrice,
+			UsageType:       PreemptibleType,
 		}, nil
 	} else if sp, ok := aws.savingsPlanPricing(k.ID()); ok {
 		strCost := fmt.Sprintf("%f", sp.EffectiveCost)
 		return &Node{
-			Cost:         strCost,
-			VCPU:         terms.VCpu,
-			RAM:          terms.Memory,
-			GPU:          terms.GPU,
-			Storage:      terms.Storage,
-			BaseCPUPrice: aws.BaseCPUPrice,
-			BaseRAMPrice: aws.BaseRAMPrice,
-			BaseGPUPrice: aws.BaseGPUPrice,
-			UsageType:    usageType,
+			Cost:            strCost,
+			VCPU:            terms.VCpu,
+			RAM:             terms.Memory,
+			GPU:             terms.GPU,
+			AcceleratorType: terms.AcceleratorType,
+			Storage:         terms.Storage,
+			BaseCPUPrice:    aws.BaseCPUPrice,
+			BaseRAMPrice:    aws.BaseRAMPrice,
+			BaseGPUPrice:    aws.BaseGPUPrice,
+			UsageType:       usageType,
 		}, nil
 
 	} else if ri, ok := aws.reservedInstancePricing(k.ID()); ok {
 		strCost := fmt.Sprintf("%f", ri.EffectiveCost)
 		return &Node{
-			Cost:         strCost,
-			VCPU:         terms.VCpu,
-			RAM:          terms.Memory,
-			GPU:          terms.GPU,
-			Storage:      terms.Storage,
-			BaseCPUPrice: aws.BaseCPUPrice,
-			BaseRAMPrice: aws.BaseRAMPrice,
-			BaseGPUPrice: aws.BaseGPUPrice,
-			UsageType:    usageType,
+			Cost:            strCost,
+			VCPU:            terms.VCpu,
+			RAM:             terms.Memory,
+			GPU:             terms.GPU,
+			AcceleratorType: terms.AcceleratorType,
+			Storage:         terms.Storage,
+			BaseCPUPrice:    aws.BaseCPUPrice,
+			BaseRAMPrice:    aws.BaseRAMPrice,
+			BaseGPUPrice:    aws.BaseGPUPrice,
+			UsageType:       usageType,
 		}, nil
 
 	}
@@ -1234,15 +1300,16 @@ func (aws *AWS) createNode(terms *AWSProductTerms, usageType string, k Key) (*No
 	}
 
 	return &Node{
-		Cost:         cost,
-		VCPU:         terms.VCpu,
-		RAM:          terms.Memory,
-		GPU:          terms.GPU,
-		Storage:      terms.Storage,
-		BaseCPUPrice: aws.BaseCPUPrice,
-		BaseRAMPrice: aws.BaseRAMPrice,
-		BaseGPUPrice: aws.BaseGPUPrice,
-		UsageType:    usageType,
+		Cost:            cost,
+		VCPU:            terms.VCpu,
+		RAM:             terms.Memory,
+		GPU:             terms.GPU,
+		AcceleratorType: terms.AcceleratorType,
+		Storage:         terms.Storage,
+		BaseCPUPrice:    aws.BaseCPUPrice,
+		BaseRAMPrice:    aws.BaseRAMPrice,
+		BaseGPUPrice:    aws.BaseGPUPrice,
+		UsageType:       usageType,
 	}, nil
 }
 
@@ -1257,6 +1324,10 @@ func (aws *AWS) NodePricing(k Key) (*Node, error) {
 		usageType = PreemptibleType
 	}
 
+	if ak, ok := k.(*awsKey); ok && ak.IsFargate() {
+		return aws.fargateNode(ak)
+	}
+
 	terms, ok := aws.Pricing[key]
 	if ok {
 		return aws.createNode(terms, usageType, k)
@@ -1292,6 +1363,126 @@ func (aws *AWS) NodePricing(k Key) (*Node, error) {
 	}
 }
 
+// downloadFargatePricing refreshes the Fargate prices of the given regions, keeping the default prices of regions
+// which fail to download.
+func (aws *AWS) downloadFargatePricing(regions map[string]bool) {
+	if len(regions) == 0 {
+		return
+	}
+	urlFmt := aws.fargatePricingURLFmt
+	if urlFmt == "" {
+		urlFmt = fargatePricingURLFmt
+	}
+	client := &http.Client{Timeout: 60 * time.Second}
+
+	pricing := make(map[string]*FargatePrices)
+	for region, prices := range fargateDefaultPrices {
+		pricing[region] = prices
+	}
+	for region := range regions {
+		if region == "" {
+			continue
+		}
+		prices, err := downloadFargatePricing(client, fmt.Sprintf(urlFmt, region))
+		if err != nil {
+			log.Warnf("Failed to download Fargate pricing for %s, using default prices: %s", region, err)
+			continue
+		}
+		pricing[region] = prices
+	}
+	aws.FargatePricing = pricing
+}
+
+// fargatePodIndexInterval is the minimum interval between indexes of the pods of Fargate nodes which are not
+// triggered by DownloadPricingData.
+const fargatePodIndexInterval = time.Minute
+
+// indexFargatePods indexes the running pods of the cluster by the name of their node.
+func (aws *AWS) indexFargatePods() {
+	aws.fargatePodsLock.Lock()
+	defer aws.fargatePodsLock.Unlock()
+
+	aws.indexFargatePodsLocked()
+}
+
+func (aws *AWS) indexFargatePodsLocked() {
+	pods := make(map[string]*v1.Pod)
+	if aws.Clientset != nil {
+		for _, p := range aws.Clientset.GetAllPods() {
+			if p.Spec.NodeName == "" || p.Status.Phase == v1.PodSucceeded || p.Status.Phase == v1.PodFailed {
+				continue
+			}
+			if _, ok := pods[p.Spec.NodeName]; !ok {
+				pods[p.Spec.NodeName] = p
+			}
+		}
+	}
+	aws.fargatePods = pods
+	aws.fargatePodsIndexed = time.Now()
+}
+
+// fargatePod returns the running pod of the given Fargate node. Nodes which are not in the index, e.g. because
+// they were created since it was built, are looked up in a new index, at most once per fargatePodIndexInterval.
+func (aws *AWS) fargatePod(node string) *v1.Pod {
+	aws.fargatePodsLock.Lock()
+	defer aws.fargatePodsLock.Unlock()
+
+	if pod, ok := aws.fargatePods[node]; ok {
+		return pod
+	}
+	if time.Since(aws.fargatePodsIndexed) < fargatePodIndexInterval {
+		return nil
+	}
+	aws.indexFargatePodsLocked()
+	return aws.fargatePods[node]
+}
+
+// fargateNode prices an EKS Fargate virtual node as the configuration of the pod running on it, so that the node's
+// cost is exactly the cost of its pod. EKS has no Fargate spot capacity, so nodes are always priced on demand.
+func (aws *AWS) fargateNode(k *awsKey) (*Node, error) {
+	region, _ := util.GetRegion(k.Labels)
+	pricing := aws.FargatePricing
+	if pricing == nil {
+		pricing = fargateDefaultPrices
+	}
+	prices, ok := pricing[region]
+	if !ok {
+		return nil, fmt.Errorf("no Fargate pricing for region \"%s\"", region)
+	}
+
+	var conf FargateConfiguration
+	if pod := aws.fargatePod(k.Name); pod != nil {
+		c, err := FargatePodConfiguration(pod)
+		if err != nil {
+			return nil, err
+		}
+		conf = c
+	} else {
+		// The pod may be gone before its node, e.g. when it completed, in which case the node is priced as the
+		// configuration of the instance type it last reported.
+		instanceType, _ := util.GetInstanceType(k.Labels)
+		c, ok := ParseFargateInstanceType(instanceType)
+		if !ok {
+			return nil, fmt.Errorf("no pod found on Fargate node \"%s\"", k.Name)
+		}
+		conf = c
+	}
+
+	cpuCost, ramCost := prices.VCPUHourly, prices.GBHourly
+	return &Node{
+		Cost:         fmt.Sprintf("%f", conf.VCPU*cpuCost+conf.MemoryGiB*ramCost),
+		VCPU:         strconv.FormatFloat(conf.VCPU, 'f', -1, 64),
+		VCPUCost:     fmt.Sprintf("%f", cpuCost),
+		RAM:          fmt.Sprintf("%sGiB", strconv.FormatFloat(conf.MemoryGiB, 'f', -1, 64)),
+		RAMBytes:     fmt.Sprintf("%f", conf.RAMBytes()),
+		RAMCost:      fmt.Sprintf("%f", ramCost),
+		InstanceType: conf.InstanceType(),
+		Region:       region,
+		UsageType:    "ondemand",
+		PricingType:  Fargate,
+	}, nil
+}
+
 // ClusterInfo returns an object that represents the cluster. TODO: actually return the name of the cluster. Blocked on cluster federation.
 func (awsProvider *AWS) ClusterInfo() (map[string]string, error) {
 	defaultClusterName := "AWS Cluster #1"
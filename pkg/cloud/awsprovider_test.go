@@ -0,0 +1,46 @@
+package cloud
+
+import (
+	"testing"
+)
+
+func TestAWSAccelerators(t *testing.T) {
+	cases := []struct {
+		name            string
+		attributes      AWSProductAttributes
+		expectedGPU     string
+		expectedAccType string
+	}{
+		{
+			name:            "GPU instance",
+			attributes:      AWSProductAttributes{InstanceType: "p3.8xlarge", GPU: "4"},
+			expectedGPU:     "4",
+			expectedAccType: "",
+		},
+		{
+			name:            "Inferentia instance",
+			attributes:      AWSProductAttributes{InstanceType: "inf2.24xlarge"},
+			expectedGPU:     "6",
+			expectedAccType: AcceleratorNeuron,
+		},
+		{
+			name:            "Trainium instance",
+			attributes:      AWSProductAttributes{InstanceType: "trn1.32xlarge"},
+			expectedGPU:     "16",
+			expectedAccType: AcceleratorNeuron,
+		},
+		{
+			name:            "CPU instance",
+			attributes:      AWSProductAttributes{InstanceType: "m5.large"},
+			expectedGPU:     "",
+			expectedAccType: "",
+		},
+	}
+
+	for _, test := range cases {
+		gpu, accType := awsAccelerators(test.attributes)
+		if gpu != test.expectedGPU || accType != test.expectedAccType {
+			t.Errorf("%s: expected %q %q; got %q %q", test.name, test.expectedGPU, test.expectedAccType, gpu, accType)
+		}
+	}
+}
@@ -1056,9 +1056,10 @@ func (az *Azure) NetworkPricing() (*Network, error) {
 	}
 
 	return &Network{
-		ZoneNetworkEgressCost:     znec,
-		RegionNetworkEgressCost:   rnec,
-		InternetNetworkEgressCost: inec,
+		ZoneNetworkEgressCost:        znec,
+		RegionNetworkEgressCost:      rnec,
+		InternetNetworkEgressCost:    inec,
+		NATGatewayDataProcessingCost: natGatewayDataProcessingCost(cpricing),
 	}, nil
 }
 
@@ -0,0 +1,170 @@
+package cloud
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/log"
+)
+
+const (
+	// awsSurplusCreditPrice is the price of a surplus CPU credit per vCPU-hour of T-series instances running in
+	// unlimited mode on Linux.
+	awsSurplusCreditPrice = 0.05
+	// burstableMaxAccrualHours is the number of hours of baseline credits a burstable instance can bank.
+	burstableMaxAccrualHours = 24
+
+	// BurstableCreditModeUnlimited instances are billed for the surplus credits they spend once their banked
+	// credits run out.
+	BurstableCreditModeUnlimited = "unlimited"
+	// BurstableCreditModeStandard instances are throttled to their baseline once their banked credits run out.
+	BurstableCreditModeStandard = "standard"
+)
+
+// BurstableAccrualPeriod is the period over which a burstable instance can bank credits, so that its balance at
+// the start of a window is set by its usage over the period before it.
+const BurstableAccrualPeriod = burstableMaxAccrualHours * time.Hour
+
+// BurstableInstance is the baseline CPU performance of a burstable instance type. A node using more vCPU than its
+// baseline spends CPU credits; once banked credits run out, it spends surplus credits which are billed on top of
+// the hourly price.
+type BurstableInstance struct {
+	VCPU          float64
+	BaselineCores float64
+	// SurplusCreditPrice is the price of running one vCPU for an hour above the baseline with no banked credits
+	SurplusCreditPrice float64
+	// CreditMode is the credit mode instances of the type launch in unless configured otherwise, or empty if the
+	// type has no credit modes
+	CreditMode string
+}
+
+// AWS T3, T3a and T4g instances launch in unlimited mode by default, whereas T2 instances launch in standard mode
+// and are throttled unless they are configured to be unlimited.
+// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/burstable-performance-instances-unlimited-mode-concepts.html
+func awsBurstable(vcpu, baselinePerVCPU float64, creditMode string) BurstableInstance {
+	return BurstableInstance{VCPU: vcpu, BaselineCores: vcpu * baselinePerVCPU, SurplusCreditPrice: awsSurplusCreditPrice, CreditMode: creditMode}
+}
+
+// Azure B-series VMs are throttled to their baseline when their credits run out rather than billed for surplus
+// credits, so they carry no surplus price unless one is configured with burstableCreditPrice.
+func azureBurstable(vcpu, baselineCores float64) BurstableInstance {
+	return BurstableInstance{VCPU: vcpu, BaselineCores: baselineCores}
+}
+
+// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/burstable-credits-baseline-concepts.html
+var awsT3Baselines = map[string]BurstableInstance{
+	"nano":    awsBurstable(2, 0.05, BurstableCreditModeUnlimited),
+	"micro":   awsBurstable(2, 0.1, BurstableCreditModeUnlimited),
+	"small":   awsBurstable(2, 0.2, BurstableCreditModeUnlimited),
+	"medium":  awsBurstable(2, 0.2, BurstableCreditModeUnlimited),
+	"large":   awsBurstable(2, 0.3, BurstableCreditModeUnlimited),
+	"xlarge":  awsBurstable(4, 0.4, BurstableCreditModeUnlimited),
+	"2xlarge": awsBurstable(8, 0.4, BurstableCreditModeUnlimited),
+}
+
+var awsT2Baselines = map[string]BurstableInstance{
+	"nano":    awsBurstable(1, 0.05, BurstableCreditModeStandard),
+	"micro":   awsBurstable(1, 0.1, BurstableCreditModeStandard),
+	"small":   awsBurstable(1, 0.2, BurstableCreditModeStandard),
+	"medium":  awsBurstable(2, 0.2, BurstableCreditModeStandard),
+	"large":   awsBurstable(2, 0.3, BurstableCreditModeStandard),
+	"xlarge":  awsBurstable(4, 0.225, BurstableCreditModeStandard),
+	"2xlarge": awsBurstable(8, 0.17, BurstableCreditModeStandard),
+}
+
+// https://learn.microsoft.com/en-us/azure/virtual-machines/sizes-b-series-burstable
+var azureBBaselines = map[string]BurstableInstance{
+	"standard_b1ls":  azureBurstable(1, 0.05),
+	"standard_b1s":   azureBurstable(1, 0.1),
+	"standard_b1ms":  azureBurstable(1, 0.2),
+	"standard_b2s":   azureBurstable(2, 0.4),
+	"standard_b2ms":  azureBurstable(2, 0.6),
+	"standard_b4ms":  azureBurstable(4, 0.9),
+	"standard_b8ms":  azureBurstable(8, 1.35),
+	"standard_b12ms": azureBurstable(12, 2.02),
+	"standard_b16ms": azureBurstable(16, 2.7),
+	"standard_b20ms": azureBurstable(20, 3.37),
+}
+
+// BurstableBaseline returns the baseline of a burstable instance type, e.g. t3.large or Standard_B2s, and false
+// for instance types which are not burstable.
+func BurstableBaseline(instanceType string) (BurstableInstance, bool) {
+	instanceType = strings.ToLower(instanceType)
+	if b, ok := azureBBaselines[instanceType]; ok {
+		return b, true
+	}
+	parts := strings.SplitN(instanceType, ".", 2)
+	if len(parts) != 2 {
+		return BurstableInstance{}, false
+	}
+	switch parts[0] {
+	case "t3", "t3a", "t4g":
+		b, ok := awsT3Baselines[parts[1]]
+		return b, ok
+	case "t2":
+		b, ok := awsT2Baselines[parts[1]]
+		return b, ok
+	}
+	return BurstableInstance{}, false
+}
+
+// BurstableCreditPrice returns the surplus credit price per vCPU-hour of a burstable instance, overridden by the
+// burstableCreditPrice custom pricing if set. Instances in standard mode, by default or as set by the
+// burstableCreditMode custom pricing, are throttled rather than billed, so their price is zero.
+func BurstableCreditPrice(b BurstableInstance, cp *CustomPricing) float64 {
+	mode := b.CreditMode
+	if cp != nil && cp.BurstableCreditMode != "" {
+		switch m := strings.ToLower(cp.BurstableCreditMode); m {
+		case BurstableCreditModeUnlimited, BurstableCreditModeStandard:
+			if mode != "" {
+				mode = m
+			}
+		default:
+			log.DedupedWarningf(5, "BurstableCreditPrice: unknown credit mode \"%s\"", cp.BurstableCreditMode)
+		}
+	}
+	if mode == BurstableCreditModeStandard {
+		return 0
+	}
+
+	if cp != nil && cp.BurstableCreditPrice != "" {
+		price, err := strconv.ParseFloat(cp.BurstableCreditPrice, 64)
+		if err == nil {
+			return price
+		}
+		log.DedupedWarningf(5, "BurstableCreditPrice: failed to parse \"%s\": %s", cp.BurstableCreditPrice, err)
+	}
+	return b.SurplusCreditPrice
+}
+
+// SurplusCreditHours estimates the vCPU-hours of surplus credits a burstable node spent over a window, given its
+// CPU usage in cores sampled every step over the BurstableAccrualPeriod before the window, prior, and over the
+// window itself, usage. Credits are earned at the baseline and spent at the usage, banking up to a day of baseline
+// credits; credits spent while the bank is empty are surplus, and are paid back by credits earned later in the
+// window.
+//
+// The bank at the start of the window is modeled from the prior usage: as credits older than the accrual period
+// expire, a day of usage sets the balance regardless of what came before it. Surplus credits spent before the
+// window are billed to the windows in which they were spent, so the bank starts no lower than empty. Nodes without
+// prior usage, e.g. nodes launched during the window, start with an empty bank, as unlimited instances launch
+// without credits.
+func SurplusCreditHours(baselineCores float64, prior, usage []float64, step time.Duration) float64 {
+	balance := math.Max(0, creditBalance(baselineCores, 0, prior, step))
+	return math.Max(0, -creditBalance(baselineCores, balance, usage, step))
+}
+
+// creditBalance returns the credit balance, in vCPU-hours, of a burstable node with the given balance after the
+// given usage, which is negative if the node spent surplus credits.
+func creditBalance(baselineCores, balance float64, usage []float64, step time.Duration) float64 {
+	maxBalance := baselineCores * burstableMaxAccrualHours
+	hours := step.Hours()
+	for _, cores := range usage {
+		if math.IsNaN(cores) {
+			continue
+		}
+		balance = math.Min(balance+(baselineCores-cores)*hours, maxBalance)
+	}
+	return balance
+}
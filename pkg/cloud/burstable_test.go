@@ -0,0 +1,197 @@
+package cloud
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// burstableUsageCurve returns a usage curve of the given cores held for the given number of samples
+func burstableUsageCurve(segments ...[2]float64) []float64 {
+	var usage []float64
+	for _, seg := range segments {
+		for i := 0; i < int(seg[1]); i++ {
+			usage = append(usage, seg[0])
+		}
+	}
+	return usage
+}
+
+func TestSurplusCreditHours(t *testing.T) {
+	cases := []struct {
+		name     string
+		baseline float64
+		prior    []float64
+		usage    []float64
+		step     time.Duration
+		expected float64
+	}{
+		{
+			name:     "usage below baseline spends no surplus",
+			baseline: 0.6,
+			usage:    burstableUsageCurve([2]float64{0.3, 60}),
+			step:     time.Minute,
+			expected: 0,
+		},
+		{
+			name:     "sustained usage above baseline",
+			baseline: 0.6,
+			usage:    burstableUsageCurve([2]float64{1.6, 60}),
+			step:     time.Minute,
+			expected: 1,
+		},
+		{
+			name:     "burst paid back by a later idle period",
+			baseline: 0.6,
+			usage:    burstableUsageCurve([2]float64{1.2, 30}, [2]float64{0, 30}),
+			step:     time.Minute,
+			expected: 0,
+		},
+		{
+			name:     "burst partly paid back",
+			baseline: 0.4,
+			usage:    burstableUsageCurve([2]float64{2, 30}, [2]float64{0.2, 30}),
+			step:     time.Minute,
+			expected: 0.7,
+		},
+		{
+			name:     "credits banked before a burst",
+			baseline: 0.4,
+			usage:    burstableUsageCurve([2]float64{0, 120}, [2]float64{1.2, 60}),
+			step:     time.Minute,
+			expected: 0,
+		},
+		{
+			name:     "banked credits are capped at a day of baseline",
+			baseline: 0.5,
+			usage:    burstableUsageCurve([2]float64{0, 48}, [2]float64{2.5, 12}),
+			step:     time.Hour,
+			expected: 12,
+		},
+		{
+			name:     "credits banked before the window are spent first",
+			baseline: 0.5,
+			prior:    burstableUsageCurve([2]float64{0, 24}),
+			usage:    burstableUsageCurve([2]float64{1.5, 12}, [2]float64{2.5, 2}),
+			step:     time.Hour,
+			expected: 4,
+		},
+		{
+			name:     "surplus spent before the window is not billed in it",
+			baseline: 0.5,
+			prior:    burstableUsageCurve([2]float64{2.5, 24}),
+			usage:    burstableUsageCurve([2]float64{0.5, 12}),
+			step:     time.Hour,
+			expected: 0,
+		},
+		{
+			name:     "missing samples are skipped",
+			baseline: 0.5,
+			usage:    []float64{1.5, math.NaN(), 1.5},
+			step:     time.Hour,
+			expected: 2,
+		},
+	}
+
+	for _, test := range cases {
+		result := SurplusCreditHours(test.baseline, test.prior, test.usage, test.step)
+		if math.Abs(result-test.expected) > 1e-9 {
+			t.Errorf("Input: %s, Expected: %f, Actual: %f", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestBurstableBaseline(t *testing.T) {
+	cases := []struct {
+		instanceType string
+		expected     BurstableInstance
+		ok           bool
+	}{
+		{
+			instanceType: "t3.large",
+			expected:     BurstableInstance{VCPU: 2, BaselineCores: 0.6, SurplusCreditPrice: 0.05, CreditMode: BurstableCreditModeUnlimited},
+			ok:           true,
+		},
+		{
+			instanceType: "t4g.xlarge",
+			expected:     BurstableInstance{VCPU: 4, BaselineCores: 1.6, SurplusCreditPrice: 0.05, CreditMode: BurstableCreditModeUnlimited},
+			ok:           true,
+		},
+		{
+			instanceType: "t2.micro",
+			expected:     BurstableInstance{VCPU: 1, BaselineCores: 0.1, SurplusCreditPrice: 0.05, CreditMode: BurstableCreditModeStandard},
+			ok:           true,
+		},
+		{
+			instanceType: "Standard_B2ms",
+			expected:     BurstableInstance{VCPU: 2, BaselineCores: 0.6},
+			ok:           true,
+		},
+		{
+			instanceType: "m5.large",
+		},
+		{
+			instanceType: "t3.metal",
+		},
+	}
+
+	for _, test := range cases {
+		result, ok := BurstableBaseline(test.instanceType)
+		if ok != test.ok || math.Abs(result.BaselineCores-test.expected.BaselineCores) > 1e-9 ||
+			result.VCPU != test.expected.VCPU || result.SurplusCreditPrice != test.expected.SurplusCreditPrice ||
+			result.CreditMode != test.expected.CreditMode {
+			t.Errorf("Input: %s, Expected: %+v %t, Actual: %+v %t", test.instanceType, test.expected, test.ok, result, ok)
+		}
+	}
+
+}
+
+func TestBurstableCreditPrice(t *testing.T) {
+	cases := []struct {
+		instanceType string
+		cp           *CustomPricing
+		expected     float64
+	}{
+		{
+			instanceType: "t3.large",
+			expected:     0.05,
+		},
+		{
+			instanceType: "t3.large",
+			cp:           &CustomPricing{BurstableCreditMode: "standard"},
+			expected:     0,
+		},
+		{
+			// T2 instances launch in standard mode, which is throttled rather than billed
+			instanceType: "t2.micro",
+			expected:     0,
+		},
+		{
+			instanceType: "t2.micro",
+			cp:           &CustomPricing{BurstableCreditMode: "Unlimited"},
+			expected:     0.05,
+		},
+		{
+			instanceType: "t2.micro",
+			cp:           &CustomPricing{BurstableCreditPrice: "0.04"},
+			expected:     0,
+		},
+		{
+			instanceType: "Standard_B2s",
+			cp:           &CustomPricing{BurstableCreditPrice: "0.04"},
+			expected:     0.04,
+		},
+		{
+			instanceType: "Standard_B2s",
+			cp:           &CustomPricing{BurstableCreditMode: "unlimited"},
+			expected:     0,
+		},
+	}
+
+	for _, test := range cases {
+		b, _ := BurstableBaseline(test.instanceType)
+		if price := BurstableCreditPrice(b, test.cp); price != test.expected {
+			t.Errorf("Input: %s %+v, Expected: %f, Actual: %f", test.instanceType, test.cp, test.expected, price)
+		}
+	}
+}
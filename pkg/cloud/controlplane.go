@@ -0,0 +1,55 @@
+package cloud
+
+import (
+	"github.com/kubecost/opencost/pkg/prom"
+)
+
+// ControlPlaneNodeLabels are the role labels marking the control-plane nodes of self-managed clusters, e.g. kubeadm,
+// kops, RKE, k3s and OpenShift, by their names. A node has the role if it has any of the labels with any of the
+// label's values; labels without values match any value.
+var ControlPlaneNodeLabels = map[string][]string{
+	"node-role.kubernetes.io/control-plane": nil,
+	"node-role.kubernetes.io/controlplane":  nil,
+	"node-role.kubernetes.io/master":        nil,
+	"node-role.kubernetes.io/etcd":          nil,
+	"kubernetes.io/role":                    {"master"},
+}
+
+// ControlPlaneNodeTaints are the keys of the taints reserving the control-plane nodes of self-managed clusters for
+// control-plane components. Managed clusters, e.g. EKS, GKE and AKS, run their control planes outside of the
+// cluster, so none of their nodes match.
+var ControlPlaneNodeTaints = []string{
+	"node-role.kubernetes.io/control-plane",
+	"node-role.kubernetes.io/controlplane",
+	"node-role.kubernetes.io/master",
+	"node-role.kubernetes.io/etcd",
+}
+
+// IsControlPlaneNode returns true if the node with the given labels and taints is a control-plane node, by its role
+// labels or taints. Labels are keyed by their sanitized names.
+func IsControlPlaneNode(labels map[string]string, taints []NodeTaint) bool {
+	for name, values := range ControlPlaneNodeLabels {
+		value, ok := labels[prom.SanitizeLabelName(name)]
+		if !ok {
+			continue
+		}
+		if len(values) == 0 {
+			return true
+		}
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+	}
+
+	for _, t := range taints {
+		for _, key := range ControlPlaneNodeTaints {
+			if t.Key == key {
+				return true
+			}
+		}
+	}
+
+	return false
+}
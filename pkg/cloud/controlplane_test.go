@@ -0,0 +1,93 @@
+package cloud
+
+import (
+	"testing"
+)
+
+func TestIsControlPlaneNode(t *testing.T) {
+	cases := []struct {
+		name     string
+		labels   map[string]string
+		taints   []NodeTaint
+		expected bool
+	}{
+		{
+			name: "kubeadm control plane",
+			labels: map[string]string{
+				"kubernetes_io_hostname":                "cp-1",
+				"node_role_kubernetes_io_control_plane": "",
+			},
+			taints:   []NodeTaint{{Key: "node-role.kubernetes.io/control-plane", Effect: "NoSchedule"}},
+			expected: true,
+		},
+		{
+			name:     "kubeadm master, by taint only",
+			taints:   []NodeTaint{{Key: "node-role.kubernetes.io/master", Effect: "NoSchedule"}},
+			expected: true,
+		},
+		{
+			name: "RKE control plane",
+			labels: map[string]string{
+				"node_role_kubernetes_io_controlplane": "true",
+				"node_role_kubernetes_io_etcd":         "true",
+			},
+			taints:   []NodeTaint{{Key: "node-role.kubernetes.io/etcd", Value: "true", Effect: "NoExecute"}},
+			expected: true,
+		},
+		{
+			name:     "RKE worker",
+			labels:   map[string]string{"node_role_kubernetes_io_worker": "true"},
+			expected: false,
+		},
+		{
+			name:     "OpenShift master",
+			labels:   map[string]string{"node_role_kubernetes_io_master": ""},
+			expected: true,
+		},
+		{
+			name: "kops master on AWS",
+			labels: map[string]string{
+				"kubernetes_io_role":               "master",
+				"node_kubernetes_io_instance_type": "m5.large",
+			},
+			expected: true,
+		},
+		{
+			name: "kops node on AWS",
+			labels: map[string]string{
+				"kubernetes_io_role":               "node",
+				"node_kubernetes_io_instance_type": "m5.large",
+			},
+			expected: false,
+		},
+		{
+			name:     "EKS node",
+			labels:   map[string]string{"eks_amazonaws_com_nodegroup": "default"},
+			expected: false,
+		},
+		{
+			name:     "GKE node",
+			labels:   map[string]string{"cloud_google_com_gke_nodepool": "default-pool"},
+			expected: false,
+		},
+		{
+			name: "AKS node",
+			labels: map[string]string{
+				"kubernetes_azure_com_agentpool": "nodepool1",
+				"kubernetes_io_role":             "agent",
+			},
+			expected: false,
+		},
+		{
+			name:     "dedicated node",
+			taints:   []NodeTaint{{Key: "dedicated", Value: "ml", Effect: "NoSchedule"}},
+			expected: false,
+		},
+	}
+
+	for _, c := range cases {
+		if actual := IsControlPlaneNode(c.labels, c.taints); actual != c.expected {
+			t.Errorf("%s: expected %t; got %t", c.name, c.expected, actual)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package cloud
 import (
 	"fmt"
 	"io"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -10,11 +11,27 @@ import (
 
 	"github.com/kubecost/opencost/pkg/clustercache"
 	"github.com/kubecost/opencost/pkg/env"
+	"github.com/kubecost/opencost/pkg/log"
 	"github.com/kubecost/opencost/pkg/util/json"
 
 	v1 "k8s.io/api/core/v1"
 )
 
+// defaultGPUModelLabel is the node label set by the NVIDIA GPU feature discovery with the GPU product name
+const defaultGPUModelLabel = "nvidia.com/gpu.product"
+
+// gpuModelTokenRegex matches a GPU model token such as a100, t4, l40s or a10g
+var gpuModelTokenRegex = regexp.MustCompile(`^[a-z]+[0-9]+[a-z]*$`)
+
+// gpuVendorTokens are dropped from GPU product names before matching a model token
+var gpuVendorTokens = map[string]bool{
+	"nvidia":  true,
+	"tesla":   true,
+	"geforce": true,
+	"rtx":     true,
+	"quadro":  true,
+}
+
 type NodePrice struct {
 	CPU string
 	RAM string
@@ -28,6 +45,9 @@ type CustomProvider struct {
 	SpotLabelValue          string
 	GPULabel                string
 	GPULabelValue           string
+	GPUModelLabel           string
+	GPUModelPricing         map[string]string
+	SpotGPUModelPricing     map[string]string
 	DownloadPricingDataLock sync.RWMutex
 	Config                  *ProviderConfig
 }
@@ -37,6 +57,7 @@ type customProviderKey struct {
 	SpotLabelValue string
 	GPULabel       string
 	GPULabelValue  string
+	GPUModelLabel  string
 	Labels         map[string]string
 }
 
@@ -65,6 +86,19 @@ func (cp *CustomProvider) UpdateConfigFromConfigMap(a map[string]string) (*Custo
 }
 
 func (cp *CustomProvider) UpdateConfig(r io.Reader, updateType string) (*CustomPricing, error) {
+	c, err := cp.updateConfig(r)
+	if err != nil {
+		return nil, err
+	}
+
+	defer cp.DownloadPricingData()
+	return c, nil
+}
+
+// updateConfig applies the config updates of the given reader, without
+// downloading pricing data, so that providers which embed CustomProvider may
+// download their own.
+func (cp *CustomProvider) updateConfig(r io.Reader) (*CustomPricing, error) {
 	// Parse config updates from reader
 	a := make(map[string]interface{})
 	err := json.NewDecoder(r).Decode(&a)
@@ -94,7 +128,6 @@ func (cp *CustomProvider) UpdateConfig(r io.Reader, updateType string) (*CustomP
 		return nil, err
 	}
 
-	defer cp.DownloadPricingData()
 	return c, nil
 }
 
@@ -136,17 +169,51 @@ func (cp *CustomProvider) NodePricing(key Key) (*Node, error) {
 	if _, ok := cp.Pricing[k]; !ok {
 		k = "default"
 	}
-	if key.GPUType() != "" {
-		k += ",gpu"    // TODO: support multiple custom gpu types.
+	spot := k == "default,spot"
+	// Nodes are priced as GPU nodes when they have the configured GPU label or, as with the default config which
+	// sets no GPU label, a GPU model label
+	hasGPU := key.GPUType() != ""
+	if cpk, ok := key.(*customProviderKey); ok && cpk.GPUModel() != "" {
+		hasGPU = true
+	}
+	if hasGPU {
+		k += ",gpu"
 		gpuCount = "1" // TODO: support more than one gpu.
 	}
 
-	return &Node{
+	node := &Node{
 		VCPUCost: cp.Pricing[k].CPU,
 		RAMCost:  cp.Pricing[k].RAM,
 		GPUCost:  cp.Pricing[k].GPU,
 		GPU:      gpuCount,
-	}, nil
+	}
+	if gpuCount != "" {
+		model := ""
+		if cpk, ok := key.(*customProviderKey); ok {
+			model = NormalizeGPUModel(cpk.GPUModel())
+		}
+		node.GPUName = model
+		node.GPUPricingModel = "default"
+		if price, ok := cp.gpuModelPrice(model, spot); ok {
+			node.GPUCost = price
+			node.GPUPricingModel = model
+		}
+	}
+	return node, nil
+}
+
+// gpuModelPrice returns the configured price of the given normalized GPU model. Spot nodes only match spot
+// model prices, so that they fall back to the default spot GPU price rather than an on-demand model price.
+func (cp *CustomProvider) gpuModelPrice(model string, spot bool) (string, bool) {
+	if model == "" {
+		return "", false
+	}
+	prices := cp.GPUModelPricing
+	if spot {
+		prices = cp.SpotGPUModelPricing
+	}
+	price, ok := prices[model]
+	return price, ok
 }
 
 func (cp *CustomProvider) DownloadPricingData() error {
@@ -165,6 +232,9 @@ func (cp *CustomProvider) DownloadPricingData() error {
 	cp.SpotLabelValue = p.SpotLabelValue
 	cp.GPULabel = p.GpuLabel
 	cp.GPULabelValue = p.GpuLabelValue
+	cp.GPUModelLabel = p.GpuModelLabel
+	cp.GPUModelPricing = parseGPUModelPrices(p.GpuModelPrices)
+	cp.SpotGPUModelPricing = parseGPUModelPrices(p.SpotGpuModelPrices)
 	cp.Pricing["default"] = &NodePrice{
 		CPU: p.CPU,
 		RAM: p.RAM,
@@ -178,15 +248,71 @@ func (cp *CustomProvider) DownloadPricingData() error {
 		RAM: p.RAM,
 		GPU: p.GPU,
 	}
+	spotGPU := p.SpotGPU
+	if spotGPU == "" {
+		spotGPU = p.GPU
+	}
+	cp.Pricing["default,spot,gpu"] = &NodePrice{
+		CPU: p.SpotCPU,
+		RAM: p.SpotRAM,
+		GPU: spotGPU,
+	}
 	return nil
 }
 
+// parseGPUModelPrices parses a comma separated list of model:price pairs, e.g. "A100:2.93,nvidia-l4:0.71", into a
+// map keyed by the normalized GPU model. Malformed pairs are logged and skipped.
+func parseGPUModelPrices(s string) map[string]string {
+	prices := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return prices
+	}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, ":", 2)
+		if len(kv) != 2 {
+			log.Warnf("Invalid GPU model price \"%s\", expected model:price", pair)
+			continue
+		}
+		model := NormalizeGPUModel(kv[0])
+		price := strings.TrimSpace(kv[1])
+		if _, err := strconv.ParseFloat(price, 64); model == "" || err != nil {
+			log.Warnf("Invalid GPU model price \"%s\", expected model:price", pair)
+			continue
+		}
+		prices[model] = price
+	}
+	return prices
+}
+
+// NormalizeGPUModel reduces a GPU product name, as found in node labels, to a lowercase model such that different
+// spellings of the same GPU price the same: "NVIDIA-A100-SXM4-80GB", "nvidia-tesla-a100" and "A100" all
+// normalize to "a100". Names without a recognizable model token, e.g. "NVIDIA-GeForce-RTX-4090", are reduced to
+// their non-vendor tokens, "4090".
+func NormalizeGPUModel(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	tokens := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_' || r == ' ' || r == '.'
+	})
+	var rest []string
+	for _, t := range tokens {
+		if gpuVendorTokens[t] {
+			continue
+		}
+		if gpuModelTokenRegex.MatchString(t) {
+			return t
+		}
+		rest = append(rest, t)
+	}
+	return strings.Join(rest, "-")
+}
+
 func (cp *CustomProvider) GetKey(labels map[string]string, n *v1.Node) Key {
 	return &customProviderKey{
 		SpotLabel:      cp.SpotLabel,
 		SpotLabelValue: cp.SpotLabelValue,
 		GPULabel:       cp.GPULabel,
 		GPULabelValue:  cp.GPULabelValue,
+		GPUModelLabel:  cp.GPUModelLabel,
 		Labels:         labels,
 	}
 }
@@ -231,9 +357,10 @@ func (cp *CustomProvider) NetworkPricing() (*Network, error) {
 	}
 
 	return &Network{
-		ZoneNetworkEgressCost:     znec,
-		RegionNetworkEgressCost:   rnec,
-		InternetNetworkEgressCost: inec,
+		ZoneNetworkEgressCost:        znec,
+		RegionNetworkEgressCost:      rnec,
+		InternetNetworkEgressCost:    inec,
+		NATGatewayDataProcessingCost: natGatewayDataProcessingCost(cpricing),
 	}, nil
 }
 
@@ -284,6 +411,19 @@ func (cpk *customProviderKey) GPUType() string {
 	return ""
 }
 
+// GPUModel returns the GPU product name of the node, read from the configured GPU model label, falling back to
+// nvidia.com/gpu.product and then the value of the GPU label.
+func (cpk *customProviderKey) GPUModel() string {
+	label := cpk.GPUModelLabel
+	if label == "" {
+		label = defaultGPUModelLabel
+	}
+	if m, ok := cpk.Labels[label]; ok && m != "" {
+		return m
+	}
+	return cpk.GPUType()
+}
+
 func (cpk *customProviderKey) ID() string {
 	return ""
 }
@@ -0,0 +1,186 @@
+package cloud
+
+import (
+	"testing"
+
+	"github.com/kubecost/opencost/pkg/util/json"
+)
+
+func TestNormalizeGPUModel(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "NVIDIA-A100-SXM4-80GB",
+			expected: "a100",
+		},
+		{
+			input:    "NVIDIA-A100-SXM4-40GB-MIG-1g.5gb",
+			expected: "a100",
+		},
+		{
+			input:    "NVIDIA-H100-80GB-HBM3",
+			expected: "h100",
+		},
+		{
+			input:    "NVIDIA-L4",
+			expected: "l4",
+		},
+		{
+			input:    "NVIDIA A10G",
+			expected: "a10g",
+		},
+		{
+			input:    "NVIDIA-L40S",
+			expected: "l40s",
+		},
+		{
+			input:    "Tesla-T4",
+			expected: "t4",
+		},
+		{
+			input:    "Tesla-V100-SXM2-16GB",
+			expected: "v100",
+		},
+		{
+			input:    "nvidia-tesla-t4",
+			expected: "t4",
+		},
+		{
+			input:    "NVIDIA-RTX-A6000",
+			expected: "a6000",
+		},
+		{
+			input:    "NVIDIA-GeForce-RTX-4090",
+			expected: "4090",
+		},
+		{
+			input:    " A100 ",
+			expected: "a100",
+		},
+		{
+			input:    "",
+			expected: "",
+		},
+	}
+
+	for _, test := range cases {
+		result := NormalizeGPUModel(test.input)
+		if result != test.expected {
+			t.Errorf("Input: %s, Expected: %s, Actual: %s", test.input, test.expected, result)
+		}
+	}
+}
+
+func TestParseGPUModelPrices(t *testing.T) {
+	prices := parseGPUModelPrices("A100:2.93, nvidia-l4:0.71,Tesla-T4:0.35,bogus,h100:free")
+	expected := map[string]string{
+		"a100": "2.93",
+		"l4":   "0.71",
+		"t4":   "0.35",
+	}
+	if len(prices) != len(expected) {
+		t.Errorf("Expected %d prices, Actual: %v", len(expected), prices)
+	}
+	for model, price := range expected {
+		if prices[model] != price {
+			t.Errorf("Input: %s, Expected: %s, Actual: %s", model, price, prices[model])
+		}
+	}
+}
+
+func TestCustomPricingGPUModelPricesJSON(t *testing.T) {
+	var cp CustomPricing
+	err := json.Unmarshal([]byte(`{"gpuModelPrices": "a100:2.93", "spotGpuModelPrices": "a100:0.88"}`), &cp)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cp.GpuModelPrices != "a100:2.93" || cp.SpotGpuModelPrices != "a100:0.88" {
+		t.Errorf("Expected GPU model prices a100:2.93 and a100:0.88, Actual: %s and %s", cp.GpuModelPrices, cp.SpotGpuModelPrices)
+	}
+}
+
+func TestCustomProviderGPUModelPricing(t *testing.T) {
+	cp := &CustomProvider{
+		SpotLabel:      "node-type",
+		SpotLabelValue: "spot",
+		GPULabel:       "gpu",
+		GPULabelValue:  "true",
+		Pricing: map[string]*NodePrice{
+			"default":          {CPU: "0.03", RAM: "0.004"},
+			"default,spot":     {CPU: "0.006", RAM: "0.0008"},
+			"default,gpu":      {CPU: "0.03", RAM: "0.004", GPU: "0.95"},
+			"default,spot,gpu": {CPU: "0.006", RAM: "0.0008", GPU: "0.3"},
+		},
+		GPUModelPricing:     parseGPUModelPrices("a100:2.93,l4:0.71"),
+		SpotGPUModelPricing: parseGPUModelPrices("a100:0.88"),
+	}
+
+	cases := []struct {
+		name          string
+		labels        map[string]string
+		expectedCost  string
+		expectedName  string
+		expectedModel string
+	}{
+		{
+			name:          "on-demand model price",
+			labels:        map[string]string{"gpu": "true", defaultGPUModelLabel: "NVIDIA-A100-SXM4-80GB"},
+			expectedCost:  "2.93",
+			expectedName:  "a100",
+			expectedModel: "a100",
+		},
+		{
+			name:          "spot model price",
+			labels:        map[string]string{"gpu": "true", "node-type": "spot", defaultGPUModelLabel: "NVIDIA-A100-SXM4-80GB"},
+			expectedCost:  "0.88",
+			expectedName:  "a100",
+			expectedModel: "a100",
+		},
+		{
+			name:          "spot without spot model price falls back to spot default",
+			labels:        map[string]string{"gpu": "true", "node-type": "spot", defaultGPUModelLabel: "NVIDIA-L4"},
+			expectedCost:  "0.3",
+			expectedName:  "l4",
+			expectedModel: "default",
+		},
+		{
+			name:          "unpriced model falls back to default",
+			labels:        map[string]string{"gpu": "true", defaultGPUModelLabel: "Tesla-T4"},
+			expectedCost:  "0.95",
+			expectedName:  "t4",
+			expectedModel: "default",
+		},
+		{
+			name:          "model label without gpu label",
+			labels:        map[string]string{defaultGPUModelLabel: "NVIDIA-L4"},
+			expectedCost:  "0.71",
+			expectedName:  "l4",
+			expectedModel: "l4",
+		},
+		{
+			name:          "no gpu",
+			labels:        map[string]string{},
+			expectedCost:  "",
+			expectedName:  "",
+			expectedModel: "",
+		},
+	}
+
+	for _, test := range cases {
+		node, err := cp.NodePricing(cp.GetKey(test.labels, nil))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", test.name, err)
+		}
+		if node.GPUCost != test.expectedCost {
+			t.Errorf("%s: Expected GPU cost: %s, Actual: %s", test.name, test.expectedCost, node.GPUCost)
+		}
+		if node.GPUName != test.expectedName {
+			t.Errorf("%s: Expected GPU name: %s, Actual: %s", test.name, test.expectedName, node.GPUName)
+		}
+		if node.GPUPricingModel != test.expectedModel {
+			t.Errorf("%s: Expected GPU pricing model: %s, Actual: %s", test.name, test.expectedModel, node.GPUPricingModel)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+package cloud
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/env"
+	"github.com/kubecost/opencost/pkg/kubecost"
+	"github.com/kubecost/opencost/pkg/log"
+	"github.com/kubecost/opencost/pkg/util"
+	"github.com/kubecost/opencost/pkg/util/json"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	digitalOceanSizesURL           = "https://api.digitalocean.com/v2/sizes?per_page=200"
+	digitalOceanPricingSource      = "Sizes API"
+	digitalOceanVolumeMonthlyGB    = 0.10
+	digitalOceanLBMonthlyPerNode   = 12.0
+	digitalOceanHoursPerMonth      = 730.0
+	digitalOceanBlockStorageDriver = "dobs.csi.digitalocean.com"
+)
+
+// digitalOceanDefaultPricing is the embedded hourly price table for droplet sizes, keyed by size slug. It is
+// used until, and if, a refresh from the sizes API succeeds.
+// Source: https://www.digitalocean.com/pricing/droplets
+var digitalOceanDefaultPricing = map[string]*DigitalOceanSize{
+	"s-1vcpu-512mb-10gb": {Slug: "s-1vcpu-512mb-10gb", VCPUs: 1, Memory: 512, PriceHourly: 0.00595, PriceMonthly: 4},
+	"s-1vcpu-1gb":        {Slug: "s-1vcpu-1gb", VCPUs: 1, Memory: 1024, PriceHourly: 0.00893, PriceMonthly: 6},
+	"s-1vcpu-2gb":        {Slug: "s-1vcpu-2gb", VCPUs: 1, Memory: 2048, PriceHourly: 0.01786, PriceMonthly: 12},
+	"s-2vcpu-2gb":        {Slug: "s-2vcpu-2gb", VCPUs: 2, Memory: 2048, PriceHourly: 0.02679, PriceMonthly: 18},
+	"s-2vcpu-4gb":        {Slug: "s-2vcpu-4gb", VCPUs: 2, Memory: 4096, PriceHourly: 0.03571, PriceMonthly: 24},
+	"s-4vcpu-8gb":        {Slug: "s-4vcpu-8gb", VCPUs: 4, Memory: 8192, PriceHourly: 0.07143, PriceMonthly: 48},
+	"s-8vcpu-16gb":       {Slug: "s-8vcpu-16gb", VCPUs: 8, Memory: 16384, PriceHourly: 0.14286, PriceMonthly: 96},
+	"g-2vcpu-8gb":        {Slug: "g-2vcpu-8gb", VCPUs: 2, Memory: 8192, PriceHourly: 0.09375, PriceMonthly: 63},
+	"g-4vcpu-16gb":       {Slug: "g-4vcpu-16gb", VCPUs: 4, Memory: 16384, PriceHourly: 0.1875, PriceMonthly: 126},
+	"g-8vcpu-32gb":       {Slug: "g-8vcpu-32gb", VCPUs: 8, Memory: 32768, PriceHourly: 0.375, PriceMonthly: 252},
+	"g-16vcpu-64gb":      {Slug: "g-16vcpu-64gb", VCPUs: 16, Memory: 65536, PriceHourly: 0.75, PriceMonthly: 504},
+	"c-2":                {Slug: "c-2", VCPUs: 2, Memory: 4096, PriceHourly: 0.0625, PriceMonthly: 42},
+	"c-4":                {Slug: "c-4", VCPUs: 4, Memory: 8192, PriceHourly: 0.125, PriceMonthly: 84},
+	"c-8":                {Slug: "c-8", VCPUs: 8, Memory: 16384, PriceHourly: 0.25, PriceMonthly: 168},
+	"c-16":               {Slug: "c-16", VCPUs: 16, Memory: 32768, PriceHourly: 0.5, PriceMonthly: 336},
+	"m-2vcpu-16gb":       {Slug: "m-2vcpu-16gb", VCPUs: 2, Memory: 16384, PriceHourly: 0.125, PriceMonthly: 84},
+	"m-4vcpu-32gb":       {Slug: "m-4vcpu-32gb", VCPUs: 4, Memory: 32768, PriceHourly: 0.25, PriceMonthly: 168},
+	"m-8vcpu-64gb":       {Slug: "m-8vcpu-64gb", VCPUs: 8, Memory: 65536, PriceHourly: 0.5, PriceMonthly: 336},
+	"gpu-h100x1-80gb":    {Slug: "gpu-h100x1-80gb", VCPUs: 20, Memory: 245760, PriceHourly: 3.39, PriceMonthly: 2474.7, GPUInfo: &DigitalOceanGPUInfo{Count: 1, Model: "nvidia_h100"}},
+	"gpu-h100x8-640gb":   {Slug: "gpu-h100x8-640gb", VCPUs: 160, Memory: 1966080, PriceHourly: 23.92, PriceMonthly: 17461.6, GPUInfo: &DigitalOceanGPUInfo{Count: 8, Model: "nvidia_h100"}},
+}
+
+// List obtained from https://docs.digitalocean.com/products/platform/availability-matrix/
+var digitalOceanRegions = []string{
+	"nyc1",
+	"nyc3",
+	"sfo2",
+	"sfo3",
+	"ams3",
+	"sgp1",
+	"lon1",
+	"fra1",
+	"tor1",
+	"blr1",
+	"syd1",
+	"atl1",
+}
+
+// DigitalOceanSize is a droplet size as returned by the DigitalOcean sizes API.
+type DigitalOceanSize struct {
+	Slug         string               `json:"slug"`
+	Memory       int                  `json:"memory"`
+	VCPUs        int                  `json:"vcpus"`
+	PriceMonthly float64              `json:"price_monthly"`
+	PriceHourly  float64              `json:"price_hourly"`
+	Available    bool                 `json:"available"`
+	GPUInfo      *DigitalOceanGPUInfo `json:"gpu_info,omitempty"`
+}
+
+// DigitalOceanGPUInfo describes the GPUs attached to a droplet size.
+type DigitalOceanGPUInfo struct {
+	Count int    `json:"count"`
+	Model string `json:"model"`
+}
+
+// DigitalOceanSizesResponse is a page of the DigitalOcean sizes API.
+// https://docs.digitalocean.com/reference/api/api-reference/#operation/sizes_list
+type DigitalOceanSizesResponse struct {
+	Sizes []*DigitalOceanSize `json:"sizes"`
+	Links struct {
+		Pages struct {
+			Next string `json:"next"`
+		} `json:"pages"`
+	} `json:"links"`
+}
+
+// DigitalOcean is the Provider implementation for DigitalOcean Kubernetes (DOKS). Droplet prices come from an
+// embedded price table which is refreshed from the sizes API when an access token is configured.
+type DigitalOcean struct {
+	*CustomProvider
+	Sizes                   map[string]*DigitalOceanSize
+	PricingError            error
+	DownloadPricingDataLock sync.RWMutex
+	clusterRegion           string
+	// sizesURL overrides the sizes API url. It is only set in tests.
+	sizesURL   string
+	httpClient *http.Client
+}
+
+type digitalOceanKey struct {
+	Labels     map[string]string
+	ProviderID string
+	gpuType    string
+}
+
+// Features returns the droplet size slug of the node.
+func (k *digitalOceanKey) Features() string {
+	slug, _ := util.GetInstanceType(k.Labels)
+	return slug
+}
+
+// GPUType returns the GPU model for GPU droplets, as resolved from the provider's sizes when the key was created.
+func (k *digitalOceanKey) GPUType() string {
+	return k.gpuType
+}
+
+// ID returns the droplet id parsed from the provider ID.
+func (k *digitalOceanKey) ID() string {
+	return parseDigitalOceanDropletID(k.ProviderID)
+}
+
+type digitalOceanPVKey struct {
+	Labels        map[string]string
+	StorageClass  string
+	DefaultRegion string
+	ProviderID    string
+}
+
+func (key *digitalOceanPVKey) ID() string {
+	return key.ProviderID
+}
+
+func (key *digitalOceanPVKey) GetStorageClass() string {
+	return key.StorageClass
+}
+
+// Features returns the region of the volume. Block storage is priced the same for every volume.
+func (key *digitalOceanPVKey) Features() string {
+	if region, ok := util.GetRegion(key.Labels); ok {
+		return region
+	}
+	return key.DefaultRegion
+}
+
+func (do *DigitalOcean) GetKey(labels map[string]string, n *v1.Node) Key {
+	key := &digitalOceanKey{
+		Labels: labels,
+	}
+	if n != nil {
+		key.ProviderID = n.Spec.ProviderID
+	}
+
+	do.DownloadPricingDataLock.RLock()
+	defer do.DownloadPricingDataLock.RUnlock()
+	if size, ok := do.Sizes[key.Features()]; ok && size.GPUInfo != nil {
+		key.gpuType = size.GPUInfo.Model
+	}
+	return key
+}
+
+func (do *DigitalOcean) GetPVKey(pv *v1.PersistentVolume, parameters map[string]string, defaultRegion string) PVKey {
+	providerID := ""
+	if pv.Spec.CSI != nil && pv.Spec.CSI.Driver == digitalOceanBlockStorageDriver {
+		providerID = pv.Spec.CSI.VolumeHandle
+	}
+	return &digitalOceanPVKey{
+		Labels:        pv.Labels,
+		StorageClass:  pv.Spec.StorageClassName,
+		DefaultRegion: defaultRegion,
+		ProviderID:    providerID,
+	}
+}
+
+// UpdateConfigFromConfigMap updates the config from the given ConfigMap data and reloads the droplet prices.
+func (do *DigitalOcean) UpdateConfigFromConfigMap(a map[string]string) (*CustomPricing, error) {
+	c, err := do.Config.UpdateFromMap(a)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := do.DownloadPricingData(); err != nil {
+		log.Errorf("DigitalOcean: failed to reload droplet prices after a config update: %s", err)
+	}
+	return c, nil
+}
+
+// UpdateConfig updates the config from the given JSON and reloads the droplet prices.
+func (do *DigitalOcean) UpdateConfig(r io.Reader, updateType string) (*CustomPricing, error) {
+	c, err := do.CustomProvider.updateConfig(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := do.DownloadPricingData(); err != nil {
+		log.Errorf("DigitalOcean: failed to reload droplet prices after a config update: %s", err)
+	}
+	return c, nil
+}
+
+// DownloadPricingData loads the embedded price table and, when DIGITALOCEAN_ACCESS_TOKEN is set, refreshes it
+// from the sizes API. A failed refresh keeps the embedded prices.
+func (do *DigitalOcean) DownloadPricingData() error {
+	do.DownloadPricingDataLock.Lock()
+	defer do.DownloadPricingDataLock.Unlock()
+
+	sizes := make(map[string]*DigitalOceanSize, len(digitalOceanDefaultPricing))
+	for slug, size := range digitalOceanDefaultPricing {
+		sizes[slug] = size
+	}
+
+	token := env.GetDigitalOceanAccessToken()
+	if token == "" {
+		log.Infof("DigitalOcean: %s is not set, using embedded droplet prices", env.DigitalOceanAccessTokenEnvVar)
+		do.Sizes = sizes
+		do.PricingError = nil
+		return nil
+	}
+
+	fetched, err := do.fetchSizes(token)
+	if err != nil {
+		log.Warnf("DigitalOcean: failed to refresh droplet prices, using embedded prices: %s", err)
+		do.Sizes = sizes
+		do.PricingError = err
+		return nil
+	}
+	for _, size := range fetched {
+		sizes[size.Slug] = size
+	}
+
+	do.Sizes = sizes
+	do.PricingError = nil
+	return nil
+}
+
+func (do *DigitalOcean) fetchSizes(token string) ([]*DigitalOceanSize, error) {
+	client := do.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	next := do.sizesURL
+	if next == "" {
+		next = digitalOceanSizesURL
+	}
+
+	var sizes []*DigitalOceanSize
+	for next != "" {
+		req, err := http.NewRequest(http.MethodGet, next, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("bogus fetch of \"%s\": %s", next, err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading sizes response: %s", err)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, fmt.Errorf("sizes API responded with status code %d", resp.StatusCode)
+		}
+
+		page := &DigitalOceanSizesResponse{}
+		if err := json.Unmarshal(body, page); err != nil {
+			return nil, fmt.Errorf("error unmarshalling sizes response: %s", err)
+		}
+		sizes = append(sizes, page.Sizes...)
+		next = page.Links.Pages.Next
+	}
+
+	return sizes, nil
+}
+
+// AllNodePricing returns the droplet sizes stored
+func (do *DigitalOcean) AllNodePricing() (interface{}, error) {
+	do.DownloadPricingDataLock.RLock()
+	defer do.DownloadPricingDataLock.RUnlock()
+	return do.Sizes, nil
+}
+
+// NodePricing returns the hourly droplet price for a node, falling back to the configured default prices for
+// unknown sizes.
+func (do *DigitalOcean) NodePricing(key Key) (*Node, error) {
+	do.DownloadPricingDataLock.RLock()
+	defer do.DownloadPricingDataLock.RUnlock()
+
+	if size, ok := do.Sizes[key.Features()]; ok {
+		node := &Node{
+			Cost:         fmt.Sprintf("%f", size.PriceHourly),
+			VCPU:         strconv.Itoa(size.VCPUs),
+			RAM:          fmt.Sprintf("%dMiB", size.Memory),
+			InstanceType: size.Slug,
+			ProviderID:   key.ID(),
+			UsageType:    "ondemand",
+			PricingType:  Api,
+		}
+		if size.GPUInfo != nil && size.GPUInfo.Count > 0 {
+			node.GPU = strconv.Itoa(size.GPUInfo.Count)
+			node.GPUName = size.GPUInfo.Model
+		}
+		return node, nil
+	}
+
+	log.DedupedWarningf(5, "no pricing data found for droplet size %s", key.Features())
+	c, err := do.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("No default pricing data available")
+	}
+	return &Node{
+		VCPUCost:         c.CPU,
+		RAMCost:          c.RAM,
+		UsesBaseCPUPrice: true,
+		PricingType:      DefaultPrices,
+	}, nil
+}
+
+// PVPricing returns the hourly GiB price of block storage volumes, which is the same in every region.
+func (do *DigitalOcean) PVPricing(pvk PVKey) (*PV, error) {
+	return &PV{
+		Cost:   fmt.Sprintf("%f", digitalOceanVolumeMonthlyGB/digitalOceanHoursPerMonth),
+		Class:  pvk.GetStorageClass(),
+		Region: pvk.Features(),
+	}, nil
+}
+
+// LoadBalancerPricing returns the hourly price of a single node regular load balancer. The configured
+// firstFiveForwardingRulesCost takes precedence over the list price.
+func (do *DigitalOcean) LoadBalancerPricing() (*LoadBalancer, error) {
+	cost := digitalOceanLBMonthlyPerNode / digitalOceanHoursPerMonth
+	cpricing, err := do.GetConfig()
+	if err == nil && cpricing.FirstFiveForwardingRulesCost != "" {
+		if c, err := strconv.ParseFloat(cpricing.FirstFiveForwardingRulesCost, 64); err == nil {
+			cost = c
+		}
+	}
+	return &LoadBalancer{
+		Cost: cost,
+	}, nil
+}
+
+func (do *DigitalOcean) ClusterInfo() (map[string]string, error) {
+	c, err := do.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string)
+	m["name"] = "DigitalOcean Cluster #1"
+	if c.ClusterName != "" {
+		m["name"] = c.ClusterName
+	}
+	m["provider"] = kubecost.DigitalOceanProvider
+	m["region"] = do.clusterRegion
+	m["provisioner"] = "DOKS"
+	m["remoteReadEnabled"] = strconv.FormatBool(env.IsRemoteEnabled())
+	m["id"] = env.GetClusterID()
+	return m, nil
+}
+
+func (do *DigitalOcean) GetManagementPlatform() (string, error) {
+	return "doks", nil
+}
+
+// PricingSourceStatus returns the status of the sizes API refresh
+func (do *DigitalOcean) PricingSourceStatus() map[string]*PricingSource {
+	do.DownloadPricingDataLock.RLock()
+	defer do.DownloadPricingDataLock.RUnlock()
+
+	errMsg := ""
+	if do.PricingError != nil {
+		errMsg = do.PricingError.Error()
+	}
+	return map[string]*PricingSource{
+		digitalOceanPricingSource: {
+			Name:      digitalOceanPricingSource,
+			Enabled:   env.GetDigitalOceanAccessToken() != "",
+			Available: do.PricingError == nil && len(do.Sizes) > 0,
+			Error:     errMsg,
+		},
+	}
+}
+
+func (do *DigitalOcean) Regions() []string {
+	return digitalOceanRegions
+}
+
+// parseDigitalOceanDropletID returns the droplet id from a provider ID of the form digitalocean://<droplet-id>,
+// or an empty string if the provider ID is not a DigitalOcean one.
+func parseDigitalOceanDropletID(id string) string {
+	if !strings.HasPrefix(id, "digitalocean://") {
+		return ""
+	}
+	return strings.TrimPrefix(id, "digitalocean://")
+}
@@ -0,0 +1,230 @@
+package cloud
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kubecost/opencost/pkg/config"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Recorded pages of the DigitalOcean sizes API, trimmed to the fields used for pricing.
+const (
+	digitalOceanSizesPage1 = `{
+		"sizes": [
+			{"slug": "s-2vcpu-4gb", "memory": 4096, "vcpus": 2, "disk": 80, "transfer": 4.0, "price_monthly": 24.0, "price_hourly": 0.03571, "available": true, "description": "Basic"},
+			{"slug": "so-2vcpu-16gb", "memory": 16384, "vcpus": 2, "disk": 300, "transfer": 4.0, "price_monthly": 131.0, "price_hourly": 0.19494, "available": true, "description": "Storage-Optimized"}
+		],
+		"links": {"pages": {"next": "%s/v2/sizes?page=2&per_page=2"}},
+		"meta": {"total": 4}
+	}`
+	digitalOceanSizesPage2 = `{
+		"sizes": [
+			{"slug": "gpu-h100x1-80gb", "memory": 245760, "vcpus": 20, "disk": 720, "transfer": 15.0, "price_monthly": 2604.0, "price_hourly": 3.5676, "available": true, "description": "H100 GPU - 1X", "gpu_info": {"count": 1, "model": "nvidia_h100"}},
+			{"slug": "gpu-l40sx1-48gb", "memory": 65536, "vcpus": 8, "disk": 500, "transfer": 10.0, "price_monthly": 1168.0, "price_hourly": 1.57, "available": true, "description": "L40S GPU - 1X", "gpu_info": {"count": 1, "model": "nvidia_l40s"}}
+		],
+		"links": {"pages": {}},
+		"meta": {"total": 4}
+	}`
+)
+
+func TestParseDigitalOceanDropletID(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "digitalocean://283419734",
+			expected: "283419734",
+		},
+		{
+			input:    "aws:///us-east-2a/i-0fea4fd46592d050b",
+			expected: "",
+		},
+		{
+			input:    "",
+			expected: "",
+		},
+	}
+
+	for _, test := range cases {
+		result := parseDigitalOceanDropletID(test.input)
+		if result != test.expected {
+			t.Errorf("Input: %s, Expected: %s, Actual: %s", test.input, test.expected, result)
+		}
+	}
+}
+
+func newTestDigitalOcean(t *testing.T) *DigitalOcean {
+	t.Setenv("CONFIG_PATH", "../../configs/")
+	confMan := config.NewConfigFileManager(&config.ConfigFileManagerOpts{
+		LocalConfigPath: "./",
+	})
+	return &DigitalOcean{
+		CustomProvider: &CustomProvider{
+			Config: NewProviderConfig(confMan, "digitalocean.json"),
+		},
+	}
+}
+
+func TestDigitalOceanEmbeddedPricing(t *testing.T) {
+	t.Setenv("DIGITALOCEAN_ACCESS_TOKEN", "")
+	do := newTestDigitalOcean(t)
+	if err := do.DownloadPricingData(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	n := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				v1.LabelTopologyRegion:             "nyc1",
+				"node.kubernetes.io/instance-type": "s-4vcpu-8gb",
+			},
+		},
+		Spec: v1.NodeSpec{ProviderID: "digitalocean://283419734"},
+	}
+	node, err := do.NodePricing(do.GetKey(n.Labels, n))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if node.Cost != "0.071430" || node.ProviderID != "283419734" || node.PricingType != Api {
+		t.Errorf("Unexpected node pricing: %+v", node)
+	}
+
+	n.Labels["node.kubernetes.io/instance-type"] = "s-unknown"
+	node, err = do.NodePricing(do.GetKey(n.Labels, n))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if node.PricingType != DefaultPrices || node.VCPUCost == "" {
+		t.Errorf("Expected default pricing for an unknown size, got: %+v", node)
+	}
+
+	pv := &v1.PersistentVolume{
+		Spec: v1.PersistentVolumeSpec{
+			StorageClassName: "do-block-storage",
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{Driver: digitalOceanBlockStorageDriver, VolumeHandle: "8f4e8a4c-1b2c-11ee-9f6e-0a58ac14a1b2"},
+			},
+		},
+	}
+	pvKey := do.GetPVKey(pv, nil, "nyc1")
+	pvPrice, _ := do.PVPricing(pvKey)
+	if pvPrice.Cost != "0.000137" || pvKey.ID() != "8f4e8a4c-1b2c-11ee-9f6e-0a58ac14a1b2" {
+		t.Errorf("Unexpected pv pricing: %+v", pvPrice)
+	}
+
+	lb, _ := do.LoadBalancerPricing()
+	if fmt.Sprintf("%.5f", lb.Cost) != "0.01644" {
+		t.Errorf("Unexpected load balancer cost: %f", lb.Cost)
+	}
+}
+
+func TestDigitalOceanRefreshPricing(t *testing.T) {
+	t.Setenv("DIGITALOCEAN_ACCESS_TOKEN", "dop_v1_test")
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer dop_v1_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(digitalOceanSizesPage2))
+			return
+		}
+		w.Write([]byte(fmt.Sprintf(digitalOceanSizesPage1, server.URL)))
+	}))
+	defer server.Close()
+
+	do := newTestDigitalOcean(t)
+	do.sizesURL = server.URL + "/v2/sizes?per_page=2"
+	if err := do.DownloadPricingData(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if do.PricingError != nil {
+		t.Fatalf("Unexpected pricing error: %s", do.PricingError)
+	}
+
+	cases := []struct {
+		slug         string
+		expectedCost string
+		expectedGPU  string
+		expectedType string
+	}{
+		{slug: "so-2vcpu-16gb", expectedCost: "0.194940"},
+		{slug: "gpu-h100x1-80gb", expectedCost: "3.567600", expectedGPU: "1", expectedType: "nvidia_h100"},
+		// not in the embedded table, so the GPU type must come from the API
+		{slug: "gpu-l40sx1-48gb", expectedCost: "1.570000", expectedGPU: "1", expectedType: "nvidia_l40s"},
+		// not returned by the API, so the embedded price is kept
+		{slug: "c-2", expectedCost: "0.062500"},
+	}
+	for _, test := range cases {
+		labels := map[string]string{"node.kubernetes.io/instance-type": test.slug}
+		key := do.GetKey(labels, nil)
+		if key.GPUType() != test.expectedType {
+			t.Errorf("%s: Expected GPU type %q, Actual: %q", test.slug, test.expectedType, key.GPUType())
+		}
+		node, err := do.NodePricing(key)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if node.Cost != test.expectedCost || node.GPU != test.expectedGPU {
+			t.Errorf("%s: Expected cost %s and gpu %q, got %+v", test.slug, test.expectedCost, test.expectedGPU, node)
+		}
+	}
+
+	do.sizesURL = server.URL + "/unauthorized"
+	t.Setenv("DIGITALOCEAN_ACCESS_TOKEN", "bad")
+	do.DownloadPricingData()
+	if do.PricingError == nil {
+		t.Errorf("Expected a pricing error for a failed refresh")
+	}
+	if len(do.Sizes) != len(digitalOceanDefaultPricing) {
+		t.Errorf("Expected embedded prices after a failed refresh, got %d sizes", len(do.Sizes))
+	}
+}
+
+func TestDigitalOceanUpdateConfig(t *testing.T) {
+	t.Setenv("DIGITALOCEAN_ACCESS_TOKEN", "")
+	t.Setenv("CONFIG_PATH", "/")
+	confMan := config.NewConfigFileManager(&config.ConfigFileManagerOpts{
+		LocalConfigPath: t.TempDir(),
+	})
+	do := &DigitalOcean{
+		CustomProvider: &CustomProvider{
+			Config: NewProviderConfig(confMan, "digitalocean.json"),
+		},
+	}
+
+	labels := map[string]string{"node.kubernetes.io/instance-type": "s-4vcpu-8gb"}
+	updates := map[string]func() error{
+		"UpdateConfig": func() error {
+			_, err := do.UpdateConfig(strings.NewReader(`{"CPU":"0.05"}`), "")
+			return err
+		},
+		"UpdateConfigFromConfigMap": func() error {
+			_, err := do.UpdateConfigFromConfigMap(map[string]string{"CPU": "0.05"})
+			return err
+		},
+	}
+	for name, update := range updates {
+		do.Sizes = nil
+		if err := update(); err != nil {
+			t.Fatalf("%s: Unexpected error: %s", name, err)
+		}
+
+		node, err := do.NodePricing(do.GetKey(labels, nil))
+		if err != nil {
+			t.Fatalf("%s: Unexpected error: %s", name, err)
+		}
+		if node.Cost != "0.071430" || node.PricingType != Api {
+			t.Errorf("%s: Expected droplet prices to be reloaded, got: %+v", name, node)
+		}
+	}
+}
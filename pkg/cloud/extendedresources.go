@@ -0,0 +1,99 @@
+package cloud
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kubecost/opencost/pkg/prom"
+)
+
+// nonExtendedResources are the sanitized names of the native resources of containers, which are priced by the
+// resource costs of their nodes rather than as extended resources.
+var nonExtendedResources = []string{"cpu", "memory", "pods", "storage", "ephemeral_storage", "attachable_volumes_.*"}
+
+// ExtendedResources prices the hugepages and extended resources, e.g. FPGAs and SR-IOV virtual functions, which
+// containers request. Resources with a configured price cost that price per unit, or per GiB for hugepages. Any other
+// hugepages cost the node's RAM price, as they are reserved from the node's RAM capacity, which no other container
+// can request. Any other extended resource is free, as its cost is already part of the node's CPU and RAM costs, and
+// charging it again would charge more than the node costs.
+type ExtendedResources struct {
+	prices map[string]float64
+}
+
+// NewExtendedResources returns the extended resource prices of the CustomPricing.
+func NewExtendedResources(cp *CustomPricing) (*ExtendedResources, error) {
+	er := &ExtendedResources{
+		prices: map[string]float64{},
+	}
+	if cp == nil {
+		return er, nil
+	}
+
+	for _, pair := range strings.Split(cp.ExtendedResourcePrices, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		// Resource names may contain neither colons nor commas, so the price follows the last colon
+		i := strings.LastIndex(pair, ":")
+		if i < 0 || strings.TrimSpace(pair[:i]) == "" {
+			return nil, fmt.Errorf("invalid extendedResourcePrices entry \"%s\", expected resource:price", pair)
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(pair[i+1:]), 64)
+		if err != nil || price < 0 {
+			return nil, fmt.Errorf("invalid extendedResourcePrices entry \"%s\", expected resource:price", pair)
+		}
+		er.prices[prom.SanitizeLabelName(strings.TrimSpace(pair[:i]))] = price
+	}
+
+	return er, nil
+}
+
+// IsHugePages returns true if the given resource, sanitized as by Prometheus, is a size of hugepages.
+func IsHugePages(resource string) bool {
+	return strings.HasPrefix(resource, "hugepages_")
+}
+
+// NonExtendedResourceRegex returns a Prometheus regex matching the resources, as sanitized in the resource label of
+// kube_pod_container_resource_requests, which are not priced as extended resources: the native resources and the
+// given accelerators, which are priced as GPUs.
+func NonExtendedResourceRegex(accelerators *Accelerators) string {
+	names := append([]string{}, nonExtendedResources...)
+	if accelerators.ResourceRegex() != "" {
+		names = append(names, accelerators.ResourceRegex())
+	}
+	return strings.Join(names, "|")
+}
+
+// Price returns the configured hourly price of one unit, or one GiB of hugepages, of the given resource, sanitized as
+// by Prometheus.
+func (er *ExtendedResources) Price(resource string) (float64, bool) {
+	if er == nil {
+		return 0, false
+	}
+	price, ok := er.prices[resource]
+	return price, ok
+}
+
+// Cost returns the cost of the given average request of a resource, sanitized as by Prometheus, over the given hours,
+// on a node with the given hourly price per GiB of RAM. Hugepages are requested in bytes.
+func (er *ExtendedResources) Cost(resource string, request, hours, ramCostPerGiBHr float64) float64 {
+	if request <= 0 || hours <= 0 {
+		return 0
+	}
+
+	units := request
+	if IsHugePages(resource) {
+		units = request / 1024 / 1024 / 1024
+	}
+
+	if price, ok := er.Price(resource); ok {
+		return units * price * hours
+	}
+
+	if IsHugePages(resource) {
+		return units * ramCostPerGiBHr * hours
+	}
+
+	return 0
+}
@@ -0,0 +1,72 @@
+package cloud
+
+import (
+	"testing"
+
+	"github.com/kubecost/opencost/pkg/util"
+)
+
+func TestNewExtendedResources(t *testing.T) {
+	er, err := NewExtendedResources(&CustomPricing{
+		ExtendedResourcePrices: "xilinx.com/fpga:0.95, hugepages-1Gi:0.01,intel_com_sriov_netdevice:0.05",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for resource, expected := range map[string]float64{
+		"xilinx_com_fpga":           0.95,
+		"hugepages_1Gi":             0.01,
+		"intel_com_sriov_netdevice": 0.05,
+	} {
+		if price, ok := er.Price(resource); !ok || price != expected {
+			t.Errorf("%s: expected price %f; got %f", resource, expected, price)
+		}
+	}
+	if _, ok := er.Price("hugepages_2Mi"); ok {
+		t.Errorf("hugepages_2Mi: expected no price")
+	}
+
+	for _, invalid := range []string{"xilinx.com/fpga", "xilinx.com/fpga:", ":0.95", "xilinx.com/fpga:-1"} {
+		if _, err := NewExtendedResources(&CustomPricing{ExtendedResourcePrices: invalid}); err == nil {
+			t.Errorf("%s: expected error", invalid)
+		}
+	}
+}
+
+func TestExtendedResources_Cost(t *testing.T) {
+	er, err := NewExtendedResources(&CustomPricing{ExtendedResourcePrices: "xilinx.com/fpga:0.95,hugepages-1Gi:0.02"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	gib := 1024.0 * 1024.0 * 1024.0
+
+	cases := []struct {
+		name     string
+		resource string
+		request  float64
+		expected float64
+	}{
+		{name: "priced per unit", resource: "xilinx_com_fpga", request: 2, expected: 2 * 0.95 * 10},
+		{name: "priced hugepages per GiB", resource: "hugepages_1Gi", request: 4 * gib, expected: 4 * 0.02 * 10},
+		{name: "hugepages at the RAM price", resource: "hugepages_2Mi", request: 0.5 * gib, expected: 0.5 * 0.004 * 10},
+		{name: "unpriced extended resource", resource: "intel_com_sriov_netdevice", request: 2, expected: 0},
+		{name: "no request", resource: "xilinx_com_fpga", request: 0, expected: 0},
+	}
+
+	for _, c := range cases {
+		actual := er.Cost(c.resource, c.request, 10, 0.004)
+		if !util.IsApproximately(actual, c.expected) {
+			t.Errorf("%s: expected %f; got %f", c.name, c.expected, actual)
+		}
+	}
+}
+
+func TestNonExtendedResourceRegex(t *testing.T) {
+	accelerators, _ := NewAccelerators(nil)
+	expected := "cpu|memory|pods|storage|ephemeral_storage|attachable_volumes_.*|nvidia_com_gpu|amd_com_gpu|gpu_intel_com_i915|google_com_tpu|aws_amazon_com_neuron"
+	if actual := NonExtendedResourceRegex(accelerators); actual != expected {
+		t.Errorf("expected %s; got %s", expected, actual)
+	}
+}
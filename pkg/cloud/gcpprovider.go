@@ -32,6 +32,9 @@ import (
 )
 
 const GKE_GPU_TAG = "cloud.google.com/gke-accelerator"
+
+// GKE_TPU_TAG labels GKE nodes with the type of their TPUs, e.g. "tpu-v5-lite-podslice"
+const GKE_TPU_TAG = "cloud.google.com/gke-tpu-accelerator"
 const BigqueryUpdateType = "bigqueryupdate"
 
 // List obtained by installing the `gcloud` CLI tool,
@@ -95,6 +98,8 @@ type GCP struct {
 	clusterProjectId        string
 	clusterRegion           string
 	clusterProvisioner      string
+	clusterAutopilot        bool
+	autopilotPricing        map[string]map[AutopilotComputeClass]*AutopilotPrices
 	*CustomProvider
 }
 
@@ -180,6 +185,9 @@ func (gcp *GCP) GetManagementPlatform() (string, error) {
 
 	if len(nodes) > 0 {
 		n := nodes[0]
+		if IsAutopilotNode(n.Name) {
+			return "gke-autopilot", nil
+		}
 		version := n.Status.NodeInfo.KubeletVersion
 		if strings.Contains(version, "gke") {
 			return "gke", nil
@@ -331,6 +339,9 @@ func (gcp *GCP) ClusterInfo() (map[string]string, error) {
 	m["project"] = gcp.clusterProjectId
 	m["region"] = gcp.clusterRegion
 	m["provisioner"] = gcp.clusterProvisioner
+	if gcp.clusterAutopilot {
+		m["provisioner"] = "GKE Autopilot"
+	}
 	m["id"] = env.GetClusterID()
 	m["remoteReadEnabled"] = strconv.FormatBool(remoteEnabled)
 	return m, nil
@@ -604,6 +615,11 @@ func (gcp *GCP) parsePage(r io.Reader, inputKeys map[string]Key, pvKeys map[stri
 					}
 				}
 
+				tpuType := parseGCPTPUSKU(product.Description)
+				if tpuType != "" {
+					log.Debug("TPU type found: " + tpuType)
+				}
+
 				candidateKeys := []string{}
 				if gcp.ValidPricingKeys == nil {
 					gcp.ValidPricingKeys = make(map[string]bool)
@@ -660,6 +676,50 @@ func (gcp *GCP) parsePage(r io.Reader, inputKeys map[string]Key, pvKeys map[stri
 								}
 							}
 						}
+					} else if tpuType != "" {
+						lastRateIndex := len(product.PricingInfo[0].PricingExpression.TieredRates) - 1
+						var units, nanos float64
+						if lastRateIndex > -1 && len(product.PricingInfo) > 0 {
+							unitPrice := product.PricingInfo[0].PricingExpression.TieredRates[lastRateIndex].UnitPrice
+							// TPUs cost more than a dollar per hour, so their prices have whole units
+							units, _ = strconv.ParseFloat(unitPrice.Units, 64)
+							nanos = unitPrice.Nanos
+						} else {
+							continue
+						}
+						hourlyPrice := units + nanos*math.Pow10(-9)
+
+						for k, key := range inputKeys {
+							gk, ok := key.(*gcpKey)
+							if !ok || gk.TPUType() != tpuType+","+usageType || region != strings.Split(k, ",")[0] {
+								continue
+							}
+							log.Infof("Matched TPU to node in region \"%s\"", region)
+							log.Debugf("PRODUCT DESCRIPTION: %s", product.Description)
+							matchedKey := key.Features()
+							gcp.ValidPricingKeys[matchedKey] = true
+							if pl, ok := gcpPricingList[matchedKey]; ok {
+								pl.Node.GPUName = tpuType
+								pl.Node.GPUCost = strconv.FormatFloat(hourlyPrice, 'f', -1, 64)
+								pl.Node.GPU = "1"
+								pl.Node.GPUPricingModel = tpuType
+								pl.Node.AcceleratorType = AcceleratorTPU
+							} else {
+								// The price of a TPU chip includes the CPU and RAM of its host VM
+								product.Node = &Node{
+									VCPUCost:        "0",
+									RAMCost:         "0",
+									GPUName:         tpuType,
+									GPUCost:         strconv.FormatFloat(hourlyPrice, 'f', -1, 64),
+									GPU:             "1",
+									GPUPricingModel: tpuType,
+									AcceleratorType: AcceleratorTPU,
+									UsageType:       usageType,
+								}
+								gcpPricingList[matchedKey] = product
+							}
+							log.Infof("Added data for " + matchedKey)
+						}
 					} else {
 						_, ok := inputKeys[candidateKey]
 						_, ok2 := inputKeys[candidateKeyGPU]
@@ -807,6 +867,8 @@ func (gcp *GCP) parsePages(inputKeys map[string]Key, pvKeys map[string]PVKey) (m
 						val.Node.GPUCost = v.Node.GPUCost
 						val.Node.GPU = v.Node.GPU
 						val.Node.GPUName = v.Node.GPUName
+						val.Node.GPUPricingModel = v.Node.GPUPricingModel
+						val.Node.AcceleratorType = v.Node.AcceleratorType
 					}
 				}
 				if val.PV != nil {
@@ -860,6 +922,11 @@ func (gcp *GCP) DownloadPricingData() error {
 		if r != "" {
 			defaultRegion = r
 		}
+		if IsAutopilotNode(n.Name) {
+			// Autopilot bills pod requests, which are priced from the Autopilot price list
+			gcp.clusterAutopilot = true
+			continue
+		}
 		key := gcp.GetKey(labels, n)
 		inputkeys[key.Features()] = key
 	}
@@ -907,9 +974,48 @@ func (gcp *GCP) DownloadPricingData() error {
 		return err
 	}
 	gcp.Pricing = pages
+
+	if gcp.clusterAutopilot {
+		autopilotPricing, err := gcp.parseAutopilotPages()
+		if err != nil {
+			log.Warnf("Failed to download Autopilot pricing, using the built-in prices: %s", err.Error())
+		} else {
+			gcp.autopilotPricing = autopilotPricing
+		}
+	}
 	return nil
 }
 
+// parseAutopilotPages reads the Autopilot pod request prices of every region from the Kubernetes Engine billing
+// catalog.
+func (gcp *GCP) parseAutopilotPages() (map[string]map[AutopilotComputeClass]*AutopilotPrices, error) {
+	c, err := gcp.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	baseURL := "https://cloudbilling.googleapis.com/v1/services/" + gkeBillingServiceID + "/skus?key=" + gcp.APIKey + "&currencyCode=" + c.CurrencyCode
+	prices := make(map[string]map[AutopilotComputeClass]*AutopilotPrices)
+	token := ""
+	for {
+		url := baseURL
+		if token != "" {
+			url = url + "&pageToken=" + token
+		}
+		resp, err := http.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		token, err = parseAutopilotPage(resp.Body, prices)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if token == "" {
+			return prices, nil
+		}
+	}
+}
+
 func (gcp *GCP) PVPricing(pvk PVKey) (*PV, error) {
 	gcp.DownloadPricingDataLock.RLock()
 	defer gcp.DownloadPricingDataLock.RUnlock()
@@ -941,9 +1047,10 @@ func (gcp *GCP) NetworkPricing() (*Network, error) {
 	}
 
 	return &Network{
-		ZoneNetworkEgressCost:     znec,
-		RegionNetworkEgressCost:   rnec,
-		InternetNetworkEgressCost: inec,
+		ZoneNetworkEgressCost:        znec,
+		RegionNetworkEgressCost:      rnec,
+		InternetNetworkEgressCost:    inec,
+		NATGatewayDataProcessingCost: natGatewayDataProcessingCost(cpricing),
 	}, nil
 }
 
@@ -1242,12 +1349,22 @@ func (key *pvKey) Features() string {
 
 type gcpKey struct {
 	Labels map[string]string
+	Name   string
 }
 
 func (gcp *GCP) GetKey(labels map[string]string, n *v1.Node) Key {
-	return &gcpKey{
+	key := &gcpKey{
 		Labels: labels,
 	}
+	if n != nil {
+		key.Name = n.Name
+	}
+	return key
+}
+
+// isSpot returns true if the node is a GKE spot or preemptible VM
+func (gcp *gcpKey) isSpot() bool {
+	return gcp.Labels["cloud.google.com/gke-preemptible"] == "true" || gcp.Labels[GKESpotLabel] == "true"
 }
 
 func (gcp *gcpKey) ID() string {
@@ -1268,6 +1385,57 @@ func (gcp *gcpKey) GPUType() string {
 	return ""
 }
 
+// TPUType returns the type of the node's TPUs and its usage type, as matched against TPU SKUs, or an empty string if
+// the node has no TPUs.
+func (gcp *gcpKey) TPUType() string {
+	t, ok := gcp.Labels[GKE_TPU_TAG]
+	if !ok {
+		return ""
+	}
+	tpuType := parseGKETPUAccelerator(t)
+	if tpuType == "" {
+		return ""
+	}
+	usageType := "ondemand"
+	if gcp.isSpot() {
+		usageType = "preemptible"
+	}
+	return tpuType + "," + usageType
+}
+
+var gcpTPUSKURx = regexp.MustCompile(`(?i)\bTPU[ -]?(v[0-9]+[a-z]?)( lite)?\b`)
+
+// parseGCPTPUSKU returns the TPU version priced by the SKU of the given description, e.g. "v5e" for
+// "TPU v5 Lite Pod running in Americas", or an empty string if the SKU does not price TPUs.
+func parseGCPTPUSKU(description string) string {
+	match := gcpTPUSKURx.FindStringSubmatch(description)
+	if match == nil {
+		return ""
+	}
+	version := strings.ToLower(match[1])
+	if match[2] != "" {
+		version += "e"
+	}
+	return version
+}
+
+// parseGKETPUAccelerator returns the TPU version of the given GKE TPU accelerator label value, e.g. "v5e" for
+// "tpu-v5-lite-podslice", or an empty string if the value is not a TPU.
+func parseGKETPUAccelerator(accelerator string) string {
+	accelerator = strings.ToLower(accelerator)
+	if !strings.HasPrefix(accelerator, "tpu-") {
+		return ""
+	}
+	version := strings.TrimPrefix(accelerator, "tpu-")
+	for _, suffix := range []string{"-podslice", "-device", "-slice"} {
+		version = strings.TrimSuffix(version, suffix)
+	}
+	if strings.HasSuffix(version, "-lite") {
+		version = strings.TrimSuffix(version, "-lite") + "e"
+	}
+	return version
+}
+
 func parseGCPInstanceTypeLabel(it string) string {
 	var instanceType string
 
@@ -1345,6 +1513,9 @@ func (gcp *GCP) isValidPricingKey(key Key) bool {
 
 // NodePricing returns GCP pricing data for a single node
 func (gcp *GCP) NodePricing(key Key) (*Node, error) {
+	if gk, ok := key.(*gcpKey); ok && IsAutopilotNode(gk.Name) {
+		return gcp.autopilotNode(gk)
+	}
 	if n, ok := gcp.getPricing(key); ok {
 		log.Debugf("Returning pricing for node %s: %+v from SKU %s", key, n.Node, n.Name)
 		n.Node.BaseCPUPrice = gcp.BaseCPUPrice
@@ -1365,6 +1536,49 @@ func (gcp *GCP) NodePricing(key Key) (*Node, error) {
 	return nil, fmt.Errorf("Warning: no pricing data found for %s", key)
 }
 
+// autopilotNode prices an Autopilot node with the pod request prices of its compute class, from the billing
+// catalog or else the built-in prices. Autopilot does not bill nodes, so these prices are only charged to the
+// requests of the node's pods.
+func (gcp *GCP) autopilotNode(key *gcpKey) (*Node, error) {
+	region, _ := util.GetRegion(key.Labels)
+	class := AutopilotNodeComputeClass(key.Labels)
+	gcp.DownloadPricingDataLock.RLock()
+	catalog := gcp.autopilotPricing[region][class]
+	gcp.DownloadPricingDataLock.RUnlock()
+	prices, err := mergeAutopilotPrices(region, class, catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	spot := key.isSpot()
+	usageType := "ondemand"
+	cpuCost, ramCost := prices.VCPUHourly, prices.GiBHourly
+	if spot {
+		usageType = "preemptible"
+		cpuCost, ramCost = prices.SpotVCPUHourly, prices.SpotGiBHourly
+	}
+	node := &Node{
+		VCPUCost:     fmt.Sprintf("%f", cpuCost),
+		RAMCost:      fmt.Sprintf("%f", ramCost),
+		InstanceType: AutopilotInstanceType(class),
+		Region:       region,
+		UsageType:    usageType,
+		PricingType:  Autopilot,
+	}
+	if accelerator, ok := key.Labels[GKE_GPU_TAG]; ok {
+		gpuPrices, err := AutopilotGPUPricesFor(region, accelerator)
+		if err != nil {
+			return nil, err
+		}
+		node.GPUName = accelerator
+		node.GPUCost = fmt.Sprintf("%f", gpuPrices.Hourly)
+		if spot {
+			node.GPUCost = fmt.Sprintf("%f", gpuPrices.SpotHourly)
+		}
+	}
+	return node, nil
+}
+
 func (gcp *GCP) ServiceAccountStatus() *ServiceAccountStatus {
 	return &ServiceAccountStatus{
 		Checks: []*ServiceAccountCheck{},
@@ -1390,7 +1604,7 @@ func sustainedUseDiscount(class string, defaultDiscount float64, isPreemptible b
 	}
 	discount := defaultDiscount
 	switch class {
-	case "e2", "f1", "g1":
+	case "e2", "f1", "g1", "autopilot":
 		discount = 0.0
 	case "n2", "n2d":
 		discount = 0.2
@@ -1,6 +1,9 @@
 package cloud
 
 import (
+	"math"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -65,3 +68,119 @@ func TestParseGCPProjectID(t *testing.T) {
 		}
 	}
 }
+
+func TestParseGCPTPUSKU(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "TPU v5 Lite Pod running in Americas",
+			expected: "v5e",
+		},
+		{
+			input:    "Tpu-v4 Pod running in Americas",
+			expected: "v4",
+		},
+		{
+			input:    "TPU v5p chip running in Iowa",
+			expected: "v5p",
+		},
+		{
+			input:    "Nvidia Tesla T4 GPU running in Americas",
+			expected: "",
+		},
+		{
+			input:    "N2 Instance Core running in Americas",
+			expected: "",
+		},
+	}
+
+	for _, test := range cases {
+		result := parseGCPTPUSKU(test.input)
+		if result != test.expected {
+			t.Errorf("Input: %s, Expected: %s, Actual: %s", test.input, test.expected, result)
+		}
+	}
+}
+
+func TestParseGKETPUAccelerator(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "tpu-v5-lite-podslice",
+			expected: "v5e",
+		},
+		{
+			input:    "tpu-v5-lite-device",
+			expected: "v5e",
+		},
+		{
+			input:    "tpu-v4-podslice",
+			expected: "v4",
+		},
+		{
+			input:    "tpu-v5p-slice",
+			expected: "v5p",
+		},
+		{
+			input:    "nvidia-tesla-t4",
+			expected: "",
+		},
+	}
+
+	for _, test := range cases {
+		result := parseGKETPUAccelerator(test.input)
+		if result != test.expected {
+			t.Errorf("Input: %s, Expected: %s, Actual: %s", test.input, test.expected, result)
+		}
+	}
+}
+
+func TestParsePage_TPU(t *testing.T) {
+	page := `{
+		"skus": [
+			{
+				"name": "services/6F81-5844-456A/skus/TPU-V5E",
+				"description": "TPU v5 Lite Pod running in Americas",
+				"category": {"resourceFamily": "Compute", "resourceGroup": "TPU", "usageType": "OnDemand"},
+				"serviceRegions": ["us-west4"],
+				"pricingInfo": [{"pricingExpression": {"tieredRates": [{"unitPrice": {"currencyCode": "USD", "units": "1", "nanos": 200000000}}]}}]
+			}
+		],
+		"nextPageToken": ""
+	}`
+
+	key := &gcpKey{
+		Labels: map[string]string{
+			"node.kubernetes.io/instance-type": "ct5lp-hightpu-4t",
+			"topology.kubernetes.io/region":    "us-west4",
+			GKE_TPU_TAG:                        "tpu-v5-lite-podslice",
+		},
+	}
+	inputKeys := map[string]Key{key.Features(): key}
+
+	gcp := &GCP{}
+	pricing, _, err := gcp.parsePage(strings.NewReader(page), inputKeys, map[string]PVKey{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	p, ok := pricing[key.Features()]
+	if !ok || p.Node == nil {
+		t.Fatalf("expected pricing for %s; got %v", key.Features(), pricing)
+	}
+	gpuCost, _ := strconv.ParseFloat(p.Node.GPUCost, 64)
+	if math.Abs(gpuCost-1.2) > 1e-9 || p.Node.GPUName != "v5e" || p.Node.AcceleratorType != AcceleratorTPU {
+		t.Errorf("expected v5e TPU priced at 1.2; got %+v", p.Node)
+	}
+	// The price of the TPU includes its host VM
+	if p.Node.VCPUCost != "0" || p.Node.RAMCost != "0" {
+		t.Errorf("expected no CPU or RAM cost; got %s and %s", p.Node.VCPUCost, p.Node.RAMCost)
+	}
+	if !gcp.isValidPricingKey(key) {
+		t.Errorf("expected %s to be a valid pricing key", key.Features())
+	}
+}
@@ -0,0 +1,302 @@
+package cloud
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/kubecost/opencost/pkg/log"
+	"github.com/kubecost/opencost/pkg/util/json"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// AutopilotComputeClass is the compute class an Autopilot pod is billed at
+type AutopilotComputeClass string
+
+const (
+	AutopilotGeneralPurpose AutopilotComputeClass = "general-purpose"
+	AutopilotBalanced       AutopilotComputeClass = "balanced"
+	AutopilotScaleOut       AutopilotComputeClass = "scale-out"
+	AutopilotAccelerator    AutopilotComputeClass = "accelerator"
+
+	// AutopilotComputeClassLabel is set on Autopilot nodes running pods which select a compute class
+	AutopilotComputeClassLabel = "cloud.google.com/compute-class"
+	// GKESpotLabel is set on GKE spot nodes
+	GKESpotLabel = "cloud.google.com/gke-spot"
+
+	autopilotNodePrefix     = "gk3-"
+	autopilotInstancePrefix = "autopilot-"
+	// autopilotCPUIncrement is the increment Autopilot rounds vCPU requests up to
+	autopilotCPUIncrement = 0.25
+	// autopilotDefaultRegion is the region whose published prices are used for regions which are neither in the
+	// billing catalog nor in the built-in price lists
+	autopilotDefaultRegion = "us-central1"
+	// gkeBillingServiceID is the billing catalog service of Kubernetes Engine, which lists the Autopilot SKUs
+	gkeBillingServiceID = "CCD8-9BF1-090E"
+)
+
+// autopilotResourceRules are the minimum requests and the allowed range of GiB of memory per vCPU of a compute class.
+type autopilotResourceRules struct {
+	MinCPU    float64
+	MinRAMGiB float64
+	MinRatio  float64
+	MaxRatio  float64
+}
+
+// https://cloud.google.com/kubernetes-engine/docs/concepts/autopilot-resource-requests
+var autopilotRules = map[AutopilotComputeClass]autopilotResourceRules{
+	AutopilotGeneralPurpose: {MinCPU: 0.25, MinRAMGiB: 0.5, MinRatio: 1, MaxRatio: 6.5},
+	AutopilotBalanced:       {MinCPU: 0.25, MinRAMGiB: 0.5, MinRatio: 1, MaxRatio: 8},
+	AutopilotScaleOut:       {MinCPU: 0.25, MinRAMGiB: 1, MinRatio: 4, MaxRatio: 4},
+	AutopilotAccelerator:    {MinCPU: 0.25, MinRAMGiB: 0.5, MinRatio: 1, MaxRatio: 6.5},
+}
+
+// AutopilotPrices are the hourly prices of pod resource requests in a compute class
+type AutopilotPrices struct {
+	VCPUHourly     float64 `json:"vCPUHourly"`
+	GiBHourly      float64 `json:"GiBHourly"`
+	SpotVCPUHourly float64 `json:"spotVCPUHourly"`
+	SpotGiBHourly  float64 `json:"spotGiBHourly"`
+}
+
+// AutopilotGPUPrices are the hourly prices of a GPU requested by an Autopilot pod
+type AutopilotGPUPrices struct {
+	Hourly     float64 `json:"hourly"`
+	SpotHourly float64 `json:"spotHourly"`
+}
+
+// autopilotPricing holds the published Autopilot pod prices by region and compute class. They are used when the
+// billing catalog can't be read; regions which are not listed are priced as autopilotDefaultRegion.
+var autopilotPricing = map[string]map[AutopilotComputeClass]*AutopilotPrices{
+	"us-central1": {
+		AutopilotGeneralPurpose: {VCPUHourly: 0.0445, GiBHourly: 0.0049225, SpotVCPUHourly: 0.0133, SpotGiBHourly: 0.0014767},
+		AutopilotBalanced:       {VCPUHourly: 0.0561, GiBHourly: 0.0062023, SpotVCPUHourly: 0.0168, SpotGiBHourly: 0.0018607},
+		AutopilotScaleOut:       {VCPUHourly: 0.0541, GiBHourly: 0.0073, SpotVCPUHourly: 0.0162, SpotGiBHourly: 0.00219},
+		AutopilotAccelerator:    {VCPUHourly: 0.0370, GiBHourly: 0.0041, SpotVCPUHourly: 0.0111, SpotGiBHourly: 0.00123},
+	},
+}
+
+// autopilotGPUPricing holds the published Autopilot GPU prices by region and accelerator type. Regions which are
+// not listed are priced as autopilotDefaultRegion.
+var autopilotGPUPricing = map[string]map[string]*AutopilotGPUPrices{
+	"us-central1": {
+		"nvidia-tesla-t4":   {Hourly: 0.35, SpotHourly: 0.11},
+		"nvidia-l4":         {Hourly: 0.56, SpotHourly: 0.2},
+		"nvidia-tesla-a100": {Hourly: 2.9331, SpotHourly: 1.1},
+		"nvidia-a100-80gb":  {Hourly: 3.9287, SpotHourly: 1.57},
+	},
+}
+
+// IsAutopilotNode returns true if the node is managed by GKE Autopilot, whose node pools are named gk3-<cluster>.
+func IsAutopilotNode(name string) bool {
+	return strings.HasPrefix(name, autopilotNodePrefix)
+}
+
+// AutopilotNodeComputeClass returns the compute class of the pods on an Autopilot node. Autopilot only schedules
+// pods of the same compute class on a node.
+func AutopilotNodeComputeClass(labels map[string]string) AutopilotComputeClass {
+	if _, ok := labels[GKE_GPU_TAG]; ok {
+		return AutopilotAccelerator
+	}
+	switch AutopilotComputeClass(strings.ToLower(labels[AutopilotComputeClassLabel])) {
+	case AutopilotBalanced:
+		return AutopilotBalanced
+	case AutopilotScaleOut:
+		return AutopilotScaleOut
+	}
+	return AutopilotGeneralPurpose
+}
+
+// AutopilotInstanceType returns the instance type reported for Autopilot nodes, e.g. autopilot-balanced, which
+// encodes the compute class the node's pods are billed at.
+func AutopilotInstanceType(class AutopilotComputeClass) string {
+	return autopilotInstancePrefix + string(class)
+}
+
+// ParseAutopilotInstanceType returns the compute class encoded in an Autopilot instance type, as produced by
+// AutopilotInstanceType.
+func ParseAutopilotInstanceType(instanceType string) (AutopilotComputeClass, bool) {
+	if !strings.HasPrefix(instanceType, autopilotInstancePrefix) {
+		return "", false
+	}
+	class := AutopilotComputeClass(strings.TrimPrefix(instanceType, autopilotInstancePrefix))
+	if _, ok := autopilotRules[class]; !ok {
+		return "", false
+	}
+	return class, true
+}
+
+// AutopilotBilledRequests returns the vCPU and memory bytes Autopilot bills for a pod with the given requests in a
+// compute class: requests are raised to the class minimums, vCPU is rounded up to 250m increments, and the
+// memory to vCPU ratio is brought into the range allowed by the class.
+func AutopilotBilledRequests(class AutopilotComputeClass, cpuCores, ramBytes float64) (float64, float64) {
+	rules, ok := autopilotRules[class]
+	if !ok {
+		rules = autopilotRules[AutopilotGeneralPurpose]
+	}
+
+	cpu := math.Max(cpuCores, rules.MinCPU)
+	cpu = roundUpTo(cpu, autopilotCPUIncrement)
+	ram := math.Max(ramBytes/gib, rules.MinRAMGiB)
+
+	if ram < cpu*rules.MinRatio {
+		ram = cpu * rules.MinRatio
+	}
+	if ram > cpu*rules.MaxRatio {
+		cpu = roundUpTo(ram/rules.MaxRatio, autopilotCPUIncrement)
+		// Rounding vCPU up may push a fixed ratio class below its minimum memory
+		ram = math.Max(ram, cpu*rules.MinRatio)
+	}
+	return cpu, ram * gib
+}
+
+// roundUpTo rounds v up to the nearest multiple of increment, tolerating floating point error.
+func roundUpTo(v, increment float64) float64 {
+	return math.Ceil(v/increment-1e-9) * increment
+}
+
+// AutopilotPricesFor returns the built-in pod resource prices of a compute class in a region. Regions without
+// built-in prices are priced as autopilotDefaultRegion, with a warning, rather than failing to be priced.
+func AutopilotPricesFor(region string, class AutopilotComputeClass) (*AutopilotPrices, error) {
+	regional, ok := autopilotPricing[region]
+	if !ok {
+		log.DedupedWarningf(5, "No Autopilot pricing for region \"%s\", using the prices of %s", region, autopilotDefaultRegion)
+		regional = autopilotPricing[autopilotDefaultRegion]
+	}
+	prices, ok := regional[class]
+	if !ok {
+		return nil, fmt.Errorf("no Autopilot pricing for compute class %s", class)
+	}
+	return prices, nil
+}
+
+// AutopilotGPUPricesFor returns the GPU prices of an accelerator type in a region. Regions without GPU prices are
+// priced as autopilotDefaultRegion, with a warning, rather than failing to be priced.
+func AutopilotGPUPricesFor(region, accelerator string) (*AutopilotGPUPrices, error) {
+	regional, ok := autopilotGPUPricing[region]
+	if !ok {
+		log.DedupedWarningf(5, "No Autopilot GPU pricing for region \"%s\", using the prices of %s", region, autopilotDefaultRegion)
+		regional = autopilotGPUPricing[autopilotDefaultRegion]
+	}
+	prices, ok := regional[accelerator]
+	if !ok {
+		return nil, fmt.Errorf("no Autopilot GPU pricing for accelerator %s", accelerator)
+	}
+	return prices, nil
+}
+
+// autopilotCatalogPage is a page of the Kubernetes Engine billing catalog.
+type autopilotCatalogPage struct {
+	SKUs          []*GCPPricing `json:"skus"`
+	NextPageToken string        `json:"nextPageToken"`
+}
+
+// parseAutopilotPage adds the Autopilot pod request prices of a page of the Kubernetes Engine billing catalog to
+// prices, by region and compute class, and returns the token of the next page. Prices missing from the catalog
+// are left at zero.
+func parseAutopilotPage(r io.Reader, prices map[string]map[AutopilotComputeClass]*AutopilotPrices) (string, error) {
+	page := &autopilotCatalogPage{}
+	if err := json.NewDecoder(r).Decode(page); err != nil {
+		return "", err
+	}
+	for _, sku := range page.SKUs {
+		desc := sku.Description
+		if !strings.Contains(desc, "Autopilot") || len(sku.PricingInfo) == 0 || sku.PricingInfo[0].PricingExpression == nil {
+			continue
+		}
+		expr := sku.PricingInfo[0].PricingExpression
+		if len(expr.TieredRates) == 0 || expr.TieredRates[len(expr.TieredRates)-1].UnitPrice == nil {
+			continue
+		}
+		unitPrice := expr.TieredRates[len(expr.TieredRates)-1].UnitPrice
+		units, _ := strconv.ParseFloat(unitPrice.Units, 64)
+		price := units + unitPrice.Nanos*math.Pow10(-9)
+
+		isCPU := strings.Contains(desc, "CPU")
+		isRAM := strings.Contains(desc, "Memory")
+		if !isCPU && !isRAM {
+			continue
+		}
+		if isCPU && strings.Contains(desc, "mCPU") {
+			price *= 1000
+		}
+		if isRAM && strings.HasPrefix(expr.UsageUnit, "MiBy") {
+			price *= 1024
+		}
+
+		class := AutopilotGeneralPurpose
+		switch {
+		case strings.Contains(desc, "Balanced"):
+			class = AutopilotBalanced
+		case strings.Contains(desc, "Scale-Out"):
+			class = AutopilotScaleOut
+		case strings.Contains(desc, "Accelerator"):
+			class = AutopilotAccelerator
+		}
+		spot := strings.Contains(desc, "Spot")
+
+		for _, region := range sku.ServiceRegions {
+			if _, ok := prices[region]; !ok {
+				prices[region] = make(map[AutopilotComputeClass]*AutopilotPrices)
+			}
+			p, ok := prices[region][class]
+			if !ok {
+				p = &AutopilotPrices{}
+				prices[region][class] = p
+			}
+			switch {
+			case isCPU && spot:
+				p.SpotVCPUHourly = price
+			case isCPU:
+				p.VCPUHourly = price
+			case spot:
+				p.SpotGiBHourly = price
+			default:
+				p.GiBHourly = price
+			}
+		}
+	}
+	return page.NextPageToken, nil
+}
+
+// mergeAutopilotPrices fills the prices missing from the billing catalog with the built-in prices of the region.
+func mergeAutopilotPrices(region string, class AutopilotComputeClass, catalog *AutopilotPrices) (*AutopilotPrices, error) {
+	if catalog != nil && catalog.VCPUHourly > 0 && catalog.GiBHourly > 0 && catalog.SpotVCPUHourly > 0 && catalog.SpotGiBHourly > 0 {
+		return catalog, nil
+	}
+	builtin, err := AutopilotPricesFor(region, class)
+	if catalog == nil {
+		return builtin, err
+	}
+	if err != nil {
+		builtin = &AutopilotPrices{}
+	}
+	prices := *catalog
+	if prices.VCPUHourly == 0 {
+		prices.VCPUHourly = builtin.VCPUHourly
+	}
+	if prices.GiBHourly == 0 {
+		prices.GiBHourly = builtin.GiBHourly
+	}
+	if prices.SpotVCPUHourly == 0 {
+		prices.SpotVCPUHourly = builtin.SpotVCPUHourly
+	}
+	if prices.SpotGiBHourly == 0 {
+		prices.SpotGiBHourly = builtin.SpotGiBHourly
+	}
+	return &prices, nil
+}
+
+// AutopilotPodRequests returns the vCPU cores and memory bytes requested by the containers of a pod, which
+// AutopilotBilledRequests adjusts to the requests Autopilot bills.
+func AutopilotPodRequests(pod *v1.Pod) (float64, float64) {
+	var cpu, ram float64
+	for _, c := range pod.Spec.Containers {
+		cpu += c.Resources.Requests.Cpu().AsApproximateFloat64()
+		ram += c.Resources.Requests.Memory().AsApproximateFloat64()
+	}
+	return cpu, ram
+}
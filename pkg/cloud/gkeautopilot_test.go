@@ -0,0 +1,267 @@
+package cloud
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAutopilotBilledRequests(t *testing.T) {
+	cases := []struct {
+		name        string
+		class       AutopilotComputeClass
+		cpu         float64
+		ramGiB      float64
+		expectedCPU float64
+		expectedRAM float64
+	}{
+		{
+			name:        "no requests are raised to the minimums",
+			class:       AutopilotGeneralPurpose,
+			cpu:         0,
+			ramGiB:      0,
+			expectedCPU: 0.25,
+			expectedRAM: 0.5,
+		},
+		{
+			name:        "cpu is rounded up to 250m",
+			class:       AutopilotGeneralPurpose,
+			cpu:         0.3,
+			ramGiB:      1,
+			expectedCPU: 0.5,
+			expectedRAM: 1,
+		},
+		{
+			name:        "exact requests are unchanged",
+			class:       AutopilotGeneralPurpose,
+			cpu:         1,
+			ramGiB:      4,
+			expectedCPU: 1,
+			expectedRAM: 4,
+		},
+		{
+			name:        "memory is raised to the minimum ratio",
+			class:       AutopilotGeneralPurpose,
+			cpu:         2,
+			ramGiB:      1,
+			expectedCPU: 2,
+			expectedRAM: 2,
+		},
+		{
+			name:        "cpu is raised to the maximum ratio",
+			class:       AutopilotGeneralPurpose,
+			cpu:         0.25,
+			ramGiB:      8,
+			expectedCPU: 1.25,
+			expectedRAM: 8,
+		},
+		{
+			name:        "balanced allows more memory per vCPU",
+			class:       AutopilotBalanced,
+			cpu:         1,
+			ramGiB:      8,
+			expectedCPU: 1,
+			expectedRAM: 8,
+		},
+		{
+			name:        "scale-out has a fixed ratio",
+			class:       AutopilotScaleOut,
+			cpu:         1,
+			ramGiB:      1,
+			expectedCPU: 1,
+			expectedRAM: 4,
+		},
+		{
+			name:        "scale-out cpu is raised for memory",
+			class:       AutopilotScaleOut,
+			cpu:         0.25,
+			ramGiB:      5,
+			expectedCPU: 1.25,
+			expectedRAM: 5,
+		},
+		{
+			name:        "scale-out memory follows rounded cpu",
+			class:       AutopilotScaleOut,
+			cpu:         0.25,
+			ramGiB:      4.5,
+			expectedCPU: 1.25,
+			expectedRAM: 5,
+		},
+	}
+
+	for _, test := range cases {
+		cpu, ram := AutopilotBilledRequests(test.class, test.cpu, test.ramGiB*gib)
+		if math.Abs(cpu-test.expectedCPU) > 1e-9 || math.Abs(ram/gib-test.expectedRAM) > 1e-9 {
+			t.Errorf("Input: %s, Expected: %f vCPU %f GiB, Actual: %f vCPU %f GiB", test.name, test.expectedCPU, test.expectedRAM, cpu, ram/gib)
+		}
+	}
+}
+
+func TestAutopilotNodeComputeClass(t *testing.T) {
+	cases := []struct {
+		labels   map[string]string
+		expected AutopilotComputeClass
+	}{
+		{
+			labels:   map[string]string{},
+			expected: AutopilotGeneralPurpose,
+		},
+		{
+			labels:   map[string]string{AutopilotComputeClassLabel: "Balanced"},
+			expected: AutopilotBalanced,
+		},
+		{
+			labels:   map[string]string{AutopilotComputeClassLabel: "Scale-Out"},
+			expected: AutopilotScaleOut,
+		},
+		{
+			labels:   map[string]string{GKE_GPU_TAG: "nvidia-l4"},
+			expected: AutopilotAccelerator,
+		},
+	}
+
+	for _, test := range cases {
+		result := AutopilotNodeComputeClass(test.labels)
+		if result != test.expected {
+			t.Errorf("Input: %v, Expected: %s, Actual: %s", test.labels, test.expected, result)
+		}
+		if class, ok := ParseAutopilotInstanceType(AutopilotInstanceType(result)); !ok || class != result {
+			t.Errorf("Input: %s, Expected: %s, Actual: %s", AutopilotInstanceType(result), result, class)
+		}
+	}
+}
+
+func TestGCPAutopilotNodePricing(t *testing.T) {
+	gcp := &GCP{}
+
+	cases := []struct {
+		name         string
+		labels       map[string]string
+		instanceType string
+		cpuCost      string
+		ramCost      string
+		gpuCost      string
+		usageType    string
+	}{
+		{
+			name:         "gk3-cluster-pool-1-0a1b2c3d-x1y2",
+			labels:       map[string]string{"topology.kubernetes.io/region": "us-central1"},
+			instanceType: "autopilot-general-purpose",
+			cpuCost:      "0.044500",
+			ramCost:      "0.004922",
+			usageType:    "ondemand",
+		},
+		{
+			name:         "gk3-cluster-nap-1a2b3c4d-x1y2",
+			labels:       map[string]string{"topology.kubernetes.io/region": "us-central1", AutopilotComputeClassLabel: "Balanced", GKESpotLabel: "true"},
+			instanceType: "autopilot-balanced",
+			cpuCost:      "0.016800",
+			ramCost:      "0.001861",
+			usageType:    "preemptible",
+		},
+		{
+			name:         "gk3-cluster-nap-5e6f7a8b-x1y2",
+			labels:       map[string]string{"topology.kubernetes.io/region": "us-central1", GKE_GPU_TAG: "nvidia-l4"},
+			instanceType: "autopilot-accelerator",
+			cpuCost:      "0.037000",
+			ramCost:      "0.004100",
+			gpuCost:      "0.560000",
+			usageType:    "ondemand",
+		},
+	}
+
+	for _, test := range cases {
+		n := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: test.name, Labels: test.labels}}
+		node, err := gcp.NodePricing(gcp.GetKey(n.Labels, n))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", test.name, err)
+		}
+		if node.InstanceType != test.instanceType {
+			t.Errorf("%s: Expected instance type: %s, Actual: %s", test.name, test.instanceType, node.InstanceType)
+		}
+		if node.VCPUCost != test.cpuCost || node.RAMCost != test.ramCost || node.GPUCost != test.gpuCost {
+			t.Errorf("%s: Expected costs: %s/%s/%s, Actual: %s/%s/%s", test.name, test.cpuCost, test.ramCost, test.gpuCost, node.VCPUCost, node.RAMCost, node.GPUCost)
+		}
+		if node.UsageType != test.usageType {
+			t.Errorf("%s: Expected usage type: %s, Actual: %s", test.name, test.usageType, node.UsageType)
+		}
+		if node.PricingType != Autopilot {
+			t.Errorf("%s: Expected pricing type: %s, Actual: %s", test.name, Autopilot, node.PricingType)
+		}
+	}
+
+	// Regions without prices are priced as the default region rather than failing, but unpriced accelerators
+	// still fail rather than being priced at zero
+	n := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gk3-cluster-pool-1-9f8e7d6c-x1y2", Labels: map[string]string{"topology.kubernetes.io/region": "europe-west1"}}}
+	node, err := gcp.NodePricing(gcp.GetKey(n.Labels, n))
+	if err != nil {
+		t.Fatalf("%s: unexpected error: %s", n.Name, err)
+	}
+	if node.VCPUCost != "0.044500" || node.RAMCost != "0.004922" || node.Region != "europe-west1" {
+		t.Errorf("%s: Expected default region costs 0.044500/0.004922 in europe-west1, Actual: %s/%s in %s", n.Name, node.VCPUCost, node.RAMCost, node.Region)
+	}
+
+	n = &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gk3-cluster-nap-9f8e7d6c-x1y2", Labels: map[string]string{"topology.kubernetes.io/region": "us-central1", GKE_GPU_TAG: "nvidia-h100-80gb"}}}
+	if node, err := gcp.NodePricing(gcp.GetKey(n.Labels, n)); err == nil {
+		t.Errorf("%s: Expected an error, Actual: %+v", n.Name, node)
+	}
+}
+
+func TestParseAutopilotPage(t *testing.T) {
+	page := `{
+		"skus": [
+			{
+				"description": "Autopilot Pod mCPU Requests (europe-west1)",
+				"serviceRegions": ["europe-west1"],
+				"pricingInfo": [{"pricingExpression": {"usageUnit": "h", "tieredRates": [{"unitPrice": {"units": "0", "nanos": 49000}}]}}]
+			},
+			{
+				"description": "Autopilot Pod Memory Requests (europe-west1)",
+				"serviceRegions": ["europe-west1"],
+				"pricingInfo": [{"pricingExpression": {"usageUnit": "GiBy.h", "tieredRates": [{"unitPrice": {"units": "0", "nanos": 5420000}}]}}]
+			},
+			{
+				"description": "Autopilot Balanced Pod mCPU Requests (europe-west1)",
+				"serviceRegions": ["europe-west1"],
+				"pricingInfo": [{"pricingExpression": {"usageUnit": "h", "tieredRates": [{"unitPrice": {"units": "0", "nanos": 61700}}]}}]
+			},
+			{
+				"description": "Regional Kubernetes Clusters",
+				"serviceRegions": ["global"],
+				"pricingInfo": [{"pricingExpression": {"usageUnit": "h", "tieredRates": [{"unitPrice": {"units": "0", "nanos": 100000000}}]}}]
+			}
+		],
+		"nextPageToken": "next"
+	}`
+
+	prices := make(map[string]map[AutopilotComputeClass]*AutopilotPrices)
+	token, err := parseAutopilotPage(strings.NewReader(page), prices)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if token != "next" {
+		t.Errorf("Expected next page token: next, Actual: %s", token)
+	}
+	if len(prices) != 1 {
+		t.Fatalf("Expected prices of 1 region, Actual: %v", prices)
+	}
+
+	gp := prices["europe-west1"][AutopilotGeneralPurpose]
+	if gp == nil || math.Abs(gp.VCPUHourly-0.049) > 1e-9 || math.Abs(gp.GiBHourly-0.00542) > 1e-9 {
+		t.Errorf("Expected general-purpose prices 0.049/0.00542, Actual: %+v", gp)
+	}
+
+	// Prices missing from the catalog are taken from the default region
+	merged, err := mergeAutopilotPrices("europe-west1", AutopilotBalanced, prices["europe-west1"][AutopilotBalanced])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := &AutopilotPrices{VCPUHourly: 0.0617, GiBHourly: 0.0062023, SpotVCPUHourly: 0.0168, SpotGiBHourly: 0.0018607}
+	if math.Abs(merged.VCPUHourly-expected.VCPUHourly) > 1e-9 || merged.GiBHourly != expected.GiBHourly ||
+		merged.SpotVCPUHourly != expected.SpotVCPUHourly || merged.SpotGiBHourly != expected.SpotGiBHourly {
+		t.Errorf("Expected balanced prices %+v, Actual: %+v", expected, merged)
+	}
+}
@@ -0,0 +1,201 @@
+package cloud
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kubecost/opencost/pkg/log"
+)
+
+const (
+	// SoleTenantNodeGroupLabel is set by GCP on VMs scheduled onto a sole-tenant node group
+	SoleTenantNodeGroupLabel = "compute.googleapis.com/node-group-name"
+	// SoleTenantNodeLabel is set by GCP on VMs scheduled onto a sole-tenant node, the host running the VM
+	SoleTenantNodeLabel = "compute.googleapis.com/node-name"
+)
+
+// HostGroupPrice is the configured price of each host in a group of dedicated or sole-tenant hosts.
+type HostGroupPrice struct {
+	HourlyCost float64
+	// VCPU is the vCPU capacity of a host, used to report host idle. Zero if unknown.
+	VCPU float64
+}
+
+// Host is a dedicated or sole-tenant host whose cost, including any premium, is spread across the nodes
+// running on it in proportion to their vCPUs. IdleCost is the part of HourlyCost paying for capacity which no
+// node uses; it is included in the node costs and reported so the host can be right-sized.
+type Host struct {
+	Name          string   `json:"name"`
+	Group         string   `json:"group"`
+	Nodes         []string `json:"nodes"`
+	HourlyCost    float64  `json:"hourlyCost"`
+	VCPU          float64  `json:"vCPU"`
+	AllocatedVCPU float64  `json:"allocatedVCPU"`
+	IdleCost      float64  `json:"idleHourlyCost"`
+}
+
+// ParseHostGroupPrices parses comma separated group:price or group:price:vCPUs entries, e.g.
+// "licensed-hosts:5.474:96,sole-tenant:4.2".
+func ParseHostGroupPrices(s string) (map[string]HostGroupPrice, error) {
+	prices := make(map[string]HostGroupPrice)
+	if strings.TrimSpace(s) == "" {
+		return prices, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		fields := strings.Split(strings.TrimSpace(entry), ":")
+		if len(fields) < 2 || len(fields) > 3 || fields[0] == "" {
+			return nil, fmt.Errorf("invalid host group price \"%s\", expected group:price[:vCPUs]", entry)
+		}
+		price := HostGroupPrice{}
+		var err error
+		price.HourlyCost, err = strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid host group price \"%s\": %s", entry, err)
+		}
+		if len(fields) == 3 {
+			price.VCPU, err = strconv.ParseFloat(fields[2], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid host group vCPUs \"%s\": %s", entry, err)
+			}
+		}
+		prices[fields[0]] = price
+	}
+	return prices, nil
+}
+
+// ApplyHostPricing prices the nodes running on dedicated or sole-tenant hosts, identified by the host group and
+// host labels in the CustomPricing, by spreading the cost of each host across its nodes in proportion to their
+// vCPUs. Hosts are priced from HostGroupPrices if their group is listed, otherwise as the sum of the node prices
+// the provider returned; either way HostPremium percent is added. nodeLabels is keyed by node name.
+func ApplyHostPricing(nodes map[string]*Node, nodeLabels map[string]map[string]string, cp *CustomPricing) []*Host {
+	if cp == nil {
+		return nil
+	}
+	groupLabel := cp.HostGroupLabel
+	if groupLabel == "" {
+		groupLabel = SoleTenantNodeGroupLabel
+	}
+	hostLabel := cp.HostLabel
+	if hostLabel == "" {
+		hostLabel = SoleTenantNodeLabel
+	}
+	prices, err := ParseHostGroupPrices(cp.HostGroupPrices)
+	if err != nil {
+		log.Errorf("HostGroupPrices: %s", err)
+		prices = make(map[string]HostGroupPrice)
+	}
+	premium := 0.0
+	if cp.HostPremium != "" {
+		premium, err = strconv.ParseFloat(cp.HostPremium, 64)
+		if err != nil {
+			log.Errorf("HostPremium: failed to parse \"%s\": %s", cp.HostPremium, err)
+			premium = 0.0
+		}
+	}
+
+	hostsByKey := make(map[string]*Host)
+	var keys []string
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		group, ok := nodeLabels[name][groupLabel]
+		if !ok || group == "" {
+			continue
+		}
+		// Without a host label, the whole group is treated as a single host
+		hostName := nodeLabels[name][hostLabel]
+		if hostName == "" {
+			hostName = group
+		}
+		key := group + "/" + hostName
+		host, ok := hostsByKey[key]
+		if !ok {
+			host = &Host{Name: hostName, Group: group}
+			hostsByKey[key] = host
+			keys = append(keys, key)
+		}
+		host.Nodes = append(host.Nodes, name)
+	}
+
+	hosts := make([]*Host, 0, len(keys))
+	for _, key := range keys {
+		host := hostsByKey[key]
+		vcpus := make([]float64, len(host.Nodes))
+		providerCost := 0.0
+		for i, name := range host.Nodes {
+			vcpus[i] = parseNodeFloat(nodes[name].VCPU)
+			host.AllocatedVCPU += vcpus[i]
+			providerCost += nodeHourlyCost(nodes[name])
+		}
+
+		if price, ok := prices[host.Group]; ok {
+			host.HourlyCost = price.HourlyCost
+			host.VCPU = price.VCPU
+		} else {
+			host.HourlyCost = providerCost
+		}
+		host.HourlyCost *= 1 + premium/100
+		if host.VCPU > host.AllocatedVCPU {
+			host.IdleCost = host.HourlyCost * (host.VCPU - host.AllocatedVCPU) / host.VCPU
+		}
+
+		for i, name := range host.Nodes {
+			share := 1.0 / float64(len(host.Nodes))
+			if host.AllocatedVCPU > 0 {
+				share = vcpus[i] / host.AllocatedVCPU
+			}
+			setNodeHourlyCost(nodes[name], host.HourlyCost*share)
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// nodeHourlyCost returns the total hourly cost of a node, from its resource costs if no total is set.
+func nodeHourlyCost(n *Node) float64 {
+	if n.Cost != "" {
+		if cost, err := strconv.ParseFloat(n.Cost, 64); err == nil {
+			return cost
+		}
+	}
+	return parseNodeFloat(n.VCPU)*parseNodeFloat(n.VCPUCost) +
+		parseNodeFloat(n.RAMBytes)/gib*parseNodeFloat(n.RAMCost) +
+		parseNodeFloat(n.GPU)*parseNodeFloat(n.GPUCost)
+}
+
+// setNodeHourlyCost sets the total hourly cost of a node, scaling its resource costs to match. A node without
+// resource costs has the cost assigned to its vCPUs.
+func setNodeHourlyCost(n *Node, cost float64) {
+	current := parseNodeFloat(n.VCPU)*parseNodeFloat(n.VCPUCost) +
+		parseNodeFloat(n.RAMBytes)/gib*parseNodeFloat(n.RAMCost) +
+		parseNodeFloat(n.GPU)*parseNodeFloat(n.GPUCost)
+	if current > 0 {
+		factor := cost / current
+		n.VCPUCost = fmt.Sprintf("%f", parseNodeFloat(n.VCPUCost)*factor)
+		n.RAMCost = fmt.Sprintf("%f", parseNodeFloat(n.RAMCost)*factor)
+		if n.GPUCost != "" {
+			n.GPUCost = fmt.Sprintf("%f", parseNodeFloat(n.GPUCost)*factor)
+		}
+	} else if cpu := parseNodeFloat(n.VCPU); cpu > 0 {
+		n.VCPUCost = fmt.Sprintf("%f", cost/cpu)
+		n.RAMCost = "0"
+	} else {
+		n.VCPUCost = fmt.Sprintf("%f", cost)
+		n.RAMCost = "0"
+	}
+	n.Cost = fmt.Sprintf("%f", cost)
+	n.PricingType = DedicatedHost
+}
+
+func parseNodeFloat(s string) float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
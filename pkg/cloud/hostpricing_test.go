@@ -0,0 +1,151 @@
+package cloud
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestParseHostGroupPrices(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected map[string]HostGroupPrice
+		err      bool
+	}{
+		{
+			input:    "",
+			expected: map[string]HostGroupPrice{},
+		},
+		{
+			input: "licensed-hosts:5.474:96, sole-tenant:4.2",
+			expected: map[string]HostGroupPrice{
+				"licensed-hosts": {HourlyCost: 5.474, VCPU: 96},
+				"sole-tenant":    {HourlyCost: 4.2},
+			},
+		},
+		{
+			input: "licensed-hosts",
+			err:   true,
+		},
+		{
+			input: "licensed-hosts:cheap",
+			err:   true,
+		},
+	}
+
+	for _, test := range cases {
+		result, err := ParseHostGroupPrices(test.input)
+		if test.err {
+			if err == nil {
+				t.Errorf("Input: %s, Expected: error, Actual: %v", test.input, result)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Input: %s, Expected: %v, Actual: error %s", test.input, test.expected, err)
+			continue
+		}
+		if len(result) != len(test.expected) {
+			t.Errorf("Input: %s, Expected: %v, Actual: %v", test.input, test.expected, result)
+		}
+		for group, price := range test.expected {
+			if result[group] != price {
+				t.Errorf("Input: %s, Expected: %v, Actual: %v", test.input, price, result[group])
+			}
+		}
+	}
+}
+
+func TestApplyHostPricing(t *testing.T) {
+	nodes := map[string]*Node{
+		// Two VMs on a configured dedicated host with 16 vCPUs
+		"licensed-1": {VCPU: "4", VCPUCost: "0.04", RAMBytes: "17179869184", RAMCost: "0.005"},
+		"licensed-2": {VCPU: "8", VCPUCost: "0.04", RAMBytes: "34359738368", RAMCost: "0.005"},
+		// Two VMs on separate sole-tenant nodes of an unconfigured group
+		"sole-1": {VCPU: "2", VCPUCost: "0.03", RAMBytes: "8589934592", RAMCost: "0.004"},
+		"sole-2": {VCPU: "2", Cost: "0.1"},
+		// A VM on shared tenancy
+		"shared": {VCPU: "2", VCPUCost: "0.03", RAMBytes: "8589934592", RAMCost: "0.004"},
+	}
+	nodeLabels := map[string]map[string]string{
+		"licensed-1": {"kubecost.com/host-group": "licensed", "kubecost.com/host": "h-0a1b"},
+		"licensed-2": {"kubecost.com/host-group": "licensed", "kubecost.com/host": "h-0a1b"},
+		"sole-1":     {"kubecost.com/host-group": "sole", "kubecost.com/host": "sole-node-1"},
+		"sole-2":     {"kubecost.com/host-group": "sole", "kubecost.com/host": "sole-node-2"},
+		"shared":     {},
+	}
+	cp := &CustomPricing{
+		HostGroupLabel:  "kubecost.com/host-group",
+		HostLabel:       "kubecost.com/host",
+		HostGroupPrices: "licensed:3.2:16",
+		HostPremium:     "10",
+	}
+
+	hosts := ApplyHostPricing(nodes, nodeLabels, cp)
+	if len(hosts) != 3 {
+		t.Fatalf("Expected 3 hosts, Actual: %d", len(hosts))
+	}
+
+	licensed := hosts[0]
+	if licensed.Name != "h-0a1b" || licensed.Group != "licensed" || len(licensed.Nodes) != 2 {
+		t.Errorf("Expected host h-0a1b in group licensed with 2 nodes, Actual: %+v", licensed)
+	}
+	expectHourly(t, "licensed host", licensed.HourlyCost, 3.52)
+	expectHourly(t, "licensed host allocated vCPUs", licensed.AllocatedVCPU, 12)
+	expectHourly(t, "licensed host idle", licensed.IdleCost, 0.88)
+
+	// The host cost is spread by vCPU, keeping each node's CPU to RAM price ratio
+	expectHourly(t, "licensed-1", nodeHourlyCost(nodes["licensed-1"]), 3.52/3)
+	expectHourly(t, "licensed-2", nodeHourlyCost(nodes["licensed-2"]), 3.52*2/3)
+	cpuCost, _ := strconv.ParseFloat(nodes["licensed-1"].VCPUCost, 64)
+	ramCost, _ := strconv.ParseFloat(nodes["licensed-1"].RAMCost, 64)
+	if math.Abs(cpuCost/ramCost-8) > 1e-3 {
+		t.Errorf("licensed-1: Expected cpu to ram price ratio: 8, Actual: %f", cpuCost/ramCost)
+	}
+	if nodes["licensed-1"].PricingType != DedicatedHost {
+		t.Errorf("Expected pricing type: %s, Actual: %s", DedicatedHost, nodes["licensed-1"].PricingType)
+	}
+
+	// Unconfigured hosts are priced from their nodes plus the premium, without idle
+	sole1, sole2 := hosts[1], hosts[2]
+	if sole1.Name != "sole-node-1" || sole2.Name != "sole-node-2" {
+		t.Errorf("Expected hosts sole-node-1 and sole-node-2, Actual: %s and %s", sole1.Name, sole2.Name)
+	}
+	expectHourly(t, "sole-1", nodeHourlyCost(nodes["sole-1"]), (2*0.03+8*0.004)*1.1)
+	expectHourly(t, "sole-2", nodeHourlyCost(nodes["sole-2"]), 0.1*1.1)
+	expectHourly(t, "sole-1 idle", sole1.IdleCost, 0)
+
+	// Nodes on shared tenancy are unchanged
+	if nodes["shared"].VCPUCost != "0.03" || nodes["shared"].PricingType != "" {
+		t.Errorf("Expected shared node to be unchanged, Actual: %+v", nodes["shared"])
+	}
+}
+
+func TestApplyHostPricingSoleTenantLabels(t *testing.T) {
+	nodes := map[string]*Node{
+		"gke-pool-1": {VCPU: "4", VCPUCost: "0.03", RAMBytes: "17179869184", RAMCost: "0.004"},
+		"gke-pool-2": {VCPU: "4", VCPUCost: "0.03", RAMBytes: "17179869184", RAMCost: "0.004"},
+	}
+	nodeLabels := map[string]map[string]string{
+		"gke-pool-1": {SoleTenantNodeGroupLabel: "licensing"},
+		"gke-pool-2": {SoleTenantNodeGroupLabel: "licensing"},
+	}
+
+	hosts := ApplyHostPricing(nodes, nodeLabels, &CustomPricing{HostGroupPrices: "licensing:2.0:32"})
+	if len(hosts) != 1 {
+		t.Fatalf("Expected 1 host, Actual: %d", len(hosts))
+	}
+	// Without a host label, the group is treated as a single host
+	if hosts[0].Name != "licensing" || len(hosts[0].Nodes) != 2 {
+		t.Errorf("Expected host licensing with 2 nodes, Actual: %+v", hosts[0])
+	}
+	expectHourly(t, "gke-pool-1", nodeHourlyCost(nodes["gke-pool-1"]), 1.0)
+	expectHourly(t, "licensing idle", hosts[0].IdleCost, 1.5)
+}
+
+func expectHourly(t *testing.T, name string, actual, expected float64) {
+	t.Helper()
+	if math.Abs(actual-expected) > 1e-4 {
+		t.Errorf("%s: Expected: %f, Actual: %f", name, expected, actual)
+	}
+}
@@ -0,0 +1,643 @@
+package cloud
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/env"
+	"github.com/kubecost/opencost/pkg/kubecost"
+	"github.com/kubecost/opencost/pkg/log"
+	"github.com/kubecost/opencost/pkg/util"
+	"github.com/kubecost/opencost/pkg/util/json"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	IBMInfrastructureVPC     = "vpc"
+	IBMInfrastructureClassic = "classic"
+
+	ibmGlobalCatalogURL    = "https://globalcatalog.cloud.ibm.com"
+	ibmPricingSnapshotFile = "ibm-pricing.json"
+	ibmPricingSource       = "Global Catalog"
+	ibmMachineTypeLabel    = "ibm-cloud.kubernetes.io/machine-type"
+	ibmRegionLabel         = "ibm-cloud.kubernetes.io/region"
+	ibmIaaSProviderLabel   = "ibm-cloud.kubernetes.io/iaas-provider"
+	ibmOSLabel             = "ibm-cloud.kubernetes.io/os"
+	ibmWorkerIDLabel       = "ibm-cloud.kubernetes.io/worker-id"
+	ibmOpenShiftOSLabel    = "node.openshift.io/os_id"
+	ibmInstanceHoursMetric = "INSTANCE_HOURS"
+	ibmHoursPerMonth       = 730.0
+	ibmDefaultStorageTier  = "vpc-block-general-purpose"
+)
+
+// IKS flavors are named <family>.<vcpu>x<memory>, optionally followed by a suffix such as ".encrypted"
+var ibmFlavorRegex = regexp.MustCompile(`^[a-z0-9]+\.(\d+)x(\d+)`)
+
+// ibmRegionCountries maps IBM Cloud regions to the country code used for pricing in the global catalog.
+var ibmRegionCountries = map[string]string{
+	"us-south": "USA",
+	"us-east":  "USA",
+	"ca-tor":   "CAN",
+	"br-sao":   "BRA",
+	"eu-gb":    "GBR",
+	"eu-de":    "DEU",
+	"eu-es":    "ESP",
+	"jp-tok":   "JPN",
+	"jp-osa":   "JPN",
+	"au-syd":   "AUS",
+}
+
+// List obtained by running `ibmcloud regions`
+var ibmRegions = []string{
+	"us-south",
+	"us-east",
+	"ca-tor",
+	"br-sao",
+	"eu-gb",
+	"eu-de",
+	"eu-es",
+	"jp-tok",
+	"jp-osa",
+	"au-syd",
+}
+
+// IBMPricingCatalog is an offline snapshot of the global catalog entries used to price IKS and Red Hat
+// OpenShift on IBM Cloud clusters.
+type IBMPricingCatalog struct {
+	Description string `json:"description"`
+	// Flavors maps a worker flavor, e.g. bx2.4x16, to its catalog entry and recorded pricing.
+	Flavors map[string]*IBMFlavor `json:"flavors"`
+	// Storage maps a storage tier, e.g. block-gold or vpc-block-10iops-tier, to its GB-month price.
+	Storage map[string]float64 `json:"storage"`
+	// LoadBalancerHourly is the hourly price of a VPC load balancer.
+	LoadBalancerHourly float64 `json:"loadBalancerHourly"`
+	// OpenShiftLicenseVCPUHourly is the hourly OpenShift license uplift per worker vCPU.
+	OpenShiftLicenseVCPUHourly float64 `json:"openShiftLicenseVCPUHourly"`
+}
+
+// IBMFlavor is a worker flavor in the pricing catalog.
+type IBMFlavor struct {
+	// EntryID is the global catalog entry id, used to refresh the pricing from the API. Flavors without one
+	// are looked up in the global catalog by name.
+	EntryID        string                   `json:"entryId"`
+	Infrastructure string                   `json:"infrastructure"`
+	Pricing        *IBMGlobalCatalogPricing `json:"pricing"`
+}
+
+// IBMGlobalCatalogPricing is the subset of a global catalog pricing document used for pricing.
+// https://cloud.ibm.com/apidocs/resource-catalog/global-catalog#get-pricing
+type IBMGlobalCatalogPricing struct {
+	Type    string `json:"type"`
+	Metrics []struct {
+		MetricID   string `json:"metric_id"`
+		ChargeUnit string `json:"charge_unit_name"`
+		Amounts    []struct {
+			Country  string `json:"country"`
+			Currency string `json:"currency"`
+			Prices   []struct {
+				QuantityTier int     `json:"quantity_tier"`
+				Price        float64 `json:"price"`
+			} `json:"prices"`
+		} `json:"amounts"`
+	} `json:"metrics"`
+}
+
+// HourlyPrice returns the first tier instance-hour price for the given country and currency, falling back to
+// USD in the USA when the country is not priced.
+func (p *IBMGlobalCatalogPricing) HourlyPrice(country, currency string) (float64, bool) {
+	if p == nil {
+		return 0, false
+	}
+	for _, try := range [][2]string{{country, currency}, {"USA", "USD"}} {
+		for _, metric := range p.Metrics {
+			if !strings.Contains(strings.ToUpper(metric.ChargeUnit), ibmInstanceHoursMetric) && !strings.Contains(strings.ToLower(metric.MetricID), "instance-hours") {
+				continue
+			}
+			for _, amount := range metric.Amounts {
+				if amount.Country == try[0] && amount.Currency == try[1] && len(amount.Prices) > 0 {
+					return amount.Prices[0].Price, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// IBM is the Provider implementation for IBM Cloud Kubernetes Service and Red Hat OpenShift on IBM Cloud.
+// Prices are read from an offline catalog snapshot, and refreshed from the global catalog API for the
+// flavors running in the cluster.
+type IBM struct {
+	*CustomProvider
+	Catalog                 *IBMPricingCatalog
+	PricingError            error
+	DownloadPricingDataLock sync.RWMutex
+	clusterRegion           string
+	clusterAccountId        string
+	// entryIDs caches the global catalog entry ids of flavors which the snapshot has none for
+	entryIDs map[string]string
+	// catalogURL overrides the global catalog url. It is only set in tests.
+	catalogURL string
+	httpClient *http.Client
+}
+
+type ibmKey struct {
+	Labels     map[string]string
+	ProviderID string
+}
+
+// Features returns a comma separated string of region, flavor and whether the worker runs OpenShift.
+func (k *ibmKey) Features() string {
+	platform := "kubernetes"
+	if k.isOpenShift() {
+		platform = "openshift"
+	}
+	return fmt.Sprintf("%s,%s,%s", k.Region(), k.Flavor(), platform)
+}
+
+// Flavor returns the worker flavor of the node.
+func (k *ibmKey) Flavor() string {
+	if f, ok := k.Labels[ibmMachineTypeLabel]; ok {
+		return strings.ToLower(f)
+	}
+	f, _ := util.GetInstanceType(k.Labels)
+	return strings.ToLower(f)
+}
+
+// Region returns the IBM Cloud region of the node.
+func (k *ibmKey) Region() string {
+	if r, ok := k.Labels[ibmRegionLabel]; ok {
+		return r
+	}
+	r, _ := util.GetRegion(k.Labels)
+	return r
+}
+
+// Infrastructure returns vpc or classic depending on the infrastructure provider of the worker.
+func (k *ibmKey) Infrastructure() string {
+	switch strings.ToLower(k.Labels[ibmIaaSProviderLabel]) {
+	case "softlayer", IBMInfrastructureClassic:
+		return IBMInfrastructureClassic
+	}
+	return IBMInfrastructureVPC
+}
+
+// VCPUs returns the number of vCPUs encoded in the flavor name.
+func (k *ibmKey) VCPUs() int {
+	vcpu, _ := parseIBMFlavor(k.Flavor())
+	return vcpu
+}
+
+func (k *ibmKey) isOpenShift() bool {
+	if _, ok := k.Labels[ibmOpenShiftOSLabel]; ok {
+		return true
+	}
+	os := strings.ToUpper(k.Labels[ibmOSLabel])
+	return strings.HasPrefix(os, "REDHAT") || strings.HasPrefix(os, "RHCOS") || strings.HasPrefix(os, "RHEL")
+}
+
+// GPUType returns the GPU family for GPU flavors (gx2, gx3, mg4c)
+func (k *ibmKey) GPUType() string {
+	flavor := k.Flavor()
+	if strings.HasPrefix(flavor, "gx") || strings.HasPrefix(flavor, "mg") {
+		return strings.Split(flavor, ".")[0]
+	}
+	return ""
+}
+
+// ID returns the worker id
+func (k *ibmKey) ID() string {
+	if id, ok := k.Labels[ibmWorkerIDLabel]; ok {
+		return id
+	}
+	return parseIBMWorkerID(k.ProviderID)
+}
+
+type ibmPVKey struct {
+	Labels                 map[string]string
+	StorageClass           string
+	StorageClassParameters map[string]string
+	DefaultRegion          string
+	ProviderID             string
+}
+
+func (key *ibmPVKey) ID() string {
+	return key.ProviderID
+}
+
+func (key *ibmPVKey) GetStorageClass() string {
+	return key.StorageClass
+}
+
+// Features returns the storage tier of the volume, e.g. block-gold, file-silver or vpc-block-10iops-tier.
+func (key *ibmPVKey) Features() string {
+	return ibmStorageTier(key.StorageClass, key.StorageClassParameters)
+}
+
+// ibmStorageTier resolves the catalog storage tier of an IKS storage class. Classic storage classes encode the
+// tier in the class name (ibmc-block-gold) or the "classVersion"/"type" parameters, VPC block storage classes
+// in the "profile" parameter or class name (ibmc-vpc-block-10iops-tier).
+func ibmStorageTier(storageClass string, params map[string]string) string {
+	name := strings.TrimPrefix(strings.ToLower(storageClass), "ibmc-")
+	name = strings.TrimSuffix(name, "-metro")
+	name = strings.TrimSuffix(name, "-retain")
+	if profile, ok := params["profile"]; ok && profile != "" {
+		return "vpc-block-" + strings.ToLower(profile)
+	}
+	for _, kind := range []string{"vpc-block", "block", "file"} {
+		if !strings.HasPrefix(name, kind+"-") {
+			continue
+		}
+		for _, tier := range []string{"10iops-tier", "5iops-tier", "general-purpose", "custom", "gold", "silver", "bronze"} {
+			if strings.Contains(name, tier) {
+				return kind + "-" + tier
+			}
+		}
+	}
+	return ibmDefaultStorageTier
+}
+
+func (ibm *IBM) GetKey(labels map[string]string, n *v1.Node) Key {
+	key := &ibmKey{
+		Labels: labels,
+	}
+	if n != nil {
+		key.ProviderID = n.Spec.ProviderID
+	}
+	return key
+}
+
+func (ibm *IBM) GetPVKey(pv *v1.PersistentVolume, parameters map[string]string, defaultRegion string) PVKey {
+	providerID := ""
+	if pv.Spec.CSI != nil {
+		providerID = pv.Spec.CSI.VolumeHandle
+	}
+	return &ibmPVKey{
+		Labels:                 pv.Labels,
+		StorageClass:           pv.Spec.StorageClassName,
+		StorageClassParameters: parameters,
+		DefaultRegion:          defaultRegion,
+		ProviderID:             providerID,
+	}
+}
+
+// UpdateConfigFromConfigMap updates the config from the given ConfigMap data and reloads the flavor prices.
+func (ibm *IBM) UpdateConfigFromConfigMap(a map[string]string) (*CustomPricing, error) {
+	c, err := ibm.Config.UpdateFromMap(a)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ibm.DownloadPricingData()
+	return c, nil
+}
+
+// UpdateConfig updates the config from the given JSON and reloads the flavor prices.
+func (ibm *IBM) UpdateConfig(r io.Reader, updateType string) (*CustomPricing, error) {
+	c, err := ibm.CustomProvider.updateConfig(r)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ibm.DownloadPricingData()
+	return c, nil
+}
+
+// DownloadPricingData loads the catalog snapshot, then, unless IBM_GLOBAL_CATALOG_REFRESH_ENABLED is false,
+// refreshes the pricing of the flavors in the cluster from the global catalog, by the entryId of the snapshot or
+// else the entry found by the flavor's name. Flavors which fail to refresh keep their snapshot pricing.
+func (ibm *IBM) DownloadPricingData() error {
+	ibm.DownloadPricingDataLock.Lock()
+	defer ibm.DownloadPricingDataLock.Unlock()
+
+	catalog, err := loadIBMPricingCatalog(configPathFor(ibmPricingSnapshotFile))
+	if err != nil {
+		log.Warnf("IBM: failed to load pricing catalog snapshot: %s", err)
+		ibm.PricingError = err
+		catalog = &IBMPricingCatalog{
+			Flavors: map[string]*IBMFlavor{},
+			Storage: map[string]float64{},
+		}
+	} else {
+		ibm.PricingError = nil
+	}
+
+	if env.IsIBMGlobalCatalogRefreshEnabled() && ibm.Clientset != nil {
+		flavors := make(map[string]bool)
+		for _, n := range ibm.Clientset.GetAllNodes() {
+			flavors[ibm.GetKey(n.Labels, n).(*ibmKey).Flavor()] = true
+		}
+		for flavor := range flavors {
+			f, ok := catalog.Flavors[flavor]
+			if !ok {
+				log.DedupedWarningf(5, "IBM: no pricing for flavor %s", flavor)
+				continue
+			}
+			entryID, err := ibm.entryID(flavor, f)
+			if err != nil {
+				log.DedupedWarningf(5, "IBM: no global catalog entry for flavor %s: %s", flavor, err)
+				continue
+			}
+			pricing, err := ibm.fetchPricing(entryID)
+			if err != nil {
+				log.Warnf("IBM: failed to refresh pricing for flavor %s: %s", flavor, err)
+				ibm.PricingError = err
+				continue
+			}
+			f.Pricing = pricing
+		}
+	}
+
+	ibm.Catalog = catalog
+	return nil
+}
+
+func loadIBMPricingCatalog(path string) (*IBMPricingCatalog, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	catalog := &IBMPricingCatalog{}
+	if err := json.Unmarshal(data, catalog); err != nil {
+		return nil, fmt.Errorf("error unmarshalling pricing catalog %s: %s", path, err)
+	}
+	if catalog.Flavors == nil {
+		catalog.Flavors = map[string]*IBMFlavor{}
+	}
+	if catalog.Storage == nil {
+		catalog.Storage = map[string]float64{}
+	}
+	return catalog, nil
+}
+
+// entryID returns the global catalog entry id of a flavor: its entryId in the snapshot, or else the id of the
+// catalog entry named after the flavor, e.g. bx2-4x16 for bx2.4x16. Found ids are cached.
+func (ibm *IBM) entryID(flavor string, f *IBMFlavor) (string, error) {
+	if f.EntryID != "" {
+		return f.EntryID, nil
+	}
+	if id, ok := ibm.entryIDs[flavor]; ok {
+		return id, nil
+	}
+
+	names := []string{flavor, strings.ReplaceAll(flavor, ".", "-")}
+	for _, name := range names {
+		var search ibmGlobalCatalogSearch
+		if err := ibm.getGlobalCatalog("/api/v1?q="+url.QueryEscape(name), &search); err != nil {
+			return "", err
+		}
+		for _, resource := range search.Resources {
+			if resource.ID != "" && strings.EqualFold(resource.Name, name) {
+				if ibm.entryIDs == nil {
+					ibm.entryIDs = make(map[string]string)
+				}
+				ibm.entryIDs[flavor] = resource.ID
+				return resource.ID, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no entry named %s", strings.Join(names, " or "))
+}
+
+// ibmGlobalCatalogSearch is the subset of a global catalog search result used to find entry ids.
+// https://cloud.ibm.com/apidocs/resource-catalog/global-catalog#list-catalog-entries
+type ibmGlobalCatalogSearch struct {
+	Resources []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"resources"`
+}
+
+func (ibm *IBM) fetchPricing(entryID string) (*IBMGlobalCatalogPricing, error) {
+	pricing := &IBMGlobalCatalogPricing{}
+	if err := ibm.getGlobalCatalog(fmt.Sprintf("/api/v1/%s/pricing", entryID), pricing); err != nil {
+		return nil, err
+	}
+	return pricing, nil
+}
+
+// getGlobalCatalog unmarshals the response of the global catalog to a GET of the given path into v.
+func (ibm *IBM) getGlobalCatalog(path string, v interface{}) error {
+	client := ibm.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	base := ibm.catalogURL
+	if base == "" {
+		base = ibmGlobalCatalogURL
+	}
+
+	catalogURL := base + path
+	resp, err := client.Get(catalogURL)
+	if err != nil {
+		return fmt.Errorf("bogus fetch of \"%s\": %s", catalogURL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading global catalog response: %s", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("global catalog responded with status code %d", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("error unmarshalling global catalog response: %s", err)
+	}
+	return nil
+}
+
+// AllNodePricing returns the pricing catalog
+func (ibm *IBM) AllNodePricing() (interface{}, error) {
+	ibm.DownloadPricingDataLock.RLock()
+	defer ibm.DownloadPricingDataLock.RUnlock()
+	return ibm.Catalog, nil
+}
+
+// NodePricing returns the hourly worker price including, for OpenShift workers, the per-vCPU license uplift.
+func (ibm *IBM) NodePricing(key Key) (*Node, error) {
+	ibm.DownloadPricingDataLock.RLock()
+	defer ibm.DownloadPricingDataLock.RUnlock()
+
+	ik, ok := key.(*ibmKey)
+	if !ok {
+		return nil, fmt.Errorf("ibm: NodePricing: key is of type %T", key)
+	}
+
+	c, err := ibm.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if ibm.Catalog != nil {
+		if f, ok := ibm.Catalog.Flavors[ik.Flavor()]; ok {
+			if f.Infrastructure != "" && f.Infrastructure != ik.Infrastructure() {
+				log.DedupedWarningf(5, "IBM: flavor %s is priced as %s infrastructure, but the worker runs on %s", ik.Flavor(), f.Infrastructure, ik.Infrastructure())
+			}
+			if price, ok := f.Pricing.HourlyPrice(ibmRegionCountries[ik.Region()], c.CurrencyCode); ok {
+				if ik.isOpenShift() {
+					price += ibm.Catalog.OpenShiftLicenseVCPUHourly * float64(ik.VCPUs())
+				}
+				return &Node{
+					Cost:         fmt.Sprintf("%f", price),
+					VCPU:         strconv.Itoa(ik.VCPUs()),
+					InstanceType: ik.Flavor(),
+					Region:       ik.Region(),
+					ProviderID:   ik.ID(),
+					UsageType:    "ondemand",
+					PricingType:  Api,
+				}, nil
+			}
+		}
+	}
+
+	log.DedupedWarningf(5, "no pricing data found for %s", ik.Features())
+	return &Node{
+		VCPUCost:         c.CPU,
+		RAMCost:          c.RAM,
+		UsesBaseCPUPrice: true,
+		PricingType:      DefaultPrices,
+	}, nil
+}
+
+// PVPricing returns the GB-hour price of the storage tier of the volume, falling back to the configured storage
+// price for unknown tiers.
+func (ibm *IBM) PVPricing(pvk PVKey) (*PV, error) {
+	ibm.DownloadPricingDataLock.RLock()
+	defer ibm.DownloadPricingDataLock.RUnlock()
+
+	if ibm.Catalog != nil {
+		if monthly, ok := ibm.Catalog.Storage[pvk.Features()]; ok {
+			return &PV{
+				Cost:  fmt.Sprintf("%f", monthly/ibmHoursPerMonth),
+				Class: pvk.GetStorageClass(),
+			}, nil
+		}
+	}
+	log.Debugf("Persistent Volume pricing not found for %s: %s", pvk.GetStorageClass(), pvk.Features())
+	c, err := ibm.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &PV{
+		Cost:  c.Storage,
+		Class: pvk.GetStorageClass(),
+	}, nil
+}
+
+// LoadBalancerPricing returns the hourly price of a VPC load balancer
+func (ibm *IBM) LoadBalancerPricing() (*LoadBalancer, error) {
+	ibm.DownloadPricingDataLock.RLock()
+	defer ibm.DownloadPricingDataLock.RUnlock()
+
+	if ibm.Catalog == nil || ibm.Catalog.LoadBalancerHourly == 0 {
+		return ibm.CustomProvider.LoadBalancerPricing()
+	}
+	return &LoadBalancer{
+		Cost: ibm.Catalog.LoadBalancerHourly,
+	}, nil
+}
+
+func (ibm *IBM) ClusterInfo() (map[string]string, error) {
+	c, err := ibm.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string)
+	m["name"] = "IBM Cloud Cluster #1"
+	if c.ClusterName != "" {
+		m["name"] = c.ClusterName
+	}
+	m["provider"] = kubecost.IBMProvider
+	m["account"] = ibm.clusterAccountId
+	m["region"] = ibm.clusterRegion
+	m["provisioner"] = "IKS"
+	m["remoteReadEnabled"] = strconv.FormatBool(env.IsRemoteEnabled())
+	m["id"] = env.GetClusterID()
+	return m, nil
+}
+
+func (ibm *IBM) GetManagementPlatform() (string, error) {
+	if ibm.Clientset == nil {
+		return "", nil
+	}
+	nodes := ibm.Clientset.GetAllNodes()
+	if len(nodes) > 0 {
+		if ibm.GetKey(nodes[0].Labels, nodes[0]).(*ibmKey).isOpenShift() {
+			return "roks", nil
+		}
+		return "iks", nil
+	}
+	return "", nil
+}
+
+// PricingSourceStatus returns the status of the pricing catalog
+func (ibm *IBM) PricingSourceStatus() map[string]*PricingSource {
+	ibm.DownloadPricingDataLock.RLock()
+	defer ibm.DownloadPricingDataLock.RUnlock()
+
+	errMsg := ""
+	if ibm.PricingError != nil {
+		errMsg = ibm.PricingError.Error()
+	}
+	return map[string]*PricingSource{
+		ibmPricingSource: {
+			Name:      ibmPricingSource,
+			Enabled:   true,
+			Available: ibm.Catalog != nil && len(ibm.Catalog.Flavors) > 0,
+			Error:     errMsg,
+		},
+	}
+}
+
+func (ibm *IBM) Regions() []string {
+	return ibmRegions
+}
+
+// parseIBMFlavor returns the vCPU and memory (GB) counts encoded in a flavor name such as bx2.4x16.
+func parseIBMFlavor(flavor string) (int, int) {
+	match := ibmFlavorRegex.FindStringSubmatch(strings.ToLower(flavor))
+	if len(match) != 3 {
+		return 0, 0
+	}
+	vcpu, _ := strconv.Atoi(match[1])
+	mem, _ := strconv.Atoi(match[2])
+	return vcpu, mem
+}
+
+// parseIBMAccountID returns the account id from a provider ID of the form
+// ibm://<account-id>///<cluster-id>/<worker-id>
+func parseIBMAccountID(id string) string {
+	if !strings.HasPrefix(id, "ibm://") {
+		return ""
+	}
+	return strings.Split(strings.TrimPrefix(id, "ibm://"), "/")[0]
+}
+
+// parseIBMWorkerID returns the worker id from a provider ID of the form
+// ibm://<account-id>///<cluster-id>/<worker-id>
+func parseIBMWorkerID(id string) string {
+	if !strings.HasPrefix(id, "ibm://") {
+		return ""
+	}
+	parts := strings.Split(id, "/")
+	return parts[len(parts)-1]
+}
+
+// isIBMNode returns true if the node has an IBM provider ID or IKS worker labels.
+func isIBMNode(node *v1.Node) bool {
+	if strings.HasPrefix(strings.ToLower(node.Spec.ProviderID), "ibm://") {
+		return true
+	}
+	_, ok := node.Labels[ibmMachineTypeLabel]
+	return ok
+}
@@ -0,0 +1,388 @@
+package cloud
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubecost/opencost/pkg/config"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Recorded global catalog pricing document for the bx2.4x16 VPC profile, trimmed to the fields used for pricing.
+const ibmGlobalCatalogPricingResponse = `{
+	"deployment_id": "",
+	"type": "paid",
+	"origin": "global_catalog",
+	"metrics": [
+		{
+			"metric_id": "part-is.instance-hours",
+			"charge_unit_name": "INSTANCE_HOURS_MULTI_TENANT",
+			"amounts": [
+				{"country": "USA", "currency": "USD", "prices": [{"quantity_tier": 1, "price": 0.2}]},
+				{"country": "DEU", "currency": "EUR", "prices": [{"quantity_tier": 1, "price": 0.19}]}
+			]
+		}
+	]
+}`
+
+func TestParseIBMProviderID(t *testing.T) {
+	cases := []struct {
+		input           string
+		expectedAccount string
+		expectedWorker  string
+	}{
+		{
+			input:           "ibm://fee47dd3a2d9c4e8b2f5a6c7d8e9f0a1///c2ab3cd4e5fg6hi7jk8l/kube-c2ab3cd4e5fg6hi7jk8l-mycluster-default-00000123",
+			expectedAccount: "fee47dd3a2d9c4e8b2f5a6c7d8e9f0a1",
+			expectedWorker:  "kube-c2ab3cd4e5fg6hi7jk8l-mycluster-default-00000123",
+		},
+		{
+			input:           "aws:///us-east-2a/i-0fea4fd46592d050b",
+			expectedAccount: "",
+			expectedWorker:  "",
+		},
+	}
+
+	for _, test := range cases {
+		account := parseIBMAccountID(test.input)
+		worker := parseIBMWorkerID(test.input)
+		if account != test.expectedAccount || worker != test.expectedWorker {
+			t.Errorf("Input: %s, Expected: (%s, %s), Actual: (%s, %s)", test.input, test.expectedAccount, test.expectedWorker, account, worker)
+		}
+	}
+}
+
+func TestIBMKeyFeatures(t *testing.T) {
+	cases := []struct {
+		name           string
+		labels         map[string]string
+		expected       string
+		expectedInfra  string
+		expectedVCPUs  int
+		expectedGPUTyp string
+	}{
+		{
+			name: "vpc kubernetes worker",
+			labels: map[string]string{
+				ibmMachineTypeLabel:  "bx2.4x16",
+				ibmRegionLabel:       "us-south",
+				ibmIaaSProviderLabel: "g2",
+				ibmOSLabel:           "UBUNTU_20_64",
+			},
+			expected:      "us-south,bx2.4x16,kubernetes",
+			expectedInfra: IBMInfrastructureVPC,
+			expectedVCPUs: 4,
+		},
+		{
+			name: "classic openshift worker",
+			labels: map[string]string{
+				ibmMachineTypeLabel:  "b3c.8x32.encrypted",
+				ibmRegionLabel:       "eu-de",
+				ibmIaaSProviderLabel: "softlayer",
+				ibmOSLabel:           "REDHAT_7_64",
+			},
+			expected:      "eu-de,b3c.8x32.encrypted,openshift",
+			expectedInfra: IBMInfrastructureClassic,
+			expectedVCPUs: 8,
+		},
+		{
+			name: "vpc gpu worker on rhcos",
+			labels: map[string]string{
+				ibmMachineTypeLabel: "gx2.8x64x1v100",
+				ibmRegionLabel:      "us-east",
+				ibmOpenShiftOSLabel: "rhcos",
+			},
+			expected:       "us-east,gx2.8x64x1v100,openshift",
+			expectedInfra:  IBMInfrastructureVPC,
+			expectedVCPUs:  8,
+			expectedGPUTyp: "gx2",
+		},
+	}
+
+	for _, test := range cases {
+		key := &ibmKey{Labels: test.labels}
+		if key.Features() != test.expected {
+			t.Errorf("%s: Expected features %s, Actual %s", test.name, test.expected, key.Features())
+		}
+		if key.Infrastructure() != test.expectedInfra {
+			t.Errorf("%s: Expected infrastructure %s, Actual %s", test.name, test.expectedInfra, key.Infrastructure())
+		}
+		if key.VCPUs() != test.expectedVCPUs {
+			t.Errorf("%s: Expected %d vCPUs, Actual %d", test.name, test.expectedVCPUs, key.VCPUs())
+		}
+		if key.GPUType() != test.expectedGPUTyp {
+			t.Errorf("%s: Expected GPU type %q, Actual %q", test.name, test.expectedGPUTyp, key.GPUType())
+		}
+	}
+}
+
+func TestIBMStorageTier(t *testing.T) {
+	cases := []struct {
+		storageClass string
+		params       map[string]string
+		expected     string
+	}{
+		{storageClass: "ibmc-block-gold", expected: "block-gold"},
+		{storageClass: "ibmc-block-retain-silver", expected: "block-silver"},
+		{storageClass: "ibmc-file-bronze-gid", expected: "file-bronze"},
+		{storageClass: "ibmc-vpc-block-10iops-tier", expected: "vpc-block-10iops-tier"},
+		{storageClass: "ibmc-vpc-block-metro-5iops-tier", expected: "vpc-block-5iops-tier"},
+		{storageClass: "custom-block", params: map[string]string{"profile": "general-purpose"}, expected: "vpc-block-general-purpose"},
+		{storageClass: "local-path", expected: ibmDefaultStorageTier},
+	}
+
+	for _, test := range cases {
+		result := ibmStorageTier(test.storageClass, test.params)
+		if result != test.expected {
+			t.Errorf("Input: %s, Expected: %s, Actual: %s", test.storageClass, test.expected, result)
+		}
+	}
+}
+
+func newTestIBM(t *testing.T, nodes []*v1.Node) *IBM {
+	t.Setenv("CONFIG_PATH", "../../configs/")
+	confMan := config.NewConfigFileManager(&config.ConfigFileManagerOpts{
+		LocalConfigPath: "./",
+	})
+	return &IBM{
+		CustomProvider: &CustomProvider{
+			Clientset: alibabaFakeCache{nodes: nodes},
+			Config:    NewProviderConfig(confMan, "ibm.json"),
+		},
+	}
+}
+
+func TestIBMSnapshotPricing(t *testing.T) {
+	t.Setenv("IBM_GLOBAL_CATALOG_REFRESH_ENABLED", "false")
+	ibm := newTestIBM(t, nil)
+	if err := ibm.DownloadPricingData(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cases := []struct {
+		name     string
+		labels   map[string]string
+		expected string
+	}{
+		{
+			name:     "vpc kubernetes worker",
+			labels:   map[string]string{ibmMachineTypeLabel: "bx2.4x16", ibmRegionLabel: "us-south"},
+			expected: "0.192000",
+		},
+		{
+			// 0.192 + 4 vCPU * 0.0435 OpenShift license
+			name:     "vpc openshift worker",
+			labels:   map[string]string{ibmMachineTypeLabel: "bx2.4x16", ibmRegionLabel: "us-south", ibmOSLabel: "RHCOS"},
+			expected: "0.366000",
+		},
+		{
+			// priced in EUR in Frankfurt, falls back to USD when the currency does not match
+			name:     "classic worker outside the USA",
+			labels:   map[string]string{ibmMachineTypeLabel: "b3c.4x16", ibmRegionLabel: "eu-de", ibmIaaSProviderLabel: "softlayer"},
+			expected: "0.220000",
+		},
+	}
+	for _, test := range cases {
+		node, err := ibm.NodePricing(ibm.GetKey(test.labels, nil))
+		if err != nil {
+			t.Fatalf("%s: Unexpected error: %s", test.name, err)
+		}
+		if node.Cost != test.expected {
+			t.Errorf("%s: Expected cost %s, Actual %s", test.name, test.expected, node.Cost)
+		}
+	}
+
+	node, _ := ibm.NodePricing(ibm.GetKey(map[string]string{ibmMachineTypeLabel: "zz9.1x1"}, nil))
+	if node.PricingType != DefaultPrices {
+		t.Errorf("Expected default pricing for an unknown flavor, got %+v", node)
+	}
+
+	pv := &v1.PersistentVolume{Spec: v1.PersistentVolumeSpec{StorageClassName: "ibmc-block-gold"}}
+	pvPrice, _ := ibm.PVPricing(ibm.GetPVKey(pv, nil, "us-south"))
+	if pvPrice.Cost != "0.000274" {
+		t.Errorf("Unexpected pv pricing: %+v", pvPrice)
+	}
+
+	lb, _ := ibm.LoadBalancerPricing()
+	if lb.Cost != 0.0286 {
+		t.Errorf("Unexpected load balancer pricing: %f", lb.Cost)
+	}
+}
+
+func TestIBMGlobalCatalogRefresh(t *testing.T) {
+	t.Setenv("IBM_GLOBAL_CATALOG_REFRESH_ENABLED", "true")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/bx2-4x16-entry/pricing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(ibmGlobalCatalogPricingResponse))
+	}))
+	defer server.Close()
+
+	n := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{ibmMachineTypeLabel: "bx2.4x16", ibmRegionLabel: "us-south"},
+		},
+		Spec: v1.NodeSpec{ProviderID: "ibm://fee47dd3a2d9c4e8b2f5a6c7d8e9f0a1///c2ab3cd4e5fg6hi7jk8l/kube-worker-1"},
+	}
+	ibm := newTestIBM(t, []*v1.Node{n})
+	ibm.catalogURL = server.URL
+
+	// Point the snapshot at a copy with a catalog entry for bx2.4x16
+	dir := t.TempDir()
+	catalog, err := loadIBMPricingCatalog("../../configs/" + ibmPricingSnapshotFile)
+	if err != nil {
+		t.Fatalf("Unexpected error loading snapshot: %s", err)
+	}
+	catalog.Flavors["bx2.4x16"].EntryID = "bx2-4x16-entry"
+	catalog.Flavors["bx2.8x32"].EntryID = "missing-entry"
+	data, _ := json.Marshal(catalog)
+	if err := os.WriteFile(filepath.Join(dir, ibmPricingSnapshotFile), data, 0644); err != nil {
+		t.Fatalf("Unexpected error writing snapshot: %s", err)
+	}
+	t.Setenv("CONFIG_PATH", dir)
+
+	if err := ibm.DownloadPricingData(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	node, err := ibm.NodePricing(ibm.GetKey(n.Labels, n))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if node.Cost != "0.200000" || node.ProviderID != "kube-worker-1" {
+		t.Errorf("Unexpected node pricing: %+v", node)
+	}
+
+	// flavors which are not running in the cluster are not refreshed
+	other, _ := ibm.NodePricing(ibm.GetKey(map[string]string{ibmMachineTypeLabel: "bx2.8x32", ibmRegionLabel: "us-south"}, nil))
+	if other.Cost != "0.384000" {
+		t.Errorf("Expected snapshot pricing for bx2.8x32, got %+v", other)
+	}
+
+	if _, err := ibm.fetchPricing("missing-entry"); err == nil {
+		t.Errorf("Expected an error for a missing catalog entry")
+	}
+}
+
+func TestIBMGlobalCatalogRefreshByName(t *testing.T) {
+	// The refresh is enabled by default
+	t.Setenv("IBM_GLOBAL_CATALOG_REFRESH_ENABLED", "")
+
+	var searches []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1":
+			q := r.URL.Query().Get("q")
+			searches = append(searches, q)
+			resources := `[]`
+			if q == "bx2-4x16" {
+				resources = `[{"id": "other-entry", "name": "bx2-4x16-other"}, {"id": "bx2-4x16-entry", "name": "bx2-4x16"}]`
+			}
+			w.Write([]byte(`{"offset": 0, "count": 1, "resources": ` + resources + `}`))
+		case "/api/v1/bx2-4x16-entry/pricing":
+			w.Write([]byte(ibmGlobalCatalogPricingResponse))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	nodes := []*v1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "vpc", Labels: map[string]string{ibmMachineTypeLabel: "bx2.4x16", ibmRegionLabel: "us-south"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "classic", Labels: map[string]string{ibmMachineTypeLabel: "b3c.4x16", ibmRegionLabel: "us-south"}}},
+	}
+	// The shipped snapshot
+	ibm := newTestIBM(t, nodes)
+	ibm.catalogURL = server.URL
+
+	if err := ibm.DownloadPricingData(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	node, err := ibm.NodePricing(ibm.GetKey(nodes[0].Labels, nodes[0]))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if node.Cost != "0.200000" {
+		t.Errorf("Expected bx2.4x16 to be refreshed from the catalog entry named bx2-4x16, got %+v", node)
+	}
+
+	// flavors without a catalog entry keep their snapshot pricing
+	classic, err := ibm.NodePricing(ibm.GetKey(nodes[1].Labels, nodes[1]))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if classic.Cost != "0.220000" {
+		t.Errorf("Expected snapshot pricing for b3c.4x16, got %+v", classic)
+	}
+
+	// found entry ids are cached
+	searched := len(searches)
+	if err := ibm.DownloadPricingData(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, q := range searches[searched:] {
+		if q == "bx2.4x16" || q == "bx2-4x16" {
+			t.Errorf("Expected the entry id of bx2.4x16 to be cached, searched for %s", q)
+		}
+	}
+}
+
+func TestIBMUpdateConfig(t *testing.T) {
+	t.Setenv("IBM_GLOBAL_CATALOG_REFRESH_ENABLED", "false")
+
+	// Keep the config written by the updates out of the repository
+	dir := t.TempDir()
+	data, err := os.ReadFile("../../configs/" + ibmPricingSnapshotFile)
+	if err != nil {
+		t.Fatalf("Unexpected error reading snapshot: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ibmPricingSnapshotFile), data, 0644); err != nil {
+		t.Fatalf("Unexpected error writing snapshot: %s", err)
+	}
+	t.Setenv("CONFIG_PATH", dir)
+	confMan := config.NewConfigFileManager(&config.ConfigFileManagerOpts{
+		LocalConfigPath: "/",
+	})
+	ibm := &IBM{
+		CustomProvider: &CustomProvider{
+			Config: NewProviderConfig(confMan, "ibm.json"),
+		},
+	}
+
+	labels := map[string]string{ibmMachineTypeLabel: "bx2.4x16", ibmRegionLabel: "us-south"}
+	updates := map[string]func() error{
+		"UpdateConfig": func() error {
+			_, err := ibm.UpdateConfig(strings.NewReader(`{"CPU":"0.05"}`), "")
+			return err
+		},
+		"UpdateConfigFromConfigMap": func() error {
+			_, err := ibm.UpdateConfigFromConfigMap(map[string]string{"CPU": "0.05"})
+			return err
+		},
+	}
+	for name, update := range updates {
+		ibm.Catalog = nil
+		if err := update(); err != nil {
+			t.Fatalf("%s: Unexpected error: %s", name, err)
+		}
+
+		node, err := ibm.NodePricing(ibm.GetKey(labels, nil))
+		if err != nil {
+			t.Fatalf("%s: Unexpected error: %s", name, err)
+		}
+		if node.Cost != "0.192000" {
+			t.Errorf("%s: Expected flavor prices to be reloaded, got: %+v", name, node)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package cloud
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/kubecost/opencost/pkg/log"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// ParseNATGatewaySubnets parses the natGatewaySubnets custom pricing, a comma separated list of the CIDRs of the
+// subnets whose internet egress is routed through a NAT gateway, e.g. "10.0.128.0/18,10.0.192.0/18". "*" stands
+// for every subnet.
+func ParseNATGatewaySubnets(s string) ([]*net.IPNet, error) {
+	var subnets []*net.IPNet
+	for _, cidr := range strings.Split(s, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if cidr == "*" {
+			cidr = "0.0.0.0/0,::/0"
+		}
+		for _, c := range strings.Split(cidr, ",") {
+			_, subnet, err := net.ParseCIDR(c)
+			if err != nil {
+				return nil, fmt.Errorf("invalid NAT gateway subnet \"%s\": %s", c, err)
+			}
+			subnets = append(subnets, subnet)
+		}
+	}
+	return subnets, nil
+}
+
+// NodeUsesNATGateway returns true if one of the node's internal IPs is in a NAT gateway subnet, in which case the
+// internet egress of its pods crosses the NAT gateway.
+func NodeUsesNATGateway(node *v1.Node, subnets []*net.IPNet) bool {
+	for _, addr := range node.Status.Addresses {
+		if addr.Type != v1.NodeInternalIP {
+			continue
+		}
+		ip := net.ParseIP(addr.Address)
+		if ip == nil {
+			continue
+		}
+		for _, subnet := range subnets {
+			if subnet.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// natGatewayDataProcessingCost returns the configured NAT gateway data processing cost per GiB. The setting is
+// optional, so it is 0 if missing or invalid rather than an error.
+func natGatewayDataProcessingCost(cp *CustomPricing) float64 {
+	if cp.NATGatewayDataProcessing == "" {
+		return 0
+	}
+	cost, err := strconv.ParseFloat(cp.NATGatewayDataProcessing, 64)
+	if err != nil {
+		log.DedupedWarningf(5, "NATGatewayDataProcessing: failed to parse \"%s\": %s", cp.NATGatewayDataProcessing, err)
+		return 0
+	}
+	return cost
+}
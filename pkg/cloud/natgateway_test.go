@@ -0,0 +1,85 @@
+package cloud
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestNodeUsesNATGateway(t *testing.T) {
+	newNode := func(addresses ...v1.NodeAddress) *v1.Node {
+		return &v1.Node{Status: v1.NodeStatus{Addresses: addresses}}
+	}
+
+	cases := []struct {
+		name     string
+		subnets  string
+		node     *v1.Node
+		expected bool
+	}{
+		{
+			name:     "node in a private subnet",
+			subnets:  "10.0.128.0/18, 10.0.192.0/18",
+			node:     newNode(v1.NodeAddress{Type: v1.NodeInternalIP, Address: "10.0.130.7"}),
+			expected: true,
+		},
+		{
+			name:     "node in a public subnet",
+			subnets:  "10.0.128.0/18,10.0.192.0/18",
+			node:     newNode(v1.NodeAddress{Type: v1.NodeInternalIP, Address: "10.0.12.7"}, v1.NodeAddress{Type: v1.NodeExternalIP, Address: "54.12.1.9"}),
+			expected: false,
+		},
+		{
+			name:     "external addresses are not matched",
+			subnets:  "54.12.0.0/16",
+			node:     newNode(v1.NodeAddress{Type: v1.NodeExternalIP, Address: "54.12.1.9"}),
+			expected: false,
+		},
+		{
+			name:     "all subnets",
+			subnets:  "*",
+			node:     newNode(v1.NodeAddress{Type: v1.NodeInternalIP, Address: "fd00::12"}),
+			expected: true,
+		},
+		{
+			name:     "no subnets configured",
+			subnets:  "",
+			node:     newNode(v1.NodeAddress{Type: v1.NodeInternalIP, Address: "10.0.130.7"}),
+			expected: false,
+		},
+	}
+
+	for _, test := range cases {
+		subnets, err := ParseNATGatewaySubnets(test.subnets)
+		if err != nil {
+			t.Errorf("Input: %s, Expected: no error, Actual: %s", test.name, err)
+			continue
+		}
+		result := NodeUsesNATGateway(test.node, subnets)
+		if result != test.expected {
+			t.Errorf("Input: %s, Expected: %t, Actual: %t", test.name, test.expected, result)
+		}
+	}
+
+	if _, err := ParseNATGatewaySubnets("10.0.128.0/33"); err == nil {
+		t.Errorf("Input: 10.0.128.0/33, Expected: error, Actual: none")
+	}
+}
+
+func TestNATGatewayDataProcessingCost(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected float64
+	}{
+		{input: "0.045", expected: 0.045},
+		{input: "", expected: 0},
+		{input: "free", expected: 0},
+	}
+
+	for _, test := range cases {
+		result := natGatewayDataProcessingCost(&CustomPricing{NATGatewayDataProcessing: test.input})
+		if result != test.expected {
+			t.Errorf("Input: %s, Expected: %f, Actual: %f", test.input, test.expected, result)
+		}
+	}
+}
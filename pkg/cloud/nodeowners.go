@@ -0,0 +1,238 @@
+package cloud
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/kubecost/opencost/pkg/config"
+	"github.com/kubecost/opencost/pkg/log"
+	"github.com/kubecost/opencost/pkg/prom"
+	"github.com/kubecost/opencost/pkg/util/json"
+)
+
+// nodeOwnersFile is the optional file, in the config path, holding the node ownership rules.
+const nodeOwnersFile = "node-owners.json"
+
+// NodePoolLabels are the labels naming the node pool of a node, in order of precedence.
+var NodePoolLabels = []string{
+	"cloud.google.com/gke-nodepool",
+	"eks.amazonaws.com/nodegroup",
+	"alpha.eksctl.io/nodegroup-name",
+	"kubernetes.azure.com/agentpool",
+	"agentpool",
+	"karpenter.sh/nodepool",
+	"karpenter.sh/provisioner-name",
+	alibabaNodePoolLabel,
+}
+
+// NodeOwnerRules map nodes dedicated to an owner, e.g. a node pool reserved for a tenant by taints and affinity,
+// to that owner. All of a dedicated node's costs, including its idle costs, are charged to its owner. Nodes matching
+// no rule are shared.
+type NodeOwnerRules struct {
+	// Rules are matched in order; the first rule matching a node owns it.
+	Rules []*NodeOwnerRule `json:"rules"`
+}
+
+// NodeOwnerRule matches nodes by cluster, labels, taints and node pool. A node matches if it matches all of the
+// rule's criteria.
+type NodeOwnerRule struct {
+	Owner   string `json:"owner"`
+	Cluster string `json:"cluster,omitempty"`
+	// Labels are label names and values which the node must all have.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Taints are taints which the node must all have, as key, key=value, key:effect or key=value:effect.
+	Taints []string `json:"taints,omitempty"`
+	// NodePool is the name of the node pool, as given by the first of the NodePoolLabels which the node has.
+	NodePool string `json:"nodePool,omitempty"`
+
+	labels map[string]string
+	taints []NodeTaint
+}
+
+// NodeTaint is a taint of a node. Empty values and effects of a rule's taints match any value and effect.
+type NodeTaint struct {
+	Key    string
+	Value  string
+	Effect string
+}
+
+// NodeOwnerRulesConfig holds the node ownership rules of the config path, which are loaded once and reloaded
+// whenever the file changes, so that computing costs does not read the file.
+type NodeOwnerRulesConfig struct {
+	lock            *sync.RWMutex
+	configFile      *config.ConfigFile
+	rules           *NodeOwnerRules
+	watcherHandleID config.HandlerID
+}
+
+// NewNodeOwnerRulesConfig loads the node ownership rules from the config path of the given ConfigFileManager, and
+// watches the file for changes.
+func NewNodeOwnerRulesConfig(configManager *config.ConfigFileManager) *NodeOwnerRulesConfig {
+	configFile := configManager.ConfigFileAt(configPathFor(nodeOwnersFile))
+	norc := &NodeOwnerRulesConfig{
+		lock:       new(sync.RWMutex),
+		configFile: configFile,
+	}
+
+	exists, err := configFile.Exists()
+	if err != nil {
+		log.Errorf("Could not check node owner rules at path %s: %s", configFile.Path(), err)
+	}
+	if exists {
+		data, err := configFile.Read()
+		if err != nil {
+			log.Errorf("Could not read node owner rules at path %s: %s", configFile.Path(), err)
+		} else {
+			norc.onConfigFileUpdated(config.ChangeTypeCreated, data)
+		}
+	}
+
+	norc.watcherHandleID = configFile.AddChangeHandler(norc.onConfigFileUpdated)
+	return norc
+}
+
+// onConfigFileUpdated parses the node ownership rules whenever the file is created or modified, keeping the previous
+// rules if they are invalid, and drops them when the file is deleted.
+func (norc *NodeOwnerRulesConfig) onConfigFileUpdated(changeType config.ChangeType, data []byte) {
+	log.Infof("Node Owner Rules Config Updated: %s", changeType)
+
+	switch changeType {
+	case config.ChangeTypeCreated:
+		fallthrough
+	case config.ChangeTypeModified:
+		rules, err := ParseNodeOwnerRules(data)
+		if err != nil {
+			log.Errorf("Could not decode node owner rules at path %s: %s", norc.configFile.Path(), err)
+			return
+		}
+
+		norc.lock.Lock()
+		defer norc.lock.Unlock()
+		norc.rules = rules
+	case config.ChangeTypeDeleted:
+		norc.lock.Lock()
+		defer norc.lock.Unlock()
+		norc.rules = nil
+	}
+}
+
+// Rules returns the current node ownership rules, or nil if there are none.
+func (norc *NodeOwnerRulesConfig) Rules() *NodeOwnerRules {
+	if norc == nil {
+		return nil
+	}
+
+	norc.lock.RLock()
+	defer norc.lock.RUnlock()
+	return norc.rules
+}
+
+// ParseNodeOwnerRules parses and validates JSON encoded node ownership rules.
+func ParseNodeOwnerRules(data []byte) (*NodeOwnerRules, error) {
+	nor := &NodeOwnerRules{}
+	if err := json.Unmarshal(data, nor); err != nil {
+		return nil, fmt.Errorf("error unmarshalling node owner rules: %s", err)
+	}
+
+	for i, r := range nor.Rules {
+		if r.Owner == "" {
+			return nil, fmt.Errorf("invalid node owner rule %d: missing owner", i)
+		}
+		if r.Cluster == "" && len(r.Labels) == 0 && len(r.Taints) == 0 && r.NodePool == "" {
+			return nil, fmt.Errorf("invalid node owner rule %d for \"%s\": rule matches every node", i, r.Owner)
+		}
+
+		// Node labels are matched as recorded in Prometheus, whose label names are sanitized
+		r.labels = make(map[string]string, len(r.Labels))
+		for name, value := range r.Labels {
+			r.labels[prom.SanitizeLabelName(name)] = value
+		}
+
+		for _, t := range r.Taints {
+			taint, err := ParseNodeTaint(t)
+			if err != nil {
+				return nil, fmt.Errorf("invalid node owner rule %d for \"%s\": %s", i, r.Owner, err)
+			}
+			r.taints = append(r.taints, taint)
+		}
+	}
+	return nor, nil
+}
+
+// ParseNodeTaint parses a taint of the form key, key=value, key:effect or key=value:effect.
+func ParseNodeTaint(s string) (NodeTaint, error) {
+	taint := NodeTaint{}
+
+	keyValue := s
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		keyValue, taint.Effect = s[:i], s[i+1:]
+		if taint.Effect == "" {
+			return taint, fmt.Errorf("invalid taint \"%s\": empty effect", s)
+		}
+	}
+
+	kv := strings.SplitN(keyValue, "=", 2)
+	taint.Key = kv[0]
+	if len(kv) == 2 {
+		taint.Value = kv[1]
+	}
+	if taint.Key == "" {
+		return taint, fmt.Errorf("invalid taint \"%s\": empty key", s)
+	}
+	return taint, nil
+}
+
+// OwnerOf returns the owner of the node with the given cluster, labels and taints, or an empty string if the node
+// is shared. Labels are keyed by their sanitized names.
+func (nor *NodeOwnerRules) OwnerOf(cluster string, labels map[string]string, taints []NodeTaint) string {
+	if nor == nil {
+		return ""
+	}
+	for _, r := range nor.Rules {
+		if r.matches(cluster, labels, taints) {
+			return r.Owner
+		}
+	}
+	return ""
+}
+
+func (r *NodeOwnerRule) matches(cluster string, labels map[string]string, taints []NodeTaint) bool {
+	if r.Cluster != "" && r.Cluster != cluster {
+		return false
+	}
+
+	for name, value := range r.labels {
+		if v, ok := labels[name]; !ok || v != value {
+			return false
+		}
+	}
+
+	if r.NodePool != "" && nodePoolOf(labels) != r.NodePool {
+		return false
+	}
+
+	for _, rt := range r.taints {
+		found := false
+		for _, t := range taints {
+			if t.Key == rt.Key && (rt.Value == "" || t.Value == rt.Value) && (rt.Effect == "" || t.Effect == rt.Effect) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// nodePoolOf returns the node pool of a node from its sanitized labels.
+func nodePoolOf(labels map[string]string) string {
+	for _, name := range NodePoolLabels {
+		if pool, ok := labels[prom.SanitizeLabelName(name)]; ok && pool != "" {
+			return pool
+		}
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+package cloud
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubecost/opencost/pkg/config"
+)
+
+const testNodeOwnerRules = `{
+	"rules": [
+		{"owner": "ml", "taints": ["dedicated=ml:NoSchedule"]},
+		{"owner": "payments", "cluster": "cluster1", "nodePool": "payments-pool"},
+		{"owner": "data", "labels": {"example.com/team": "data", "node.kubernetes.io/instance-type": "r5.large"}}
+	]
+}`
+
+func TestNodeOwnerRulesOwnerOf(t *testing.T) {
+	nor, err := ParseNodeOwnerRules([]byte(testNodeOwnerRules))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cases := []struct {
+		name     string
+		cluster  string
+		labels   map[string]string
+		taints   []NodeTaint
+		expected string
+	}{
+		{
+			name:     "matching taint",
+			cluster:  "cluster1",
+			taints:   []NodeTaint{{Key: "dedicated", Value: "ml", Effect: "NoSchedule"}},
+			expected: "ml",
+		},
+		{
+			name:     "taint with another effect",
+			cluster:  "cluster1",
+			taints:   []NodeTaint{{Key: "dedicated", Value: "ml", Effect: "PreferNoSchedule"}},
+			expected: "",
+		},
+		{
+			name:     "GKE node pool",
+			cluster:  "cluster1",
+			labels:   map[string]string{"cloud_google_com_gke_nodepool": "payments-pool"},
+			expected: "payments",
+		},
+		{
+			name:     "EKS node group",
+			cluster:  "cluster1",
+			labels:   map[string]string{"eks_amazonaws_com_nodegroup": "payments-pool"},
+			expected: "payments",
+		},
+		{
+			name:     "node pool in another cluster",
+			cluster:  "cluster2",
+			labels:   map[string]string{"cloud_google_com_gke_nodepool": "payments-pool"},
+			expected: "",
+		},
+		{
+			name:     "all labels",
+			cluster:  "cluster2",
+			labels:   map[string]string{"example_com_team": "data", "node_kubernetes_io_instance_type": "r5.large"},
+			expected: "data",
+		},
+		{
+			name:     "some labels",
+			cluster:  "cluster2",
+			labels:   map[string]string{"example_com_team": "data"},
+			expected: "",
+		},
+		{
+			name:    "first matching rule",
+			cluster: "cluster1",
+			labels:  map[string]string{"cloud_google_com_gke_nodepool": "payments-pool"},
+			taints: []NodeTaint{
+				{Key: "node.kubernetes.io/unschedulable", Effect: "NoSchedule"},
+				{Key: "dedicated", Value: "ml", Effect: "NoSchedule"},
+			},
+			expected: "ml",
+		},
+		{
+			name:     "shared node",
+			cluster:  "cluster1",
+			labels:   map[string]string{"kubernetes_io_os": "linux"},
+			expected: "",
+		},
+	}
+
+	for _, test := range cases {
+		result := nor.OwnerOf(test.cluster, test.labels, test.taints)
+		if result != test.expected {
+			t.Errorf("%s: Expected: %s, Actual: %s", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestParseNodeTaint(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected NodeTaint
+	}{
+		{input: "dedicated", expected: NodeTaint{Key: "dedicated"}},
+		{input: "dedicated=ml", expected: NodeTaint{Key: "dedicated", Value: "ml"}},
+		{input: "dedicated:NoSchedule", expected: NodeTaint{Key: "dedicated", Effect: "NoSchedule"}},
+		{input: "example.com/dedicated=ml:NoExecute", expected: NodeTaint{Key: "example.com/dedicated", Value: "ml", Effect: "NoExecute"}},
+	}
+
+	for _, test := range cases {
+		result, err := ParseNodeTaint(test.input)
+		if err != nil || result != test.expected {
+			t.Errorf("Input: %s, Expected: %+v, Actual: %+v, %v", test.input, test.expected, result, err)
+		}
+	}
+}
+
+func TestParseNodeOwnerRulesErrors(t *testing.T) {
+	cases := []string{
+		`{"rules": [{"nodePool": "payments-pool"}]}`,
+		`{"rules": [{"owner": "payments"}]}`,
+		`{"rules": [{"owner": "payments", "taints": ["=ml"]}]}`,
+		`{"rules": [{"owner": "payments", "taints": ["dedicated=ml:"]}]}`,
+	}
+
+	for _, input := range cases {
+		if _, err := ParseNodeOwnerRules([]byte(input)); err == nil {
+			t.Errorf("Input: %s, Expected: error, Actual: nil", input)
+		}
+	}
+}
+
+func TestNodeOwnerRulesConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CONFIG_PATH", dir)
+	confMan := config.NewConfigFileManager(&config.ConfigFileManagerOpts{
+		LocalConfigPath: "/",
+	})
+
+	if nor := NewNodeOwnerRulesConfig(confMan).Rules(); nor != nil {
+		t.Errorf("Expected no rules without a file, Actual: %v", nor)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, nodeOwnersFile), []byte(testNodeOwnerRules), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	norc := NewNodeOwnerRulesConfig(confMan)
+	if nor := norc.Rules(); nor == nil || len(nor.Rules) != 3 {
+		t.Fatalf("Expected the rules of the file, Actual: %v", nor)
+	}
+
+	// Changes to the file, as dispatched by its watcher, replace the rules unless they are invalid
+	norc.onConfigFileUpdated(config.ChangeTypeModified, []byte(`{"rules": [{"owner": "ml", "nodePool": "gpu-pool"}]}`))
+	if nor := norc.Rules(); nor == nil || len(nor.Rules) != 1 || nor.Rules[0].NodePool != "gpu-pool" {
+		t.Fatalf("Expected the modified rules, Actual: %v", nor)
+	}
+	norc.onConfigFileUpdated(config.ChangeTypeModified, []byte(`{"rules": [{"owner": "ml"}]}`))
+	if nor := norc.Rules(); nor == nil || nor.Rules[0].NodePool != "gpu-pool" {
+		t.Errorf("Expected invalid rules to keep the previous ones, Actual: %v", nor)
+	}
+	norc.onConfigFileUpdated(config.ChangeTypeDeleted, nil)
+	if nor := norc.Rules(); nor != nil {
+		t.Errorf("Expected no rules after deleting the file, Actual: %v", nor)
+	}
+}
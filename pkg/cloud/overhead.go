@@ -0,0 +1,81 @@
+package cloud
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OverheadLabel is set on the allocations of platform overhead containers, e.g. service mesh sidecars and log
+// shippers, so their cost can be aggregated by label:kubecost_overhead or shared across the other allocations.
+const OverheadLabel = "kubecost_overhead"
+
+// ContainerOverhead classifies containers as platform overhead by matching their names and images against the
+// overheadContainerNames and overheadContainerImages patterns of the CustomPricing. Patterns are regular
+// expressions matching the whole name or image.
+type ContainerOverhead struct {
+	names  []*regexp.Regexp
+	images []*regexp.Regexp
+}
+
+// NewContainerOverhead compiles the overhead container patterns of the CustomPricing.
+func NewContainerOverhead(cp *CustomPricing) (*ContainerOverhead, error) {
+	co := &ContainerOverhead{}
+	if cp == nil {
+		return co, nil
+	}
+
+	var err error
+	co.names, err = compileOverheadPatterns(cp.OverheadContainerNames)
+	if err != nil {
+		return nil, fmt.Errorf("invalid overheadContainerNames: %s", err)
+	}
+	co.images, err = compileOverheadPatterns(cp.OverheadContainerImages)
+	if err != nil {
+		return nil, fmt.Errorf("invalid overheadContainerImages: %s", err)
+	}
+	return co, nil
+}
+
+func compileOverheadPatterns(s string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + p + ")$")
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// Enabled returns true if any overhead patterns are configured.
+func (co *ContainerOverhead) Enabled() bool {
+	return co != nil && (len(co.names) > 0 || len(co.images) > 0)
+}
+
+// IsOverhead returns true if the container name or image matches an overhead pattern. The image may be empty if
+// unknown.
+func (co *ContainerOverhead) IsOverhead(container, image string) bool {
+	if co == nil {
+		return false
+	}
+	for _, re := range co.names {
+		if re.MatchString(container) {
+			return true
+		}
+	}
+	if image == "" {
+		return false
+	}
+	for _, re := range co.images {
+		if re.MatchString(image) {
+			return true
+		}
+	}
+	return false
+}
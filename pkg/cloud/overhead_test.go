@@ -0,0 +1,41 @@
+package cloud
+
+import "testing"
+
+func TestContainerOverhead(t *testing.T) {
+	overhead, err := NewContainerOverhead(&CustomPricing{
+		OverheadContainerNames:  "istio-proxy, istio-init, linkerd-.*",
+		OverheadContainerImages: ".*/fluent-bit:.*",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cases := []struct {
+		container string
+		image     string
+		expected  bool
+	}{
+		{container: "istio-proxy", image: "docker.io/istio/proxyv2:1.17.1", expected: true},
+		{container: "istio-init", expected: true},
+		{container: "linkerd-proxy", expected: true},
+		{container: "logs", image: "cr.fluentbit.io/fluent/fluent-bit:2.0", expected: true},
+		// Patterns match the whole name
+		{container: "my-istio-proxy-app", expected: false},
+		{container: "app", image: "example.com/app:1.0", expected: false},
+	}
+
+	for _, test := range cases {
+		result := overhead.IsOverhead(test.container, test.image)
+		if result != test.expected {
+			t.Errorf("Input: %s %s, Expected: %t, Actual: %t", test.container, test.image, test.expected, result)
+		}
+	}
+
+	if _, err := NewContainerOverhead(&CustomPricing{OverheadContainerNames: "istio-(proxy"}); err == nil {
+		t.Errorf("Expected an error for an invalid pattern")
+	}
+	if overhead, _ := NewContainerOverhead(&CustomPricing{}); overhead.Enabled() {
+		t.Errorf("Expected overhead classification to be disabled without patterns")
+	}
+}
@@ -0,0 +1,99 @@
+package cloud
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// QoS classes of pods, as reported by Kubernetes.
+const (
+	QoSGuaranteed = "Guaranteed"
+	QoSBurstable  = "Burstable"
+	QoSBestEffort = "BestEffort"
+)
+
+// PriceMultipliers scale the CPU and RAM costs of pods by their QoS class and PriorityClass, e.g. to charge more for
+// Guaranteed pods and high priority workloads, whose capacity cannot be overcommitted or preempted, and less for
+// BestEffort pods. Multipliers default to 1, and a pod's QoS class and PriorityClass multipliers compound.
+type PriceMultipliers struct {
+	qos      map[string]float64
+	priority map[string]float64
+}
+
+// NewPriceMultipliers parses the QoS class and PriorityClass multipliers of the CustomPricing.
+func NewPriceMultipliers(cp *CustomPricing) (*PriceMultipliers, error) {
+	pm := &PriceMultipliers{
+		qos:      map[string]float64{},
+		priority: map[string]float64{},
+	}
+	if cp == nil {
+		return pm, nil
+	}
+
+	for class, s := range map[string]string{
+		QoSGuaranteed: cp.QoSGuaranteedMultiplier,
+		QoSBurstable:  cp.QoSBurstableMultiplier,
+		QoSBestEffort: cp.QoSBestEffortMultiplier,
+	} {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		m, err := parseMultiplier(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s multiplier: %s", class, err)
+		}
+		pm.qos[class] = m
+	}
+
+	for _, pair := range strings.Split(cp.PriorityClassMultipliers, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, ":", 2)
+		class := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || class == "" {
+			return nil, fmt.Errorf("invalid priorityClassMultipliers entry \"%s\", expected class:multiplier", pair)
+		}
+		m, err := parseMultiplier(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid priorityClassMultipliers entry \"%s\": %s", pair, err)
+		}
+		pm.priority[class] = m
+	}
+
+	return pm, nil
+}
+
+func parseMultiplier(s string) (float64, error) {
+	m, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, err
+	}
+	if m < 0 {
+		return 0, fmt.Errorf("negative multiplier %f", m)
+	}
+	return m, nil
+}
+
+// Enabled returns true if any multipliers are configured.
+func (pm *PriceMultipliers) Enabled() bool {
+	return pm != nil && (len(pm.qos) > 0 || len(pm.priority) > 0)
+}
+
+// Multiplier returns the multiplier of a pod's CPU and RAM costs given its QoS class and PriorityClass, either of
+// which may be empty if unknown.
+func (pm *PriceMultipliers) Multiplier(qosClass, priorityClass string) float64 {
+	if pm == nil {
+		return 1
+	}
+
+	m := 1.0
+	if qm, ok := pm.qos[qosClass]; ok {
+		m *= qm
+	}
+	if prm, ok := pm.priority[priorityClass]; ok {
+		m *= prm
+	}
+	return m
+}
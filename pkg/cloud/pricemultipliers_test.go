@@ -0,0 +1,69 @@
+package cloud
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPriceMultipliersMultiplier(t *testing.T) {
+	pm, err := NewPriceMultipliers(&CustomPricing{
+		QoSGuaranteedMultiplier:  "1.2",
+		QoSBestEffortMultiplier:  "0.1",
+		PriorityClassMultipliers: "system-cluster-critical:1.5, batch-low:0.5",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !pm.Enabled() {
+		t.Fatalf("Expected multipliers to be enabled")
+	}
+
+	cases := []struct {
+		qosClass      string
+		priorityClass string
+		expected      float64
+	}{
+		{qosClass: QoSGuaranteed, expected: 1.2},
+		{qosClass: QoSBurstable, expected: 1},
+		{qosClass: QoSBestEffort, expected: 0.1},
+		{qosClass: QoSBurstable, priorityClass: "batch-low", expected: 0.5},
+		{qosClass: QoSGuaranteed, priorityClass: "system-cluster-critical", expected: 1.8},
+		{priorityClass: "unknown", expected: 1},
+		{expected: 1},
+	}
+
+	for _, test := range cases {
+		result := pm.Multiplier(test.qosClass, test.priorityClass)
+		if math.Abs(result-test.expected) > 1e-9 {
+			t.Errorf("Input: %s/%s, Expected: %f, Actual: %f", test.qosClass, test.priorityClass, test.expected, result)
+		}
+	}
+}
+
+func TestNewPriceMultipliersErrors(t *testing.T) {
+	cases := []*CustomPricing{
+		{QoSGuaranteedMultiplier: "high"},
+		{QoSBestEffortMultiplier: "-1"},
+		{PriorityClassMultipliers: "system-cluster-critical"},
+		{PriorityClassMultipliers: ":1.5"},
+		{PriorityClassMultipliers: "batch-low:cheap"},
+	}
+
+	for _, input := range cases {
+		if _, err := NewPriceMultipliers(input); err == nil {
+			t.Errorf("Input: %+v, Expected: error, Actual: nil", *input)
+		}
+	}
+}
+
+func TestPriceMultipliersDisabled(t *testing.T) {
+	pm, err := NewPriceMultipliers(&CustomPricing{})
+	if err != nil || pm.Enabled() {
+		t.Errorf("Expected disabled multipliers, Actual: %+v, %v", pm, err)
+	}
+
+	var nilPM *PriceMultipliers
+	if nilPM.Multiplier(QoSGuaranteed, "") != 1 {
+		t.Errorf("Expected a multiplier of 1 without multipliers")
+	}
+}
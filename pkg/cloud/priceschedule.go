@@ -0,0 +1,361 @@
+package cloud
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	// Schedules are resolved in their own time zone, which the container may not have data for
+	_ "time/tzdata"
+
+	"github.com/kubecost/opencost/pkg/config"
+	"github.com/kubecost/opencost/pkg/log"
+	"github.com/kubecost/opencost/pkg/util/json"
+)
+
+// priceScheduleFile is the optional file, in the config path, holding the price schedule.
+const priceScheduleFile = "price-schedule.json"
+
+const minutesPerDay = 24 * 60
+
+var weekdayKeys = map[time.Weekday]string{
+	time.Monday:    "mon",
+	time.Tuesday:   "tue",
+	time.Wednesday: "wed",
+	time.Thursday:  "thu",
+	time.Friday:    "fri",
+	time.Saturday:  "sat",
+	time.Sunday:    "sun",
+}
+
+// PriceSchedule varies node resource prices by time of day, day of the week and holiday calendar, e.g. for
+// on-prem power contracts with peak and off-peak rates. Times are resolved as wall clock time in Timezone, so a
+// window from 09:00 to 17:00 follows daylight saving time transitions.
+type PriceSchedule struct {
+	// Timezone is an IANA time zone name, e.g. America/New_York. Defaults to UTC.
+	Timezone string `json:"timezone,omitempty"`
+	// Holidays are dates, e.g. 2026-12-25, which only match windows listing the "holiday" day.
+	Holidays []string `json:"holidays,omitempty"`
+	// Windows are matched in order; the first window matching a time prices it. Times matching no window are
+	// priced at the node's prices.
+	Windows []*PriceWindow `json:"windows"`
+
+	location *time.Location
+	holidays map[string]bool
+}
+
+// PriceWindow is a time of day window on some days of the week with either a multiplier of the node's prices, or
+// absolute prices per CPU core-hour, RAM GiB-hour and GPU-hour. Absolute prices take precedence over the
+// multiplier for the resources they are set for.
+type PriceWindow struct {
+	Name string `json:"name"`
+	// Days lists mon through sun, weekday, weekend or holiday. Empty matches every day, including holidays.
+	Days []string `json:"days,omitempty"`
+	// Start and End are HH:MM wall clock times. End is exclusive and may be 24:00. A window whose End is before
+	// its Start wraps around midnight, matching the late and early hours of its days; equal times match all day.
+	Start      string   `json:"start"`
+	End        string   `json:"end"`
+	Multiplier *float64 `json:"multiplier,omitempty"`
+	CPU        *float64 `json:"CPU,omitempty"`
+	RAM        *float64 `json:"RAM,omitempty"`
+	GPU        *float64 `json:"GPU,omitempty"`
+
+	start   int
+	end     int
+	allDays bool
+	days    map[string]bool
+}
+
+// PriceScheduleConfig holds the price schedule of the config path, which is loaded once and reloaded whenever the
+// file changes, so that computing costs does not read the file.
+type PriceScheduleConfig struct {
+	lock            *sync.RWMutex
+	configFile      *config.ConfigFile
+	schedule        *PriceSchedule
+	watcherHandleID config.HandlerID
+}
+
+// NewPriceScheduleConfig loads the price schedule from the config path of the given ConfigFileManager, and watches
+// the file for changes.
+func NewPriceScheduleConfig(configManager *config.ConfigFileManager) *PriceScheduleConfig {
+	configFile := configManager.ConfigFileAt(configPathFor(priceScheduleFile))
+	psc := &PriceScheduleConfig{
+		lock:       new(sync.RWMutex),
+		configFile: configFile,
+	}
+
+	exists, err := configFile.Exists()
+	if err != nil {
+		log.Errorf("Could not check price schedule at path %s: %s", configFile.Path(), err)
+	}
+	if exists {
+		data, err := configFile.Read()
+		if err != nil {
+			log.Errorf("Could not read price schedule at path %s: %s", configFile.Path(), err)
+		} else {
+			psc.onConfigFileUpdated(config.ChangeTypeCreated, data)
+		}
+	}
+
+	psc.watcherHandleID = configFile.AddChangeHandler(psc.onConfigFileUpdated)
+	return psc
+}
+
+// onConfigFileUpdated parses the price schedule whenever the file is created or modified, keeping the previous
+// schedule if it is invalid, and drops it when the file is deleted.
+func (psc *PriceScheduleConfig) onConfigFileUpdated(changeType config.ChangeType, data []byte) {
+	log.Infof("Price Schedule Config Updated: %s", changeType)
+
+	switch changeType {
+	case config.ChangeTypeCreated:
+		fallthrough
+	case config.ChangeTypeModified:
+		schedule, err := ParsePriceSchedule(data)
+		if err != nil {
+			log.Errorf("Could not decode price schedule at path %s: %s", psc.configFile.Path(), err)
+			return
+		}
+
+		psc.lock.Lock()
+		defer psc.lock.Unlock()
+		psc.schedule = schedule
+	case config.ChangeTypeDeleted:
+		psc.lock.Lock()
+		defer psc.lock.Unlock()
+		psc.schedule = nil
+	}
+}
+
+// Schedule returns the current price schedule, or nil if there is none.
+func (psc *PriceScheduleConfig) Schedule() *PriceSchedule {
+	if psc == nil {
+		return nil
+	}
+
+	psc.lock.RLock()
+	defer psc.lock.RUnlock()
+	return psc.schedule
+}
+
+// ParsePriceSchedule parses and validates a JSON encoded price schedule.
+func ParsePriceSchedule(data []byte) (*PriceSchedule, error) {
+	ps := &PriceSchedule{}
+	if err := json.Unmarshal(data, ps); err != nil {
+		return nil, fmt.Errorf("error unmarshalling price schedule: %s", err)
+	}
+
+	var err error
+	ps.location, err = time.LoadLocation(ps.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price schedule timezone \"%s\": %s", ps.Timezone, err)
+	}
+
+	ps.holidays = make(map[string]bool, len(ps.Holidays))
+	for _, h := range ps.Holidays {
+		if _, err := time.Parse("2006-01-02", h); err != nil {
+			return nil, fmt.Errorf("invalid price schedule holiday \"%s\", expected YYYY-MM-DD", h)
+		}
+		ps.holidays[h] = true
+	}
+
+	for _, w := range ps.Windows {
+		if err := w.parse(); err != nil {
+			return nil, fmt.Errorf("invalid price schedule window \"%s\": %s", w.Name, err)
+		}
+	}
+	return ps, nil
+}
+
+func (w *PriceWindow) parse() error {
+	var err error
+	if w.start, err = parseWallClock(w.Start); err != nil {
+		return err
+	}
+	if w.end, err = parseWallClock(w.End); err != nil {
+		return err
+	}
+
+	w.allDays = len(w.Days) == 0
+	w.days = make(map[string]bool)
+	for _, d := range w.Days {
+		switch d = strings.ToLower(strings.TrimSpace(d)); d {
+		case "weekday":
+			for _, k := range []string{"mon", "tue", "wed", "thu", "fri"} {
+				w.days[k] = true
+			}
+		case "weekend":
+			w.days["sat"] = true
+			w.days["sun"] = true
+		case "mon", "tue", "wed", "thu", "fri", "sat", "sun", "holiday":
+			w.days[d] = true
+		default:
+			return fmt.Errorf("unknown day \"%s\"", d)
+		}
+	}
+
+	for _, p := range []*float64{w.Multiplier, w.CPU, w.RAM, w.GPU} {
+		if p != nil && *p < 0 {
+			return fmt.Errorf("prices and multipliers must not be negative")
+		}
+	}
+	return nil
+}
+
+// parseWallClock parses an HH:MM time into minutes after midnight.
+func parseWallClock(s string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil {
+		return 0, fmt.Errorf("invalid time \"%s\", expected HH:MM", s)
+	}
+	if h < 0 || m < 0 || m > 59 || h*60+m > minutesPerDay {
+		return 0, fmt.Errorf("invalid time \"%s\", expected HH:MM", s)
+	}
+	return h*60 + m, nil
+}
+
+func (w *PriceWindow) matches(day string, holiday bool, minute int) bool {
+	switch {
+	case w.allDays:
+	case holiday:
+		if !w.days["holiday"] {
+			return false
+		}
+	case !w.days[day]:
+		return false
+	}
+
+	if w.start == w.end || (w.start == 0 && w.end == minutesPerDay) {
+		return true
+	}
+	if w.start < w.end {
+		return minute >= w.start && minute < w.end
+	}
+	return minute >= w.start || minute < w.end
+}
+
+// rates returns the prices in the window given the node's prices.
+func (w *PriceWindow) rates(cpu, ram, gpu float64) (float64, float64, float64) {
+	if w.Multiplier != nil {
+		cpu, ram, gpu = cpu**w.Multiplier, ram**w.Multiplier, gpu**w.Multiplier
+	}
+	if w.CPU != nil {
+		cpu = *w.CPU
+	}
+	if w.RAM != nil {
+		ram = *w.RAM
+	}
+	if w.GPU != nil {
+		gpu = *w.GPU
+	}
+	return cpu, ram, gpu
+}
+
+// WindowAt returns the window pricing the instant t, or nil if no window matches.
+func (ps *PriceSchedule) WindowAt(t time.Time) *PriceWindow {
+	local := t.In(ps.location)
+	day := weekdayKeys[local.Weekday()]
+	holiday := ps.holidays[local.Format("2006-01-02")]
+	minute := local.Hour()*60 + local.Minute()
+	for _, w := range ps.Windows {
+		if w.matches(day, holiday, minute) {
+			return w
+		}
+	}
+	return nil
+}
+
+// AverageRates returns the average hourly CPU, RAM and GPU prices between start and end given the node's prices,
+// weighting the prices of each window by the time spent in it.
+func (ps *PriceSchedule) AverageRates(start, end time.Time, cpu, ram, gpu float64) (float64, float64, float64) {
+	if ps == nil || len(ps.Windows) == 0 {
+		return cpu, ram, gpu
+	}
+	if !end.After(start) {
+		if w := ps.WindowAt(start); w != nil {
+			return w.rates(cpu, ram, gpu)
+		}
+		return cpu, ram, gpu
+	}
+
+	var cpuSum, ramSum, gpuSum float64
+	bounds := ps.boundaries(start, end)
+	for i := 0; i < len(bounds)-1; i++ {
+		hours := bounds[i+1].Sub(bounds[i]).Hours()
+		c, r, g := cpu, ram, gpu
+		if w := ps.WindowAt(bounds[i]); w != nil {
+			c, r, g = w.rates(cpu, ram, gpu)
+		}
+		cpuSum += c * hours
+		ramSum += r * hours
+		gpuSum += g * hours
+	}
+	total := end.Sub(start).Hours()
+	return cpuSum / total, ramSum / total, gpuSum / total
+}
+
+// boundaries returns the sorted instants in [start, end] at which the matching window may change: the start and
+// end, every local midnight, every instant the wall clock reads a window's start or end, and every daylight
+// saving time transition, which may skip over a window boundary.
+func (ps *PriceSchedule) boundaries(start, end time.Time) []time.Time {
+	loc := ps.location
+	seen := map[int64]bool{}
+	bounds := []time.Time{}
+	add := func(t time.Time) {
+		if t.Before(start) || t.After(end) || seen[t.UnixNano()] {
+			return
+		}
+		seen[t.UnixNano()] = true
+		bounds = append(bounds, t)
+	}
+	add(start)
+	add(end)
+
+	wallClocks := []int{0}
+	for _, w := range ps.Windows {
+		wallClocks = append(wallClocks, w.start, w.end)
+	}
+
+	ls := start.In(loc)
+	for y, m, d := ls.Date(); ; d++ {
+		dayStart := time.Date(y, m, d, 0, 0, 0, 0, loc)
+		if dayStart.After(end) {
+			break
+		}
+		for _, minutes := range wallClocks {
+			t := time.Date(y, m, d, minutes/60, minutes%60, 0, 0, loc)
+			// A wall clock time occurs twice when clocks are set back, an hour apart
+			for _, c := range []time.Time{t.Add(-time.Hour), t, t.Add(time.Hour)} {
+				lc := c.In(loc)
+				if lc.Hour()*60+lc.Minute() == minutes%minutesPerDay && lc.Second() == 0 {
+					add(c)
+				}
+			}
+		}
+		dayEnd := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+		_, startOffset := dayStart.Zone()
+		_, endOffset := dayEnd.Zone()
+		if startOffset != endOffset {
+			add(zoneTransition(dayStart, dayEnd))
+		}
+	}
+
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i].Before(bounds[j]) })
+	return bounds
+}
+
+// zoneTransition returns the first instant after from whose zone offset differs from that of from, given that
+// the offset at to differs.
+func zoneTransition(from, to time.Time) time.Time {
+	_, offset := from.Zone()
+	for to.Sub(from) > time.Second {
+		mid := from.Add(to.Sub(from) / 2).Truncate(time.Second)
+		if _, o := mid.Zone(); o == offset {
+			from = mid
+		} else {
+			to = mid
+		}
+	}
+	return to
+}
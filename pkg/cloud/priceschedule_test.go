@@ -0,0 +1,234 @@
+package cloud
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/config"
+)
+
+const testPriceSchedule = `{
+	"timezone": "America/New_York",
+	"holidays": ["2026-12-25"],
+	"windows": [
+		{"name": "holiday", "days": ["holiday"], "start": "00:00", "end": "24:00", "multiplier": 0.5},
+		{"name": "peak", "days": ["weekday"], "start": "09:00", "end": "17:00", "multiplier": 1.5},
+		{"name": "overnight", "days": ["weekday"], "start": "22:00", "end": "06:00", "CPU": 0.01, "RAM": 0.001},
+		{"name": "weekend", "days": ["weekend"], "start": "00:00", "end": "00:00", "multiplier": 0.8}
+	]
+}`
+
+func TestPriceScheduleWindowAt(t *testing.T) {
+	ps, err := ParsePriceSchedule([]byte(testPriceSchedule))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		// Wednesday
+		{input: "2026-10-14T14:00:00-04:00", expected: "peak"},
+		{input: "2026-10-14T08:59:59-04:00", expected: ""},
+		{input: "2026-10-14T17:00:00-04:00", expected: ""},
+		{input: "2026-10-14T02:00:00-04:00", expected: "overnight"},
+		{input: "2026-10-14T23:30:00-04:00", expected: "overnight"},
+		// The same instant in UTC resolves in the schedule's time zone
+		{input: "2026-10-14T18:00:00Z", expected: "peak"},
+		{input: "2026-10-17T14:00:00-04:00", expected: "weekend"},
+		// Christmas on a Friday only matches the holiday window
+		{input: "2026-12-25T14:00:00-05:00", expected: "holiday"},
+		{input: "2026-12-25T02:00:00-05:00", expected: "holiday"},
+	}
+
+	for _, test := range cases {
+		at, _ := time.Parse(time.RFC3339, test.input)
+		result := ""
+		if w := ps.WindowAt(at); w != nil {
+			result = w.Name
+		}
+		if result != test.expected {
+			t.Errorf("Input: %s, Expected: %s, Actual: %s", test.input, test.expected, result)
+		}
+	}
+}
+
+func TestPriceScheduleAverageRates(t *testing.T) {
+	ps, err := ParsePriceSchedule([]byte(testPriceSchedule))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cases := []struct {
+		name     string
+		start    string
+		end      string
+		expected [3]float64
+	}{
+		{
+			name:     "2pm batch job at peak",
+			start:    "2026-10-14T14:00:00-04:00",
+			end:      "2026-10-14T15:00:00-04:00",
+			expected: [3]float64{0.03, 0.003, 1.5},
+		},
+		{
+			name:     "2am batch job at absolute overnight prices, GPU unchanged",
+			start:    "2026-10-14T02:00:00-04:00",
+			end:      "2026-10-14T03:00:00-04:00",
+			expected: [3]float64{0.01, 0.001, 1},
+		},
+		{
+			name:     "half at node prices, half at peak",
+			start:    "2026-10-14T08:00:00-04:00",
+			end:      "2026-10-14T10:00:00-04:00",
+			expected: [3]float64{0.025, 0.0025, 1.25},
+		},
+		{
+			// 8h overnight at 0.01, 3h unscheduled, 8h peak at 0.03 and 5h unscheduled
+			name:     "whole weekday",
+			start:    "2026-10-14T00:00:00-04:00",
+			end:      "2026-10-15T00:00:00-04:00",
+			expected: [3]float64{(8*0.01 + 8*0.02 + 8*0.03) / 24, (8*0.001 + 8*0.002 + 8*0.003) / 24, (8*1 + 8*1 + 8*1.5) / 24},
+		},
+		{
+			name:     "weekend",
+			start:    "2026-10-17T00:00:00-04:00",
+			end:      "2026-10-19T00:00:00-04:00",
+			expected: [3]float64{0.016, 0.0016, 0.8},
+		},
+	}
+
+	for _, test := range cases {
+		start, _ := time.Parse(time.RFC3339, test.start)
+		end, _ := time.Parse(time.RFC3339, test.end)
+		cpu, ram, gpu := ps.AverageRates(start, end, 0.02, 0.002, 1)
+		result := [3]float64{cpu, ram, gpu}
+		for i := range result {
+			if math.Abs(result[i]-test.expected[i]) > 1e-9 {
+				t.Errorf("%s: Expected: %v, Actual: %v", test.name, test.expected, result)
+				break
+			}
+		}
+	}
+}
+
+func TestPriceScheduleDaylightSavingTime(t *testing.T) {
+	// Windows on either side of the skipped and repeated hours of the America/New_York transitions
+	ps, err := ParsePriceSchedule([]byte(`{
+		"timezone": "America/New_York",
+		"windows": [
+			{"name": "early", "start": "01:00", "end": "02:30", "multiplier": 2},
+			{"name": "late", "start": "02:30", "end": "04:00", "multiplier": 3}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cases := []struct {
+		name     string
+		start    string
+		end      string
+		expected float64
+	}{
+		{
+			// 01:00 EST to 03:00 EDT is one hour early, and 03:00 to 04:00 EDT is one hour late
+			name:     "spring forward",
+			start:    "2026-03-08T01:00:00-05:00",
+			end:      "2026-03-08T04:00:00-04:00",
+			expected: (1*2 + 1*3) / 2.0,
+		},
+		{
+			// 01:00 EDT to 01:00 EST and on to 02:30 EST is 2.5 hours early, then 1.5 hours late
+			name:     "fall back",
+			start:    "2026-11-01T01:00:00-04:00",
+			end:      "2026-11-01T04:00:00-05:00",
+			expected: (2.5*2 + 1.5*3) / 4.0,
+		},
+		{
+			// The 23 hour day has one hour early and one hour late
+			name:     "spring forward day",
+			start:    "2026-03-08T00:00:00-05:00",
+			end:      "2026-03-09T00:00:00-04:00",
+			expected: (1*2 + 1*3 + 21*1) / 23.0,
+		},
+		{
+			// The 25 hour day has 2.5 hours early and 1.5 hours late
+			name:     "fall back day",
+			start:    "2026-11-01T00:00:00-04:00",
+			end:      "2026-11-02T00:00:00-05:00",
+			expected: (2.5*2 + 1.5*3 + 21*1) / 25.0,
+		},
+		{
+			// Both occurrences of 01:30 are early
+			name:     "repeated hour",
+			start:    "2026-11-01T01:30:00-04:00",
+			end:      "2026-11-01T01:30:00-05:00",
+			expected: 2,
+		},
+	}
+
+	for _, test := range cases {
+		start, _ := time.Parse(time.RFC3339, test.start)
+		end, _ := time.Parse(time.RFC3339, test.end)
+		cpu, _, _ := ps.AverageRates(start, end, 1, 1, 1)
+		if math.Abs(cpu-test.expected) > 1e-9 {
+			t.Errorf("%s: Expected: %f, Actual: %f", test.name, test.expected, cpu)
+		}
+	}
+}
+
+func TestParsePriceScheduleErrors(t *testing.T) {
+	cases := []string{
+		`{"timezone": "Mars/Olympus_Mons", "windows": []}`,
+		`{"holidays": ["12/25/2026"], "windows": []}`,
+		`{"windows": [{"name": "peak", "start": "9am", "end": "17:00"}]}`,
+		`{"windows": [{"name": "peak", "start": "09:00", "end": "24:30"}]}`,
+		`{"windows": [{"name": "peak", "days": ["someday"], "start": "09:00", "end": "17:00"}]}`,
+		`{"windows": [{"name": "peak", "start": "09:00", "end": "17:00", "multiplier": -1}]}`,
+	}
+
+	for _, input := range cases {
+		if _, err := ParsePriceSchedule([]byte(input)); err == nil {
+			t.Errorf("Input: %s, Expected: error, Actual: nil", input)
+		}
+	}
+}
+
+func TestPriceScheduleConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CONFIG_PATH", dir)
+	confMan := config.NewConfigFileManager(&config.ConfigFileManagerOpts{
+		LocalConfigPath: "/",
+	})
+
+	if ps := NewPriceScheduleConfig(confMan).Schedule(); ps != nil {
+		t.Errorf("Expected no schedule without a file, Actual: %v", ps)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, priceScheduleFile), []byte(testPriceSchedule), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	psc := NewPriceScheduleConfig(confMan)
+	if ps := psc.Schedule(); ps == nil || len(ps.Windows) != 4 {
+		t.Fatalf("Expected the schedule of the file, Actual: %v", ps)
+	}
+
+	// Changes to the file, as dispatched by its watcher, replace the schedule unless they are invalid
+	psc.onConfigFileUpdated(config.ChangeTypeModified, []byte(`{"windows": [{"name": "flat", "start": "00:00", "end": "00:00", "multiplier": 2}]}`))
+	if ps := psc.Schedule(); ps == nil || len(ps.Windows) != 1 || ps.Windows[0].Name != "flat" {
+		t.Fatalf("Expected the modified schedule, Actual: %v", ps)
+	}
+	psc.onConfigFileUpdated(config.ChangeTypeModified, []byte(`{"timezone": "Nowhere/Invalid"}`))
+	if ps := psc.Schedule(); ps == nil || ps.Windows[0].Name != "flat" {
+		t.Errorf("Expected an invalid schedule to keep the previous one, Actual: %v", ps)
+	}
+	psc.onConfigFileUpdated(config.ChangeTypeDeleted, nil)
+	if ps := psc.Schedule(); ps != nil {
+		t.Errorf("Expected no schedule after deleting the file, Actual: %v", ps)
+	}
+}
@@ -70,6 +70,8 @@ type Node struct {
 	Reserved         *ReservedInstanceData `json:"reserved,omitempty"`
 	ProviderID       string                `json:"providerID,omitempty"`
 	PricingType      PricingType           `json:"pricingType,omitempty"`
+	GPUPricingModel  string                `json:"gpuPricingModel,omitempty"` // GPUPricingModel is the GPU model whose price was matched, or "default"
+	AcceleratorType  string                `json:"acceleratorType,omitempty"` // AcceleratorType is the type of the node's GPUs or other accelerators, e.g. nvidia or tpu
 }
 
 // IsSpot determines whether or not a Node uses spot by usage type
@@ -99,6 +101,8 @@ type Network struct {
 	ZoneNetworkEgressCost     float64
 	RegionNetworkEgressCost   float64
 	InternetNetworkEgressCost float64
+	// NATGatewayDataProcessingCost is charged per GiB of internet egress from nodes in NAT gateway subnets
+	NATGatewayDataProcessingCost float64
 }
 
 // PV is the interface by which the provider and cost model communicate PV prices.
@@ -148,6 +152,8 @@ type CustomPricing struct {
 	ZoneNetworkEgress            string `json:"zoneNetworkEgress"`
 	RegionNetworkEgress          string `json:"regionNetworkEgress"`
 	InternetNetworkEgress        string `json:"internetNetworkEgress"`
+	NATGatewayDataProcessing     string `json:"natGatewayDataProcessing,omitempty"`
+	NATGatewaySubnets            string `json:"natGatewaySubnets,omitempty"` // comma separated CIDRs of subnets egressing through a NAT gateway, or *
 	FirstFiveForwardingRulesCost string `json:"firstFiveForwardingRulesCost"`
 	AdditionalForwardingRuleCost string `json:"additionalForwardingRuleCost"`
 	LBIngressDataCost            string `json:"LBIngressDataCost"`
@@ -155,6 +161,24 @@ type CustomPricing struct {
 	SpotLabelValue               string `json:"spotLabelValue,omitempty"`
 	GpuLabel                     string `json:"gpuLabel,omitempty"`
 	GpuLabelValue                string `json:"gpuLabelValue,omitempty"`
+	GpuModelLabel                string `json:"gpuModelLabel,omitempty"`
+	GpuModelPrices               string `json:"gpuModelPrices,omitempty"`         // comma separated model:price pairs, e.g. a100:2.93,l4:0.71
+	SpotGpuModelPrices           string `json:"spotGpuModelPrices,omitempty"`     // comma separated model:price pairs, e.g. a100:0.88,l4:0.21
+	AcceleratorResources         string `json:"acceleratorResources,omitempty"`   // comma separated resource:type pairs, e.g. example.com/npu:npu
+	AcceleratorPrices            string `json:"acceleratorPrices,omitempty"`      // comma separated type:hourly price per device pairs, e.g. amd:1.80,tpu:1.20
+	ExtendedResourcePrices       string `json:"extendedResourcePrices,omitempty"` // comma separated resource:hourly price per unit (GiB for hugepages) pairs, e.g. xilinx.com/fpga:0.95,hugepages-1Gi:0.01
+	HostGroupLabel               string `json:"hostGroupLabel,omitempty"`
+	HostLabel                    string `json:"hostLabel,omitempty"`
+	HostGroupPrices              string `json:"hostGroupPrices,omitempty"`          // comma separated group:price[:vCPUs] entries, e.g. licensed-hosts:5.474:96
+	HostPremium                  string `json:"hostPremium,omitempty"`              // percent added to host prices, e.g. 10 for GCP sole-tenancy
+	BurstableCreditPrice         string `json:"burstableCreditPrice,omitempty"`     // surplus CPU credit price per vCPU-hour of burstable nodes
+	BurstableCreditMode          string `json:"burstableCreditMode,omitempty"`      // unlimited or standard, overriding the default credit mode of burstable instance families
+	OverheadContainerNames       string `json:"overheadContainerNames,omitempty"`   // comma separated container name patterns, e.g. istio-proxy,linkerd-.*
+	OverheadContainerImages      string `json:"overheadContainerImages,omitempty"`  // comma separated container image patterns, e.g. .*/fluent-bit:.*
+	QoSGuaranteedMultiplier      string `json:"qosGuaranteedMultiplier,omitempty"`  // CPU and RAM cost multiplier of Guaranteed pods, e.g. 1.2
+	QoSBurstableMultiplier       string `json:"qosBurstableMultiplier,omitempty"`   // CPU and RAM cost multiplier of Burstable pods
+	QoSBestEffortMultiplier      string `json:"qosBestEffortMultiplier,omitempty"`  // CPU and RAM cost multiplier of BestEffort pods, e.g. 0.1
+	PriorityClassMultipliers     string `json:"priorityClassMultipliers,omitempty"` // comma separated class:multiplier pairs, e.g. system-cluster-critical:1.5
 	ServiceKeyName               string `json:"awsServiceKeyName,omitempty"`
 	ServiceKeySecret             string `json:"awsServiceKeySecret,omitempty"`
 	SpotDataRegion               string `json:"awsSpotDataRegion,omitempty"`
@@ -177,6 +201,8 @@ type CustomPricing struct {
 	AzureTenantID                string `json:"azureTenantID"`
 	AzureBillingRegion           string `json:"azureBillingRegion"`
 	AzureOfferDurableID          string `json:"azureOfferDurableID"`
+	AlibabaServiceKeyName        string `json:"alibabaServiceKeyName,omitempty"`
+	AlibabaServiceKeySecret      string `json:"alibabaServiceKeySecret,omitempty"`
 	CurrencyCode                 string `json:"currencyCode"`
 	Discount                     string `json:"discount"`
 	NegotiatedDiscount           string `json:"negotiatedDiscount"`
@@ -275,11 +301,15 @@ const (
 	CsvExact      PricingType = "csvExact"
 	CsvClass      PricingType = "csvClass"
 	DefaultPrices PricingType = "defaultPrices"
+	Fargate       PricingType = "fargate"
+	Autopilot     PricingType = "autopilot"
+	DedicatedHost PricingType = "dedicatedHost"
 )
 
 type PricingMatchMetadata struct {
 	TotalNodes        int                 `json:"TotalNodes"`
 	PricingTypeCounts map[PricingType]int `json:"PricingType"`
+	GPUModelCounts    map[string]int      `json:"GPUModel,omitempty"`
 }
 
 // Provider represents a k8s provider.
@@ -475,6 +505,34 @@ func NewProvider(cache clustercache.ClusterCache, apiKey string, config *config.
 			clusterAccountId:     cp.accountID,
 			serviceAccountChecks: NewServiceAccountChecks(),
 		}, nil
+	case kubecost.AlibabaProvider:
+		log.Info("Found ACK node labels or ProviderID, using Alibaba Provider")
+		return &Alibaba{
+			Clientset:            cache,
+			Config:               NewProviderConfig(config, cp.configFileName),
+			clusterRegion:        cp.region,
+			clusterAccountId:     cp.accountID,
+			serviceAccountChecks: NewServiceAccountChecks(),
+		}, nil
+	case kubecost.DigitalOceanProvider:
+		log.Info("Found ProviderID starting with \"digitalocean\", using DigitalOcean Provider")
+		return &DigitalOcean{
+			CustomProvider: &CustomProvider{
+				Clientset: cache,
+				Config:    NewProviderConfig(config, cp.configFileName),
+			},
+			clusterRegion: cp.region,
+		}, nil
+	case kubecost.IBMProvider:
+		log.Info("Found ProviderID starting with \"ibm\" or IKS node labels, using IBM Provider")
+		return &IBM{
+			CustomProvider: &CustomProvider{
+				Clientset: cache,
+				Config:    NewProviderConfig(config, cp.configFileName),
+			},
+			clusterRegion:    cp.region,
+			clusterAccountId: cp.accountID,
+		}, nil
 	default:
 		log.Info("Unsupported provider, falling back to default")
 		return &CustomProvider{
@@ -513,6 +571,22 @@ func getClusterProperties(node *v1.Node) clusterProperties {
 		cp.provider = kubecost.AzureProvider
 		cp.configFileName = "azure.json"
 		cp.accountID = parseAzureSubscriptionID(providerID)
+	} else if strings.HasPrefix(providerID, "digitalocean") {
+		cp.provider = kubecost.DigitalOceanProvider
+		cp.configFileName = "digitalocean.json"
+	} else if isIBMNode(node) {
+		cp.provider = kubecost.IBMProvider
+		cp.configFileName = "ibm.json"
+		cp.accountID = parseIBMAccountID(providerID)
+		if r, ok := node.Labels[ibmRegionLabel]; ok && cp.region == "" {
+			cp.region = r
+		}
+	} else if isAlibabaNode(node) {
+		cp.provider = kubecost.AlibabaProvider
+		cp.configFileName = "alibaba.json"
+		if cp.region == "" {
+			cp.region, _ = parseAlibabaProviderID(providerID)
+		}
 	}
 	if env.IsUseCSVProvider() {
 		cp.provider = kubecost.CSVProvider
@@ -625,6 +699,16 @@ func ParseID(id string) string {
 		return match[1]
 	}
 
+	// cn-hangzhou.i-bp1a2b3c4d5e6f7g8h9i => i-bp1a2b3c4d5e6f7g8h9i
+	if _, instanceID := parseAlibabaProviderID(id); instanceID != "" {
+		return instanceID
+	}
+
+	// digitalocean://283419734 => 283419734
+	if dropletID := parseDigitalOceanDropletID(id); dropletID != "" {
+		return dropletID
+	}
+
 	// Return id for Azure Provider, CSV Provider and Custom Provider
 	return id
 }
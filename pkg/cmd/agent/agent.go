@@ -205,6 +205,8 @@ func Execute(opts *AgentOpts) error {
 	clusterMap := clusters.NewClusterMap(promCli, clusterInfoProvider, 5*time.Minute)
 
 	costModel := costmodel.NewCostModel(promCli, cloudProvider, clusterCache, clusterMap, scrapeInterval)
+	costModel.PriceSchedule = cloud.NewPriceScheduleConfig(confManager).Schedule
+	costModel.NodeOwnerRules = cloud.NewNodeOwnerRulesConfig(confManager).Rules
 
 	// initialize Kubernetes Metrics Emitter
 	metricsEmitter := costmodel.NewCostModelMetricsEmitter(promCli, clusterCache, cloudProvider, clusterInfoProvider, costModel)
@@ -1,13 +1,21 @@
 package costmodel
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/kubecost/opencost/pkg/apiserver"
 	"github.com/kubecost/opencost/pkg/costmodel"
+	"github.com/kubecost/opencost/pkg/env"
 	"github.com/kubecost/opencost/pkg/errors"
+	"github.com/kubecost/opencost/pkg/focus"
 	"github.com/kubecost/opencost/pkg/log"
 	"github.com/kubecost/opencost/pkg/metrics"
+	"github.com/kubecost/opencost/pkg/storage"
 	"github.com/kubecost/opencost/pkg/version"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/cors"
@@ -36,5 +44,90 @@ func Execute(opts *CostModelOpts) error {
 	telemetryHandler := metrics.ResponseMetricMiddleware(rootMux)
 	handler := cors.AllowAll().Handler(telemetryHandler)
 
+	if env.IsCostAPIServerEnabled() {
+		go startCostAPIServer(a)
+	}
+
+	if env.IsFOCUSExportEnabled() {
+		go startFOCUSExport(a)
+	}
+
 	return http.ListenAndServe(":9003", errors.PanicHandlerMiddleware(handler))
 }
+
+// startCostAPIServer serves the cost resources as a Kubernetes aggregated API,
+// authenticating the requests proxied by the kube-apiserver and authorizing
+// them with SubjectAccessReviews.
+func startCostAPIServer(a *costmodel.Accesses) {
+	requestHeader, err := apiserver.NewRequestHeaderAuthenticatorFromCluster(context.Background(), a.KubeClientSet)
+	if err != nil {
+		log.Warnf("API server: request header authentication disabled: %s", err)
+	}
+
+	authenticators := []apiserver.Authenticator{}
+	if requestHeader != nil {
+		authenticators = append(authenticators, requestHeader)
+	}
+	authenticators = append(authenticators, apiserver.NewTokenReviewAuthenticator(a.KubeClientSet))
+
+	server := apiserver.NewServer(
+		a.Model,
+		apiserver.NewUnionAuthenticator(authenticators...),
+		apiserver.NewSubjectAccessReviewAuthorizer(a.KubeClientSet),
+		env.GetETLResolution(),
+		env.GetCostAPIServerDefaultWindow(),
+	)
+
+	addr := fmt.Sprintf(":%d", env.GetCostAPIServerPort())
+	err = server.ListenAndServeTLS(addr, env.GetCostAPIServerCertFile(), env.GetCostAPIServerKeyFile())
+	if err != nil {
+		log.Errorf("API server: %s", err)
+	}
+}
+
+// startFOCUSExport exports the allocations and assets of each day, once it has
+// ended, in the FOCUS format, to the configured bucket or the config path.
+func startFOCUSExport(a *costmodel.Accesses) {
+	var store storage.Storage
+	if bucketConfigFile := env.GetFOCUSExportBucketConfig(); bucketConfigFile != "" {
+		bucketConfig, err := ioutil.ReadFile(bucketConfigFile)
+		if err != nil {
+			log.Errorf("FOCUS: error reading bucket config: %s", err)
+			return
+		}
+		store, err = storage.NewBucketStorage(bucketConfig)
+		if err != nil {
+			log.Errorf("FOCUS: error creating bucket storage: %s", err)
+			return
+		}
+	} else {
+		store = storage.NewFileStorage(env.GetConfigPathWithDefault("/var/configs/"))
+	}
+
+	provider := ""
+	if info, err := a.CloudProvider.ClusterInfo(); err == nil {
+		provider = info["provider"]
+	}
+	currency := "USD"
+	if c, err := a.CloudProvider.GetConfig(); err == nil && c.CurrencyCode != "" {
+		currency = c.CurrencyCode
+	}
+
+	exporter := focus.NewExporter(store, env.GetFOCUSExportPath(), provider, currency)
+
+	for {
+		// Export yesterday, in the timezone of the UTC offset, which is
+		// skipped if it was already exported.
+		loc := time.FixedZone("", int(env.GetParsedUTCOffset().Seconds()))
+		now := time.Now().In(loc)
+		end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		start := end.AddDate(0, 0, -1)
+
+		err := exporter.ExportPeriod(a.Model, start, end, env.GetETLResolution())
+		if err != nil {
+			log.Errorf("FOCUS: %s", err)
+		}
+
+		time.Sleep(time.Hour)
+	}
+}
@@ -2245,6 +2245,31 @@ func (a *Accesses) ComputeAllocationHandler(w http.ResponseWriter, r *http.Reque
 	// Defaults to 0. If a value is not passed then the parameter is not used.
 	accumulateBy := qp.GetDuration("accumulateBy", 0)
 
+	// ShareOverhead is an optional parameter, defaulting to false, which if
+	// true shares the cost of platform overhead containers, e.g. service mesh
+	// sidecars, across the other allocations. ShareSplit determines whether
+	// they are shared in proportion to cost ("weighted") or evenly ("even").
+	var options *kubecost.AllocationAggregationOptions
+	if qp.GetBool("shareOverhead", false) {
+		options = &kubecost.AllocationAggregationOptions{
+			ShareFuncs: []kubecost.AllocationMatchFunc{isOverheadAllocation},
+			ShareSplit: kubecost.ShareWeighted,
+		}
+		if qp.Get("shareSplit", "weighted") == "even" {
+			options.ShareSplit = kubecost.ShareEven
+		}
+	}
+
+	// ShareDaemonSets is an optional parameter, defaulting to false, which if
+	// true shares the cost of each DaemonSet pod among the other pods on its
+	// node, in proportion to their cost.
+	if qp.GetBool("shareDaemonSets", false) {
+		if options == nil {
+			options = &kubecost.AllocationAggregationOptions{}
+		}
+		options.ShareDaemonSetsByNode = true
+	}
+
 	// Query for AllocationSets in increments of the given step duration,
 	// appending each to the AllocationSetRange.
 	asr := kubecost.NewAllocationSetRange()
@@ -2263,9 +2288,9 @@ func (a *Accesses) ComputeAllocationHandler(w http.ResponseWriter, r *http.Reque
 		stepStart = stepEnd
 	}
 
-	// Aggregate, if requested
-	if len(aggregateBy) > 0 {
-		err = asr.AggregateBy(aggregateBy, nil)
+	// Aggregate and share, if requested
+	if len(aggregateBy) > 0 || options != nil {
+		err = asr.AggregateBy(aggregateBy, options)
 		if err != nil {
 			WriteError(w, InternalServerError(err.Error()))
 			return
@@ -2291,6 +2316,15 @@ func (a *Accesses) ComputeAllocationHandler(w http.ResponseWriter, r *http.Reque
 	w.Write(WrapData(asr, nil))
 }
 
+// isOverheadAllocation matches the allocations of platform overhead
+// containers, which are labelled when computing allocations.
+func isOverheadAllocation(a *kubecost.Allocation) bool {
+	if a == nil || a.Properties == nil {
+		return false
+	}
+	return a.Properties.Labels[cloud.OverheadLabel] == "true"
+}
+
 // The below was transferred from a different package in order to maintain
 // previous behavior. Ultimately, we should clean this up at some point.
 // TODO move to util and/or standardize everything
@@ -3,6 +3,7 @@ package costmodel
 import (
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -10,10 +11,10 @@ import (
 	"github.com/kubecost/opencost/pkg/util/timeutil"
 
 	"github.com/kubecost/opencost/pkg/cloud"
-	"github.com/kubecost/opencost/pkg/env"
 	"github.com/kubecost/opencost/pkg/kubecost"
 	"github.com/kubecost/opencost/pkg/log"
 	"github.com/kubecost/opencost/pkg/prom"
+	"github.com/kubecost/opencost/pkg/util"
 	"k8s.io/apimachinery/pkg/labels"
 )
 
@@ -26,9 +27,15 @@ const (
 	queryFmtRAMUsageMax              = `max(max_over_time(container_memory_working_set_bytes{container!="", container_name!="POD", container!="POD"}[%s])) by (container_name, container, pod_name, pod, namespace, instance, %s)`
 	queryFmtCPUCoresAllocated        = `avg(avg_over_time(container_cpu_allocation{container!="", container!="POD", node!=""}[%s])) by (container, pod, namespace, node, %s)`
 	queryFmtCPURequests              = `avg(avg_over_time(kube_pod_container_resource_requests{resource="cpu", unit="core", container!="", container!="POD", node!=""}[%s])) by (container, pod, namespace, node, %s)`
+	queryFmtInitCPURequests          = `avg(avg_over_time(kube_pod_init_container_resource_requests{resource="cpu", unit="core", container!="", node!=""}[%s])) by (container, pod, namespace, node, %s)`
+	queryFmtInitRAMRequests          = `avg(avg_over_time(kube_pod_init_container_resource_requests{resource="memory", unit="byte", container!="", node!=""}[%s])) by (container, pod, namespace, node, %s)`
+	queryFmtContainerImages          = `avg(avg_over_time({__name__=~"kube_pod_container_info|kube_pod_init_container_info", container!=""}[%s])) by (container, pod, namespace, image, %s)`
 	queryFmtCPUUsageAvg              = `avg(rate(container_cpu_usage_seconds_total{container!="", container_name!="POD", container!="POD"}[%s])) by (container_name, container, pod_name, pod, namespace, instance, %s)`
 	queryFmtCPUUsageMax              = `max(rate(container_cpu_usage_seconds_total{container!="", container_name!="POD", container!="POD"}[%s])) by (container_name, container, pod_name, pod, namespace, instance, %s)`
-	queryFmtGPUsRequested            = `avg(avg_over_time(kube_pod_container_resource_requests{resource="nvidia_com_gpu", container!="",container!="POD", node!=""}[%s])) by (container, pod, namespace, node, %s)`
+	queryFmtNodeCPUUsage             = `sum(rate(container_cpu_usage_seconds_total{container!="", container_name!="POD", container!="POD"}[%s])) by (node, instance, %s)[%s:%s]`
+	queryFmtGPUsRequested            = `sum(avg(avg_over_time(kube_pod_container_resource_requests{resource=~"%s", container!="",container!="POD", node!=""}[%s])) by (container, pod, namespace, node, resource, %s)) by (container, pod, namespace, node, %s)`
+	queryFmtAcceleratorsRequested    = `avg(avg_over_time(kube_pod_container_resource_requests{resource=~"%s", container!="",container!="POD", node!=""}[%s])) by (container, pod, namespace, node, resource, %s)`
+	queryFmtExtResourcesRequested    = `avg(avg_over_time(kube_pod_container_resource_requests{resource!~"%s", container!="",container!="POD", node!=""}[%s])) by (container, pod, namespace, node, resource, %s)`
 	queryFmtGPUsAllocated            = `avg(avg_over_time(container_gpu_allocation{container!="", container!="POD", node!=""}[%s])) by (container, pod, namespace, node, %s)`
 	queryFmtNodeCostPerCPUHr         = `avg(avg_over_time(node_cpu_hourly_cost[%s])) by (node, %s, instance_type, provider_id)`
 	queryFmtNodeCostPerRAMGiBHr      = `avg(avg_over_time(node_ram_hourly_cost[%s])) by (node, %s, instance_type, provider_id)`
@@ -45,12 +52,17 @@ const (
 	queryFmtNetRegionCostPerGiB      = `avg(avg_over_time(kubecost_network_region_egress_cost{}[%s])) by (%s)`
 	queryFmtNetInternetGiB           = `sum(increase(kubecost_pod_network_egress_bytes_total{internet="true"}[%s])) by (pod_name, namespace, %s) / 1024 / 1024 / 1024`
 	queryFmtNetInternetCostPerGiB    = `avg(avg_over_time(kubecost_network_internet_egress_cost{}[%s])) by (%s)`
+	queryFmtNetNATGatewayCostPerGiB  = `avg(avg_over_time(kubecost_network_nat_gateway_cost{}[%s])) by (node, %s)`
 	queryFmtNetReceiveBytes          = `sum(increase(container_network_receive_bytes_total{pod!="", container="POD"}[%s])) by (pod_name, pod, namespace, %s)`
 	queryFmtNetTransferBytes         = `sum(increase(container_network_transmit_bytes_total{pod!="", container="POD"}[%s])) by (pod_name, pod, namespace, %s)`
 	queryFmtNamespaceLabels          = `avg_over_time(kube_namespace_labels[%s])`
 	queryFmtNamespaceAnnotations     = `avg_over_time(kube_namespace_annotations[%s])`
+	queryFmtNodeLabels               = `avg_over_time(kube_node_labels[%s])`
+	queryFmtNodeTaints               = `max(max_over_time(kube_node_spec_taint[%s])) by (node, key, value, effect, %s)`
 	queryFmtPodLabels                = `avg_over_time(kube_pod_labels[%s])`
 	queryFmtPodAnnotations           = `avg_over_time(kube_pod_annotations[%s])`
+	queryFmtPodQoSClass              = `max(max_over_time(kube_pod_status_qos_class[%s])) by (pod, namespace, qos_class, %s) > 0`
+	queryFmtPodPriorityClass         = `max(max_over_time(kube_pod_info{priority_class!=""}[%s])) by (pod, namespace, priority_class, %s)`
 	queryFmtServiceLabels            = `avg_over_time(service_selector_labels[%s])`
 	queryFmtDeploymentLabels         = `avg_over_time(deployment_match_labels[%s])`
 	queryFmtStatefulSetLabels        = `avg_over_time(statefulSet_match_labels[%s])`
@@ -72,7 +84,7 @@ const MAX_CPU_CAP = 512
 // data to match, that's okay, and should be communicated with an error
 // response from ComputeAllocation.
 func (cm *CostModel) CanCompute(start, end time.Time) bool {
-	return start.Before(time.Now())
+	return start.Before(cm.now())
 }
 
 // Name returns the name of the Source
@@ -285,7 +297,7 @@ func (cm *CostModel) computeAllocation(start, end time.Time, resolution time.Dur
 	// with the same names. However, this will lead to a many-to-one metric
 	// to podKey relation, so this map allows us to map the metric's
 	// "<pod_name>" key to the edited "<pod_name> <pod_uid>" keys in podMap.
-	ingestPodUID := env.IsIngestingPodUID()
+	ingestPodUID := cm.IngestPodUID
 	podUIDKeyMap := make(map[podKey][]podKey)
 
 	if ingestPodUID {
@@ -293,7 +305,7 @@ func (cm *CostModel) computeAllocation(start, end time.Time, resolution time.Dur
 	}
 
 	// TODO:CLEANUP remove "max batch" idea and clusterStart/End
-	cm.buildPodMap(window, resolution, env.GetETLMaxPrometheusQueryDuration(), podMap, clusterStart, clusterEnd, ingestPodUID, podUIDKeyMap)
+	cm.buildPodMap(window, resolution, cm.MaxPrometheusQueryDuration, podMap, clusterStart, clusterEnd, ingestPodUID, podUIDKeyMap)
 
 	// (2) Run and apply remaining queries
 
@@ -306,101 +318,147 @@ func (cm *CostModel) computeAllocation(start, end time.Time, resolution time.Dur
 	// Convert resolution duration to a query-ready string
 	resStr := timeutil.DurationString(resolution)
 
-	ctx := prom.NewNamedContext(cm.PrometheusClient, prom.AllocationContextName)
+	ctx := cm.promContext(prom.AllocationContextName)
 
-	queryRAMBytesAllocated := fmt.Sprintf(queryFmtRAMBytesAllocated, durStr, env.GetPromClusterLabel())
+	queryRAMBytesAllocated := fmt.Sprintf(queryFmtRAMBytesAllocated, durStr, cm.ClusterLabel)
 	resChRAMBytesAllocated := ctx.QueryAtTime(queryRAMBytesAllocated, end)
 
-	queryRAMRequests := fmt.Sprintf(queryFmtRAMRequests, durStr, env.GetPromClusterLabel())
+	queryRAMRequests := fmt.Sprintf(queryFmtRAMRequests, durStr, cm.ClusterLabel)
 	resChRAMRequests := ctx.QueryAtTime(queryRAMRequests, end)
 
-	queryRAMUsageAvg := fmt.Sprintf(queryFmtRAMUsageAvg, durStr, env.GetPromClusterLabel())
+	queryRAMUsageAvg := fmt.Sprintf(queryFmtRAMUsageAvg, durStr, cm.ClusterLabel)
 	resChRAMUsageAvg := ctx.QueryAtTime(queryRAMUsageAvg, end)
 
-	queryRAMUsageMax := fmt.Sprintf(queryFmtRAMUsageMax, durStr, env.GetPromClusterLabel())
+	queryRAMUsageMax := fmt.Sprintf(queryFmtRAMUsageMax, durStr, cm.ClusterLabel)
 	resChRAMUsageMax := ctx.QueryAtTime(queryRAMUsageMax, end)
 
-	queryCPUCoresAllocated := fmt.Sprintf(queryFmtCPUCoresAllocated, durStr, env.GetPromClusterLabel())
+	queryCPUCoresAllocated := fmt.Sprintf(queryFmtCPUCoresAllocated, durStr, cm.ClusterLabel)
 	resChCPUCoresAllocated := ctx.QueryAtTime(queryCPUCoresAllocated, end)
 
-	queryCPURequests := fmt.Sprintf(queryFmtCPURequests, durStr, env.GetPromClusterLabel())
+	queryCPURequests := fmt.Sprintf(queryFmtCPURequests, durStr, cm.ClusterLabel)
 	resChCPURequests := ctx.QueryAtTime(queryCPURequests, end)
 
-	queryCPUUsageAvg := fmt.Sprintf(queryFmtCPUUsageAvg, durStr, env.GetPromClusterLabel())
+	queryInitCPURequests := fmt.Sprintf(queryFmtInitCPURequests, durStr, cm.ClusterLabel)
+	resChInitCPURequests := ctx.QueryAtTime(queryInitCPURequests, end)
+
+	queryInitRAMRequests := fmt.Sprintf(queryFmtInitRAMRequests, durStr, cm.ClusterLabel)
+	resChInitRAMRequests := ctx.QueryAtTime(queryInitRAMRequests, end)
+
+	queryContainerImages := fmt.Sprintf(queryFmtContainerImages, durStr, cm.ClusterLabel)
+	resChContainerImages := ctx.QueryAtTime(queryContainerImages, end)
+
+	queryCPUUsageAvg := fmt.Sprintf(queryFmtCPUUsageAvg, durStr, cm.ClusterLabel)
 	resChCPUUsageAvg := ctx.QueryAtTime(queryCPUUsageAvg, end)
 
-	queryCPUUsageMax := fmt.Sprintf(queryFmtCPUUsageMax, durStr, env.GetPromClusterLabel())
+	queryCPUUsageMax := fmt.Sprintf(queryFmtCPUUsageMax, durStr, cm.ClusterLabel)
 	resChCPUUsageMax := ctx.QueryAtTime(queryCPUUsageMax, end)
 
-	queryGPUsRequested := fmt.Sprintf(queryFmtGPUsRequested, durStr, env.GetPromClusterLabel())
+	// Node usage is sampled at each resolution step to estimate burstable CPU credits, with a rate range long
+	// enough to span several scrapes, from the start of the credit accrual period before the window, which sets
+	// the credits banked at its start.
+	nodeCPUUsageRateStr := timeutil.DurationString(time.Duration(math.Max(float64(resolution), float64(5*time.Minute))))
+	nodeCPUUsageDurStr := timeutil.DurationString(end.Sub(start) + cloud.BurstableAccrualPeriod)
+	queryNodeCPUUsage := fmt.Sprintf(queryFmtNodeCPUUsage, nodeCPUUsageRateStr, cm.ClusterLabel, nodeCPUUsageDurStr, resStr)
+	resChNodeCPUUsage := ctx.QueryAtTime(queryNodeCPUUsage, end)
+
+	// GPUs and other accelerators, e.g. TPUs, are requested by the extended resources of their device plugins
+	accelerators := getAccelerators(cm.Provider)
+
+	queryGPUsRequested := fmt.Sprintf(queryFmtGPUsRequested, accelerators.ResourceRegex(), durStr, cm.ClusterLabel, cm.ClusterLabel)
 	resChGPUsRequested := ctx.QueryAtTime(queryGPUsRequested, end)
 
-	queryGPUsAllocated := fmt.Sprintf(queryFmtGPUsAllocated, durStr, env.GetPromClusterLabel())
+	queryAcceleratorsRequested := fmt.Sprintf(queryFmtAcceleratorsRequested, accelerators.ResourceRegex(), durStr, cm.ClusterLabel)
+	resChAcceleratorsRequested := ctx.QueryAtTime(queryAcceleratorsRequested, end)
+
+	// Hugepages and other extended resources, e.g. FPGAs and SR-IOV virtual functions, are priced per unit, and
+	// hugepages otherwise at their nodes' RAM prices
+	extendedResources := getExtendedResources(cm.Provider)
+	nonExtendedResourceRegex := cloud.NonExtendedResourceRegex(accelerators)
+
+	queryExtendedResourcesRequested := fmt.Sprintf(queryFmtExtResourcesRequested, nonExtendedResourceRegex, durStr, cm.ClusterLabel)
+	resChExtendedResourcesRequested := ctx.QueryAtTime(queryExtendedResourcesRequested, end)
+
+	queryGPUsAllocated := fmt.Sprintf(queryFmtGPUsAllocated, durStr, cm.ClusterLabel)
 	resChGPUsAllocated := ctx.QueryAtTime(queryGPUsAllocated, end)
 
-	queryNodeCostPerCPUHr := fmt.Sprintf(queryFmtNodeCostPerCPUHr, durStr, env.GetPromClusterLabel())
+	queryNodeCostPerCPUHr := fmt.Sprintf(queryFmtNodeCostPerCPUHr, durStr, cm.ClusterLabel)
 	resChNodeCostPerCPUHr := ctx.QueryAtTime(queryNodeCostPerCPUHr, end)
 
-	queryNodeCostPerRAMGiBHr := fmt.Sprintf(queryFmtNodeCostPerRAMGiBHr, durStr, env.GetPromClusterLabel())
+	queryNodeCostPerRAMGiBHr := fmt.Sprintf(queryFmtNodeCostPerRAMGiBHr, durStr, cm.ClusterLabel)
 	resChNodeCostPerRAMGiBHr := ctx.QueryAtTime(queryNodeCostPerRAMGiBHr, end)
 
-	queryNodeCostPerGPUHr := fmt.Sprintf(queryFmtNodeCostPerGPUHr, durStr, env.GetPromClusterLabel())
+	queryNodeCostPerGPUHr := fmt.Sprintf(queryFmtNodeCostPerGPUHr, durStr, cm.ClusterLabel)
 	resChNodeCostPerGPUHr := ctx.QueryAtTime(queryNodeCostPerGPUHr, end)
 
 	queryNodeIsSpot := fmt.Sprintf(queryFmtNodeIsSpot, durStr)
 	resChNodeIsSpot := ctx.QueryAtTime(queryNodeIsSpot, end)
 
-	queryPVCInfo := fmt.Sprintf(queryFmtPVCInfo, env.GetPromClusterLabel(), durStr, resStr)
+	queryPVCInfo := fmt.Sprintf(queryFmtPVCInfo, cm.ClusterLabel, durStr, resStr)
 	resChPVCInfo := ctx.QueryAtTime(queryPVCInfo, end)
 
-	queryPVBytes := fmt.Sprintf(queryFmtPVBytes, durStr, env.GetPromClusterLabel())
+	queryPVBytes := fmt.Sprintf(queryFmtPVBytes, durStr, cm.ClusterLabel)
 	resChPVBytes := ctx.QueryAtTime(queryPVBytes, end)
 
-	queryPodPVCAllocation := fmt.Sprintf(queryFmtPodPVCAllocation, durStr, env.GetPromClusterLabel())
+	queryPodPVCAllocation := fmt.Sprintf(queryFmtPodPVCAllocation, durStr, cm.ClusterLabel)
 	resChPodPVCAllocation := ctx.QueryAtTime(queryPodPVCAllocation, end)
 
-	queryPVCBytesRequested := fmt.Sprintf(queryFmtPVCBytesRequested, durStr, env.GetPromClusterLabel())
+	queryPVCBytesRequested := fmt.Sprintf(queryFmtPVCBytesRequested, durStr, cm.ClusterLabel)
 	resChPVCBytesRequested := ctx.QueryAtTime(queryPVCBytesRequested, end)
 
-	queryPVCostPerGiBHour := fmt.Sprintf(queryFmtPVCostPerGiBHour, durStr, env.GetPromClusterLabel())
+	queryPVCostPerGiBHour := fmt.Sprintf(queryFmtPVCostPerGiBHour, durStr, cm.ClusterLabel)
 	resChPVCostPerGiBHour := ctx.QueryAtTime(queryPVCostPerGiBHour, end)
 
-	queryNetTransferBytes := fmt.Sprintf(queryFmtNetTransferBytes, durStr, env.GetPromClusterLabel())
+	queryNetTransferBytes := fmt.Sprintf(queryFmtNetTransferBytes, durStr, cm.ClusterLabel)
 	resChNetTransferBytes := ctx.QueryAtTime(queryNetTransferBytes, end)
 
-	queryNetReceiveBytes := fmt.Sprintf(queryFmtNetReceiveBytes, durStr, env.GetPromClusterLabel())
+	queryNetReceiveBytes := fmt.Sprintf(queryFmtNetReceiveBytes, durStr, cm.ClusterLabel)
 	resChNetReceiveBytes := ctx.QueryAtTime(queryNetReceiveBytes, end)
 
-	queryNetZoneGiB := fmt.Sprintf(queryFmtNetZoneGiB, durStr, env.GetPromClusterLabel())
+	queryNetZoneGiB := fmt.Sprintf(queryFmtNetZoneGiB, durStr, cm.ClusterLabel)
 	resChNetZoneGiB := ctx.QueryAtTime(queryNetZoneGiB, end)
 
-	queryNetZoneCostPerGiB := fmt.Sprintf(queryFmtNetZoneCostPerGiB, durStr, env.GetPromClusterLabel())
+	queryNetZoneCostPerGiB := fmt.Sprintf(queryFmtNetZoneCostPerGiB, durStr, cm.ClusterLabel)
 	resChNetZoneCostPerGiB := ctx.QueryAtTime(queryNetZoneCostPerGiB, end)
 
-	queryNetRegionGiB := fmt.Sprintf(queryFmtNetRegionGiB, durStr, env.GetPromClusterLabel())
+	queryNetRegionGiB := fmt.Sprintf(queryFmtNetRegionGiB, durStr, cm.ClusterLabel)
 	resChNetRegionGiB := ctx.QueryAtTime(queryNetRegionGiB, end)
 
-	queryNetRegionCostPerGiB := fmt.Sprintf(queryFmtNetRegionCostPerGiB, durStr, env.GetPromClusterLabel())
+	queryNetRegionCostPerGiB := fmt.Sprintf(queryFmtNetRegionCostPerGiB, durStr, cm.ClusterLabel)
 	resChNetRegionCostPerGiB := ctx.QueryAtTime(queryNetRegionCostPerGiB, end)
 
-	queryNetInternetGiB := fmt.Sprintf(queryFmtNetInternetGiB, durStr, env.GetPromClusterLabel())
+	queryNetInternetGiB := fmt.Sprintf(queryFmtNetInternetGiB, durStr, cm.ClusterLabel)
 	resChNetInternetGiB := ctx.QueryAtTime(queryNetInternetGiB, end)
 
-	queryNetInternetCostPerGiB := fmt.Sprintf(queryFmtNetInternetCostPerGiB, durStr, env.GetPromClusterLabel())
+	queryNetInternetCostPerGiB := fmt.Sprintf(queryFmtNetInternetCostPerGiB, durStr, cm.ClusterLabel)
 	resChNetInternetCostPerGiB := ctx.QueryAtTime(queryNetInternetCostPerGiB, end)
 
+	queryNetNATGatewayCostPerGiB := fmt.Sprintf(queryFmtNetNATGatewayCostPerGiB, durStr, cm.ClusterLabel)
+	resChNetNATGatewayCostPerGiB := ctx.QueryAtTime(queryNetNATGatewayCostPerGiB, end)
+
 	queryNamespaceLabels := fmt.Sprintf(queryFmtNamespaceLabels, durStr)
 	resChNamespaceLabels := ctx.QueryAtTime(queryNamespaceLabels, end)
 
 	queryNamespaceAnnotations := fmt.Sprintf(queryFmtNamespaceAnnotations, durStr)
 	resChNamespaceAnnotations := ctx.QueryAtTime(queryNamespaceAnnotations, end)
 
+	queryNodeLabels := fmt.Sprintf(queryFmtNodeLabels, durStr)
+	resChNodeLabels := ctx.QueryAtTime(queryNodeLabels, end)
+
+	queryNodeTaints := fmt.Sprintf(queryFmtNodeTaints, durStr, cm.ClusterLabel)
+	resChNodeTaints := ctx.QueryAtTime(queryNodeTaints, end)
+
 	queryPodLabels := fmt.Sprintf(queryFmtPodLabels, durStr)
 	resChPodLabels := ctx.QueryAtTime(queryPodLabels, end)
 
 	queryPodAnnotations := fmt.Sprintf(queryFmtPodAnnotations, durStr)
 	resChPodAnnotations := ctx.QueryAtTime(queryPodAnnotations, end)
 
+	queryPodQoSClass := fmt.Sprintf(queryFmtPodQoSClass, durStr, cm.ClusterLabel)
+	resChPodQoSClass := ctx.QueryAtTime(queryPodQoSClass, end)
+
+	queryPodPriorityClass := fmt.Sprintf(queryFmtPodPriorityClass, durStr, cm.ClusterLabel)
+	resChPodPriorityClass := ctx.QueryAtTime(queryPodPriorityClass, end)
+
 	queryServiceLabels := fmt.Sprintf(queryFmtServiceLabels, durStr)
 	resChServiceLabels := ctx.QueryAtTime(queryServiceLabels, end)
 
@@ -410,34 +468,40 @@ func (cm *CostModel) computeAllocation(start, end time.Time, resolution time.Dur
 	queryStatefulSetLabels := fmt.Sprintf(queryFmtStatefulSetLabels, durStr)
 	resChStatefulSetLabels := ctx.QueryAtTime(queryStatefulSetLabels, end)
 
-	queryDaemonSetLabels := fmt.Sprintf(queryFmtDaemonSetLabels, durStr, env.GetPromClusterLabel())
+	queryDaemonSetLabels := fmt.Sprintf(queryFmtDaemonSetLabels, durStr, cm.ClusterLabel)
 	resChDaemonSetLabels := ctx.QueryAtTime(queryDaemonSetLabels, end)
 
-	queryPodsWithReplicaSetOwner := fmt.Sprintf(queryFmtPodsWithReplicaSetOwner, durStr, env.GetPromClusterLabel())
+	queryPodsWithReplicaSetOwner := fmt.Sprintf(queryFmtPodsWithReplicaSetOwner, durStr, cm.ClusterLabel)
 	resChPodsWithReplicaSetOwner := ctx.QueryAtTime(queryPodsWithReplicaSetOwner, end)
 
-	queryReplicaSetsWithoutOwners := fmt.Sprintf(queryFmtReplicaSetsWithoutOwners, durStr, env.GetPromClusterLabel())
+	queryReplicaSetsWithoutOwners := fmt.Sprintf(queryFmtReplicaSetsWithoutOwners, durStr, cm.ClusterLabel)
 	resChReplicaSetsWithoutOwners := ctx.QueryAtTime(queryReplicaSetsWithoutOwners, end)
 
-	queryJobLabels := fmt.Sprintf(queryFmtJobLabels, durStr, env.GetPromClusterLabel())
+	queryJobLabels := fmt.Sprintf(queryFmtJobLabels, durStr, cm.ClusterLabel)
 	resChJobLabels := ctx.QueryAtTime(queryJobLabels, end)
 
-	queryLBCostPerHr := fmt.Sprintf(queryFmtLBCostPerHr, durStr, env.GetPromClusterLabel())
+	queryLBCostPerHr := fmt.Sprintf(queryFmtLBCostPerHr, durStr, cm.ClusterLabel)
 	resChLBCostPerHr := ctx.QueryAtTime(queryLBCostPerHr, end)
 
-	queryLBActiveMins := fmt.Sprintf(queryFmtLBActiveMins, env.GetPromClusterLabel(), durStr, resStr)
+	queryLBActiveMins := fmt.Sprintf(queryFmtLBActiveMins, cm.ClusterLabel, durStr, resStr)
 	resChLBActiveMins := ctx.QueryAtTime(queryLBActiveMins, end)
 
 	resCPUCoresAllocated, _ := resChCPUCoresAllocated.Await()
 	resCPURequests, _ := resChCPURequests.Await()
 	resCPUUsageAvg, _ := resChCPUUsageAvg.Await()
 	resCPUUsageMax, _ := resChCPUUsageMax.Await()
+	resNodeCPUUsage, _ := resChNodeCPUUsage.Await()
 	resRAMBytesAllocated, _ := resChRAMBytesAllocated.Await()
 	resRAMRequests, _ := resChRAMRequests.Await()
 	resRAMUsageAvg, _ := resChRAMUsageAvg.Await()
 	resRAMUsageMax, _ := resChRAMUsageMax.Await()
 	resGPUsRequested, _ := resChGPUsRequested.Await()
 	resGPUsAllocated, _ := resChGPUsAllocated.Await()
+	resAcceleratorsRequested, _ := resChAcceleratorsRequested.Await()
+	resExtendedResourcesRequested, _ := resChExtendedResourcesRequested.Await()
+	resInitCPURequests, _ := resChInitCPURequests.Await()
+	resInitRAMRequests, _ := resChInitRAMRequests.Await()
+	resContainerImages, _ := resChContainerImages.Await()
 
 	resNodeCostPerCPUHr, _ := resChNodeCostPerCPUHr.Await()
 	resNodeCostPerRAMGiBHr, _ := resChNodeCostPerRAMGiBHr.Await()
@@ -459,11 +523,16 @@ func (cm *CostModel) computeAllocation(start, end time.Time, resolution time.Dur
 	resNetRegionCostPerGiB, _ := resChNetRegionCostPerGiB.Await()
 	resNetInternetGiB, _ := resChNetInternetGiB.Await()
 	resNetInternetCostPerGiB, _ := resChNetInternetCostPerGiB.Await()
+	resNetNATGatewayCostPerGiB, _ := resChNetNATGatewayCostPerGiB.Await()
 
 	resNamespaceLabels, _ := resChNamespaceLabels.Await()
 	resNamespaceAnnotations, _ := resChNamespaceAnnotations.Await()
+	resNodeLabels, _ := resChNodeLabels.Await()
+	resNodeTaints, _ := resChNodeTaints.Await()
 	resPodLabels, _ := resChPodLabels.Await()
 	resPodAnnotations, _ := resChPodAnnotations.Await()
+	resPodQoSClass, _ := resChPodQoSClass.Await()
+	resPodPriorityClass, _ := resChPodPriorityClass.Await()
 	resServiceLabels, _ := resChServiceLabels.Await()
 	resDeploymentLabels, _ := resChDeploymentLabels.Await()
 	resStatefulSetLabels, _ := resChStatefulSetLabels.Await()
@@ -485,19 +554,23 @@ func (cm *CostModel) computeAllocation(start, end time.Time, resolution time.Dur
 	// We choose to apply allocation before requests in the cases of RAM and
 	// CPU so that we can assert that allocation should always be greater than
 	// or equal to request.
-	applyCPUCoresAllocated(podMap, resCPUCoresAllocated, podUIDKeyMap)
-	applyCPUCoresRequested(podMap, resCPURequests, podUIDKeyMap)
-	applyCPUCoresUsedAvg(podMap, resCPUUsageAvg, podUIDKeyMap)
-	applyCPUCoresUsedMax(podMap, resCPUUsageMax, podUIDKeyMap)
-	applyRAMBytesAllocated(podMap, resRAMBytesAllocated, podUIDKeyMap)
-	applyRAMBytesRequested(podMap, resRAMRequests, podUIDKeyMap)
-	applyRAMBytesUsedAvg(podMap, resRAMUsageAvg, podUIDKeyMap)
-	applyRAMBytesUsedMax(podMap, resRAMUsageMax, podUIDKeyMap)
-	applyGPUsAllocated(podMap, resGPUsRequested, resGPUsAllocated, podUIDKeyMap)
-	applyNetworkTotals(podMap, resNetTransferBytes, resNetReceiveBytes, podUIDKeyMap)
-	applyNetworkAllocation(podMap, resNetZoneGiB, resNetZoneCostPerGiB, podUIDKeyMap)
-	applyNetworkAllocation(podMap, resNetRegionGiB, resNetRegionCostPerGiB, podUIDKeyMap)
-	applyNetworkAllocation(podMap, resNetInternetGiB, resNetInternetCostPerGiB, podUIDKeyMap)
+	cm.applyCPUCoresAllocated(podMap, resCPUCoresAllocated, podUIDKeyMap)
+	cm.applyCPUCoresRequested(podMap, resCPURequests, podUIDKeyMap)
+	cm.applyCPUCoresUsedAvg(podMap, resCPUUsageAvg, podUIDKeyMap)
+	cm.applyCPUCoresUsedMax(podMap, resCPUUsageMax, podUIDKeyMap)
+	cm.applyRAMBytesAllocated(podMap, resRAMBytesAllocated, podUIDKeyMap)
+	cm.applyRAMBytesRequested(podMap, resRAMRequests, podUIDKeyMap)
+	cm.applyRAMBytesUsedAvg(podMap, resRAMUsageAvg, podUIDKeyMap)
+	cm.applyRAMBytesUsedMax(podMap, resRAMUsageMax, podUIDKeyMap)
+	cm.applyInitContainerRequests(podMap, resInitCPURequests, resInitRAMRequests, podUIDKeyMap)
+	cm.applyGPUsAllocated(podMap, resGPUsRequested, resGPUsAllocated, podUIDKeyMap)
+	cm.applyAcceleratorTypes(podMap, resAcceleratorsRequested, accelerators, podUIDKeyMap)
+	extendedResourceRequests := cm.resToExtendedResourceRequests(podMap, resExtendedResourcesRequested, podUIDKeyMap)
+	cm.applyNetworkTotals(podMap, resNetTransferBytes, resNetReceiveBytes, podUIDKeyMap)
+	cm.applyNetworkAllocation(podMap, resNetZoneGiB, resNetZoneCostPerGiB, podUIDKeyMap)
+	cm.applyNetworkAllocation(podMap, resNetRegionGiB, resNetRegionCostPerGiB, podUIDKeyMap)
+	cm.applyNetworkAllocation(podMap, resNetInternetGiB, resNetInternetCostPerGiB, podUIDKeyMap)
+	cm.applyNetworkNATGateway(podMap, resNetInternetGiB, resNetNATGatewayCostPerGiB, podUIDKeyMap)
 
 	// In the case that a two pods with the same name had different containers,
 	// we will double-count the containers. There is no way to associate each
@@ -507,22 +580,27 @@ func (cm *CostModel) computeAllocation(start, end time.Time, resolution time.Dur
 	// Other than that case, Allocations should be associated with pods by the
 	// above functions.
 
-	namespaceLabels := resToNamespaceLabels(resNamespaceLabels)
-	podLabels := resToPodLabels(resPodLabels, podUIDKeyMap, ingestPodUID)
+	namespaceLabels := cm.resToNamespaceLabels(resNamespaceLabels)
+	podLabels := cm.resToPodLabels(resPodLabels, podUIDKeyMap, ingestPodUID)
 	namespaceAnnotations := resToNamespaceAnnotations(resNamespaceAnnotations)
-	podAnnotations := resToPodAnnotations(resPodAnnotations, podUIDKeyMap, ingestPodUID)
+	podAnnotations := cm.resToPodAnnotations(resPodAnnotations, podUIDKeyMap, ingestPodUID)
 	applyLabels(podMap, namespaceLabels, podLabels)
 	applyAnnotations(podMap, namespaceAnnotations, podAnnotations)
+	cm.applyContainerOverhead(podMap, resContainerImages, podUIDKeyMap)
+	applyNamespaceHierarchy(podMap, cm.buildNamespaceHierarchy(podMap, namespaceLabels, namespaceAnnotations))
+	cm.applyDeployUnits(podMap)
+	cm.applyNodeOwners(podMap, resNodeLabels, resNodeTaints)
+	cm.applyControlPlaneNodes(podMap, resNodeLabels, resNodeTaints)
 
-	serviceLabels := getServiceLabels(resServiceLabels)
+	serviceLabels := cm.getServiceLabels(resServiceLabels)
 	allocsByService := map[serviceKey][]*kubecost.Allocation{}
 	applyServicesToPods(podMap, podLabels, allocsByService, serviceLabels)
 
-	podDeploymentMap := labelsToPodControllerMap(podLabels, resToDeploymentLabels(resDeploymentLabels))
-	podStatefulSetMap := labelsToPodControllerMap(podLabels, resToStatefulSetLabels(resStatefulSetLabels))
-	podDaemonSetMap := resToPodDaemonSetMap(resDaemonSetLabels, podUIDKeyMap, ingestPodUID)
-	podJobMap := resToPodJobMap(resJobLabels, podUIDKeyMap, ingestPodUID)
-	podReplicaSetMap := resToPodReplicaSetMap(resPodsWithReplicaSetOwner, resReplicaSetsWithoutOwners, podUIDKeyMap, ingestPodUID)
+	podDeploymentMap := labelsToPodControllerMap(podLabels, cm.resToDeploymentLabels(resDeploymentLabels))
+	podStatefulSetMap := labelsToPodControllerMap(podLabels, cm.resToStatefulSetLabels(resStatefulSetLabels))
+	podDaemonSetMap := cm.resToPodDaemonSetMap(resDaemonSetLabels, podUIDKeyMap, ingestPodUID)
+	podJobMap := cm.resToPodJobMap(resJobLabels, podUIDKeyMap, ingestPodUID)
+	podReplicaSetMap := cm.resToPodReplicaSetMap(resPodsWithReplicaSetOwner, resReplicaSetsWithoutOwners, podUIDKeyMap, ingestPodUID)
 	applyControllersToPods(podMap, podDeploymentMap)
 	applyControllersToPods(podMap, podStatefulSetMap)
 	applyControllersToPods(podMap, podDaemonSetMap)
@@ -535,11 +613,14 @@ func (cm *CostModel) computeAllocation(start, end time.Time, resolution time.Dur
 	// for converting resource allocation data to cumulative costs.
 	nodeMap := map[nodeKey]*NodePricing{}
 
-	applyNodeCostPerCPUHr(nodeMap, resNodeCostPerCPUHr)
-	applyNodeCostPerRAMGiBHr(nodeMap, resNodeCostPerRAMGiBHr)
-	applyNodeCostPerGPUHr(nodeMap, resNodeCostPerGPUHr)
-	applyNodeSpot(nodeMap, resNodeIsSpot)
+	cm.applyNodeCostPerCPUHr(nodeMap, resNodeCostPerCPUHr)
+	cm.applyNodeCostPerRAMGiBHr(nodeMap, resNodeCostPerRAMGiBHr)
+	cm.applyNodeCostPerGPUHr(nodeMap, resNodeCostPerGPUHr)
+	cm.applyNodeSpot(nodeMap, resNodeIsSpot)
 	applyNodeDiscount(nodeMap, cm)
+	applyFargateConfigurations(podMap, nodeMap)
+	applyAutopilotRequests(podMap, nodeMap)
+	creditSurcharges := cm.getBurstableCreditSurcharges(podMap, nodeMap, cm.resToNodeCPUUsage(resNodeCPUUsage, start), resolution)
 
 	// Build out the map of all PVs with class, size and cost-per-hour.
 	// Note: this does not record time running, which we may want to
@@ -547,21 +628,21 @@ func (cm *CostModel) computeAllocation(start, end time.Time, resolution time.Dur
 	// a PVC, we get time running there, so this is only inaccurate
 	// for short-lived, unmounted PVs.)
 	pvMap := map[pvKey]*PV{}
-	buildPVMap(pvMap, resPVCostPerGiBHour)
-	applyPVBytes(pvMap, resPVBytes)
+	cm.buildPVMap(pvMap, resPVCostPerGiBHour)
+	cm.applyPVBytes(pvMap, resPVBytes)
 
 	// Build out the map of all PVCs with time running, bytes requested,
 	// and connect to the correct PV from pvMap. (If no PV exists, that
 	// is noted, but does not result in any allocation/cost.)
 	pvcMap := map[pvcKey]*PVC{}
-	buildPVCMap(window, pvcMap, pvMap, resPVCInfo)
-	applyPVCBytesRequested(pvcMap, resPVCBytesRequested)
+	cm.buildPVCMap(window, pvcMap, pvMap, resPVCInfo)
+	cm.applyPVCBytesRequested(pvcMap, resPVCBytesRequested)
 
 	// Build out the relationships of pods to their PVCs. This step
 	// populates the PVC.Count field so that PVC allocation can be
 	// split appropriately among each pod's container allocation.
 	podPVCMap := map[podKey][]*PVC{}
-	buildPodPVCMap(podPVCMap, pvMap, pvcMap, podMap, resPodPVCAllocation, podUIDKeyMap, ingestPodUID)
+	cm.buildPodPVCMap(podPVCMap, pvMap, pvcMap, podMap, resPodPVCAllocation, podUIDKeyMap, ingestPodUID)
 
 	// Because PVCs can be shared among pods, the respective PV cost
 	// needs to be evenly distributed to those pods based on time
@@ -628,11 +709,13 @@ func (cm *CostModel) computeAllocation(start, end time.Time, resolution time.Dur
 	// cluster representing each cluster's unmounted PVs (if necessary).
 	applyUnmountedPVs(window, podMap, pvMap, pvcMap)
 
-	lbMap := getLoadBalancerCosts(resLBCostPerHr, resLBActiveMins, resolution)
+	lbMap := cm.getLoadBalancerCosts(resLBCostPerHr, resLBActiveMins, resolution)
 	applyLoadBalancersToPods(lbMap, allocsByService)
 
 	// (3) Build out AllocationSet from Pod map
 
+	schedule := cm.priceSchedule()
+
 	for _, pod := range podMap {
 		for _, alloc := range pod.Allocations {
 			cluster := alloc.Properties.Cluster
@@ -646,9 +729,19 @@ func (cm *CostModel) computeAllocation(start, end time.Time, resolution time.Dur
 
 			node := cm.getNodePricing(nodeMap, nodeKey)
 			alloc.Properties.ProviderID = node.ProviderID
-			alloc.CPUCost = alloc.CPUCoreHours * node.CostPerCPUHr
-			alloc.RAMCost = (alloc.RAMByteHours / 1024 / 1024 / 1024) * node.CostPerRAMGiBHr
-			alloc.GPUCost = alloc.GPUHours * node.CostPerGPUHr
+
+			// Price schedules vary prices over the allocation's window, so a job running off-peak costs less
+			cpuPrice, ramPrice, gpuPrice := node.CostPerCPUHr, node.CostPerRAMGiBHr, node.CostPerGPUHr
+			if schedule != nil {
+				cpuPrice, ramPrice, gpuPrice = schedule.AverageRates(alloc.Start, alloc.End, cpuPrice, ramPrice, gpuPrice)
+			}
+			alloc.CPUCost = alloc.CPUCoreHours * cpuPrice
+			// Surplus credits are billed on top of the price of the node, so they are kept apart from the cost of
+			// its cores, as they are on the node's asset.
+			alloc.CPUCostAdjustment += creditSurcharges[alloc]
+			alloc.RAMCost = (alloc.RAMByteHours / 1024 / 1024 / 1024) * ramPrice
+			alloc.GPUCost = alloc.GPUHours * gpuPrice
+			alloc.ExtendedResourceCosts = computeExtendedResourceCosts(extendedResources, extendedResourceRequests[alloc], alloc.Minutes()/60.0, ramPrice)
 
 			if pvcs, ok := podPVCMap[podKey]; ok {
 				for _, pvc := range pvcs {
@@ -704,6 +797,9 @@ func (cm *CostModel) computeAllocation(start, end time.Time, resolution time.Dur
 		}
 	}
 
+	cm.applyPriceMultipliers(allocSet, resPodQoSClass, resPodPriorityClass)
+	applyControlPlaneCosts(allocSet)
+
 	return allocSet, nil
 }
 
@@ -714,7 +810,7 @@ func (cm *CostModel) buildPodMap(window kubecost.Window, resolution, maxBatchSiz
 	// Convert resolution duration to a query-ready string
 	resStr := timeutil.DurationString(resolution)
 
-	ctx := prom.NewNamedContext(cm.PrometheusClient, prom.AllocationContextName)
+	ctx := cm.promContext(prom.AllocationContextName)
 
 	// Query for (start, end) by (pod, namespace, cluster) over the given
 	// window, using the given resolution, and if necessary in batches no
@@ -754,9 +850,9 @@ func (cm *CostModel) buildPodMap(window kubecost.Window, resolution, maxBatchSiz
 			var queryPods string
 			// If ingesting UIDs, avg on them
 			if ingestPodUID {
-				queryPods = fmt.Sprintf(queryFmtPodsUID, env.GetPromClusterLabel(), durStr, resStr)
+				queryPods = fmt.Sprintf(queryFmtPodsUID, cm.ClusterLabel, durStr, resStr)
 			} else {
-				queryPods = fmt.Sprintf(queryFmtPods, env.GetPromClusterLabel(), durStr, resStr)
+				queryPods = fmt.Sprintf(queryFmtPods, cm.ClusterLabel, durStr, resStr)
 			}
 
 			queryProfile := time.Now()
@@ -792,7 +888,7 @@ func (cm *CostModel) buildPodMap(window kubecost.Window, resolution, maxBatchSiz
 			}
 		}
 
-		applyPodResults(window, resolution, podMap, clusterStart, clusterEnd, resPods, ingestPodUID, podUIDKeyMap)
+		cm.applyPodResults(window, resolution, podMap, clusterStart, clusterEnd, resPods, ingestPodUID, podUIDKeyMap)
 
 		coverage = coverage.ExpandEnd(batchEnd)
 		numQuery++
@@ -801,16 +897,16 @@ func (cm *CostModel) buildPodMap(window kubecost.Window, resolution, maxBatchSiz
 	return nil
 }
 
-func applyPodResults(window kubecost.Window, resolution time.Duration, podMap map[podKey]*Pod, clusterStart, clusterEnd map[string]time.Time, resPods []*prom.QueryResult, ingestPodUID bool, podUIDKeyMap map[podKey][]podKey) {
+func (cm *CostModel) applyPodResults(window kubecost.Window, resolution time.Duration, podMap map[podKey]*Pod, clusterStart, clusterEnd map[string]time.Time, resPods []*prom.QueryResult, ingestPodUID bool, podUIDKeyMap map[podKey][]podKey) {
 	for _, res := range resPods {
 		if len(res.Values) == 0 {
 			log.Warnf("CostModel.ComputeAllocation: empty minutes result")
 			continue
 		}
 
-		cluster, err := res.GetString(env.GetPromClusterLabel())
+		cluster, err := res.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		labels, err := res.GetStrings("namespace", "pod")
@@ -955,9 +1051,9 @@ func applyPodResults(window kubecost.Window, resolution time.Duration, podMap ma
 	}
 }
 
-func applyCPUCoresAllocated(podMap map[podKey]*Pod, resCPUCoresAllocated []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
+func (cm *CostModel) applyCPUCoresAllocated(podMap map[podKey]*Pod, resCPUCoresAllocated []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
 	for _, res := range resCPUCoresAllocated {
-		key, err := resultPodKey(res, env.GetPromClusterLabel(), "namespace")
+		key, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
 		if err != nil {
 			log.DedupedWarningf(10, "CostModel.ComputeAllocation: CPU allocation result missing field: %s", err)
 			continue
@@ -1011,9 +1107,9 @@ func applyCPUCoresAllocated(podMap map[podKey]*Pod, resCPUCoresAllocated []*prom
 	}
 }
 
-func applyCPUCoresRequested(podMap map[podKey]*Pod, resCPUCoresRequested []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
+func (cm *CostModel) applyCPUCoresRequested(podMap map[podKey]*Pod, resCPUCoresRequested []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
 	for _, res := range resCPUCoresRequested {
-		key, err := resultPodKey(res, env.GetPromClusterLabel(), "namespace")
+		key, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
 		if err != nil {
 			log.DedupedWarningf(10, "CostModel.ComputeAllocation: CPU request result missing field: %s", err)
 			continue
@@ -1070,9 +1166,9 @@ func applyCPUCoresRequested(podMap map[podKey]*Pod, resCPUCoresRequested []*prom
 	}
 }
 
-func applyCPUCoresUsedAvg(podMap map[podKey]*Pod, resCPUCoresUsedAvg []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
+func (cm *CostModel) applyCPUCoresUsedAvg(podMap map[podKey]*Pod, resCPUCoresUsedAvg []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
 	for _, res := range resCPUCoresUsedAvg {
-		key, err := resultPodKey(res, env.GetPromClusterLabel(), "namespace")
+		key, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
 		if err != nil {
 			log.DedupedWarningf(10, "CostModel.ComputeAllocation: CPU usage avg result missing field: %s", err)
 			continue
@@ -1120,9 +1216,9 @@ func applyCPUCoresUsedAvg(podMap map[podKey]*Pod, resCPUCoresUsedAvg []*prom.Que
 	}
 }
 
-func applyCPUCoresUsedMax(podMap map[podKey]*Pod, resCPUCoresUsedMax []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
+func (cm *CostModel) applyCPUCoresUsedMax(podMap map[podKey]*Pod, resCPUCoresUsedMax []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
 	for _, res := range resCPUCoresUsedMax {
-		key, err := resultPodKey(res, env.GetPromClusterLabel(), "namespace")
+		key, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
 		if err != nil {
 			log.DedupedWarningf(10, "CostModel.ComputeAllocation: CPU usage max result missing field: %s", err)
 			continue
@@ -1172,9 +1268,9 @@ func applyCPUCoresUsedMax(podMap map[podKey]*Pod, resCPUCoresUsedMax []*prom.Que
 	}
 }
 
-func applyRAMBytesAllocated(podMap map[podKey]*Pod, resRAMBytesAllocated []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
+func (cm *CostModel) applyRAMBytesAllocated(podMap map[podKey]*Pod, resRAMBytesAllocated []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
 	for _, res := range resRAMBytesAllocated {
-		key, err := resultPodKey(res, env.GetPromClusterLabel(), "namespace")
+		key, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
 		if err != nil {
 			log.DedupedWarningf(10, "CostModel.ComputeAllocation: RAM allocation result missing field: %s", err)
 			continue
@@ -1224,9 +1320,9 @@ func applyRAMBytesAllocated(podMap map[podKey]*Pod, resRAMBytesAllocated []*prom
 	}
 }
 
-func applyRAMBytesRequested(podMap map[podKey]*Pod, resRAMBytesRequested []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
+func (cm *CostModel) applyRAMBytesRequested(podMap map[podKey]*Pod, resRAMBytesRequested []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
 	for _, res := range resRAMBytesRequested {
-		key, err := resultPodKey(res, env.GetPromClusterLabel(), "namespace")
+		key, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
 		if err != nil {
 			log.DedupedWarningf(10, "CostModel.ComputeAllocation: RAM request result missing field: %s", err)
 			continue
@@ -1280,9 +1376,9 @@ func applyRAMBytesRequested(podMap map[podKey]*Pod, resRAMBytesRequested []*prom
 	}
 }
 
-func applyRAMBytesUsedAvg(podMap map[podKey]*Pod, resRAMBytesUsedAvg []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
+func (cm *CostModel) applyRAMBytesUsedAvg(podMap map[podKey]*Pod, resRAMBytesUsedAvg []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
 	for _, res := range resRAMBytesUsedAvg {
-		key, err := resultPodKey(res, env.GetPromClusterLabel(), "namespace")
+		key, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
 		if err != nil {
 			log.DedupedWarningf(10, "CostModel.ComputeAllocation: RAM avg usage result missing field: %s", err)
 			continue
@@ -1326,9 +1422,9 @@ func applyRAMBytesUsedAvg(podMap map[podKey]*Pod, resRAMBytesUsedAvg []*prom.Que
 	}
 }
 
-func applyRAMBytesUsedMax(podMap map[podKey]*Pod, resRAMBytesUsedMax []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
+func (cm *CostModel) applyRAMBytesUsedMax(podMap map[podKey]*Pod, resRAMBytesUsedMax []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
 	for _, res := range resRAMBytesUsedMax {
-		key, err := resultPodKey(res, env.GetPromClusterLabel(), "namespace")
+		key, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
 		if err != nil {
 			log.DedupedWarningf(10, "CostModel.ComputeAllocation: RAM usage max result missing field: %s", err)
 			continue
@@ -1378,12 +1474,104 @@ func applyRAMBytesUsedMax(podMap map[podKey]*Pod, resRAMBytesUsedMax []*prom.Que
 	}
 }
 
-func applyGPUsAllocated(podMap map[podKey]*Pod, resGPUsRequested []*prom.QueryResult, resGPUsAllocated []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
+// initContainerRequest is the largest request of any of a pod's init containers for a resource.
+type initContainerRequest struct {
+	container string
+	node      string
+	value     float64
+}
+
+// resToInitContainerRequests returns the largest init container request of each pod in the pod map.
+func (cm *CostModel) resToInitContainerRequests(podMap map[podKey]*Pod, resInitRequests []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) map[podKey]initContainerRequest {
+	requests := map[podKey]initContainerRequest{}
+
+	for _, res := range resInitRequests {
+		key, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
+		if err != nil {
+			log.DedupedWarningf(10, "CostModel.ComputeAllocation: init container request result missing field: %s", err)
+			continue
+		}
+
+		container, err := res.GetString("container")
+		if err != nil {
+			log.DedupedWarningf(10, "CostModel.ComputeAllocation: init container request query result missing 'container': %s", key)
+			continue
+		}
+
+		node, _ := res.GetString("node")
+
+		var keys []podKey
+		if _, ok := podMap[key]; ok {
+			keys = []podKey{key}
+		} else if uidKeys, ok := podUIDKeyMap[key]; ok {
+			keys = uidKeys
+		}
+
+		for _, k := range keys {
+			if req, ok := requests[k]; !ok || res.Values[0].Value > req.value {
+				requests[k] = initContainerRequest{container: container, node: node, value: res.Values[0].Value}
+			}
+		}
+	}
+
+	return requests
+}
+
+// applyInitContainerRequests accounts for the effective request of pods whose largest init container requests
+// more than the sum of the pod's container requests: the scheduler reserves the larger amount for the lifetime of
+// the pod, so the excess is allocated to the init container.
+func (cm *CostModel) applyInitContainerRequests(podMap map[podKey]*Pod, resInitCPURequests []*prom.QueryResult, resInitRAMRequests []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
+	initCPURequests := cm.resToInitContainerRequests(podMap, resInitCPURequests, podUIDKeyMap)
+	initRAMRequests := cm.resToInitContainerRequests(podMap, resInitRAMRequests, podUIDKeyMap)
+
+	initContainerAlloc := func(pod *Pod, req initContainerRequest) *kubecost.Allocation {
+		if _, ok := pod.Allocations[req.container]; !ok {
+			pod.AppendContainer(req.container)
+		}
+		alloc := pod.Allocations[req.container]
+		if alloc.Properties.Node == "" {
+			alloc.Properties.Node = req.node
+		}
+		return alloc
+	}
+
+	for key, pod := range podMap {
+		if req, ok := initCPURequests[key]; ok {
+			requested := 0.0
+			for _, alloc := range pod.Allocations {
+				requested += alloc.CPUCoreRequestAverage
+			}
+			if excess := req.value - requested; excess > 0 {
+				alloc := initContainerAlloc(pod, req)
+				alloc.CPUCoreRequestAverage += excess
+				if alloc.CPUCores() < alloc.CPUCoreRequestAverage {
+					alloc.CPUCoreHours = alloc.CPUCoreRequestAverage * (alloc.Minutes() / 60.0)
+				}
+			}
+		}
+
+		if req, ok := initRAMRequests[key]; ok {
+			requested := 0.0
+			for _, alloc := range pod.Allocations {
+				requested += alloc.RAMBytesRequestAverage
+			}
+			if excess := req.value - requested; excess > 0 {
+				alloc := initContainerAlloc(pod, req)
+				alloc.RAMBytesRequestAverage += excess
+				if alloc.RAMBytes() < alloc.RAMBytesRequestAverage {
+					alloc.RAMByteHours = alloc.RAMBytesRequestAverage * (alloc.Minutes() / 60.0)
+				}
+			}
+		}
+	}
+}
+
+func (cm *CostModel) applyGPUsAllocated(podMap map[podKey]*Pod, resGPUsRequested []*prom.QueryResult, resGPUsAllocated []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
 	if len(resGPUsAllocated) > 0 { // Use the new query, when it's become available in a window
 		resGPUsRequested = resGPUsAllocated
 	}
 	for _, res := range resGPUsRequested {
-		key, err := resultPodKey(res, env.GetPromClusterLabel(), "namespace")
+		key, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
 		if err != nil {
 			log.DedupedWarningf(10, "CostModel.ComputeAllocation: GPU request result missing field: %s", err)
 			continue
@@ -1425,9 +1613,128 @@ func applyGPUsAllocated(podMap map[podKey]*Pod, resGPUsRequested []*prom.QueryRe
 	}
 }
 
-func applyNetworkTotals(podMap map[podKey]*Pod, resNetworkTransferBytes []*prom.QueryResult, resNetworkReceiveBytes []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
+// applyAcceleratorTypes sets the accelerator type label on the allocations of containers requesting GPUs or other
+// accelerators, by the accelerator resources they request.
+func (cm *CostModel) applyAcceleratorTypes(podMap map[podKey]*Pod, resAcceleratorsRequested []*prom.QueryResult, accelerators *cloud.Accelerators, podUIDKeyMap map[podKey][]podKey) {
+	for _, res := range resAcceleratorsRequested {
+		key, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
+		if err != nil {
+			log.DedupedWarningf(10, "CostModel.ComputeAllocation: accelerator request result missing field: %s", err)
+			continue
+		}
+
+		container, err := res.GetString("container")
+		if err != nil {
+			log.DedupedWarningf(10, "CostModel.ComputeAllocation: accelerator request query result missing 'container': %s", key)
+			continue
+		}
+
+		resource, err := res.GetString("resource")
+		if err != nil {
+			continue
+		}
+		accelType := accelerators.TypeOf(resource)
+		if accelType == "" || len(res.Values) == 0 || res.Values[0].Value <= 0 {
+			continue
+		}
+
+		var pods []*Pod
+		if pod, ok := podMap[key]; ok {
+			pods = []*Pod{pod}
+		} else {
+			for _, uidKey := range podUIDKeyMap[key] {
+				if pod, ok := podMap[uidKey]; ok {
+					pods = append(pods, pod)
+				}
+			}
+		}
+
+		for _, pod := range pods {
+			alloc, ok := pod.Allocations[container]
+			if !ok {
+				continue
+			}
+			if alloc.Properties.Labels == nil {
+				alloc.Properties.Labels = map[string]string{}
+			}
+			alloc.Properties.Labels[kubecost.AcceleratorTypeLabel] = accelType
+		}
+	}
+}
+
+// resToExtendedResourceRequests returns the average requests of the hugepages and extended resources of each
+// container's Allocation, by resource.
+func (cm *CostModel) resToExtendedResourceRequests(podMap map[podKey]*Pod, resExtendedResourcesRequested []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) map[*kubecost.Allocation]map[string]float64 {
+	requests := map[*kubecost.Allocation]map[string]float64{}
+
+	for _, res := range resExtendedResourcesRequested {
+		key, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
+		if err != nil {
+			log.DedupedWarningf(10, "CostModel.ComputeAllocation: extended resource request result missing field: %s", err)
+			continue
+		}
+
+		container, err := res.GetString("container")
+		if err != nil {
+			log.DedupedWarningf(10, "CostModel.ComputeAllocation: extended resource request query result missing 'container': %s", key)
+			continue
+		}
+
+		resource, err := res.GetString("resource")
+		if err != nil || resource == "" || len(res.Values) == 0 || res.Values[0].Value <= 0 {
+			continue
+		}
+
+		var pods []*Pod
+		if pod, ok := podMap[key]; ok {
+			pods = []*Pod{pod}
+		} else {
+			for _, uidKey := range podUIDKeyMap[key] {
+				if pod, ok := podMap[uidKey]; ok {
+					pods = append(pods, pod)
+				}
+			}
+		}
+
+		for _, pod := range pods {
+			alloc, ok := pod.Allocations[container]
+			if !ok {
+				continue
+			}
+			if _, ok := requests[alloc]; !ok {
+				requests[alloc] = map[string]float64{}
+			}
+			requests[alloc][resource] = res.Values[0].Value
+		}
+	}
+
+	return requests
+}
+
+// computeExtendedResourceCosts returns the costs of the given requests of hugepages and extended resources over the
+// given hours, on a node with the given hourly RAM price per GiB, by resource.
+func computeExtendedResourceCosts(extendedResources *cloud.ExtendedResources, requests map[string]float64, hours, ramCostPerGiBHr float64) map[string]float64 {
+	if len(requests) == 0 {
+		return nil
+	}
+
+	costs := map[string]float64{}
+	for resource, request := range requests {
+		cost := extendedResources.Cost(resource, request, hours, ramCostPerGiBHr)
+		if cost > 0 {
+			costs[resource] = cost
+		}
+	}
+	if len(costs) == 0 {
+		return nil
+	}
+
+	return costs
+}
+
+func (cm *CostModel) applyNetworkTotals(podMap map[podKey]*Pod, resNetworkTransferBytes []*prom.QueryResult, resNetworkReceiveBytes []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
 	for _, res := range resNetworkTransferBytes {
-		podKey, err := resultPodKey(res, env.GetPromClusterLabel(), "namespace")
+		podKey, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
 		if err != nil {
 			log.DedupedWarningf(10, "CostModel.ComputeAllocation: Network Transfer Bytes query result missing field: %s", err)
 			continue
@@ -1458,7 +1765,7 @@ func applyNetworkTotals(podMap map[podKey]*Pod, resNetworkTransferBytes []*prom.
 		}
 	}
 	for _, res := range resNetworkReceiveBytes {
-		podKey, err := resultPodKey(res, env.GetPromClusterLabel(), "namespace")
+		podKey, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
 		if err != nil {
 			log.DedupedWarningf(10, "CostModel.ComputeAllocation: Network Receive Bytes query result missing field: %s", err)
 			continue
@@ -1490,20 +1797,20 @@ func applyNetworkTotals(podMap map[podKey]*Pod, resNetworkTransferBytes []*prom.
 	}
 }
 
-func applyNetworkAllocation(podMap map[podKey]*Pod, resNetworkGiB []*prom.QueryResult, resNetworkCostPerGiB []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
+func (cm *CostModel) applyNetworkAllocation(podMap map[podKey]*Pod, resNetworkGiB []*prom.QueryResult, resNetworkCostPerGiB []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
 	costPerGiBByCluster := map[string]float64{}
 
 	for _, res := range resNetworkCostPerGiB {
-		cluster, err := res.GetString(env.GetPromClusterLabel())
+		cluster, err := res.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		costPerGiBByCluster[cluster] = res.Values[0].Value
 	}
 
 	for _, res := range resNetworkGiB {
-		podKey, err := resultPodKey(res, env.GetPromClusterLabel(), "namespace")
+		podKey, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
 		if err != nil {
 			log.DedupedWarningf(10, "CostModel.ComputeAllocation: Network allocation query result missing field: %s", err)
 			continue
@@ -1537,11 +1844,70 @@ func applyNetworkAllocation(podMap map[podKey]*Pod, resNetworkGiB []*prom.QueryR
 	}
 }
 
-func resToNamespaceLabels(resNamespaceLabels []*prom.QueryResult) map[namespaceKey]map[string]string {
+// applyNetworkNATGateway adds the NAT gateway data processing cost of the
+// internet egress of pods on nodes whose subnets route through a NAT gateway.
+func (cm *CostModel) applyNetworkNATGateway(podMap map[podKey]*Pod, resNetInternetGiB []*prom.QueryResult, resNATGatewayCostPerGiB []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
+	costPerGiBByNode := map[nodeKey]float64{}
+
+	for _, res := range resNATGatewayCostPerGiB {
+		cluster, err := res.GetString(cm.ClusterLabel)
+		if err != nil {
+			cluster = cm.ClusterID
+		}
+
+		node, err := res.GetString("node")
+		if err != nil {
+			log.DedupedWarningf(10, "CostModel.ComputeAllocation: NAT gateway cost query result missing field: %s", err)
+			continue
+		}
+
+		costPerGiBByNode[newNodeKey(cluster, node)] = res.Values[0].Value
+	}
+
+	if len(costPerGiBByNode) == 0 {
+		return
+	}
+
+	for _, res := range resNetInternetGiB {
+		podKey, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
+		if err != nil {
+			log.DedupedWarningf(10, "CostModel.ComputeAllocation: Network allocation query result missing field: %s", err)
+			continue
+		}
+
+		var pods []*Pod
+
+		pod, ok := podMap[podKey]
+		if !ok {
+			if uidKeys, ok := podUIDKeyMap[podKey]; ok {
+				for _, uidKey := range uidKeys {
+					pod, ok = podMap[uidKey]
+					if ok {
+						pods = append(pods, pod)
+					}
+				}
+			} else {
+				continue
+			}
+		} else {
+			pods = []*Pod{pod}
+		}
+
+		for _, pod := range pods {
+			for _, alloc := range pod.Allocations {
+				costPerGiB := costPerGiBByNode[newNodeKey(podKey.Cluster, alloc.Properties.Node)]
+				gib := res.Values[0].Value / float64(len(pod.Allocations))
+				alloc.NetworkCost += gib * costPerGiB / float64(len(pods))
+			}
+		}
+	}
+}
+
+func (cm *CostModel) resToNamespaceLabels(resNamespaceLabels []*prom.QueryResult) map[namespaceKey]map[string]string {
 	namespaceLabels := map[namespaceKey]map[string]string{}
 
 	for _, res := range resNamespaceLabels {
-		nsKey, err := resultNamespaceKey(res, env.GetPromClusterLabel(), "namespace")
+		nsKey, err := resultNamespaceKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
 		if err != nil {
 			continue
 		}
@@ -1558,11 +1924,11 @@ func resToNamespaceLabels(resNamespaceLabels []*prom.QueryResult) map[namespaceK
 	return namespaceLabels
 }
 
-func resToPodLabels(resPodLabels []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey, ingestPodUID bool) map[podKey]map[string]string {
+func (cm *CostModel) resToPodLabels(resPodLabels []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey, ingestPodUID bool) map[podKey]map[string]string {
 	podLabels := map[podKey]map[string]string{}
 
 	for _, res := range resPodLabels {
-		key, err := resultPodKey(res, env.GetPromClusterLabel(), "namespace")
+		key, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
 		if err != nil {
 			continue
 		}
@@ -1614,11 +1980,11 @@ func resToNamespaceAnnotations(resNamespaceAnnotations []*prom.QueryResult) map[
 	return namespaceAnnotations
 }
 
-func resToPodAnnotations(resPodAnnotations []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey, ingestPodUID bool) map[podKey]map[string]string {
+func (cm *CostModel) resToPodAnnotations(resPodAnnotations []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey, ingestPodUID bool) map[podKey]map[string]string {
 	podAnnotations := map[podKey]map[string]string{}
 
 	for _, res := range resPodAnnotations {
-		key, err := resultPodKey(res, env.GetPromClusterLabel(), "namespace")
+		key, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
 		if err != nil {
 			continue
 		}
@@ -1700,11 +2066,324 @@ func applyAnnotations(podMap map[podKey]*Pod, namespaceAnnotations map[string]ma
 	}
 }
 
-func getServiceLabels(resServiceLabels []*prom.QueryResult) map[serviceKey]map[string]string {
+// applyContainerOverhead sets the overhead label on the allocations of containers whose name or image match the
+// configured overhead patterns.
+func (cm *CostModel) applyContainerOverhead(podMap map[podKey]*Pod, resContainerImages []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) {
+	var cfg *cloud.CustomPricing
+	if cm != nil && cm.Provider != nil {
+		c, err := cm.Provider.GetConfig()
+		if err != nil {
+			log.Errorf("CostModel.ComputeAllocation: applyContainerOverhead: %s", err)
+		}
+		cfg = c
+	}
+
+	overhead, err := cloud.NewContainerOverhead(cfg)
+	if err != nil {
+		log.Errorf("CostModel.ComputeAllocation: applyContainerOverhead: %s", err)
+		return
+	}
+
+	applyOverheadLabels(podMap, cm.resToContainerImages(resContainerImages, podUIDKeyMap), overhead)
+}
+
+// resToContainerImages returns the image of each container, keyed by pod and then container name.
+func (cm *CostModel) resToContainerImages(resContainerImages []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey) map[podKey]map[string]string {
+	images := map[podKey]map[string]string{}
+
+	for _, res := range resContainerImages {
+		key, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
+		if err != nil {
+			log.DedupedWarningf(10, "CostModel.ComputeAllocation: container image result missing field: %s", err)
+			continue
+		}
+
+		container, err := res.GetString("container")
+		if err != nil {
+			continue
+		}
+		image, err := res.GetString("image")
+		if err != nil {
+			continue
+		}
+
+		keys := []podKey{key}
+		if uidKeys, ok := podUIDKeyMap[key]; ok {
+			keys = append(keys, uidKeys...)
+		}
+		for _, k := range keys {
+			if _, ok := images[k]; !ok {
+				images[k] = map[string]string{}
+			}
+			images[k][container] = image
+		}
+	}
+
+	return images
+}
+
+func applyOverheadLabels(podMap map[podKey]*Pod, containerImages map[podKey]map[string]string, overhead *cloud.ContainerOverhead) {
+	if !overhead.Enabled() {
+		return
+	}
+
+	for key, pod := range podMap {
+		for container, alloc := range pod.Allocations {
+			if !overhead.IsOverhead(container, containerImages[key][container]) {
+				continue
+			}
+			if alloc.Properties.Labels == nil {
+				alloc.Properties.Labels = map[string]string{}
+			}
+			alloc.Properties.Labels[cloud.OverheadLabel] = "true"
+		}
+	}
+}
+
+// applyNodeOwners sets the node owner label on the allocations of nodes dedicated to an owner by the node ownership
+// rules, so that all of the node's costs are charged to the owner.
+func (cm *CostModel) applyNodeOwners(podMap map[podKey]*Pod, resNodeLabels []*prom.QueryResult, resNodeTaints []*prom.QueryResult) {
+	rules := cm.nodeOwnerRules()
+	if rules == nil {
+		return
+	}
+
+	applyNodeOwnerLabels(podMap, cm.resToNodeOwners(rules, resNodeLabels, resNodeTaints))
+}
+
+// resToNodeOwners returns the owner of each node dedicated to an owner by the node ownership rules.
+func (cm *CostModel) resToNodeOwners(rules *cloud.NodeOwnerRules, resNodeLabels []*prom.QueryResult, resNodeTaints []*prom.QueryResult) map[nodeKey]string {
+	nodeLabels, nodeTaints := cm.resToNodeLabelsAndTaints(resNodeLabels, resNodeTaints)
+
+	nodeOwners := map[nodeKey]string{}
+	for key, labels := range nodeLabels {
+		if owner := rules.OwnerOf(key.Cluster, labels, nodeTaints[key]); owner != "" {
+			nodeOwners[key] = owner
+		}
+	}
+
+	return nodeOwners
+}
+
+// resToNodeLabelsAndTaints returns the labels and taints of each node. Nodes with taints but no labels are given
+// empty labels, so that every node with either is keyed in the labels.
+func (cm *CostModel) resToNodeLabelsAndTaints(resNodeLabels []*prom.QueryResult, resNodeTaints []*prom.QueryResult) (map[nodeKey]map[string]string, map[nodeKey][]cloud.NodeTaint) {
+	nodeLabels := map[nodeKey]map[string]string{}
+	for _, res := range resNodeLabels {
+		key, err := resultNodeKey(res, cm.ClusterLabel, cm.ClusterID, "node")
+		if err != nil {
+			continue
+		}
+		if _, ok := nodeLabels[key]; !ok {
+			nodeLabels[key] = map[string]string{}
+		}
+		for k, v := range res.GetLabels() {
+			nodeLabels[key][k] = v
+		}
+	}
+
+	nodeTaints := map[nodeKey][]cloud.NodeTaint{}
+	for _, res := range resNodeTaints {
+		key, err := resultNodeKey(res, cm.ClusterLabel, cm.ClusterID, "node")
+		if err != nil {
+			continue
+		}
+		taintKey, err := res.GetString("key")
+		if err != nil {
+			continue
+		}
+		value, _ := res.GetString("value")
+		effect, _ := res.GetString("effect")
+		nodeTaints[key] = append(nodeTaints[key], cloud.NodeTaint{Key: taintKey, Value: value, Effect: effect})
+
+		if _, ok := nodeLabels[key]; !ok {
+			nodeLabels[key] = map[string]string{}
+		}
+	}
+
+	return nodeLabels, nodeTaints
+}
+
+func applyNodeOwnerLabels(podMap map[podKey]*Pod, nodeOwners map[nodeKey]string) {
+	if len(nodeOwners) == 0 {
+		return
+	}
+
+	for _, pod := range podMap {
+		for _, alloc := range pod.Allocations {
+			owner, ok := nodeOwners[newNodeKey(alloc.Properties.Cluster, alloc.Properties.Node)]
+			if !ok {
+				continue
+			}
+			if alloc.Properties.Labels == nil {
+				alloc.Properties.Labels = map[string]string{}
+			}
+			alloc.Properties.Labels[kubecost.NodeOwnerLabel] = owner
+		}
+	}
+}
+
+// applyControlPlaneNodes sets the control plane label on the allocations of the control-plane nodes of self-managed
+// clusters, detected by their role labels and taints, whose costs are reported as cluster management.
+func (cm *CostModel) applyControlPlaneNodes(podMap map[podKey]*Pod, resNodeLabels []*prom.QueryResult, resNodeTaints []*prom.QueryResult) {
+	applyControlPlaneLabels(podMap, cm.resToControlPlaneNodes(resNodeLabels, resNodeTaints))
+}
+
+// resToControlPlaneNodes returns the control-plane nodes.
+func (cm *CostModel) resToControlPlaneNodes(resNodeLabels []*prom.QueryResult, resNodeTaints []*prom.QueryResult) map[nodeKey]bool {
+	nodeLabels, nodeTaints := cm.resToNodeLabelsAndTaints(resNodeLabels, resNodeTaints)
+
+	controlPlaneNodes := map[nodeKey]bool{}
+	for key, labels := range nodeLabels {
+		if cloud.IsControlPlaneNode(labels, nodeTaints[key]) {
+			controlPlaneNodes[key] = true
+		}
+	}
+
+	return controlPlaneNodes
+}
+
+func applyControlPlaneLabels(podMap map[podKey]*Pod, controlPlaneNodes map[nodeKey]bool) {
+	if len(controlPlaneNodes) == 0 {
+		return
+	}
+
+	for _, pod := range podMap {
+		for _, alloc := range pod.Allocations {
+			if !controlPlaneNodes[newNodeKey(alloc.Properties.Cluster, alloc.Properties.Node)] {
+				continue
+			}
+			if alloc.Properties.Labels == nil {
+				alloc.Properties.Labels = map[string]string{}
+			}
+			alloc.Properties.Labels[kubecost.ControlPlaneLabel] = "true"
+		}
+	}
+}
+
+// isControlPlaneAllocation matches the allocations of the control-plane nodes of self-managed clusters.
+func isControlPlaneAllocation(alloc *kubecost.Allocation) bool {
+	if alloc == nil || alloc.Properties == nil {
+		return false
+	}
+	return alloc.Properties.Labels[kubecost.ControlPlaneLabel] == "true"
+}
+
+// applyControlPlaneCosts offsets the CPU, RAM and GPU costs of the allocations of control-plane nodes by cost
+// adjustments. The costs of control-plane nodes are reported as ClusterManagement assets, like the control-plane fees
+// of managed clusters, rather than as nodes, so the allocations on them must not be charged for them again. Extended
+// resources are priced from their nodes, so their costs are dropped.
+func applyControlPlaneCosts(allocSet *kubecost.AllocationSet) {
+	allocSet.Each(func(_ string, alloc *kubecost.Allocation) {
+		if !isControlPlaneAllocation(alloc) {
+			return
+		}
+		alloc.CPUCostAdjustment = -alloc.CPUCost
+		alloc.RAMCostAdjustment = -alloc.RAMCost
+		alloc.GPUCostAdjustment = -alloc.GPUCost
+		alloc.ExtendedResourceCosts = nil
+	})
+}
+
+// applyPriceMultipliers scales the CPU and RAM costs of allocations by the configured multipliers of their pods' QoS
+// classes and PriorityClasses. The difference is reported as a cost adjustment on each allocation and flows to the
+// idle cost of its node, which may become negative, so that the cluster's total cost is unchanged and idle sharing
+// redistributes it.
+func (cm *CostModel) applyPriceMultipliers(allocSet *kubecost.AllocationSet, resPodQoSClass, resPodPriorityClass []*prom.QueryResult) {
+	var cfg *cloud.CustomPricing
+	if cm != nil && cm.Provider != nil {
+		c, err := cm.Provider.GetConfig()
+		if err != nil {
+			log.Errorf("CostModel.ComputeAllocation: applyPriceMultipliers: %s", err)
+		}
+		cfg = c
+	}
+
+	pm, err := cloud.NewPriceMultipliers(cfg)
+	if err != nil {
+		log.Errorf("CostModel.ComputeAllocation: applyPriceMultipliers: %s", err)
+		return
+	}
+	if !pm.Enabled() {
+		return
+	}
+
+	applyPodPriceMultipliers(allocSet, pm, cm.resToPodClasses(resPodQoSClass, "qos_class"), cm.resToPodClasses(resPodPriorityClass, "priority_class"))
+}
+
+// resToPodClasses maps pods to the value of the given class label, e.g. their QoS class or PriorityClass.
+func (cm *CostModel) resToPodClasses(resPodClass []*prom.QueryResult, classLabel string) map[podKey]string {
+	podClasses := map[podKey]string{}
+
+	for _, res := range resPodClass {
+		key, err := resultPodKey(res, cm.ClusterLabel, cm.ClusterID, "namespace")
+		if err != nil {
+			continue
+		}
+		class, err := res.GetString(classLabel)
+		if err != nil || class == "" {
+			continue
+		}
+		podClasses[key] = class
+	}
+
+	return podClasses
+}
+
+func applyPodPriceMultipliers(allocSet *kubecost.AllocationSet, pm *cloud.PriceMultipliers, podQoSClasses, podPriorityClasses map[podKey]string) {
+	idleAdjustments := map[nodeKey]*kubecost.Allocation{}
+
+	allocSet.Each(func(_ string, alloc *kubecost.Allocation) {
+		// The costs of control-plane nodes are not charged to their allocations, nor to their idle
+		if alloc.IsIdle() || alloc.IsUnmounted() || alloc.Properties == nil || isControlPlaneAllocation(alloc) {
+			return
+		}
+
+		// Pods are suffixed with their UIDs when ingesting UIDs
+		pod := strings.SplitN(alloc.Properties.Pod, " ", 2)[0]
+		key := newPodKey(alloc.Properties.Cluster, alloc.Properties.Namespace, pod)
+		m := pm.Multiplier(podQoSClasses[key], podPriorityClasses[key])
+		if m == 1 {
+			return
+		}
+
+		cpuAdjustment := (m - 1) * alloc.CPUCost
+		ramAdjustment := (m - 1) * alloc.RAMCost
+		alloc.CPUCostAdjustment += cpuAdjustment
+		alloc.RAMCostAdjustment += ramAdjustment
+
+		nk := newNodeKey(alloc.Properties.Cluster, alloc.Properties.Node)
+		idle, ok := idleAdjustments[nk]
+		if !ok {
+			idle = &kubecost.Allocation{
+				Name: fmt.Sprintf("%s/%s/%s", alloc.Properties.Cluster, alloc.Properties.Node, kubecost.IdleSuffix),
+				Properties: &kubecost.AllocationProperties{
+					Cluster:    alloc.Properties.Cluster,
+					Node:       alloc.Properties.Node,
+					ProviderID: alloc.Properties.ProviderID,
+				},
+				Window: allocSet.Window.Clone(),
+				Start:  allocSet.Start(),
+				End:    allocSet.End(),
+			}
+			idleAdjustments[nk] = idle
+		}
+		idle.CPUCost -= cpuAdjustment
+		idle.RAMCost -= ramAdjustment
+	})
+
+	for _, idle := range idleAdjustments {
+		if err := allocSet.Insert(idle); err != nil {
+			log.Errorf("CostModel.ComputeAllocation: applyPriceMultipliers: %s", err)
+		}
+	}
+}
+
+func (cm *CostModel) getServiceLabels(resServiceLabels []*prom.QueryResult) map[serviceKey]map[string]string {
 	serviceLabels := map[serviceKey]map[string]string{}
 
 	for _, res := range resServiceLabels {
-		serviceKey, err := resultServiceKey(res, env.GetPromClusterLabel(), "namespace", "service")
+		serviceKey, err := resultServiceKey(res, cm.ClusterLabel, cm.ClusterID, "namespace", "service")
 		if err != nil {
 			continue
 		}
@@ -1733,11 +2412,11 @@ func getServiceLabels(resServiceLabels []*prom.QueryResult) map[serviceKey]map[s
 	return serviceLabels
 }
 
-func resToDeploymentLabels(resDeploymentLabels []*prom.QueryResult) map[controllerKey]map[string]string {
+func (cm *CostModel) resToDeploymentLabels(resDeploymentLabels []*prom.QueryResult) map[controllerKey]map[string]string {
 	deploymentLabels := map[controllerKey]map[string]string{}
 
 	for _, res := range resDeploymentLabels {
-		controllerKey, err := resultDeploymentKey(res, env.GetPromClusterLabel(), "namespace", "deployment")
+		controllerKey, err := resultDeploymentKey(res, cm.ClusterLabel, cm.ClusterID, "namespace", "deployment")
 		if err != nil {
 			continue
 		}
@@ -1766,11 +2445,11 @@ func resToDeploymentLabels(resDeploymentLabels []*prom.QueryResult) map[controll
 	return deploymentLabels
 }
 
-func resToStatefulSetLabels(resStatefulSetLabels []*prom.QueryResult) map[controllerKey]map[string]string {
+func (cm *CostModel) resToStatefulSetLabels(resStatefulSetLabels []*prom.QueryResult) map[controllerKey]map[string]string {
 	statefulSetLabels := map[controllerKey]map[string]string{}
 
 	for _, res := range resStatefulSetLabels {
-		controllerKey, err := resultStatefulSetKey(res, env.GetPromClusterLabel(), "namespace", "statefulSet")
+		controllerKey, err := resultStatefulSetKey(res, cm.ClusterLabel, cm.ClusterID, "namespace", "statefulSet")
 		if err != nil {
 			continue
 		}
@@ -1828,11 +2507,11 @@ func labelsToPodControllerMap(podLabels map[podKey]map[string]string, controller
 	return podControllerMap
 }
 
-func resToPodDaemonSetMap(resDaemonSetLabels []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey, ingestPodUID bool) map[podKey]controllerKey {
+func (cm *CostModel) resToPodDaemonSetMap(resDaemonSetLabels []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey, ingestPodUID bool) map[podKey]controllerKey {
 	daemonSetLabels := map[podKey]controllerKey{}
 
 	for _, res := range resDaemonSetLabels {
-		controllerKey, err := resultDaemonSetKey(res, env.GetPromClusterLabel(), "namespace", "owner_name")
+		controllerKey, err := resultDaemonSetKey(res, cm.ClusterLabel, cm.ClusterID, "namespace", "owner_name")
 		if err != nil {
 			continue
 		}
@@ -1864,11 +2543,11 @@ func resToPodDaemonSetMap(resDaemonSetLabels []*prom.QueryResult, podUIDKeyMap m
 	return daemonSetLabels
 }
 
-func resToPodJobMap(resJobLabels []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey, ingestPodUID bool) map[podKey]controllerKey {
+func (cm *CostModel) resToPodJobMap(resJobLabels []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey, ingestPodUID bool) map[podKey]controllerKey {
 	jobLabels := map[podKey]controllerKey{}
 
 	for _, res := range resJobLabels {
-		controllerKey, err := resultJobKey(res, env.GetPromClusterLabel(), "namespace", "owner_name")
+		controllerKey, err := resultJobKey(res, cm.ClusterLabel, cm.ClusterID, "namespace", "owner_name")
 		if err != nil {
 			continue
 		}
@@ -1907,7 +2586,7 @@ func resToPodJobMap(resJobLabels []*prom.QueryResult, podUIDKeyMap map[podKey][]
 	return jobLabels
 }
 
-func resToPodReplicaSetMap(resPodsWithReplicaSetOwner []*prom.QueryResult, resReplicaSetsWithoutOwners []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey, ingestPodUID bool) map[podKey]controllerKey {
+func (cm *CostModel) resToPodReplicaSetMap(resPodsWithReplicaSetOwner []*prom.QueryResult, resReplicaSetsWithoutOwners []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey, ingestPodUID bool) map[podKey]controllerKey {
 	// Build out set of ReplicaSets that have no owners, themselves, such that
 	// the ReplicaSet should be used as the owner of the Pods it controls.
 	// (This should exclude, for example, ReplicaSets that are controlled by
@@ -1915,7 +2594,7 @@ func resToPodReplicaSetMap(resPodsWithReplicaSetOwner []*prom.QueryResult, resRe
 	replicaSets := map[controllerKey]struct{}{}
 
 	for _, res := range resReplicaSetsWithoutOwners {
-		controllerKey, err := resultReplicaSetKey(res, env.GetPromClusterLabel(), "namespace", "replicaset")
+		controllerKey, err := resultReplicaSetKey(res, cm.ClusterLabel, cm.ClusterID, "namespace", "replicaset")
 		if err != nil {
 			continue
 		}
@@ -1928,7 +2607,7 @@ func resToPodReplicaSetMap(resPodsWithReplicaSetOwner []*prom.QueryResult, resRe
 	podToReplicaSet := map[podKey]controllerKey{}
 
 	for _, res := range resPodsWithReplicaSetOwner {
-		controllerKey, err := resultReplicaSetKey(res, env.GetPromClusterLabel(), "namespace", "owner_name")
+		controllerKey, err := resultReplicaSetKey(res, cm.ClusterLabel, cm.ClusterID, "namespace", "owner_name")
 		if err != nil {
 			continue
 		}
@@ -2019,11 +2698,11 @@ func applyControllersToPods(podMap map[podKey]*Pod, podControllerMap map[podKey]
 	}
 }
 
-func applyNodeCostPerCPUHr(nodeMap map[nodeKey]*NodePricing, resNodeCostPerCPUHr []*prom.QueryResult) {
+func (cm *CostModel) applyNodeCostPerCPUHr(nodeMap map[nodeKey]*NodePricing, resNodeCostPerCPUHr []*prom.QueryResult) {
 	for _, res := range resNodeCostPerCPUHr {
-		cluster, err := res.GetString(env.GetPromClusterLabel())
+		cluster, err := res.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		node, err := res.GetString("node")
@@ -2057,11 +2736,11 @@ func applyNodeCostPerCPUHr(nodeMap map[nodeKey]*NodePricing, resNodeCostPerCPUHr
 	}
 }
 
-func applyNodeCostPerRAMGiBHr(nodeMap map[nodeKey]*NodePricing, resNodeCostPerRAMGiBHr []*prom.QueryResult) {
+func (cm *CostModel) applyNodeCostPerRAMGiBHr(nodeMap map[nodeKey]*NodePricing, resNodeCostPerRAMGiBHr []*prom.QueryResult) {
 	for _, res := range resNodeCostPerRAMGiBHr {
-		cluster, err := res.GetString(env.GetPromClusterLabel())
+		cluster, err := res.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		node, err := res.GetString("node")
@@ -2095,11 +2774,11 @@ func applyNodeCostPerRAMGiBHr(nodeMap map[nodeKey]*NodePricing, resNodeCostPerRA
 	}
 }
 
-func applyNodeCostPerGPUHr(nodeMap map[nodeKey]*NodePricing, resNodeCostPerGPUHr []*prom.QueryResult) {
+func (cm *CostModel) applyNodeCostPerGPUHr(nodeMap map[nodeKey]*NodePricing, resNodeCostPerGPUHr []*prom.QueryResult) {
 	for _, res := range resNodeCostPerGPUHr {
-		cluster, err := res.GetString(env.GetPromClusterLabel())
+		cluster, err := res.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		node, err := res.GetString("node")
@@ -2133,11 +2812,11 @@ func applyNodeCostPerGPUHr(nodeMap map[nodeKey]*NodePricing, resNodeCostPerGPUHr
 	}
 }
 
-func applyNodeSpot(nodeMap map[nodeKey]*NodePricing, resNodeIsSpot []*prom.QueryResult) {
+func (cm *CostModel) applyNodeSpot(nodeMap map[nodeKey]*NodePricing, resNodeIsSpot []*prom.QueryResult) {
 	for _, res := range resNodeIsSpot {
-		cluster, err := res.GetString(env.GetPromClusterLabel())
+		cluster, err := res.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		node, err := res.GetString("node")
@@ -2187,11 +2866,239 @@ func applyNodeDiscount(nodeMap map[nodeKey]*NodePricing, cm *CostModel) {
 	}
 }
 
-func buildPVMap(pvMap map[pvKey]*PV, resPVCostPerGiBHour []*prom.QueryResult) {
+// applyFargateConfigurations bills pods running on EKS Fargate nodes for the
+// configuration their node is priced at, per second with a one minute
+// minimum, rather than for their requests or usage. The configuration is
+// split among the pod's containers in proportion to their requests.
+func applyFargateConfigurations(podMap map[podKey]*Pod, nodeMap map[nodeKey]*NodePricing) {
+	for _, pod := range podMap {
+		var conf cloud.FargateConfiguration
+		isFargate := false
+		var cpuRequests, ramRequests float64
+		for _, alloc := range pod.Allocations {
+			node, ok := nodeMap[newNodeKey(alloc.Properties.Cluster, alloc.Properties.Node)]
+			if !ok {
+				continue
+			}
+			if c, ok := cloud.ParseFargateInstanceType(node.NodeType); ok {
+				conf = c
+				isFargate = true
+			}
+			cpuRequests += alloc.CPUCoreRequestAverage
+			ramRequests += alloc.RAMBytesRequestAverage
+		}
+		if !isFargate {
+			continue
+		}
+
+		// Per second rounding and the one minute minimum only apply to the
+		// window in which the pod started.
+		hours := pod.End.Sub(pod.Start).Hours()
+		if pod.Window.Start() == nil || pod.Start.After(*pod.Window.Start()) {
+			hours = cloud.FargateBillableHours(pod.End.Sub(pod.Start))
+		}
+		count := float64(len(pod.Allocations))
+		for _, alloc := range pod.Allocations {
+			cpuShare := 1.0 / count
+			if cpuRequests > 0 {
+				cpuShare = alloc.CPUCoreRequestAverage / cpuRequests
+			}
+			ramShare := 1.0 / count
+			if ramRequests > 0 {
+				ramShare = alloc.RAMBytesRequestAverage / ramRequests
+			}
+			alloc.CPUCoreHours = conf.VCPU * hours * cpuShare
+			alloc.RAMByteHours = conf.RAMBytes() * hours * ramShare
+		}
+	}
+}
+
+// applyAutopilotRequests bills pods running on GKE Autopilot nodes for their
+// requests, adjusted to the minimums and ratios of the node's compute class,
+// rather than for the larger of their requests and usage. The adjusted
+// requests are split among the pod's containers in proportion to their
+// requests.
+func applyAutopilotRequests(podMap map[podKey]*Pod, nodeMap map[nodeKey]*NodePricing) {
+	for _, pod := range podMap {
+		var class cloud.AutopilotComputeClass
+		isAutopilot := false
+		var cpuRequests, ramRequests float64
+		for _, alloc := range pod.Allocations {
+			node, ok := nodeMap[newNodeKey(alloc.Properties.Cluster, alloc.Properties.Node)]
+			if !ok {
+				continue
+			}
+			if c, ok := cloud.ParseAutopilotInstanceType(node.NodeType); ok {
+				class = c
+				isAutopilot = true
+			}
+			cpuRequests += alloc.CPUCoreRequestAverage
+			ramRequests += alloc.RAMBytesRequestAverage
+		}
+		if !isAutopilot {
+			continue
+		}
+
+		cpu, ram := cloud.AutopilotBilledRequests(class, cpuRequests, ramRequests)
+		count := float64(len(pod.Allocations))
+		for _, alloc := range pod.Allocations {
+			hours := alloc.Minutes() / 60.0
+			cpuShare := 1.0 / count
+			if cpuRequests > 0 {
+				cpuShare = alloc.CPUCoreRequestAverage / cpuRequests
+			}
+			ramShare := 1.0 / count
+			if ramRequests > 0 {
+				ramShare = alloc.RAMBytesRequestAverage / ramRequests
+			}
+			alloc.CPUCoreHours = cpu * hours * cpuShare
+			alloc.RAMByteHours = ram * hours * ramShare
+		}
+	}
+}
+
+// nodeCPUUsage is the CPU cores used by the containers on a node at every
+// resolution step of the credit accrual period before a window, Prior, and
+// of the window itself, Window, in time order.
+type nodeCPUUsage struct {
+	Prior  []float64
+	Window []float64
+}
+
+// resToNodeCPUUsage returns the CPU cores used by the containers on each node
+// before and after the given start of the window.
+func (cm *CostModel) resToNodeCPUUsage(resNodeCPUUsage []*prom.QueryResult, start time.Time) map[nodeKey]*nodeCPUUsage {
+	nodeCPUUsages := map[nodeKey]*nodeCPUUsage{}
+
+	for _, res := range resNodeCPUUsage {
+		cluster, err := res.GetString(cm.ClusterLabel)
+		if err != nil {
+			cluster = cm.ClusterID
+		}
+
+		node, err := res.GetString("node")
+		if node == "" || err != nil {
+			node, err = res.GetString("instance")
+			if err != nil {
+				log.DedupedWarningf(10, "CostModel.ComputeAllocation: node CPU usage result missing node")
+				continue
+			}
+		}
+
+		values := make(util.VectorSlice, len(res.Values))
+		copy(values, res.Values)
+		sort.Sort(values)
+
+		key := newNodeKey(cluster, node)
+		usage, ok := nodeCPUUsages[key]
+		if !ok {
+			usage = &nodeCPUUsage{}
+			nodeCPUUsages[key] = usage
+		}
+		for _, v := range values {
+			if v.Timestamp <= float64(start.Unix()) {
+				usage.Prior = append(usage.Prior, v.Value)
+			} else {
+				usage.Window = append(usage.Window, v.Value)
+			}
+		}
+	}
+
+	return nodeCPUUsages
+}
+
+// getBurstableCreditSurcharges estimates the surplus CPU credits spent by
+// nodes on burstable instance types over the window and returns their cost
+// by Allocation. Each container is entitled to a share of the node's baseline
+// in proportion to its CPU request; the cost is split among the containers
+// in proportion to their usage above that share.
+func (cm *CostModel) getBurstableCreditSurcharges(podMap map[podKey]*Pod, nodeMap map[nodeKey]*NodePricing, nodeCPUUsages map[nodeKey]*nodeCPUUsage, resolution time.Duration) map[*kubecost.Allocation]float64 {
+	var cfg *cloud.CustomPricing
+	if cm != nil && cm.Provider != nil {
+		c, err := cm.Provider.GetConfig()
+		if err != nil {
+			log.Errorf("CostModel.ComputeAllocation: getBurstableCreditSurcharges: %s", err)
+		}
+		cfg = c
+	}
+
+	return applyBurstableCreditSurcharges(podMap, nodeMap, nodeCPUUsages, resolution, cfg)
+}
+
+func applyBurstableCreditSurcharges(podMap map[podKey]*Pod, nodeMap map[nodeKey]*NodePricing, nodeCPUUsages map[nodeKey]*nodeCPUUsage, resolution time.Duration, cfg *cloud.CustomPricing) map[*kubecost.Allocation]float64 {
+	surcharges := map[*kubecost.Allocation]float64{}
+
+	allocsByNode := map[nodeKey][]*kubecost.Allocation{}
+	for _, pod := range podMap {
+		for _, alloc := range pod.Allocations {
+			key := newNodeKey(alloc.Properties.Cluster, alloc.Properties.Node)
+			allocsByNode[key] = append(allocsByNode[key], alloc)
+		}
+	}
+
+	for key, node := range nodeMap {
+		burstable, ok := cloud.BurstableBaseline(node.NodeType)
+		if !ok {
+			continue
+		}
+		allocs := allocsByNode[key]
+		if len(allocs) == 0 {
+			continue
+		}
+
+		cost := burstableCreditSurcharge(node.NodeType, nodeCPUUsages[key], resolution, cfg)
+		if cost <= 0 {
+			continue
+		}
+
+		totalRequests := 0.0
+		for _, alloc := range allocs {
+			totalRequests += alloc.CPUCoreRequestAverage
+		}
+
+		excess := make([]float64, len(allocs))
+		totalExcess := 0.0
+		for i, alloc := range allocs {
+			baselineShare := burstable.BaselineCores / float64(len(allocs))
+			if totalRequests > 0 {
+				baselineShare = burstable.BaselineCores * alloc.CPUCoreRequestAverage / totalRequests
+			}
+			excess[i] = math.Max(0, alloc.CPUCoreUsageAverage-baselineShare) * alloc.Minutes() / 60.0
+			totalExcess += excess[i]
+		}
+
+		for i, alloc := range allocs {
+			// Usage outside of containers may exhaust the credits, in which
+			// case the cost is split evenly
+			if totalExcess > 0 {
+				surcharges[alloc] += cost * excess[i] / totalExcess
+			} else {
+				surcharges[alloc] += cost / float64(len(allocs))
+			}
+		}
+	}
+
+	return surcharges
+}
+
+// burstableCreditSurcharge returns the cost of the surplus CPU credits spent
+// by a node of the given instance type, with the given CPU usage sampled at
+// every step, or zero if the instance type is not burstable.
+func burstableCreditSurcharge(nodeType string, usage *nodeCPUUsage, step time.Duration, cfg *cloud.CustomPricing) float64 {
+	burstable, ok := cloud.BurstableBaseline(nodeType)
+	if !ok || usage == nil {
+		return 0
+	}
+
+	surplusHours := cloud.SurplusCreditHours(burstable.BaselineCores, usage.Prior, usage.Window, step)
+	return surplusHours * cloud.BurstableCreditPrice(burstable, cfg)
+}
+
+func (cm *CostModel) buildPVMap(pvMap map[pvKey]*PV, resPVCostPerGiBHour []*prom.QueryResult) {
 	for _, res := range resPVCostPerGiBHour {
-		cluster, err := res.GetString(env.GetPromClusterLabel())
+		cluster, err := res.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		name, err := res.GetString("volumename")
@@ -2210,9 +3117,9 @@ func buildPVMap(pvMap map[pvKey]*PV, resPVCostPerGiBHour []*prom.QueryResult) {
 	}
 }
 
-func applyPVBytes(pvMap map[pvKey]*PV, resPVBytes []*prom.QueryResult) {
+func (cm *CostModel) applyPVBytes(pvMap map[pvKey]*PV, resPVBytes []*prom.QueryResult) {
 	for _, res := range resPVBytes {
-		key, err := resultPVKey(res, env.GetPromClusterLabel(), "persistentvolume")
+		key, err := resultPVKey(res, cm.ClusterLabel, cm.ClusterID, "persistentvolume")
 		if err != nil {
 			log.Warnf("CostModel.ComputeAllocation: PV bytes query result missing field: %s", err)
 			continue
@@ -2227,11 +3134,11 @@ func applyPVBytes(pvMap map[pvKey]*PV, resPVBytes []*prom.QueryResult) {
 	}
 }
 
-func buildPVCMap(window kubecost.Window, pvcMap map[pvcKey]*PVC, pvMap map[pvKey]*PV, resPVCInfo []*prom.QueryResult) {
+func (cm *CostModel) buildPVCMap(window kubecost.Window, pvcMap map[pvcKey]*PVC, pvMap map[pvKey]*PV, resPVCInfo []*prom.QueryResult) {
 	for _, res := range resPVCInfo {
-		cluster, err := res.GetString(env.GetPromClusterLabel())
+		cluster, err := res.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		values, err := res.GetStrings("persistentvolumeclaim", "storageclass", "volumename", "namespace")
@@ -2286,9 +3193,9 @@ func buildPVCMap(window kubecost.Window, pvcMap map[pvcKey]*PVC, pvMap map[pvKey
 	}
 }
 
-func applyPVCBytesRequested(pvcMap map[pvcKey]*PVC, resPVCBytesRequested []*prom.QueryResult) {
+func (cm *CostModel) applyPVCBytesRequested(pvcMap map[pvcKey]*PVC, resPVCBytesRequested []*prom.QueryResult) {
 	for _, res := range resPVCBytesRequested {
-		key, err := resultPVCKey(res, env.GetPromClusterLabel(), "namespace", "persistentvolumeclaim")
+		key, err := resultPVCKey(res, cm.ClusterLabel, cm.ClusterID, "namespace", "persistentvolumeclaim")
 		if err != nil {
 			continue
 		}
@@ -2301,11 +3208,11 @@ func applyPVCBytesRequested(pvcMap map[pvcKey]*PVC, resPVCBytesRequested []*prom
 	}
 }
 
-func buildPodPVCMap(podPVCMap map[podKey][]*PVC, pvMap map[pvKey]*PV, pvcMap map[pvcKey]*PVC, podMap map[podKey]*Pod, resPodPVCAllocation []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey, ingestPodUID bool) {
+func (cm *CostModel) buildPodPVCMap(podPVCMap map[podKey][]*PVC, pvMap map[pvKey]*PV, pvcMap map[pvcKey]*PVC, podMap map[podKey]*Pod, resPodPVCAllocation []*prom.QueryResult, podUIDKeyMap map[podKey][]podKey, ingestPodUID bool) {
 	for _, res := range resPodPVCAllocation {
-		cluster, err := res.GetString(env.GetPromClusterLabel())
+		cluster, err := res.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		values, err := res.GetStrings("persistentvolume", "persistentvolumeclaim", "pod", "namespace")
@@ -2487,18 +3394,18 @@ type LB struct {
 	End       time.Time
 }
 
-func getLoadBalancerCosts(resLBCost, resLBActiveMins []*prom.QueryResult, resolution time.Duration) map[serviceKey]*LB {
+func (cm *CostModel) getLoadBalancerCosts(resLBCost, resLBActiveMins []*prom.QueryResult, resolution time.Duration) map[serviceKey]*LB {
 	lbMap := make(map[serviceKey]*LB)
 	lbHourlyCosts := make(map[serviceKey]float64)
 	for _, res := range resLBCost {
-		serviceKey, err := resultServiceKey(res, env.GetPromClusterLabel(), "namespace", "service_name")
+		serviceKey, err := resultServiceKey(res, cm.ClusterLabel, cm.ClusterID, "namespace", "service_name")
 		if err != nil {
 			continue
 		}
 		lbHourlyCosts[serviceKey] = res.Values[0].Value
 	}
 	for _, res := range resLBActiveMins {
-		serviceKey, err := resultServiceKey(res, env.GetPromClusterLabel(), "namespace", "service_name")
+		serviceKey, err := resultServiceKey(res, cm.ClusterLabel, cm.ClusterID, "namespace", "service_name")
 		if err != nil || len(res.Values) == 0 {
 			continue
 		}
@@ -0,0 +1,1040 @@
+package costmodel
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/cloud"
+	"github.com/kubecost/opencost/pkg/kubecost"
+	"github.com/kubecost/opencost/pkg/prom"
+	"github.com/kubecost/opencost/pkg/util"
+)
+
+func TestApplyFargateConfigurations(t *testing.T) {
+	windowStart := time.Date(2021, 2, 19, 8, 0, 0, 0, time.UTC)
+	windowEnd := time.Date(2021, 2, 19, 9, 0, 0, 0, time.UTC)
+	window := kubecost.NewClosedWindow(windowStart, windowEnd)
+	gib := 1024.0 * 1024.0 * 1024.0
+
+	newAlloc := func(node, container string, cpuRequest, ramRequest, cpuCoreHours, ramByteHours float64) *kubecost.Allocation {
+		return &kubecost.Allocation{
+			Properties: &kubecost.AllocationProperties{
+				Cluster:   "cluster1",
+				Node:      node,
+				Namespace: "namespace1",
+				Container: container,
+			},
+			CPUCoreRequestAverage:  cpuRequest,
+			RAMBytesRequestAverage: ramRequest,
+			CPUCoreHours:           cpuCoreHours,
+			RAMByteHours:           ramByteHours,
+		}
+	}
+
+	nodeMap := map[nodeKey]*NodePricing{
+		newNodeKey("cluster1", "fargate-ip-10-0-1-2.ec2.internal"): {
+			Name:     "fargate-ip-10-0-1-2.ec2.internal",
+			NodeType: "fargate-0.5vCPU-1GB",
+		},
+		newNodeKey("cluster1", "node1"): {
+			Name:     "node1",
+			NodeType: "m5.large",
+		},
+	}
+
+	cases := []struct {
+		name     string
+		pod      *Pod
+		expected map[string][2]float64 // container -> CPUCoreHours, RAMByteHours
+	}{
+		{
+			name: "short-lived pod is billed for one minute, split by requests",
+			pod: &Pod{
+				Window: window,
+				Start:  windowStart.Add(10 * time.Minute),
+				End:    windowStart.Add(10*time.Minute + 30*time.Second),
+				Allocations: map[string]*kubecost.Allocation{
+					"app":     newAlloc("fargate-ip-10-0-1-2.ec2.internal", "app", 0.3, 0.25*gib, 0.0025, 0.25*gib/120),
+					"sidecar": newAlloc("fargate-ip-10-0-1-2.ec2.internal", "sidecar", 0.1, 0.25*gib, 0.0008, 0.25*gib/120),
+				},
+			},
+			expected: map[string][2]float64{
+				"app":     {0.5 * 0.75 / 60, gib * 0.5 / 60},
+				"sidecar": {0.5 * 0.25 / 60, gib * 0.5 / 60},
+			},
+		},
+		{
+			name: "seconds are rounded up",
+			pod: &Pod{
+				Window: window,
+				Start:  windowStart.Add(10 * time.Minute),
+				End:    windowStart.Add(12*time.Minute + 500*time.Millisecond),
+				Allocations: map[string]*kubecost.Allocation{
+					"app": newAlloc("fargate-ip-10-0-1-2.ec2.internal", "app", 0, 0, 0, 0),
+				},
+			},
+			expected: map[string][2]float64{
+				"app": {0.5 * 121 / 3600, gib * 121 / 3600},
+			},
+		},
+		{
+			name: "pod running before the window is billed for its minutes in the window",
+			pod: &Pod{
+				Window: window,
+				Start:  windowStart,
+				End:    windowStart.Add(30 * time.Second),
+				Allocations: map[string]*kubecost.Allocation{
+					"app": newAlloc("fargate-ip-10-0-1-2.ec2.internal", "app", 0.25, 0.5*gib, 0.25/120, 0.5*gib/120),
+				},
+			},
+			expected: map[string][2]float64{
+				"app": {0.5 / 120, gib / 120},
+			},
+		},
+		{
+			name: "pod on an EC2 node is unchanged",
+			pod: &Pod{
+				Window: window,
+				Start:  windowStart,
+				End:    windowEnd,
+				Allocations: map[string]*kubecost.Allocation{
+					"app": newAlloc("node1", "app", 0.25, 0.5*gib, 0.25, 0.5*gib),
+				},
+			},
+			expected: map[string][2]float64{
+				"app": {0.25, 0.5 * gib},
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			podMap := map[podKey]*Pod{
+				newPodKey("cluster1", "namespace1", "pod1"): testCase.pod,
+			}
+			applyFargateConfigurations(podMap, nodeMap)
+
+			for container, expected := range testCase.expected {
+				alloc := testCase.pod.Allocations[container]
+				if math.Abs(alloc.CPUCoreHours-expected[0]) > 1e-9 {
+					t.Errorf("%s: expected CPUCoreHours %f, actual %f", container, expected[0], alloc.CPUCoreHours)
+				}
+				if math.Abs(alloc.RAMByteHours-expected[1]) > 1e-3 {
+					t.Errorf("%s: expected RAMByteHours %f, actual %f", container, expected[1], alloc.RAMByteHours)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyAutopilotRequests(t *testing.T) {
+	windowStart := time.Date(2021, 2, 19, 8, 0, 0, 0, time.UTC)
+	windowEnd := time.Date(2021, 2, 19, 9, 0, 0, 0, time.UTC)
+	gib := 1024.0 * 1024.0 * 1024.0
+
+	newAlloc := func(node, container string, cpuRequest, ramRequest float64, minutes int) *kubecost.Allocation {
+		return &kubecost.Allocation{
+			Properties: &kubecost.AllocationProperties{
+				Cluster:   "cluster1",
+				Node:      node,
+				Namespace: "namespace1",
+				Container: container,
+			},
+			Start:                  windowStart,
+			End:                    windowStart.Add(time.Duration(minutes) * time.Minute),
+			CPUCoreRequestAverage:  cpuRequest,
+			RAMBytesRequestAverage: ramRequest,
+			CPUCoreHours:           cpuRequest * float64(minutes) / 60,
+			RAMByteHours:           ramRequest * float64(minutes) / 60,
+		}
+	}
+
+	nodeMap := map[nodeKey]*NodePricing{
+		newNodeKey("cluster1", "gk3-cluster-pool-1-0a1b2c3d-x1y2"): {
+			Name:     "gk3-cluster-pool-1-0a1b2c3d-x1y2",
+			NodeType: "autopilot-general-purpose",
+		},
+		newNodeKey("cluster1", "gk3-cluster-nap-1a2b3c4d-x1y2"): {
+			Name:     "gk3-cluster-nap-1a2b3c4d-x1y2",
+			NodeType: "autopilot-scale-out",
+		},
+		newNodeKey("cluster1", "node1"): {
+			Name:     "node1",
+			NodeType: "n2-standard-4",
+		},
+	}
+
+	cases := []struct {
+		name     string
+		pod      *Pod
+		expected map[string][2]float64 // container -> CPUCoreHours, RAMByteHours
+	}{
+		{
+			name: "pod requests are raised to the minimums, split by requests",
+			pod: &Pod{
+				Start: windowStart,
+				End:   windowEnd,
+				Allocations: map[string]*kubecost.Allocation{
+					"app":     newAlloc("gk3-cluster-pool-1-0a1b2c3d-x1y2", "app", 0.15, 0.3*gib, 60),
+					"sidecar": newAlloc("gk3-cluster-pool-1-0a1b2c3d-x1y2", "sidecar", 0.05, 0.1*gib, 60),
+				},
+			},
+			expected: map[string][2]float64{
+				"app":     {0.25 * 0.75, 0.5 * gib * 0.75},
+				"sidecar": {0.25 * 0.25, 0.5 * gib * 0.25},
+			},
+		},
+		{
+			name: "pod without requests is billed the minimums for its minutes",
+			pod: &Pod{
+				Start: windowStart,
+				End:   windowStart.Add(30 * time.Minute),
+				Allocations: map[string]*kubecost.Allocation{
+					"app": newAlloc("gk3-cluster-pool-1-0a1b2c3d-x1y2", "app", 0, 0, 30),
+				},
+			},
+			expected: map[string][2]float64{
+				"app": {0.25 * 0.5, 0.5 * gib * 0.5},
+			},
+		},
+		{
+			name: "scale-out memory follows the fixed ratio",
+			pod: &Pod{
+				Start: windowStart,
+				End:   windowEnd,
+				Allocations: map[string]*kubecost.Allocation{
+					"app": newAlloc("gk3-cluster-nap-1a2b3c4d-x1y2", "app", 1, gib, 60),
+				},
+			},
+			expected: map[string][2]float64{
+				"app": {1, 4 * gib},
+			},
+		},
+		{
+			name: "pod on a standard node is unchanged",
+			pod: &Pod{
+				Start: windowStart,
+				End:   windowEnd,
+				Allocations: map[string]*kubecost.Allocation{
+					"app": newAlloc("node1", "app", 0.1, 0.1*gib, 60),
+				},
+			},
+			expected: map[string][2]float64{
+				"app": {0.1, 0.1 * gib},
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			podMap := map[podKey]*Pod{
+				newPodKey("cluster1", "namespace1", "pod1"): testCase.pod,
+			}
+			applyAutopilotRequests(podMap, nodeMap)
+
+			for container, expected := range testCase.expected {
+				alloc := testCase.pod.Allocations[container]
+				if math.Abs(alloc.CPUCoreHours-expected[0]) > 1e-9 {
+					t.Errorf("%s: expected CPUCoreHours %f, actual %f", container, expected[0], alloc.CPUCoreHours)
+				}
+				if math.Abs(alloc.RAMByteHours-expected[1]) > 1e-3 {
+					t.Errorf("%s: expected RAMByteHours %f, actual %f", container, expected[1], alloc.RAMByteHours)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyBurstableCreditSurcharges(t *testing.T) {
+	windowStart := time.Date(2021, 2, 19, 8, 0, 0, 0, time.UTC)
+	windowEnd := time.Date(2021, 2, 19, 9, 0, 0, 0, time.UTC)
+
+	newAlloc := func(node, container string, cpuRequest, cpuUsage float64) *kubecost.Allocation {
+		return &kubecost.Allocation{
+			Properties: &kubecost.AllocationProperties{
+				Cluster:   "cluster1",
+				Node:      node,
+				Namespace: "namespace1",
+				Container: container,
+			},
+			Start:                 windowStart,
+			End:                   windowEnd,
+			CPUCoreRequestAverage: cpuRequest,
+			CPUCoreUsageAverage:   cpuUsage,
+		}
+	}
+
+	// flat returns a usage curve of one sample per minute over the window
+	flat := func(cores float64) *nodeCPUUsage {
+		usage := make([]float64, 60)
+		for i := range usage {
+			usage[i] = cores
+		}
+		return &nodeCPUUsage{Window: usage}
+	}
+
+	nodeMap := map[nodeKey]*NodePricing{
+		newNodeKey("cluster1", "t3-node"): {
+			Name:     "t3-node",
+			NodeType: "t3.large", // 0.6 core baseline
+		},
+		newNodeKey("cluster1", "m5-node"): {
+			Name:     "m5-node",
+			NodeType: "m5.large",
+		},
+		newNodeKey("cluster1", "t2-node"): {
+			Name:     "t2-node",
+			NodeType: "t2.large", // 0.6 core baseline
+		},
+	}
+
+	cases := []struct {
+		name     string
+		allocs   map[string]*kubecost.Allocation
+		usage    map[nodeKey]*nodeCPUUsage
+		cfg      *cloud.CustomPricing
+		expected map[string]float64
+	}{
+		{
+			name: "surcharge is attributed to usage above the baseline share",
+			allocs: map[string]*kubecost.Allocation{
+				"busy":  newAlloc("t3-node", "busy", 0.2, 1.2),
+				"quiet": newAlloc("t3-node", "quiet", 0.1, 0.1),
+			},
+			usage: map[nodeKey]*nodeCPUUsage{newNodeKey("cluster1", "t3-node"): flat(1.6)},
+			// 1 surplus vCPU-hour at $0.05; busy is entitled to 0.4 cores, quiet to 0.2
+			expected: map[string]float64{"busy": 0.05, "quiet": 0},
+		},
+		{
+			name: "surcharge is split among all containers over their share",
+			allocs: map[string]*kubecost.Allocation{
+				"a": newAlloc("t3-node", "a", 0.1, 1.3),
+				"b": newAlloc("t3-node", "b", 0.1, 0.5),
+			},
+			usage: map[nodeKey]*nodeCPUUsage{newNodeKey("cluster1", "t3-node"): flat(1.8)},
+			cfg:   &cloud.CustomPricing{BurstableCreditPrice: "0.1"},
+			// 1.2 surplus vCPU-hours at $0.10; excess is 1.0 and 0.2 cores over a 0.3 core share
+			expected: map[string]float64{"a": 0.1, "b": 0.02},
+		},
+		{
+			name: "usage within the baseline is not surcharged",
+			allocs: map[string]*kubecost.Allocation{
+				"app": newAlloc("t3-node", "app", 0.5, 0.5),
+			},
+			usage:    map[nodeKey]*nodeCPUUsage{newNodeKey("cluster1", "t3-node"): flat(0.5)},
+			expected: map[string]float64{"app": 0},
+		},
+		{
+			name: "non-burstable nodes are not surcharged",
+			allocs: map[string]*kubecost.Allocation{
+				"app": newAlloc("m5-node", "app", 0.5, 2),
+			},
+			usage:    map[nodeKey]*nodeCPUUsage{newNodeKey("cluster1", "m5-node"): flat(2)},
+			expected: map[string]float64{"app": 0},
+		},
+		{
+			name: "credits banked before the window are spent first",
+			allocs: map[string]*kubecost.Allocation{
+				"app": newAlloc("t3-node", "app", 0.6, 1.6),
+			},
+			usage: map[nodeKey]*nodeCPUUsage{newNodeKey("cluster1", "t3-node"): {
+				Prior:  flat(0.1).Window,
+				Window: flat(1.6).Window,
+			}},
+			// 0.5 vCPU-hours banked in the prior hour cover half of the 1 surplus vCPU-hour
+			expected: map[string]float64{"app": 0.025},
+		},
+		{
+			name: "standard mode nodes are throttled rather than surcharged",
+			allocs: map[string]*kubecost.Allocation{
+				"app": newAlloc("t2-node", "app", 0.2, 1.6),
+			},
+			usage:    map[nodeKey]*nodeCPUUsage{newNodeKey("cluster1", "t2-node"): flat(1.6)},
+			expected: map[string]float64{"app": 0},
+		},
+		{
+			name: "standard mode nodes configured as unlimited are surcharged",
+			allocs: map[string]*kubecost.Allocation{
+				"app": newAlloc("t2-node", "app", 0.2, 1.6),
+			},
+			usage:    map[nodeKey]*nodeCPUUsage{newNodeKey("cluster1", "t2-node"): flat(1.6)},
+			cfg:      &cloud.CustomPricing{BurstableCreditMode: "unlimited"},
+			expected: map[string]float64{"app": 0.05},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			podMap := map[podKey]*Pod{
+				newPodKey("cluster1", "namespace1", "pod1"): {
+					Start:       windowStart,
+					End:         windowEnd,
+					Allocations: testCase.allocs,
+				},
+			}
+			surcharges := applyBurstableCreditSurcharges(podMap, nodeMap, testCase.usage, time.Minute, testCase.cfg)
+
+			for container, expected := range testCase.expected {
+				actual := surcharges[testCase.allocs[container]]
+				if math.Abs(actual-expected) > 1e-9 {
+					t.Errorf("%s: expected surcharge %f, actual %f", container, expected, actual)
+				}
+			}
+
+			// The nodes are surcharged what their containers are
+			nodeSurcharge, allocSurcharge := 0.0, 0.0
+			for key, usage := range testCase.usage {
+				nodeSurcharge += burstableCreditSurcharge(nodeMap[key].NodeType, usage, time.Minute, testCase.cfg)
+			}
+			for _, surcharge := range surcharges {
+				allocSurcharge += surcharge
+			}
+			if math.Abs(nodeSurcharge-allocSurcharge) > 1e-9 {
+				t.Errorf("expected node surcharge %f to equal allocation surcharges %f", nodeSurcharge, allocSurcharge)
+			}
+		})
+	}
+}
+
+func TestApplyNetworkNATGateway(t *testing.T) {
+	newAlloc := func(node, container string, networkCost float64) *kubecost.Allocation {
+		return &kubecost.Allocation{
+			Properties: &kubecost.AllocationProperties{
+				Cluster:   "cluster1",
+				Node:      node,
+				Namespace: "namespace1",
+				Container: container,
+			},
+			NetworkCost: networkCost,
+		}
+	}
+
+	podMap := map[podKey]*Pod{
+		newPodKey("cluster1", "namespace1", "private-pod"): {
+			Allocations: map[string]*kubecost.Allocation{
+				"app":     newAlloc("private-node", "app", 1.2),
+				"sidecar": newAlloc("private-node", "sidecar", 1.2),
+			},
+		},
+		newPodKey("cluster1", "namespace1", "public-pod"): {
+			Allocations: map[string]*kubecost.Allocation{
+				"app": newAlloc("public-node", "app", 1.2),
+			},
+		},
+	}
+
+	// Internet egress of each pod, as recorded by the network costs daemonset
+	resNetInternetGiB := []*prom.QueryResult{
+		{
+			Metric: map[string]interface{}{
+				"cluster_id": "cluster1",
+				"namespace":  "namespace1",
+				"pod_name":   "private-pod",
+			},
+			Values: []*util.Vector{{Value: 20}},
+		},
+		{
+			Metric: map[string]interface{}{
+				"cluster_id": "cluster1",
+				"namespace":  "namespace1",
+				"pod_name":   "public-pod",
+			},
+			Values: []*util.Vector{{Value: 10}},
+		},
+	}
+
+	resNATGatewayCostPerGiB := []*prom.QueryResult{
+		{
+			Metric: map[string]interface{}{
+				"cluster_id": "cluster1",
+				"node":       "private-node",
+			},
+			Values: []*util.Vector{{Value: 0.045}},
+		},
+		{
+			Metric: map[string]interface{}{
+				"cluster_id": "cluster1",
+				"node":       "public-node",
+			},
+			Values: []*util.Vector{{Value: 0}},
+		},
+	}
+
+	testCostModel.applyNetworkNATGateway(podMap, resNetInternetGiB, resNATGatewayCostPerGiB, map[podKey][]podKey{})
+
+	expected := map[string]map[string]float64{
+		"private-pod": {"app": 1.2 + 10*0.045, "sidecar": 1.2 + 10*0.045},
+		"public-pod":  {"app": 1.2},
+	}
+	for pod, containers := range expected {
+		for container, cost := range containers {
+			alloc := podMap[newPodKey("cluster1", "namespace1", pod)].Allocations[container]
+			if math.Abs(alloc.NetworkCost-cost) > 1e-9 {
+				t.Errorf("%s/%s: expected NetworkCost %f, actual %f", pod, container, cost, alloc.NetworkCost)
+			}
+		}
+	}
+}
+
+func TestApplyInitContainerRequests(t *testing.T) {
+	start := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	window := kubecost.NewWindow(&start, &end)
+
+	newPod := func(name string, cpuRequests map[string]float64) *Pod {
+		pod := &Pod{
+			Window:      window,
+			Start:       start,
+			End:         end,
+			Key:         newPodKey("cluster1", "namespace1", name),
+			Allocations: map[string]*kubecost.Allocation{},
+		}
+		for container, cores := range cpuRequests {
+			pod.AppendContainer(container)
+			pod.Allocations[container].Properties.Node = "node1"
+			pod.Allocations[container].CPUCoreRequestAverage = cores
+			pod.Allocations[container].CPUCoreHours = cores * 2
+			pod.Allocations[container].RAMBytesRequestAverage = 1024 * 1024 * 1024
+			pod.Allocations[container].RAMByteHours = 2 * 1024 * 1024 * 1024
+		}
+		return pod
+	}
+
+	podMap := map[podKey]*Pod{
+		newPodKey("cluster1", "namespace1", "migrating-pod"):  newPod("migrating-pod", map[string]float64{"app": 0.5, "istio-proxy": 0.1}),
+		newPodKey("cluster1", "namespace1", "small-init-pod"): newPod("small-init-pod", map[string]float64{"app": 1}),
+	}
+
+	newResult := func(pod, container string, value float64) *prom.QueryResult {
+		return &prom.QueryResult{
+			Metric: map[string]interface{}{
+				"cluster_id": "cluster1",
+				"namespace":  "namespace1",
+				"pod":        pod,
+				"container":  container,
+				"node":       "node1",
+			},
+			Values: []*util.Vector{{Value: value}},
+		}
+	}
+
+	resInitCPURequests := []*prom.QueryResult{
+		newResult("migrating-pod", "istio-init", 0.1),
+		newResult("migrating-pod", "migrate", 2),
+		newResult("small-init-pod", "wait", 0.5),
+	}
+	resInitRAMRequests := []*prom.QueryResult{
+		newResult("migrating-pod", "migrate", 4*1024*1024*1024),
+	}
+
+	testCostModel.applyInitContainerRequests(podMap, resInitCPURequests, resInitRAMRequests, map[podKey][]podKey{})
+
+	// The largest init container requests 2 cores and 4GiB against the 0.6 cores and 2GiB of the containers
+	migrating := podMap[newPodKey("cluster1", "namespace1", "migrating-pod")]
+	migrate, ok := migrating.Allocations["migrate"]
+	if !ok {
+		t.Fatalf("expected an allocation for the init container migrate")
+	}
+	if math.Abs(migrate.CPUCoreRequestAverage-1.4) > 1e-9 || math.Abs(migrate.CPUCoreHours-2.8) > 1e-9 {
+		t.Errorf("migrate: expected 1.4 cores requested over 2.8 core-hours, actual %f over %f", migrate.CPUCoreRequestAverage, migrate.CPUCoreHours)
+	}
+	gib := 1024.0 * 1024 * 1024
+	if math.Abs(migrate.RAMBytesRequestAverage-2*gib) > 1 || math.Abs(migrate.RAMByteHours-4*gib) > 1 {
+		t.Errorf("migrate: expected 2GiB requested over 4GiB-hours, actual %f over %f", migrate.RAMBytesRequestAverage/gib, migrate.RAMByteHours/gib)
+	}
+	if migrate.Properties.Node != "node1" {
+		t.Errorf("migrate: expected node node1, actual %s", migrate.Properties.Node)
+	}
+	if _, ok := migrating.Allocations["istio-init"]; ok {
+		t.Errorf("expected no allocation for the init container istio-init")
+	}
+
+	// Init containers requesting less than the containers do not change the pod's effective request
+	small := podMap[newPodKey("cluster1", "namespace1", "small-init-pod")]
+	if len(small.Allocations) != 1 || small.Allocations["app"].CPUCoreHours != 2 {
+		t.Errorf("small-init-pod: expected only the app allocation with 2 core-hours, actual %v", small.Allocations)
+	}
+}
+
+func TestApplyOverheadLabels(t *testing.T) {
+	podMap := map[podKey]*Pod{
+		newPodKey("cluster1", "namespace1", "pod1"): {
+			Allocations: map[string]*kubecost.Allocation{
+				"app":         {Properties: &kubecost.AllocationProperties{Labels: map[string]string{"app": "web"}}},
+				"istio-proxy": {Properties: &kubecost.AllocationProperties{Labels: map[string]string{"app": "web"}}},
+				"logs":        {Properties: &kubecost.AllocationProperties{}},
+			},
+		},
+	}
+	containerImages := map[podKey]map[string]string{
+		newPodKey("cluster1", "namespace1", "pod1"): {
+			"app":         "example.com/web:1.0",
+			"istio-proxy": "docker.io/istio/proxyv2:1.17.1",
+			"logs":        "cr.fluentbit.io/fluent/fluent-bit:2.0",
+		},
+	}
+
+	overhead, err := cloud.NewContainerOverhead(&cloud.CustomPricing{
+		OverheadContainerNames:  "istio-proxy,linkerd-proxy",
+		OverheadContainerImages: ".*/fluent-bit:.*",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	applyOverheadLabels(podMap, containerImages, overhead)
+
+	expected := map[string]string{"app": "", "istio-proxy": "true", "logs": "true"}
+	for container, value := range expected {
+		alloc := podMap[newPodKey("cluster1", "namespace1", "pod1")].Allocations[container]
+		if alloc.Properties.Labels[cloud.OverheadLabel] != value {
+			t.Errorf("%s: expected %s label %q, actual %q", container, cloud.OverheadLabel, value, alloc.Properties.Labels[cloud.OverheadLabel])
+		}
+	}
+	if !isOverheadAllocation(podMap[newPodKey("cluster1", "namespace1", "pod1")].Allocations["istio-proxy"]) {
+		t.Errorf("expected istio-proxy to be shared as overhead")
+	}
+}
+
+func TestApplyNodeOwnerLabels(t *testing.T) {
+	rules, err := cloud.ParseNodeOwnerRules([]byte(`{"rules": [
+		{"owner": "ml", "taints": ["dedicated=ml:NoSchedule"]},
+		{"owner": "payments", "nodePool": "payments-pool"}
+	]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	resNodeLabels := []*prom.QueryResult{
+		{
+			Metric: map[string]interface{}{
+				"cluster_id":                          "cluster1",
+				"node":                                "payments-node",
+				"label_cloud_google_com_gke_nodepool": "payments-pool",
+			},
+			Values: []*util.Vector{{Value: 1}},
+		},
+		{
+			Metric: map[string]interface{}{
+				"cluster_id":                          "cluster1",
+				"node":                                "shared-node",
+				"label_cloud_google_com_gke_nodepool": "default-pool",
+			},
+			Values: []*util.Vector{{Value: 1}},
+		},
+	}
+	resNodeTaints := []*prom.QueryResult{
+		{
+			Metric: map[string]interface{}{
+				"cluster_id": "cluster1",
+				"node":       "gpu-node",
+				"key":        "dedicated",
+				"value":      "ml",
+				"effect":     "NoSchedule",
+			},
+			Values: []*util.Vector{{Value: 1}},
+		},
+	}
+
+	podMap := map[podKey]*Pod{}
+	for _, node := range []string{"payments-node", "shared-node", "gpu-node"} {
+		podMap[newPodKey("cluster1", "namespace1", node+"-pod")] = &Pod{
+			Allocations: map[string]*kubecost.Allocation{
+				"app": {Properties: &kubecost.AllocationProperties{Cluster: "cluster1", Node: node}},
+			},
+		}
+	}
+
+	applyNodeOwnerLabels(podMap, testCostModel.resToNodeOwners(rules, resNodeLabels, resNodeTaints))
+
+	expected := map[string]string{"payments-node": "payments", "shared-node": "", "gpu-node": "ml"}
+	for node, owner := range expected {
+		alloc := podMap[newPodKey("cluster1", "namespace1", node+"-pod")].Allocations["app"]
+		if alloc.Properties.Labels[kubecost.NodeOwnerLabel] != owner {
+			t.Errorf("%s: expected owner %q, actual %q", node, owner, alloc.Properties.Labels[kubecost.NodeOwnerLabel])
+		}
+	}
+}
+
+func TestApplyPodPriceMultipliers(t *testing.T) {
+	start := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	pm, err := cloud.NewPriceMultipliers(&cloud.CustomPricing{
+		QoSGuaranteedMultiplier:  "1.5",
+		QoSBestEffortMultiplier:  "0.1",
+		PriorityClassMultipliers: "batch-low:0.5",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	resPodQoSClass := []*prom.QueryResult{}
+	for pod, qosClass := range map[string]string{"guaranteed": "Guaranteed", "besteffort": "BestEffort", "batch": "Burstable", "burstable": "Burstable"} {
+		resPodQoSClass = append(resPodQoSClass, &prom.QueryResult{
+			Metric: map[string]interface{}{"cluster_id": "cluster1", "namespace": "namespace1", "pod": pod, "qos_class": qosClass},
+			Values: []*util.Vector{{Value: 1}},
+		})
+	}
+	resPodPriorityClass := []*prom.QueryResult{
+		{
+			Metric: map[string]interface{}{"cluster_id": "cluster1", "namespace": "namespace1", "pod": "batch", "priority_class": "batch-low"},
+			Values: []*util.Vector{{Value: 1}},
+		},
+	}
+
+	as := kubecost.NewAllocationSet(start, end)
+	newAlloc := func(node, pod string, cpuCost, ramCost float64) {
+		as.Set(&kubecost.Allocation{
+			Name:       "cluster1/" + node + "/namespace1/" + pod + "/app",
+			Properties: &kubecost.AllocationProperties{Cluster: "cluster1", Node: node, Namespace: "namespace1", Pod: pod, Container: "app"},
+			Window:     kubecost.NewWindow(&start, &end),
+			Start:      start,
+			End:        end,
+			CPUCost:    cpuCost,
+			RAMCost:    ramCost,
+		})
+	}
+	newAlloc("node1", "guaranteed", 4, 2)
+	// Pods are suffixed with their UIDs when ingesting UIDs
+	newAlloc("node1", "besteffort 9a6f8c62-0b1e-4c1b-a5a1-1c2d3e4f5a6b", 1, 1)
+	newAlloc("node2", "batch", 2, 4)
+	newAlloc("node2", "burstable", 8, 8)
+	totalCost := as.TotalCost()
+
+	applyPodPriceMultipliers(as, pm, testCostModel.resToPodClasses(resPodQoSClass, "qos_class"), testCostModel.resToPodClasses(resPodPriorityClass, "priority_class"))
+
+	cases := []struct {
+		name     string
+		expected float64
+	}{
+		{name: "cluster1/node1/namespace1/guaranteed/app", expected: 9},
+		{name: "cluster1/node1/namespace1/besteffort 9a6f8c62-0b1e-4c1b-a5a1-1c2d3e4f5a6b/app", expected: 0.2},
+		{name: "cluster1/node2/namespace1/batch/app", expected: 3},
+		{name: "cluster1/node2/namespace1/burstable/app", expected: 16},
+		// Each node's idle offsets the adjustments of its allocations
+		{name: "cluster1/node1/" + kubecost.IdleSuffix, expected: -(3 - 1.8)},
+		{name: "cluster1/node2/" + kubecost.IdleSuffix, expected: 3},
+	}
+	for _, test := range cases {
+		alloc := as.Get(test.name)
+		if alloc == nil {
+			t.Errorf("%s: expected allocation", test.name)
+			continue
+		}
+		if math.Abs(alloc.TotalCost()-test.expected) > 1e-9 {
+			t.Errorf("%s: expected total cost %f, actual %f", test.name, test.expected, alloc.TotalCost())
+		}
+	}
+
+	guaranteed := as.Get("cluster1/node1/namespace1/guaranteed/app")
+	if guaranteed.CPUCost != 4 || guaranteed.CPUCostAdjustment != 2 || guaranteed.RAMCost != 2 || guaranteed.RAMCostAdjustment != 1 {
+		t.Errorf("guaranteed: expected unchanged costs with adjustments of 2 and 1, actual %+v", guaranteed)
+	}
+
+	// The cost of the cluster is conserved, before and after aggregation
+	if math.Abs(as.TotalCost()-totalCost) > 1e-9 {
+		t.Errorf("expected total cost %f, actual %f", totalCost, as.TotalCost())
+	}
+	if err := as.AggregateBy([]string{kubecost.AllocationNamespaceProp}, &kubecost.AllocationAggregationOptions{ShareIdle: kubecost.ShareWeighted}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if math.Abs(as.TotalCost()-totalCost) > 1e-9 {
+		t.Errorf("aggregated: expected total cost %f, actual %f", totalCost, as.TotalCost())
+	}
+}
+
+func TestApplyControlPlaneLabels(t *testing.T) {
+	resNodeLabels := []*prom.QueryResult{
+		{
+			Metric: map[string]interface{}{
+				"cluster_id": "cluster1",
+				"node":       "cp-node",
+				"label_node_role_kubernetes_io_control_plane": "",
+			},
+			Values: []*util.Vector{{Value: 1}},
+		},
+		{
+			Metric: map[string]interface{}{
+				"cluster_id":                           "cluster1",
+				"node":                                 "worker-node",
+				"label_node_role_kubernetes_io_worker": "true",
+			},
+			Values: []*util.Vector{{Value: 1}},
+		},
+	}
+	resNodeTaints := []*prom.QueryResult{
+		{
+			Metric: map[string]interface{}{
+				"cluster_id": "cluster1",
+				"node":       "master-node",
+				"key":        "node-role.kubernetes.io/master",
+				"effect":     "NoSchedule",
+			},
+			Values: []*util.Vector{{Value: 1}},
+		},
+	}
+
+	podMap := map[podKey]*Pod{}
+	for _, node := range []string{"cp-node", "worker-node", "master-node"} {
+		podMap[newPodKey("cluster1", "kube-system", node+"-pod")] = &Pod{
+			Allocations: map[string]*kubecost.Allocation{
+				"app": {Properties: &kubecost.AllocationProperties{Cluster: "cluster1", Node: node}},
+			},
+		}
+	}
+
+	applyControlPlaneLabels(podMap, testCostModel.resToControlPlaneNodes(resNodeLabels, resNodeTaints))
+
+	expected := map[string]string{"cp-node": "true", "worker-node": "", "master-node": "true"}
+	for node, label := range expected {
+		alloc := podMap[newPodKey("cluster1", "kube-system", node+"-pod")].Allocations["app"]
+		if alloc.Properties.Labels[kubecost.ControlPlaneLabel] != label {
+			t.Errorf("%s: expected control plane label %q, actual %q", node, label, alloc.Properties.Labels[kubecost.ControlPlaneLabel])
+		}
+	}
+}
+
+func TestApplyControlPlaneCosts(t *testing.T) {
+	start := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	window := kubecost.NewWindow(&start, &end)
+
+	pm, err := cloud.NewPriceMultipliers(&cloud.CustomPricing{QoSGuaranteedMultiplier: "1.5"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	as := kubecost.NewAllocationSet(start, end)
+	newAlloc := func(node, pod string, labels map[string]string) *kubecost.Allocation {
+		alloc := &kubecost.Allocation{
+			Name:                  "cluster1/" + node + "/kube-system/" + pod + "/app",
+			Properties:            &kubecost.AllocationProperties{Cluster: "cluster1", Node: node, Namespace: "kube-system", Pod: pod, Container: "app", Labels: labels},
+			Window:                window.Clone(),
+			Start:                 start,
+			End:                   end,
+			CPUCoreHours:          24,
+			CPUCost:               2,
+			RAMCost:               1,
+			GPUCost:               0.5,
+			ExtendedResourceCosts: map[string]float64{"hugepages_2Mi": 0.1},
+		}
+		as.Set(alloc)
+		return alloc
+	}
+	apiServer := newAlloc("cp-node", "kube-apiserver", map[string]string{kubecost.ControlPlaneLabel: "true"})
+	coreDNS := newAlloc("worker-node", "coredns", nil)
+
+	podQoSClasses := map[podKey]string{
+		newPodKey("cluster1", "kube-system", "kube-apiserver"): "Guaranteed",
+	}
+	applyPodPriceMultipliers(as, pm, podQoSClasses, nil)
+	applyControlPlaneCosts(as)
+
+	// The control-plane node is reported as cluster management, so neither its
+	// allocations nor its idle are charged for it
+	if apiServer.TotalCost() != 0 || apiServer.CPUCost != 2 || apiServer.CPUCoreHours != 24 {
+		t.Errorf("expected the control-plane allocation to keep its resources and list costs at no cost, actual %f costing %f", apiServer.CPUCost, apiServer.TotalCost())
+	}
+	if as.Get("cluster1/cp-node/"+kubecost.IdleSuffix) != nil {
+		t.Errorf("expected no idle adjustment of the control-plane node")
+	}
+	if math.Abs(coreDNS.TotalCost()-3.6) > 1e-9 {
+		t.Errorf("expected the worker allocation to cost 3.6, actual %f", coreDNS.TotalCost())
+	}
+
+	// The cost of the control-plane node is counted once, by the cluster
+	// management asset, rather than by the allocations too
+	nodeMap := map[NodeIdentifier]*Node{
+		{Cluster: "cluster1", Name: "cp-node"}: {Cluster: "cluster1", Name: "cp-node", CPUCost: 4, RAMCost: 2, ControlPlane: true},
+	}
+	if cost := ClusterControlPlanes("", nodeMap, window)["cluster1"].Cost; cost != 6 {
+		t.Errorf("expected cluster management to cost 6, actual %f", cost)
+	}
+	if math.Abs(as.TotalCost()-coreDNS.TotalCost()) > 1e-9 {
+		t.Errorf("expected the allocations to cost %f, actual %f", coreDNS.TotalCost(), as.TotalCost())
+	}
+}
+
+func TestApplyAcceleratorTypes(t *testing.T) {
+	accelerators, err := cloud.NewAccelerators(&cloud.CustomPricing{AcceleratorResources: "example.com/npu:npu"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Each pod requests the accelerators of one vendor; the cpu pod requests none
+	resources := map[string]string{
+		"nvidia-pod": "nvidia_com_gpu",
+		"amd-pod":    "amd_com_gpu",
+		"intel-pod":  "gpu_intel_com_i915",
+		"tpu-pod":    "google_com_tpu",
+		"neuron-pod": "aws_amazon_com_neuron",
+		"npu-pod":    "example_com_npu",
+	}
+
+	podMap := map[podKey]*Pod{}
+	resAcceleratorsRequested := []*prom.QueryResult{}
+	for _, pod := range []string{"nvidia-pod", "amd-pod", "intel-pod", "tpu-pod", "neuron-pod", "npu-pod", "cpu-pod"} {
+		podMap[newPodKey("cluster1", "namespace1", pod)] = &Pod{
+			Allocations: map[string]*kubecost.Allocation{
+				"app": {Properties: &kubecost.AllocationProperties{Cluster: "cluster1", Namespace: "namespace1", Pod: pod}},
+			},
+		}
+		if resource, ok := resources[pod]; ok {
+			resAcceleratorsRequested = append(resAcceleratorsRequested, &prom.QueryResult{
+				Metric: map[string]interface{}{
+					"cluster_id": "cluster1",
+					"namespace":  "namespace1",
+					"pod":        pod,
+					"container":  "app",
+					"node":       "node1",
+					"resource":   resource,
+				},
+				Values: []*util.Vector{{Value: 1}},
+			})
+		}
+	}
+
+	testCostModel.applyAcceleratorTypes(podMap, resAcceleratorsRequested, accelerators, map[podKey][]podKey{})
+
+	expected := map[string]string{
+		"nvidia-pod": cloud.AcceleratorNVIDIA,
+		"amd-pod":    cloud.AcceleratorAMD,
+		"intel-pod":  cloud.AcceleratorIntel,
+		"tpu-pod":    cloud.AcceleratorTPU,
+		"neuron-pod": cloud.AcceleratorNeuron,
+		"npu-pod":    "npu",
+		"cpu-pod":    "",
+	}
+	for pod, accelType := range expected {
+		alloc := podMap[newPodKey("cluster1", "namespace1", pod)].Allocations["app"]
+		if alloc.Properties.Labels[kubecost.AcceleratorTypeLabel] != accelType {
+			t.Errorf("%s: expected accelerator type %q, actual %q", pod, accelType, alloc.Properties.Labels[kubecost.AcceleratorTypeLabel])
+		}
+	}
+}
+
+func TestComputeExtendedResourceCosts(t *testing.T) {
+	extendedResources, err := cloud.NewExtendedResources(&cloud.CustomPricing{ExtendedResourcePrices: "xilinx.com/fpga:0.95"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	gib := 1024.0 * 1024.0 * 1024.0
+
+	podMap := map[podKey]*Pod{}
+	for _, pod := range []string{"cnf-pod", "fpga-pod", "web-pod"} {
+		podMap[newPodKey("cluster1", "namespace1", pod)] = &Pod{
+			Allocations: map[string]*kubecost.Allocation{
+				"app": {Properties: &kubecost.AllocationProperties{Cluster: "cluster1", Node: "node1", Namespace: "namespace1", Pod: pod}},
+			},
+		}
+	}
+
+	requestResult := func(pod, resource string, value float64) *prom.QueryResult {
+		return &prom.QueryResult{
+			Metric: map[string]interface{}{
+				"cluster_id": "cluster1",
+				"namespace":  "namespace1",
+				"pod":        pod,
+				"container":  "app",
+				"node":       "node1",
+				"resource":   resource,
+			},
+			Values: []*util.Vector{{Value: value}},
+		}
+	}
+	resExtendedResourcesRequested := []*prom.QueryResult{
+		requestResult("cnf-pod", "hugepages_1Gi", 4*gib),
+		requestResult("cnf-pod", "intel_com_sriov_netdevice", 2),
+		requestResult("fpga-pod", "xilinx_com_fpga", 1),
+	}
+
+	requests := testCostModel.resToExtendedResourceRequests(podMap, resExtendedResourcesRequested, map[podKey][]podKey{})
+
+	expected := map[string]map[string]float64{
+		// 4GiB of hugepages at the node's RAM price, and no cost for the unpriced virtual functions
+		"cnf-pod": {
+			"hugepages_1Gi": 4 * 0.005 * 10,
+		},
+		"fpga-pod": {
+			"xilinx_com_fpga": 0.95 * 10,
+		},
+		"web-pod": nil,
+	}
+	for pod, costs := range expected {
+		alloc := podMap[newPodKey("cluster1", "namespace1", pod)].Allocations["app"]
+		actual := computeExtendedResourceCosts(extendedResources, requests[alloc], 10, 0.005)
+		if len(actual) != len(costs) {
+			t.Fatalf("%s: expected %d extended resource costs, actual %d", pod, len(costs), len(actual))
+		}
+		for resource, cost := range costs {
+			if !util.IsApproximately(actual[resource], cost) {
+				t.Errorf("%s: expected %s cost %f, actual %f", pod, resource, cost, actual[resource])
+			}
+		}
+	}
+}
+
+func TestExtendedResourceCostsConserveNodeCost(t *testing.T) {
+	extendedResources, err := cloud.NewExtendedResources(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	gib := 1024.0 * 1024.0 * 1024.0
+	hours := 10.0
+
+	// A node of 4 cores and 16GiB of RAM, of which 4GiB are reserved as
+	// hugepages, with 8 SR-IOV virtual functions
+	cpuPrice, ramPrice := 0.03, 0.005
+	nodeCost := (4*cpuPrice + 16*ramPrice) * hours
+
+	podMap := map[podKey]*Pod{}
+	for _, pod := range []string{"cnf-pod", "web-pod"} {
+		podMap[newPodKey("cluster1", "namespace1", pod)] = &Pod{
+			Allocations: map[string]*kubecost.Allocation{
+				"app": {Properties: &kubecost.AllocationProperties{Cluster: "cluster1", Node: "node1", Namespace: "namespace1", Pod: pod}},
+			},
+		}
+	}
+	requestResult := func(pod, resource string, value float64) *prom.QueryResult {
+		return &prom.QueryResult{
+			Metric: map[string]interface{}{
+				"cluster_id": "cluster1",
+				"namespace":  "namespace1",
+				"pod":        pod,
+				"container":  "app",
+				"node":       "node1",
+				"resource":   resource,
+			},
+			Values: []*util.Vector{{Value: value}},
+		}
+	}
+	requests := testCostModel.resToExtendedResourceRequests(podMap, []*prom.QueryResult{
+		requestResult("cnf-pod", "hugepages_1Gi", 4*gib),
+		requestResult("cnf-pod", "intel_com_sriov_netdevice", 8),
+	}, map[podKey][]podKey{})
+
+	// The containers request all of the node's cores, RAM, hugepages and
+	// virtual functions
+	cores := map[string]float64{"cnf-pod": 1, "web-pod": 3}
+	ramGiB := map[string]float64{"cnf-pod": 0, "web-pod": 12}
+
+	total := 0.0
+	for pod := range cores {
+		alloc := podMap[newPodKey("cluster1", "namespace1", pod)].Allocations["app"]
+		alloc.CPUCost = cores[pod] * hours * cpuPrice
+		alloc.RAMCost = ramGiB[pod] * hours * ramPrice
+		alloc.ExtendedResourceCosts = computeExtendedResourceCosts(extendedResources, requests[alloc], hours, ramPrice)
+		total += alloc.TotalCost()
+	}
+
+	if total > nodeCost+1e-9 {
+		t.Errorf("expected the allocations to cost no more than their node's %f; got %f", nodeCost, total)
+	}
+	if !util.IsApproximately(total, nodeCost) {
+		t.Errorf("expected the allocations to cost their node's %f; got %f", nodeCost, total)
+	}
+}
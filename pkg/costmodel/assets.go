@@ -0,0 +1,134 @@
+package costmodel
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/kubecost"
+	"github.com/kubecost/opencost/pkg/log"
+)
+
+// ComputeAssets uses the CostModel instance to compute an AssetSet of the
+// nodes, disks, load balancers and cluster management of the window defined
+// by the given start and end times. Control-plane nodes of self-managed
+// clusters are reported as cluster management rather than as nodes.
+func (cm *CostModel) ComputeAssets(start, end time.Time) (*kubecost.AssetSet, error) {
+	window := kubecost.NewWindow(&start, &end)
+	assetSet := kubecost.NewAssetSet(start, end)
+
+	nodeMap, err := cm.ClusterNodes(start, end)
+	if err != nil {
+		return assetSet, fmt.Errorf("error computing nodes for %s: %s", window, err)
+	}
+
+	diskMap, err := cm.ClusterDisks(start, end)
+	if err != nil {
+		return assetSet, fmt.Errorf("error computing disks for %s: %s", window, err)
+	}
+
+	lbMap, err := cm.ClusterLoadBalancers(start, end)
+	if err != nil {
+		return assetSet, fmt.Errorf("error computing load balancers for %s: %s", window, err)
+	}
+
+	provider := ""
+	if cm.Provider != nil {
+		if info, err := cm.Provider.ClusterInfo(); err == nil {
+			provider = info["provider"]
+		}
+	}
+
+	for _, cmAsset := range ClusterControlPlanes(provider, nodeMap, window) {
+		insertAsset(assetSet, cmAsset)
+	}
+
+	for _, n := range nodeMap {
+		insertAsset(assetSet, nodeToAsset(n, window))
+	}
+
+	for _, d := range diskMap {
+		insertAsset(assetSet, diskToAsset(d, window))
+	}
+
+	for _, lb := range lbMap {
+		insertAsset(assetSet, loadBalancerToAsset(lb, window))
+	}
+
+	return assetSet, nil
+}
+
+// insertAsset inserts the given Asset into the AssetSet, logging any error.
+func insertAsset(assetSet *kubecost.AssetSet, asset kubecost.Asset) {
+	if err := assetSet.Insert(asset); err != nil {
+		log.Warnf("CostModel.ComputeAssets: failed to insert asset %s: %s", asset.Properties().Name, err)
+	}
+}
+
+// nodeToAsset converts the given Node, with its cumulative costs over the
+// window, to a Node Asset.
+func nodeToAsset(n *Node, window kubecost.Window) *kubecost.Node {
+	hours := n.Minutes / 60.0
+
+	node := kubecost.NewNode(n.Name, n.Cluster, n.ProviderID, n.Start, n.End, window)
+	node.NodeType = n.NodeType
+	node.CPUCoreHours = n.CPUCores * hours
+	node.RAMByteHours = n.RAMBytes * hours
+	node.GPUHours = n.GPUCount * hours
+	node.CPUBreakdown = toBreakdown(n.CPUBreakdown)
+	node.RAMBreakdown = toBreakdown(n.RAMBreakdown)
+	node.CPUCost = n.CPUCost
+	node.GPUCost = n.GPUCost
+	node.GPUCount = n.GPUCount
+	node.RAMCost = n.RAMCost
+	node.Discount = n.Discount
+	if n.Preemptible {
+		node.Preemptible = 1.0
+	}
+	// Surplus CPU credits are billed on top of the price of the node
+	node.SetAdjustment(n.CPUCreditSurcharge)
+	labels := kubecost.AssetLabels(n.Labels)
+	if n.AcceleratorType != "" {
+		labels = labels.Clone()
+		labels[kubecost.AcceleratorTypeLabel] = n.AcceleratorType
+	}
+	node.SetLabels(labels)
+
+	return node
+}
+
+// diskToAsset converts the given Disk, with its cumulative cost over the
+// window, to a Disk Asset.
+func diskToAsset(d *Disk, window kubecost.Window) *kubecost.Disk {
+	disk := kubecost.NewDisk(d.Name, d.Cluster, d.ProviderID, d.Start, d.End, window)
+	disk.Cost = d.Cost
+	disk.ByteHours = d.Bytes * d.Minutes / 60.0
+	if d.Local {
+		disk.Local = 1.0
+	}
+	disk.Breakdown = toBreakdown(d.Breakdown)
+
+	return disk
+}
+
+// loadBalancerToAsset converts the given LoadBalancer, with its cumulative
+// cost over the window, to a LoadBalancer Asset.
+func loadBalancerToAsset(lb *LoadBalancer, window kubecost.Window) *kubecost.LoadBalancer {
+	loadBalancer := kubecost.NewLoadBalancer(fmt.Sprintf("%s/%s", lb.Namespace, lb.Name), lb.Cluster, lb.ProviderID, lb.Start, lb.End, window)
+	loadBalancer.Cost = lb.Cost
+
+	return loadBalancer
+}
+
+// toBreakdown converts the given ClusterCostsBreakdown to a Breakdown.
+func toBreakdown(b *ClusterCostsBreakdown) *kubecost.Breakdown {
+	if b == nil {
+		return &kubecost.Breakdown{}
+	}
+
+	return &kubecost.Breakdown{
+		Idle:   b.Idle,
+		Other:  b.Other,
+		System: b.System,
+		User:   b.User,
+	}
+}
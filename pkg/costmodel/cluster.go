@@ -2,6 +2,7 @@ package costmodel
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"time"
 
@@ -58,9 +59,14 @@ type ClusterCosts struct {
 	StorageCumulative float64                `json:"storageCumulativeCost"`
 	StorageMonthly    float64                `json:"storageMonthlyCost"`
 	StorageBreakdown  *ClusterCostsBreakdown `json:"storageBreakdown"`
-	TotalCumulative   float64                `json:"totalCumulativeCost"`
-	TotalMonthly      float64                `json:"totalMonthlyCost"`
-	DataMinutes       float64
+	// ManagementCumulative and ManagementMonthly are the costs of the
+	// control-plane nodes of self-managed clusters, which are reported as
+	// cluster management rather than as CPU, GPU and RAM.
+	ManagementCumulative float64 `json:"managementCumulativeCost"`
+	ManagementMonthly    float64 `json:"managementMonthlyCost"`
+	TotalCumulative      float64 `json:"totalCumulativeCost"`
+	TotalMonthly         float64 `json:"totalMonthlyCost"`
+	DataMinutes          float64
 }
 
 // ClusterCostsBreakdown provides percentage-based breakdown of a resource by
@@ -74,7 +80,7 @@ type ClusterCostsBreakdown struct {
 
 // NewClusterCostsFromCumulative takes cumulative cost data over a given time range, computes
 // the associated monthly rate data, and returns the Costs.
-func NewClusterCostsFromCumulative(cpu, gpu, ram, storage float64, window, offset time.Duration, dataHours float64) (*ClusterCosts, error) {
+func NewClusterCostsFromCumulative(cpu, gpu, ram, storage, management float64, window, offset time.Duration, dataHours float64) (*ClusterCosts, error) {
 	start, end := timeutil.ParseTimeRange(window, offset)
 
 	// If the number of hours is not given (i.e. is zero) compute one from the window and offset
@@ -88,19 +94,21 @@ func NewClusterCostsFromCumulative(cpu, gpu, ram, storage float64, window, offse
 	}
 
 	cc := &ClusterCosts{
-		Start:             &start,
-		End:               &end,
-		CPUCumulative:     cpu,
-		GPUCumulative:     gpu,
-		RAMCumulative:     ram,
-		StorageCumulative: storage,
-		TotalCumulative:   cpu + gpu + ram + storage,
-		CPUMonthly:        cpu / dataHours * (timeutil.HoursPerMonth),
-		GPUMonthly:        gpu / dataHours * (timeutil.HoursPerMonth),
-		RAMMonthly:        ram / dataHours * (timeutil.HoursPerMonth),
-		StorageMonthly:    storage / dataHours * (timeutil.HoursPerMonth),
+		Start:                &start,
+		End:                  &end,
+		CPUCumulative:        cpu,
+		GPUCumulative:        gpu,
+		RAMCumulative:        ram,
+		StorageCumulative:    storage,
+		ManagementCumulative: management,
+		TotalCumulative:      cpu + gpu + ram + storage + management,
+		CPUMonthly:           cpu / dataHours * (timeutil.HoursPerMonth),
+		GPUMonthly:           gpu / dataHours * (timeutil.HoursPerMonth),
+		RAMMonthly:           ram / dataHours * (timeutil.HoursPerMonth),
+		StorageMonthly:       storage / dataHours * (timeutil.HoursPerMonth),
+		ManagementMonthly:    management / dataHours * (timeutil.HoursPerMonth),
 	}
-	cc.TotalMonthly = cc.CPUMonthly + cc.GPUMonthly + cc.RAMMonthly + cc.StorageMonthly
+	cc.TotalMonthly = cc.CPUMonthly + cc.GPUMonthly + cc.RAMMonthly + cc.StorageMonthly + cc.ManagementMonthly
 
 	return cc, nil
 }
@@ -123,7 +131,7 @@ type DiskIdentifier struct {
 	Name    string
 }
 
-func ClusterDisks(client prometheus.Client, provider cloud.Provider, start, end time.Time) (map[DiskIdentifier]*Disk, error) {
+func (cm *CostModel) ClusterDisks(start, end time.Time) (map[DiskIdentifier]*Disk, error) {
 	// Query for the duration between start and end
 	durStr := timeutil.DurationString(end.Sub(start))
 	if durStr == "" {
@@ -147,15 +155,15 @@ func ClusterDisks(client prometheus.Client, provider cloud.Provider, start, end
 	// TODO niko/assets how do we not hard-code this price?
 	costPerGBHr := 0.04 / 730.0
 
-	ctx := prom.NewNamedContext(client, prom.ClusterContextName)
-	queryPVCost := fmt.Sprintf(`avg(avg_over_time(pv_hourly_cost[%s])) by (%s, persistentvolume,provider_id)`, durStr, env.GetPromClusterLabel())
-	queryPVSize := fmt.Sprintf(`avg(avg_over_time(kube_persistentvolume_capacity_bytes[%s])) by (%s, persistentvolume)`, durStr, env.GetPromClusterLabel())
-	queryActiveMins := fmt.Sprintf(`count(pv_hourly_cost) by (%s, persistentvolume)[%s:%dm]`, env.GetPromClusterLabel(), durStr, minsPerResolution)
+	ctx := cm.promContext(prom.ClusterContextName)
+	queryPVCost := fmt.Sprintf(`avg(avg_over_time(pv_hourly_cost[%s])) by (%s, persistentvolume,provider_id)`, durStr, cm.ClusterLabel)
+	queryPVSize := fmt.Sprintf(`avg(avg_over_time(kube_persistentvolume_capacity_bytes[%s])) by (%s, persistentvolume)`, durStr, cm.ClusterLabel)
+	queryActiveMins := fmt.Sprintf(`count(pv_hourly_cost) by (%s, persistentvolume)[%s:%dm]`, cm.ClusterLabel, durStr, minsPerResolution)
 
-	queryLocalStorageCost := fmt.Sprintf(`sum_over_time(sum(container_fs_limit_bytes{device!="tmpfs", id="/"}) by (instance, %s)[%s:%dm]) / 1024 / 1024 / 1024 * %f * %f`, env.GetPromClusterLabel(), durStr, minsPerResolution, hourlyToCumulative, costPerGBHr)
-	queryLocalStorageUsedCost := fmt.Sprintf(`sum_over_time(sum(container_fs_usage_bytes{device!="tmpfs", id="/"}) by (instance, %s)[%s:%dm]) / 1024 / 1024 / 1024 * %f * %f`, env.GetPromClusterLabel(), durStr, minsPerResolution, hourlyToCumulative, costPerGBHr)
-	queryLocalStorageBytes := fmt.Sprintf(`avg_over_time(sum(container_fs_limit_bytes{device!="tmpfs", id="/"}) by (instance, %s)[%s:%dm])`, env.GetPromClusterLabel(), durStr, minsPerResolution)
-	queryLocalActiveMins := fmt.Sprintf(`count(node_total_hourly_cost) by (%s, node)[%s:%dm]`, env.GetPromClusterLabel(), durStr, minsPerResolution)
+	queryLocalStorageCost := fmt.Sprintf(`sum_over_time(sum(container_fs_limit_bytes{device!="tmpfs", id="/"}) by (instance, %s)[%s:%dm]) / 1024 / 1024 / 1024 * %f * %f`, cm.ClusterLabel, durStr, minsPerResolution, hourlyToCumulative, costPerGBHr)
+	queryLocalStorageUsedCost := fmt.Sprintf(`sum_over_time(sum(container_fs_usage_bytes{device!="tmpfs", id="/"}) by (instance, %s)[%s:%dm]) / 1024 / 1024 / 1024 * %f * %f`, cm.ClusterLabel, durStr, minsPerResolution, hourlyToCumulative, costPerGBHr)
+	queryLocalStorageBytes := fmt.Sprintf(`avg_over_time(sum(container_fs_limit_bytes{device!="tmpfs", id="/"}) by (instance, %s)[%s:%dm])`, cm.ClusterLabel, durStr, minsPerResolution)
+	queryLocalActiveMins := fmt.Sprintf(`count(node_total_hourly_cost) by (%s, node)[%s:%dm]`, cm.ClusterLabel, durStr, minsPerResolution)
 
 	resChPVCost := ctx.QueryAtTime(queryPVCost, t)
 	resChPVSize := ctx.QueryAtTime(queryPVSize, t)
@@ -178,12 +186,12 @@ func ClusterDisks(client prometheus.Client, provider cloud.Provider, start, end
 
 	diskMap := map[DiskIdentifier]*Disk{}
 
-	pvCosts(diskMap, resolution, resActiveMins, resPVSize, resPVCost, provider)
+	cm.pvCosts(diskMap, resolution, resActiveMins, resPVSize, resPVCost, cm.Provider)
 
 	for _, result := range resLocalStorageCost {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		name, err := result.GetString("instance")
@@ -206,9 +214,9 @@ func ClusterDisks(client prometheus.Client, provider cloud.Provider, start, end
 	}
 
 	for _, result := range resLocalStorageUsedCost {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		name, err := result.GetString("instance")
@@ -231,9 +239,9 @@ func ClusterDisks(client prometheus.Client, provider cloud.Provider, start, end
 	}
 
 	for _, result := range resLocalStorageBytes {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		name, err := result.GetString("instance")
@@ -260,9 +268,9 @@ func ClusterDisks(client prometheus.Client, provider cloud.Provider, start, end
 	}
 
 	for _, result := range resLocalActiveMins {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		name, err := result.GetString("node")
@@ -324,9 +332,16 @@ type Node struct {
 	End             time.Time
 	Minutes         float64
 	Labels          map[string]string
+	ControlPlane    bool
 	CostPerCPUHr    float64
 	CostPerRAMGiBHr float64
 	CostPerGPUHr    float64
+	// CPUCreditSurcharge is the cost of the surplus CPU credits spent by a
+	// burstable node over the window, which is billed on top of CPUCost.
+	CPUCreditSurcharge float64
+	// AcceleratorType is the type of the node's GPUs or other accelerators,
+	// e.g. "nvidia", "tpu" or "neuron".
+	AcceleratorType string
 }
 
 // GKE lies about the number of cores e2 nodes have. This table
@@ -349,6 +364,42 @@ type nodeIdentifierNoProviderID struct {
 	Name    string
 }
 
+// applyPriceSchedule replaces the hourly CPU, RAM and GPU prices of each node
+// with their average over the node's active window under the given schedule,
+// as the allocations on the node are priced. RAM prices are per byte-hour and
+// GPU prices are per node, while the schedule's are per GiB-hour and GPU-hour.
+func applyPriceSchedule(schedule *cloud.PriceSchedule, activeDataMap map[NodeIdentifier]activeData, cpuCostMap, ramCostMap, gpuCostMap, gpuCountMap map[NodeIdentifier]float64) {
+	if schedule == nil {
+		return
+	}
+
+	for k, v := range activeDataMap {
+		_, hasCPU := cpuCostMap[k]
+		_, hasRAM := ramCostMap[k]
+		_, hasGPU := gpuCostMap[k]
+		if !hasCPU && !hasRAM && !hasGPU {
+			continue
+		}
+
+		gpuCount := gpuCountMap[k]
+		gpuPrice := 0.0
+		if gpuCount > 0 {
+			gpuPrice = gpuCostMap[k] / gpuCount
+		}
+
+		cpu, ram, gpu := schedule.AverageRates(v.start, v.end, cpuCostMap[k], ramCostMap[k]*1024*1024*1024, gpuPrice)
+		if hasCPU {
+			cpuCostMap[k] = cpu
+		}
+		if hasRAM {
+			ramCostMap[k] = ram / 1024 / 1024 / 1024
+		}
+		if hasGPU && gpuCount > 0 {
+			gpuCostMap[k] = gpu * gpuCount
+		}
+	}
+}
+
 func costTimesMinuteAndCount(activeDataMap map[NodeIdentifier]activeData, costMap map[NodeIdentifier]float64, resourceCountMap map[nodeIdentifierNoProviderID]float64) {
 	for k, v := range activeDataMap {
 		keyNon := nodeIdentifierNoProviderID{
@@ -375,7 +426,7 @@ func costTimesMinute(activeDataMap map[NodeIdentifier]activeData, costMap map[No
 	}
 }
 
-func ClusterNodes(cp cloud.Provider, client prometheus.Client, start, end time.Time) (map[NodeIdentifier]*Node, error) {
+func (cm *CostModel) ClusterNodes(start, end time.Time) (map[NodeIdentifier]*Node, error) {
 	// Query for the duration between start and end
 	durStr := timeutil.DurationString(end.Sub(start))
 	if durStr == "" {
@@ -391,21 +442,23 @@ func ClusterNodes(cp cloud.Provider, client prometheus.Client, start, end time.T
 	minsPerResolution := 1
 	resolution := time.Duration(minsPerResolution) * time.Minute
 
-	requiredCtx := prom.NewNamedContext(client, prom.ClusterContextName)
-	optionalCtx := prom.NewNamedContext(client, prom.ClusterOptionalContextName)
-
-	queryNodeCPUHourlyCost := fmt.Sprintf(`avg(avg_over_time(node_cpu_hourly_cost[%s])) by (%s, node, instance_type, provider_id)`, durStr, env.GetPromClusterLabel())
-	queryNodeCPUCores := fmt.Sprintf(`avg(avg_over_time(kube_node_status_capacity_cpu_cores[%s])) by (%s, node)`, durStr, env.GetPromClusterLabel())
-	queryNodeRAMHourlyCost := fmt.Sprintf(`avg(avg_over_time(node_ram_hourly_cost[%s])) by (%s, node, instance_type, provider_id) / 1024 / 1024 / 1024`, durStr, env.GetPromClusterLabel())
-	queryNodeRAMBytes := fmt.Sprintf(`avg(avg_over_time(kube_node_status_capacity_memory_bytes[%s])) by (%s, node)`, durStr, env.GetPromClusterLabel())
-	queryNodeGPUCount := fmt.Sprintf(`avg(avg_over_time(node_gpu_count[%s])) by (%s, node, provider_id)`, durStr, env.GetPromClusterLabel())
-	queryNodeGPUHourlyCost := fmt.Sprintf(`avg(avg_over_time(node_gpu_hourly_cost[%s])) by (%s, node, instance_type, provider_id)`, durStr, env.GetPromClusterLabel())
-	queryNodeCPUModeTotal := fmt.Sprintf(`sum(rate(node_cpu_seconds_total[%s:%dm])) by (kubernetes_node, %s, mode)`, durStr, minsPerResolution, env.GetPromClusterLabel())
-	queryNodeRAMSystemPct := fmt.Sprintf(`sum(sum_over_time(container_memory_working_set_bytes{container_name!="POD",container_name!="",namespace="kube-system"}[%s:%dm])) by (instance, %s) / avg(label_replace(sum(sum_over_time(kube_node_status_capacity_memory_bytes[%s:%dm])) by (node, %s), "instance", "$1", "node", "(.*)")) by (instance, %s)`, durStr, minsPerResolution, env.GetPromClusterLabel(), durStr, minsPerResolution, env.GetPromClusterLabel(), env.GetPromClusterLabel())
-	queryNodeRAMUserPct := fmt.Sprintf(`sum(sum_over_time(container_memory_working_set_bytes{container_name!="POD",container_name!="",namespace!="kube-system"}[%s:%dm])) by (instance, %s) / avg(label_replace(sum(sum_over_time(kube_node_status_capacity_memory_bytes[%s:%dm])) by (node, %s), "instance", "$1", "node", "(.*)")) by (instance, %s)`, durStr, minsPerResolution, env.GetPromClusterLabel(), durStr, minsPerResolution, env.GetPromClusterLabel(), env.GetPromClusterLabel())
-	queryActiveMins := fmt.Sprintf(`avg(node_total_hourly_cost) by (node, %s, provider_id)[%s:%dm]`, env.GetPromClusterLabel(), durStr, minsPerResolution)
+	requiredCtx := cm.promContext(prom.ClusterContextName)
+	optionalCtx := cm.promContext(prom.ClusterOptionalContextName)
+
+	queryNodeCPUHourlyCost := fmt.Sprintf(`avg(avg_over_time(node_cpu_hourly_cost[%s])) by (%s, node, instance_type, provider_id)`, durStr, cm.ClusterLabel)
+	queryNodeCPUCores := fmt.Sprintf(`avg(avg_over_time(kube_node_status_capacity_cpu_cores[%s])) by (%s, node)`, durStr, cm.ClusterLabel)
+	queryNodeRAMHourlyCost := fmt.Sprintf(`avg(avg_over_time(node_ram_hourly_cost[%s])) by (%s, node, instance_type, provider_id) / 1024 / 1024 / 1024`, durStr, cm.ClusterLabel)
+	queryNodeRAMBytes := fmt.Sprintf(`avg(avg_over_time(kube_node_status_capacity_memory_bytes[%s])) by (%s, node)`, durStr, cm.ClusterLabel)
+	queryNodeGPUCount := fmt.Sprintf(`avg(avg_over_time(node_gpu_count[%s])) by (%s, node, provider_id)`, durStr, cm.ClusterLabel)
+	queryNodeGPUHourlyCost := fmt.Sprintf(`avg(avg_over_time(node_gpu_hourly_cost[%s])) by (%s, node, instance_type, provider_id)`, durStr, cm.ClusterLabel)
+	queryNodeCPUModeTotal := fmt.Sprintf(`sum(rate(node_cpu_seconds_total[%s:%dm])) by (kubernetes_node, %s, mode)`, durStr, minsPerResolution, cm.ClusterLabel)
+	queryNodeRAMSystemPct := fmt.Sprintf(`sum(sum_over_time(container_memory_working_set_bytes{container_name!="POD",container_name!="",namespace="kube-system"}[%s:%dm])) by (instance, %s) / avg(label_replace(sum(sum_over_time(kube_node_status_capacity_memory_bytes[%s:%dm])) by (node, %s), "instance", "$1", "node", "(.*)")) by (instance, %s)`, durStr, minsPerResolution, cm.ClusterLabel, durStr, minsPerResolution, cm.ClusterLabel, cm.ClusterLabel)
+	queryNodeRAMUserPct := fmt.Sprintf(`sum(sum_over_time(container_memory_working_set_bytes{container_name!="POD",container_name!="",namespace!="kube-system"}[%s:%dm])) by (instance, %s) / avg(label_replace(sum(sum_over_time(kube_node_status_capacity_memory_bytes[%s:%dm])) by (node, %s), "instance", "$1", "node", "(.*)")) by (instance, %s)`, durStr, minsPerResolution, cm.ClusterLabel, durStr, minsPerResolution, cm.ClusterLabel, cm.ClusterLabel)
+	queryActiveMins := fmt.Sprintf(`avg(node_total_hourly_cost) by (node, %s, provider_id)[%s:%dm]`, cm.ClusterLabel, durStr, minsPerResolution)
 	queryIsSpot := fmt.Sprintf(`avg_over_time(kubecost_node_is_spot[%s:%dm])`, durStr, minsPerResolution)
 	queryLabels := fmt.Sprintf(`count_over_time(kube_node_labels[%s:%dm])`, durStr, minsPerResolution)
+	queryTaints := fmt.Sprintf(`max(max_over_time(kube_node_spec_taint[%s])) by (node, key, value, effect, %s)`, durStr, cm.ClusterLabel)
+	queryAcceleratorTypes := fmt.Sprintf(`max(max_over_time(kubecost_node_accelerator_info[%s])) by (node, accelerator_type, %s)`, durStr, cm.ClusterLabel)
 
 	// Return errors if these fail
 	resChNodeCPUHourlyCost := requiredCtx.QueryAtTime(queryNodeCPUHourlyCost, t)
@@ -422,6 +475,8 @@ func ClusterNodes(cp cloud.Provider, client prometheus.Client, start, end time.T
 	resChNodeRAMSystemPct := optionalCtx.QueryAtTime(queryNodeRAMSystemPct, t)
 	resChNodeRAMUserPct := optionalCtx.QueryAtTime(queryNodeRAMUserPct, t)
 	resChLabels := optionalCtx.QueryAtTime(queryLabels, t)
+	resChTaints := optionalCtx.QueryAtTime(queryTaints, t)
+	resChAcceleratorTypes := optionalCtx.QueryAtTime(queryAcceleratorTypes, t)
 
 	resNodeCPUHourlyCost, _ := resChNodeCPUHourlyCost.Await()
 	resNodeCPUCores, _ := resChNodeCPUCores.Await()
@@ -435,6 +490,8 @@ func ClusterNodes(cp cloud.Provider, client prometheus.Client, start, end time.T
 	resNodeRAMUserPct, _ := resChNodeRAMUserPct.Await()
 	resActiveMins, _ := resChActiveMins.Await()
 	resLabels, _ := resChLabels.Await()
+	resTaints, _ := resChTaints.Await()
+	resAcceleratorTypes, _ := resChAcceleratorTypes.Await()
 
 	if optionalCtx.HasErrors() {
 		for _, err := range optionalCtx.Errors() {
@@ -449,27 +506,85 @@ func ClusterNodes(cp cloud.Provider, client prometheus.Client, start, end time.T
 		return nil, requiredCtx.ErrorCollection()
 	}
 
-	activeDataMap := buildActiveDataMap(resActiveMins, resolution)
+	activeDataMap := cm.buildActiveDataMap(resActiveMins, resolution)
 
-	gpuCountMap := buildGPUCountMap(resNodeGPUCount)
-	preemptibleMap := buildPreemptibleMap(resIsSpot)
+	gpuCountMap := cm.buildGPUCountMap(resNodeGPUCount)
+	preemptibleMap := cm.buildPreemptibleMap(resIsSpot)
 
-	cpuCostMap, clusterAndNameToType1 := buildCPUCostMap(resNodeCPUHourlyCost, cp, preemptibleMap)
-	ramCostMap, clusterAndNameToType2 := buildRAMCostMap(resNodeRAMHourlyCost, cp, preemptibleMap)
-	gpuCostMap, clusterAndNameToType3 := buildGPUCostMap(resNodeGPUHourlyCost, gpuCountMap, cp, preemptibleMap)
+	cpuCostMap, clusterAndNameToType1 := cm.buildCPUCostMap(resNodeCPUHourlyCost, cm.Provider, preemptibleMap)
+	ramCostMap, clusterAndNameToType2 := cm.buildRAMCostMap(resNodeRAMHourlyCost, cm.Provider, preemptibleMap)
+	gpuCostMap, clusterAndNameToType3 := cm.buildGPUCostMap(resNodeGPUHourlyCost, gpuCountMap, cm.Provider, preemptibleMap)
 
 	clusterAndNameToTypeIntermediate := mergeTypeMaps(clusterAndNameToType1, clusterAndNameToType2)
 	clusterAndNameToType := mergeTypeMaps(clusterAndNameToTypeIntermediate, clusterAndNameToType3)
 
-	cpuCoresMap := buildCPUCoresMap(resNodeCPUCores)
-	ramBytesMap := buildRAMBytesMap(resNodeRAMBytes)
+	cpuCoresMap := cm.buildCPUCoresMap(resNodeCPUCores)
+	ramBytesMap := cm.buildRAMBytesMap(resNodeRAMBytes)
+
+	ramUserPctMap := cm.buildRAMUserPctMap(resNodeRAMUserPct)
+	ramSystemPctMap := cm.buildRAMSystemPctMap(resNodeRAMSystemPct)
+
+	cpuBreakdownMap := cm.buildCPUBreakdownMap(resNodeCPUModeTotal)
+
+	labelsMap := cm.buildLabelsMap(resLabels)
+	controlPlaneMap := cm.buildControlPlaneMap(labelsMap, resTaints)
+	acceleratorTypeMap := cm.buildAcceleratorTypeMap(resAcceleratorTypes)
+
+	// Autopilot bills the requests of pods rather than nodes, so Autopilot
+	// nodes are priced as the requests their pods were billed for.
+	if autopilotNodes := autopilotNodeClasses(clusterAndNameToType); len(autopilotNodes) > 0 {
+		queryPodCPURequests := fmt.Sprintf(`sum(avg_over_time(kube_pod_container_resource_requests{resource="cpu", unit="core", container!="", container!="POD", node!=""}[%s])) by (pod, namespace, node, %s)`, durStr, cm.ClusterLabel)
+		queryPodRAMRequests := fmt.Sprintf(`sum(avg_over_time(kube_pod_container_resource_requests{resource="memory", unit="byte", container!="", container!="POD", node!=""}[%s])) by (pod, namespace, node, %s)`, durStr, cm.ClusterLabel)
+		queryPodMins := fmt.Sprintf(`max(count_over_time(kube_pod_container_resource_requests{resource="cpu", unit="core", container!="", container!="POD", node!=""}[%s:%dm])) by (pod, namespace, node, %s)`, durStr, minsPerResolution, cm.ClusterLabel)
+
+		autopilotCtx := cm.promContext(prom.ClusterContextName)
+		resChPodCPURequests := autopilotCtx.QueryAtTime(queryPodCPURequests, t)
+		resChPodRAMRequests := autopilotCtx.QueryAtTime(queryPodRAMRequests, t)
+		resChPodMins := autopilotCtx.QueryAtTime(queryPodMins, t)
+
+		resPodCPURequests, _ := resChPodCPURequests.Await()
+		resPodRAMRequests, _ := resChPodRAMRequests.Await()
+		resPodMins, _ := resChPodMins.Await()
 
-	ramUserPctMap := buildRAMUserPctMap(resNodeRAMUserPct)
-	ramSystemPctMap := buildRAMSystemPctMap(resNodeRAMSystemPct)
+		if autopilotCtx.HasErrors() {
+			for _, err := range autopilotCtx.Errors() {
+				log.Errorf("ClusterNodes: %s", err)
+			}
+
+			return nil, autopilotCtx.ErrorCollection()
+		}
+
+		billedCores, billedRAMBytes := cm.buildAutopilotBilledRequestsMaps(resPodCPURequests, resPodRAMRequests, resPodMins, autopilotNodes, activeDataMap, resolution)
+		for id, cores := range billedCores {
+			cpuCoresMap[id] = cores
+		}
+		for id, ramBytes := range billedRAMBytes {
+			ramBytesMap[id] = ramBytes
+		}
+	}
 
-	cpuBreakdownMap := buildCPUBreakdownMap(resNodeCPUModeTotal)
+	// Burstable nodes are billed for the surplus CPU credits they spend, which
+	// are estimated from their CPU usage at every resolution step of the
+	// window and of the credit accrual period before it.
+	var nodeCPUUsages map[nodeKey]*nodeCPUUsage
+	if hasBurstableNodes(clusterAndNameToType) {
+		nodeCPUUsageRateStr := timeutil.DurationString(time.Duration(math.Max(float64(resolution), float64(5*time.Minute))))
+		nodeCPUUsageDurStr := timeutil.DurationString(end.Sub(start) + cloud.BurstableAccrualPeriod)
+		queryNodeCPUUsage := fmt.Sprintf(queryFmtNodeCPUUsage, nodeCPUUsageRateStr, cm.ClusterLabel, nodeCPUUsageDurStr, timeutil.DurationString(resolution))
+
+		burstableCtx := cm.promContext(prom.ClusterOptionalContextName)
+		resNodeCPUUsage, _ := burstableCtx.QueryAtTime(queryNodeCPUUsage, t).Await()
+		if burstableCtx.HasErrors() {
+			for _, err := range burstableCtx.Errors() {
+				log.Warnf("ClusterNodes: %s", err)
+			}
+		}
+		nodeCPUUsages = cm.resToNodeCPUUsage(resNodeCPUUsage, start)
+	}
 
-	labelsMap := buildLabelsMap(resLabels)
+	// The node_*_hourly_cost metrics are the nodes' list prices, so that the
+	// price schedule is applied once, over the window of the costs.
+	applyPriceSchedule(cm.priceSchedule(), activeDataMap, cpuCostMap, ramCostMap, gpuCostMap, gpuCountMap)
 
 	costTimesMinuteAndCount(activeDataMap, cpuCostMap, cpuCoresMap)
 	costTimesMinuteAndCount(activeDataMap, ramCostMap, ramBytesMap)
@@ -487,7 +602,7 @@ func ClusterNodes(cp cloud.Provider, client prometheus.Client, start, end time.T
 		resolution,
 	)
 
-	c, err := cp.GetConfig()
+	c, err := cm.Provider.GetConfig()
 	if err != nil {
 		return nil, err
 	}
@@ -502,9 +617,15 @@ func ClusterNodes(cp cloud.Provider, client prometheus.Client, start, end time.T
 		return nil, err
 	}
 
-	for _, node := range nodeMap {
+	for id, node := range nodeMap {
 		// TODO take GKE Reserved Instances into account
-		node.Discount = cp.CombinedDiscountForNode(node.NodeType, node.Preemptible, discount, negotiatedDiscount)
+		node.Discount = cm.Provider.CombinedDiscountForNode(node.NodeType, node.Preemptible, discount, negotiatedDiscount)
+
+		node.ControlPlane = controlPlaneMap[nodeIdentifierNoProviderID{Cluster: id.Cluster, Name: id.Name}]
+
+		node.AcceleratorType = acceleratorTypeMap[nodeIdentifierNoProviderID{Cluster: id.Cluster, Name: id.Name}]
+
+		node.CPUCreditSurcharge = burstableCreditSurcharge(node.NodeType, nodeCPUUsages[newNodeKey(id.Cluster, id.Name)], resolution, c)
 
 		// Apply all remaining resources to Idle
 		node.CPUBreakdown.Idle = 1.0 - (node.CPUBreakdown.System + node.CPUBreakdown.Other + node.CPUBreakdown.User)
@@ -514,6 +635,33 @@ func ClusterNodes(cp cloud.Provider, client prometheus.Client, start, end time.T
 	return nodeMap, nil
 }
 
+// ClusterControlPlanes removes the control-plane nodes of self-managed
+// clusters from the given nodes and returns their costs, by cluster, as
+// ClusterManagement assets of the given provider. Reporting them as cluster
+// management, rather than as nodes, keeps their costs out of idle and allows
+// sharing them across tenants as the control-plane fees of managed clusters
+// are.
+func ClusterControlPlanes(provider string, nodeMap map[NodeIdentifier]*Node, window kubecost.Window) map[string]*kubecost.ClusterManagement {
+	cms := map[string]*kubecost.ClusterManagement{}
+
+	for id, node := range nodeMap {
+		if !node.ControlPlane {
+			continue
+		}
+
+		cm, ok := cms[node.Cluster]
+		if !ok {
+			cm = kubecost.NewClusterManagement(provider, node.Cluster, window)
+			cms[node.Cluster] = cm
+		}
+		cm.Cost += (node.CPUCost+node.RAMCost)*(1.0-node.Discount) + node.GPUCost + node.CPUCreditSurcharge
+
+		delete(nodeMap, id)
+	}
+
+	return cms
+}
+
 type LoadBalancerIdentifier struct {
 	Cluster   string
 	Namespace string
@@ -531,7 +679,7 @@ type LoadBalancer struct {
 	Minutes    float64
 }
 
-func ClusterLoadBalancers(client prometheus.Client, start, end time.Time) (map[LoadBalancerIdentifier]*LoadBalancer, error) {
+func (cm *CostModel) ClusterLoadBalancers(start, end time.Time) (map[LoadBalancerIdentifier]*LoadBalancer, error) {
 	// Query for the duration between start and end
 	durStr := timeutil.DurationString(end.Sub(start))
 	if durStr == "" {
@@ -546,10 +694,10 @@ func ClusterLoadBalancers(client prometheus.Client, start, end time.Time) (map[L
 	// but more expensive queries, and vice-a-versa.
 	minsPerResolution := 1
 
-	ctx := prom.NewNamedContext(client, prom.ClusterContextName)
+	ctx := cm.promContext(prom.ClusterContextName)
 
-	queryLBCost := fmt.Sprintf(`avg(avg_over_time(kubecost_load_balancer_cost[%s])) by (namespace, service_name, %s, ingress_ip)`, durStr, env.GetPromClusterLabel())
-	queryActiveMins := fmt.Sprintf(`avg(kubecost_load_balancer_cost) by (namespace, service_name, %s, ingress_ip)[%s:%dm]`, env.GetPromClusterLabel(), durStr, minsPerResolution)
+	queryLBCost := fmt.Sprintf(`avg(avg_over_time(kubecost_load_balancer_cost[%s])) by (namespace, service_name, %s, ingress_ip)`, durStr, cm.ClusterLabel)
+	queryActiveMins := fmt.Sprintf(`avg(kubecost_load_balancer_cost) by (namespace, service_name, %s, ingress_ip)[%s:%dm]`, cm.ClusterLabel, durStr, minsPerResolution)
 
 	resChLBCost := ctx.QueryAtTime(queryLBCost, t)
 	resChActiveMins := ctx.QueryAtTime(queryActiveMins, t)
@@ -564,9 +712,9 @@ func ClusterLoadBalancers(client prometheus.Client, start, end time.Time) (map[L
 	loadBalancerMap := make(map[LoadBalancerIdentifier]*LoadBalancer, len(resActiveMins))
 
 	for _, result := range resActiveMins {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 		namespace, err := result.GetString("namespace")
 		if err != nil {
@@ -620,9 +768,9 @@ func ClusterLoadBalancers(client prometheus.Client, start, end time.Time) (map[L
 	}
 
 	for _, result := range resLBCost {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 		namespace, err := result.GetString("namespace")
 		if err != nil {
@@ -684,21 +832,21 @@ func (a *Accesses) ComputeClusterCosts(client prometheus.Client, provider cloud.
 	const fmtQueryTotalGPU = `
 		sum(
 			sum_over_time(node_gpu_hourly_cost[%s:%dm]%s) * %f
-		) by (%s)
+		) by (node, %s)
 	`
 
 	const fmtQueryTotalCPU = `
 		sum(
 			sum_over_time(avg(kube_node_status_capacity_cpu_cores) by (node, %s)[%s:%dm]%s) *
 			avg(avg_over_time(node_cpu_hourly_cost[%s:%dm]%s)) by (node, %s) * %f
-		) by (%s)
+		) by (node, %s)
 	`
 
 	const fmtQueryTotalRAM = `
 		sum(
 			sum_over_time(avg(kube_node_status_capacity_memory_bytes) by (node, %s)[%s:%dm]%s) / 1024 / 1024 / 1024 *
 			avg(avg_over_time(node_ram_hourly_cost[%s:%dm]%s)) by (node, %s) * %f
-		) by (%s)
+		) by (node, %s)
 	`
 
 	const fmtQueryTotalStorage = `
@@ -708,6 +856,10 @@ func (a *Accesses) ComputeClusterCosts(client prometheus.Client, provider cloud.
 		) by (%s)
 	`
 
+	const fmtQueryNodeLabels = `count_over_time(kube_node_labels[%s:%dm]%s)`
+
+	const fmtQueryNodeTaints = `max(max_over_time(kube_node_spec_taint[%s]%s)) by (node, key, value, effect, %s)`
+
 	const fmtQueryCPUModePct = `
 		sum(rate(node_cpu_seconds_total[%s]%s)) by (%s, mode) / ignoring(mode)
 		group_left sum(rate(node_cpu_seconds_total[%s]%s)) by (%s)
@@ -741,9 +893,17 @@ func (a *Accesses) ComputeClusterCosts(client prometheus.Client, provider cloud.
 	queryTotalCPU := fmt.Sprintf(fmtQueryTotalCPU, env.GetPromClusterLabel(), windowStr, minsPerResolution, fmtOffset, windowStr, minsPerResolution, fmtOffset, env.GetPromClusterLabel(), hourlyToCumulative, env.GetPromClusterLabel())
 	queryTotalRAM := fmt.Sprintf(fmtQueryTotalRAM, env.GetPromClusterLabel(), windowStr, minsPerResolution, fmtOffset, windowStr, minsPerResolution, fmtOffset, env.GetPromClusterLabel(), hourlyToCumulative, env.GetPromClusterLabel())
 	queryTotalStorage := fmt.Sprintf(fmtQueryTotalStorage, env.GetPromClusterLabel(), windowStr, minsPerResolution, fmtOffset, windowStr, minsPerResolution, fmtOffset, env.GetPromClusterLabel(), hourlyToCumulative, env.GetPromClusterLabel())
+	queryNodeLabels := fmt.Sprintf(fmtQueryNodeLabels, windowStr, minsPerResolution, fmtOffset)
+	queryNodeTaints := fmt.Sprintf(fmtQueryNodeTaints, windowStr, fmtOffset, env.GetPromClusterLabel())
 
 	ctx := prom.NewNamedContext(client, prom.ClusterContextName)
 
+	// Control-plane nodes are detected by their labels and taints; without
+	// them, all nodes are counted as nodes.
+	optionalCtx := prom.NewNamedContext(client, prom.ClusterOptionalContextName)
+	resChNodeLabels := optionalCtx.Query(queryNodeLabels)
+	resChNodeTaints := optionalCtx.Query(queryNodeTaints)
+
 	resChs := ctx.QueryAll(
 		queryDataCount,
 		queryTotalGPU,
@@ -795,6 +955,15 @@ func (a *Accesses) ComputeClusterCosts(client prometheus.Client, provider cloud.
 
 	defaultClusterID := env.GetClusterID()
 
+	resNodeLabels, _ := resChNodeLabels.Await()
+	resNodeTaints, _ := resChNodeTaints.Await()
+	if optionalCtx.HasErrors() {
+		for _, err := range optionalCtx.Errors() {
+			log.Warnf("ComputeClusterCosts: %s", err)
+		}
+	}
+	controlPlaneMap := a.Model.buildControlPlaneMap(a.Model.buildLabelsMap(resNodeLabels), resNodeTaints)
+
 	dataMinsByCluster := map[string]float64{}
 	for _, result := range resDataCount {
 		clusterID, _ := result.GetString(env.GetPromClusterLabel())
@@ -828,7 +997,8 @@ func (a *Accesses) ComputeClusterCosts(client prometheus.Client, provider cloud.
 	costData := make(map[string]map[string]float64)
 
 	// Helper function to iterate over Prom query results, parsing the raw values into
-	// the intermediate costData structure.
+	// the intermediate costData structure. The costs of control-plane nodes are
+	// reported as cluster management, as they are by ComputeAssets.
 	setCostsFromResults := func(costData map[string]map[string]float64, results []*prom.QueryResult, name string, discount float64, customDiscount float64) {
 		for _, result := range results {
 			clusterID, _ := result.GetString(env.GetPromClusterLabel())
@@ -838,8 +1008,12 @@ func (a *Accesses) ComputeClusterCosts(client prometheus.Client, provider cloud.
 			if _, ok := costData[clusterID]; !ok {
 				costData[clusterID] = map[string]float64{}
 			}
+			key := name
+			if node, err := result.GetString("node"); err == nil && controlPlaneMap[nodeIdentifierNoProviderID{Cluster: clusterID, Name: node}] {
+				key = "management"
+			}
 			if len(result.Values) > 0 {
-				costData[clusterID][name] += result.Values[0].Value * (1.0 - discount) * (1.0 - customDiscount)
+				costData[clusterID][key] += result.Values[0].Value * (1.0 - discount) * (1.0 - customDiscount)
 				costData[clusterID]["total"] += result.Values[0].Value * (1.0 - discount) * (1.0 - customDiscount)
 			}
 		}
@@ -957,7 +1131,7 @@ func (a *Accesses) ComputeClusterCosts(client prometheus.Client, provider cloud.
 			dataMins = mins
 			log.Warnf("Cluster cost data count not found for cluster %s", id)
 		}
-		costs, err := NewClusterCostsFromCumulative(cd["cpu"], cd["gpu"], cd["ram"], cd["storage"]+cd["localstorage"], window, offset, dataMins/timeutil.MinsPerHour)
+		costs, err := NewClusterCostsFromCumulative(cd["cpu"], cd["gpu"], cd["ram"], cd["storage"]+cd["localstorage"], cd["management"], window, offset, dataMins/timeutil.MinsPerHour)
 		if err != nil {
 			log.Warnf("Failed to parse cluster costs on %s (%s) from cumulative data: %+v", window, offset, cd)
 			return nil, err
@@ -1114,11 +1288,11 @@ func ClusterCostsOverTime(cli prometheus.Client, provider cloud.Provider, startS
 	}, nil
 }
 
-func pvCosts(diskMap map[DiskIdentifier]*Disk, resolution time.Duration, resActiveMins, resPVSize, resPVCost []*prom.QueryResult, cp cloud.Provider) {
+func (cm *CostModel) pvCosts(diskMap map[DiskIdentifier]*Disk, resolution time.Duration, resActiveMins, resPVSize, resPVCost []*prom.QueryResult, cp cloud.Provider) {
 	for _, result := range resActiveMins {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		name, err := result.GetString("persistentvolume")
@@ -1151,9 +1325,9 @@ func pvCosts(diskMap map[DiskIdentifier]*Disk, resolution time.Duration, resActi
 	}
 
 	for _, result := range resPVSize {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		name, err := result.GetString("persistentvolume")
@@ -1183,9 +1357,9 @@ func pvCosts(diskMap map[DiskIdentifier]*Disk, resolution time.Duration, resActi
 	}
 
 	for _, result := range resPVCost {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		name, err := result.GetString("persistentvolume")
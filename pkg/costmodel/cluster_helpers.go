@@ -1,12 +1,13 @@
 package costmodel
 
 import (
+	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/kubecost/opencost/pkg/cloud"
 
-	"github.com/kubecost/opencost/pkg/env"
 	"github.com/kubecost/opencost/pkg/log"
 	"github.com/kubecost/opencost/pkg/prom"
 )
@@ -28,7 +29,7 @@ func mergeTypeMaps(clusterAndNameToType1, clusterAndNameToType2 map[nodeIdentifi
 	return merged
 }
 
-func buildCPUCostMap(
+func (cm *CostModel) buildCPUCostMap(
 	resNodeCPUCost []*prom.QueryResult,
 	cp cloud.Provider,
 	preemptible map[NodeIdentifier]bool,
@@ -47,9 +48,9 @@ func buildCPUCostMap(
 	}
 
 	for _, result := range resNodeCPUCost {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		name, err := result.GetString("node")
@@ -102,7 +103,7 @@ func buildCPUCostMap(
 	return cpuCostMap, clusterAndNameToType
 }
 
-func buildRAMCostMap(
+func (cm *CostModel) buildRAMCostMap(
 	resNodeRAMCost []*prom.QueryResult,
 	cp cloud.Provider,
 	preemptible map[NodeIdentifier]bool,
@@ -121,9 +122,9 @@ func buildRAMCostMap(
 	}
 
 	for _, result := range resNodeRAMCost {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		name, err := result.GetString("node")
@@ -176,7 +177,7 @@ func buildRAMCostMap(
 	return ramCostMap, clusterAndNameToType
 }
 
-func buildGPUCostMap(
+func (cm *CostModel) buildGPUCostMap(
 	resNodeGPUCost []*prom.QueryResult,
 	gpuCountMap map[NodeIdentifier]float64,
 	cp cloud.Provider,
@@ -196,9 +197,9 @@ func buildGPUCostMap(
 	}
 
 	for _, result := range resNodeGPUCost {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		name, err := result.GetString("node")
@@ -257,16 +258,16 @@ func buildGPUCostMap(
 	return gpuCostMap, clusterAndNameToType
 }
 
-func buildGPUCountMap(
+func (cm *CostModel) buildGPUCountMap(
 	resNodeGPUCount []*prom.QueryResult,
 ) map[NodeIdentifier]float64 {
 
 	gpuCountMap := make(map[NodeIdentifier]float64)
 
 	for _, result := range resNodeGPUCount {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		name, err := result.GetString("node")
@@ -289,16 +290,16 @@ func buildGPUCountMap(
 	return gpuCountMap
 }
 
-func buildCPUCoresMap(
+func (cm *CostModel) buildCPUCoresMap(
 	resNodeCPUCores []*prom.QueryResult,
 ) map[nodeIdentifierNoProviderID]float64 {
 
 	m := make(map[nodeIdentifierNoProviderID]float64)
 
 	for _, result := range resNodeCPUCores {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		name, err := result.GetString("node")
@@ -319,14 +320,14 @@ func buildCPUCoresMap(
 	return m
 }
 
-func buildRAMBytesMap(resNodeRAMBytes []*prom.QueryResult) map[nodeIdentifierNoProviderID]float64 {
+func (cm *CostModel) buildRAMBytesMap(resNodeRAMBytes []*prom.QueryResult) map[nodeIdentifierNoProviderID]float64 {
 
 	m := make(map[nodeIdentifierNoProviderID]float64)
 
 	for _, result := range resNodeRAMBytes {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		name, err := result.GetString("node")
@@ -348,7 +349,7 @@ func buildRAMBytesMap(resNodeRAMBytes []*prom.QueryResult) map[nodeIdentifierNoP
 }
 
 // Mapping of cluster/node=cpu for computing resource efficiency
-func buildCPUBreakdownMap(resNodeCPUModeTotal []*prom.QueryResult) map[nodeIdentifierNoProviderID]*ClusterCostsBreakdown {
+func (cm *CostModel) buildCPUBreakdownMap(resNodeCPUModeTotal []*prom.QueryResult) map[nodeIdentifierNoProviderID]*ClusterCostsBreakdown {
 
 	cpuBreakdownMap := make(map[nodeIdentifierNoProviderID]*ClusterCostsBreakdown)
 
@@ -360,9 +361,9 @@ func buildCPUBreakdownMap(resNodeCPUModeTotal []*prom.QueryResult) map[nodeIdent
 	// Build intermediate structures for CPU usage by (cluster, node) and by
 	// (cluster, node, mode) for computing resouce efficiency
 	for _, result := range resNodeCPUModeTotal {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		node, err := result.GetString("kubernetes_node")
@@ -425,14 +426,14 @@ func buildCPUBreakdownMap(resNodeCPUModeTotal []*prom.QueryResult) map[nodeIdent
 	return cpuBreakdownMap
 }
 
-func buildRAMUserPctMap(resNodeRAMUserPct []*prom.QueryResult) map[nodeIdentifierNoProviderID]float64 {
+func (cm *CostModel) buildRAMUserPctMap(resNodeRAMUserPct []*prom.QueryResult) map[nodeIdentifierNoProviderID]float64 {
 
 	m := make(map[nodeIdentifierNoProviderID]float64)
 
 	for _, result := range resNodeRAMUserPct {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		name, err := result.GetString("instance")
@@ -454,14 +455,14 @@ func buildRAMUserPctMap(resNodeRAMUserPct []*prom.QueryResult) map[nodeIdentifie
 	return m
 }
 
-func buildRAMSystemPctMap(resNodeRAMSystemPct []*prom.QueryResult) map[nodeIdentifierNoProviderID]float64 {
+func (cm *CostModel) buildRAMSystemPctMap(resNodeRAMSystemPct []*prom.QueryResult) map[nodeIdentifierNoProviderID]float64 {
 
 	m := make(map[nodeIdentifierNoProviderID]float64)
 
 	for _, result := range resNodeRAMSystemPct {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		name, err := result.GetString("instance")
@@ -489,14 +490,14 @@ type activeData struct {
 	minutes float64
 }
 
-func buildActiveDataMap(resActiveMins []*prom.QueryResult, resolution time.Duration) map[NodeIdentifier]activeData {
+func (cm *CostModel) buildActiveDataMap(resActiveMins []*prom.QueryResult, resolution time.Duration) map[NodeIdentifier]activeData {
 
 	m := make(map[NodeIdentifier]activeData)
 
 	for _, result := range resActiveMins {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		name, err := result.GetString("node")
@@ -534,7 +535,7 @@ func buildActiveDataMap(resActiveMins []*prom.QueryResult, resolution time.Durat
 
 // Determine preemptibility with node labels
 // node id -> is preemptible?
-func buildPreemptibleMap(
+func (cm *CostModel) buildPreemptibleMap(
 	resIsSpot []*prom.QueryResult,
 ) map[NodeIdentifier]bool {
 
@@ -549,9 +550,9 @@ func buildPreemptibleMap(
 		// GCP preemptible label
 		pre := result.Values[0].Value
 
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 
 		providerID, _ := result.GetString("provider_id")
@@ -576,7 +577,7 @@ func buildPreemptibleMap(
 	return m
 }
 
-func buildLabelsMap(
+func (cm *CostModel) buildLabelsMap(
 	resLabels []*prom.QueryResult,
 ) map[nodeIdentifierNoProviderID]map[string]string {
 
@@ -584,9 +585,9 @@ func buildLabelsMap(
 
 	// Copy labels into node
 	for _, result := range resLabels {
-		cluster, err := result.GetString(env.GetPromClusterLabel())
+		cluster, err := result.GetString(cm.ClusterLabel)
 		if err != nil {
-			cluster = env.GetClusterID()
+			cluster = cm.ClusterID
 		}
 		node, err := result.GetString("node")
 		if err != nil {
@@ -609,6 +610,210 @@ func buildLabelsMap(
 	return m
 }
 
+// buildAcceleratorTypeMap returns the types of the accelerators of nodes, by
+// the given kubecost_node_accelerator_info results.
+func (cm *CostModel) buildAcceleratorTypeMap(
+	resAcceleratorTypes []*prom.QueryResult,
+) map[nodeIdentifierNoProviderID]string {
+
+	m := make(map[nodeIdentifierNoProviderID]string)
+
+	for _, result := range resAcceleratorTypes {
+		cluster, err := result.GetString(cm.ClusterLabel)
+		if err != nil {
+			cluster = cm.ClusterID
+		}
+		node, err := result.GetString("node")
+		if err != nil {
+			log.DedupedWarningf(5, "ClusterNodes: accelerator data missing node")
+			continue
+		}
+		accelType, err := result.GetString("accelerator_type")
+		if err != nil {
+			continue
+		}
+
+		m[nodeIdentifierNoProviderID{
+			Cluster: cluster,
+			Name:    node,
+		}] = accelType
+	}
+
+	return m
+}
+
+// buildControlPlaneMap returns the control-plane nodes, by the role labels of
+// the given labels map and the given taints.
+func (cm *CostModel) buildControlPlaneMap(
+	labelsMap map[nodeIdentifierNoProviderID]map[string]string,
+	resTaints []*prom.QueryResult,
+) map[nodeIdentifierNoProviderID]bool {
+
+	taintsMap := make(map[nodeIdentifierNoProviderID][]cloud.NodeTaint)
+	for _, result := range resTaints {
+		cluster, err := result.GetString(cm.ClusterLabel)
+		if err != nil {
+			cluster = cm.ClusterID
+		}
+		node, err := result.GetString("node")
+		if err != nil {
+			log.DedupedWarningf(5, "ClusterNodes: taint data missing node")
+			continue
+		}
+		key, err := result.GetString("key")
+		if err != nil {
+			continue
+		}
+		value, _ := result.GetString("value")
+		effect, _ := result.GetString("effect")
+
+		id := nodeIdentifierNoProviderID{
+			Cluster: cluster,
+			Name:    node,
+		}
+		taintsMap[id] = append(taintsMap[id], cloud.NodeTaint{Key: key, Value: value, Effect: effect})
+	}
+
+	m := make(map[nodeIdentifierNoProviderID]bool)
+
+	for id, labels := range labelsMap {
+		// The labels map holds the labels as recorded in Prometheus, i.e.
+		// prefixed with "label_"
+		nodeLabels := make(map[string]string, len(labels))
+		for name, value := range labels {
+			if strings.HasPrefix(name, "label_") {
+				nodeLabels[strings.TrimPrefix(name, "label_")] = value
+			}
+		}
+		if cloud.IsControlPlaneNode(nodeLabels, taintsMap[id]) {
+			m[id] = true
+		}
+	}
+
+	for id, taints := range taintsMap {
+		if _, ok := labelsMap[id]; !ok && cloud.IsControlPlaneNode(nil, taints) {
+			m[id] = true
+		}
+	}
+
+	return m
+}
+
+// autopilotNodeClasses returns the compute classes of the Autopilot nodes of
+// the given node types.
+func autopilotNodeClasses(clusterAndNameToType map[nodeIdentifierNoProviderID]string) map[nodeIdentifierNoProviderID]cloud.AutopilotComputeClass {
+	m := make(map[nodeIdentifierNoProviderID]cloud.AutopilotComputeClass)
+	for id, nodeType := range clusterAndNameToType {
+		if class, ok := cloud.ParseAutopilotInstanceType(nodeType); ok {
+			m[id] = class
+		}
+	}
+	return m
+}
+
+// hasBurstableNodes returns true if any of the given node types is a
+// burstable instance type.
+func hasBurstableNodes(clusterAndNameToType map[nodeIdentifierNoProviderID]string) bool {
+	for _, nodeType := range clusterAndNameToType {
+		if _, ok := cloud.BurstableBaseline(nodeType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// buildAutopilotBilledRequestsMaps returns the vCPU cores and memory bytes
+// billed for the requests of the pods of the given Autopilot nodes, on
+// average over the minutes each node was active. Autopilot bills the
+// requests of pods, adjusted to the minimums and ratios of their compute
+// class, rather than nodes, so these take the place of the capacity of the
+// nodes, such that each node costs what its pods were billed.
+func (cm *CostModel) buildAutopilotBilledRequestsMaps(
+	resPodCPURequests, resPodRAMRequests, resPodMins []*prom.QueryResult,
+	autopilotNodes map[nodeIdentifierNoProviderID]cloud.AutopilotComputeClass,
+	activeDataMap map[NodeIdentifier]activeData,
+	resolution time.Duration,
+) (map[nodeIdentifierNoProviderID]float64, map[nodeIdentifierNoProviderID]float64) {
+
+	type podRequests struct {
+		node    nodeIdentifierNoProviderID
+		cpu     float64
+		ram     float64
+		minutes float64
+	}
+	pods := make(map[podKey]*podRequests)
+
+	getPod := func(result *prom.QueryResult) *podRequests {
+		cluster, err := result.GetString(cm.ClusterLabel)
+		if err != nil {
+			cluster = cm.ClusterID
+		}
+		node, err := result.GetString("node")
+		if err != nil {
+			return nil
+		}
+		id := nodeIdentifierNoProviderID{Cluster: cluster, Name: node}
+		if _, ok := autopilotNodes[id]; !ok {
+			return nil
+		}
+		namespace, _ := result.GetString("namespace")
+		pod, err := result.GetString("pod")
+		if err != nil || len(result.Values) == 0 {
+			return nil
+		}
+
+		key := newPodKey(cluster, namespace, pod)
+		if _, ok := pods[key]; !ok {
+			pods[key] = &podRequests{node: id}
+		}
+		return pods[key]
+	}
+
+	for _, result := range resPodCPURequests {
+		if p := getPod(result); p != nil {
+			p.cpu = result.Values[0].Value
+		}
+	}
+	for _, result := range resPodRAMRequests {
+		if p := getPod(result); p != nil {
+			p.ram = result.Values[0].Value
+		}
+	}
+	for _, result := range resPodMins {
+		if p := getPod(result); p != nil {
+			p.minutes = result.Values[0].Value * resolution.Minutes()
+		}
+	}
+
+	coreHours := make(map[nodeIdentifierNoProviderID]float64)
+	byteHours := make(map[nodeIdentifierNoProviderID]float64)
+	for _, p := range pods {
+		cpu, ram := cloud.AutopilotBilledRequests(autopilotNodes[p.node], p.cpu, p.ram)
+		coreHours[p.node] += cpu * p.minutes / 60.0
+		byteHours[p.node] += ram * p.minutes / 60.0
+	}
+
+	nodeHours := make(map[nodeIdentifierNoProviderID]float64)
+	for id, data := range activeDataMap {
+		keyNon := nodeIdentifierNoProviderID{Cluster: id.Cluster, Name: id.Name}
+		nodeHours[keyNon] = math.Max(nodeHours[keyNon], data.minutes/60.0)
+	}
+
+	cpuCoresMap := make(map[nodeIdentifierNoProviderID]float64, len(autopilotNodes))
+	ramBytesMap := make(map[nodeIdentifierNoProviderID]float64, len(autopilotNodes))
+	for id := range autopilotNodes {
+		// Nodes without pods are not billed
+		cpuCoresMap[id] = 0
+		ramBytesMap[id] = 0
+		if hours := nodeHours[id]; hours > 0 {
+			cpuCoresMap[id] = coreHours[id] / hours
+			ramBytesMap[id] = byteHours[id] / hours
+		}
+	}
+
+	return cpuCoresMap, ramBytesMap
+}
+
 // checkForKeyAndInitIfMissing inits a key in the provided nodemap if
 // it does not exist. Intended to be called ONLY by buildNodeMap
 func checkForKeyAndInitIfMissing(
@@ -768,6 +973,13 @@ func buildNodeMap(
 			nodePtr.RAMBytes = ramBytes
 		}
 
+		// Fargate nodes are billed for the configuration of their pod, which
+		// is encoded in the node type, rather than the virtual node capacity.
+		if conf, ok := cloud.ParseFargateInstanceType(nodePtr.NodeType); ok {
+			nodePtr.CPUCores = conf.VCPU
+			nodePtr.RAMBytes = conf.RAMBytes()
+		}
+
 		if ramUserPct, ok := ramUserPctMap[clusterAndNameID]; ok {
 			nodePtr.RAMBreakdown.User = ramUserPct
 		}
@@ -1,12 +1,14 @@
 package costmodel
 
 import (
+	"math"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/kubecost/opencost/pkg/cloud"
 	"github.com/kubecost/opencost/pkg/config"
+	"github.com/kubecost/opencost/pkg/kubecost"
 	"github.com/kubecost/opencost/pkg/prom"
 	"github.com/kubecost/opencost/pkg/util"
 
@@ -636,6 +638,51 @@ func TestBuildNodeMap(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "fargate node billed for its pod configuration",
+			cpuCostMap: map[NodeIdentifier]float64{
+				NodeIdentifier{
+					Cluster:    "cluster1",
+					Name:       "fargate-ip-10-0-1-2.ec2.internal",
+					ProviderID: "prov_node1",
+				}: 0.04048,
+			},
+			cpuCoresMap: map[nodeIdentifierNoProviderID]float64{
+				nodeIdentifierNoProviderID{
+					Cluster: "cluster1",
+					Name:    "fargate-ip-10-0-1-2.ec2.internal",
+				}: 2.0, // capacity of the virtual node
+			},
+			ramBytesMap: map[nodeIdentifierNoProviderID]float64{
+				nodeIdentifierNoProviderID{
+					Cluster: "cluster1",
+					Name:    "fargate-ip-10-0-1-2.ec2.internal",
+				}: 4 * 1024 * 1024 * 1024,
+			},
+			clusterAndNameToType: map[nodeIdentifierNoProviderID]string{
+				nodeIdentifierNoProviderID{
+					Cluster: "cluster1",
+					Name:    "fargate-ip-10-0-1-2.ec2.internal",
+				}: "fargate-0.5vCPU-1GB",
+			},
+			expected: map[NodeIdentifier]*Node{
+				NodeIdentifier{
+					Cluster:    "cluster1",
+					Name:       "fargate-ip-10-0-1-2.ec2.internal",
+					ProviderID: "prov_node1",
+				}: &Node{
+					Cluster:      "cluster1",
+					Name:         "fargate-ip-10-0-1-2.ec2.internal",
+					ProviderID:   "prov_node1",
+					NodeType:     "fargate-0.5vCPU-1GB",
+					CPUCost:      0.04048,
+					CPUCores:     0.5,
+					RAMBytes:     1024 * 1024 * 1024,
+					CPUBreakdown: &ClusterCostsBreakdown{},
+					RAMBreakdown: &ClusterCostsBreakdown{},
+				},
+			},
+		},
 		{
 			name: "e2-medium cpu cost adjustment",
 			cpuCostMap: map[NodeIdentifier]float64{
@@ -702,6 +749,208 @@ func TestBuildNodeMap(t *testing.T) {
 	}
 }
 
+func TestAutopilotNodeCostsMatchAllocations(t *testing.T) {
+	windowStart := time.Date(2021, 2, 19, 8, 0, 0, 0, time.UTC)
+	gib := 1024.0 * 1024.0 * 1024.0
+	cpuRate, ramRate := 0.0445, 0.0049225/gib
+
+	nodeID := NodeIdentifier{Cluster: "cluster1", Name: "gk3-cluster-pool-1-0a1b2c3d-x1y2", ProviderID: "prov_node1"}
+	nodeIDNon := nodeIdentifierNoProviderID{Cluster: nodeID.Cluster, Name: nodeID.Name}
+	clusterAndNameToType := map[nodeIdentifierNoProviderID]string{nodeIDNon: "autopilot-general-purpose"}
+	activeDataMap := map[NodeIdentifier]activeData{
+		nodeID: {start: windowStart, end: windowStart.Add(time.Hour), minutes: 60},
+	}
+
+	// Two pods: one below the minimums with two containers for the hour,
+	// and one without requests for half of it
+	type container struct {
+		pod, name string
+		cpu, ram  float64
+		minutes   int
+	}
+	containers := []container{
+		{"pod1", "app", 0.15, 0.3 * gib, 60},
+		{"pod1", "sidecar", 0.05, 0.1 * gib, 60},
+		{"pod2", "app", 0, 0, 30},
+	}
+
+	// Assets
+	podResult := func(pod string, value float64) *prom.QueryResult {
+		return &prom.QueryResult{
+			Metric: map[string]interface{}{"cluster_id": "cluster1", "node": nodeID.Name, "namespace": "namespace1", "pod": pod},
+			Values: []*util.Vector{{Value: value}},
+		}
+	}
+	podCPU, podRAM, podMins := map[string]float64{}, map[string]float64{}, map[string]float64{}
+	for _, c := range containers {
+		podCPU[c.pod] += c.cpu
+		podRAM[c.pod] += c.ram
+		podMins[c.pod] = float64(c.minutes)
+	}
+	var resPodCPURequests, resPodRAMRequests, resPodMins []*prom.QueryResult
+	for _, pod := range []string{"pod1", "pod2"} {
+		resPodCPURequests = append(resPodCPURequests, podResult(pod, podCPU[pod]))
+		resPodRAMRequests = append(resPodRAMRequests, podResult(pod, podRAM[pod]))
+		resPodMins = append(resPodMins, podResult(pod, podMins[pod]))
+	}
+
+	cpuCoresMap := map[nodeIdentifierNoProviderID]float64{nodeIDNon: 2}
+	ramBytesMap := map[nodeIdentifierNoProviderID]float64{nodeIDNon: 8 * gib}
+	billedCores, billedRAMBytes := testCostModel.buildAutopilotBilledRequestsMaps(resPodCPURequests, resPodRAMRequests, resPodMins, autopilotNodeClasses(clusterAndNameToType), activeDataMap, time.Minute)
+	for id, cores := range billedCores {
+		cpuCoresMap[id] = cores
+	}
+	for id, ramBytes := range billedRAMBytes {
+		ramBytesMap[id] = ramBytes
+	}
+
+	cpuCostMap := map[NodeIdentifier]float64{nodeID: cpuRate}
+	ramCostMap := map[NodeIdentifier]float64{nodeID: ramRate}
+	costTimesMinuteAndCount(activeDataMap, cpuCostMap, cpuCoresMap)
+	costTimesMinuteAndCount(activeDataMap, ramCostMap, ramBytesMap)
+
+	nodeMap := buildNodeMap(
+		cpuCostMap, ramCostMap, nil, nil,
+		cpuCoresMap, ramBytesMap, nil,
+		nil,
+		nil,
+		activeDataMap,
+		nil,
+		nil,
+		clusterAndNameToType,
+		time.Minute,
+	)
+	node := nodeMap[nodeID]
+	assetCost := node.CPUCost + node.RAMCost
+
+	// Allocations
+	podMap := map[podKey]*Pod{}
+	for _, c := range containers {
+		key := newPodKey("cluster1", "namespace1", c.pod)
+		if _, ok := podMap[key]; !ok {
+			podMap[key] = &Pod{Allocations: map[string]*kubecost.Allocation{}}
+		}
+		podMap[key].Allocations[c.name] = &kubecost.Allocation{
+			Properties: &kubecost.AllocationProperties{
+				Cluster:   "cluster1",
+				Node:      nodeID.Name,
+				Namespace: "namespace1",
+				Pod:       c.pod,
+				Container: c.name,
+			},
+			Start:                  windowStart,
+			End:                    windowStart.Add(time.Duration(c.minutes) * time.Minute),
+			CPUCoreRequestAverage:  c.cpu,
+			RAMBytesRequestAverage: c.ram,
+		}
+	}
+	applyAutopilotRequests(podMap, map[nodeKey]*NodePricing{
+		newNodeKey("cluster1", nodeID.Name): {Name: nodeID.Name, NodeType: "autopilot-general-purpose"},
+	})
+	allocationCost := 0.0
+	for _, pod := range podMap {
+		for _, alloc := range pod.Allocations {
+			allocationCost += alloc.CPUCoreHours*cpuRate + alloc.RAMByteHours*ramRate
+		}
+	}
+
+	// pod1 is billed 0.25 vCPU and 0.5 GiB for the hour, pod2 the same for
+	// half of it
+	expected := 0.375*cpuRate + 0.75*gib*ramRate
+	if math.Abs(assetCost-expected) > 1e-9 {
+		t.Errorf("expected node cost %f; got %f", expected, assetCost)
+	}
+	if math.Abs(assetCost-allocationCost) > 1e-9 {
+		t.Errorf("expected node cost %f to equal allocation cost %f", assetCost, allocationCost)
+	}
+}
+
+func TestNodeToAsset_CPUCreditSurcharge(t *testing.T) {
+	start := time.Date(2021, 2, 19, 8, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	n := &Node{
+		Cluster:            "cluster1",
+		Name:               "t3-node",
+		NodeType:           "t3.large",
+		CPUCost:            0.04,
+		CPUCores:           2,
+		RAMCost:            0.04,
+		Discount:           0.5,
+		Start:              start,
+		End:                end,
+		Minutes:            60,
+		CPUCreditSurcharge: 0.05,
+	}
+	node := nodeToAsset(n, kubecost.NewClosedWindow(start, end))
+
+	// The surcharge is an adjustment, which is not discounted
+	if node.CPUCost != 0.04 || node.Adjustment() != 0.05 {
+		t.Errorf("expected CPU cost 0.04 and adjustment 0.05; got %f and %f", node.CPUCost, node.Adjustment())
+	}
+	if math.Abs(node.TotalCost()-0.09) > 1e-9 {
+		t.Errorf("expected total cost 0.09; got %f", node.TotalCost())
+	}
+}
+
+func TestNodeToAsset_AcceleratorType(t *testing.T) {
+	start := time.Date(2021, 2, 19, 8, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	resAcceleratorTypes := []*prom.QueryResult{
+		{
+			Metric: map[string]interface{}{
+				"cluster_id":       "cluster1",
+				"node":             "tpu-node",
+				"accelerator_type": "tpu",
+			},
+			Values: []*util.Vector{{Value: 1}},
+		},
+		{
+			Metric: map[string]interface{}{
+				"cluster_id": "cluster1",
+				"node":       "cpu-node",
+			},
+			Values: []*util.Vector{{Value: 1}},
+		},
+	}
+	acceleratorTypeMap := testCostModel.buildAcceleratorTypeMap(resAcceleratorTypes)
+	expected := map[nodeIdentifierNoProviderID]string{
+		{Cluster: "cluster1", Name: "tpu-node"}: "tpu",
+	}
+	if !reflect.DeepEqual(acceleratorTypeMap, expected) {
+		t.Fatalf("buildAcceleratorTypeMap: expected %v; got %v", expected, acceleratorTypeMap)
+	}
+
+	labels := map[string]string{"label_cloud_google_com_gke_tpu_accelerator": "tpu-v5-lite-podslice"}
+	tpuNode := nodeToAsset(&Node{
+		Cluster:         "cluster1",
+		Name:            "tpu-node",
+		Start:           start,
+		End:             end,
+		Minutes:         60,
+		Labels:          labels,
+		AcceleratorType: acceleratorTypeMap[nodeIdentifierNoProviderID{Cluster: "cluster1", Name: "tpu-node"}],
+	}, kubecost.NewClosedWindow(start, end))
+	if tpuNode.Labels()[kubecost.AcceleratorTypeLabel] != "tpu" {
+		t.Errorf("expected accelerator type label \"tpu\"; got %v", tpuNode.Labels())
+	}
+	if _, ok := labels[kubecost.AcceleratorTypeLabel]; ok {
+		t.Errorf("expected the node's labels to be left unmodified")
+	}
+
+	cpuNode := nodeToAsset(&Node{
+		Cluster: "cluster1",
+		Name:    "cpu-node",
+		Start:   start,
+		End:     end,
+		Minutes: 60,
+	}, kubecost.NewClosedWindow(start, end))
+	if _, ok := cpuNode.Labels()[kubecost.AcceleratorTypeLabel]; ok {
+		t.Errorf("expected no accelerator type label; got %v", cpuNode.Labels())
+	}
+}
+
 func TestBuildGPUCostMap(t *testing.T) {
 	cases := []struct {
 		name       string
@@ -857,7 +1106,7 @@ func TestBuildGPUCostMap(t *testing.T) {
 				Config: cloud.NewProviderConfig(config.NewConfigFileManager(nil), "fakeFile"),
 			}
 			testPreemptible := make(map[NodeIdentifier]bool)
-			result, _ := buildGPUCostMap(testCase.promResult, testCase.countMap, testProvider, testPreemptible)
+			result, _ := testCostModel.buildGPUCostMap(testCase.promResult, testCase.countMap, testProvider, testPreemptible)
 			if !reflect.DeepEqual(result, testCase.expected) {
 				t.Errorf("buildGPUCostMap case %s failed. Got %+v but expected %+v", testCase.name, result, testCase.expected)
 			}
@@ -991,16 +1240,16 @@ func TestAssetCustompricing(t *testing.T) {
 			testProvider.UpdateConfigFromConfigMap(testCase.customPricingMap)
 
 			testPreemptible := make(map[NodeIdentifier]bool)
-			cpuMap, _ := buildCPUCostMap(nodePromResult, testProvider, testPreemptible)
-			ramMap, _ := buildRAMCostMap(nodePromResult, testProvider, testPreemptible)
-			gpuMap, _ := buildGPUCostMap(nodePromResult, gpuCountMap, testProvider, testPreemptible)
+			cpuMap, _ := testCostModel.buildCPUCostMap(nodePromResult, testProvider, testPreemptible)
+			ramMap, _ := testCostModel.buildRAMCostMap(nodePromResult, testProvider, testPreemptible)
+			gpuMap, _ := testCostModel.buildGPUCostMap(nodePromResult, gpuCountMap, testProvider, testPreemptible)
 
 			cpuResult := cpuMap[nodeKey]
 			ramResult := ramMap[nodeKey]
 			gpuResult := gpuMap[nodeKey]
 
 			diskMap := map[DiskIdentifier]*Disk{}
-			pvCosts(diskMap, time.Hour, pvMinsPromResult, pvSizePromResult, pvCostPromResult, testProvider)
+			testCostModel.pvCosts(diskMap, time.Hour, pvMinsPromResult, pvSizePromResult, pvCostPromResult, testProvider)
 
 			diskResult := diskMap[DiskIdentifier{"cluster1", "pvc1"}].Cost
 
@@ -1020,3 +1269,212 @@ func TestAssetCustompricing(t *testing.T) {
 	}
 
 }
+
+func TestBuildControlPlaneMap(t *testing.T) {
+	labelsMap := map[nodeIdentifierNoProviderID]map[string]string{
+		{Cluster: "kubeadm", Name: "cp-1"}: {
+			"node": "cp-1",
+			"label_node_role_kubernetes_io_control_plane": "",
+		},
+		{Cluster: "kubeadm", Name: "worker-1"}: {
+			"node":                         "worker-1",
+			"label_kubernetes_io_hostname": "worker-1",
+		},
+		{Cluster: "rke", Name: "cp-1"}: {
+			"node": "cp-1",
+			"label_node_role_kubernetes_io_controlplane": "true",
+		},
+		{Cluster: "rke", Name: "worker-1"}: {
+			"node":                                 "worker-1",
+			"label_node_role_kubernetes_io_worker": "true",
+		},
+		{Cluster: "kops", Name: "master-1"}: {
+			"node":                     "master-1",
+			"label_kubernetes_io_role": "master",
+		},
+		{Cluster: "kops", Name: "node-1"}: {
+			"node":                     "node-1",
+			"label_kubernetes_io_role": "node",
+		},
+		{Cluster: "eks", Name: "node-1"}: {
+			"node":                              "node-1",
+			"label_eks_amazonaws_com_nodegroup": "default",
+		},
+	}
+
+	// The RKE etcd node has taints but no labels recorded
+	resTaints := []*prom.QueryResult{
+		{
+			Metric: map[string]interface{}{
+				"cluster_id": "kubeadm",
+				"node":       "cp-1",
+				"key":        "node-role.kubernetes.io/control-plane",
+				"effect":     "NoSchedule",
+			},
+		},
+		{
+			Metric: map[string]interface{}{
+				"cluster_id": "rke",
+				"node":       "etcd-1",
+				"key":        "node-role.kubernetes.io/etcd",
+				"value":      "true",
+				"effect":     "NoExecute",
+			},
+		},
+		{
+			Metric: map[string]interface{}{
+				"cluster_id": "eks",
+				"node":       "node-1",
+				"key":        "dedicated",
+				"value":      "ml",
+				"effect":     "NoSchedule",
+			},
+		},
+	}
+
+	expected := map[nodeIdentifierNoProviderID]bool{
+		{Cluster: "kubeadm", Name: "cp-1"}:  true,
+		{Cluster: "rke", Name: "cp-1"}:      true,
+		{Cluster: "rke", Name: "etcd-1"}:    true,
+		{Cluster: "kops", Name: "master-1"}: true,
+	}
+
+	actual := testCostModel.buildControlPlaneMap(labelsMap, resTaints)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("buildControlPlaneMap: expected %v; got %v", expected, actual)
+	}
+}
+
+func TestClusterControlPlanes(t *testing.T) {
+	start := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	window := kubecost.NewWindow(&start, &end)
+
+	cases := []struct {
+		provider         string
+		expectedProvider string
+	}{
+		{provider: kubecost.AWSProvider, expectedProvider: kubecost.AWSProvider},
+		{provider: kubecost.GCPProvider, expectedProvider: kubecost.GCPProvider},
+		{provider: "Azure", expectedProvider: kubecost.AzureProvider},
+		{provider: "custom", expectedProvider: kubecost.NilProvider},
+	}
+
+	for _, c := range cases {
+		nodeMap := map[NodeIdentifier]*Node{
+			{Cluster: "cluster1", Name: "cp-1"}: {
+				Cluster: "cluster1", Name: "cp-1", ControlPlane: true,
+				CPUCost: 10.0, RAMCost: 6.0, Discount: 0.25,
+			},
+			{Cluster: "cluster1", Name: "cp-2"}: {
+				Cluster: "cluster1", Name: "cp-2", ControlPlane: true,
+				CPUCost: 4.0, RAMCost: 4.0, GPUCost: 2.0,
+			},
+			{Cluster: "cluster1", Name: "worker-1"}: {
+				Cluster: "cluster1", Name: "worker-1",
+				CPUCost: 20.0, RAMCost: 10.0,
+			},
+			{Cluster: "cluster2", Name: "worker-1"}: {
+				Cluster: "cluster2", Name: "worker-1",
+				CPUCost: 20.0, RAMCost: 10.0,
+			},
+		}
+
+		cms := ClusterControlPlanes(c.provider, nodeMap, window)
+		if len(cms) != 1 {
+			t.Fatalf("%s: expected 1 ClusterManagement; got %d", c.provider, len(cms))
+		}
+		cm, ok := cms["cluster1"]
+		if !ok {
+			t.Fatalf("%s: missing ClusterManagement of cluster1", c.provider)
+		}
+		if !util.IsApproximately(cm.Cost, 22.0) {
+			t.Errorf("%s: expected cost 22.0; got %f", c.provider, cm.Cost)
+		}
+		if cm.Properties().Provider != c.expectedProvider {
+			t.Errorf("%s: expected provider %s; got %s", c.provider, c.expectedProvider, cm.Properties().Provider)
+		}
+		if cm.Properties().Category != kubecost.ManagementCategory {
+			t.Errorf("%s: expected category %s; got %s", c.provider, kubecost.ManagementCategory, cm.Properties().Category)
+		}
+
+		// Control-plane nodes are reported as cluster management only
+		if len(nodeMap) != 2 {
+			t.Errorf("%s: expected 2 remaining nodes; got %d", c.provider, len(nodeMap))
+		}
+		for _, node := range nodeMap {
+			if node.ControlPlane {
+				t.Errorf("%s: control-plane node %s remains", c.provider, node.Name)
+			}
+		}
+	}
+}
+
+func TestApplyPriceSchedule(t *testing.T) {
+	schedule, err := cloud.ParsePriceSchedule([]byte(`{"windows": [
+		{"name": "peak", "days": ["weekday"], "start": "09:00", "end": "17:00", "multiplier": 2, "GPU": 1.0}
+	]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Active for an hour off-peak and an hour at peak on a Wednesday
+	node := NodeIdentifier{Cluster: "cluster1", Name: "node1", ProviderID: "node1"}
+	idle := NodeIdentifier{Cluster: "cluster1", Name: "node2", ProviderID: "node2"}
+	activeDataMap := map[NodeIdentifier]activeData{
+		node: {
+			start:   time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC),
+			end:     time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC),
+			minutes: 120,
+		},
+		idle: {
+			start:   time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC),
+			end:     time.Date(2026, 10, 14, 2, 0, 0, 0, time.UTC),
+			minutes: 120,
+		},
+	}
+	cpuCostMap := map[NodeIdentifier]float64{node: 0.04, idle: 0.04}
+	ramCostMap := map[NodeIdentifier]float64{node: 0.005 / 1024 / 1024 / 1024, idle: 0.005 / 1024 / 1024 / 1024}
+	gpuCostMap := map[NodeIdentifier]float64{node: 1.0, idle: 0}
+	gpuCountMap := map[NodeIdentifier]float64{node: 2}
+
+	applyPriceSchedule(schedule, activeDataMap, cpuCostMap, ramCostMap, gpuCostMap, gpuCountMap)
+
+	const tolerance = 1e-9
+	if math.Abs(cpuCostMap[node]-0.06) > tolerance {
+		t.Errorf("expected CPU price 0.06; got %f", cpuCostMap[node])
+	}
+	if ramGiB := ramCostMap[node] * 1024 * 1024 * 1024; math.Abs(ramGiB-0.0075) > tolerance {
+		t.Errorf("expected RAM price 0.0075 per GiB-hour; got %f", ramGiB)
+	}
+	// The schedule prices each of the 2 GPUs at 0.5 off-peak and 1.0 at peak
+	if math.Abs(gpuCostMap[node]-1.5) > tolerance {
+		t.Errorf("expected GPU price 1.5; got %f", gpuCostMap[node])
+	}
+
+	// Nodes active entirely off-peak keep their list prices
+	if cpuCostMap[idle] != 0.04 || gpuCostMap[idle] != 0 {
+		t.Errorf("expected list prices off-peak; got CPU %f, GPU %f", cpuCostMap[idle], gpuCostMap[idle])
+	}
+
+	// Without a schedule, prices are unchanged
+	applyPriceSchedule(nil, activeDataMap, cpuCostMap, ramCostMap, gpuCostMap, gpuCountMap)
+	if math.Abs(cpuCostMap[node]-0.06) > tolerance {
+		t.Errorf("expected CPU price 0.06 without a schedule; got %f", cpuCostMap[node])
+	}
+}
+
+func TestNewClusterCostsFromCumulative_Management(t *testing.T) {
+	costs, err := NewClusterCostsFromCumulative(10, 2, 5, 3, 4, 24*time.Hour, 0, 24)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The control-plane nodes are counted once, as cluster management
+	if costs.TotalCumulative != 24 || costs.ManagementCumulative != 4 {
+		t.Errorf("expected a total of 24 with management costing 4; got %f and %f", costs.TotalCumulative, costs.ManagementCumulative)
+	}
+	if math.Abs(costs.TotalMonthly-costs.CPUMonthly-costs.GPUMonthly-costs.RAMMonthly-costs.StorageMonthly-costs.ManagementMonthly) > 1e-9 {
+		t.Errorf("expected the monthly total to sum its costs; got %f", costs.TotalMonthly)
+	}
+}
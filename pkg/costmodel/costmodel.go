@@ -57,7 +57,26 @@ type CostModel struct {
 	ScrapeInterval             time.Duration
 	PrometheusClient           prometheus.Client
 	Provider                   costAnalyzerCloud.Provider
-	pricingMetadata            *costAnalyzerCloud.PricingMatchMetadata
+	// ClusterID is the cluster of metrics which have no ClusterLabel, the
+	// label which identifies the cluster of metrics in Prometheus.
+	ClusterID    string
+	ClusterLabel string
+	// IngestPodUID distinguishes pods of the same name by their UID.
+	IngestPodUID bool
+	// PriceSchedule returns the schedule by which the prices of nodes vary
+	// over time, or nil if they do not. Optional.
+	PriceSchedule func() *costAnalyzerCloud.PriceSchedule
+	// NodeOwnerRules returns the rules by which nodes are dedicated to
+	// owners, or nil if there are none. Optional.
+	NodeOwnerRules func() *costAnalyzerCloud.NodeOwnerRules
+	// PrometheusQueryOffset is subtracted from the time of queries which are
+	// not given one.
+	PrometheusQueryOffset time.Duration
+	// Clock tells the current time, after which windows may not be
+	// computed. Defaults to time.Now.
+	Clock           func() time.Time
+	pricingMetadata *costAnalyzerCloud.PricingMatchMetadata
+	hosts           []*costAnalyzerCloud.Host
 }
 
 func NewCostModel(client prometheus.Client, provider costAnalyzerCloud.Provider, cache clustercache.ClusterCache, clusterMap clusters.ClusterMap, scrapeInterval time.Duration) *CostModel {
@@ -72,9 +91,48 @@ func NewCostModel(client prometheus.Client, provider costAnalyzerCloud.Provider,
 		Provider:                   provider,
 		RequestGroup:               requestGroup,
 		ScrapeInterval:             scrapeInterval,
+		ClusterID:                  env.GetClusterID(),
+		ClusterLabel:               env.GetPromClusterLabel(),
+		IngestPodUID:               env.IsIngestingPodUID(),
+		PrometheusQueryOffset:      env.GetPrometheusQueryOffset(),
+		Clock:                      time.Now,
 	}
 }
 
+// now returns the time of the CostModel's Clock.
+func (cm *CostModel) now() time.Time {
+	if cm.Clock == nil {
+		return time.Now()
+	}
+	return cm.Clock()
+}
+
+// promContext creates a named Prometheus querying context of the CostModel's
+// client, query offset and Clock.
+func (cm *CostModel) promContext(name string) *prom.Context {
+	return prom.NewNamedContextWithOptions(cm.PrometheusClient, name, prom.ContextOptions{
+		QueryOffset: cm.PrometheusQueryOffset,
+		Clock:       cm.Clock,
+	})
+}
+
+// priceSchedule returns the current price schedule, or nil if there is none.
+func (cm *CostModel) priceSchedule() *costAnalyzerCloud.PriceSchedule {
+	if cm.PriceSchedule == nil {
+		return nil
+	}
+	return cm.PriceSchedule()
+}
+
+// nodeOwnerRules returns the current node ownership rules, or nil if there
+// are none.
+func (cm *CostModel) nodeOwnerRules() *costAnalyzerCloud.NodeOwnerRules {
+	if cm.NodeOwnerRules == nil {
+		return nil
+	}
+	return cm.NodeOwnerRules()
+}
+
 type CostData struct {
 	Name            string                       `json:"name,omitempty"`
 	PodName         string                       `json:"podName,omitempty"`
@@ -321,6 +379,8 @@ func (cm *CostModel) ComputeCostData(cli prometheusClient.Client, cp costAnalyze
 		vgpuCoeff = vgpuCount
 	}
 
+	accelerators := getAccelerators(cp)
+
 	nodes, err := cm.GetNodeCost(cp)
 	if err != nil {
 		log.Warnf("GetNodeCost: no node cost model available: " + err.Error())
@@ -505,10 +565,10 @@ func (cm *CostModel) ComputeCostData(cli prometheusClient.Client, cp costAnalyze
 				}
 
 				gpuReqCount := 0.0
-				if g, ok := container.Resources.Requests["nvidia.com/gpu"]; ok {
-					gpuReqCount = g.AsApproximateFloat64()
-				} else if g, ok := container.Resources.Limits["nvidia.com/gpu"]; ok {
-					gpuReqCount = g.AsApproximateFloat64()
+				if _, count := accelerators.Count(container.Resources.Requests); count > 0 {
+					gpuReqCount = count
+				} else if _, count := accelerators.Count(container.Resources.Limits); count > 0 {
+					gpuReqCount = count
 				} else if g, ok := container.Resources.Requests["k8s.amazonaws.com/vgpu"]; ok {
 					// divide vgpu request/limits by total vgpus to get the portion of physical gpus requested
 					gpuReqCount = g.AsApproximateFloat64() / vgpuCoeff
@@ -930,6 +990,49 @@ func (cm *CostModel) GetPricingSourceCounts() (*costAnalyzerCloud.PricingMatchMe
 	}
 }
 
+// GetHostPricing returns the dedicated and sole-tenant hosts priced by the last node cost calculation
+func (cm *CostModel) GetHostPricing() ([]*costAnalyzerCloud.Host, error) {
+	if cm.hosts != nil {
+		return cm.hosts, nil
+	} else {
+		return nil, fmt.Errorf("Node costs not yet calculated")
+	}
+}
+
+// getAccelerators returns the accelerators configured for the provider, or the default accelerators if the
+// configuration is invalid.
+func getAccelerators(cp costAnalyzerCloud.Provider) *costAnalyzerCloud.Accelerators {
+	var cfg *costAnalyzerCloud.CustomPricing
+	if cp != nil {
+		if c, err := cp.GetConfig(); err == nil {
+			cfg = c
+		}
+	}
+	accelerators, err := costAnalyzerCloud.NewAccelerators(cfg)
+	if err != nil {
+		log.Warnf("invalid accelerator configuration: %s", err)
+		accelerators, _ = costAnalyzerCloud.NewAccelerators(nil)
+	}
+	return accelerators
+}
+
+// getExtendedResources returns the extended resource prices of the provider's configuration, or none if it is
+// invalid.
+func getExtendedResources(cp costAnalyzerCloud.Provider) *costAnalyzerCloud.ExtendedResources {
+	var cfg *costAnalyzerCloud.CustomPricing
+	if cp != nil {
+		if c, err := cp.GetConfig(); err == nil {
+			cfg = c
+		}
+	}
+	extendedResources, err := costAnalyzerCloud.NewExtendedResources(cfg)
+	if err != nil {
+		log.Warnf("invalid extended resource configuration: %s", err)
+		extendedResources, _ = costAnalyzerCloud.NewExtendedResources(nil)
+	}
+	return extendedResources
+}
+
 func (cm *CostModel) GetNodeCost(cp costAnalyzerCloud.Provider) (map[string]*costAnalyzerCloud.Node, error) {
 	cfg, err := cp.GetConfig()
 	if err != nil {
@@ -945,14 +1048,19 @@ func (cm *CostModel) GetNodeCost(cp costAnalyzerCloud.Provider) (map[string]*cos
 		vgpuCoeff = vgpuCount
 	}
 
+	accelerators := getAccelerators(cp)
+
 	pmd := &costAnalyzerCloud.PricingMatchMetadata{
 		TotalNodes:        0,
 		PricingTypeCounts: make(map[costAnalyzerCloud.PricingType]int),
+		GPUModelCounts:    make(map[string]int),
 	}
+	nodeLabelsByName := make(map[string]map[string]string)
 	for _, n := range nodeList {
 		name := n.GetObjectMeta().GetName()
 		nodeLabels := n.GetObjectMeta().GetLabels()
 		nodeLabels["providerID"] = n.Spec.ProviderID
+		nodeLabelsByName[name] = nodeLabels
 
 		pmd.TotalNodes++
 
@@ -975,6 +1083,9 @@ func (cm *CostModel) GetNodeCost(cp costAnalyzerCloud.Provider) (map[string]*cos
 		} else {
 			pmd.PricingTypeCounts[cnode.PricingType] = 1
 		}
+		if cnode.GPUPricingModel != "" {
+			pmd.GPUModelCounts[cnode.GPUPricingModel]++
+		}
 
 		newCnode := *cnode
 		if newCnode.InstanceType == "" {
@@ -1007,6 +1118,13 @@ func (cm *CostModel) GetNodeCost(cp costAnalyzerCloud.Provider) (map[string]*cos
 			newCnode.RAM = n.Status.Capacity.Memory().String()
 		}
 		ram = float64(n.Status.Capacity.Memory().Value())
+		if newCnode.PricingType == costAnalyzerCloud.Fargate {
+			// Fargate nodes are billed for their pod's configuration rather than the capacity of the virtual node
+			ram, err = strconv.ParseFloat(newCnode.RAMBytes, 64)
+			if err != nil {
+				log.Warnf("parsing RAMBytes value: \"%s\" as float64", newCnode.RAMBytes)
+			}
+		}
 		if math.IsNaN(ram) {
 			log.Warnf("ram parsed as NaN. Setting to 0.")
 			ram = 0
@@ -1017,18 +1135,16 @@ func (cm *CostModel) GetNodeCost(cp costAnalyzerCloud.Provider) (map[string]*cos
 		// Azure does not seem to provide a GPU count in its pricing API. GKE supports attaching multiple GPUs
 		// So the k8s api will often report more accurate results for GPU count under status > capacity > nvidia.com/gpu than the cloud providers billing data
 		// not all providers are guaranteed to use this, so don't overwrite a Provider assignment if we can't find something under that capacity exists
+		// Other accelerators, e.g. AMD and Intel GPUs, TPUs and Neuron devices, are counted as GPUs.
 		gpuc := 0.0
-		q, ok := n.Status.Capacity["nvidia.com/gpu"]
-		if ok {
-			gpuCount := q.Value()
-			if gpuCount != 0 {
-				newCnode.GPU = fmt.Sprintf("%d", q.Value())
-				gpuc = float64(gpuCount)
-			}
+		if accelType, count := accelerators.Count(n.Status.Capacity); count > 0 {
+			newCnode.GPU = fmt.Sprintf("%d", int64(count))
+			newCnode.AcceleratorType = accelType
+			gpuc = count
 		} else if g, ok := n.Status.Capacity["k8s.amazonaws.com/vgpu"]; ok {
 			gpuCount := g.Value()
 			if gpuCount != 0 {
-				newCnode.GPU = fmt.Sprintf("%d", int(float64(q.Value())/vgpuCoeff))
+				newCnode.GPU = fmt.Sprintf("%d", int(float64(gpuCount)/vgpuCoeff))
 				gpuc = float64(gpuCount) / vgpuCoeff
 			}
 		} else {
@@ -1042,6 +1158,13 @@ func (cm *CostModel) GetNodeCost(cp costAnalyzerCloud.Provider) (map[string]*cos
 			gpuc = 0.0
 		}
 
+		// Accelerator prices take precedence over the provider's default GPU price, but not over the price of a
+		// matched GPU model.
+		accelPrice, hasAccelPrice := accelerators.Price(newCnode.AcceleratorType)
+		if hasAccelPrice && newCnode.GPUCost != "" && (newCnode.GPUPricingModel == "" || newCnode.GPUPricingModel == "default") {
+			newCnode.GPUCost = fmt.Sprintf("%f", accelPrice)
+		}
+
 		if newCnode.GPU != "" && newCnode.GPUCost == "" {
 			// We couldn't find a gpu cost, so fix cpu and ram, then accordingly
 			log.Debugf("GPU without cost found for %s, calculating...", cp.GetKey(nodeLabels, n).Features())
@@ -1075,6 +1198,9 @@ func (cm *CostModel) GetNodeCost(cp costAnalyzerCloud.Provider) (map[string]*cos
 				log.Warnf("defaultGPU parsed as NaN. Setting to 0.")
 				defaultGPU = 0
 			}
+			if hasAccelPrice {
+				defaultGPU = accelPrice
+			}
 
 			cpuToRAMRatio := defaultCPU / defaultRAM
 			if math.IsNaN(cpuToRAMRatio) {
@@ -1220,6 +1346,7 @@ func (cm *CostModel) GetNodeCost(cp costAnalyzerCloud.Provider) (map[string]*cos
 	}
 	cm.pricingMetadata = pmd
 	cp.ApplyReservedInstancePricing(nodes)
+	cm.hosts = costAnalyzerCloud.ApplyHostPricing(nodes, nodeLabelsByName, cfg)
 
 	return nodes, nil
 }
@@ -1,9 +1,23 @@
 package costmodel
 
 import (
+	"strconv"
 	"testing"
+
+	"github.com/kubecost/opencost/pkg/cloud"
+	"github.com/kubecost/opencost/pkg/clustercache"
+	"github.com/kubecost/opencost/pkg/config"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// testCostModel is a CostModel with the default cluster label, with which the
+// helpers that parse query results are tested.
+var testCostModel = &CostModel{ClusterLabel: "cluster_id"}
+
 func Test_CostData_GetController_CronJob(t *testing.T) {
 	cases := []struct {
 		name string
@@ -65,3 +79,101 @@ func Test_CostData_GetController_CronJob(t *testing.T) {
 		})
 	}
 }
+
+// nodeFixtureClusterCache serves nodes, and panics on any other use of the
+// cluster cache but listing DaemonSets.
+type nodeFixtureClusterCache struct {
+	clustercache.ClusterCache
+
+	nodes []*v1.Node
+}
+
+func (nfcc *nodeFixtureClusterCache) GetAllNodes() []*v1.Node               { return nfcc.nodes }
+func (nfcc *nodeFixtureClusterCache) GetAllDaemonSets() []*appsv1.DaemonSet { return nil }
+
+func newAcceleratorNode(name, accelResource, count string) *v1.Node {
+	capacity := v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("8"),
+		v1.ResourceMemory: resource.MustParse("32Gi"),
+	}
+	labels := map[string]string{}
+	if accelResource != "" {
+		capacity[v1.ResourceName(accelResource)] = resource.MustParse(count)
+		labels["accelerator"] = "true"
+	}
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Status:     v1.NodeStatus{Capacity: capacity},
+	}
+}
+
+func TestGetNodeCost_Accelerators(t *testing.T) {
+	provider := &cloud.CustomProvider{
+		Config: cloud.NewProviderConfig(config.NewConfigFileManager(nil), ""),
+	}
+	provider.UpdateConfigFromConfigMap(map[string]string{
+		"CPU":               "21.9",
+		"RAM":               "2.92",
+		"GPU":               "730",
+		"gpuLabel":          "accelerator",
+		"acceleratorPrices": "amd:1.80,tpu:1.20,neuron:0.76",
+	})
+	if err := provider.DownloadPricingData(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cm := &CostModel{
+		Cache: &nodeFixtureClusterCache{
+			nodes: []*v1.Node{
+				newAcceleratorNode("nvidia-node", "nvidia.com/gpu", "4"),
+				newAcceleratorNode("amd-node", "amd.com/gpu", "8"),
+				newAcceleratorNode("intel-node", "gpu.intel.com/i915", "1"),
+				newAcceleratorNode("tpu-node", "google.com/tpu", "4"),
+				newAcceleratorNode("neuron-node", "aws.amazon.com/neuron", "16"),
+				newAcceleratorNode("cpu-node", "", ""),
+			},
+		},
+	}
+
+	nodes, err := cm.GetNodeCost(provider)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Monthly CPU, RAM and GPU prices are configured, but accelerator prices
+	// are hourly. NVIDIA and Intel GPUs have no accelerator price, so they are
+	// priced at the default GPU price of 1.00 per hour.
+	cases := []struct {
+		node      string
+		accelType string
+		gpuCount  string
+		gpuCost   float64
+	}{
+		{node: "nvidia-node", accelType: cloud.AcceleratorNVIDIA, gpuCount: "4", gpuCost: 1.00},
+		{node: "amd-node", accelType: cloud.AcceleratorAMD, gpuCount: "8", gpuCost: 1.80},
+		{node: "intel-node", accelType: cloud.AcceleratorIntel, gpuCount: "1", gpuCost: 1.00},
+		{node: "tpu-node", accelType: cloud.AcceleratorTPU, gpuCount: "4", gpuCost: 1.20},
+		{node: "neuron-node", accelType: cloud.AcceleratorNeuron, gpuCount: "16", gpuCost: 0.76},
+	}
+
+	for _, c := range cases {
+		node, ok := nodes[c.node]
+		if !ok {
+			t.Fatalf("%s: missing node", c.node)
+		}
+		if node.AcceleratorType != c.accelType {
+			t.Errorf("%s: expected accelerator type %q; got %q", c.node, c.accelType, node.AcceleratorType)
+		}
+		if node.GPU != c.gpuCount {
+			t.Errorf("%s: expected %s GPUs; got %s", c.node, c.gpuCount, node.GPU)
+		}
+		cost, err := strconv.ParseFloat(node.GPUCost, 64)
+		if err != nil || cost != c.gpuCost {
+			t.Errorf("%s: expected GPU cost %f; got %s", c.node, c.gpuCost, node.GPUCost)
+		}
+	}
+
+	if node := nodes["cpu-node"]; node.AcceleratorType != "" || node.GPU != "" {
+		t.Errorf("cpu-node: expected no accelerators; got %s %q", node.GPU, node.AcceleratorType)
+	}
+}
@@ -0,0 +1,173 @@
+package costmodel
+
+import (
+	"strings"
+
+	"github.com/kubecost/opencost/pkg/clustercache"
+	"github.com/kubecost/opencost/pkg/kubecost"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Helm annotates the objects of a release with the release's name, and
+	// its charts conventionally label them with the release's name as their
+	// instance when managed by Helm
+	helmReleaseNameAnnotation = "meta.helm.sh/release-name"
+	helmChartLabel            = "helm.sh/chart"
+	appManagedByLabel         = "app.kubernetes.io/managed-by"
+	appInstanceLabel          = "app.kubernetes.io/instance"
+	helmManagedBy             = "Helm"
+
+	// Argo CD tracks the objects of an application with a label or with an
+	// annotation of the form <application>:<group>/<kind>:<namespace>/<name>
+	argoCDInstanceLabel        = "argocd.argoproj.io/instance"
+	argoCDTrackingIDAnnotation = "argocd.argoproj.io/tracking-id"
+
+	// Flux labels the objects it applies with the name of the Kustomization
+	// or HelmRelease applying them
+	fluxKustomizationNameLabel = "kustomize.toolkit.fluxcd.io/name"
+	fluxHelmReleaseNameLabel   = "helm.toolkit.fluxcd.io/name"
+
+	// maxOwnerDepth bounds walks up the owner chain, in case of cycles
+	maxOwnerDepth = 16
+)
+
+// deployUnit is the Helm release and the GitOps application which deployed
+// a pod, either of which may be empty if unknown.
+type deployUnit struct {
+	helmRelease string
+	gitOpsApp   string
+}
+
+// ownerObjectKey identifies an object which may own pods, or own objects
+// owning pods, by namespace, kind and name.
+type ownerObjectKey struct {
+	namespace string
+	kind      string
+	name      string
+}
+
+// helmReleaseOf returns the Helm release of an object from its annotations
+// and labels, or an empty string if the object is not part of a release.
+func helmReleaseOf(obj *metav1.ObjectMeta) string {
+	if release := obj.Annotations[helmReleaseNameAnnotation]; release != "" {
+		return release
+	}
+	if obj.Labels[appManagedByLabel] == helmManagedBy || obj.Labels[helmChartLabel] != "" {
+		return obj.Labels[appInstanceLabel]
+	}
+	return ""
+}
+
+// gitOpsAppOf returns the Argo CD application or Flux Kustomization or
+// HelmRelease of an object from its annotations and labels, or an empty
+// string if the object is not deployed by either.
+func gitOpsAppOf(obj *metav1.ObjectMeta) string {
+	if app := obj.Labels[argoCDInstanceLabel]; app != "" {
+		return app
+	}
+	if trackingID := obj.Annotations[argoCDTrackingIDAnnotation]; trackingID != "" {
+		return strings.SplitN(trackingID, ":", 2)[0]
+	}
+	if app := obj.Labels[fluxKustomizationNameLabel]; app != "" {
+		return app
+	}
+	return obj.Labels[fluxHelmReleaseNameLabel]
+}
+
+// buildPodDeployUnits resolves the Helm release and GitOps application of
+// each pod in the cluster cache by walking up the pod's owner chain, e.g.
+// from the pod to its ReplicaSet and on to its Deployment. The pod and the
+// nearest owners take precedence, as release identity is often only set on
+// the controller, and not always on the pods.
+func buildPodDeployUnits(cache clustercache.ClusterCache, cluster string) map[podKey]deployUnit {
+	owners := map[ownerObjectKey]*metav1.ObjectMeta{}
+	addOwner := func(kind string, obj *metav1.ObjectMeta) {
+		owners[ownerObjectKey{namespace: obj.Namespace, kind: kind, name: obj.Name}] = obj
+	}
+	for _, rs := range cache.GetAllReplicaSets() {
+		addOwner("ReplicaSet", &rs.ObjectMeta)
+	}
+	for _, d := range cache.GetAllDeployments() {
+		addOwner("Deployment", &d.ObjectMeta)
+	}
+	for _, ss := range cache.GetAllStatefulSets() {
+		addOwner("StatefulSet", &ss.ObjectMeta)
+	}
+	for _, ds := range cache.GetAllDaemonSets() {
+		addOwner("DaemonSet", &ds.ObjectMeta)
+	}
+	for _, job := range cache.GetAllJobs() {
+		addOwner("Job", &job.ObjectMeta)
+	}
+	for _, rc := range cache.GetAllReplicationControllers() {
+		addOwner("ReplicationController", &rc.ObjectMeta)
+	}
+
+	podDeployUnits := map[podKey]deployUnit{}
+	for _, pod := range cache.GetAllPods() {
+		var unit deployUnit
+
+		obj := &pod.ObjectMeta
+		for depth := 0; obj != nil && depth < maxOwnerDepth; depth++ {
+			if unit.helmRelease == "" {
+				unit.helmRelease = helmReleaseOf(obj)
+			}
+			if unit.gitOpsApp == "" {
+				unit.gitOpsApp = gitOpsAppOf(obj)
+			}
+			if unit.helmRelease != "" && unit.gitOpsApp != "" {
+				break
+			}
+
+			owner := metav1.GetControllerOf(obj)
+			if owner == nil {
+				break
+			}
+			obj = owners[ownerObjectKey{namespace: obj.Namespace, kind: owner.Kind, name: owner.Name}]
+		}
+
+		if unit.helmRelease != "" || unit.gitOpsApp != "" {
+			podDeployUnits[newPodKey(cluster, pod.Namespace, pod.Name)] = unit
+		}
+	}
+
+	return podDeployUnits
+}
+
+// applyDeployUnits labels the allocations of pods in the cluster cache with
+// the Helm release and GitOps application which deployed them.
+func (cm *CostModel) applyDeployUnits(podMap map[podKey]*Pod) {
+	if cm == nil || cm.Cache == nil {
+		return
+	}
+
+	applyPodDeployUnits(podMap, buildPodDeployUnits(cm.Cache, cm.ClusterID))
+}
+
+func applyPodDeployUnits(podMap map[podKey]*Pod, podDeployUnits map[podKey]deployUnit) {
+	if len(podDeployUnits) == 0 {
+		return
+	}
+
+	for _, pod := range podMap {
+		for _, alloc := range pod.Allocations {
+			// Pods are suffixed with their UIDs when ingesting UIDs
+			name := strings.SplitN(alloc.Properties.Pod, " ", 2)[0]
+			unit, ok := podDeployUnits[newPodKey(alloc.Properties.Cluster, alloc.Properties.Namespace, name)]
+			if !ok {
+				continue
+			}
+
+			if alloc.Properties.Labels == nil {
+				alloc.Properties.Labels = map[string]string{}
+			}
+			if unit.helmRelease != "" {
+				alloc.Properties.Labels[kubecost.HelmReleaseLabel] = unit.helmRelease
+			}
+			if unit.gitOpsApp != "" {
+				alloc.Properties.Labels[kubecost.GitOpsAppLabel] = unit.gitOpsApp
+			}
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package costmodel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/clustercache"
+	"github.com/kubecost/opencost/pkg/kubecost"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fixtureClusterCache serves the objects of an owner graph, and panics on
+// any other use of the cluster cache.
+type fixtureClusterCache struct {
+	clustercache.ClusterCache
+
+	pods         []*v1.Pod
+	replicaSets  []*appsv1.ReplicaSet
+	deployments  []*appsv1.Deployment
+	statefulSets []*appsv1.StatefulSet
+	daemonSets   []*appsv1.DaemonSet
+	jobs         []*batchv1.Job
+}
+
+func (fcc *fixtureClusterCache) GetAllPods() []*v1.Pod                   { return fcc.pods }
+func (fcc *fixtureClusterCache) GetAllReplicaSets() []*appsv1.ReplicaSet { return fcc.replicaSets }
+func (fcc *fixtureClusterCache) GetAllDeployments() []*appsv1.Deployment { return fcc.deployments }
+func (fcc *fixtureClusterCache) GetAllStatefulSets() []*appsv1.StatefulSet {
+	return fcc.statefulSets
+}
+func (fcc *fixtureClusterCache) GetAllDaemonSets() []*appsv1.DaemonSet { return fcc.daemonSets }
+func (fcc *fixtureClusterCache) GetAllJobs() []*batchv1.Job            { return fcc.jobs }
+func (fcc *fixtureClusterCache) GetAllReplicationControllers() []*v1.ReplicationController {
+	return nil
+}
+
+func fixtureObjectMeta(namespace, name string, labels, annotations map[string]string, ownerKind, ownerName string) metav1.ObjectMeta {
+	meta := metav1.ObjectMeta{
+		Namespace:   namespace,
+		Name:        name,
+		Labels:      labels,
+		Annotations: annotations,
+	}
+	if ownerKind != "" {
+		controller := true
+		meta.OwnerReferences = []metav1.OwnerReference{
+			{Kind: ownerKind, Name: ownerName, Controller: &controller},
+		}
+	}
+	return meta
+}
+
+// newFixtureOwnerGraph builds an owner graph of pods and their controllers:
+//
+//	Deployment api (Helm release payments, Argo CD app payments-prod)
+//	└── ReplicaSet api-7d9f (Argo CD app payments-canary)
+//	    └── Pod api-7d9f-x2x4z
+//	StatefulSet db (Helm chart instance payments-db, Argo CD tracking ID)
+//	└── Pod db-0
+//	CronJob migrate (not cached)
+//	└── Job migrate-27800000 (Flux Kustomization infra)
+//	    └── Pod migrate-27800000-abcde (Helm chart instance migrations)
+//	DaemonSet agent (instance without Helm)
+//	└── Pod agent-q8v2l
+//	Pod standalone
+func newFixtureOwnerGraph() *fixtureClusterCache {
+	return &fixtureClusterCache{
+		deployments: []*appsv1.Deployment{
+			{ObjectMeta: fixtureObjectMeta("payments", "api",
+				map[string]string{argoCDInstanceLabel: "payments-prod"},
+				map[string]string{helmReleaseNameAnnotation: "payments"},
+				"", "")},
+		},
+		replicaSets: []*appsv1.ReplicaSet{
+			{ObjectMeta: fixtureObjectMeta("payments", "api-7d9f",
+				map[string]string{argoCDInstanceLabel: "payments-canary"},
+				nil,
+				"Deployment", "api")},
+		},
+		statefulSets: []*appsv1.StatefulSet{
+			{ObjectMeta: fixtureObjectMeta("payments", "db",
+				map[string]string{appManagedByLabel: helmManagedBy, appInstanceLabel: "payments-db"},
+				map[string]string{argoCDTrackingIDAnnotation: "payments-db-app:apps/StatefulSet:payments/db"},
+				"", "")},
+		},
+		jobs: []*batchv1.Job{
+			{ObjectMeta: fixtureObjectMeta("payments", "migrate-27800000",
+				map[string]string{fluxKustomizationNameLabel: "infra"},
+				nil,
+				"CronJob", "migrate")},
+		},
+		daemonSets: []*appsv1.DaemonSet{
+			{ObjectMeta: fixtureObjectMeta("kube-system", "agent",
+				map[string]string{appInstanceLabel: "agent"},
+				nil,
+				"", "")},
+		},
+		pods: []*v1.Pod{
+			{ObjectMeta: fixtureObjectMeta("payments", "api-7d9f-x2x4z", nil, nil, "ReplicaSet", "api-7d9f")},
+			{ObjectMeta: fixtureObjectMeta("payments", "db-0", nil, nil, "StatefulSet", "db")},
+			{ObjectMeta: fixtureObjectMeta("payments", "migrate-27800000-abcde",
+				map[string]string{helmChartLabel: "migrations-0.1.0", appInstanceLabel: "migrations"},
+				nil,
+				"Job", "migrate-27800000")},
+			{ObjectMeta: fixtureObjectMeta("kube-system", "agent-q8v2l", nil, nil, "DaemonSet", "agent")},
+			{ObjectMeta: fixtureObjectMeta("payments", "standalone", nil, nil, "", "")},
+		},
+	}
+}
+
+func TestBuildPodDeployUnits(t *testing.T) {
+	podDeployUnits := buildPodDeployUnits(newFixtureOwnerGraph(), "cluster1")
+
+	cases := []struct {
+		input    podKey
+		expected deployUnit
+	}{
+		// Release identity is found on the controller two owners up, and
+		// the nearest owner takes precedence
+		{
+			input:    newPodKey("cluster1", "payments", "api-7d9f-x2x4z"),
+			expected: deployUnit{helmRelease: "payments", gitOpsApp: "payments-canary"},
+		},
+		{
+			input:    newPodKey("cluster1", "payments", "db-0"),
+			expected: deployUnit{helmRelease: "payments-db", gitOpsApp: "payments-db-app"},
+		},
+		// The pod's own labels take precedence, and owners which are not
+		// cached end the walk without error
+		{
+			input:    newPodKey("cluster1", "payments", "migrate-27800000-abcde"),
+			expected: deployUnit{helmRelease: "migrations", gitOpsApp: "infra"},
+		},
+	}
+
+	for _, test := range cases {
+		result, ok := podDeployUnits[test.input]
+		if !ok || result != test.expected {
+			t.Errorf("Input: %s, Expected: %+v, Actual: %+v", test.input, test.expected, result)
+		}
+	}
+
+	// Instances not managed by Helm are not releases, and pods without
+	// release identity are left out
+	for _, key := range []podKey{
+		newPodKey("cluster1", "kube-system", "agent-q8v2l"),
+		newPodKey("cluster1", "payments", "standalone"),
+	} {
+		if result, ok := podDeployUnits[key]; ok {
+			t.Errorf("Input: %s, Expected: none, Actual: %+v", key, result)
+		}
+	}
+}
+
+func TestApplyPodDeployUnits(t *testing.T) {
+	start := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	podMap := map[podKey]*Pod{}
+	for _, name := range []string{"api-7d9f-x2x4z", "standalone"} {
+		key := newPodKey("cluster1", "payments", name)
+		podMap[key] = &Pod{
+			Key: key,
+			Allocations: map[string]*kubecost.Allocation{
+				"app": {
+					Name: "cluster1/payments/" + name + "/app",
+					Properties: &kubecost.AllocationProperties{
+						Cluster:   "cluster1",
+						Namespace: "payments",
+						// Pods are suffixed with their UIDs when ingesting UIDs
+						Pod:       name + " 9a6f8c62-0b1e-4c1b-a5a1-1c2d3e4f5a6b",
+						Container: "app",
+					},
+					Window: kubecost.NewWindow(&start, &end),
+					Start:  start,
+					End:    end,
+				},
+			},
+		}
+	}
+
+	applyPodDeployUnits(podMap, buildPodDeployUnits(newFixtureOwnerGraph(), "cluster1"))
+
+	api := podMap[newPodKey("cluster1", "payments", "api-7d9f-x2x4z")].Allocations["app"]
+	if api.Properties.Labels[kubecost.HelmReleaseLabel] != "payments" || api.Properties.Labels[kubecost.GitOpsAppLabel] != "payments-canary" {
+		t.Errorf("api: expected Helm release payments and GitOps app payments-canary, actual %v", api.Properties.Labels)
+	}
+
+	standalone := podMap[newPodKey("cluster1", "payments", "standalone")].Allocations["app"]
+	if len(standalone.Properties.Labels) != 0 {
+		t.Errorf("standalone: expected no labels, actual %v", standalone.Properties.Labels)
+	}
+
+	as := kubecost.NewAllocationSet(start, end)
+	as.Set(api)
+	as.Set(standalone)
+	if err := as.AggregateBy([]string{kubecost.AllocationHelmReleaseProp}, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, name := range []string{"payments", kubecost.UnallocatedSuffix} {
+		if as.Get(name) == nil {
+			t.Errorf("helmRelease: expected allocation %s among %d allocations", name, as.Length())
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package costmodel
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/kubecost/opencost/pkg/env"
+	"github.com/kubecost/opencost/pkg/kubecost"
+	"github.com/kubecost/opencost/pkg/log"
+	"github.com/kubecost/opencost/pkg/prom"
+	"github.com/kubecost/opencost/pkg/util/httputil"
+	"github.com/kubecost/opencost/pkg/util/timeutil"
+)
+
+const (
+	queryFmtJobCronJobOwners   = `max(max_over_time(kube_job_owner{owner_kind="CronJob"}[%s])) by (job_name, owner_name, namespace, %s)`
+	queryFmtJobStatusFailed    = `max(max_over_time(kube_job_status_failed[%s])) by (job_name, namespace, %s)`
+	queryFmtJobStatusSucceeded = `max(max_over_time(kube_job_status_succeeded[%s])) by (job_name, namespace, %s)`
+)
+
+// Job run statuses
+const (
+	JobRunSucceeded = "Succeeded"
+	JobRunFailed    = "Failed"
+	JobRunRunning   = "Running"
+	JobRunUnknown   = "Unknown"
+)
+
+// JobRun is the cost of a single run of a Job, i.e. of all the pods the Job
+// created, over the run's whole lifetime rather than a single window.
+type JobRun struct {
+	Cluster              string    `json:"cluster"`
+	Namespace            string    `json:"namespace"`
+	Job                  string    `json:"job"`
+	CronJob              string    `json:"cronJob,omitempty"`
+	Status               string    `json:"status"`
+	Start                time.Time `json:"start"`
+	End                  time.Time `json:"end"`
+	Minutes              float64   `json:"minutes"`
+	Pods                 int       `json:"pods"`
+	CPUCoreHours         float64   `json:"cpuCoreHours"`
+	CPUCoreUsageAverage  float64   `json:"cpuCoreUsageAverage"`
+	RAMByteHours         float64   `json:"ramByteHours"`
+	RAMBytesUsageAverage float64   `json:"ramByteUsageAverage"`
+	GPUHours             float64   `json:"gpuHours"`
+	CPUCost              float64   `json:"cpuCost"`
+	RAMCost              float64   `json:"ramCost"`
+	GPUCost              float64   `json:"gpuCost"`
+	PVCost               float64   `json:"pvCost"`
+	NetworkCost          float64   `json:"networkCost"`
+	LoadBalancerCost     float64   `json:"loadBalancerCost"`
+	TotalCost            float64   `json:"totalCost"`
+
+	pods map[string]bool
+}
+
+// JobRunHistory is the runs of a CronJob, or the single run of a Job which
+// no CronJob owns, in order of their start, with trends across the runs.
+// CostTrend and MinutesTrend are the least squares slopes of the cost and
+// duration of the runs, i.e. how much each run costs and takes longer than
+// the previous one.
+type JobRunHistory struct {
+	Cluster        string    `json:"cluster"`
+	Namespace      string    `json:"namespace"`
+	Name           string    `json:"name"`
+	Kind           string    `json:"kind"`
+	Runs           []*JobRun `json:"runs"`
+	FailedRuns     int       `json:"failedRuns"`
+	TotalCost      float64   `json:"totalCost"`
+	AverageCost    float64   `json:"averageCost"`
+	AverageMinutes float64   `json:"averageMinutes"`
+	CostTrend      float64   `json:"costTrend"`
+	MinutesTrend   float64   `json:"minutesTrend"`
+}
+
+// ComputeJobRuns computes the cost of each run of each Job with pods running
+// between start and end, grouped into the histories of their CronJobs. The
+// allocations are computed over the whole window, so that runs spanning the
+// boundaries of shorter windows are not split.
+func (cm *CostModel) ComputeJobRuns(start, end time.Time, resolution time.Duration) ([]*JobRunHistory, error) {
+	allocSet, err := cm.ComputeAllocation(start, end, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	durStr := timeutil.DurationString(end.Sub(start))
+	if durStr == "" {
+		return nil, fmt.Errorf("illegal duration value for %s", kubecost.NewClosedWindow(start, end))
+	}
+
+	ctx := cm.promContext(prom.AllocationContextName)
+
+	resChJobPods := ctx.QueryAtTime(fmt.Sprintf(queryFmtJobLabels, durStr, cm.ClusterLabel), end)
+	resChJobCronJobOwners := ctx.QueryAtTime(fmt.Sprintf(queryFmtJobCronJobOwners, durStr, cm.ClusterLabel), end)
+	resChJobStatusFailed := ctx.QueryAtTime(fmt.Sprintf(queryFmtJobStatusFailed, durStr, cm.ClusterLabel), end)
+	resChJobStatusSucceeded := ctx.QueryAtTime(fmt.Sprintf(queryFmtJobStatusSucceeded, durStr, cm.ClusterLabel), end)
+
+	resJobPods, _ := resChJobPods.Await()
+	resJobCronJobOwners, _ := resChJobCronJobOwners.Await()
+	resJobStatusFailed, _ := resChJobStatusFailed.Await()
+	resJobStatusSucceeded, _ := resChJobStatusSucceeded.Await()
+
+	if ctx.HasErrors() {
+		for _, err := range ctx.Errors() {
+			log.Errorf("CostModel.ComputeJobRuns: %s", err)
+		}
+		return nil, ctx.ErrorCollection()
+	}
+
+	podJobs := resToPodJobs(resJobPods, cm.ClusterLabel, cm.ClusterID)
+	jobCronJobs := resToJobValues(resJobCronJobOwners, "owner_name", cm.ClusterLabel, cm.ClusterID)
+	jobFailed := resToJobValues(resJobStatusFailed, "", cm.ClusterLabel, cm.ClusterID)
+	jobSucceeded := resToJobValues(resJobStatusSucceeded, "", cm.ClusterLabel, cm.ClusterID)
+
+	return buildJobRunHistories(allocSet, podJobs, jobCronJobs, jobFailed, jobSucceeded, end), nil
+}
+
+// resToPodJobs maps each pod to the key of the Job which owns it. Results
+// without the given cluster label belong to the default cluster.
+func resToPodJobs(resJobPods []*prom.QueryResult, clusterLabel, defaultClusterID string) map[podKey]controllerKey {
+	podJobs := map[podKey]controllerKey{}
+
+	for _, res := range resJobPods {
+		jobKey, err := resultJobKey(res, clusterLabel, defaultClusterID, "namespace", "owner_name")
+		if err != nil {
+			continue
+		}
+
+		pod, err := res.GetString("pod")
+		if err != nil {
+			log.DedupedWarningf(10, "CostModel.ComputeJobRuns: Job owner result without pod: %s", jobKey)
+			continue
+		}
+
+		podJobs[newPodKey(jobKey.Cluster, jobKey.Namespace, pod)] = jobKey
+	}
+
+	return podJobs
+}
+
+// resToJobValues maps each Job to the given label of its result, or to the
+// result's value if label is empty. Results without the given cluster label
+// belong to the default cluster.
+func resToJobValues(res []*prom.QueryResult, label, clusterLabel, defaultClusterID string) map[controllerKey]string {
+	values := map[controllerKey]string{}
+
+	for _, r := range res {
+		jobKey, err := resultJobKey(r, clusterLabel, defaultClusterID, "namespace", "job_name")
+		if err != nil {
+			continue
+		}
+
+		if label == "" {
+			if len(r.Values) > 0 {
+				values[jobKey] = fmt.Sprintf("%f", r.Values[0].Value)
+			}
+			continue
+		}
+
+		value, err := r.GetString(label)
+		if err != nil {
+			continue
+		}
+		values[jobKey] = value
+	}
+
+	return values
+}
+
+// buildJobRunHistories groups the allocations of Job pods into runs, and
+// the runs into histories by the CronJob owning each Job. CronJobs are taken
+// from the Job owners if known, otherwise from the names of the Jobs.
+func buildJobRunHistories(allocSet *kubecost.AllocationSet, podJobs map[podKey]controllerKey, jobCronJobs, jobFailed, jobSucceeded map[controllerKey]string, end time.Time) []*JobRunHistory {
+	runs := map[controllerKey]*JobRun{}
+
+	allocSet.Each(func(name string, alloc *kubecost.Allocation) {
+		if alloc.Properties == nil {
+			return
+		}
+		props := alloc.Properties
+
+		// Pods are suffixed with their UIDs when ingesting UIDs
+		pod := strings.SplitN(props.Pod, " ", 2)[0]
+		jobKey, ok := podJobs[newPodKey(props.Cluster, props.Namespace, pod)]
+		if !ok {
+			return
+		}
+
+		run, ok := runs[jobKey]
+		if !ok {
+			run = &JobRun{
+				Cluster:   jobKey.Cluster,
+				Namespace: jobKey.Namespace,
+				Job:       jobKey.Controller,
+				Start:     alloc.Start,
+				End:       alloc.End,
+				pods:      map[string]bool{},
+			}
+			runs[jobKey] = run
+		}
+
+		if alloc.Start.Before(run.Start) {
+			run.Start = alloc.Start
+		}
+		if alloc.End.After(run.End) {
+			run.End = alloc.End
+		}
+		run.pods[props.Pod] = true
+		run.CPUCoreHours += alloc.CPUCoreHours
+		run.CPUCoreUsageAverage += alloc.CPUCoreUsageAverage * alloc.Minutes()
+		run.RAMByteHours += alloc.RAMByteHours
+		run.RAMBytesUsageAverage += alloc.RAMBytesUsageAverage * alloc.Minutes()
+		run.GPUHours += alloc.GPUHours
+		run.CPUCost += alloc.CPUTotalCost()
+		run.RAMCost += alloc.RAMTotalCost()
+		run.GPUCost += alloc.GPUTotalCost()
+		run.PVCost += alloc.PVTotalCost()
+		run.NetworkCost += alloc.NetworkTotalCost()
+		run.LoadBalancerCost += alloc.LBTotalCost()
+		run.TotalCost += alloc.TotalCost()
+	})
+
+	histories := map[controllerKey]*JobRunHistory{}
+	for jobKey, run := range runs {
+		run.Pods = len(run.pods)
+		run.Minutes = run.End.Sub(run.Start).Minutes()
+		// Usage is summed over pods weighted by their minutes, so average it
+		// over the run to get the usage of the run's pods running together
+		if run.Minutes > 0 {
+			run.CPUCoreUsageAverage /= run.Minutes
+			run.RAMBytesUsageAverage /= run.Minutes
+		}
+
+		run.Status = JobRunUnknown
+		switch {
+		case parseJobValue(jobSucceeded[jobKey]) > 0:
+			run.Status = JobRunSucceeded
+		// A Job with failed pods which is still running is retrying them, so it
+		// has not failed, unless it stopped before the end of the window.
+		case !run.End.Before(end):
+			run.Status = JobRunRunning
+		case parseJobValue(jobFailed[jobKey]) > 0:
+			run.Status = JobRunFailed
+		}
+
+		historyKey := jobKey
+		historyKey.ControllerKind = "job"
+		if cronJob, ok := jobCronJobs[jobKey]; ok && cronJob != "" {
+			run.CronJob = cronJob
+		} else if match := isCron.FindStringSubmatch(jobKey.Controller); match != nil {
+			run.CronJob = match[1]
+		}
+		if run.CronJob != "" {
+			historyKey.ControllerKind = "cronjob"
+			historyKey.Controller = run.CronJob
+		}
+
+		history, ok := histories[historyKey]
+		if !ok {
+			history = &JobRunHistory{
+				Cluster:   historyKey.Cluster,
+				Namespace: historyKey.Namespace,
+				Name:      historyKey.Controller,
+				Kind:      "Job",
+			}
+			if historyKey.ControllerKind == "cronjob" {
+				history.Kind = "CronJob"
+			}
+			histories[historyKey] = history
+		}
+		history.Runs = append(history.Runs, run)
+	}
+
+	result := make([]*JobRunHistory, 0, len(histories))
+	for _, history := range histories {
+		sort.Slice(history.Runs, func(i, j int) bool {
+			if history.Runs[i].Start.Equal(history.Runs[j].Start) {
+				return history.Runs[i].Job < history.Runs[j].Job
+			}
+			return history.Runs[i].Start.Before(history.Runs[j].Start)
+		})
+
+		costs := make([]float64, len(history.Runs))
+		minutes := make([]float64, len(history.Runs))
+		for i, run := range history.Runs {
+			if run.Status == JobRunFailed {
+				history.FailedRuns++
+			}
+			history.TotalCost += run.TotalCost
+			history.AverageMinutes += run.Minutes
+			costs[i] = run.TotalCost
+			minutes[i] = run.Minutes
+		}
+		history.AverageCost = history.TotalCost / float64(len(history.Runs))
+		history.AverageMinutes /= float64(len(history.Runs))
+		history.CostTrend = leastSquaresSlope(costs)
+		history.MinutesTrend = leastSquaresSlope(minutes)
+
+		result = append(result, history)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Cluster != result[j].Cluster {
+			return result[i].Cluster < result[j].Cluster
+		}
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+func parseJobValue(s string) float64 {
+	var f float64
+	fmt.Sscanf(s, "%f", &f)
+	return f
+}
+
+// leastSquaresSlope returns the slope of the least squares line through the
+// values, indexed by their position. It is zero for fewer than two values.
+func leastSquaresSlope(values []float64) float64 {
+	n := float64(len(values))
+	if n < 2 {
+		return 0
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i, y := range values {
+		x := float64(i)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	return (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+}
+
+// ComputeJobRunsHandler returns the cost history of the runs of each Job and
+// CronJob over the given window, optionally filtered by namespace.
+func (a *Accesses) ComputeJobRunsHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	qp := httputil.NewQueryParams(r.URL.Query())
+
+	window, err := kubecost.ParseWindowWithOffset(qp.Get("window", ""), env.GetParsedUTCOffset())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid 'window' parameter: %s", err), http.StatusBadRequest)
+		return
+	}
+	if window.IsOpen() {
+		http.Error(w, fmt.Sprintf("Invalid 'window' parameter: %s is open", window), http.StatusBadRequest)
+		return
+	}
+
+	resolution := qp.GetDuration("resolution", env.GetETLResolution())
+	namespace := qp.Get("namespace", "")
+
+	histories, err := a.Model.ComputeJobRuns(*window.Start(), *window.End(), resolution)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	if namespace != "" {
+		filtered := []*JobRunHistory{}
+		for _, history := range histories {
+			if history.Namespace == namespace {
+				filtered = append(filtered, history)
+			}
+		}
+		histories = filtered
+	}
+
+	w.Write(WrapData(histories, nil))
+}
@@ -0,0 +1,162 @@
+package costmodel
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/kubecost"
+)
+
+func TestBuildJobRunHistories(t *testing.T) {
+	windowStart := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	windowEnd := windowStart.Add(72 * time.Hour)
+
+	allocs := []*kubecost.Allocation{}
+	podJobs := map[podKey]controllerKey{}
+	newPod := func(job, pod, container string, start, end time.Time, cpuCost, ramCost float64) {
+		allocs = append(allocs, &kubecost.Allocation{
+			Name: "cluster1/batch/" + pod + "/" + container,
+			Properties: &kubecost.AllocationProperties{
+				Cluster:   "cluster1",
+				Namespace: "batch",
+				Pod:       pod,
+				Container: container,
+			},
+			Window:              kubecost.NewWindow(&windowStart, &windowEnd),
+			Start:               start,
+			End:                 end,
+			CPUCoreHours:        end.Sub(start).Hours(),
+			CPUCoreUsageAverage: 0.5,
+			CPUCost:             cpuCost,
+			RAMCost:             ramCost,
+		})
+		podJobs[newPodKey("cluster1", "batch", pod)] = controllerKey{Cluster: "cluster1", Namespace: "batch", ControllerKind: "job", Controller: job}
+	}
+	at := func(hours float64) time.Time {
+		return windowStart.Add(time.Duration(hours * float64(time.Hour)))
+	}
+
+	// A nightly CronJob whose runs start at 23:00 and span midnight, getting
+	// longer and more expensive each night. The second run fails after a retry.
+	newPod("nightly-etl-27746580", "nightly-etl-27746580-a", "etl", at(-1), at(1), 1, 0)
+	newPod("nightly-etl-27748020", "nightly-etl-27748020-a", "etl", at(23), at(24), 0.5, 0)
+	newPod("nightly-etl-27748020", "nightly-etl-27748020-b", "etl", at(24), at(25.5), 1, 0.5)
+	newPod("nightly-etl-27749460", "nightly-etl-27749460-a", "etl", at(47), at(50), 3, 0)
+
+	// A Job created from the report CronJob by hand, whose name does not carry a timestamp
+	newPod("report-manual", "report-manual-a", "report", at(10), at(11), 0.2, 0.1)
+
+	// A Job still running at the end of the window
+	newPod("backfill", "backfill-a", "backfill", at(60), at(72), 4, 1)
+
+	// A Job retrying a failed pod at the end of the window
+	newPod("retrying", "retrying-a", "retrying", at(62), at(63), 0.5, 0)
+	newPod("retrying", "retrying-b", "retrying", at(63), at(72), 2, 0)
+
+	// Allocations of pods not owned by a Job are ignored
+	allocs = append(allocs, &kubecost.Allocation{
+		Name:       "cluster1/batch/web/web",
+		Properties: &kubecost.AllocationProperties{Cluster: "cluster1", Namespace: "batch", Pod: "web", Container: "web"},
+		Start:      windowStart,
+		End:        windowEnd,
+		CPUCost:    10,
+	})
+
+	jobKey := func(job string) controllerKey {
+		return controllerKey{Cluster: "cluster1", Namespace: "batch", ControllerKind: "job", Controller: job}
+	}
+	jobCronJobs := map[controllerKey]string{
+		jobKey("nightly-etl-27748020"): "nightly-etl",
+		jobKey("report-manual"):        "report",
+	}
+	jobFailed := map[controllerKey]string{
+		jobKey("nightly-etl-27746580"): "0.000000",
+		jobKey("nightly-etl-27748020"): "1.000000",
+		jobKey("retrying"):             "1.000000",
+	}
+	jobSucceeded := map[controllerKey]string{
+		jobKey("nightly-etl-27746580"): "1.000000",
+		jobKey("nightly-etl-27749460"): "1.000000",
+		jobKey("report-manual"):        "1.000000",
+	}
+
+	histories := buildJobRunHistories(kubecost.NewAllocationSet(windowStart, windowEnd, allocs...), podJobs, jobCronJobs, jobFailed, jobSucceeded, windowEnd)
+	if len(histories) != 4 {
+		t.Fatalf("expected 4 histories, actual %d", len(histories))
+	}
+
+	backfill, nightly, report, retrying := histories[0], histories[1], histories[2], histories[3]
+
+	if backfill.Name != "backfill" || backfill.Kind != "Job" || len(backfill.Runs) != 1 {
+		t.Fatalf("expected a history of the backfill Job with 1 run, actual %s %s with %d runs", backfill.Kind, backfill.Name, len(backfill.Runs))
+	}
+	if backfill.Runs[0].Status != JobRunRunning {
+		t.Errorf("backfill: expected status %s, actual %s", JobRunRunning, backfill.Runs[0].Status)
+	}
+
+	if nightly.Name != "nightly-etl" || nightly.Kind != "CronJob" || len(nightly.Runs) != 3 {
+		t.Fatalf("expected a history of the nightly-etl CronJob with 3 runs, actual %s %s with %d runs", nightly.Kind, nightly.Name, len(nightly.Runs))
+	}
+	expectedRuns := []struct {
+		job     string
+		status  string
+		minutes float64
+		pods    int
+		cost    float64
+	}{
+		{job: "nightly-etl-27746580", status: JobRunSucceeded, minutes: 120, pods: 1, cost: 1},
+		{job: "nightly-etl-27748020", status: JobRunFailed, minutes: 150, pods: 2, cost: 2},
+		{job: "nightly-etl-27749460", status: JobRunSucceeded, minutes: 180, pods: 1, cost: 3},
+	}
+	for i, expected := range expectedRuns {
+		run := nightly.Runs[i]
+		if run.Job != expected.job || run.Status != expected.status || run.Pods != expected.pods || run.CronJob != "nightly-etl" {
+			t.Errorf("run %d: expected %s %s with %d pods, actual %s %s with %d pods", i, expected.job, expected.status, expected.pods, run.Job, run.Status, run.Pods)
+		}
+		if math.Abs(run.Minutes-expected.minutes) > 1e-9 || math.Abs(run.TotalCost-expected.cost) > 1e-9 {
+			t.Errorf("run %d: expected %f minutes costing %f, actual %f minutes costing %f", i, expected.minutes, expected.cost, run.Minutes, run.TotalCost)
+		}
+	}
+	// The pods of the failed run ran one after the other, so their usage averages to that of one pod
+	if math.Abs(nightly.Runs[1].CPUCoreUsageAverage-0.5) > 1e-9 {
+		t.Errorf("expected the failed run to average 0.5 cores, actual %f", nightly.Runs[1].CPUCoreUsageAverage)
+	}
+	if nightly.FailedRuns != 1 || math.Abs(nightly.AverageCost-2) > 1e-9 || math.Abs(nightly.AverageMinutes-150) > 1e-9 {
+		t.Errorf("nightly-etl: expected 1 failed run averaging 150 minutes costing 2, actual %d averaging %f costing %f", nightly.FailedRuns, nightly.AverageMinutes, nightly.AverageCost)
+	}
+	if math.Abs(nightly.CostTrend-1) > 1e-9 || math.Abs(nightly.MinutesTrend-30) > 1e-9 {
+		t.Errorf("nightly-etl: expected runs to cost 1 more and take 30 minutes longer each night, actual %f and %f", nightly.CostTrend, nightly.MinutesTrend)
+	}
+
+	if report.Name != "report" || report.Kind != "CronJob" || len(report.Runs) != 1 || report.Runs[0].Job != "report-manual" {
+		t.Errorf("expected the report-manual Job in the history of the report CronJob, actual %s %s", report.Kind, report.Name)
+	}
+
+	if retrying.Name != "retrying" || len(retrying.Runs) != 1 || retrying.Runs[0].Pods != 2 {
+		t.Fatalf("expected a history of the retrying Job with 1 run of 2 pods, actual %s with %d runs", retrying.Name, len(retrying.Runs))
+	}
+	if retrying.Runs[0].Status != JobRunRunning || retrying.FailedRuns != 0 {
+		t.Errorf("retrying: expected status %s and no failed runs, actual %s and %d failed runs", JobRunRunning, retrying.Runs[0].Status, retrying.FailedRuns)
+	}
+}
+
+func TestLeastSquaresSlope(t *testing.T) {
+	cases := []struct {
+		input    []float64
+		expected float64
+	}{
+		{input: nil, expected: 0},
+		{input: []float64{5}, expected: 0},
+		{input: []float64{1, 2, 3}, expected: 1},
+		{input: []float64{3, 3, 3}, expected: 0},
+		{input: []float64{4, 1, 4, 1}, expected: -0.6},
+	}
+
+	for _, test := range cases {
+		result := leastSquaresSlope(test.input)
+		if math.Abs(result-test.expected) > 1e-9 {
+			t.Errorf("Input: %v, Expected: %f, Actual: %f", test.input, test.expected, result)
+		}
+	}
+}
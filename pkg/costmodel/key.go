@@ -3,7 +3,6 @@ package costmodel
 import (
 	"fmt"
 
-	"github.com/kubecost/opencost/pkg/env"
 	"github.com/kubecost/opencost/pkg/prom"
 )
 
@@ -32,13 +31,13 @@ func newContainerKey(cluster, namespace, pod, container string) containerKey {
 // passing "cluster_id" for clusterLabel will use the value of the label
 // "cluster_id" as the containerKey's Cluster field. If a given field does not
 // exist on the result, an error is returned. (The only exception to that is
-// clusterLabel, which we expect may not exist, but has a default value.)
-func resultContainerKey(res *prom.QueryResult, clusterLabel, namespaceLabel, podLabel, containerLabel string) (containerKey, error) {
+// clusterLabel, which we expect may not exist, and defaults to defaultClusterID.)
+func resultContainerKey(res *prom.QueryResult, clusterLabel, defaultClusterID, namespaceLabel, podLabel, containerLabel string) (containerKey, error) {
 	key := containerKey{}
 
 	cluster, err := res.GetString(clusterLabel)
 	if err != nil {
-		cluster = env.GetClusterID()
+		cluster = defaultClusterID
 	}
 	key.Cluster = cluster
 
@@ -87,13 +86,13 @@ func newPodKey(cluster, namespace, pod string) podKey {
 // "cluster_id" for clusterLabel will use the value of the label "cluster_id"
 // as the podKey's Cluster field. If a given field does not exist on the
 // result, an error is returned. (The only exception to that is clusterLabel,
-// which we expect may not exist, but has a default value.)
-func resultPodKey(res *prom.QueryResult, clusterLabel, namespaceLabel string) (podKey, error) {
+// which we expect may not exist, and defaults to defaultClusterID.)
+func resultPodKey(res *prom.QueryResult, clusterLabel, defaultClusterID, namespaceLabel string) (podKey, error) {
 	key := podKey{}
 
 	cluster, err := res.GetString(clusterLabel)
 	if err != nil {
-		cluster = env.GetClusterID()
+		cluster = defaultClusterID
 	}
 	key.Cluster = cluster
 
@@ -136,13 +135,13 @@ func newNamespaceKey(cluster, namespace string) namespaceKey {
 // passing "cluster_id" for clusterLabel will use the value of the label
 // "cluster_id" as the namespaceKey's Cluster field. If a given field does not
 // exist on the result, an error is returned. (The only exception to that is
-// clusterLabel, which we expect may not exist, but has a default value.)
-func resultNamespaceKey(res *prom.QueryResult, clusterLabel, namespaceLabel string) (namespaceKey, error) {
+// clusterLabel, which we expect may not exist, and defaults to defaultClusterID.)
+func resultNamespaceKey(res *prom.QueryResult, clusterLabel, defaultClusterID, namespaceLabel string) (namespaceKey, error) {
 	key := namespaceKey{}
 
 	cluster, err := res.GetString(clusterLabel)
 	if err != nil {
-		cluster = env.GetClusterID()
+		cluster = defaultClusterID
 	}
 	key.Cluster = cluster
 
@@ -180,13 +179,13 @@ func newControllerKey(cluster, namespace, controllerKind, controller string) con
 // passing "cluster_id" for clusterLabel will use the value of the label
 // "cluster_id" as the controllerKey's Cluster field. If a given field does not
 // exist on the result, an error is returned. (The only exception to that is
-// clusterLabel, which we expect may not exist, but has a default value.)
-func resultControllerKey(controllerKind string, res *prom.QueryResult, clusterLabel, namespaceLabel, controllerLabel string) (controllerKey, error) {
+// clusterLabel, which we expect may not exist, and defaults to defaultClusterID.)
+func resultControllerKey(controllerKind string, res *prom.QueryResult, clusterLabel, defaultClusterID, namespaceLabel, controllerLabel string) (controllerKey, error) {
 	key := controllerKey{}
 
 	cluster, err := res.GetString(clusterLabel)
 	if err != nil {
-		cluster = env.GetClusterID()
+		cluster = defaultClusterID
 	}
 	key.Cluster = cluster
 
@@ -209,32 +208,32 @@ func resultControllerKey(controllerKind string, res *prom.QueryResult, clusterLa
 
 // resultDeploymentKey creates a controllerKey for a Deployment.
 // (See resultControllerKey for more.)
-func resultDeploymentKey(res *prom.QueryResult, clusterLabel, namespaceLabel, controllerLabel string) (controllerKey, error) {
-	return resultControllerKey("deployment", res, clusterLabel, namespaceLabel, controllerLabel)
+func resultDeploymentKey(res *prom.QueryResult, clusterLabel, defaultClusterID, namespaceLabel, controllerLabel string) (controllerKey, error) {
+	return resultControllerKey("deployment", res, clusterLabel, defaultClusterID, namespaceLabel, controllerLabel)
 }
 
 // resultStatefulSetKey creates a controllerKey for a StatefulSet.
 // (See resultControllerKey for more.)
-func resultStatefulSetKey(res *prom.QueryResult, clusterLabel, namespaceLabel, controllerLabel string) (controllerKey, error) {
-	return resultControllerKey("statefulset", res, clusterLabel, namespaceLabel, controllerLabel)
+func resultStatefulSetKey(res *prom.QueryResult, clusterLabel, defaultClusterID, namespaceLabel, controllerLabel string) (controllerKey, error) {
+	return resultControllerKey("statefulset", res, clusterLabel, defaultClusterID, namespaceLabel, controllerLabel)
 }
 
 // resultDaemonSetKey creates a controllerKey for a DaemonSet.
 // (See resultControllerKey for more.)
-func resultDaemonSetKey(res *prom.QueryResult, clusterLabel, namespaceLabel, controllerLabel string) (controllerKey, error) {
-	return resultControllerKey("daemonset", res, clusterLabel, namespaceLabel, controllerLabel)
+func resultDaemonSetKey(res *prom.QueryResult, clusterLabel, defaultClusterID, namespaceLabel, controllerLabel string) (controllerKey, error) {
+	return resultControllerKey("daemonset", res, clusterLabel, defaultClusterID, namespaceLabel, controllerLabel)
 }
 
 // resultJobKey creates a controllerKey for a Job.
 // (See resultControllerKey for more.)
-func resultJobKey(res *prom.QueryResult, clusterLabel, namespaceLabel, controllerLabel string) (controllerKey, error) {
-	return resultControllerKey("job", res, clusterLabel, namespaceLabel, controllerLabel)
+func resultJobKey(res *prom.QueryResult, clusterLabel, defaultClusterID, namespaceLabel, controllerLabel string) (controllerKey, error) {
+	return resultControllerKey("job", res, clusterLabel, defaultClusterID, namespaceLabel, controllerLabel)
 }
 
 // resultReplicaSetKey creates a controllerKey for a Job.
 // (See resultControllerKey for more.)
-func resultReplicaSetKey(res *prom.QueryResult, clusterLabel, namespaceLabel, controllerLabel string) (controllerKey, error) {
-	return resultControllerKey("replicaset", res, clusterLabel, namespaceLabel, controllerLabel)
+func resultReplicaSetKey(res *prom.QueryResult, clusterLabel, defaultClusterID, namespaceLabel, controllerLabel string) (controllerKey, error) {
+	return resultControllerKey("replicaset", res, clusterLabel, defaultClusterID, namespaceLabel, controllerLabel)
 }
 
 type serviceKey struct {
@@ -260,13 +259,13 @@ func newServiceKey(cluster, namespace, service string) serviceKey {
 // passing "cluster_id" for clusterLabel will use the value of the label
 // "cluster_id" as the serviceKey's Cluster field. If a given field does not
 // exist on the result, an error is returned. (The only exception to that is
-// clusterLabel, which we expect may not exist, but has a default value.)
-func resultServiceKey(res *prom.QueryResult, clusterLabel, namespaceLabel, serviceLabel string) (serviceKey, error) {
+// clusterLabel, which we expect may not exist, and defaults to defaultClusterID.)
+func resultServiceKey(res *prom.QueryResult, clusterLabel, defaultClusterID, namespaceLabel, serviceLabel string) (serviceKey, error) {
 	key := serviceKey{}
 
 	cluster, err := res.GetString(clusterLabel)
 	if err != nil {
-		cluster = env.GetClusterID()
+		cluster = defaultClusterID
 	}
 	key.Cluster = cluster
 
@@ -306,13 +305,13 @@ func newNodeKey(cluster, node string) nodeKey {
 // passing "cluster_id" for clusterLabel will use the value of the label
 // "cluster_id" as the nodeKey's Cluster field. If a given field does not
 // exist on the result, an error is returned. (The only exception to that is
-// clusterLabel, which we expect may not exist, but has a default value.)
-func resultNodeKey(res *prom.QueryResult, clusterLabel, nodeLabel string) (nodeKey, error) {
+// clusterLabel, which we expect may not exist, and defaults to defaultClusterID.)
+func resultNodeKey(res *prom.QueryResult, clusterLabel, defaultClusterID, nodeLabel string) (nodeKey, error) {
 	key := nodeKey{}
 
 	cluster, err := res.GetString(clusterLabel)
 	if err != nil {
-		cluster = env.GetClusterID()
+		cluster = defaultClusterID
 	}
 	key.Cluster = cluster
 
@@ -348,13 +347,13 @@ func newPVCKey(cluster, namespace, persistentVolumeClaim string) pvcKey {
 // passing "cluster_id" for clusterLabel will use the value of the label
 // "cluster_id" as the pvcKey's Cluster field. If a given field does not
 // exist on the result, an error is returned. (The only exception to that is
-// clusterLabel, which we expect may not exist, but has a default value.)
-func resultPVCKey(res *prom.QueryResult, clusterLabel, namespaceLabel, pvcLabel string) (pvcKey, error) {
+// clusterLabel, which we expect may not exist, and defaults to defaultClusterID.)
+func resultPVCKey(res *prom.QueryResult, clusterLabel, defaultClusterID, namespaceLabel, pvcLabel string) (pvcKey, error) {
 	key := pvcKey{}
 
 	cluster, err := res.GetString(clusterLabel)
 	if err != nil {
-		cluster = env.GetClusterID()
+		cluster = defaultClusterID
 	}
 	key.Cluster = cluster
 
@@ -394,13 +393,13 @@ func newPVKey(cluster, persistentVolume string) pvKey {
 // passing "cluster_id" for clusterLabel will use the value of the label
 // "cluster_id" as the pvKey's Cluster field. If a given field does not
 // exist on the result, an error is returned. (The only exception to that is
-// clusterLabel, which we expect may not exist, but has a default value.)
-func resultPVKey(res *prom.QueryResult, clusterLabel, persistentVolumeLabel string) (pvKey, error) {
+// clusterLabel, which we expect may not exist, and defaults to defaultClusterID.)
+func resultPVKey(res *prom.QueryResult, clusterLabel, defaultClusterID, persistentVolumeLabel string) (pvKey, error) {
 	key := pvKey{}
 
 	cluster, err := res.GetString(clusterLabel)
 	if err != nil {
-		cluster = env.GetClusterID()
+		cluster = defaultClusterID
 	}
 	key.Cluster = cluster
 
@@ -123,6 +123,7 @@ var (
 	gpuCountGv                 *prometheus.GaugeVec
 	pvGv                       *prometheus.GaugeVec
 	spotGv                     *prometheus.GaugeVec
+	acceleratorGv              *prometheus.GaugeVec
 	totalGv                    *prometheus.GaugeVec
 	ramAllocGv                 *prometheus.GaugeVec
 	cpuAllocGv                 *prometheus.GaugeVec
@@ -131,6 +132,7 @@ var (
 	networkZoneEgressCostG     prometheus.Gauge
 	networkRegionEgressCostG   prometheus.Gauge
 	networkInternetEgressCostG prometheus.Gauge
+	networkNATGatewayCostGv    *prometheus.GaugeVec
 	clusterManagementCostGv    *prometheus.GaugeVec
 	lbCostGv                   *prometheus.GaugeVec
 )
@@ -192,6 +194,14 @@ func initCostModelMetrics(clusterCache clustercache.ClusterCache, provider cloud
 			toRegisterGV = append(toRegisterGV, spotGv)
 		}
 
+		if _, disabled := disabledMetrics["kubecost_node_accelerator_info"]; !disabled {
+			acceleratorGv = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name: "kubecost_node_accelerator_info",
+				Help: "kubecost_node_accelerator_info Type of the GPUs or other accelerators on this node",
+			}, []string{"instance", "node", "instance_type", "region", "provider_id", "accelerator_type"})
+			toRegisterGV = append(toRegisterGV, acceleratorGv)
+		}
+
 		if _, disabled := disabledMetrics["node_total_hourly_cost"]; !disabled {
 			totalGv = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 				Name: "node_total_hourly_cost",
@@ -256,6 +266,14 @@ func initCostModelMetrics(clusterCache clustercache.ClusterCache, provider cloud
 			toRegisterGauge = append(toRegisterGauge, networkInternetEgressCostG)
 		}
 
+		if _, disabled := disabledMetrics["kubecost_network_nat_gateway_cost"]; !disabled {
+			networkNATGatewayCostGv = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name: "kubecost_network_nat_gateway_cost",
+				Help: "kubecost_network_nat_gateway_cost Total cost per GB of internet egress processed by a NAT gateway from this node",
+			}, []string{"node"})
+			toRegisterGV = append(toRegisterGV, networkNATGatewayCostGv)
+		}
+
 		if _, disabled := disabledMetrics["kubecost_cluster_management_cost"]; !disabled {
 			clusterManagementCostGv = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 				Name: "kubecost_cluster_management_cost",
@@ -308,6 +326,7 @@ type CostModelMetricsEmitter struct {
 	GPUCountRecorder              *prometheus.GaugeVec
 	PVAllocationRecorder          *prometheus.GaugeVec
 	NodeSpotRecorder              *prometheus.GaugeVec
+	NodeAcceleratorRecorder       *prometheus.GaugeVec
 	NodeTotalPriceRecorder        *prometheus.GaugeVec
 	RAMAllocationRecorder         *prometheus.GaugeVec
 	CPUAllocationRecorder         *prometheus.GaugeVec
@@ -317,6 +336,7 @@ type CostModelMetricsEmitter struct {
 	NetworkZoneEgressRecorder     prometheus.Gauge
 	NetworkRegionEgressRecorder   prometheus.Gauge
 	NetworkInternetEgressRecorder prometheus.Gauge
+	NetworkNATGatewayRecorder     *prometheus.GaugeVec
 
 	// Concurrent Flow Control - Manages the run state of the metric emitter
 	runState atomic.AtomicRunState
@@ -359,6 +379,7 @@ func NewCostModelMetricsEmitter(promClient promclient.Client, clusterCache clust
 		GPUCountRecorder:              gpuCountGv,
 		PersistentVolumePriceRecorder: pvGv,
 		NodeSpotRecorder:              spotGv,
+		NodeAcceleratorRecorder:       acceleratorGv,
 		NodeTotalPriceRecorder:        totalGv,
 		RAMAllocationRecorder:         ramAllocGv,
 		CPUAllocationRecorder:         cpuAllocGv,
@@ -367,6 +388,7 @@ func NewCostModelMetricsEmitter(promClient promclient.Client, clusterCache clust
 		NetworkZoneEgressRecorder:     networkZoneEgressCostG,
 		NetworkRegionEgressRecorder:   networkRegionEgressCostG,
 		NetworkInternetEgressRecorder: networkInternetEgressCostG,
+		NetworkNATGatewayRecorder:     networkNATGatewayCostGv,
 		ClusterManagementCostRecorder: clusterManagementCostGv,
 		LBCostRecorder:                lbCostGv,
 	}
@@ -406,6 +428,8 @@ func (cmme *CostModelMetricsEmitter) Start() bool {
 		loadBalancerSeen := make(map[string]bool)
 		pvSeen := make(map[string]bool)
 		pvcSeen := make(map[string]bool)
+		natGatewaySeen := make(map[string]bool)
+		acceleratorSeen := make(map[string]bool)
 		nodeCostAverages := make(map[string]NodeCostAverages)
 
 		getKeyFromLabelStrings := func(labels ...string) string {
@@ -432,6 +456,8 @@ func (cmme *CostModelMetricsEmitter) Start() bool {
 			for _, pod := range podlist {
 				podStatus[pod.Name] = pod.Status.Phase
 			}
+			// indexed on the first Autopilot node of the pass
+			var runningPodsByNode map[string][]*v1.Pod
 
 			cfg, _ := cmme.CloudProvider.GetConfig()
 
@@ -451,6 +477,22 @@ func (cmme *CostModelMetricsEmitter) Start() bool {
 				cmme.NetworkInternetEgressRecorder.Set(networkCosts.InternetNetworkEgressCost)
 			}
 
+			// Record NAT gateway data processing cost for the nodes whose internet egress crosses a NAT gateway
+			if cmme.NetworkNATGatewayRecorder != nil && networkCosts != nil && cfg != nil {
+				subnets, err := cloud.ParseNATGatewaySubnets(cfg.NATGatewaySubnets)
+				if err != nil {
+					log.DedupedWarningf(5, "Failed to parse NAT gateway subnets: %s", err)
+				}
+				for _, n := range cmme.KubeClusterCache.GetAllNodes() {
+					natGatewayCost := 0.0
+					if cloud.NodeUsesNATGateway(n, subnets) {
+						natGatewayCost = networkCosts.NATGatewayDataProcessingCost
+					}
+					cmme.NetworkNATGatewayRecorder.WithLabelValues(n.Name).Set(natGatewayCost)
+					natGatewaySeen[n.Name] = true
+				}
+			}
+
 			// TODO: Pass PrometheusClient and CloudProvider into CostModel on instantiation so this isn't so awkward
 			data, err := cmme.Model.ComputeCostData(cmme.PrometheusClient, cmme.CloudProvider, "2m", "", "")
 			if err != nil {
@@ -512,6 +554,21 @@ func (cmme *CostModelMetricsEmitter) Start() bool {
 				nodeRegion := node.Region
 
 				totalCost := cpu*cpuCost + ramCost*(ram/1024/1024/1024) + gpu*gpuCost
+				if node.PricingType == cloud.Autopilot {
+					// Autopilot bills the requests of pods rather than nodes
+					if runningPodsByNode == nil {
+						runningPodsByNode = indexRunningPodsByNode(podlist)
+					}
+					class, _ := cloud.ParseAutopilotInstanceType(nodeType)
+					var billedCPU, billedRAM float64
+					for _, pod := range runningPodsByNode[nodeName] {
+						requestedCPU, requestedRAM := cloud.AutopilotPodRequests(pod)
+						podCPU, podRAM := cloud.AutopilotBilledRequests(class, requestedCPU, requestedRAM)
+						billedCPU += podCPU
+						billedRAM += podRAM
+					}
+					totalCost = billedCPU*cpuCost + ramCost*(billedRAM/1024/1024/1024) + gpu*gpuCost
+				}
 
 				labelKey := getKeyFromLabelStrings(nodeName, nodeName, nodeType, nodeRegion, node.ProviderID)
 
@@ -562,6 +619,10 @@ func (cmme *CostModelMetricsEmitter) Start() bool {
 				} else {
 					cmme.NodeSpotRecorder.WithLabelValues(nodeName, nodeName, nodeType, nodeRegion, node.ProviderID).Set(0.0)
 				}
+				if node.AcceleratorType != "" {
+					cmme.NodeAcceleratorRecorder.WithLabelValues(nodeName, nodeName, nodeType, nodeRegion, node.ProviderID, node.AcceleratorType).Set(1.0)
+					acceleratorSeen[getKeyFromLabelStrings(nodeName, nodeName, nodeType, nodeRegion, node.ProviderID, node.AcceleratorType)] = true
+				}
 				nodeSeen[labelKey] = true
 			}
 
@@ -712,6 +773,22 @@ func (cmme *CostModelMetricsEmitter) Start() bool {
 					nodeSeen[labelString] = false
 				}
 			}
+			for labelString, seen := range acceleratorSeen {
+				if !seen {
+					cmme.NodeAcceleratorRecorder.DeleteLabelValues(getLabelStringsFromKey(labelString)...)
+					delete(acceleratorSeen, labelString)
+				} else {
+					acceleratorSeen[labelString] = false
+				}
+			}
+			for nodeName, seen := range natGatewaySeen {
+				if !seen {
+					cmme.NetworkNATGatewayRecorder.DeleteLabelValues(nodeName)
+					delete(natGatewaySeen, nodeName)
+				} else {
+					natGatewaySeen[nodeName] = false
+				}
+			}
 			for labelString, seen := range loadBalancerSeen {
 				if !seen {
 					labels := getLabelStringsFromKey(labelString)
@@ -771,3 +848,16 @@ func (cmme *CostModelMetricsEmitter) Start() bool {
 func (cmme *CostModelMetricsEmitter) Stop() {
 	cmme.runState.Stop()
 }
+
+// indexRunningPodsByNode returns the running pods of the given pods by the
+// name of their node.
+func indexRunningPodsByNode(pods []*v1.Pod) map[string][]*v1.Pod {
+	m := make(map[string][]*v1.Pod)
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		m[pod.Spec.NodeName] = append(m[pod.Spec.NodeName], pod)
+	}
+	return m
+}
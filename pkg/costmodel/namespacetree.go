@@ -0,0 +1,313 @@
+package costmodel
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/kubecost/opencost/pkg/env"
+	"github.com/kubecost/opencost/pkg/kubecost"
+	"github.com/kubecost/opencost/pkg/prom"
+	"github.com/kubecost/opencost/pkg/util/httputil"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// HNC labels each namespace with <ancestor>.tree.hnc.x-k8s.io/depth for
+	// itself and each of its ancestors, where the parent has depth 1
+	hncTreeDepthLabelSuffix = ".tree.hnc.x-k8s.io/depth"
+	// HNC annotates subnamespaces with the namespace they were created in
+	hncSubnamespaceOfAnnotation = "hnc.x-k8s.io/subnamespace-of"
+	// Capsule labels the namespaces of a tenant with the tenant's name, and
+	// sets the tenant as their owner
+	capsuleTenantLabel = "capsule.clastix.io/tenant"
+	capsuleTenantKind  = "Tenant"
+	capsuleAPIGroup    = "capsule.clastix.io"
+
+	// maxNamespaceDepth bounds walks up the hierarchy, in case of cycles
+	maxNamespaceDepth = 64
+)
+
+// namespaceHierarchy records the parent and tenant of namespaces, from which
+// the path from the root of their hierarchy and their tenant are resolved.
+type namespaceHierarchy struct {
+	parents map[namespaceKey]string
+	tenants map[namespaceKey]string
+}
+
+func newNamespaceHierarchy() *namespaceHierarchy {
+	return &namespaceHierarchy{
+		parents: map[namespaceKey]string{},
+		tenants: map[namespaceKey]string{},
+	}
+}
+
+// addNamespace records the parent and tenant of a namespace from the labels,
+// annotations and owners of the namespace object, unless they are already
+// known.
+func (h *namespaceHierarchy) addNamespace(cluster string, ns *v1.Namespace) {
+	key := newNamespaceKey(cluster, ns.Name)
+
+	if _, ok := h.parents[key]; !ok {
+		parent := ns.Annotations[hncSubnamespaceOfAnnotation]
+		if parent == "" {
+			for label, depth := range ns.Labels {
+				if depth == "1" && strings.HasSuffix(label, hncTreeDepthLabelSuffix) {
+					parent = strings.TrimSuffix(label, hncTreeDepthLabelSuffix)
+					break
+				}
+			}
+		}
+		if parent != "" {
+			h.parents[key] = parent
+		}
+	}
+
+	if _, ok := h.tenants[key]; !ok {
+		tenant := ns.Labels[capsuleTenantLabel]
+		if tenant == "" {
+			for _, owner := range ns.OwnerReferences {
+				if owner.Kind == capsuleTenantKind && strings.HasPrefix(owner.APIVersion, capsuleAPIGroup+"/") {
+					tenant = owner.Name
+					break
+				}
+			}
+		}
+		if tenant != "" {
+			h.tenants[key] = tenant
+		}
+	}
+}
+
+// addSanitizedNamespace records the parent and tenant of a namespace from
+// its labels and annotations as recorded in Prometheus, whose names are
+// sanitized. Namespace names in label names are resolved using names, which
+// maps sanitized namespace names of the cluster to their names.
+func (h *namespaceHierarchy) addSanitizedNamespace(key namespaceKey, labels, annotations map[string]string, names map[string]string) {
+	suffix := prom.SanitizeLabelName(hncTreeDepthLabelSuffix)
+
+	parent := annotations[prom.SanitizeLabelName(hncSubnamespaceOfAnnotation)]
+	if parent == "" {
+		for label, depth := range labels {
+			if depth == "1" && strings.HasSuffix(label, suffix) {
+				parent = strings.TrimSuffix(label, suffix)
+				if name, ok := names[parent]; ok {
+					parent = name
+				}
+				break
+			}
+		}
+	}
+	if parent != "" {
+		h.parents[key] = parent
+	}
+
+	if tenant := labels[prom.SanitizeLabelName(capsuleTenantLabel)]; tenant != "" {
+		h.tenants[key] = tenant
+	}
+}
+
+// path returns the namespaces from the root of the namespace's hierarchy
+// down to the namespace itself.
+func (h *namespaceHierarchy) path(key namespaceKey) []string {
+	path := []string{key.Namespace}
+	visited := map[string]bool{key.Namespace: true}
+	for len(path) < maxNamespaceDepth {
+		parent, ok := h.parents[key]
+		if !ok || visited[parent] {
+			break
+		}
+		visited[parent] = true
+		path = append([]string{parent}, path...)
+		key = newNamespaceKey(key.Cluster, parent)
+	}
+	return path
+}
+
+// tenant returns the tenant of the namespace, or of its nearest ancestor
+// belonging to a tenant.
+func (h *namespaceHierarchy) tenant(key namespaceKey) string {
+	path := h.path(key)
+	for i := len(path) - 1; i >= 0; i-- {
+		if tenant, ok := h.tenants[newNamespaceKey(key.Cluster, path[i])]; ok {
+			return tenant
+		}
+	}
+	return ""
+}
+
+// buildNamespaceHierarchy builds the namespace hierarchy from the namespace
+// labels and annotations in Prometheus, falling back to the namespaces of
+// the local cluster in the cluster cache.
+func (cm *CostModel) buildNamespaceHierarchy(podMap map[podKey]*Pod, namespaceLabels map[namespaceKey]map[string]string, namespaceAnnotations map[string]map[string]string) *namespaceHierarchy {
+	var namespaces []*v1.Namespace
+	if cm != nil && cm.Cache != nil {
+		namespaces = cm.Cache.GetAllNamespaces()
+	}
+
+	return buildNamespaceHierarchy(podMap, namespaceLabels, namespaceAnnotations, cm.ClusterID, namespaces)
+}
+
+func buildNamespaceHierarchy(podMap map[podKey]*Pod, namespaceLabels map[namespaceKey]map[string]string, namespaceAnnotations map[string]map[string]string, localCluster string, namespaces []*v1.Namespace) *namespaceHierarchy {
+	h := newNamespaceHierarchy()
+
+	// Names of the namespaces of each cluster, by their sanitized names
+	names := map[string]map[string]string{}
+	addName := func(cluster, namespace string) {
+		if _, ok := names[cluster]; !ok {
+			names[cluster] = map[string]string{}
+		}
+		names[cluster][prom.SanitizeLabelName(namespace)] = namespace
+	}
+	for key := range podMap {
+		addName(key.Cluster, key.Namespace)
+	}
+	for key := range namespaceLabels {
+		addName(key.Cluster, key.Namespace)
+	}
+	for _, ns := range namespaces {
+		addName(localCluster, ns.Name)
+	}
+
+	for key, labels := range namespaceLabels {
+		h.addSanitizedNamespace(key, labels, namespaceAnnotations[key.Namespace], names[key.Cluster])
+	}
+	for _, ns := range namespaces {
+		h.addNamespace(localCluster, ns)
+	}
+
+	return h
+}
+
+// applyNamespaceHierarchy labels the allocations of namespaces with a parent
+// or a tenant with the root of their hierarchy, their path from the root and
+// their tenant.
+func applyNamespaceHierarchy(podMap map[podKey]*Pod, h *namespaceHierarchy) {
+	for _, pod := range podMap {
+		for _, alloc := range pod.Allocations {
+			key := newNamespaceKey(alloc.Properties.Cluster, alloc.Properties.Namespace)
+			path := h.path(key)
+			tenant := h.tenant(key)
+			if len(path) == 1 && tenant == "" {
+				continue
+			}
+
+			if alloc.Properties.Labels == nil {
+				alloc.Properties.Labels = map[string]string{}
+			}
+			alloc.Properties.Labels[kubecost.NamespaceRootLabel] = path[0]
+			alloc.Properties.Labels[kubecost.NamespacePathLabel] = strings.Join(path, "/")
+			if tenant != "" {
+				alloc.Properties.Labels[kubecost.TenantLabel] = tenant
+			}
+		}
+	}
+}
+
+// NamespaceCostNode is the cost of a namespace and of its descendants in the
+// namespace hierarchy. Cost is the cost of the namespace alone, and
+// TotalCost includes the costs of its descendants.
+type NamespaceCostNode struct {
+	Cluster   string               `json:"cluster"`
+	Name      string               `json:"name"`
+	Tenant    string               `json:"tenant,omitempty"`
+	Cost      float64              `json:"cost"`
+	TotalCost float64              `json:"totalCost"`
+	Children  []*NamespaceCostNode `json:"children,omitempty"`
+}
+
+// buildNamespaceCostTree rolls the costs of the allocations up the
+// namespace hierarchy, returning the root namespaces of each cluster.
+func buildNamespaceCostTree(as *kubecost.AllocationSet) []*NamespaceCostNode {
+	nodes := map[namespaceKey]*NamespaceCostNode{}
+	var roots []*NamespaceCostNode
+
+	getNode := func(cluster string, path []string) *NamespaceCostNode {
+		var parent *NamespaceCostNode
+		for i, namespace := range path {
+			key := newNamespaceKey(cluster, strings.Join(path[:i+1], "/"))
+			node, ok := nodes[key]
+			if !ok {
+				node = &NamespaceCostNode{Cluster: cluster, Name: namespace}
+				nodes[key] = node
+				if parent == nil {
+					roots = append(roots, node)
+				} else {
+					parent.Children = append(parent.Children, node)
+				}
+			}
+			parent = node
+		}
+		return parent
+	}
+
+	as.Each(func(name string, alloc *kubecost.Allocation) {
+		if alloc.Properties == nil || alloc.Properties.Namespace == "" || alloc.IsIdle() || alloc.IsUnallocated() {
+			return
+		}
+
+		path := []string{alloc.Properties.Namespace}
+		if p := alloc.Properties.Labels[kubecost.NamespacePathLabel]; p != "" {
+			path = strings.Split(p, "/")
+		}
+
+		node := getNode(alloc.Properties.Cluster, path)
+		node.Cost += alloc.TotalCost()
+		if tenant := alloc.Properties.Labels[kubecost.TenantLabel]; tenant != "" {
+			node.Tenant = tenant
+		}
+	})
+
+	var rollUp func(node *NamespaceCostNode) float64
+	rollUp = func(node *NamespaceCostNode) float64 {
+		sort.Slice(node.Children, func(i, j int) bool { return node.Children[i].Name < node.Children[j].Name })
+		node.TotalCost = node.Cost
+		for _, child := range node.Children {
+			node.TotalCost += rollUp(child)
+		}
+		return node.TotalCost
+	}
+	for _, root := range roots {
+		rollUp(root)
+	}
+
+	sort.Slice(roots, func(i, j int) bool {
+		if roots[i].Cluster != roots[j].Cluster {
+			return roots[i].Cluster < roots[j].Cluster
+		}
+		return roots[i].Name < roots[j].Name
+	})
+
+	return roots
+}
+
+// ComputeNamespaceTreeHandler returns the costs of namespaces over the given
+// window as trees following the namespace hierarchy, with the cost of each
+// namespace rolled up into its ancestors.
+func (a *Accesses) ComputeNamespaceTreeHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	qp := httputil.NewQueryParams(r.URL.Query())
+
+	window, err := kubecost.ParseWindowWithOffset(qp.Get("window", ""), env.GetParsedUTCOffset())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid 'window' parameter: %s", err), http.StatusBadRequest)
+		return
+	}
+	if window.IsOpen() {
+		http.Error(w, fmt.Sprintf("Invalid 'window' parameter: %s is open", window), http.StatusBadRequest)
+		return
+	}
+
+	resolution := qp.GetDuration("resolution", env.GetETLResolution())
+
+	as, err := a.Model.ComputeAllocation(*window.Start(), *window.End(), resolution)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	w.Write(WrapData(buildNamespaceCostTree(as), nil))
+}
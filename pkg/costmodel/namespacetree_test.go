@@ -0,0 +1,193 @@
+package costmodel
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/kubecost"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newFixtureNamespaceHierarchy builds a hierarchy of namespaces across two clusters:
+//
+//	cluster1 (from Prometheus)       cluster1 (from the cluster cache)   cluster2 (from Prometheus)
+//	org                              payments (tenant finance)           org
+//	├── team-a (tenant acme)         └── payments-dev                    └── team-b
+//	│   └── team-a-dev
+//	└── team-b
+func newFixtureNamespaceHierarchy() *namespaceHierarchy {
+	podMap := map[podKey]*Pod{
+		newPodKey("cluster1", "team-a-dev", "pod1"):   {},
+		newPodKey("cluster1", "payments-dev", "pod1"): {},
+	}
+
+	namespaceLabels := map[namespaceKey]map[string]string{
+		newNamespaceKey("cluster1", "org"): {
+			"org_tree_hnc_x_k8s_io_depth": "0",
+		},
+		newNamespaceKey("cluster1", "team-a"): {
+			"org_tree_hnc_x_k8s_io_depth":    "1",
+			"team_a_tree_hnc_x_k8s_io_depth": "0",
+			"capsule_clastix_io_tenant":      "acme",
+		},
+		newNamespaceKey("cluster1", "team-a-dev"): {
+			"org_tree_hnc_x_k8s_io_depth":        "2",
+			"team_a_tree_hnc_x_k8s_io_depth":     "1",
+			"team_a_dev_tree_hnc_x_k8s_io_depth": "0",
+		},
+		newNamespaceKey("cluster1", "team-b"): {},
+		newNamespaceKey("cluster2", "team-b"): {
+			"org_tree_hnc_x_k8s_io_depth": "1",
+		},
+	}
+	namespaceAnnotations := map[string]map[string]string{
+		"team-b": {"hnc_x_k8s_io_subnamespace_of": "org"},
+	}
+
+	namespaces := []*v1.Namespace{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "payments",
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: "capsule.clastix.io/v1beta2", Kind: "Tenant", Name: "finance"},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "payments-dev",
+				Labels: map[string]string{"payments.tree.hnc.x-k8s.io/depth": "1"},
+			},
+		},
+	}
+
+	return buildNamespaceHierarchy(podMap, namespaceLabels, namespaceAnnotations, "cluster1", namespaces)
+}
+
+func TestNamespaceHierarchy(t *testing.T) {
+	h := newFixtureNamespaceHierarchy()
+
+	cases := []struct {
+		input          namespaceKey
+		expectedPath   string
+		expectedTenant string
+	}{
+		{input: newNamespaceKey("cluster1", "org"), expectedPath: "org"},
+		{input: newNamespaceKey("cluster1", "team-a"), expectedPath: "org/team-a", expectedTenant: "acme"},
+		// Sanitized ancestor names are resolved to the namespace names, and tenants are inherited
+		{input: newNamespaceKey("cluster1", "team-a-dev"), expectedPath: "org/team-a/team-a-dev", expectedTenant: "acme"},
+		// Subnamespace annotations are keyed by namespace alone, so apply in every cluster
+		{input: newNamespaceKey("cluster1", "team-b"), expectedPath: "org/team-b"},
+		{input: newNamespaceKey("cluster2", "team-b"), expectedPath: "org/team-b"},
+		{input: newNamespaceKey("cluster1", "payments"), expectedPath: "payments", expectedTenant: "finance"},
+		{input: newNamespaceKey("cluster1", "payments-dev"), expectedPath: "payments/payments-dev", expectedTenant: "finance"},
+		// The cluster cache only describes the local cluster
+		{input: newNamespaceKey("cluster2", "payments-dev"), expectedPath: "payments-dev"},
+		{input: newNamespaceKey("cluster1", "unknown"), expectedPath: "unknown"},
+	}
+
+	for _, test := range cases {
+		path := strings.Join(h.path(test.input), "/")
+		if path != test.expectedPath {
+			t.Errorf("Input: %s, Expected path: %s, Actual: %s", test.input, test.expectedPath, path)
+		}
+		tenant := h.tenant(test.input)
+		if tenant != test.expectedTenant {
+			t.Errorf("Input: %s, Expected tenant: %s, Actual: %s", test.input, test.expectedTenant, tenant)
+		}
+	}
+}
+
+func TestNamespaceHierarchyCycle(t *testing.T) {
+	h := newNamespaceHierarchy()
+	h.parents[newNamespaceKey("cluster1", "a")] = "b"
+	h.parents[newNamespaceKey("cluster1", "b")] = "a"
+
+	path := strings.Join(h.path(newNamespaceKey("cluster1", "a")), "/")
+	if path != "b/a" {
+		t.Errorf("Expected path: b/a, Actual: %s", path)
+	}
+}
+
+func TestApplyNamespaceHierarchyAndCostTree(t *testing.T) {
+	start := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	podMap := map[podKey]*Pod{}
+	newAlloc := func(cluster, namespace string, cost float64) {
+		key := newPodKey(cluster, namespace, "pod1")
+		podMap[key] = &Pod{
+			Key: key,
+			Allocations: map[string]*kubecost.Allocation{
+				"app": {
+					Name:       cluster + "/" + namespace + "/pod1/app",
+					Properties: &kubecost.AllocationProperties{Cluster: cluster, Namespace: namespace, Pod: "pod1", Container: "app"},
+					Window:     kubecost.NewWindow(&start, &end),
+					Start:      start,
+					End:        end,
+					CPUCost:    cost,
+				},
+			},
+		}
+	}
+	newAlloc("cluster1", "org", 1)
+	newAlloc("cluster1", "team-a", 2)
+	newAlloc("cluster1", "team-a-dev", 4)
+	newAlloc("cluster1", "team-b", 8)
+	newAlloc("cluster1", "payments-dev", 16)
+	newAlloc("cluster1", "standalone", 32)
+
+	applyNamespaceHierarchy(podMap, newFixtureNamespaceHierarchy())
+
+	dev := podMap[newPodKey("cluster1", "team-a-dev", "pod1")].Allocations["app"]
+	if dev.Properties.Labels[kubecost.NamespaceRootLabel] != "org" ||
+		dev.Properties.Labels[kubecost.NamespacePathLabel] != "org/team-a/team-a-dev" ||
+		dev.Properties.Labels[kubecost.TenantLabel] != "acme" {
+		t.Errorf("team-a-dev: expected root org, path org/team-a/team-a-dev and tenant acme, actual %v", dev.Properties.Labels)
+	}
+	standalone := podMap[newPodKey("cluster1", "standalone", "pod1")].Allocations["app"]
+	if len(standalone.Properties.Labels) != 0 {
+		t.Errorf("standalone: expected no labels, actual %v", standalone.Properties.Labels)
+	}
+
+	as := kubecost.NewAllocationSet(start, end)
+	for _, pod := range podMap {
+		for _, alloc := range pod.Allocations {
+			as.Set(alloc)
+		}
+	}
+
+	// Aggregating by root rolls descendants up into their root
+	byRoot := as.Clone()
+	if err := byRoot.AggregateBy([]string{kubecost.AllocationNamespaceRootProp}, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expectedRoots := map[string]float64{"org": 15, "payments": 16, "standalone": 32}
+	for root, cost := range expectedRoots {
+		alloc := byRoot.Get(root)
+		if alloc == nil || math.Abs(alloc.TotalCost()-cost) > 1e-9 {
+			t.Errorf("namespaceRoot %s: expected cost %f, actual %v", root, cost, alloc)
+		}
+	}
+
+	roots := buildNamespaceCostTree(as)
+	if len(roots) != 3 {
+		t.Fatalf("expected 3 roots, actual %d", len(roots))
+	}
+	org := roots[0]
+	if org.Name != "org" || org.Cost != 1 || org.TotalCost != 15 || len(org.Children) != 2 {
+		t.Fatalf("org: expected cost 1 and total cost 15 over 2 children, actual %+v", org)
+	}
+	teamA := org.Children[0]
+	if teamA.Name != "team-a" || teamA.TotalCost != 6 || teamA.Tenant != "acme" || len(teamA.Children) != 1 || teamA.Children[0].TotalCost != 4 {
+		t.Errorf("team-a: expected tenant acme, total cost 6 and a child costing 4, actual %+v", teamA)
+	}
+	// Namespaces without allocations of their own still carry their descendants
+	payments := roots[1]
+	if payments.Name != "payments" || payments.Cost != 0 || payments.TotalCost != 16 {
+		t.Errorf("payments: expected cost 0 and total cost 16, actual %+v", payments)
+	}
+}
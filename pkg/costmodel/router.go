@@ -679,6 +679,13 @@ func (a *Accesses) GetPricingSourceCounts(w http.ResponseWriter, _ *http.Request
 	w.Write(WrapData(a.Model.GetPricingSourceCounts()))
 }
 
+func (a *Accesses) GetHostPricing(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	w.Write(WrapData(a.Model.GetHostPricing()))
+}
+
 func (a *Accesses) GetPrometheusMetadata(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -1640,6 +1647,8 @@ func Initialize(additionalConfigWatchers ...*watcher.ConfigMapWatcher) *Accesses
 		pc = promCli
 	}
 	costModel := NewCostModel(pc, cloudProvider, k8sCache, clusterMap, scrapeInterval)
+	costModel.PriceSchedule = cloud.NewPriceScheduleConfig(confManager).Schedule
+	costModel.NodeOwnerRules = cloud.NewNodeOwnerRulesConfig(confManager).Rules
 	metricsEmitter := NewCostModelMetricsEmitter(promCli, k8sCache, cloudProvider, clusterInfoProvider, costModel)
 
 	a := &Accesses{
@@ -1692,6 +1701,8 @@ func Initialize(additionalConfigWatchers ...*watcher.ConfigMapWatcher) *Accesses
 	a.Router.GET("/aggregatedCostModel", a.AggregateCostModelHandler)
 	a.Router.GET("/allocation/compute", a.ComputeAllocationHandler)
 	a.Router.GET("/allocation/compute/summary", a.ComputeAllocationHandlerSummary)
+	a.Router.GET("/allocation/jobRuns", a.ComputeJobRunsHandler)
+	a.Router.GET("/allocation/namespaceTree", a.ComputeNamespaceTreeHandler)
 	a.Router.GET("/allNodePricing", a.GetAllNodePricing)
 	a.Router.POST("/refreshPricing", a.RefreshPricingData)
 	a.Router.GET("/clusterCostsOverTime", a.ClusterCostsOverTime)
@@ -1704,6 +1715,7 @@ func Initialize(additionalConfigWatchers ...*watcher.ConfigMapWatcher) *Accesses
 	a.Router.GET("/serviceAccountStatus", a.GetServiceAccountStatus)
 	a.Router.GET("/pricingSourceStatus", a.GetPricingSourceStatus)
 	a.Router.GET("/pricingSourceCounts", a.GetPricingSourceCounts)
+	a.Router.GET("/hostPricing", a.GetHostPricing)
 
 	// endpoints migrated from server
 	a.Router.GET("/allPersistentVolumes", a.GetAllPersistentVolumes)
@@ -1736,6 +1748,16 @@ func Initialize(additionalConfigWatchers ...*watcher.ConfigMapWatcher) *Accesses
 	a.Router.GET("/diagnostics/requestQueue", a.GetPrometheusQueueState)
 	a.Router.GET("/diagnostics/prometheusMetrics", a.GetPrometheusMetrics)
 
+	// optional GraphQL API over allocations, assets and cluster metadata
+	if env.IsGraphQLEnabled() {
+		a.httpServices.Add(services.NewGraphQLService(a.Model, a.Model, a.ClusterInfoProvider, a.ClusterCache))
+	}
+
+	// optional Grafana JSON datasource over allocations and assets
+	if env.IsGrafanaDatasourceEnabled() {
+		a.httpServices.Add(services.NewGrafanaDatasourceService(a.Model, a.Model))
+	}
+
 	a.httpServices.RegisterAll(a.Router)
 
 	return a
@@ -16,6 +16,13 @@ const (
 	AWSAccessKeySecretEnvVar = "AWS_SECRET_ACCESS_KEY"
 	AWSClusterIDEnvVar       = "AWS_CLUSTER_ID"
 
+	AlibabaAccessKeyIDEnvVar     = "ALIBABA_ACCESS_KEY_ID"
+	AlibabaAccessKeySecretEnvVar = "ALIBABA_SECRET_ACCESS_KEY"
+
+	DigitalOceanAccessTokenEnvVar = "DIGITALOCEAN_ACCESS_TOKEN"
+
+	IBMGlobalCatalogRefreshEnabledEnvVar = "IBM_GLOBAL_CATALOG_REFRESH_ENABLED"
+
 	KubecostNamespaceEnvVar        = "KUBECOST_NAMESPACE"
 	ClusterIDEnvVar                = "CLUSTER_ID"
 	ClusterProfileEnvVar           = "CLUSTER_PROFILE"
@@ -85,6 +92,21 @@ const (
 
 	IngestPodUIDEnvVar = "INGEST_POD_UID"
 
+	GraphQLEnabledEnvVar       = "GRAPHQL_ENABLED"
+	GraphQLMaxComplexityEnvVar = "GRAPHQL_MAX_COMPLEXITY"
+
+	GrafanaDatasourceEnabledEnvVar = "GRAFANA_DATASOURCE_ENABLED"
+
+	CostAPIServerEnabledEnvVar       = "COST_APISERVER_ENABLED"
+	CostAPIServerPortEnvVar          = "COST_APISERVER_PORT"
+	CostAPIServerCertFileEnvVar      = "COST_APISERVER_CERT_FILE"
+	CostAPIServerKeyFileEnvVar       = "COST_APISERVER_KEY_FILE"
+	CostAPIServerDefaultWindowEnvVar = "COST_APISERVER_DEFAULT_WINDOW"
+
+	FOCUSExportEnabledEnvVar      = "FOCUS_EXPORT_ENABLED"
+	FOCUSExportBucketConfigEnvVar = "FOCUS_EXPORT_BUCKET_CONFIG"
+	FOCUSExportPathEnvVar         = "FOCUS_EXPORT_PATH"
+
 	ETLReadOnlyMode = "ETL_READ_ONLY"
 )
 
@@ -202,6 +224,31 @@ func GetAWSClusterID() string {
 	return Get(AWSClusterIDEnvVar, "")
 }
 
+// GetAlibabaAccessKeyID returns the environment variable value for AlibabaAccessKeyIDEnvVar which represents
+// the Alibaba Cloud access key for authentication
+func GetAlibabaAccessKeyID() string {
+	return Get(AlibabaAccessKeyIDEnvVar, "")
+}
+
+// GetAlibabaAccessKeySecret returns the environment variable value for AlibabaAccessKeySecretEnvVar which represents
+// the Alibaba Cloud access key secret for authentication
+func GetAlibabaAccessKeySecret() string {
+	return Get(AlibabaAccessKeySecretEnvVar, "")
+}
+
+// GetDigitalOceanAccessToken returns the environment variable value for DigitalOceanAccessTokenEnvVar which
+// represents the DigitalOcean API token used to refresh droplet prices
+func GetDigitalOceanAccessToken() string {
+	return Get(DigitalOceanAccessTokenEnvVar, "")
+}
+
+// IsIBMGlobalCatalogRefreshEnabled returns true if IBM Cloud worker prices should be refreshed from the
+// global catalog API rather than only read from the offline catalog snapshot. It is enabled by default;
+// flavors which fail to refresh keep their snapshot prices.
+func IsIBMGlobalCatalogRefreshEnabled() bool {
+	return GetBool(IBMGlobalCatalogRefreshEnabledEnvVar, true)
+}
+
 // GetKubecostNamespace returns the environment variable value for KubecostNamespaceEnvVar which
 // represents the namespace the cost model exists in.
 func GetKubecostNamespace() string {
@@ -473,3 +520,69 @@ func GetPromClusterLabel() string {
 func IsIngestingPodUID() bool {
 	return GetBool(IngestPodUIDEnvVar, false)
 }
+
+// IsGraphQLEnabled returns true if the GraphQL API over allocations, assets and
+// cluster metadata is served.
+func IsGraphQLEnabled() bool {
+	return GetBool(GraphQLEnabledEnvVar, false)
+}
+
+// GetGraphQLMaxComplexity returns the maximum complexity of the GraphQL queries
+// which will be executed.
+func GetGraphQLMaxComplexity() int {
+	return GetInt(GraphQLMaxComplexityEnvVar, 1000)
+}
+
+// IsGrafanaDatasourceEnabled returns true if the Grafana JSON datasource
+// endpoints over allocations and assets are served.
+func IsGrafanaDatasourceEnabled() bool {
+	return GetBool(GrafanaDatasourceEnabledEnvVar, false)
+}
+
+// IsCostAPIServerEnabled returns true if the cost resources are served as a
+// Kubernetes aggregated API.
+func IsCostAPIServerEnabled() bool {
+	return GetBool(CostAPIServerEnabledEnvVar, false)
+}
+
+// GetCostAPIServerPort returns the port on which the aggregated API is served.
+func GetCostAPIServerPort() int {
+	return GetInt(CostAPIServerPortEnvVar, 8443)
+}
+
+// GetCostAPIServerCertFile returns the path of the serving certificate of the
+// aggregated API. If empty, a self-signed certificate is generated.
+func GetCostAPIServerCertFile() string {
+	return Get(CostAPIServerCertFileEnvVar, "")
+}
+
+// GetCostAPIServerKeyFile returns the path of the key of the serving
+// certificate of the aggregated API.
+func GetCostAPIServerKeyFile() string {
+	return Get(CostAPIServerKeyFileEnvVar, "")
+}
+
+// GetCostAPIServerDefaultWindow returns the window of the costs of the
+// aggregated API when a request does not select one.
+func GetCostAPIServerDefaultWindow() string {
+	return Get(CostAPIServerDefaultWindowEnvVar, "1d")
+}
+
+// IsFOCUSExportEnabled returns true if the allocations and assets of each day
+// are exported in the FOCUS format.
+func IsFOCUSExportEnabled() bool {
+	return GetBool(FOCUSExportEnabledEnvVar, false)
+}
+
+// GetFOCUSExportBucketConfig returns the path of the configuration of the
+// bucket to which FOCUS exports are written. If empty, they are written to the
+// config path.
+func GetFOCUSExportBucketConfig() string {
+	return Get(FOCUSExportBucketConfigEnvVar, "")
+}
+
+// GetFOCUSExportPath returns the directory, of the bucket or the config path,
+// to which FOCUS exports are written.
+func GetFOCUSExportPath() string {
+	return Get(FOCUSExportPathEnvVar, "focus")
+}
@@ -0,0 +1,196 @@
+package focus
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/kubecost"
+	"github.com/kubecost/opencost/pkg/log"
+	"github.com/kubecost/opencost/pkg/storage"
+	"github.com/kubecost/opencost/pkg/util/json"
+)
+
+const (
+	// AllocationsFile is the name of the file of the rows of allocations.
+	AllocationsFile = "allocations.csv"
+	// AssetsFile is the name of the file of the rows of assets.
+	AssetsFile = "assets.csv"
+)
+
+// periodFormat is the format of the start and end of the directories of
+// periods, which omits the colons some storage does not support in paths.
+const periodFormat = "20060102T150405Z"
+
+// Computer computes the allocations and assets of a period, e.g. a
+// costmodel.CostModel or an opencost.Computer.
+type Computer interface {
+	ComputeAllocation(start, end time.Time, resolution time.Duration) (*kubecost.AllocationSet, error)
+	ComputeAssets(start, end time.Time) (*kubecost.AssetSet, error)
+}
+
+// Exporter writes the FOCUS rows of the allocations and assets of periods to
+// a directory of a storage.Storage.
+type Exporter struct {
+	store    storage.Storage
+	dir      string
+	provider string
+	currency string
+}
+
+// NewExporter creates a new Exporter, which writes to the given directory of
+// the given storage. The given provider is the ProviderName of the rows of
+// allocations, and of assets without a provider; the given currency is their
+// BillingCurrency.
+func NewExporter(store storage.Storage, dir, provider, currency string) *Exporter {
+	return &Exporter{
+		store:    store,
+		dir:      dir,
+		provider: provider,
+		currency: currency,
+	}
+}
+
+// PeriodDir returns the directory of the files of the given window, e.g.
+// "focus/20220601T000000Z-20220602T000000Z".
+func (e *Exporter) PeriodDir(window kubecost.Window) string {
+	period := fmt.Sprintf("%s-%s", window.Start().UTC().Format(periodFormat), window.End().UTC().Format(periodFormat))
+	return path.Join(e.dir, period)
+}
+
+// Export writes the rows of the given AllocationSet and AssetSet, of the same
+// window, to the directory of the window, overwriting existing files.
+func (e *Exporter) Export(as *kubecost.AllocationSet, assets *kubecost.AssetSet) error {
+	dir := e.PeriodDir(as.Window)
+
+	if err := e.write(path.Join(dir, AllocationsFile), e.AllocationRows(as, assets)); err != nil {
+		return err
+	}
+	if err := e.write(path.Join(dir, AssetsFile), e.AssetRows(assets)); err != nil {
+		return err
+	}
+
+	log.Infof("FOCUS: exported %s to %s", as.Window, e.store.FullPath(dir))
+	return nil
+}
+
+// ExportPeriod computes and exports the allocations and assets of the window
+// of the given start and end times, unless they were already exported.
+func (e *Exporter) ExportPeriod(c Computer, start, end time.Time, resolution time.Duration) error {
+	window := kubecost.NewClosedWindow(start, end)
+
+	exists, err := e.store.Exists(path.Join(e.PeriodDir(window), AssetsFile))
+	if err != nil {
+		return fmt.Errorf("error checking export of %s: %s", window, err)
+	}
+	if exists {
+		return nil
+	}
+
+	as, err := c.ComputeAllocation(start, end, resolution)
+	if err != nil {
+		return fmt.Errorf("error computing allocation of %s: %s", window, err)
+	}
+
+	assets, err := c.ComputeAssets(start, end)
+	if err != nil {
+		return fmt.Errorf("error computing assets of %s: %s", window, err)
+	}
+
+	return e.Export(as, assets)
+}
+
+func (e *Exporter) write(file string, rows []*Row) error {
+	buf := &bytes.Buffer{}
+	if err := WriteCSV(buf, rows); err != nil {
+		return fmt.Errorf("error encoding %s: %s", file, err)
+	}
+
+	if err := e.store.Write(file, buf.Bytes()); err != nil {
+		return fmt.Errorf("error writing %s: %s", file, err)
+	}
+	return nil
+}
+
+// WriteCSV writes the given rows as CSV, with a header of their columns.
+func WriteCSV(w io.Writer, rows []*Row) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		tags := ""
+		if len(row.Tags) > 0 {
+			data, err := json.Marshal(row.Tags)
+			if err != nil {
+				return err
+			}
+			tags = string(data)
+		}
+
+		quantity := ""
+		if row.ConsumedUnit != "" {
+			quantity = formatFloat(row.ConsumedQuantity)
+		}
+
+		record := []string{
+			formatFloat(row.BilledCost),
+			formatFloat(row.EffectiveCost),
+			formatFloat(row.ListCost),
+			row.BillingCurrency,
+			formatTime(row.BillingPeriodStart),
+			formatTime(row.BillingPeriodEnd),
+			formatTime(row.ChargePeriodStart),
+			formatTime(row.ChargePeriodEnd),
+			row.ChargeCategory,
+			row.ChargeDescription,
+			row.ChargeFrequency,
+			quantity,
+			row.ConsumedUnit,
+			row.ProviderName,
+			row.PublisherName,
+			row.InvoiceIssuerName,
+			row.RegionId,
+			row.AvailabilityZone,
+			row.ResourceId,
+			row.ResourceName,
+			row.ResourceType,
+			row.ServiceCategory,
+			row.ServiceName,
+			row.SubAccountId,
+			tags,
+			row.Cluster,
+			row.Namespace,
+			row.Node,
+			row.CostType,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatFloat formats the given value rounded to nanos, e.g. of dollars, so
+// that the error of floating point arithmetic is not exported.
+func formatFloat(f float64) string {
+	rounded := math.Round(f*1e9) / 1e9
+	if rounded == 0 {
+		// Not "-0"
+		rounded = 0
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
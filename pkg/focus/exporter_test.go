@@ -0,0 +1,259 @@
+package focus
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/kubecost"
+	"github.com/kubecost/opencost/pkg/storage"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+var testStart = time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+var testEnd = time.Date(2022, 6, 2, 0, 0, 0, 0, time.UTC)
+
+func newTestAllocationSet() *kubecost.AllocationSet {
+	window := kubecost.NewClosedWindow(testStart, testEnd)
+
+	web := &kubecost.Allocation{
+		Name: "cluster-one/node-1/web/web-1/nginx",
+		Properties: &kubecost.AllocationProperties{
+			Cluster:   "cluster-one",
+			Node:      "node-1",
+			Namespace: "web",
+			Pod:       "web-1",
+			Container: "nginx",
+			Labels:    kubecost.AllocationLabels{"app": "web", "team": "frontend"},
+		},
+		Window:               window,
+		Start:                testStart,
+		End:                  testEnd,
+		CPUCoreHours:         12,
+		CPUCost:              0.48,
+		CPUCostAdjustment:    0.02,
+		RAMByteHours:         24 * gib,
+		RAMCost:              0.12,
+		NetworkTransferBytes: 2 * gib,
+		NetworkCost:          0.02,
+		PVs: kubecost.PVAllocations{
+			{Cluster: "cluster-one", Name: "pvc-1"}: {ByteHours: 240 * gib, Cost: 0.1},
+		},
+		SharedCost: 0.05,
+	}
+
+	job := &kubecost.Allocation{
+		Name: "cluster-one/node-1/batch/job-1/worker",
+		Properties: &kubecost.AllocationProperties{
+			Cluster:   "cluster-one",
+			Node:      "node-1",
+			Namespace: "batch",
+			Pod:       "job-1",
+			Container: "worker",
+		},
+		Window:                window,
+		Start:                 testStart.Add(8 * time.Hour),
+		End:                   testStart.Add(15 * time.Hour),
+		CPUCoreHours:          7,
+		CPUCost:               0.28,
+		RAMByteHours:          14 * gib,
+		RAMCost:               0.07,
+		GPUHours:              7,
+		GPUCost:               1.5,
+		ExtendedResourceCosts: map[string]float64{"hugepages_2Mi": 0.01},
+	}
+
+	unmounted := &kubecost.Allocation{
+		Name: "cluster-one/__unmounted__/__unmounted__/__unmounted__",
+		Properties: &kubecost.AllocationProperties{
+			Cluster:   "cluster-one",
+			Node:      kubecost.UnmountedSuffix,
+			Namespace: kubecost.UnmountedSuffix,
+			Pod:       kubecost.UnmountedSuffix,
+		},
+		Window: window,
+		Start:  testStart,
+		End:    testEnd,
+		PVs: kubecost.PVAllocations{
+			{Cluster: "cluster-one", Name: "pvc-2"}: {ByteHours: 120 * gib, Cost: 0.03},
+		},
+	}
+
+	return kubecost.NewAllocationSet(testStart, testEnd, web, job, unmounted)
+}
+
+func newTestAssetSet() *kubecost.AssetSet {
+	window := kubecost.NewClosedWindow(testStart, testEnd)
+
+	node := kubecost.NewNode("node-1", "cluster-one", "i-0123456789", testStart, testEnd, window)
+	node.NodeType = "m5.large"
+	node.CPUCoreHours = 48
+	node.CPUCost = 1.92
+	node.RAMByteHours = 192 * gib
+	node.RAMCost = 0.96
+	node.GPUHours = 24
+	node.GPUCost = 3.0
+	node.Discount = 0.1
+	node.SetLabels(kubecost.AssetLabels{
+		"label_topology_kubernetes_io_region": "us-east-1",
+		"label_topology_kubernetes_io_zone":   "us-east-1a",
+	})
+	node.SetAdjustment(-0.01)
+
+	disk := kubecost.NewDisk("pvc-1", "cluster-one", "vol-0123456789", testStart, testEnd, window)
+	disk.ByteHours = 240 * gib
+	disk.Cost = 0.1
+
+	lb := kubecost.NewLoadBalancer("web/web", "cluster-one", "203.0.113.1", testStart, testEnd, window)
+	lb.Cost = 0.6
+
+	cm := kubecost.NewClusterManagement("AWS", "cluster-one", window)
+	cm.Cost = 2.4
+
+	return kubecost.NewAssetSet(testStart, testEnd, node, disk, lb, cm)
+}
+
+// checkGolden compares the CSV of the given rows with the given golden file of
+// testdata, or updates it if the -update flag is set.
+func checkGolden(t *testing.T, golden string, rows []*Row) {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	if err := WriteCSV(buf, rows); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	path := filepath.Join("testdata", golden)
+	if *update {
+		if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatalf("error updating %s: %s", path, err)
+		}
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading %s: %s", path, err)
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("rows do not match %s; got:\n%s", path, buf.String())
+	}
+}
+
+func TestExporter_AllocationRows(t *testing.T) {
+	e := NewExporter(nil, "focus", "AWS", "USD")
+
+	rows := e.AllocationRows(newTestAllocationSet(), newTestAssetSet())
+	checkGolden(t, "allocations.csv", rows)
+}
+
+func TestExporter_AllocationRows_Idle(t *testing.T) {
+	e := NewExporter(nil, "focus", "AWS", "USD")
+
+	as := newTestAllocationSet()
+	as.Insert(&kubecost.Allocation{
+		Name:       "cluster-one/" + kubecost.IdleSuffix,
+		Properties: &kubecost.AllocationProperties{Cluster: "cluster-one"},
+		Window:     kubecost.NewClosedWindow(testStart, testEnd),
+		Start:      testStart,
+		End:        testEnd,
+		CPUCost:    1.0,
+		RAMCost:    0.5,
+	})
+
+	// Idle allocations are exported as they are, rather than idle costs
+	// derived from the assets.
+	rows := e.AllocationRows(as, newTestAssetSet())
+	idle := []*Row{}
+	for _, row := range rows {
+		if row.CostType == CostTypeIdle {
+			idle = append(idle, row)
+		}
+	}
+	if len(idle) != 2 {
+		t.Fatalf("expected 2 idle rows; got %d", len(idle))
+	}
+	for _, row := range idle {
+		if row.ResourceId != "cluster-one/"+kubecost.IdleSuffix || row.ResourceType != "Idle" {
+			t.Errorf("unexpected idle row %s of %s", row.ResourceType, row.ResourceId)
+		}
+	}
+	if idle[0].ChargeDescription != "CPU" || idle[0].EffectiveCost != 1.0 {
+		t.Errorf("expected idle CPU cost 1.0; got %s %f", idle[0].ChargeDescription, idle[0].EffectiveCost)
+	}
+}
+
+func TestExporter_AssetRows(t *testing.T) {
+	e := NewExporter(nil, "focus", "AWS", "USD")
+
+	rows := e.AssetRows(newTestAssetSet())
+	checkGolden(t, "assets.csv", rows)
+}
+
+// testComputer is a Computer of the test sets, which counts its calls.
+type testComputer struct {
+	calls int
+}
+
+func (tc *testComputer) ComputeAllocation(start, end time.Time, resolution time.Duration) (*kubecost.AllocationSet, error) {
+	tc.calls++
+	return newTestAllocationSet(), nil
+}
+
+func (tc *testComputer) ComputeAssets(start, end time.Time) (*kubecost.AssetSet, error) {
+	return newTestAssetSet(), nil
+}
+
+func TestExporter_ExportPeriod(t *testing.T) {
+	dir := t.TempDir()
+	e := NewExporter(storage.NewFileStorage(dir), "focus", "AWS", "USD")
+	c := &testComputer{}
+
+	if err := e.ExportPeriod(c, testStart, testEnd, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, file := range []string{AllocationsFile, AssetsFile} {
+		path := filepath.Join(dir, "focus", "20220601T000000Z-20220602T000000Z", file)
+		actual, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("error reading export: %s", err)
+		}
+		expected, err := ioutil.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Fatalf("error reading golden file: %s", err)
+		}
+		if !bytes.Equal(actual, expected) {
+			t.Errorf("export %s does not match testdata/%s", path, file)
+		}
+	}
+
+	// Periods which were already exported are not computed again.
+	if err := e.ExportPeriod(c, testStart, testEnd, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if c.calls != 1 {
+		t.Errorf("expected 1 computation; got %d", c.calls)
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	cases := map[float64]string{
+		0:                    "0",
+		-0.0000000001:        "0",
+		0.1 + 0.2:            "0.3",
+		1.5:                  "1.5",
+		0.00000000123456:     "0.000000001",
+		1234.56789012345:     "1234.567890123",
+		-0.47999999999999998: "-0.48",
+	}
+
+	for f, expected := range cases {
+		if actual := formatFloat(f); actual != expected {
+			t.Errorf("formatFloat(%v): expected %s; got %s", f, expected, actual)
+		}
+	}
+}
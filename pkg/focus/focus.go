@@ -0,0 +1,132 @@
+// Package focus exports allocations and assets in the FinOps Open Cost and
+// Usage Specification (FOCUS) format, so that the costs of Kubernetes can be
+// analyzed alongside the bills of cloud providers.
+//
+// The rows of allocations and the rows of assets are exported to separate
+// files, allocations.csv and assets.csv, in a directory per period. Both
+// describe the same spend: allocations by the containers which consumed it,
+// and assets by the nodes, disks and load balancers which were paid for. Sum
+// the costs of one or the other, never both.
+//
+// The costs are computed by OpenCost, not invoiced, so BilledCost and
+// EffectiveCost are equal, and include the adjustments and discounts of the
+// costs. ListCost excludes them. ChargePeriodStart and ChargePeriodEnd are the
+// window of the period; BillingPeriodStart and BillingPeriodEnd are the month
+// in which it starts.
+//
+// Each allocation is exported as one row per non-zero cost: CPU, GPU, RAM,
+// persistent volumes, network, load balancers, extended resources, shared
+// and external costs. The rows are told apart by ChargeDescription, and share
+// the ResourceId of the name of the allocation.
+//
+// Idle costs, those of the capacity of nodes which no allocation requested,
+// are exported as rows of the ResourceType "Idle", with the ResourceId
+// "<cluster>/__idle__", one per resource of each cluster. Idle allocations of
+// the exported AllocationSet are exported as they are; otherwise the idle
+// costs are the costs of the nodes of the exported AssetSet, less those of the
+// allocations of their cluster.
+//
+// Shared costs, those which were shared with an allocation by aggregation,
+// are exported as rows of the allocation with the x_KubernetesCostType
+// "Shared", so that they may be told apart from the costs the allocation
+// incurred itself. Allocations of the costs which were not shared, named
+// "__shared__", are exported as rows of the same x_KubernetesCostType.
+//
+// Each asset is exported as one row. Its ResourceId is the provider ID of the
+// asset if known, e.g. the instance ID of a node, so that it matches the
+// ResourceId of the bill of the provider.
+package focus
+
+import (
+	"time"
+)
+
+// The values of x_KubernetesCostType, which tells what the cost of a row is
+// attributed to.
+const (
+	CostTypeAllocated = "Allocated"
+	CostTypeIdle      = "Idle"
+	CostTypeShared    = "Shared"
+	CostTypeExternal  = "External"
+	CostTypeUnmounted = "Unmounted"
+	CostTypeAsset     = "Asset"
+)
+
+// ServiceName is the ServiceName of every row.
+const ServiceName = "Kubernetes"
+
+// The values of ServiceCategory.
+const (
+	ServiceCategoryCompute    = "Compute"
+	ServiceCategoryStorage    = "Storage"
+	ServiceCategoryNetworking = "Networking"
+	ServiceCategoryManagement = "Management and Governance"
+	ServiceCategoryOther      = "Other"
+)
+
+// Row is a row of FOCUS columns, followed by the x_ prefixed columns of
+// Kubernetes.
+type Row struct {
+	BilledCost         float64
+	EffectiveCost      float64
+	ListCost           float64
+	BillingCurrency    string
+	BillingPeriodStart time.Time
+	BillingPeriodEnd   time.Time
+	ChargePeriodStart  time.Time
+	ChargePeriodEnd    time.Time
+	ChargeCategory     string
+	ChargeDescription  string
+	ChargeFrequency    string
+	ConsumedQuantity   float64
+	ConsumedUnit       string
+	ProviderName       string
+	PublisherName      string
+	InvoiceIssuerName  string
+	RegionId           string
+	AvailabilityZone   string
+	ResourceId         string
+	ResourceName       string
+	ResourceType       string
+	ServiceCategory    string
+	ServiceName        string
+	SubAccountId       string
+	Tags               map[string]string
+	Cluster            string
+	Namespace          string
+	Node               string
+	CostType           string
+}
+
+// columns are the header of exported files, in the order of the fields of Row.
+var columns = []string{
+	"BilledCost",
+	"EffectiveCost",
+	"ListCost",
+	"BillingCurrency",
+	"BillingPeriodStart",
+	"BillingPeriodEnd",
+	"ChargePeriodStart",
+	"ChargePeriodEnd",
+	"ChargeCategory",
+	"ChargeDescription",
+	"ChargeFrequency",
+	"ConsumedQuantity",
+	"ConsumedUnit",
+	"ProviderName",
+	"PublisherName",
+	"InvoiceIssuerName",
+	"RegionId",
+	"AvailabilityZone",
+	"ResourceId",
+	"ResourceName",
+	"ResourceType",
+	"ServiceCategory",
+	"ServiceName",
+	"SubAccountId",
+	"Tags",
+	"x_KubernetesCluster",
+	"x_KubernetesNamespace",
+	"x_KubernetesNode",
+	"x_KubernetesCostType",
+}
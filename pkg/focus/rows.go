@@ -0,0 +1,340 @@
+package focus
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/kubecost"
+)
+
+const gib = 1024.0 * 1024.0 * 1024.0
+
+// The labels of nodes from which the RegionId and AvailabilityZone of assets
+// are read, as sanitized by Prometheus.
+var (
+	regionLabels = []string{"label_topology_kubernetes_io_region", "label_failure_domain_beta_kubernetes_io_region"}
+	zoneLabels   = []string{"label_topology_kubernetes_io_zone", "label_failure_domain_beta_kubernetes_io_zone"}
+)
+
+// rowBuilder creates the rows of a period, with the columns common to all of
+// its rows.
+type rowBuilder struct {
+	window   kubecost.Window
+	provider string
+	currency string
+}
+
+func (rb *rowBuilder) newRow(cost, listCost float64) *Row {
+	start, end := *rb.window.Start(), *rb.window.End()
+	billingStart := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location())
+
+	return &Row{
+		BilledCost:         cost,
+		EffectiveCost:      cost,
+		ListCost:           listCost,
+		BillingCurrency:    rb.currency,
+		BillingPeriodStart: billingStart,
+		BillingPeriodEnd:   billingStart.AddDate(0, 1, 0),
+		ChargePeriodStart:  start,
+		ChargePeriodEnd:    end,
+		ChargeCategory:     "Usage",
+		ChargeFrequency:    "Usage-Based",
+		ProviderName:       rb.provider,
+		PublisherName:      rb.provider,
+		InvoiceIssuerName:  rb.provider,
+		ServiceName:        ServiceName,
+	}
+}
+
+// allocationCost is one of the costs of an allocation, which is exported as a
+// row.
+type allocationCost struct {
+	description string
+	category    string
+	costType    string
+	cost        float64
+	listCost    float64
+	quantity    float64
+	unit        string
+}
+
+// allocationCosts returns the non-zero costs of the given allocation.
+func allocationCosts(alloc *kubecost.Allocation, costType string) []allocationCost {
+	costs := []allocationCost{
+		{"CPU", ServiceCategoryCompute, costType, alloc.CPUTotalCost(), alloc.CPUCost, alloc.CPUCoreHours, "Core-Hours"},
+		{"GPU", ServiceCategoryCompute, costType, alloc.GPUTotalCost(), alloc.GPUCost, alloc.GPUHours, "GPU-Hours"},
+		{"RAM", ServiceCategoryCompute, costType, alloc.RAMTotalCost(), alloc.RAMCost, alloc.RAMByteHours / gib, "GiB-Hours"},
+		{"Persistent volumes", ServiceCategoryStorage, costType, alloc.PVTotalCost(), alloc.PVCost(), alloc.PVByteHours() / gib, "GiB-Hours"},
+		{"Network", ServiceCategoryNetworking, costType, alloc.NetworkTotalCost(), alloc.NetworkCost, alloc.NetworkTransferBytes / gib, "GiB"},
+		{"Load balancers", ServiceCategoryNetworking, costType, alloc.LoadBalancerTotalCost(), alloc.LoadBalancerCost, 0, ""},
+	}
+
+	resources := make([]string, 0, len(alloc.ExtendedResourceCosts))
+	for resource := range alloc.ExtendedResourceCosts {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	for _, resource := range resources {
+		cost := alloc.ExtendedResourceCosts[resource]
+		costs = append(costs, allocationCost{resource, ServiceCategoryCompute, costType, cost, cost, 0, ""})
+	}
+
+	costs = append(costs,
+		allocationCost{"Shared", ServiceCategoryOther, CostTypeShared, alloc.SharedCost, alloc.SharedCost, 0, ""},
+		allocationCost{"External", ServiceCategoryOther, CostTypeExternal, alloc.ExternalCost, alloc.ExternalCost, 0, ""},
+	)
+
+	nonZero := []allocationCost{}
+	for _, c := range costs {
+		if c.cost != 0 || c.listCost != 0 {
+			nonZero = append(nonZero, c)
+		}
+	}
+	return nonZero
+}
+
+// allocationCostType returns the x_KubernetesCostType of the given allocation.
+func allocationCostType(alloc *kubecost.Allocation) string {
+	switch {
+	case alloc.IsIdle():
+		return CostTypeIdle
+	case alloc.IsUnmounted():
+		return CostTypeUnmounted
+	case alloc.IsExternal():
+		return CostTypeExternal
+	case strings.Contains(alloc.Name, kubecost.SharedSuffix):
+		return CostTypeShared
+	}
+	return CostTypeAllocated
+}
+
+// AllocationRows returns the rows of the given AllocationSet, and of the idle
+// costs of the nodes of the given AssetSet, which may be nil, if the
+// AllocationSet has no idle allocations.
+func (e *Exporter) AllocationRows(as *kubecost.AllocationSet, assets *kubecost.AssetSet) []*Row {
+	rb := &rowBuilder{window: as.Window, provider: e.provider, currency: e.currency}
+	rows := []*Row{}
+
+	hasIdle := false
+	as.Each(func(name string, alloc *kubecost.Allocation) {
+		costType := allocationCostType(alloc)
+		if costType == CostTypeIdle {
+			hasIdle = true
+		}
+
+		resourceType := "Allocation"
+		resourceName := alloc.Name
+		var cluster, namespace, node string
+		var tags map[string]string
+		if alloc.Properties != nil {
+			cluster = alloc.Properties.Cluster
+			namespace = alloc.Properties.Namespace
+			node = alloc.Properties.Node
+			tags = alloc.Properties.Labels
+			if alloc.Properties.Container != "" {
+				resourceType = "Container"
+				resourceName = alloc.Properties.Container
+			}
+		}
+		if costType == CostTypeIdle {
+			resourceType = "Idle"
+		}
+
+		for _, c := range allocationCosts(alloc, costType) {
+			row := rb.newRow(c.cost, c.listCost)
+			row.ChargeDescription = c.description
+			row.ConsumedQuantity = c.quantity
+			row.ConsumedUnit = c.unit
+			row.ResourceId = alloc.Name
+			row.ResourceName = resourceName
+			row.ResourceType = resourceType
+			row.ServiceCategory = c.category
+			row.Tags = tags
+			row.Cluster = cluster
+			row.Namespace = namespace
+			row.Node = node
+			row.CostType = c.costType
+			rows = append(rows, row)
+		}
+	})
+
+	if !hasIdle && assets != nil {
+		rows = append(rows, idleRows(rb, as, assets)...)
+	}
+
+	sortRows(rows)
+	return rows
+}
+
+// idleRows returns the rows of the costs of the CPU, GPU and RAM of the nodes
+// of each cluster, less those of its allocations.
+func idleRows(rb *rowBuilder, as *kubecost.AllocationSet, assets *kubecost.AssetSet) []*Row {
+	type resourceCosts struct {
+		cpuCost, gpuCost, ramCost            float64
+		cpuCoreHours, gpuHours, ramByteHours float64
+	}
+
+	idle := map[string]*resourceCosts{}
+	assets.Each(func(key string, a kubecost.Asset) {
+		node, ok := a.(*kubecost.Node)
+		if !ok {
+			return
+		}
+		cluster := node.Properties().Cluster
+		if _, ok := idle[cluster]; !ok {
+			idle[cluster] = &resourceCosts{}
+		}
+		rc := idle[cluster]
+		rc.cpuCost += node.CPUCost * (1.0 - node.Discount)
+		rc.gpuCost += node.GPUCost
+		rc.ramCost += node.RAMCost * (1.0 - node.Discount)
+		rc.cpuCoreHours += node.CPUCoreHours
+		rc.gpuHours += node.GPUHours
+		rc.ramByteHours += node.RAMByteHours
+	})
+
+	as.Each(func(name string, alloc *kubecost.Allocation) {
+		if alloc.Properties == nil {
+			return
+		}
+		rc, ok := idle[alloc.Properties.Cluster]
+		if !ok {
+			return
+		}
+		rc.cpuCost -= alloc.CPUTotalCost()
+		rc.gpuCost -= alloc.GPUTotalCost()
+		rc.ramCost -= alloc.RAMTotalCost()
+		rc.cpuCoreHours -= alloc.CPUCoreHours
+		rc.gpuHours -= alloc.GPUHours
+		rc.ramByteHours -= alloc.RAMByteHours
+	})
+
+	rows := []*Row{}
+	for cluster, rc := range idle {
+		costs := []allocationCost{
+			{"CPU", ServiceCategoryCompute, CostTypeIdle, rc.cpuCost, rc.cpuCost, rc.cpuCoreHours, "Core-Hours"},
+			{"GPU", ServiceCategoryCompute, CostTypeIdle, rc.gpuCost, rc.gpuCost, rc.gpuHours, "GPU-Hours"},
+			{"RAM", ServiceCategoryCompute, CostTypeIdle, rc.ramCost, rc.ramCost, rc.ramByteHours / gib, "GiB-Hours"},
+		}
+		for _, c := range costs {
+			// Allocations may cost more than their nodes, e.g. when they
+			// request more than the capacity of the nodes.
+			if c.cost <= 0 {
+				continue
+			}
+
+			row := rb.newRow(c.cost, c.listCost)
+			row.ChargeDescription = c.description
+			row.ConsumedQuantity = c.quantity
+			if row.ConsumedQuantity < 0 {
+				row.ConsumedQuantity = 0
+			}
+			row.ConsumedUnit = c.unit
+			row.ResourceId = fmt.Sprintf("%s/%s", cluster, kubecost.IdleSuffix)
+			row.ResourceName = kubecost.IdleSuffix
+			row.ResourceType = "Idle"
+			row.ServiceCategory = c.category
+			row.Cluster = cluster
+			row.CostType = CostTypeIdle
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// AssetRows returns the rows of the given AssetSet.
+func (e *Exporter) AssetRows(assets *kubecost.AssetSet) []*Row {
+	rb := &rowBuilder{window: assets.Window, provider: e.provider, currency: e.currency}
+	rows := []*Row{}
+
+	assets.Each(func(key string, a kubecost.Asset) {
+		props := a.Properties()
+		if props == nil {
+			props = &kubecost.AssetProperties{}
+		}
+
+		cost := a.TotalCost()
+		listCost := cost - a.Adjustment()
+		if node, ok := a.(*kubecost.Node); ok {
+			listCost = node.CPUCost + node.GPUCost + node.RAMCost
+		}
+		if cost == 0 && listCost == 0 {
+			return
+		}
+
+		row := rb.newRow(cost, listCost)
+		row.ChargeDescription = fmt.Sprintf("%s %s", a.Type(), props.Name)
+		if props.Name == "" {
+			row.ChargeDescription = a.Type().String()
+		}
+		row.ConsumedQuantity = a.Minutes() / 60.0
+		row.ConsumedUnit = "Hours"
+		if props.Provider != "" {
+			row.ProviderName = props.Provider
+			row.PublisherName = props.Provider
+			row.InvoiceIssuerName = props.Provider
+		}
+
+		labels := a.Labels()
+		row.RegionId = firstLabel(labels, regionLabels)
+		row.AvailabilityZone = firstLabel(labels, zoneLabels)
+
+		row.ResourceId = props.ProviderID
+		if row.ResourceId == "" {
+			row.ResourceId = key
+		}
+		row.ResourceName = props.Name
+		row.ResourceType = a.Type().String()
+		row.ServiceCategory = assetServiceCategory(props.Category)
+		row.SubAccountId = props.Account
+		if row.SubAccountId == "" {
+			row.SubAccountId = props.Project
+		}
+		row.Tags = labels
+		row.Cluster = props.Cluster
+		if a.Type() == kubecost.NodeAssetType {
+			row.Node = props.Name
+		}
+		row.CostType = CostTypeAsset
+		rows = append(rows, row)
+	})
+
+	sortRows(rows)
+	return rows
+}
+
+func assetServiceCategory(category string) string {
+	switch category {
+	case kubecost.ComputeCategory:
+		return ServiceCategoryCompute
+	case kubecost.StorageCategory:
+		return ServiceCategoryStorage
+	case kubecost.NetworkCategory:
+		return ServiceCategoryNetworking
+	case kubecost.ManagementCategory:
+		return ServiceCategoryManagement
+	}
+	return ServiceCategoryOther
+}
+
+func firstLabel(labels map[string]string, names []string) string {
+	for _, name := range names {
+		if value, ok := labels[name]; ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// sortRows sorts rows by ResourceId, then ChargeDescription, so that exports
+// are reproducible.
+func sortRows(rows []*Row) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].ResourceId != rows[j].ResourceId {
+			return rows[i].ResourceId < rows[j].ResourceId
+		}
+		return rows[i].ChargeDescription < rows[j].ChargeDescription
+	})
+}
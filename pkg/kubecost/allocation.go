@@ -3,6 +3,7 @@ package kubecost
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 	"sync"
@@ -81,6 +82,10 @@ type Allocation struct {
 	// RawAllocationOnly is a pointer so if it is not present it will be
 	// marshalled as null rather than as an object with Go default values.
 	RawAllocationOnly *RawAllocationOnlyData `json:"rawAllocationOnly"`
+	// ExtendedResourceCosts are the costs of the hugepages and extended
+	// resources, e.g. FPGAs and SR-IOV virtual functions, requested by the
+	// Allocation, keyed by their resource names as sanitized by Prometheus.
+	ExtendedResourceCosts map[string]float64 `json:"extendedResourceCosts,omitempty"` // @bingen:field[version=16]
 }
 
 // RawAllocationOnlyData is information that only belong in "raw" Allocations,
@@ -223,9 +228,24 @@ func (a *Allocation) Clone() *Allocation {
 		SharedCost:                 a.SharedCost,
 		ExternalCost:               a.ExternalCost,
 		RawAllocationOnly:          a.RawAllocationOnly.Clone(),
+		ExtendedResourceCosts:      cloneExtendedResourceCosts(a.ExtendedResourceCosts),
 	}
 }
 
+// cloneExtendedResourceCosts returns a copy of the given extended resource
+// costs, or nil if there are none.
+func cloneExtendedResourceCosts(costs map[string]float64) map[string]float64 {
+	if costs == nil {
+		return nil
+	}
+
+	clone := make(map[string]float64, len(costs))
+	for resource, cost := range costs {
+		clone[resource] = cost
+	}
+	return clone
+}
+
 // Clone returns a deep copy of the given RawAllocationOnlyData
 func (r *RawAllocationOnlyData) Clone() *RawAllocationOnlyData {
 	if r == nil {
@@ -333,6 +353,16 @@ func (a *Allocation) Equal(that *Allocation) bool {
 		}
 	}
 
+	if len(a.ExtendedResourceCosts) != len(that.ExtendedResourceCosts) {
+		return false
+	}
+	for resource, cost := range a.ExtendedResourceCosts {
+		thatCost, ok := that.ExtendedResourceCosts[resource]
+		if !ok || !util.IsApproximately(cost, thatCost) {
+			return false
+		}
+	}
+
 	aPVs := a.PVs
 	thatPVs := that.PVs
 	if len(aPVs) == len(thatPVs) {
@@ -354,7 +384,7 @@ func (a *Allocation) TotalCost() float64 {
 		return 0.0
 	}
 
-	return a.CPUTotalCost() + a.GPUTotalCost() + a.RAMTotalCost() + a.PVTotalCost() + a.NetworkTotalCost() + a.LBTotalCost() + a.SharedTotalCost() + a.ExternalCost
+	return a.CPUTotalCost() + a.GPUTotalCost() + a.RAMTotalCost() + a.PVTotalCost() + a.NetworkTotalCost() + a.LBTotalCost() + a.SharedTotalCost() + a.ExternalCost + a.ExtendedResourceTotalCost()
 }
 
 // CPUTotalCost calculates total CPU cost of Allocation including adjustment
@@ -426,6 +456,20 @@ func (a *Allocation) SharedTotalCost() float64 {
 	return a.SharedCost
 }
 
+// ExtendedResourceTotalCost calculates total cost of the hugepages and
+// extended resources of Allocation
+func (a *Allocation) ExtendedResourceTotalCost() float64 {
+	if a == nil {
+		return 0.0
+	}
+
+	cost := 0.0
+	for _, c := range a.ExtendedResourceCosts {
+		cost += c
+	}
+	return cost
+}
+
 // PVCost calculate cumulative cost of all PVs that Allocation is attached to
 func (a *Allocation) PVCost() float64 {
 	if a == nil {
@@ -592,6 +636,9 @@ func (a *Allocation) MarshalJSON() ([]byte, error) {
 	jsonEncodeFloat64(buffer, "ramEfficiency", a.RAMEfficiency(), ",")
 	jsonEncodeFloat64(buffer, "sharedCost", a.SharedCost, ",")
 	jsonEncodeFloat64(buffer, "externalCost", a.ExternalCost, ",")
+	if len(a.ExtendedResourceCosts) > 0 {
+		jsonEncode(buffer, "extendedResourceCosts", a.ExtendedResourceCosts, ",")
+	}
 	jsonEncodeFloat64(buffer, "totalCost", a.TotalCost(), ",")
 	jsonEncodeFloat64(buffer, "totalEfficiency", a.TotalEfficiency(), ",")
 	jsonEncode(buffer, "rawAllocationOnly", a.RawAllocationOnly, "")
@@ -779,6 +826,16 @@ func (a *Allocation) add(that *Allocation) {
 	// Sum PVAllocations
 	a.PVs = a.PVs.Add(that.PVs)
 
+	// Sum extended resource costs
+	if len(that.ExtendedResourceCosts) > 0 {
+		if a.ExtendedResourceCosts == nil {
+			a.ExtendedResourceCosts = make(map[string]float64, len(that.ExtendedResourceCosts))
+		}
+		for resource, cost := range that.ExtendedResourceCosts {
+			a.ExtendedResourceCosts[resource] += cost
+		}
+	}
+
 	// Sum all cumulative adjustment fields
 	a.CPUCostAdjustment += that.CPUCostAdjustment
 	a.RAMCostAdjustment += that.RAMCostAdjustment
@@ -827,7 +884,8 @@ func NewAllocationSet(start, end time.Time, allocs ...*Allocation) *AllocationSe
 // functions such that, if any function fails, the allocation is ignored.
 // ShareFuncs are a list of match functions such that, if any function
 // succeeds, the allocation is marked as a shared resource. ShareIdle is a
-// simple flag for sharing idle resources.
+// simple flag for sharing idle resources. ShareDaemonSetsByNode shares the
+// cost of each DaemonSet pod among the other pods on its node.
 type AllocationAggregationOptions struct {
 	AllocationTotalsStore AllocationTotalsStore
 	FilterFuncs           []AllocationMatchFunc
@@ -836,6 +894,7 @@ type AllocationAggregationOptions struct {
 	MergeUnallocated      bool
 	Reconcile             bool
 	ReconcileNetwork      bool
+	ShareDaemonSetsByNode bool
 	ShareFuncs            []AllocationMatchFunc
 	ShareIdle             string
 	ShareSplit            string
@@ -849,6 +908,10 @@ type AllocationAggregationOptions struct {
 func (as *AllocationSet) AggregateBy(aggregateBy []string, options *AllocationAggregationOptions) error {
 	// The order of operations for aggregating allocations is as follows:
 	//
+	//  0. If DaemonSets are shared by node, distribute the cost of each
+	//     DaemonSet pod among the other allocations of its node, in
+	//     proportion to their cost.
+	//
 	//  1. Partition external, idle, and shared allocations into separate sets.
 	//     Also, create the aggSet into which the results will be aggregated.
 	//
@@ -888,6 +951,11 @@ func (as *AllocationSet) AggregateBy(aggregateBy []string, options *AllocationAg
 	//	   whose costs were not distributed because there was no usage of a
 	//     specific resource type, re-add the idle to the aggregation with
 	//     only that type.
+	//
+	// Nodes dedicated to an owner (see NodeOwnerLabel) are charged in full to
+	// their owner: the idle allocations of a dedicated node are distributed
+	// among the allocations of that node alone, regardless of ShareIdle, and
+	// the allocations of dedicated nodes are never shared.
 
 	if as.IsEmpty() {
 		return nil
@@ -913,7 +981,7 @@ func (as *AllocationSet) AggregateBy(aggregateBy []string, options *AllocationAg
 	// generateKey for why that makes sense.
 	shouldAggregate := aggregateBy != nil
 	shouldFilter := len(options.FilterFuncs) > 0
-	shouldShare := len(options.SharedHourlyCosts) > 0 || len(options.ShareFuncs) > 0
+	shouldShare := len(options.SharedHourlyCosts) > 0 || len(options.ShareFuncs) > 0 || options.ShareDaemonSetsByNode
 	if !shouldAggregate && !shouldFilter && !shouldShare {
 		// There is nothing for AggregateBy to do, so simply return nil
 		return nil
@@ -941,9 +1009,23 @@ func (as *AllocationSet) AggregateBy(aggregateBy []string, options *AllocationAg
 		Window: as.Window.Clone(),
 	}
 
+	// dedicatedIdleSet will be charged to the allocations of the dedicated
+	// nodes whose idle costs it holds
+	dedicatedIdleSet := &AllocationSet{
+		Window: as.Window.Clone(),
+	}
+
 	as.Lock()
 	defer as.Unlock()
 
+	// (0) DaemonSet pods run because their nodes do, so their costs follow
+	// the workloads running on those nodes.
+	if options.ShareDaemonSetsByNode {
+		as.shareDaemonSetsByNode()
+	}
+
+	nodeOwners := as.dedicatedNodeOwners()
+
 	// (1) Loop and find all of the external, idle, and shared allocations. Add
 	// them to their respective sets, removing them from the set of allocations
 	// to aggregate.
@@ -965,7 +1047,9 @@ func (as *AllocationSet) AggregateBy(aggregateBy []string, options *AllocationAg
 			delete(as.idleKeys, alloc.Name)
 			delete(as.allocations, alloc.Name)
 
-			if options.ShareIdle == ShareEven || options.ShareIdle == ShareWeighted {
+			if _, ok := nodeOwners[alloc.dedicatedNodeId()]; ok {
+				dedicatedIdleSet.Insert(alloc)
+			} else if options.ShareIdle == ShareEven || options.ShareIdle == ShareWeighted {
 				idleSet.Insert(alloc)
 			} else {
 				aggSet.Insert(alloc)
@@ -974,6 +1058,12 @@ func (as *AllocationSet) AggregateBy(aggregateBy []string, options *AllocationAg
 			continue
 		}
 
+		// Allocations of dedicated nodes are charged to the node's owner, so
+		// they are never shared.
+		if _, ok := nodeOwners[alloc.dedicatedNodeId()]; ok {
+			continue
+		}
+
 		// Shared allocations must be identified and separated prior to
 		// aggregation and filtering. That is, if any of the ShareFuncs return
 		// true for the allocation, then move it to shareSet.
@@ -1026,12 +1116,19 @@ func (as *AllocationSet) AggregateBy(aggregateBy []string, options *AllocationAg
 
 	var err error
 
+	// Dedicated nodes with idle allocations of their own are charged their
+	// idle costs, so do not take part in sharing the remaining idle costs.
+	dedicatedIdleNodes := map[string]bool{}
+	for _, idleAlloc := range dedicatedIdleSet.allocations {
+		dedicatedIdleNodes[idleAlloc.dedicatedNodeId()] = true
+	}
+
 	// (2a) If there are idle costs to be shared, compute the coefficients for
 	// sharing them among the non-idle, non-aggregated allocations (including
 	// the shared allocations).
 	var idleCoefficients map[string]map[string]map[string]float64
 	if idleSet.Length() > 0 && options.ShareIdle != ShareNone {
-		idleCoefficients, allocatedTotalsMap, err = computeIdleCoeffs(options, as, shareSet)
+		idleCoefficients, allocatedTotalsMap, err = computeIdleCoeffs(options, as, shareSet, dedicatedIdleNodes)
 		if err != nil {
 			log.Warnf("AllocationSet.AggregateBy: compute idle coeff: %s", err)
 			return fmt.Errorf("error computing idle coefficients: %s", err)
@@ -1064,12 +1161,23 @@ func (as *AllocationSet) AggregateBy(aggregateBy []string, options *AllocationAg
 	// need to track this on a per-cluster or per-node, per-allocation, per-resource basis.
 	var idleFiltrationCoefficients map[string]map[string]map[string]float64
 	if len(options.FilterFuncs) > 0 && options.ShareIdle == ShareNone {
-		idleFiltrationCoefficients, _, err = computeIdleCoeffs(options, as, shareSet)
+		idleFiltrationCoefficients, _, err = computeIdleCoeffs(options, as, shareSet, dedicatedIdleNodes)
 		if err != nil {
 			return fmt.Errorf("error computing idle filtration coefficients: %s", err)
 		}
 	}
 
+	// (2e) If there are idle costs of dedicated nodes, compute the
+	// coefficients for charging them to the allocations of each node.
+	var dedicatedIdleCoefficients map[string]map[string]map[string]float64
+	var dedicatedTotalsMap map[string]map[string]float64
+	if dedicatedIdleSet.Length() > 0 {
+		dedicatedIdleCoefficients, dedicatedTotalsMap, err = computeIdleCoeffs(&AllocationAggregationOptions{IdleByNode: true}, as.dedicatedNodeAllocations(dedicatedIdleNodes), &AllocationSet{}, nil)
+		if err != nil {
+			return fmt.Errorf("error computing dedicated node idle coefficients: %s", err)
+		}
+	}
+
 	// (2c) Convert SharedHourlyCosts to Allocations in the shareSet. This must
 	// come after idle coefficients are computed so that allocations generated
 	// by shared overhead do not skew the idle coefficient computation.
@@ -1140,7 +1248,16 @@ func (as *AllocationSet) AggregateBy(aggregateBy []string, options *AllocationAg
 		// NOTE: if idle allocation is off (i.e. ShareIdle == ShareNone) then
 		// all idle allocations will be in the aggSet at this point, so idleSet
 		// will be empty and we won't enter this block.
-		if idleSet.Length() > 0 {
+		//
+		// Allocations of dedicated nodes with idle allocations of their own
+		// are charged those instead.
+		if nodeId := alloc.dedicatedNodeId(); dedicatedIdleNodes[nodeId] {
+			for _, idleAlloc := range dedicatedIdleSet.allocations {
+				if idleAlloc.dedicatedNodeId() == nodeId {
+					alloc.shareIdle(idleAlloc, dedicatedIdleCoefficients[nodeId][alloc.Name])
+				}
+			}
+		} else if idleSet.Length() > 0 {
 			// Distribute idle allocations by coefficient per-idleId, per-allocation
 			for _, idleAlloc := range idleSet.allocations {
 				// Only share idle if the idleId matches; i.e. the allocation
@@ -1385,6 +1502,60 @@ func (as *AllocationSet) AggregateBy(aggregateBy []string, options *AllocationAg
 		}
 	}
 
+	// (10b) Charge any idle costs of dedicated nodes which could not be
+	// distributed, because there is no usage of that resource type on the
+	// node, directly to the node's owner when aggregating by owner. Otherwise,
+	// add them back as idle, as in step (10).
+	aggregateByOwner := false
+	for _, agg := range aggregateBy {
+		if agg == AllocationOwnerProp {
+			aggregateByOwner = true
+		}
+	}
+	for _, idleAlloc := range dedicatedIdleSet.allocations {
+		nodeId := idleAlloc.dedicatedNodeId()
+
+		if dedicatedTotalsMap[nodeId]["cpu"] > 0 {
+			idleAlloc.CPUCost = 0
+			idleAlloc.CPUCoreHours = 0
+		}
+		if dedicatedTotalsMap[nodeId]["gpu"] > 0 {
+			idleAlloc.GPUCost = 0
+			idleAlloc.GPUHours = 0
+		}
+		if dedicatedTotalsMap[nodeId]["ram"] > 0 {
+			idleAlloc.RAMCost = 0
+			idleAlloc.RAMByteHours = 0
+		}
+		if idleAlloc.CPUCost == 0 && idleAlloc.GPUCost == 0 && idleAlloc.RAMCost == 0 {
+			continue
+		}
+
+		idleAlloc.Properties = idleAlloc.Properties.Clone()
+		if idleAlloc.Properties.Labels == nil {
+			idleAlloc.Properties.Labels = AllocationLabels{}
+		}
+		idleAlloc.Properties.Labels[NodeOwnerLabel] = nodeOwners[nodeId]
+
+		skip := false
+		for _, ff := range options.FilterFuncs {
+			if !ff(idleAlloc) {
+				skip = true
+				break
+			}
+		}
+		if skip {
+			continue
+		}
+
+		if aggregateByOwner {
+			idleAlloc.Name = idleAlloc.generateKey(aggregateBy, options.LabelConfig)
+		} else {
+			idleAlloc.Name = fmt.Sprintf("%s/%s", nodeId, IdleSuffix)
+		}
+		aggSet.Insert(idleAlloc)
+	}
+
 	// (11) Combine all idle allocations into a single "__idle__" allocation
 	if !options.SplitIdle {
 		for _, idleAlloc := range aggSet.IdleAllocations() {
@@ -1517,7 +1688,9 @@ func computeShareCoeffs(aggregateBy []string, options *AllocationAggregationOpti
 	return coeffs, nil
 }
 
-func computeIdleCoeffs(options *AllocationAggregationOptions, as *AllocationSet, shareSet *AllocationSet) (map[string]map[string]map[string]float64, map[string]map[string]float64, error) {
+// computeIdleCoeffs computes the coefficients for sharing idle costs, leaving
+// out the allocations of the given dedicated nodes.
+func computeIdleCoeffs(options *AllocationAggregationOptions, as *AllocationSet, shareSet *AllocationSet, dedicatedIdleNodes map[string]bool) (map[string]map[string]map[string]float64, map[string]map[string]float64, error) {
 	types := []string{"cpu", "gpu", "ram"}
 
 	// Compute idle coefficients, then save them in AllocationAggregationOptions
@@ -1534,6 +1707,10 @@ func computeIdleCoeffs(options *AllocationAggregationOptions, as *AllocationSet,
 			// Skip idle allocations in coefficient calculation
 			continue
 		}
+		if dedicatedIdleNodes[alloc.dedicatedNodeId()] {
+			// Skip allocations charged the idle of their dedicated node
+			continue
+		}
 
 		idleId, err := alloc.getIdleId(options)
 		if err != nil {
@@ -1635,6 +1812,116 @@ func (a *Allocation) getIdleId(options *AllocationAggregationOptions) (string, e
 	return idleId, nil
 }
 
+// dedicatedNodeId returns the cluster and node of an Allocation in the form
+// of an idle-by-node idleId, or an empty string if its node is unknown.
+func (a *Allocation) dedicatedNodeId() string {
+	if a.Properties == nil || a.Properties.Node == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s", a.Properties.Cluster, a.Properties.Node)
+}
+
+// shareIdle adds the given per-resource shares of an idle allocation to the
+// Allocation.
+func (a *Allocation) shareIdle(idleAlloc *Allocation, coeffs map[string]float64) {
+	a.CPUCoreHours += idleAlloc.CPUCoreHours * coeffs["cpu"]
+	a.GPUHours += idleAlloc.GPUHours * coeffs["gpu"]
+	a.RAMByteHours += idleAlloc.RAMByteHours * coeffs["ram"]
+
+	a.CPUCost += idleAlloc.CPUCost * coeffs["cpu"]
+	a.GPUCost += idleAlloc.GPUCost * coeffs["gpu"]
+	a.RAMCost += idleAlloc.RAMCost * coeffs["ram"]
+}
+
+// dedicatedNodeOwners returns the owners of the dedicated nodes of the
+// AllocationSet's allocations, as labelled by NodeOwnerLabel, by the
+// dedicatedNodeId of the nodes.
+func (as *AllocationSet) dedicatedNodeOwners() map[string]string {
+	owners := map[string]string{}
+	for _, alloc := range as.allocations {
+		nodeId := alloc.dedicatedNodeId()
+		if nodeId == "" {
+			continue
+		}
+		if owner := alloc.Properties.Labels[NodeOwnerLabel]; owner != "" {
+			owners[nodeId] = owner
+		}
+	}
+	return owners
+}
+
+// dedicatedNodeAllocations returns a set of the non-idle allocations of the
+// given dedicated nodes.
+func (as *AllocationSet) dedicatedNodeAllocations(dedicatedIdleNodes map[string]bool) *AllocationSet {
+	set := &AllocationSet{
+		allocations: map[string]*Allocation{},
+	}
+	for name, alloc := range as.allocations {
+		if !alloc.IsIdle() && dedicatedIdleNodes[alloc.dedicatedNodeId()] {
+			set.allocations[name] = alloc
+		}
+	}
+	return set
+}
+
+// isDaemonSet returns true if the Allocation belongs to a DaemonSet pod.
+func (a *Allocation) isDaemonSet() bool {
+	return a.Properties != nil && a.Properties.ControllerKind == "daemonset"
+}
+
+// shareDaemonSetsByNode removes the allocations of DaemonSet pods from the
+// AllocationSet and adds their costs, as shared costs, to the other
+// allocations of their nodes, in proportion to those allocations' costs.
+// DaemonSet allocations of nodes without other costs are left in place, as
+// there is nothing to share them with. Must be called with the lock held.
+func (as *AllocationSet) shareDaemonSetsByNode() {
+	daemonSets := map[string][]*Allocation{}
+	for _, alloc := range as.allocations {
+		if alloc.isDaemonSet() && !alloc.IsIdle() && !alloc.IsExternal() {
+			if nodeId := alloc.dedicatedNodeId(); nodeId != "" {
+				daemonSets[nodeId] = append(daemonSets[nodeId], alloc)
+			}
+		}
+	}
+	if len(daemonSets) == 0 {
+		return
+	}
+
+	coeffs := map[string]map[string]float64{}
+	totals := map[string]float64{}
+	for name, alloc := range as.allocations {
+		if alloc.isDaemonSet() || alloc.IsIdle() || alloc.IsExternal() || alloc.IsUnmounted() {
+			continue
+		}
+		nodeId := alloc.dedicatedNodeId()
+		if _, ok := daemonSets[nodeId]; !ok {
+			continue
+		}
+		if _, ok := coeffs[nodeId]; !ok {
+			coeffs[nodeId] = map[string]float64{}
+		}
+		cost := math.Max(alloc.TotalCost(), 0)
+		coeffs[nodeId][name] += cost
+		totals[nodeId] += cost
+	}
+
+	for nodeId, dsAllocs := range daemonSets {
+		if totals[nodeId] <= 0 {
+			continue
+		}
+
+		dsCost := 0.0
+		for _, dsAlloc := range dsAllocs {
+			dsCost += dsAlloc.TotalCost()
+			delete(as.allocations, dsAlloc.Name)
+		}
+
+		for name, cost := range coeffs[nodeId] {
+			as.allocations[name].SharedCost += dsCost * cost / totals[nodeId]
+		}
+	}
+}
+
 func (a *Allocation) generateKey(aggregateBy []string, labelConfig *LabelConfig) string {
 	if a == nil {
 		return ""
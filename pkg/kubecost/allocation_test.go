@@ -563,6 +563,89 @@ func TestAllocationSet_generateKey(t *testing.T) {
 	if key != "dept1/envt1/ownr1/prod1/team1/team2/__unallocated__" {
 		t.Fatalf("generateKey: expected \"dept1/envt1/ownr1/prod1/team1/team2/__unallocated__\"; actual \"%s\"", key)
 	}
+
+	// Namespaces in a hierarchy are aggregated by their root, path and tenant,
+	// and namespaces outside of any are their own root and path.
+
+	props = []string{
+		AllocationNamespaceRootProp,
+		AllocationNamespacePathProp,
+		AllocationTenantProp,
+	}
+
+	alloc.Properties = &AllocationProperties{
+		Cluster:   "cluster1",
+		Namespace: "team-a-dev",
+		Labels: map[string]string{
+			NamespaceRootLabel: "org",
+			NamespacePathLabel: "org/team-a/team-a-dev",
+			TenantLabel:        "tenant1",
+		},
+	}
+	key = alloc.generateKey(props, nil)
+	if key != "org/org/team-a/team-a-dev/tenant1" {
+		t.Fatalf("generateKey: expected \"org/org/team-a/team-a-dev/tenant1\"; actual \"%s\"", key)
+	}
+
+	alloc.Properties = &AllocationProperties{
+		Cluster:   "cluster1",
+		Namespace: "namespace1",
+	}
+	key = alloc.generateKey(props, nil)
+	if key != "namespace1/namespace1/__unallocated__" {
+		t.Fatalf("generateKey: expected \"namespace1/namespace1/__unallocated__\"; actual \"%s\"", key)
+	}
+
+	// Helm releases and GitOps applications are aggregated by their labels
+
+	props = []string{
+		AllocationHelmReleaseProp,
+		AllocationGitOpsAppProp,
+	}
+
+	alloc.Properties = &AllocationProperties{
+		Cluster:   "cluster1",
+		Namespace: "namespace1",
+		Labels: map[string]string{
+			HelmReleaseLabel: "release1",
+			GitOpsAppLabel:   "app1",
+		},
+	}
+	key = alloc.generateKey(props, nil)
+	if key != "release1/app1" {
+		t.Fatalf("generateKey: expected \"release1/app1\"; actual \"%s\"", key)
+	}
+
+	alloc.Properties = &AllocationProperties{
+		Cluster:   "cluster1",
+		Namespace: "namespace1",
+		Labels: map[string]string{
+			GitOpsAppLabel: "app1",
+		},
+	}
+	key = alloc.generateKey(props, nil)
+	if key != "__unallocated__/app1" {
+		t.Fatalf("generateKey: expected \"__unallocated__/app1\"; actual \"%s\"", key)
+	}
+
+	// Accelerator types are aggregated by their label
+
+	props = []string{
+		AllocationNamespaceProp,
+		AllocationAcceleratorTypeProp,
+	}
+
+	alloc.Properties = &AllocationProperties{
+		Cluster:   "cluster1",
+		Namespace: "namespace1",
+		Labels: map[string]string{
+			AcceleratorTypeLabel: "tpu",
+		},
+	}
+	key = alloc.generateKey(props, nil)
+	if key != "namespace1/tpu" {
+		t.Fatalf("generateKey: expected \"namespace1/tpu\"; actual \"%s\"", key)
+	}
 }
 
 func TestNewAllocationSet(t *testing.T) {
@@ -1604,6 +1687,247 @@ func TestAllocationSet_AggregateBy(t *testing.T) {
 	}
 }
 
+func TestAllocationSet_AggregateBy_DedicatedNodes(t *testing.T) {
+	start := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	newAlloc := func(name, node, namespace string, labels map[string]string, cpuCost, gpuCost, ramCost float64) *Allocation {
+		return &Allocation{
+			Name: name,
+			Properties: &AllocationProperties{
+				Cluster:   "cluster1",
+				Node:      node,
+				Namespace: namespace,
+				Labels:    labels,
+			},
+			Window:       NewWindow(&start, &end),
+			Start:        start,
+			End:          end,
+			CPUCoreHours: cpuCost,
+			CPUCost:      cpuCost,
+			GPUHours:     gpuCost,
+			GPUCost:      gpuCost,
+			RAMByteHours: ramCost,
+			RAMCost:      ramCost,
+		}
+	}
+
+	// node1 is dedicated to payments, so all of its allocations, including
+	// the DaemonSet pod in kube-system, are labelled with their node's owner.
+	// node2 is shared. Costs total 44.00:
+	//
+	//   node       allocation     cpu  gpu  ram
+	//   node1      payments       6.0  0.0  4.0
+	//   node1      kube-system    2.0  0.0  0.0
+	//   node1      __idle__       8.0  2.0  4.0
+	//   node2      team-b         4.0  0.0  4.0
+	//   node2      team-c         4.0  0.0  0.0
+	//   node2      __idle__       4.0  0.0  2.0
+	generateSet := func() *AllocationSet {
+		dedicated := map[string]string{NodeOwnerLabel: "payments"}
+		return NewAllocationSet(start, end,
+			newAlloc("cluster1/node1/payments/pod1/app", "node1", "payments", dedicated, 6, 0, 4),
+			newAlloc("cluster1/node1/kube-system/agent/agent", "node1", "kube-system", dedicated, 2, 0, 0),
+			newAlloc("cluster1/node1/__idle__", "node1", "", nil, 8, 2, 4),
+			newAlloc("cluster1/node2/team-b/pod1/app", "node2", "team-b", map[string]string{"owner": "team-b"}, 4, 0, 4),
+			newAlloc("cluster1/node2/team-c/pod1/app", "node2", "team-c", nil, 4, 0, 0),
+			newAlloc("cluster1/node2/__idle__", "node2", "", nil, 4, 0, 2),
+		)
+	}
+
+	isKubeSystem := func(a *Allocation) bool {
+		return a.Properties.Namespace == "kube-system"
+	}
+
+	cases := []struct {
+		name        string
+		aggregateBy []string
+		options     *AllocationAggregationOptions
+		expected    map[string]float64
+	}{
+		{
+			// The idle of node1 is charged to payments, including the GPU idle
+			// which no allocation of node1 uses, while the idle of node2 is
+			// shared by cluster among the allocations of shared nodes only
+			name:        "owner, sharing idle by cluster",
+			aggregateBy: []string{AllocationOwnerProp},
+			options:     &AllocationAggregationOptions{ShareIdle: ShareWeighted},
+			expected: map[string]float64{
+				"payments":        26.00,
+				"team-b":          12.00,
+				UnallocatedSuffix: 6.00,
+			},
+		},
+		{
+			name:        "owner, sharing idle by node",
+			aggregateBy: []string{AllocationOwnerProp},
+			options:     &AllocationAggregationOptions{ShareIdle: ShareWeighted, IdleByNode: true},
+			expected: map[string]float64{
+				"payments":        26.00,
+				"team-b":          12.00,
+				UnallocatedSuffix: 6.00,
+			},
+		},
+		{
+			// Dedicated nodes are charged their idle even when idle is not shared
+			name:        "owner, not sharing idle",
+			aggregateBy: []string{AllocationOwnerProp},
+			options:     &AllocationAggregationOptions{},
+			expected: map[string]float64{
+				"payments":        26.00,
+				"team-b":          8.00,
+				UnallocatedSuffix: 4.00,
+				IdleSuffix:        6.00,
+			},
+		},
+		{
+			// The allocations of node1 are charged its idle by their costs,
+			// and kube-system is not shared away from the dedicated node. The
+			// GPU idle of node1 remains idle, as owners are not aggregated.
+			name:        "namespace, sharing kube-system",
+			aggregateBy: []string{AllocationNamespaceProp},
+			options:     &AllocationAggregationOptions{ShareFuncs: []AllocationMatchFunc{isKubeSystem}},
+			expected: map[string]float64{
+				"payments":    20.00,
+				"kube-system": 4.00,
+				"team-b":      8.00,
+				"team-c":      4.00,
+				IdleSuffix:    8.00,
+			},
+		},
+	}
+
+	for _, test := range cases {
+		as := generateSet()
+		err := as.AggregateBy(test.aggregateBy, test.options)
+		assertAllocationSetTotals(t, as, test.name, err, len(test.expected), 44.00)
+		assertAllocationTotals(t, as, test.name, test.expected)
+	}
+
+	// Without dedicated nodes, the idle of node1 is shared like any other, and
+	// its GPU idle remains idle
+	as := generateSet()
+	as.Each(func(name string, a *Allocation) {
+		delete(a.Properties.Labels, NodeOwnerLabel)
+	})
+	err := as.AggregateBy([]string{AllocationOwnerProp}, &AllocationAggregationOptions{ShareIdle: ShareWeighted, IdleByNode: true})
+	assertAllocationSetTotals(t, as, "owner, no dedicated nodes", err, 3, 44.00)
+	assertAllocationTotals(t, as, "owner, no dedicated nodes", map[string]float64{
+		"team-b":          12.00,
+		UnallocatedSuffix: 30.00,
+		IdleSuffix:        2.00,
+	})
+}
+
+func TestAllocationSet_AggregateBy_ShareDaemonSetsByNode(t *testing.T) {
+	start := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	newAlloc := func(name, node, namespace, controllerKind string, cpuCost, ramCost float64) *Allocation {
+		return &Allocation{
+			Name: name,
+			Properties: &AllocationProperties{
+				Cluster:        "cluster1",
+				Node:           node,
+				Namespace:      namespace,
+				ControllerKind: controllerKind,
+			},
+			Window:       NewWindow(&start, &end),
+			Start:        start,
+			End:          end,
+			CPUCoreHours: cpuCost,
+			CPUCost:      cpuCost,
+			RAMByteHours: ramCost,
+			RAMCost:      ramCost,
+		}
+	}
+
+	// Costs total 24.00:
+	//
+	//   node       allocation     kind        cpu  ram
+	//   node1      team-a         deployment  4.0  2.0
+	//   node1      team-b         deployment  1.0  1.0
+	//   node1      kube-system    daemonset   3.0  1.0
+	//   node1      __idle__                   3.0  2.0
+	//   node2      team-a         deployment  2.0  2.0
+	//   node2      kube-system    daemonset   1.0  1.0
+	//   node3      kube-system    daemonset   1.0  0.0
+	generateSet := func() *AllocationSet {
+		return NewAllocationSet(start, end,
+			newAlloc("cluster1/node1/team-a/pod1/app", "node1", "team-a", "deployment", 4, 2),
+			newAlloc("cluster1/node1/team-b/pod1/app", "node1", "team-b", "deployment", 1, 1),
+			newAlloc("cluster1/node1/kube-system/agent-1/agent", "node1", "kube-system", "daemonset", 3, 1),
+			newAlloc("cluster1/node1/__idle__", "node1", "", "", 3, 2),
+			newAlloc("cluster1/node2/team-a/pod2/app", "node2", "team-a", "deployment", 2, 2),
+			newAlloc("cluster1/node2/kube-system/agent-2/agent", "node2", "kube-system", "daemonset", 1, 1),
+			newAlloc("cluster1/node3/kube-system/agent-3/agent", "node3", "kube-system", "daemonset", 1, 0),
+		)
+	}
+
+	cases := []struct {
+		name        string
+		aggregateBy []string
+		options     *AllocationAggregationOptions
+		expected    map[string]float64
+	}{
+		{
+			name:        "namespace, not sharing daemonsets",
+			aggregateBy: []string{AllocationNamespaceProp},
+			options:     &AllocationAggregationOptions{},
+			expected: map[string]float64{
+				"team-a":      10.00,
+				"team-b":      2.00,
+				"kube-system": 7.00,
+				IdleSuffix:    5.00,
+			},
+		},
+		{
+			// The daemonset of node1 is shared 6:2 between team-a and team-b,
+			// that of node2 goes to team-a, and that of node3 stays in place,
+			// as there is nothing else on node3 to share it with. Idle is
+			// never charged daemonset costs.
+			name:        "namespace, sharing daemonsets",
+			aggregateBy: []string{AllocationNamespaceProp},
+			options:     &AllocationAggregationOptions{ShareDaemonSetsByNode: true},
+			expected: map[string]float64{
+				"team-a":      15.00,
+				"team-b":      3.00,
+				"kube-system": 1.00,
+				IdleSuffix:    5.00,
+			},
+		},
+		{
+			name:        "controller kind, sharing daemonsets",
+			aggregateBy: []string{AllocationControllerKindProp},
+			options:     &AllocationAggregationOptions{ShareDaemonSetsByNode: true},
+			expected: map[string]float64{
+				"deployment": 18.00,
+				"daemonset":  1.00,
+				IdleSuffix:   5.00,
+			},
+		},
+	}
+
+	for _, test := range cases {
+		as := generateSet()
+		err := as.AggregateBy(test.aggregateBy, test.options)
+		assertAllocationSetTotals(t, as, test.name, err, len(test.expected), 24.00)
+		assertAllocationTotals(t, as, test.name, test.expected)
+	}
+
+	// The shared costs of daemonsets are accounted as SharedCost
+	as := generateSet()
+	err := as.AggregateBy([]string{AllocationNamespaceProp}, &AllocationAggregationOptions{ShareDaemonSetsByNode: true})
+	assertAllocationSetTotals(t, as, "shared cost, sharing daemonsets", err, 4, 24.00)
+	sharedCost := 0.0
+	as.Each(func(name string, a *Allocation) {
+		sharedCost += a.SharedCost
+	})
+	if math.Abs(sharedCost-6.00) > 0.0001 {
+		t.Fatalf("shared cost, sharing daemonsets: expected shared cost 6.00; actual %.2f", sharedCost)
+	}
+}
+
 // TODO niko/etl
 //func TestAllocationSet_Clone(t *testing.T) {}
 
@@ -10,29 +10,71 @@ import (
 )
 
 const (
-	AllocationNilProp            string = ""
-	AllocationClusterProp        string = "cluster"
-	AllocationNodeProp           string = "node"
-	AllocationContainerProp      string = "container"
-	AllocationControllerProp     string = "controller"
-	AllocationControllerKindProp string = "controllerKind"
-	AllocationNamespaceProp      string = "namespace"
-	AllocationPodProp            string = "pod"
-	AllocationProviderIDProp     string = "providerID"
-	AllocationServiceProp        string = "service"
-	AllocationLabelProp          string = "label"
-	AllocationAnnotationProp     string = "annotation"
-	AllocationDeploymentProp     string = "deployment"
-	AllocationStatefulSetProp    string = "statefulset"
-	AllocationDaemonSetProp      string = "daemonset"
-	AllocationJobProp            string = "job"
-	AllocationDepartmentProp     string = "department"
-	AllocationEnvironmentProp    string = "environment"
-	AllocationOwnerProp          string = "owner"
-	AllocationProductProp        string = "product"
-	AllocationTeamProp           string = "team"
+	AllocationNilProp             string = ""
+	AllocationClusterProp         string = "cluster"
+	AllocationNodeProp            string = "node"
+	AllocationContainerProp       string = "container"
+	AllocationControllerProp      string = "controller"
+	AllocationControllerKindProp  string = "controllerKind"
+	AllocationNamespaceProp       string = "namespace"
+	AllocationPodProp             string = "pod"
+	AllocationProviderIDProp      string = "providerID"
+	AllocationServiceProp         string = "service"
+	AllocationLabelProp           string = "label"
+	AllocationAnnotationProp      string = "annotation"
+	AllocationDeploymentProp      string = "deployment"
+	AllocationStatefulSetProp     string = "statefulset"
+	AllocationDaemonSetProp       string = "daemonset"
+	AllocationJobProp             string = "job"
+	AllocationDepartmentProp      string = "department"
+	AllocationEnvironmentProp     string = "environment"
+	AllocationOwnerProp           string = "owner"
+	AllocationProductProp         string = "product"
+	AllocationTeamProp            string = "team"
+	AllocationNamespaceRootProp   string = "namespaceRoot"
+	AllocationNamespacePathProp   string = "namespacePath"
+	AllocationTenantProp          string = "tenant"
+	AllocationHelmReleaseProp     string = "helmRelease"
+	AllocationGitOpsAppProp       string = "gitopsApp"
+	AllocationAcceleratorTypeProp string = "acceleratorType"
 )
 
+// Allocations are labelled with the position of their namespace in the
+// hierarchy of namespaces, e.g. of the Hierarchical Namespace Controller, and
+// with their namespace's tenant, e.g. a Capsule tenant. The namespaceRoot,
+// namespacePath and tenant properties aggregate by these labels.
+const (
+	NamespaceRootLabel = "kubecost_namespace_root"
+	NamespacePathLabel = "kubecost_namespace_path"
+	TenantLabel        = "kubecost_tenant"
+)
+
+// Allocations are labelled with the Helm release and the GitOps application,
+// e.g. an Argo CD Application or a Flux Kustomization, which deployed their
+// pod. The helmRelease and gitopsApp properties aggregate by these labels.
+const (
+	HelmReleaseLabel = "kubecost_helm_release"
+	GitOpsAppLabel   = "kubecost_gitops_app"
+)
+
+// AcceleratorTypeLabel is set on the allocations of containers requesting
+// GPUs or other accelerators to the type of the accelerators, e.g. "nvidia",
+// "amd", "intel", "tpu" or "neuron", and on the Node assets of nodes with
+// accelerators. The acceleratorType property aggregates by this label.
+const AcceleratorTypeLabel = "kubecost_accelerator_type"
+
+// NodeOwnerLabel is set on the allocations of nodes dedicated to an owner,
+// e.g. the node pool of a tenant, and takes precedence over the owner label
+// of the label config when aggregating by owner. All of a dedicated node's
+// costs, including its idle costs, are charged to its owner.
+const NodeOwnerLabel = "kubecost_node_owner"
+
+// ControlPlaneLabel is set to "true" on the allocations of the control-plane
+// nodes of self-managed clusters, e.g. kubeadm, RKE or OpenShift. The costs of
+// those nodes are reported as ClusterManagement assets, like the control-plane
+// fees of managed clusters, so the allocations are not charged for them.
+const ControlPlaneLabel = "kubecost_control_plane"
+
 func ParseProperty(text string) (string, error) {
 	switch strings.TrimSpace(strings.ToLower(text)) {
 	case "cluster":
@@ -75,6 +117,18 @@ func ParseProperty(text string) (string, error) {
 		return AllocationProductProp, nil
 	case "team":
 		return AllocationTeamProp, nil
+	case "namespaceroot":
+		return AllocationNamespaceRootProp, nil
+	case "namespacepath":
+		return AllocationNamespacePathProp, nil
+	case "tenant":
+		return AllocationTenantProp, nil
+	case "helmrelease":
+		return AllocationHelmReleaseProp, nil
+	case "gitopsapp":
+		return AllocationGitOpsAppProp, nil
+	case "acceleratortype":
+		return AllocationAcceleratorTypeProp, nil
 	}
 
 	if strings.HasPrefix(text, "label:") {
@@ -353,7 +407,9 @@ func (p *AllocationProperties) GenerateKey(aggregateBy []string, labelConfig *La
 				labelNames := strings.Split(labelConfig.OwnerLabel, ",")
 				for _, labelName := range labelNames {
 					labelName = labelConfig.Sanitize(labelName)
-					if labelValue, ok := labels[labelName]; ok {
+					if nodeOwner, ok := labels[NodeOwnerLabel]; ok && nodeOwner != "" {
+						names = append(names, nodeOwner)
+					} else if labelValue, ok := labels[labelName]; ok {
 						names = append(names, labelValue)
 					} else {
 						names = append(names, UnallocatedSuffix)
@@ -390,6 +446,37 @@ func (p *AllocationProperties) GenerateKey(aggregateBy []string, labelConfig *La
 					}
 				}
 			}
+		case agg == AllocationNamespaceRootProp || agg == AllocationNamespacePathProp:
+			// A namespace outside of any hierarchy is its own root and path
+			label := NamespaceRootLabel
+			if agg == AllocationNamespacePathProp {
+				label = NamespacePathLabel
+			}
+			if value, ok := p.Labels[label]; ok && value != "" {
+				names = append(names, value)
+			} else if p.Namespace != "" {
+				names = append(names, p.Namespace)
+			} else {
+				names = append(names, UnallocatedSuffix)
+			}
+		case agg == AllocationTenantProp:
+			if value, ok := p.Labels[TenantLabel]; ok && value != "" {
+				names = append(names, value)
+			} else {
+				names = append(names, UnallocatedSuffix)
+			}
+		case agg == AllocationHelmReleaseProp || agg == AllocationGitOpsAppProp || agg == AllocationAcceleratorTypeProp:
+			label := HelmReleaseLabel
+			if agg == AllocationGitOpsAppProp {
+				label = GitOpsAppLabel
+			} else if agg == AllocationAcceleratorTypeProp {
+				label = AcceleratorTypeLabel
+			}
+			if value, ok := p.Labels[label]; ok && value != "" {
+				names = append(names, value)
+			} else {
+				names = append(names, UnallocatedSuffix)
+			}
 		default:
 			// This case should never be reached, as input up until this point
 			// should be checked and rejected if invalid. But if we do get a
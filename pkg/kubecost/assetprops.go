@@ -99,6 +99,15 @@ const GCPProvider = "GCP"
 // AzureProvider describes the provider Azure
 const AzureProvider = "Azure"
 
+// AlibabaProvider describes the provider Alibaba Cloud
+const AlibabaProvider = "Alibaba"
+
+// DigitalOceanProvider describes the provider DigitalOcean
+const DigitalOceanProvider = "DigitalOcean"
+
+// IBMProvider describes the provider IBM Cloud
+const IBMProvider = "IBM"
+
 // CSVProvider describes the provider a CSV
 const CSVProvider = "CSV"
 
@@ -118,6 +127,12 @@ func ParseProvider(str string) string {
 		return GCPProvider
 	case "azure":
 		return AzureProvider
+	case "alibaba", "ack", "aliyun":
+		return AlibabaProvider
+	case "digitalocean", "doks":
+		return DigitalOceanProvider
+	case "ibm", "iks", "roks":
+		return IBMProvider
 	default:
 		return NilProvider
 	}
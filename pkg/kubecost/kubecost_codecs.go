@@ -41,7 +41,7 @@ const (
 	AssetsCodecVersion uint8 = 15
 
 	// AllocationCodecVersion is used for any resources listed in the Allocation version set
-	AllocationCodecVersion uint8 = 15
+	AllocationCodecVersion uint8 = 16
 )
 
 //--------------------------------------------------------------------------
@@ -406,6 +406,25 @@ func (target *Allocation) MarshalBinaryWithContext(ctx *EncodingContext) (err er
 		}
 		// --- [end][write][struct](RawAllocationOnlyData) ---
 
+	}
+	if target.ExtendedResourceCosts == nil {
+		buff.WriteUInt8(uint8(0)) // write nil byte
+	} else {
+		buff.WriteUInt8(uint8(1)) // write non-nil byte
+
+		// --- [begin][write][map](map[string]float64) ---
+		buff.WriteInt(len(target.ExtendedResourceCosts)) // map length
+		for vv, zz := range target.ExtendedResourceCosts {
+			if ctx.IsStringTable() {
+				b := ctx.Table.AddOrGet(vv)
+				buff.WriteInt(b) // write table index
+			} else {
+				buff.WriteString(vv) // write string
+			}
+			buff.WriteFloat64(zz) // write float64
+		}
+		// --- [end][write][map](map[string]float64) ---
+
 	}
 	return nil
 }
@@ -786,6 +805,40 @@ func (target *Allocation) UnmarshalBinaryWithContext(ctx *DecodingContext) (err
 
 	}
 
+	if uint8(16) /* field version */ <= version {
+		if buff.ReadUInt8() == uint8(0) {
+			target.ExtendedResourceCosts = nil
+		} else {
+			// --- [begin][read][map](map[string]float64) ---
+			uu := buff.ReadInt() // map len
+			tt := make(map[string]float64, uu)
+			for j := 0; j < uu; j++ {
+				var vv string
+				var xx string
+				if ctx.IsStringTable() {
+					yy := buff.ReadInt() // read string index
+					xx = ctx.Table[yy]
+				} else {
+					xx = buff.ReadString() // read string
+				}
+				ww := xx
+				vv = ww
+
+				var zz float64
+				aaa := buff.ReadFloat64() // read float64
+				zz = aaa
+
+				tt[vv] = zz
+			}
+			target.ExtendedResourceCosts = tt
+			// --- [end][read][map](map[string]float64) ---
+
+		}
+	} else {
+		target.ExtendedResourceCosts = nil
+
+	}
+
 	return nil
 }
 
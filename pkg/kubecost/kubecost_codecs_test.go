@@ -3,10 +3,51 @@ package kubecost
 import (
 	"testing"
 	"time"
+
+	"github.com/kubecost/opencost/pkg/util"
 )
 
 func TestAllocation_BinaryEncoding(t *testing.T) {
-	// TODO niko/etl
+	start := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	a0 := NewMockUnitAllocation("cluster1/node1/namespace1/pod1/container1", start, day, nil)
+	a0.ExtendedResourceCosts = map[string]float64{
+		"hugepages_1Gi":             0.24,
+		"intel_com_sriov_netdevice": 1.50,
+	}
+
+	bs, err := a0.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Allocation.Binary: unexpected error: %s", err)
+	}
+
+	a1 := &Allocation{}
+	err = a1.UnmarshalBinary(bs)
+	if err != nil {
+		t.Fatalf("Allocation.Binary: unexpected error: %s", err)
+	}
+
+	if !a0.Equal(a1) {
+		t.Fatalf("Allocation.Binary: expected %v; found %v", a0, a1)
+	}
+	if !util.IsApproximately(a1.ExtendedResourceTotalCost(), 1.74) {
+		t.Fatalf("Allocation.Binary: expected extended resource cost 1.74; found %f", a1.ExtendedResourceTotalCost())
+	}
+
+	// Allocations without extended resources decode without them
+	a0.ExtendedResourceCosts = nil
+	bs, err = a0.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Allocation.Binary: unexpected error: %s", err)
+	}
+	a1 = &Allocation{}
+	err = a1.UnmarshalBinary(bs)
+	if err != nil {
+		t.Fatalf("Allocation.Binary: unexpected error: %s", err)
+	}
+	if a1.ExtendedResourceCosts != nil {
+		t.Fatalf("Allocation.Binary: expected no extended resource costs; found %v", a1.ExtendedResourceCosts)
+	}
 }
 
 func TestAllocationSet_BinaryEncoding(t *testing.T) {
@@ -35,6 +35,7 @@ type SummaryAllocation struct {
 	RAMCost                float64               `json:"ramCost"`
 	SharedCost             float64               `json:"sharedCost"`
 	ExternalCost           float64               `json:"externalCost"`
+	ExtendedResourceCost   float64               `json:"extendedResourceCost"`
 	Share                  bool                  `json:"-"`
 }
 
@@ -64,6 +65,7 @@ func NewSummaryAllocation(alloc *Allocation, reconcile, reconcileNetwork bool) *
 		RAMCost:                alloc.RAMCost + alloc.RAMCostAdjustment,
 		SharedCost:             alloc.SharedCost,
 		ExternalCost:           alloc.ExternalCost,
+		ExtendedResourceCost:   alloc.ExtendedResourceTotalCost(),
 	}
 
 	// Revert adjustments if reconciliation is off. If only network
@@ -140,6 +142,7 @@ func (sa *SummaryAllocation) Add(that *SummaryAllocation) error {
 	sa.PVCost += that.PVCost
 	sa.RAMCost += that.RAMCost
 	sa.SharedCost += that.SharedCost
+	sa.ExtendedResourceCost += that.ExtendedResourceCost
 
 	return nil
 }
@@ -163,6 +166,7 @@ func (sa *SummaryAllocation) Clone() *SummaryAllocation {
 		RAMCost:                sa.RAMCost,
 		SharedCost:             sa.SharedCost,
 		ExternalCost:           sa.ExternalCost,
+		ExtendedResourceCost:   sa.ExtendedResourceCost,
 	}
 }
 
@@ -266,7 +270,7 @@ func (sa *SummaryAllocation) TotalCost() float64 {
 		return 0.0
 	}
 
-	return sa.CPUCost + sa.GPUCost + sa.RAMCost + sa.PVCost + sa.NetworkCost + sa.LoadBalancerCost + sa.SharedCost + sa.ExternalCost
+	return sa.CPUCost + sa.GPUCost + sa.RAMCost + sa.PVCost + sa.NetworkCost + sa.LoadBalancerCost + sa.SharedCost + sa.ExternalCost + sa.ExtendedResourceCost
 }
 
 // TotalEfficiency is the cost-weighted average of CPU and RAM efficiency. If
@@ -112,6 +112,12 @@ func ParseWindowWithOffset(window string, offset time.Duration) (Window, error)
 	return parseWindow(window, now)
 }
 
+// ParseWindowAt parses the given window string relative to the given moment
+// in time, within the context of the timezone of its location.
+func ParseWindowAt(window string, now time.Time) (Window, error) {
+	return parseWindow(window, now)
+}
+
 // parseWindow generalizes the parsing of window strings, relative to a given
 // moment in time, defined as "now".
 func parseWindow(window string, now time.Time) (Window, error) {
@@ -324,6 +324,31 @@ func TestParseWindowUTC(t *testing.T) {
 	}
 }
 
+func TestParseWindowAt(t *testing.T) {
+	now := time.Date(2022, time.June, 2, 15, 30, 0, 0, time.UTC)
+
+	yesterday, err := ParseWindowAt("yesterday", now)
+	if err != nil {
+		t.Fatalf(`unexpected error parsing "yesterday": %s`, err)
+	}
+	expStart := time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC)
+	expEnd := time.Date(2022, time.June, 2, 0, 0, 0, 0, time.UTC)
+	if !yesterday.Start().Equal(expStart) || !yesterday.End().Equal(expEnd) {
+		t.Fatalf(`expect: window "yesterday" to be [%s, %s); actual: %s`, expStart, expEnd, yesterday)
+	}
+
+	// Relative to a time in UTC-07:00, "today" starts at 07:00 UTC
+	loc := time.FixedZone("", -7*60*60)
+	today, err := ParseWindowAt("today", now.In(loc))
+	if err != nil {
+		t.Fatalf(`unexpected error parsing "today": %s`, err)
+	}
+	expStart = time.Date(2022, time.June, 2, 7, 0, 0, 0, time.UTC)
+	if !today.Start().Equal(expStart) || today.Duration() != 24*time.Hour {
+		t.Fatalf(`expect: window "today" to start at %s and last 24h; actual: %s`, expStart, today)
+	}
+}
+
 func TestParseWindowWithOffsetString(t *testing.T) {
 	// ParseWindowWithOffsetString should equal ParseWindowUTC when location == "UTC"
 	// for all window string formats
@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"fmt"
+
 	"github.com/kubecost/opencost/pkg/clustercache"
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
@@ -30,6 +32,12 @@ func (kjc KubeJobCollector) Describe(ch chan<- *prometheus.Desc) {
 	}
 
 	ch <- prometheus.NewDesc("kube_job_status_failed", "The number of pods which reached Phase Failed and the reason for failure.", []string{}, nil)
+	if _, disabled := disabledMetrics["kube_job_status_succeeded"]; !disabled {
+		ch <- prometheus.NewDesc("kube_job_status_succeeded", "The number of pods which reached Phase Succeeded.", []string{}, nil)
+	}
+	if _, disabled := disabledMetrics["kube_job_owner"]; !disabled {
+		ch <- prometheus.NewDesc("kube_job_owner", "Information about the Job's owner.", []string{}, nil)
+	}
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
@@ -44,6 +52,16 @@ func (kjc KubeJobCollector) Collect(ch chan<- prometheus.Metric) {
 		jobName := job.GetName()
 		jobNS := job.GetNamespace()
 
+		if _, disabled := disabledMetrics["kube_job_status_succeeded"]; !disabled {
+			ch <- newKubeJobStatusSucceededMetric(jobName, jobNS, "kube_job_status_succeeded", float64(job.Status.Succeeded))
+		}
+
+		if _, disabled := disabledMetrics["kube_job_owner"]; !disabled {
+			for _, owner := range job.OwnerReferences {
+				ch <- newKubeJobOwnerMetric(jobName, jobNS, "kube_job_owner", owner.Name, owner.Kind, owner.Controller != nil && *owner.Controller)
+			}
+		}
+
 		if job.Status.Failed == 0 {
 			ch <- newKubeJobStatusFailedMetric(jobName, jobNS, "kube_job_status_failed", "", 0)
 		} else {
@@ -126,3 +144,131 @@ func (kjsf KubeJobStatusFailedMetric) Write(m *dto.Metric) error {
 	}
 	return nil
 }
+
+//--------------------------------------------------------------------------
+//  KubeJobStatusSucceededMetric
+//--------------------------------------------------------------------------
+
+// KubeJobStatusSucceededMetric
+type KubeJobStatusSucceededMetric struct {
+	fqName    string
+	help      string
+	job       string
+	namespace string
+	value     float64
+}
+
+// Creates a new KubeJobStatusSucceededMetric, implementation of prometheus.Metric
+func newKubeJobStatusSucceededMetric(job, namespace, fqName string, value float64) KubeJobStatusSucceededMetric {
+	return KubeJobStatusSucceededMetric{
+		fqName:    fqName,
+		help:      "kube_job_status_succeeded Succeeded job",
+		job:       job,
+		namespace: namespace,
+		value:     value,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (kjss KubeJobStatusSucceededMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"job_name":  kjss.job,
+		"namespace": kjss.namespace,
+	}
+	return prometheus.NewDesc(kjss.fqName, kjss.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (kjss KubeJobStatusSucceededMetric) Write(m *dto.Metric) error {
+	m.Gauge = &dto.Gauge{
+		Value: &kjss.value,
+	}
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("job_name"),
+			Value: &kjss.job,
+		},
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &kjss.namespace,
+		},
+	}
+	return nil
+}
+
+//--------------------------------------------------------------------------
+//  KubeJobOwnerMetric
+//--------------------------------------------------------------------------
+
+// KubeJobOwnerMetric is a prometheus.Metric used to encode the owner of a Job, e.g. its CronJob
+type KubeJobOwnerMetric struct {
+	fqName       string
+	help         string
+	job          string
+	namespace    string
+	ownerName    string
+	ownerKind    string
+	isController bool
+}
+
+// Creates a new KubeJobOwnerMetric, implementation of prometheus.Metric
+func newKubeJobOwnerMetric(job, namespace, fqName, ownerName, ownerKind string, isController bool) KubeJobOwnerMetric {
+	return KubeJobOwnerMetric{
+		fqName:       fqName,
+		help:         "kube_job_owner Information about the Job's owner",
+		job:          job,
+		namespace:    namespace,
+		ownerName:    ownerName,
+		ownerKind:    ownerKind,
+		isController: isController,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (kjo KubeJobOwnerMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"job_name":            kjo.job,
+		"namespace":           kjo.namespace,
+		"owner_name":          kjo.ownerName,
+		"owner_kind":          kjo.ownerKind,
+		"owner_is_controller": fmt.Sprintf("%t", kjo.isController),
+	}
+	return prometheus.NewDesc(kjo.fqName, kjo.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (kjo KubeJobOwnerMetric) Write(m *dto.Metric) error {
+	v := float64(1)
+	m.Gauge = &dto.Gauge{
+		Value: &v,
+	}
+
+	isController := fmt.Sprintf("%t", kjo.isController)
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("job_name"),
+			Value: &kjo.job,
+		},
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &kjo.namespace,
+		},
+		{
+			Name:  toStringPtr("owner_name"),
+			Value: &kjo.ownerName,
+		},
+		{
+			Name:  toStringPtr("owner_kind"),
+			Value: &kjo.ownerKind,
+		},
+		{
+			Name:  toStringPtr("owner_is_controller"),
+			Value: &isController,
+		},
+	}
+	return nil
+}
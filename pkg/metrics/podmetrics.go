@@ -87,6 +87,15 @@ func (kpmc KubePodCollector) Describe(ch chan<- *prometheus.Desc) {
 	if _, disabled := disabledMetrics["kube_pod_container_resource_requests"]; !disabled {
 		ch <- prometheus.NewDesc("kube_pod_container_resource_requests", "The number of requested resource by a container", []string{}, nil)
 	}
+	if _, disabled := disabledMetrics["kube_pod_init_container_resource_requests"]; !disabled {
+		ch <- prometheus.NewDesc("kube_pod_init_container_resource_requests", "The number of requested resource by an init container", []string{}, nil)
+	}
+	if _, disabled := disabledMetrics["kube_pod_container_info"]; !disabled {
+		ch <- prometheus.NewDesc("kube_pod_container_info", "Information about a container in a pod.", []string{}, nil)
+	}
+	if _, disabled := disabledMetrics["kube_pod_init_container_info"]; !disabled {
+		ch <- prometheus.NewDesc("kube_pod_init_container_info", "Information about an init container in a pod.", []string{}, nil)
+	}
 	if _, disabled := disabledMetrics["kube_pod_container_resource_limits"]; !disabled {
 		ch <- prometheus.NewDesc("kube_pod_container_resource_limits", "The number of requested limit resource by a container.", []string{}, nil)
 	}
@@ -99,6 +108,12 @@ func (kpmc KubePodCollector) Describe(ch chan<- *prometheus.Desc) {
 	if _, disabled := disabledMetrics["kube_pod_status_phase"]; !disabled {
 		ch <- prometheus.NewDesc("kube_pod_status_phase", "The pods current phase.", []string{}, nil)
 	}
+	if _, disabled := disabledMetrics["kube_pod_status_qos_class"]; !disabled {
+		ch <- prometheus.NewDesc("kube_pod_status_qos_class", "The pods current qosClass.", []string{}, nil)
+	}
+	if _, disabled := disabledMetrics["kube_pod_info"]; !disabled {
+		ch <- prometheus.NewDesc("kube_pod_info", "Information about pod.", []string{}, nil)
+	}
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
@@ -133,6 +148,18 @@ func (kpmc KubePodCollector) Collect(ch chan<- prometheus.Metric) {
 			}
 		}
 
+		// Pod QoS Class
+		if _, disabled := disabledMetrics["kube_pod_status_qos_class"]; !disabled {
+			if pod.Status.QOSClass != "" {
+				ch <- newKubePodStatusQoSClassMetric("kube_pod_status_qos_class", podNS, podName, podUID, string(pod.Status.QOSClass))
+			}
+		}
+
+		// Pod Info
+		if _, disabled := disabledMetrics["kube_pod_info"]; !disabled {
+			ch <- newKubePodInfoMetric("kube_pod_info", podNS, podName, podUID, node, pod.Spec.PriorityClassName)
+		}
+
 		// Pod Labels
 		if _, disabled := disabledMetrics["kube_pod_labels"]; !disabled {
 			labelNames, labelValues := prom.KubePrependQualifierToLabels(pod.GetLabels(), "label_")
@@ -170,8 +197,44 @@ func (kpmc KubePodCollector) Collect(ch chan<- prometheus.Metric) {
 			}
 		}
 
+		// Init containers hold their requests while they run, so the pod's effective request is the larger of
+		// the sum of its containers' requests and the largest init container request.
+		for _, container := range pod.Spec.InitContainers {
+			if _, disabled := disabledMetrics["kube_pod_init_container_info"]; !disabled {
+				ch <- newKubePodContainerInfoMetric("kube_pod_init_container_info", podNS, podName, podUID, container.Name, container.Image)
+			}
+
+			if _, disabled := disabledMetrics["kube_pod_init_container_resource_requests"]; !disabled {
+				for resourceName, quantity := range container.Resources.Requests {
+					resource, unit, value := toResourceUnitValue(resourceName, quantity)
+
+					// failed to parse the resource type
+					if resource == "" {
+						log.DedupedWarningf(5, "Failed to parse resource units and quantity for resource: %s", resourceName)
+						continue
+					}
+
+					ch <- newKubePodContainerResourceRequestsMetric(
+						"kube_pod_init_container_resource_requests",
+						podNS,
+						podName,
+						podUID,
+						container.Name,
+						node,
+						resource,
+						unit,
+						value)
+				}
+			}
+		}
+
 		for _, container := range pod.Spec.Containers {
 
+			// Info
+			if _, disabled := disabledMetrics["kube_pod_container_info"]; !disabled {
+				ch <- newKubePodContainerInfoMetric("kube_pod_container_info", podNS, podName, podUID, container.Name, container.Image)
+			}
+
 			// Requests
 			if _, disabled := disabledMetrics["kube_pod_container_resource_requests"]; !disabled {
 				for resourceName, quantity := range container.Resources.Requests {
@@ -600,6 +663,149 @@ func (kpcs KubePodStatusPhaseMetric) Write(m *dto.Metric) error {
 	return nil
 }
 
+//--------------------------------------------------------------------------
+//  KubePodStatusQoSClassMetric
+//--------------------------------------------------------------------------
+
+// KubePodStatusQoSClassMetric is a prometheus.Metric emitting the QoS class of a pod
+type KubePodStatusQoSClassMetric struct {
+	fqName    string
+	help      string
+	pod       string
+	namespace string
+	uid       string
+	qosClass  string
+}
+
+// Creates a new KubePodStatusQoSClassMetric, implementation of prometheus.Metric
+func newKubePodStatusQoSClassMetric(fqname, namespace, pod, uid, qosClass string) KubePodStatusQoSClassMetric {
+	return KubePodStatusQoSClassMetric{
+		fqName:    fqname,
+		help:      "kube_pod_status_qos_class The pods current qosClass.",
+		pod:       pod,
+		namespace: namespace,
+		uid:       uid,
+		qosClass:  qosClass,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (kpsq KubePodStatusQoSClassMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"namespace": kpsq.namespace,
+		"pod":       kpsq.pod,
+		"uid":       kpsq.uid,
+		"qos_class": kpsq.qosClass,
+	}
+	return prometheus.NewDesc(kpsq.fqName, kpsq.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data transmission object.
+func (kpsq KubePodStatusQoSClassMetric) Write(m *dto.Metric) error {
+	v := float64(1)
+	m.Gauge = &dto.Gauge{
+		Value: &v,
+	}
+
+	var labels []*dto.LabelPair
+	labels = append(labels,
+		&dto.LabelPair{
+			Name:  toStringPtr("namespace"),
+			Value: &kpsq.namespace,
+		},
+		&dto.LabelPair{
+			Name:  toStringPtr("pod"),
+			Value: &kpsq.pod,
+		},
+		&dto.LabelPair{
+			Name:  toStringPtr("uid"),
+			Value: &kpsq.uid,
+		},
+		&dto.LabelPair{
+			Name:  toStringPtr("qos_class"),
+			Value: &kpsq.qosClass,
+		},
+	)
+	m.Label = labels
+	return nil
+}
+
+//--------------------------------------------------------------------------
+//  KubePodInfoMetric
+//--------------------------------------------------------------------------
+
+// KubePodInfoMetric is a prometheus.Metric emitting the node and priority class of a pod
+type KubePodInfoMetric struct {
+	fqName        string
+	help          string
+	pod           string
+	namespace     string
+	uid           string
+	node          string
+	priorityClass string
+}
+
+// Creates a new KubePodInfoMetric, implementation of prometheus.Metric
+func newKubePodInfoMetric(fqname, namespace, pod, uid, node, priorityClass string) KubePodInfoMetric {
+	return KubePodInfoMetric{
+		fqName:        fqname,
+		help:          "kube_pod_info Information about pod.",
+		pod:           pod,
+		namespace:     namespace,
+		uid:           uid,
+		node:          node,
+		priorityClass: priorityClass,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (kpi KubePodInfoMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"namespace":      kpi.namespace,
+		"pod":            kpi.pod,
+		"uid":            kpi.uid,
+		"node":           kpi.node,
+		"priority_class": kpi.priorityClass,
+	}
+	return prometheus.NewDesc(kpi.fqName, kpi.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data transmission object.
+func (kpi KubePodInfoMetric) Write(m *dto.Metric) error {
+	v := float64(1)
+	m.Gauge = &dto.Gauge{
+		Value: &v,
+	}
+
+	var labels []*dto.LabelPair
+	labels = append(labels,
+		&dto.LabelPair{
+			Name:  toStringPtr("namespace"),
+			Value: &kpi.namespace,
+		},
+		&dto.LabelPair{
+			Name:  toStringPtr("pod"),
+			Value: &kpi.pod,
+		},
+		&dto.LabelPair{
+			Name:  toStringPtr("uid"),
+			Value: &kpi.uid,
+		},
+		&dto.LabelPair{
+			Name:  toStringPtr("node"),
+			Value: &kpi.node,
+		},
+		&dto.LabelPair{
+			Name:  toStringPtr("priority_class"),
+			Value: &kpi.priorityClass,
+		},
+	)
+	m.Label = labels
+	return nil
+}
+
 //--------------------------------------------------------------------------
 //  KubePodContainerStatusRunningMetric
 //--------------------------------------------------------------------------
@@ -693,7 +899,7 @@ type KubePodContainerResourceRequestsMetric struct {
 func newKubePodContainerResourceRequestsMetric(fqname, namespace, pod, uid, container, node, resource, unit string, value float64) KubePodContainerResourceRequestsMetric {
 	return KubePodContainerResourceRequestsMetric{
 		fqName:    fqname,
-		help:      "kube_pod_container_resource_requests pods container resource requests",
+		help:      fqname + " pods container resource requests",
 		pod:       pod,
 		namespace: namespace,
 		uid:       uid,
@@ -760,6 +966,80 @@ func (kpcrr KubePodContainerResourceRequestsMetric) Write(m *dto.Metric) error {
 	return nil
 }
 
+//--------------------------------------------------------------------------
+//  KubePodContainerInfoMetric
+//--------------------------------------------------------------------------
+
+// KubePodContainerInfoMetric is a prometheus.Metric used to encode the image of a container
+type KubePodContainerInfoMetric struct {
+	fqName    string
+	help      string
+	pod       string
+	namespace string
+	container string
+	uid       string
+	image     string
+}
+
+// Creates a new KubePodContainerInfoMetric, implementation of prometheus.Metric
+func newKubePodContainerInfoMetric(fqname, namespace, pod, uid, container, image string) KubePodContainerInfoMetric {
+	return KubePodContainerInfoMetric{
+		fqName:    fqname,
+		help:      fqname + " pods container info",
+		pod:       pod,
+		namespace: namespace,
+		uid:       uid,
+		container: container,
+		image:     image,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (kpci KubePodContainerInfoMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"namespace": kpci.namespace,
+		"pod":       kpci.pod,
+		"uid":       kpci.uid,
+		"container": kpci.container,
+		"image":     kpci.image,
+	}
+	return prometheus.NewDesc(kpci.fqName, kpci.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (kpci KubePodContainerInfoMetric) Write(m *dto.Metric) error {
+	v := float64(1)
+	m.Gauge = &dto.Gauge{
+		Value: &v,
+	}
+
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &kpci.namespace,
+		},
+		{
+			Name:  toStringPtr("pod"),
+			Value: &kpci.pod,
+		},
+		{
+			Name:  toStringPtr("container"),
+			Value: &kpci.container,
+		},
+		{
+			Name:  toStringPtr("uid"),
+			Value: &kpci.uid,
+		},
+		{
+			Name:  toStringPtr("image"),
+			Value: &kpci.image,
+		},
+	}
+	return nil
+}
+
 //--------------------------------------------------------------------------
 //  KubePodContainerResourceLimitsMetric
 //--------------------------------------------------------------------------
@@ -1043,7 +1323,7 @@ func (kpo KubePodOwnerMetric) Desc() *prometheus.Desc {
 // Write encodes the Metric into a "Metric" Protocol Buffer data
 // transmission object.
 func (kpo KubePodOwnerMetric) Write(m *dto.Metric) error {
-	v := float64(1.0)
+	v := float64(1)
 	m.Gauge = &dto.Gauge{
 		Value: &v,
 	}
@@ -0,0 +1,78 @@
+package opencost
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/kubecost"
+)
+
+// newExampleComputer creates a Computer of the recorded metrics of
+// testdata/prometheus.json, as of noon on the day after they were recorded.
+func newExampleComputer() *Computer {
+	client, err := newFixtureClient("testdata/prometheus.json")
+	if err != nil {
+		panic(err)
+	}
+
+	c, err := New(Options{
+		Prometheus: client,
+		Provider:   fixtureProvider{},
+		Config:     Config{ClusterID: "cluster-one"},
+		Clock:      FixedClock(time.Date(2022, 6, 2, 12, 0, 0, 0, time.UTC)),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+func ExampleComputer_ComputeAllocation() {
+	c := newExampleComputer()
+
+	window, err := c.ParseWindow("yesterday")
+	if err != nil {
+		panic(err)
+	}
+
+	as, err := c.ComputeAllocation(*window.Start(), *window.End(), time.Hour)
+	if err != nil {
+		panic(err)
+	}
+
+	err = as.AggregateBy([]string{kubecost.AllocationNamespaceProp}, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, namespace := range []string{"batch", "web"} {
+		alloc := as.Get(namespace)
+		fmt.Printf("%s: %.0f minutes, CPU $%.2f, RAM $%.2f, total $%.2f\n", namespace, alloc.Minutes(), alloc.CPUCost, alloc.RAMCost, alloc.TotalCost())
+	}
+	// Output:
+	// batch: 420 minutes, CPU $0.28, RAM $0.07, total $0.35
+	// web: 1440 minutes, CPU $0.48, RAM $0.12, total $0.60
+}
+
+func ExampleComputer_ComputeAssets() {
+	c := newExampleComputer()
+
+	window, err := c.ParseWindow("yesterday")
+	if err != nil {
+		panic(err)
+	}
+
+	assets, err := c.ComputeAssets(*window.Start(), *window.End())
+	if err != nil {
+		panic(err)
+	}
+
+	assets.Each(func(key string, a kubecost.Asset) {
+		if node, ok := a.(*kubecost.Node); ok {
+			fmt.Printf("%s: %.0f minutes, CPU $%.2f, RAM $%.2f, total $%.2f\n", node.Properties().Name, node.Minutes(), node.CPUCost, node.RAMCost, node.TotalCost())
+		}
+	})
+	// Output:
+	// node-1: 1440 minutes, CPU $1.92, RAM $0.96, total $2.88
+}
@@ -0,0 +1,194 @@
+// Package opencost computes the allocations and assets of a cluster, from its
+// metrics in Prometheus, without running the cost-model server. Everything the
+// computation depends on is given explicitly as Options; unlike the server,
+// nothing is read from the environment.
+package opencost
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/cloud"
+	"github.com/kubecost/opencost/pkg/clustercache"
+	"github.com/kubecost/opencost/pkg/costmodel"
+	"github.com/kubecost/opencost/pkg/kubecost"
+
+	prometheus "github.com/prometheus/client_golang/api"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultClusterLabel is the Prometheus label of the cluster of metrics, if
+// Config does not set one.
+const DefaultClusterLabel = "cluster_id"
+
+// DefaultMaxQueryDuration is the longest duration queried at once, if Config
+// does not set one.
+const DefaultMaxQueryDuration = 24 * time.Hour
+
+// Clock tells the time, relative to which windows such as "yesterday" are
+// parsed, and after which windows may not start.
+type Clock interface {
+	Now() time.Time
+}
+
+type clockFunc func() time.Time
+
+func (cf clockFunc) Now() time.Time {
+	return cf()
+}
+
+// SystemClock is the Clock of the system time.
+var SystemClock Clock = clockFunc(time.Now)
+
+// FixedClock returns a Clock which always tells the given time, e.g. the end
+// of the recorded data from which costs are computed.
+func FixedClock(t time.Time) Clock {
+	return clockFunc(func() time.Time { return t })
+}
+
+// Config is the configuration of the computation which the cost-model server
+// otherwise reads from the environment.
+type Config struct {
+	// ClusterID is the cluster of metrics without a ClusterLabel.
+	ClusterID string
+	// ClusterLabel is the Prometheus label of the cluster of metrics.
+	// Defaults to DefaultClusterLabel.
+	ClusterLabel string
+	// MaxQueryDuration is the longest duration queried at once. Longer
+	// windows are computed piecewise and accumulated. Defaults to
+	// DefaultMaxQueryDuration.
+	MaxQueryDuration time.Duration
+	// IngestPodUID distinguishes pods of the same name by their UID, as
+	// INGEST_POD_UID does for the server.
+	IngestPodUID bool
+	// UTCOffset is the offset of the timezone in which windows such as
+	// "today" are parsed.
+	UTCOffset time.Duration
+	// PriceSchedule varies the prices of nodes over time, as the
+	// price-schedule.json file of the server's config path does. Optional.
+	PriceSchedule *cloud.PriceSchedule
+	// NodeOwnerRules dedicate nodes to owners, as the node-owners.json file
+	// of the server's config path does. Optional.
+	NodeOwnerRules *cloud.NodeOwnerRules
+	// PrometheusQueryOffset is subtracted from the time of queries which are
+	// not given one, as PROMETHEUS_QUERY_OFFSET does for the server.
+	PrometheusQueryOffset time.Duration
+}
+
+// Options are the dependencies of a Computer.
+type Options struct {
+	// Prometheus is the client of the Prometheus, or compatible API, which
+	// has the metrics of the cluster. Required.
+	Prometheus prometheus.Client
+	// ClusterCache has the objects of the cluster, e.g. a ClusterImporter of
+	// a snapshot. Optional; without it, allocations are computed from the
+	// metrics alone.
+	ClusterCache clustercache.ClusterCache
+	// Provider prices the nodes, disks and load balancers of the cluster.
+	// Required.
+	Provider cloud.Provider
+	Config   Config
+	// Clock defaults to SystemClock.
+	Clock Clock
+}
+
+// Computer computes the allocations and assets of a cluster. It is safe for
+// concurrent use.
+type Computer struct {
+	model     *costmodel.CostModel
+	clock     Clock
+	utcOffset time.Duration
+}
+
+// New creates a new Computer from the given Options, returning an error if a
+// required option is missing.
+func New(opts Options) (*Computer, error) {
+	if opts.Prometheus == nil {
+		return nil, fmt.Errorf("opencost: Prometheus client is required")
+	}
+	if opts.Provider == nil {
+		return nil, fmt.Errorf("opencost: Provider is required")
+	}
+
+	cfg := opts.Config
+	if cfg.ClusterLabel == "" {
+		cfg.ClusterLabel = DefaultClusterLabel
+	}
+	if cfg.MaxQueryDuration <= 0 {
+		cfg.MaxQueryDuration = DefaultMaxQueryDuration
+	}
+
+	clock := opts.Clock
+	if clock == nil {
+		clock = SystemClock
+	}
+
+	return &Computer{
+		model: &costmodel.CostModel{
+			Cache:                      opts.ClusterCache,
+			MaxPrometheusQueryDuration: cfg.MaxQueryDuration,
+			RequestGroup:               new(singleflight.Group),
+			PrometheusClient:           opts.Prometheus,
+			Provider:                   opts.Provider,
+			ClusterID:                  cfg.ClusterID,
+			ClusterLabel:               cfg.ClusterLabel,
+			IngestPodUID:               cfg.IngestPodUID,
+			PriceSchedule:              func() *cloud.PriceSchedule { return cfg.PriceSchedule },
+			NodeOwnerRules:             func() *cloud.NodeOwnerRules { return cfg.NodeOwnerRules },
+			PrometheusQueryOffset:      cfg.PrometheusQueryOffset,
+			Clock:                      clock.Now,
+		},
+		clock:     clock,
+		utcOffset: cfg.UTCOffset,
+	}, nil
+}
+
+// ParseWindow parses the given window, e.g. "yesterday", "7d" or
+// "2022-06-01T00:00:00Z,2022-06-02T00:00:00Z", relative to the Clock, in the
+// timezone of the configured UTCOffset. Open windows are rejected.
+func (c *Computer) ParseWindow(window string) (kubecost.Window, error) {
+	now := c.clock.Now().In(time.FixedZone("", int(c.utcOffset.Seconds())))
+
+	w, err := kubecost.ParseWindowAt(window, now)
+	if err != nil {
+		return w, fmt.Errorf("invalid window %q: %s", window, err)
+	}
+	if w.IsOpen() {
+		return w, fmt.Errorf("invalid window %q: window must be closed", window)
+	}
+	return w, nil
+}
+
+// ComputeAllocation computes the unaggregated AllocationSet, of containers, of
+// the window defined by the given start and end times, from metrics sampled at
+// the given resolution.
+func (c *Computer) ComputeAllocation(start, end time.Time, resolution time.Duration) (*kubecost.AllocationSet, error) {
+	if err := c.checkWindow(start, end); err != nil {
+		return nil, err
+	}
+
+	return c.model.ComputeAllocation(start, end, resolution)
+}
+
+// ComputeAssets computes the AssetSet of the nodes, disks, load balancers and
+// cluster management of the window defined by the given start and end times.
+func (c *Computer) ComputeAssets(start, end time.Time) (*kubecost.AssetSet, error) {
+	if err := c.checkWindow(start, end); err != nil {
+		return nil, err
+	}
+
+	return c.model.ComputeAssets(start, end)
+}
+
+// checkWindow returns an error if the window of the given start and end times
+// is empty, or starts after the time of the Clock.
+func (c *Computer) checkWindow(start, end time.Time) error {
+	window := kubecost.NewClosedWindow(start, end)
+	if !end.After(start) {
+		return fmt.Errorf("opencost: empty window %s", window)
+	}
+	if !start.Before(c.clock.Now()) {
+		return fmt.Errorf("opencost: window %s starts in the future", window)
+	}
+	return nil
+}
@@ -0,0 +1,463 @@
+package opencost
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/cloud"
+	"github.com/kubecost/opencost/pkg/kubecost"
+	"github.com/kubecost/opencost/pkg/util/json"
+
+	prometheus "github.com/prometheus/client_golang/api"
+)
+
+// fixtureStart and fixtureEnd are the window of the recorded metrics of
+// testdata/prometheus.json.
+var fixtureStart = time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+var fixtureEnd = time.Date(2022, 6, 2, 0, 0, 0, 0, time.UTC)
+
+// fixtureResult is the recorded result of the queries containing Query.
+type fixtureResult struct {
+	Query  string        `json:"query"`
+	Result []interface{} `json:"result"`
+}
+
+// fixtureClient is a prometheus.Client which answers queries with the first
+// matching result of a fixture file, and with an empty result otherwise.
+type fixtureClient struct {
+	results []fixtureResult
+}
+
+func newFixtureClient(path string) (*fixtureClient, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []fixtureResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, err
+	}
+
+	return &fixtureClient{results: results}, nil
+}
+
+func (fc *fixtureClient) URL(ep string, args map[string]string) *url.URL {
+	for arg, val := range args {
+		ep = strings.Replace(ep, ":"+arg, val, -1)
+	}
+	return &url.URL{Scheme: "http", Host: "prometheus", Path: ep}
+}
+
+func (fc *fixtureClient) Do(ctx context.Context, req *http.Request) (*http.Response, []byte, prometheus.Warnings, error) {
+	query := req.URL.Query().Get("query")
+
+	result := []interface{}{}
+	for _, fr := range fc.results {
+		if strings.Contains(query, fr.Query) {
+			result = fr.Result
+			break
+		}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"status": "success",
+		"data": map[string]interface{}{
+			"resultType": "vector",
+			"result":     result,
+		},
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return &http.Response{StatusCode: http.StatusOK, Request: req}, body, nil, nil
+}
+
+// fixtureProvider is a cloud.Provider of the default pricing, which reads
+// nothing from the environment.
+type fixtureProvider struct {
+	cloud.Provider
+}
+
+func (fixtureProvider) GetConfig() (*cloud.CustomPricing, error) {
+	return cloud.DefaultPricing(), nil
+}
+
+func (fixtureProvider) ClusterInfo() (map[string]string, error) {
+	return map[string]string{"id": "cluster-one", "provider": "fixture"}, nil
+}
+
+func (fixtureProvider) GetLocalStorageQuery(window, offset time.Duration, rate bool, used bool) string {
+	return ""
+}
+
+func (fixtureProvider) CombinedDiscountForNode(instanceType string, isPreemptible bool, defaultDiscount, negotiatedDiscount float64) float64 {
+	return 1.0 - ((1.0 - defaultDiscount) * (1.0 - negotiatedDiscount))
+}
+
+func newFixtureComputer(t *testing.T) *Computer {
+	client, err := newFixtureClient("testdata/prometheus.json")
+	if err != nil {
+		t.Fatalf("error reading fixture: %s", err)
+	}
+
+	c, err := New(Options{
+		Prometheus: client,
+		Provider:   fixtureProvider{},
+		Config:     Config{ClusterID: "cluster-one"},
+		Clock:      FixedClock(fixtureEnd),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return c
+}
+
+func TestNew(t *testing.T) {
+	client := &fixtureClient{}
+
+	if _, err := New(Options{Provider: fixtureProvider{}}); err == nil {
+		t.Errorf("expected error without Prometheus client")
+	}
+	if _, err := New(Options{Prometheus: client}); err == nil {
+		t.Errorf("expected error without Provider")
+	}
+
+	c, err := New(Options{Prometheus: client, Provider: fixtureProvider{}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if c.model.ClusterLabel != DefaultClusterLabel {
+		t.Errorf("expected cluster label %q; got %q", DefaultClusterLabel, c.model.ClusterLabel)
+	}
+	if c.model.MaxPrometheusQueryDuration != DefaultMaxQueryDuration {
+		t.Errorf("expected max query duration %s; got %s", DefaultMaxQueryDuration, c.model.MaxPrometheusQueryDuration)
+	}
+	if c.clock == nil {
+		t.Errorf("expected default Clock")
+	}
+}
+
+func TestComputer_ParseWindow(t *testing.T) {
+	c := newFixtureComputer(t)
+
+	cases := map[string]struct {
+		window string
+		start  time.Time
+		end    time.Time
+		err    bool
+	}{
+		"yesterday": {
+			window: "yesterday",
+			start:  fixtureStart,
+			end:    fixtureEnd,
+		},
+		"duration": {
+			window: "3h",
+			start:  fixtureEnd.Add(-3 * time.Hour),
+			end:    fixtureEnd,
+		},
+		"range": {
+			window: "2022-05-01T00:00:00Z,2022-05-02T00:00:00Z",
+			start:  time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC),
+			end:    time.Date(2022, 5, 2, 0, 0, 0, 0, time.UTC),
+		},
+		"invalid": {
+			window: "invalid",
+			err:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			w, err := c.ParseWindow(tc.window)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected error; got %s", w)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !w.Start().Equal(tc.start) || !w.End().Equal(tc.end) {
+				t.Errorf("expected window [%s, %s); got %s", tc.start, tc.end, w)
+			}
+		})
+	}
+}
+
+func TestComputer_ComputeAllocation(t *testing.T) {
+	c := newFixtureComputer(t)
+
+	if _, err := c.ComputeAllocation(fixtureEnd, fixtureStart, time.Hour); err == nil {
+		t.Errorf("expected error for empty window")
+	}
+	if _, err := c.ComputeAllocation(fixtureEnd, fixtureEnd.Add(time.Hour), time.Hour); err == nil {
+		t.Errorf("expected error for future window")
+	}
+
+	as, err := c.ComputeAllocation(fixtureStart, fixtureEnd, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cases := map[string]struct {
+		minutes float64
+		cpuCost float64
+		ramCost float64
+	}{
+		"cluster-one/node-1/web/web-1/nginx": {
+			minutes: 1440,
+			cpuCost: 0.5 * 24 * 0.04,
+			ramCost: 1 * 24 * 0.005,
+		},
+		"cluster-one/node-1/batch/job-1/worker": {
+			minutes: 420,
+			cpuCost: 1 * 7 * 0.04,
+			ramCost: 2 * 7 * 0.005,
+		},
+	}
+
+	for name, tc := range cases {
+		alloc := as.Get(name)
+		if alloc == nil {
+			t.Errorf("missing allocation %s", name)
+			continue
+		}
+		if !approx(alloc.Minutes(), tc.minutes) {
+			t.Errorf("%s: expected %.2f minutes; got %.2f", name, tc.minutes, alloc.Minutes())
+		}
+		if !approx(alloc.CPUCost, tc.cpuCost) {
+			t.Errorf("%s: expected CPU cost %.4f; got %.4f", name, tc.cpuCost, alloc.CPUCost)
+		}
+		if !approx(alloc.RAMCost, tc.ramCost) {
+			t.Errorf("%s: expected RAM cost %.4f; got %.4f", name, tc.ramCost, alloc.RAMCost)
+		}
+	}
+}
+
+func TestComputer_ComputeAssets(t *testing.T) {
+	c := newFixtureComputer(t)
+
+	assets, err := c.ComputeAssets(fixtureStart, fixtureEnd)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var node *kubecost.Node
+	assets.Each(func(key string, a kubecost.Asset) {
+		if n, ok := a.(*kubecost.Node); ok && n.Properties().Name == "node-1" {
+			node = n
+		}
+	})
+	if node == nil {
+		t.Fatalf("missing node node-1")
+	}
+
+	if !approx(node.Minutes(), 1440) {
+		t.Errorf("expected 1440 minutes; got %.2f", node.Minutes())
+	}
+	if !approx(node.CPUCost, 2*24*0.04) {
+		t.Errorf("expected CPU cost %.4f; got %.4f", 2*24*0.04, node.CPUCost)
+	}
+	if !approx(node.RAMCost, 8*24*0.005) {
+		t.Errorf("expected RAM cost %.4f; got %.4f", 8*24*0.005, node.RAMCost)
+	}
+}
+
+func TestComputer_PriceSchedule(t *testing.T) {
+	schedule, err := cloud.ParsePriceSchedule([]byte(`{"windows": [{"name": "flat", "start": "00:00", "end": "00:00", "multiplier": 2}]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client, err := newFixtureClient("testdata/prometheus.json")
+	if err != nil {
+		t.Fatalf("error reading fixture: %s", err)
+	}
+	c, err := New(Options{
+		Prometheus: client,
+		Provider:   fixtureProvider{},
+		Config:     Config{ClusterID: "cluster-one", PriceSchedule: schedule},
+		Clock:      FixedClock(fixtureEnd),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	as, err := c.ComputeAllocation(fixtureStart, fixtureEnd, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	alloc := as.Get("cluster-one/node-1/web/web-1/nginx")
+	if alloc == nil {
+		t.Fatalf("missing allocation cluster-one/node-1/web/web-1/nginx")
+	}
+	if !approx(alloc.CPUCost, 2*0.5*24*0.04) {
+		t.Errorf("expected CPU cost %.4f; got %.4f", 2*0.5*24*0.04, alloc.CPUCost)
+	}
+
+	assets, err := c.ComputeAssets(fixtureStart, fixtureEnd)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	assets.Each(func(key string, a kubecost.Asset) {
+		if n, ok := a.(*kubecost.Node); ok && !approx(n.CPUCost, 2*2*24*0.04) {
+			t.Errorf("%s: expected CPU cost %.4f; got %.4f", n.Properties().Name, 2*2*24*0.04, n.CPUCost)
+		}
+	})
+}
+
+// TestComputer_IgnoresConfigPath runs the examples with a price schedule and
+// node ownership rules in the server's config path, which must not change
+// their output, as a Computer reads nothing from the environment.
+func TestComputer_IgnoresConfigPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CONFIG_PATH", dir)
+	files := map[string]string{
+		"price-schedule.json": `{"windows": [{"name": "flat", "start": "00:00", "end": "00:00", "multiplier": 10}]}`,
+		"node-owners.json":    `{"rules": [{"owner": "ml", "cluster": "cluster-one"}]}`,
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	testExampleOutputs(t)
+}
+
+// TestComputer_IgnoresEnvironment runs the examples with the server's
+// environment variables set to conflict with their Config, which must not
+// change their output, and checks that queries are run at the times of the
+// Computer's Clock, without the query offset of the environment.
+func TestComputer_IgnoresEnvironment(t *testing.T) {
+	env := map[string]string{
+		"CLUSTER_ID":              "cluster-two",
+		"PROM_CLUSTER_ID_LABEL":   "env_cluster_label",
+		"PROMETHEUS_QUERY_OFFSET": "3h",
+		"INGEST_POD_UID":          "true",
+		"UTC_OFFSET":              "+05:00",
+		"ETL_MAX_PROMETHEUS_QUERY_DURATION_MINUTES": "60",
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+
+	testExampleOutputs(t)
+
+	fixture, err := newFixtureClient("testdata/prometheus.json")
+	if err != nil {
+		t.Fatalf("error reading fixture: %s", err)
+	}
+	client := &recordingClient{fixtureClient: fixture}
+	c, err := New(Options{
+		Prometheus: client,
+		Provider:   fixtureProvider{},
+		Config:     Config{ClusterID: "cluster-one"},
+		Clock:      FixedClock(fixtureEnd),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if c.model.CanCompute(fixtureEnd, fixtureEnd.Add(time.Hour)) {
+		t.Errorf("expected a window starting at the time of the Clock not to be computable")
+	}
+	if _, err := c.ComputeAllocation(fixtureStart, fixtureEnd, time.Hour); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := c.ComputeAssets(fixtureStart, fixtureEnd); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := strconv.FormatInt(fixtureEnd.Unix(), 10)
+	for query, at := range client.times {
+		if at != expected {
+			t.Errorf("expected query %q at %s; got %s", query, expected, at)
+		}
+		if strings.Contains(query, "env_cluster_label") {
+			t.Errorf("expected query %q not to use the cluster label of the environment", query)
+		}
+	}
+}
+
+// testExampleOutputs checks the output of the examples.
+func testExampleOutputs(t *testing.T) {
+	cases := map[string]struct {
+		example  func()
+		expected string
+	}{
+		"ComputeAllocation": {
+			example:  ExampleComputer_ComputeAllocation,
+			expected: "batch: 420 minutes, CPU $0.28, RAM $0.07, total $0.35\nweb: 1440 minutes, CPU $0.48, RAM $0.12, total $0.60\n",
+		},
+		"ComputeAssets": {
+			example:  ExampleComputer_ComputeAssets,
+			expected: "node-1: 1440 minutes, CPU $1.92, RAM $0.96, total $2.88\n",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if actual := captureStdout(t, tc.example); actual != tc.expected {
+				t.Errorf("expected output:\n%s\ngot:\n%s", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// recordingClient is a fixtureClient which records the time of each query.
+type recordingClient struct {
+	*fixtureClient
+	lock  sync.Mutex
+	times map[string]string
+}
+
+func (rc *recordingClient) Do(ctx context.Context, req *http.Request) (*http.Response, []byte, prometheus.Warnings, error) {
+	rc.lock.Lock()
+	if rc.times == nil {
+		rc.times = map[string]string{}
+	}
+	rc.times[req.URL.Query().Get("query")] = req.URL.Query().Get("time")
+	rc.lock.Unlock()
+
+	return rc.fixtureClient.Do(ctx, req)
+}
+
+// captureStdout returns what the given function prints to stdout.
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	f()
+	w.Close()
+
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return string(out)
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 0.0001
+}
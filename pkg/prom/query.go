@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/kubecost/opencost/pkg/env"
@@ -22,9 +23,29 @@ const (
 	epQueryRange = apiPrefix + "/query_range"
 )
 
-// prometheus query offset to apply to each non-range query
-// package scope to prevent calling duration parse each use
-var promQueryOffset time.Duration = env.GetPrometheusQueryOffset()
+// prometheus query offset to apply to each non-range query of contexts
+// created without ContextOptions, parsed from the environment on first use
+var (
+	promQueryOffset     time.Duration
+	promQueryOffsetOnce sync.Once
+)
+
+func defaultQueryOffset() time.Duration {
+	promQueryOffsetOnce.Do(func() {
+		promQueryOffset = env.GetPrometheusQueryOffset()
+	})
+	return promQueryOffset
+}
+
+// ContextOptions configure the times at which a Context runs queries which
+// are not given one.
+type ContextOptions struct {
+	// QueryOffset is subtracted from the time of non-range queries, for
+	// Prometheus compatible stores with delayed insertion (thanos, cortex, etc...)
+	QueryOffset time.Duration
+	// Clock tells the current time. Defaults to time.Now.
+	Clock func() time.Time
+}
 
 // Context wraps a Prometheus client and provides methods for querying and
 // parsing query responses and errors.
@@ -32,17 +53,14 @@ type Context struct {
 	Client         prometheus.Client
 	name           string
 	errorCollector *QueryErrorCollector
+	queryOffset    time.Duration
+	clock          func() time.Time
 }
 
-// NewContext creates a new Promethues querying context from the given client
+// NewContext creates a new Promethues querying context from the given client,
+// which applies the query offset of the environment.
 func NewContext(client prometheus.Client) *Context {
-	var ec QueryErrorCollector
-
-	return &Context{
-		Client:         client,
-		name:           "",
-		errorCollector: &ec,
-	}
+	return NewNamedContextWithOptions(client, "", ContextOptions{QueryOffset: defaultQueryOffset()})
 }
 
 // NewNamedContext creates a new named Promethues querying context from the given client
@@ -52,6 +70,25 @@ func NewNamedContext(client prometheus.Client, name string) *Context {
 	return ctx
 }
 
+// NewNamedContextWithOptions creates a new named Promethues querying context
+// from the given client, which reads nothing from the environment.
+func NewNamedContextWithOptions(client prometheus.Client, name string, opts ContextOptions) *Context {
+	var ec QueryErrorCollector
+
+	clock := opts.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+
+	return &Context{
+		Client:         client,
+		name:           name,
+		errorCollector: &ec,
+		queryOffset:    opts.QueryOffset,
+		clock:          clock,
+	}
+}
+
 // Warnings returns the warnings collected from the Context's ErrorCollector
 func (ctx *Context) Warnings() []*QueryWarning {
 	return ctx.errorCollector.Warnings()
@@ -89,7 +126,7 @@ func (ctx *Context) ErrorCollection() error {
 func (ctx *Context) Query(query string) QueryResultsChan {
 	resCh := make(QueryResultsChan)
 
-	go runQuery(query, ctx, resCh, time.Now(), "")
+	go runQuery(query, ctx, resCh, ctx.clock(), "")
 
 	return resCh
 }
@@ -112,7 +149,7 @@ func (ctx *Context) QueryAtTime(query string, t time.Time) QueryResultsChan {
 func (ctx *Context) ProfileQuery(query string, profileLabel string) QueryResultsChan {
 	resCh := make(QueryResultsChan)
 
-	go runQuery(query, ctx, resCh, time.Now(), profileLabel)
+	go runQuery(query, ctx, resCh, ctx.clock(), profileLabel)
 
 	return resCh
 }
@@ -146,7 +183,7 @@ func (ctx *Context) ProfileQueryAll(queries ...string) []QueryResultsChan {
 }
 
 func (ctx *Context) QuerySync(query string) ([]*QueryResult, prometheus.Warnings, error) {
-	raw, warnings, err := ctx.query(query, time.Now())
+	raw, warnings, err := ctx.query(query, ctx.clock())
 	if err != nil {
 		return nil, warnings, err
 	}
@@ -195,10 +232,10 @@ func (ctx *Context) RawQuery(query string, t time.Time) ([]byte, error) {
 		// for non-range queries, we set the timestamp for the query to time-offset
 		// this is a special use case that's typically only used when our primary
 		// prom db has delayed insertion (thanos, cortex, etc...)
-		if promQueryOffset != 0 && ctx.name != AllocationContextName {
-			q.Set("time", time.Now().Add(-promQueryOffset).UTC().Format(time.RFC3339))
+		if ctx.queryOffset != 0 && ctx.name != AllocationContextName {
+			q.Set("time", ctx.clock().Add(-ctx.queryOffset).UTC().Format(time.RFC3339))
 		} else {
-			q.Set("time", time.Now().UTC().Format(time.RFC3339))
+			q.Set("time", ctx.clock().UTC().Format(time.RFC3339))
 		}
 	}
 
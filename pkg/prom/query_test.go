@@ -1,6 +1,15 @@
 package prom
 
-import "testing"
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	prometheus "github.com/prometheus/client_golang/api"
+)
 
 func TestWarningsFrom(t *testing.T) {
 	var results interface{}
@@ -25,3 +34,40 @@ func TestWarningsFrom(t *testing.T) {
 		t.Errorf("Unexpected second warning: %s", warnings[1])
 	}
 }
+
+// timeClient is a prometheus.Client which records the time of the last query.
+type timeClient struct {
+	time string
+}
+
+func (tc *timeClient) URL(ep string, args map[string]string) *url.URL {
+	return &url.URL{Scheme: "http", Host: "prometheus", Path: ep}
+}
+
+func (tc *timeClient) Do(ctx context.Context, req *http.Request) (*http.Response, []byte, prometheus.Warnings, error) {
+	tc.time = req.URL.Query().Get("time")
+	return &http.Response{StatusCode: http.StatusOK, Request: req}, []byte(`{"status": "success", "data": {"resultType": "vector", "result": []}}`), nil, nil
+}
+
+func TestContextOptions(t *testing.T) {
+	now := time.Date(2022, 6, 2, 12, 0, 0, 0, time.UTC)
+	client := &timeClient{}
+	ctx := NewNamedContextWithOptions(client, ClusterContextName, ContextOptions{
+		QueryOffset: time.Hour,
+		Clock:       func() time.Time { return now },
+	})
+
+	if _, err := ctx.RawQuery("up", time.Time{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := now.Add(-time.Hour).Format(time.RFC3339); client.time != expected {
+		t.Errorf("Unexpected query time: %s, Expected %s.", client.time, expected)
+	}
+
+	if _, _, err := ctx.QuerySync("up"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := strconv.FormatInt(now.Unix(), 10); client.time != expected {
+		t.Errorf("Unexpected query time: %s, Expected %s.", client.time, expected)
+	}
+}
@@ -0,0 +1,868 @@
+package grafana
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/kubecost"
+	allocationfilterutil "github.com/kubecost/opencost/pkg/util/allocationfilterutil/v2"
+	"github.com/kubecost/opencost/pkg/util/json"
+	"github.com/kubecost/opencost/pkg/util/timeutil"
+)
+
+// AllocationComputer computes the unaggregated AllocationSet of a window.
+type AllocationComputer interface {
+	ComputeAllocation(start, end time.Time, resolution time.Duration) (*kubecost.AllocationSet, error)
+}
+
+// AssetComputer computes the AssetSet of a window.
+type AssetComputer interface {
+	ComputeAssets(start, end time.Time) (*kubecost.AssetSet, error)
+}
+
+// Target types of query requests
+const (
+	TargetTypeTimeSeries = "timeseries"
+	TargetTypeTable      = "table"
+)
+
+// Prefixes of the targets of allocation and asset metrics, e.g.
+// "allocation.totalCost". A bare prefix targets every metric, as a table.
+const (
+	AllocationTarget = "allocation"
+	AssetTarget      = "asset"
+)
+
+// defaultAggregate is the aggregation of allocation queries without one,
+// because allocations can only be shared and filtered when aggregated.
+const defaultAggregate = "namespace"
+
+// maxSteps is the maximum number of steps, and so of datapoints, of a time
+// series. Queries of finer steps are computed over coarser ones.
+const maxSteps = 200
+
+// allocationMetrics are the metrics of allocations, by name.
+var allocationMetrics = map[string]func(*kubecost.Allocation) float64{
+	"cpuCores":         func(a *kubecost.Allocation) float64 { return a.CPUCores() },
+	"cpuCost":          func(a *kubecost.Allocation) float64 { return a.CPUTotalCost() },
+	"cpuEfficiency":    func(a *kubecost.Allocation) float64 { return a.CPUEfficiency() },
+	"externalCost":     func(a *kubecost.Allocation) float64 { return a.ExternalCost },
+	"gpuCost":          func(a *kubecost.Allocation) float64 { return a.GPUTotalCost() },
+	"gpus":             func(a *kubecost.Allocation) float64 { return a.GPUs() },
+	"loadBalancerCost": func(a *kubecost.Allocation) float64 { return a.LoadBalancerTotalCost() },
+	"networkCost":      func(a *kubecost.Allocation) float64 { return a.NetworkTotalCost() },
+	"pvBytes":          func(a *kubecost.Allocation) float64 { return a.PVBytes() },
+	"pvCost":           func(a *kubecost.Allocation) float64 { return a.PVTotalCost() },
+	"ramBytes":         func(a *kubecost.Allocation) float64 { return a.RAMBytes() },
+	"ramCost":          func(a *kubecost.Allocation) float64 { return a.RAMTotalCost() },
+	"ramEfficiency":    func(a *kubecost.Allocation) float64 { return a.RAMEfficiency() },
+	"sharedCost":       func(a *kubecost.Allocation) float64 { return a.SharedTotalCost() },
+	"totalCost":        func(a *kubecost.Allocation) float64 { return a.TotalCost() },
+	"totalEfficiency":  func(a *kubecost.Allocation) float64 { return a.TotalEfficiency() },
+}
+
+// assetMetrics are the metrics of assets, by name.
+var assetMetrics = map[string]func(kubecost.Asset) float64{
+	"adjustment": func(a kubecost.Asset) float64 { return a.Adjustment() },
+	"minutes":    func(a kubecost.Asset) float64 { return a.Minutes() },
+	"totalCost":  func(a kubecost.Asset) float64 { return a.TotalCost() },
+}
+
+// tagKeys are the ad hoc filter keys of allocations, which are their
+// properties. Labels are filtered by "label:<name>".
+var tagKeys = []string{"cluster", "node", "namespace", "controllerKind", "controller", "pod", "container"}
+
+// Range is the time range of a request, which is mapped to a closed Window.
+type Range struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// Window returns the closed Window of the Range.
+func (r Range) Window() (kubecost.Window, error) {
+	if r.From.IsZero() || r.To.IsZero() || !r.To.After(r.From) {
+		return kubecost.Window{}, fmt.Errorf("invalid range: %s to %s", r.From, r.To)
+	}
+	return kubecost.NewClosedWindow(r.From.UTC(), r.To.UTC()), nil
+}
+
+// AdhocFilter is an ad hoc filter of a dashboard, e.g. namespace = kubecost.
+type AdhocFilter struct {
+	Key      string `json:"key"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// Payload holds the options of a query target, which may be given either as
+// an object or as a JSON string, with dashboard variables interpolated.
+type Payload struct {
+	// Aggregate is a comma-separated list of properties by which to
+	// aggregate, e.g. "namespace" or "cluster,label:app".
+	Aggregate string `json:"aggregate,omitempty"`
+	// Filter is an allocation filter, e.g. namespace:"kubecost".
+	Filter string `json:"filter,omitempty"`
+	// ShareIdle shares the costs of idle resources across allocations,
+	// "weighted" by cost or "even"ly. Idle is not shared by default.
+	ShareIdle string `json:"shareIdle,omitempty"`
+	// IdleByNode shares idle costs by node, rather than by cluster.
+	IdleByNode bool `json:"idleByNode,omitempty"`
+	// ShareNamespaces is a comma-separated list of namespaces of which the
+	// costs are shared across the other allocations.
+	ShareNamespaces string `json:"shareNamespaces,omitempty"`
+	// ShareSplit determines whether shared costs are shared "weighted" by
+	// cost, the default, or "even"ly.
+	ShareSplit string `json:"shareSplit,omitempty"`
+	// Step is the duration of each datapoint of a time series, e.g. "1h" or
+	// "1d". Defaults to the interval of the panel, of at least an hour.
+	Step string `json:"step,omitempty"`
+}
+
+// UnmarshalJSON unmarshals a Payload from either an object or a JSON string.
+func (p *Payload) UnmarshalJSON(data []byte) error {
+	type payload Payload
+
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		if strings.TrimSpace(text) == "" {
+			*p = Payload{}
+			return nil
+		}
+		data = []byte(text)
+	}
+
+	var pl payload
+	if err := json.Unmarshal(data, &pl); err != nil {
+		return fmt.Errorf("invalid payload: %s", err)
+	}
+	*p = Payload(pl)
+
+	return nil
+}
+
+// Target is a query of a panel.
+type Target struct {
+	RefID   string   `json:"refId"`
+	Target  string   `json:"target"`
+	Type    string   `json:"type,omitempty"`
+	Hide    bool     `json:"hide,omitempty"`
+	Payload *Payload `json:"payload,omitempty"`
+	// Data is the payload of the targets of older datasource versions.
+	Data *Payload `json:"data,omitempty"`
+}
+
+func (t Target) payload() Payload {
+	if t.Payload != nil {
+		return *t.Payload
+	}
+	if t.Data != nil {
+		return *t.Data
+	}
+	return Payload{}
+}
+
+// QueryRequest is the body of a query request.
+type QueryRequest struct {
+	Range        Range         `json:"range"`
+	IntervalMs   int64         `json:"intervalMs"`
+	Targets      []Target      `json:"targets"`
+	AdhocFilters []AdhocFilter `json:"adhocFilters,omitempty"`
+}
+
+// SearchRequest is the body of a search request.
+type SearchRequest struct {
+	Target string `json:"target"`
+}
+
+// Annotation is the annotation query of an annotations request, which is
+// returned with each of its events.
+type Annotation struct {
+	Name       string      `json:"name"`
+	Datasource interface{} `json:"datasource,omitempty"`
+	Enable     bool        `json:"enable"`
+	IconColor  string      `json:"iconColor,omitempty"`
+	Query      string      `json:"query,omitempty"`
+}
+
+// AnnotationsRequest is the body of an annotations request.
+type AnnotationsRequest struct {
+	Range      Range      `json:"range"`
+	Annotation Annotation `json:"annotation"`
+}
+
+// TagValuesRequest is the body of a tag values request.
+type TagValuesRequest struct {
+	Key   string `json:"key"`
+	Range *Range `json:"range,omitempty"`
+}
+
+// TimeSeries is a series of datapoints, each a value and a time in
+// milliseconds since the epoch.
+type TimeSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// Column is a column of a Table.
+type Column struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+// Table is a table of rows of the values of its columns.
+type Table struct {
+	Type    string          `json:"type"`
+	Columns []Column        `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// AnnotationEvent is an event resulting from an annotation query.
+type AnnotationEvent struct {
+	Annotation Annotation `json:"annotation"`
+	Time       int64      `json:"time"`
+	TimeEnd    int64      `json:"timeEnd,omitempty"`
+	Title      string     `json:"title"`
+	Text       string     `json:"text"`
+	Tags       []string   `json:"tags"`
+}
+
+// TagKey is a key of ad hoc filters.
+type TagKey struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// TagValue is a value of an ad hoc filter key.
+type TagValue struct {
+	Text string `json:"text"`
+}
+
+// Datasource answers the requests of the Grafana JSON datasource protocol
+// with allocation and asset data.
+type Datasource struct {
+	allocations AllocationComputer
+	assets      AssetComputer
+	resolution  time.Duration
+}
+
+// NewDatasource creates a new Datasource of the allocations and assets
+// computed by the given computers, at the given resolution.
+func NewDatasource(allocations AllocationComputer, assets AssetComputer, resolution time.Duration) *Datasource {
+	return &Datasource{
+		allocations: allocations,
+		assets:      assets,
+		resolution:  resolution,
+	}
+}
+
+// Search returns the targets which contain the given text, by name.
+func (ds *Datasource) Search(req SearchRequest) []string {
+	targets := []string{AllocationTarget, AssetTarget}
+	for metric := range allocationMetrics {
+		targets = append(targets, AllocationTarget+"."+metric)
+	}
+	for metric := range assetMetrics {
+		targets = append(targets, AssetTarget+"."+metric)
+	}
+	sort.Strings(targets)
+
+	matches := []string{}
+	for _, target := range targets {
+		if strings.Contains(strings.ToLower(target), strings.ToLower(req.Target)) {
+			matches = append(matches, target)
+		}
+	}
+	return matches
+}
+
+// Query returns the time series, or tables, of each of the targets of the
+// request, which are computed over the range of the request.
+func (ds *Datasource) Query(req QueryRequest) ([]interface{}, error) {
+	window, err := req.Range.Window()
+	if err != nil {
+		return nil, err
+	}
+
+	results := []interface{}{}
+	for _, target := range req.Targets {
+		if target.Hide || target.Target == "" {
+			continue
+		}
+
+		kind, metric := parseTarget(target.Target)
+		var result []interface{}
+		switch kind {
+		case AllocationTarget:
+			result, err = ds.queryAllocations(window, target, metric, req)
+		case AssetTarget:
+			result, err = ds.queryAssets(window, target, metric, req)
+		default:
+			err = fmt.Errorf("unknown target \"%s\"", target.Target)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("target %s: %s", target.RefID, err)
+		}
+
+		results = append(results, result...)
+	}
+
+	return results, nil
+}
+
+// Annotations returns an event for each node which was added, or removed,
+// within the range of the request.
+func (ds *Datasource) Annotations(req AnnotationsRequest) ([]AnnotationEvent, error) {
+	window, err := req.Range.Window()
+	if err != nil {
+		return nil, err
+	}
+
+	as, err := ds.assets.ComputeAssets(*window.Start(), *window.End())
+	if err != nil {
+		return nil, fmt.Errorf("error computing assets for %s: %s", window, err)
+	}
+
+	events := []AnnotationEvent{}
+	as.Each(func(_ string, a kubecost.Asset) {
+		node, ok := a.(*kubecost.Node)
+		if !ok {
+			return
+		}
+
+		props := node.Properties()
+		tags := []string{"node", "cluster:" + props.Cluster}
+		if node.NodeType != "" {
+			tags = append(tags, "nodeType:"+node.NodeType)
+		}
+
+		if node.Start().After(*window.Start()) {
+			events = append(events, AnnotationEvent{
+				Annotation: req.Annotation,
+				Time:       node.Start().UnixNano() / int64(time.Millisecond),
+				Title:      fmt.Sprintf("Node %s added", props.Name),
+				Text:       fmt.Sprintf("%s node %s was added to cluster %s", node.NodeType, props.Name, props.Cluster),
+				Tags:       append([]string{"added"}, tags...),
+			})
+		}
+		if node.End().Before(*window.End()) {
+			events = append(events, AnnotationEvent{
+				Annotation: req.Annotation,
+				Time:       node.End().UnixNano() / int64(time.Millisecond),
+				Title:      fmt.Sprintf("Node %s removed", props.Name),
+				Text:       fmt.Sprintf("%s node %s was removed from cluster %s, costing %.2f", node.NodeType, props.Name, props.Cluster, node.TotalCost()),
+				Tags:       append([]string{"removed"}, tags...),
+			})
+		}
+	})
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Time != events[j].Time {
+			return events[i].Time < events[j].Time
+		}
+		return events[i].Title < events[j].Title
+	})
+
+	return events, nil
+}
+
+// TagKeys returns the keys of the ad hoc filters of allocations.
+func (ds *Datasource) TagKeys() []TagKey {
+	keys := make([]TagKey, 0, len(tagKeys))
+	for _, key := range tagKeys {
+		keys = append(keys, TagKey{Type: "string", Text: key})
+	}
+	return keys
+}
+
+// TagValues returns the values of the given ad hoc filter key among the
+// allocations of the range of the request, or of the last day.
+func (ds *Datasource) TagValues(req TagValuesRequest) ([]TagValue, error) {
+	var window kubecost.Window
+	if req.Range != nil {
+		w, err := req.Range.Window()
+		if err != nil {
+			return nil, err
+		}
+		window = w
+	} else {
+		end := time.Now().UTC().Truncate(time.Hour)
+		start := end.Add(-24 * time.Hour)
+		window = kubecost.NewClosedWindow(start, end)
+	}
+
+	as, err := ds.allocations.ComputeAllocation(*window.Start(), *window.End(), ds.resolution)
+	if err != nil {
+		return nil, fmt.Errorf("error computing allocations for %s: %s", window, err)
+	}
+
+	set := map[string]bool{}
+	as.Each(func(_ string, a *kubecost.Allocation) {
+		if a.IsIdle() || a.IsUnmounted() {
+			return
+		}
+		if value, ok := allocationTagValue(a, req.Key); ok && value != "" {
+			set[value] = true
+		}
+	})
+
+	values := make([]TagValue, 0, len(set))
+	for value := range set {
+		values = append(values, TagValue{Text: value})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Text < values[j].Text
+	})
+
+	return values, nil
+}
+
+func (ds *Datasource) queryAllocations(window kubecost.Window, target Target, metric string, req QueryRequest) ([]interface{}, error) {
+	payload := target.payload()
+
+	metrics, err := selectMetrics(metric, allocationMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregateBy, err := parseAllocationAggregate(payload.Aggregate)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := allocationOptions(payload, req.AdhocFilters)
+	if err != nil {
+		return nil, err
+	}
+
+	isTable := target.Type == TargetTypeTable || metric == ""
+
+	// Tables are computed over the whole window, and time series at each step
+	steps := []kubecost.Window{window}
+	if !isTable {
+		steps, err = stepWindows(window, payload.Step, req.IntervalMs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sets := make([]*kubecost.AllocationSet, 0, len(steps))
+	for _, step := range steps {
+		as, err := ds.allocations.ComputeAllocation(*step.Start(), *step.End(), ds.resolution)
+		if err != nil {
+			return nil, fmt.Errorf("error computing allocations for %s: %s", step, err)
+		}
+
+		// Options are copied because aggregation may modify them
+		opts := *options
+		err = as.AggregateBy(aggregateBy, &opts)
+		if err != nil {
+			return nil, fmt.Errorf("error aggregating allocations by %s: %s", strings.Join(aggregateBy, ","), err)
+		}
+		sets = append(sets, as)
+	}
+
+	if isTable {
+		table := newTable(metrics)
+		sets[0].Each(func(name string, a *kubecost.Allocation) {
+			row := []interface{}{name}
+			for _, m := range metrics {
+				row = append(row, allocationMetrics[m](a))
+			}
+			table.Rows = append(table.Rows, row)
+		})
+		sortRows(table)
+		return []interface{}{table}, nil
+	}
+
+	series := map[string]*TimeSeries{}
+	for i, as := range sets {
+		ts := float64(steps[i].Start().UnixNano() / int64(time.Millisecond))
+		as.Each(func(name string, a *kubecost.Allocation) {
+			if _, ok := series[name]; !ok {
+				series[name] = &TimeSeries{Target: name, Datapoints: [][2]float64{}}
+			}
+			series[name].Datapoints = append(series[name].Datapoints, [2]float64{allocationMetrics[metric](a), ts})
+		})
+	}
+
+	return sortSeries(series), nil
+}
+
+func (ds *Datasource) queryAssets(window kubecost.Window, target Target, metric string, req QueryRequest) ([]interface{}, error) {
+	payload := target.payload()
+
+	metrics, err := selectMetrics(metric, assetMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregateBy := []string{}
+	for _, agg := range strings.Split(payload.Aggregate, ",") {
+		if strings.TrimSpace(agg) == "" {
+			continue
+		}
+		prop, err := kubecost.ParseAssetProperty(agg)
+		if err != nil {
+			return nil, err
+		}
+		aggregateBy = append(aggregateBy, string(prop))
+	}
+	if len(aggregateBy) == 0 {
+		aggregateBy = append(aggregateBy, string(kubecost.AssetTypeProp))
+	}
+
+	opts := &kubecost.AssetAggregationOptions{}
+	for _, f := range req.AdhocFilters {
+		ff, err := assetFilterFunc(f)
+		if err != nil {
+			return nil, err
+		}
+		opts.FilterFuncs = append(opts.FilterFuncs, ff)
+	}
+
+	isTable := target.Type == TargetTypeTable || metric == ""
+
+	steps := []kubecost.Window{window}
+	if !isTable {
+		steps, err = stepWindows(window, payload.Step, req.IntervalMs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sets := make([]*kubecost.AssetSet, 0, len(steps))
+	for _, step := range steps {
+		as, err := ds.assets.ComputeAssets(*step.Start(), *step.End())
+		if err != nil {
+			return nil, fmt.Errorf("error computing assets for %s: %s", step, err)
+		}
+
+		err = as.AggregateBy(aggregateBy, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error aggregating assets by %s: %s", strings.Join(aggregateBy, ","), err)
+		}
+		sets = append(sets, as)
+	}
+
+	if isTable {
+		table := newTable(metrics)
+		sets[0].Each(func(name string, a kubecost.Asset) {
+			row := []interface{}{name}
+			for _, m := range metrics {
+				row = append(row, assetMetrics[m](a))
+			}
+			table.Rows = append(table.Rows, row)
+		})
+		sortRows(table)
+		return []interface{}{table}, nil
+	}
+
+	series := map[string]*TimeSeries{}
+	for i, as := range sets {
+		ts := float64(steps[i].Start().UnixNano() / int64(time.Millisecond))
+		as.Each(func(name string, a kubecost.Asset) {
+			if _, ok := series[name]; !ok {
+				series[name] = &TimeSeries{Target: name, Datapoints: [][2]float64{}}
+			}
+			series[name].Datapoints = append(series[name].Datapoints, [2]float64{assetMetrics[metric](a), ts})
+		})
+	}
+
+	return sortSeries(series), nil
+}
+
+// parseTarget splits the given target into its kind and metric, e.g.
+// "allocation.totalCost" into "allocation" and "totalCost".
+func parseTarget(target string) (string, string) {
+	parts := strings.SplitN(strings.TrimSpace(target), ".", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// selectMetrics returns the given metric, or every metric, by name, if it is
+// empty.
+func selectMetrics[T any](metric string, metrics map[string]T) ([]string, error) {
+	if metric != "" {
+		if _, ok := metrics[metric]; !ok {
+			return nil, fmt.Errorf("unknown metric \"%s\"", metric)
+		}
+		return []string{metric}, nil
+	}
+
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// parseAllocationAggregate parses the given comma-separated aggregate as the
+// aggregate parameter of the allocation API, defaulting to defaultAggregate.
+func parseAllocationAggregate(text string) ([]string, error) {
+	aggregateBy := []string{}
+	for _, agg := range strings.Split(text, ",") {
+		aggregate := strings.TrimSpace(agg)
+		if aggregate == "" {
+			continue
+		}
+		if prop, err := kubecost.ParseProperty(aggregate); err == nil {
+			aggregateBy = append(aggregateBy, string(prop))
+		} else if strings.HasPrefix(aggregate, "label:") || strings.HasPrefix(aggregate, "annotation:") {
+			aggregateBy = append(aggregateBy, aggregate)
+		} else {
+			return nil, fmt.Errorf("invalid aggregate \"%s\"", aggregate)
+		}
+	}
+	if len(aggregateBy) == 0 {
+		aggregateBy = append(aggregateBy, defaultAggregate)
+	}
+	return aggregateBy, nil
+}
+
+// allocationOptions returns the aggregation options of the given payload and
+// ad hoc filters.
+func allocationOptions(payload Payload, adhocFilters []AdhocFilter) (*kubecost.AllocationAggregationOptions, error) {
+	options := &kubecost.AllocationAggregationOptions{
+		IdleByNode: payload.IdleByNode,
+		ShareSplit: kubecost.ShareWeighted,
+	}
+
+	switch strings.ToLower(payload.ShareIdle) {
+	case "", "false", "none":
+		options.ShareIdle = kubecost.ShareNone
+	case "true", "weighted":
+		options.ShareIdle = kubecost.ShareWeighted
+	case "even":
+		options.ShareIdle = kubecost.ShareEven
+	default:
+		return nil, fmt.Errorf("invalid shareIdle \"%s\"", payload.ShareIdle)
+	}
+
+	switch strings.ToLower(payload.ShareSplit) {
+	case "", "weighted":
+	case "even":
+		options.ShareSplit = kubecost.ShareEven
+	default:
+		return nil, fmt.Errorf("invalid shareSplit \"%s\"", payload.ShareSplit)
+	}
+
+	shareNamespaces := map[string]bool{}
+	for _, ns := range strings.Split(payload.ShareNamespaces, ",") {
+		if strings.TrimSpace(ns) != "" {
+			shareNamespaces[strings.TrimSpace(ns)] = true
+		}
+	}
+	if len(shareNamespaces) > 0 {
+		options.ShareFuncs = append(options.ShareFuncs, func(a *kubecost.Allocation) bool {
+			return a.Properties != nil && shareNamespaces[a.Properties.Namespace]
+		})
+	}
+
+	if strings.TrimSpace(payload.Filter) != "" {
+		filter, err := allocationfilterutil.ParseAllocationFilter(payload.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %s", err)
+		}
+		options.FilterFuncs = append(options.FilterFuncs, filter.Matches)
+	}
+
+	for _, f := range adhocFilters {
+		ff, err := allocationFilterFunc(f)
+		if err != nil {
+			return nil, err
+		}
+		options.FilterFuncs = append(options.FilterFuncs, ff)
+	}
+
+	return options, nil
+}
+
+// stepWindows splits the given window into steps of the given duration, or
+// of the given interval, of at least an hour, coarsened to at most maxSteps.
+func stepWindows(window kubecost.Window, step string, intervalMs int64) ([]kubecost.Window, error) {
+	var dur time.Duration
+	if step != "" {
+		d, err := timeutil.ParseDuration(step)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid step \"%s\"", step)
+		}
+		dur = d
+	} else {
+		dur = time.Duration(intervalMs) * time.Millisecond
+		if dur < time.Hour {
+			dur = time.Hour
+		}
+		dur = dur.Round(time.Hour)
+	}
+
+	if window.Duration() > dur*maxSteps {
+		min := window.Duration() / maxSteps
+		dur = min.Truncate(time.Hour)
+		if dur < min {
+			dur += time.Hour
+		}
+	}
+
+	steps := []kubecost.Window{}
+	for start := *window.Start(); start.Before(*window.End()); start = start.Add(dur) {
+		end := start.Add(dur)
+		if end.After(*window.End()) {
+			end = *window.End()
+		}
+		steps = append(steps, kubecost.NewClosedWindow(start, end))
+	}
+
+	return steps, nil
+}
+
+// allocationTagValue returns the value of the given ad hoc filter key of the
+// given allocation.
+func allocationTagValue(a *kubecost.Allocation, key string) (string, bool) {
+	if a.Properties == nil {
+		return "", false
+	}
+	props := a.Properties
+
+	if strings.HasPrefix(key, "label:") {
+		value, ok := props.Labels[strings.TrimPrefix(key, "label:")]
+		return value, ok
+	}
+
+	switch key {
+	case "cluster":
+		return props.Cluster, true
+	case "node":
+		return props.Node, true
+	case "namespace":
+		return props.Namespace, true
+	case "controllerKind":
+		return props.ControllerKind, true
+	case "controller":
+		return props.Controller, true
+	case "pod":
+		return props.Pod, true
+	case "container":
+		return props.Container, true
+	}
+
+	return "", false
+}
+
+// allocationFilterFunc returns an AllocationMatchFunc of the given ad hoc
+// filter of allocations.
+func allocationFilterFunc(f AdhocFilter) (kubecost.AllocationMatchFunc, error) {
+	if _, ok := allocationTagValue(&kubecost.Allocation{Properties: &kubecost.AllocationProperties{}}, f.Key); !ok && !strings.HasPrefix(f.Key, "label:") {
+		return nil, fmt.Errorf("invalid filter key \"%s\"", f.Key)
+	}
+
+	matches, err := matcher(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(a *kubecost.Allocation) bool {
+		value, _ := allocationTagValue(a, f.Key)
+		return matches(value)
+	}, nil
+}
+
+// assetFilterFunc returns an AssetMatchFunc of the given ad hoc filter of
+// assets, of which the keys are asset properties.
+func assetFilterFunc(f AdhocFilter) (kubecost.AssetMatchFunc, error) {
+	prop, err := kubecost.ParseAssetProperty(f.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter key \"%s\"", f.Key)
+	}
+
+	matches, err := matcher(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(a kubecost.Asset) bool {
+		props := a.Properties()
+		if props == nil {
+			props = &kubecost.AssetProperties{}
+		}
+
+		var value string
+		switch prop {
+		case kubecost.AssetAccountProp:
+			value = props.Account
+		case kubecost.AssetCategoryProp:
+			value = props.Category
+		case kubecost.AssetClusterProp:
+			value = props.Cluster
+		case kubecost.AssetNameProp:
+			value = props.Name
+		case kubecost.AssetProjectProp:
+			value = props.Project
+		case kubecost.AssetProviderProp:
+			value = props.Provider
+		case kubecost.AssetProviderIDProp:
+			value = props.ProviderID
+		case kubecost.AssetServiceProp:
+			value = props.Service
+		case kubecost.AssetTypeProp:
+			value = a.Type().String()
+		}
+
+		return matches(value)
+	}, nil
+}
+
+// matcher returns a function matching the values which satisfy the operator
+// and value of the given ad hoc filter.
+func matcher(f AdhocFilter) (func(string) bool, error) {
+	switch f.Operator {
+	case "=", "":
+		return func(v string) bool { return v == f.Value }, nil
+	case "!=":
+		return func(v string) bool { return v != f.Value }, nil
+	case "=~", "!~":
+		re, err := regexp.Compile("^(?:" + f.Value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter regex \"%s\": %s", f.Value, err)
+		}
+		negate := f.Operator == "!~"
+		return func(v string) bool { return re.MatchString(v) != negate }, nil
+	}
+	return nil, fmt.Errorf("invalid filter operator \"%s\"", f.Operator)
+}
+
+func newTable(metrics []string) *Table {
+	table := &Table{
+		Type:    TargetTypeTable,
+		Columns: []Column{{Text: "name", Type: "string"}},
+		Rows:    [][]interface{}{},
+	}
+	for _, m := range metrics {
+		table.Columns = append(table.Columns, Column{Text: m, Type: "number"})
+	}
+	return table
+}
+
+func sortRows(table *Table) {
+	sort.Slice(table.Rows, func(i, j int) bool {
+		return table.Rows[i][0].(string) < table.Rows[j][0].(string)
+	})
+}
+
+func sortSeries(series map[string]*TimeSeries) []interface{} {
+	names := make([]string, 0, len(series))
+	for name := range series {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		result = append(result, series[name])
+	}
+	return result
+}
@@ -0,0 +1,145 @@
+package grafana
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/opencost/pkg/log"
+	"github.com/kubecost/opencost/pkg/util/json"
+)
+
+// DatasourceHTTPService is an implementation of HTTPService which provides
+// the endpoints of the Grafana JSON datasource protocol under /grafana, so
+// that allocation and asset queries, with idle and shared costs distributed,
+// may be used in Grafana panels.
+type DatasourceHTTPService struct {
+	datasource *Datasource
+}
+
+// NewDatasourceHTTPService creates a new Grafana datasource http service
+func NewDatasourceHTTPService(datasource *Datasource) *DatasourceHTTPService {
+	return &DatasourceHTTPService{
+		datasource: datasource,
+	}
+}
+
+// Register assigns the endpoints and returns an error on failure.
+func (dhs *DatasourceHTTPService) Register(router *httprouter.Router) error {
+	router.GET("/grafana/", dhs.TestDatasource)
+	router.POST("/grafana/search", dhs.Search)
+	router.POST("/grafana/query", dhs.Query)
+	router.POST("/grafana/annotations", dhs.Annotations)
+	router.POST("/grafana/tag-keys", dhs.TagKeys)
+	router.POST("/grafana/tag-values", dhs.TagValues)
+
+	return nil
+}
+
+// TestDatasource responds to the connection test of the datasource settings.
+func (dhs *DatasourceHTTPService) TestDatasource(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (dhs *DatasourceHTTPService) Search(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req SearchRequest
+	if err := readRequest(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeResponse(w, dhs.datasource.Search(req))
+}
+
+func (dhs *DatasourceHTTPService) Query(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req QueryRequest
+	if err := readRequest(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	results, err := dhs.datasource.Query(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeResponse(w, results)
+}
+
+func (dhs *DatasourceHTTPService) Annotations(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req AnnotationsRequest
+	if err := readRequest(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	events, err := dhs.datasource.Annotations(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeResponse(w, events)
+}
+
+func (dhs *DatasourceHTTPService) TagKeys(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writeResponse(w, dhs.datasource.TagKeys())
+}
+
+func (dhs *DatasourceHTTPService) TagValues(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req TagValuesRequest
+	if err := readRequest(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	values, err := dhs.datasource.TagValues(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeResponse(w, values)
+}
+
+func readRequest(r *http.Request, req interface{}) error {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, req); err != nil {
+		return fmt.Errorf("invalid request body: %s", err)
+	}
+	return nil
+}
+
+func writeResponse(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	resp, err := json.Marshal(data)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Write(resp)
+}
+
+// writeError writes the given error as the message which Grafana displays in
+// the panel of a failed query.
+func writeError(w http.ResponseWriter, code int, err error) {
+	log.Infof("Error returned to Grafana: %s", err)
+
+	resp, _ := json.Marshal(map[string]string{"message": err.Error()})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(resp)
+}
@@ -0,0 +1,399 @@
+package grafana
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/opencost/pkg/kubecost"
+	"github.com/kubecost/opencost/pkg/util"
+	"github.com/kubecost/opencost/pkg/util/json"
+)
+
+// The following requests are as Grafana 9 sends them with the JSON datasource
+// plugin, trimmed of the dashboard metadata which is not read.
+
+const searchRequest = `{"target":"alloc"}`
+
+const timeSeriesQueryRequest = `{
+  "app": "dashboard",
+  "requestId": "Q104",
+  "timezone": "browser",
+  "panelId": 2,
+  "dashboardUID": "opencost-allocations",
+  "range": {
+    "from": "2022-06-01T00:00:00.000Z",
+    "to": "2022-06-01T03:00:00.000Z",
+    "raw": {"from": "2022-06-01T00:00:00.000Z", "to": "2022-06-01T03:00:00.000Z"}
+  },
+  "timeInfo": "",
+  "interval": "1h",
+  "intervalMs": 3600000,
+  "targets": [
+    {
+      "datasource": {"type": "simpod-json-datasource", "uid": "opencost"},
+      "editorMode": "code",
+      "payload": "{\n  \"aggregate\": \"namespace\",\n  \"shareIdle\": \"weighted\"\n}",
+      "refId": "A",
+      "target": "allocation.totalCost"
+    }
+  ],
+  "maxDataPoints": 1113,
+  "scopedVars": {
+    "__interval": {"text": "1h", "value": "1h"},
+    "__interval_ms": {"text": "3600000", "value": 3600000}
+  },
+  "startTime": 1654041600000,
+  "rangeRaw": {"from": "2022-06-01T00:00:00.000Z", "to": "2022-06-01T03:00:00.000Z"},
+  "adhocFilters": [
+    {"key": "namespace", "operator": "=~", "value": "ns1|ns2", "condition": ""}
+  ]
+}`
+
+const tableQueryRequest = `{
+  "app": "dashboard",
+  "requestId": "Q105",
+  "panelId": 4,
+  "range": {
+    "from": "2022-06-01T00:00:00.000Z",
+    "to": "2022-06-02T00:00:00.000Z",
+    "raw": {"from": "now-1d", "to": "now"}
+  },
+  "interval": "1m",
+  "intervalMs": 60000,
+  "targets": [
+    {
+      "datasource": {"type": "grafana-simple-json-datasource", "uid": "opencost"},
+      "data": {"aggregate": "namespace", "filter": "namespace:\"ns1\""},
+      "refId": "A",
+      "target": "allocation",
+      "type": "table"
+    }
+  ],
+  "maxDataPoints": 800,
+  "adhocFilters": []
+}`
+
+const assetQueryRequest = `{
+  "app": "dashboard",
+  "requestId": "Q106",
+  "panelId": 6,
+  "range": {
+    "from": "2022-06-01T00:00:00.000Z",
+    "to": "2022-06-03T00:00:00.000Z",
+    "raw": {"from": "now-2d", "to": "now"}
+  },
+  "interval": "30m",
+  "intervalMs": 1800000,
+  "targets": [
+    {
+      "datasource": {"type": "simpod-json-datasource", "uid": "opencost"},
+      "payload": {"aggregate": "type", "step": "1d"},
+      "refId": "B",
+      "target": "asset.totalCost"
+    },
+    {
+      "datasource": {"type": "simpod-json-datasource", "uid": "opencost"},
+      "refId": "C",
+      "target": "asset.totalCost",
+      "hide": true
+    }
+  ],
+  "adhocFilters": [
+    {"key": "cluster", "operator": "=", "value": "cluster1", "condition": ""}
+  ]
+}`
+
+const annotationsRequest = `{
+  "range": {
+    "from": "2022-06-01T00:00:00.000Z",
+    "to": "2022-06-01T03:00:00.000Z",
+    "raw": {"from": "2022-06-01T00:00:00.000Z", "to": "2022-06-01T03:00:00.000Z"}
+  },
+  "rangeRaw": {"from": "2022-06-01T00:00:00.000Z", "to": "2022-06-01T03:00:00.000Z"},
+  "annotation": {
+    "datasource": {"type": "simpod-json-datasource", "uid": "opencost"},
+    "enable": true,
+    "iconColor": "red",
+    "name": "Nodes",
+    "query": "nodes"
+  },
+  "dashboard": {"uid": "opencost-allocations"}
+}`
+
+const tagKeysRequest = `{}`
+
+const tagValuesRequest = `{"key": "namespace"}`
+
+var testStart = time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+
+type fakeAllocations struct {
+	calls []kubecost.Window
+}
+
+func (fa *fakeAllocations) ComputeAllocation(start, end time.Time, resolution time.Duration) (*kubecost.AllocationSet, error) {
+	fa.calls = append(fa.calls, kubecost.NewClosedWindow(start, end))
+
+	alloc := func(name, ns string) *kubecost.Allocation {
+		return kubecost.NewMockUnitAllocation(name, start, end.Sub(start), &kubecost.AllocationProperties{
+			Cluster:   "cluster1",
+			Node:      "node1",
+			Namespace: ns,
+			Pod:       strings.Split(name, "/")[2],
+			Container: "container1",
+		})
+	}
+	idle := kubecost.NewMockUnitAllocation(fmt.Sprintf("cluster1/%s", kubecost.IdleSuffix), start, end.Sub(start), &kubecost.AllocationProperties{
+		Cluster: "cluster1",
+		Node:    "node1",
+	})
+
+	return kubecost.NewAllocationSet(start, end,
+		alloc("cluster1/ns1/pod1/container1", "ns1"),
+		alloc("cluster1/ns1/pod2/container1", "ns1"),
+		alloc("cluster1/ns2/pod3/container1", "ns2"),
+		alloc("cluster1/kube-system/pod4/container1", "kube-system"),
+		idle,
+	), nil
+}
+
+type fakeAssets struct{}
+
+func (fakeAssets) ComputeAssets(start, end time.Time) (*kubecost.AssetSet, error) {
+	window := kubecost.NewWindow(&start, &end)
+
+	// node1 was added an hour into the window, and removed an hour before
+	// its end; node2 ran throughout
+	node1 := kubecost.NewNode("node1", "cluster1", "i-1", start.Add(time.Hour), end.Add(-time.Hour), window)
+	node1.NodeType = "m5.large"
+	node1.CPUCost = 3
+	node2 := kubecost.NewNode("node2", "cluster1", "i-2", start, end, window)
+	node2.CPUCost = 5
+	disk := kubecost.NewDisk("disk1", "cluster1", "vol-1", start, end, window)
+	disk.Cost = 2
+	other := kubecost.NewDisk("disk2", "cluster2", "vol-2", start, end, window)
+	other.Cost = 100
+
+	return kubecost.NewAssetSet(start, end, node1, node2, disk, other), nil
+}
+
+func newTestRouter() (*httprouter.Router, *fakeAllocations) {
+	allocations := &fakeAllocations{}
+
+	router := httprouter.New()
+	NewDatasourceHTTPService(NewDatasource(allocations, fakeAssets{}, time.Minute)).Register(router)
+
+	return router, allocations
+}
+
+func post(t *testing.T, router *httprouter.Router, path, body string, resp interface{}) {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s: expected status 200; got %d: %s", path, w.Code, w.Body.String())
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil {
+		t.Fatalf("%s: unexpected error: %s", path, err)
+	}
+}
+
+func TestDatasource_TestConnection(t *testing.T) {
+	router, _ := newTestRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/grafana/", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200; got %d", w.Code)
+	}
+}
+
+func TestDatasource_Search(t *testing.T) {
+	router, _ := newTestRouter()
+
+	var targets []string
+	post(t, router, "/grafana/search", searchRequest, &targets)
+	if len(targets) != len(allocationMetrics)+1 || targets[0] != "allocation" || targets[len(targets)-1] != "allocation.totalEfficiency" {
+		t.Fatalf("expected allocation targets; got %v", targets)
+	}
+}
+
+func TestDatasource_QueryTimeSeries(t *testing.T) {
+	router, allocations := newTestRouter()
+
+	var series []TimeSeries
+	post(t, router, "/grafana/query", timeSeriesQueryRequest, &series)
+
+	// The 3 hour range is queried at the 1 hour interval
+	if len(allocations.calls) != 3 {
+		t.Fatalf("expected 3 steps; got %v", allocations.calls)
+	}
+	for i, w := range allocations.calls {
+		if !w.Start().Equal(testStart.Add(time.Duration(i)*time.Hour)) || w.Duration() != time.Hour {
+			t.Fatalf("expected step %d to be hour %d; got %s", i, i, w)
+		}
+	}
+
+	// Idle is shared, and kube-system is filtered out, leaving ns1 and ns2
+	if len(series) != 2 || series[0].Target != "ns1" || series[1].Target != "ns2" {
+		t.Fatalf("expected series ns1 and ns2; got %v", series)
+	}
+
+	unit := kubecost.NewMockUnitAllocation("", testStart, time.Hour, nil).TotalCost()
+	for _, s := range series {
+		if len(s.Datapoints) != 3 {
+			t.Fatalf("%s: expected 3 datapoints; got %v", s.Target, s.Datapoints)
+		}
+		for i, dp := range s.Datapoints {
+			if int64(dp[1]) != testStart.Add(time.Duration(i)*time.Hour).UnixNano()/int64(time.Millisecond) {
+				t.Fatalf("%s: expected datapoint %d at hour %d; got %v", s.Target, i, i, dp)
+			}
+		}
+	}
+	ns1, ns2 := series[0].Datapoints[0][0], series[1].Datapoints[0][0]
+	if ns1 <= 2*unit || ns2 <= unit {
+		t.Fatalf("expected idle to be shared; got ns1 %f and ns2 %f of unit %f", ns1, ns2, unit)
+	}
+	if !util.IsApproximately(ns1, 2*ns2) {
+		t.Fatalf("expected ns1 to cost twice ns2; got %f and %f", ns1, ns2)
+	}
+}
+
+func TestDatasource_QueryTable(t *testing.T) {
+	router, allocations := newTestRouter()
+
+	var tables []Table
+	post(t, router, "/grafana/query", tableQueryRequest, &tables)
+
+	// Tables are computed over the whole range, whatever the interval
+	if len(allocations.calls) != 1 || allocations.calls[0].Duration() != 24*time.Hour {
+		t.Fatalf("expected one day; got %v", allocations.calls)
+	}
+
+	if len(tables) != 1 {
+		t.Fatalf("expected 1 table; got %v", tables)
+	}
+	table := tables[0]
+	if table.Type != "table" || len(table.Columns) != len(allocationMetrics)+1 || table.Columns[0].Text != "name" {
+		t.Fatalf("expected a name column and a column per metric; got %v", table.Columns)
+	}
+	// Idle is not shared by default
+	if len(table.Rows) != 2 || table.Rows[0][0] != kubecost.IdleSuffix || table.Rows[1][0] != "ns1" {
+		t.Fatalf("expected rows of idle and ns1; got %v", table.Rows)
+	}
+	for i, col := range table.Columns {
+		if col.Text == "totalCost" {
+			unit := kubecost.NewMockUnitAllocation("", testStart, 24*time.Hour, nil).TotalCost()
+			if cost := table.Rows[1][i].(float64); !util.IsApproximately(cost, 2*unit) {
+				t.Fatalf("expected ns1 to cost %f; got %f", 2*unit, cost)
+			}
+		}
+	}
+}
+
+func TestDatasource_QueryAssets(t *testing.T) {
+	router, _ := newTestRouter()
+
+	var series []TimeSeries
+	post(t, router, "/grafana/query", assetQueryRequest, &series)
+
+	// The hidden target is not queried, and cluster2 is filtered out
+	if len(series) != 2 || series[0].Target != "Disk" || series[1].Target != "Node" {
+		t.Fatalf("expected series Disk and Node; got %v", series)
+	}
+	for _, s := range series {
+		if len(s.Datapoints) != 2 {
+			t.Fatalf("%s: expected a datapoint per day; got %v", s.Target, s.Datapoints)
+		}
+	}
+	if series[0].Datapoints[0][0] != 2 || series[1].Datapoints[1][0] != 8 {
+		t.Fatalf("expected disks to cost 2 and nodes 8; got %v", series)
+	}
+}
+
+func TestDatasource_Annotations(t *testing.T) {
+	router, _ := newTestRouter()
+
+	var events []AnnotationEvent
+	post(t, router, "/grafana/annotations", annotationsRequest, &events)
+
+	if len(events) != 2 {
+		t.Fatalf("expected node1 to be added and removed; got %v", events)
+	}
+	added, removed := events[0], events[1]
+	if added.Title != "Node node1 added" || added.Time != testStart.Add(time.Hour).UnixNano()/int64(time.Millisecond) {
+		t.Fatalf("expected node1 to be added at hour 1; got %v", added)
+	}
+	if removed.Title != "Node node1 removed" || removed.Time != testStart.Add(2*time.Hour).UnixNano()/int64(time.Millisecond) {
+		t.Fatalf("expected node1 to be removed at hour 2; got %v", removed)
+	}
+	if added.Annotation.Name != "Nodes" || !added.Annotation.Enable || added.Annotation.IconColor != "red" {
+		t.Fatalf("expected the annotation query to be returned; got %v", added.Annotation)
+	}
+}
+
+func TestDatasource_Tags(t *testing.T) {
+	router, _ := newTestRouter()
+
+	var keys []TagKey
+	post(t, router, "/grafana/tag-keys", tagKeysRequest, &keys)
+	if len(keys) != len(tagKeys) || keys[2].Text != "namespace" || keys[2].Type != "string" {
+		t.Fatalf("expected tag keys %v; got %v", tagKeys, keys)
+	}
+
+	var values []TagValue
+	post(t, router, "/grafana/tag-values", tagValuesRequest, &values)
+	if len(values) != 3 || values[0].Text != "kube-system" || values[1].Text != "ns1" || values[2].Text != "ns2" {
+		t.Fatalf("expected namespaces kube-system, ns1 and ns2; got %v", values)
+	}
+}
+
+func TestDatasource_QueryErrors(t *testing.T) {
+	router, _ := newTestRouter()
+
+	for _, body := range []string{
+		`{"range": {"from": "2022-06-01T00:00:00Z", "to": "2022-06-01T00:00:00Z"}, "targets": [{"target": "allocation.totalCost"}]}`,
+		`{"range": {"from": "2022-06-01T00:00:00Z", "to": "2022-06-02T00:00:00Z"}, "targets": [{"target": "allocation.unknown"}]}`,
+		`{"range": {"from": "2022-06-01T00:00:00Z", "to": "2022-06-02T00:00:00Z"}, "targets": [{"target": "unknown"}]}`,
+		`{"range": {"from": "2022-06-01T00:00:00Z", "to": "2022-06-02T00:00:00Z"}, "targets": [{"target": "allocation", "payload": {"aggregate": "invalid"}}]}`,
+		`{"range": {"from": "2022-06-01T00:00:00Z", "to": "2022-06-02T00:00:00Z"}, "targets": [{"target": "allocation", "payload": "{\"shareIdle\": \"invalid\"}"}]}`,
+		`{"range": {"from": "2022-06-01T00:00:00Z", "to": "2022-06-02T00:00:00Z"}, "targets": [{"target": "allocation"}], "adhocFilters": [{"key": "unknown", "operator": "=", "value": "x"}]}`,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/grafana/query", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"message"`) {
+			t.Errorf("%s: expected status 400 with a message; got %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestStepWindows(t *testing.T) {
+	end := testStart.Add(30 * 24 * time.Hour)
+	window := kubecost.NewClosedWindow(testStart, end)
+
+	cases := []struct {
+		name       string
+		step       string
+		intervalMs int64
+		expected   time.Duration
+		count      int
+	}{
+		{name: "step", step: "1d", expected: 24 * time.Hour, count: 30},
+		{name: "interval of at least an hour", intervalMs: 60000, expected: 4 * time.Hour, count: 180},
+		{name: "interval", intervalMs: 6 * 3600000, expected: 6 * time.Hour, count: 120},
+	}
+
+	for _, c := range cases {
+		steps, err := stepWindows(window, c.step, c.intervalMs)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", c.name, err)
+		}
+		if len(steps) != c.count || steps[0].Duration() != c.expected || !steps[len(steps)-1].End().Equal(end) {
+			t.Fatalf("%s: expected %d steps of %s; got %d of %s", c.name, c.count, c.expected, len(steps), steps[0].Duration())
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package services
+
+import (
+	"github.com/kubecost/opencost/pkg/env"
+	"github.com/kubecost/opencost/pkg/services/grafana"
+)
+
+// NewGrafanaDatasourceService creates a new HTTPService implementation serving the Grafana JSON datasource protocol
+// over the allocations and assets computed by the given computers.
+func NewGrafanaDatasourceService(allocations grafana.AllocationComputer, assets grafana.AssetComputer) HTTPService {
+	return grafana.NewDatasourceHTTPService(grafana.NewDatasource(allocations, assets, env.GetETLResolution()))
+}
@@ -0,0 +1,164 @@
+package graphql
+
+import (
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// listFactor is the assumed number of items of each list, by which the
+// complexity of the selections of list fields is multiplied.
+const listFactor = 10
+
+// fieldComplexities are the complexities of the fields which compute, or
+// join with, allocations and assets. Any other field has a complexity of 1.
+var fieldComplexities = map[string]int{
+	"Query.allocations":    25,
+	"Query.assets":         25,
+	"Allocation.nodeAsset": 2,
+	"Allocation.node":      2,
+	"Allocation.namespace": 2,
+	"Allocation.pod":       2,
+}
+
+// Complexity returns the estimated complexity of the most complex operation of
+// the given document: the sum of the complexities of its fields, with the
+// selections of list fields counted listFactor times.
+func Complexity(schema gql.Schema, doc *ast.Document) int {
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, def := range doc.Definitions {
+		if frag, ok := def.(*ast.FragmentDefinition); ok && frag.Name != nil {
+			fragments[frag.Name.Value] = frag
+		}
+	}
+
+	c := &complexityCalculator{
+		schema:    schema,
+		fragments: fragments,
+		visiting:  map[string]bool{},
+	}
+
+	max := 0
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		var root *gql.Object
+		switch op.Operation {
+		case ast.OperationTypeQuery:
+			root = schema.QueryType()
+		case ast.OperationTypeMutation:
+			root = schema.MutationType()
+		case ast.OperationTypeSubscription:
+			root = schema.SubscriptionType()
+		}
+		if root == nil {
+			continue
+		}
+
+		if complexity := c.selectionSet(op.SelectionSet, root); complexity > max {
+			max = complexity
+		}
+	}
+
+	return max
+}
+
+type complexityCalculator struct {
+	schema    gql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	visiting  map[string]bool
+}
+
+func (c *complexityCalculator) selectionSet(set *ast.SelectionSet, parent gql.Type) int {
+	if set == nil {
+		return 0
+	}
+
+	complexity := 0
+	for _, selection := range set.Selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			complexity += c.field(s, parent)
+		case *ast.InlineFragment:
+			complexity += c.selectionSet(s.SelectionSet, c.typeCondition(s.TypeCondition, parent))
+		case *ast.FragmentSpread:
+			if s.Name == nil {
+				continue
+			}
+			frag, ok := c.fragments[s.Name.Value]
+			// Cyclic fragments are invalid, and are rejected by validation, so
+			// only guard against recursing forever here
+			if !ok || c.visiting[s.Name.Value] {
+				continue
+			}
+			c.visiting[s.Name.Value] = true
+			complexity += c.selectionSet(frag.SelectionSet, c.typeCondition(frag.TypeCondition, parent))
+			delete(c.visiting, s.Name.Value)
+		}
+	}
+
+	return complexity
+}
+
+func (c *complexityCalculator) field(field *ast.Field, parent gql.Type) int {
+	if field.Name == nil {
+		return 0
+	}
+
+	// Introspection fields, and fields unknown to the schema, which are
+	// rejected by validation, have no children to count
+	obj, ok := parent.(*gql.Object)
+	if !ok {
+		return 1
+	}
+	def, ok := obj.Fields()[field.Name.Value]
+	if !ok {
+		return 1
+	}
+
+	complexity, ok := fieldComplexities[obj.Name()+"."+field.Name.Value]
+	if !ok {
+		complexity = 1
+	}
+
+	children := c.selectionSet(field.SelectionSet, unwrap(def.Type))
+	if isList(def.Type) {
+		children *= listFactor
+	}
+
+	return complexity + children
+}
+
+func (c *complexityCalculator) typeCondition(named *ast.Named, parent gql.Type) gql.Type {
+	if named == nil || named.Name == nil {
+		return parent
+	}
+	if t := c.schema.Type(named.Name.Value); t != nil {
+		return t
+	}
+	return parent
+}
+
+// unwrap returns the named type of the given list or non-null type.
+func unwrap(t gql.Type) gql.Type {
+	for {
+		switch wrapped := t.(type) {
+		case *gql.NonNull:
+			t = wrapped.OfType
+		case *gql.List:
+			t = wrapped.OfType
+		default:
+			return t
+		}
+	}
+}
+
+func isList(t gql.Type) bool {
+	if nn, ok := t.(*gql.NonNull); ok {
+		t = nn.OfType
+	}
+	_, ok := t.(*gql.List)
+	return ok
+}
@@ -0,0 +1,165 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/opencost/pkg/clustercache"
+	"github.com/kubecost/opencost/pkg/costmodel/clusters"
+	"github.com/kubecost/opencost/pkg/kubecost"
+	"github.com/kubecost/opencost/pkg/log"
+	"github.com/kubecost/opencost/pkg/util/json"
+)
+
+// Request is the body of a GraphQL request.
+type Request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+}
+
+// GraphQLHTTPService is an implementation of HTTPService which serves a
+// GraphQL API over allocations, assets and cluster metadata at /graphql.
+type GraphQLHTTPService struct {
+	schema        gql.Schema
+	allocations   AllocationComputer
+	assets        AssetComputer
+	clusterInfo   clusters.ClusterInfoProvider
+	clusterCache  clustercache.ClusterCache
+	resolution    time.Duration
+	maxComplexity int
+}
+
+// NewGraphQLHTTPService creates a new GraphQL http service, which rejects
+// queries of a complexity greater than maxComplexity, if it is positive.
+func NewGraphQLHTTPService(allocations AllocationComputer, assets AssetComputer, clusterInfo clusters.ClusterInfoProvider, cache clustercache.ClusterCache, resolution time.Duration, maxComplexity int) (*GraphQLHTTPService, error) {
+	schema, err := NewSchema()
+	if err != nil {
+		return nil, fmt.Errorf("error creating GraphQL schema: %s", err)
+	}
+
+	return &GraphQLHTTPService{
+		schema:        schema,
+		allocations:   allocations,
+		assets:        assets,
+		clusterInfo:   clusterInfo,
+		clusterCache:  cache,
+		resolution:    resolution,
+		maxComplexity: maxComplexity,
+	}, nil
+}
+
+// Register assigns the endpoints and returns an error on failure.
+func (gs *GraphQLHTTPService) Register(router *httprouter.Router) error {
+	router.GET("/graphql", gs.GetGraphQL)
+	router.POST("/graphql", gs.PostGraphQL)
+
+	return nil
+}
+
+// GetGraphQL executes the GraphQL request of the query, variables and
+// operationName query parameters.
+func (gs *GraphQLHTTPService) GetGraphQL(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	qp := r.URL.Query()
+
+	req := Request{
+		Query:         qp.Get("query"),
+		OperationName: qp.Get("operationName"),
+	}
+	if variables := qp.Get("variables"); variables != "" {
+		if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+			writeResult(w, http.StatusBadRequest, errorResult(fmt.Errorf("invalid variables: %s", err)))
+			return
+		}
+	}
+
+	gs.serve(w, r.Context(), req)
+}
+
+// PostGraphQL executes the GraphQL request of the JSON body.
+func (gs *GraphQLHTTPService) PostGraphQL(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeResult(w, http.StatusBadRequest, errorResult(err))
+		return
+	}
+
+	var req Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		writeResult(w, http.StatusBadRequest, errorResult(fmt.Errorf("invalid request body: %s", err)))
+		return
+	}
+
+	gs.serve(w, r.Context(), req)
+}
+
+func (gs *GraphQLHTTPService) serve(w http.ResponseWriter, ctx context.Context, req Request) {
+	if req.Query == "" {
+		writeResult(w, http.StatusBadRequest, errorResult(fmt.Errorf("missing query")))
+		return
+	}
+
+	writeResult(w, http.StatusOK, gs.Do(ctx, req))
+}
+
+// Do executes the given GraphQL request, after rejecting it if it is more
+// complex than allowed. Allocations and assets are only computed if the
+// request selects them, and at most once per window.
+func (gs *GraphQLHTTPService) Do(ctx context.Context, req Request) *gql.Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		return &gql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	if gs.maxComplexity > 0 {
+		if complexity := Complexity(gs.schema, doc); complexity > gs.maxComplexity {
+			return errorResult(fmt.Errorf("query complexity %d exceeds the maximum of %d", complexity, gs.maxComplexity))
+		}
+	}
+
+	l := &loader{
+		allocations:    gs.allocations,
+		assets:         gs.assets,
+		clusterInfo:    gs.clusterInfo,
+		clusterCache:   gs.clusterCache,
+		resolution:     gs.resolution,
+		allocationSets: map[string]*kubecost.AllocationSet{},
+		assetSets:      map[string]*kubecost.AssetSet{},
+		nodeAssets:     map[string]map[string]*kubecost.Node{},
+	}
+
+	return gql.Do(gql.Params{
+		Schema:         gs.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        withLoader(ctx, l),
+	})
+}
+
+func errorResult(err error) *gql.Result {
+	return &gql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(err.Error())}}
+}
+
+func writeResult(w http.ResponseWriter, code int, result *gql.Result) {
+	w.Header().Set("Content-Type", "application/json")
+
+	resp, err := json.Marshal(result)
+	if err != nil {
+		log.Errorf("GraphQL: failed to marshal result: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(code)
+	w.Write(resp)
+}
@@ -0,0 +1,281 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/opencost/pkg/clustercache"
+	"github.com/kubecost/opencost/pkg/kubecost"
+	"github.com/kubecost/opencost/pkg/util/json"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testWindow = "2022-06-01T00:00:00Z,2022-06-02T00:00:00Z"
+
+type fakeAllocations struct {
+	calls int
+}
+
+func (fa *fakeAllocations) ComputeAllocation(start, end time.Time, resolution time.Duration) (*kubecost.AllocationSet, error) {
+	fa.calls++
+
+	props := func(ns, pod string) *kubecost.AllocationProperties {
+		return &kubecost.AllocationProperties{
+			Cluster:   "cluster1",
+			Node:      "node1",
+			Namespace: ns,
+			Pod:       pod,
+			Container: "container1",
+		}
+	}
+
+	return kubecost.NewAllocationSet(start, end,
+		kubecost.NewMockUnitAllocation("cluster1/ns1/pod1/container1", start, end.Sub(start), props("ns1", "pod1")),
+		kubecost.NewMockUnitAllocation("cluster1/ns1/pod2/container1", start, end.Sub(start), props("ns1", "pod2")),
+		kubecost.NewMockUnitAllocation("cluster1/ns2/pod3/container1", start, end.Sub(start), props("ns2", "pod3")),
+	), nil
+}
+
+type fakeAssets struct {
+	calls int
+}
+
+func (fa *fakeAssets) ComputeAssets(start, end time.Time) (*kubecost.AssetSet, error) {
+	fa.calls++
+
+	window := kubecost.NewWindow(&start, &end)
+	node := kubecost.NewNode("node1", "cluster1", "i-1", start, end, window)
+	node.NodeType = "m5.large"
+	node.CPUCost = 10
+	node.RAMCost = 5
+	disk := kubecost.NewDisk("disk1", "cluster1", "vol-1", start, end, window)
+	disk.Cost = 2
+
+	return kubecost.NewAssetSet(start, end, node, disk), nil
+}
+
+type fakeClusterInfo struct{}
+
+func (fakeClusterInfo) GetClusterInfo() map[string]string {
+	return map[string]string{"id": "cluster1", "name": "Cluster One", "provider": "AWS"}
+}
+
+type fakeClusterCache struct {
+	clustercache.ClusterCache
+}
+
+func (fakeClusterCache) GetAllNamespaces() []*v1.Namespace {
+	return []*v1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "ns2"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "ns1", Labels: map[string]string{"team": "a"}}},
+	}
+}
+
+func (fakeClusterCache) GetAllNodes() []*v1.Node {
+	return []*v1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}}
+}
+
+func (fakeClusterCache) GetAllPods() []*v1.Pod {
+	return []*v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "ns1"}, Spec: v1.PodSpec{NodeName: "node1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod3", Namespace: "ns2"}, Spec: v1.PodSpec{NodeName: "node1"}},
+	}
+}
+
+func newTestService(t *testing.T, maxComplexity int) (*GraphQLHTTPService, *fakeAllocations, *fakeAssets) {
+	allocations := &fakeAllocations{}
+	assets := &fakeAssets{}
+
+	gs, err := NewGraphQLHTTPService(allocations, assets, fakeClusterInfo{}, fakeClusterCache{}, time.Minute, maxComplexity)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	return gs, allocations, assets
+}
+
+func do(t *testing.T, gs *GraphQLHTTPService, query string, variables map[string]interface{}) map[string]interface{} {
+	result := gs.Do(context.Background(), Request{Query: query, Variables: variables})
+	if result.HasErrors() {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+
+	// Round-trip the result through JSON to compare plain values
+	data, err := json.Marshal(result.Data)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	return m
+}
+
+func TestGraphQL_ComputesOnlySelectedData(t *testing.T) {
+	gs, allocations, assets := newTestService(t, 0)
+
+	data := do(t, gs, `{ clusterInfo { id name provider } namespaces { name } }`, nil)
+	if allocations.calls != 0 || assets.calls != 0 {
+		t.Fatalf("expected no allocations or assets to be computed; got %d and %d", allocations.calls, assets.calls)
+	}
+	if name := data["clusterInfo"].(map[string]interface{})["name"]; name != "Cluster One" {
+		t.Fatalf("expected cluster name \"Cluster One\"; got %v", name)
+	}
+	if ns := data["namespaces"].([]interface{}); len(ns) != 2 || ns[0].(map[string]interface{})["name"] != "ns1" {
+		t.Fatalf("expected namespaces [ns1 ns2]; got %v", ns)
+	}
+
+	do(t, gs, `query($w: String!) { allocations(window: $w) { name totalCost } }`, map[string]interface{}{"w": testWindow})
+	if allocations.calls != 1 || assets.calls != 0 {
+		t.Fatalf("expected only allocations to be computed; got %d and %d", allocations.calls, assets.calls)
+	}
+
+	// Joining allocations with their node assets computes the assets once,
+	// however many allocations join with them
+	data = do(t, gs, `query($w: String!) {
+		a: allocations(window: $w) { name nodeAsset { nodeType totalCost } }
+		b: allocations(window: $w) { name }
+	}`, map[string]interface{}{"w": testWindow})
+	if allocations.calls != 2 || assets.calls != 1 {
+		t.Fatalf("expected allocations and assets to be computed once more; got %d and %d", allocations.calls, assets.calls)
+	}
+	for _, a := range data["a"].([]interface{}) {
+		nodeAsset := a.(map[string]interface{})["nodeAsset"].(map[string]interface{})
+		if nodeAsset["nodeType"] != "m5.large" || nodeAsset["totalCost"] != 15.0 {
+			t.Fatalf("expected node asset m5.large costing 15; got %v", nodeAsset)
+		}
+	}
+}
+
+func TestGraphQL_Allocations(t *testing.T) {
+	gs, _, _ := newTestService(t, 0)
+
+	data := do(t, gs, `query($w: String!) {
+		allocations(window: $w, aggregate: ["namespace"]) { name totalCost }
+	}`, map[string]interface{}{"w": testWindow})
+	allocs := data["allocations"].([]interface{})
+	if len(allocs) != 2 {
+		t.Fatalf("expected 2 namespaces; got %v", allocs)
+	}
+	ns1 := allocs[0].(map[string]interface{})
+	ns2 := allocs[1].(map[string]interface{})
+	if ns1["name"] != "ns1" || ns2["name"] != "ns2" {
+		t.Fatalf("expected namespaces ns1 and ns2; got %v", allocs)
+	}
+	if ns1["totalCost"].(float64) != 2*ns2["totalCost"].(float64) {
+		t.Fatalf("expected ns1 to cost twice ns2; got %v", allocs)
+	}
+
+	data = do(t, gs, `query($w: String!) {
+		allocations(window: $w, filter: "namespace:\"ns1\"") { name pod { name node } namespace { labels { key value } } }
+	}`, map[string]interface{}{"w": testWindow})
+	allocs = data["allocations"].([]interface{})
+	if len(allocs) != 2 {
+		t.Fatalf("expected the 2 allocations of ns1; got %v", allocs)
+	}
+	pod1 := allocs[0].(map[string]interface{})
+	if pod := pod1["pod"].(map[string]interface{}); pod["name"] != "pod1" || pod["node"] != "node1" {
+		t.Fatalf("expected pod1 on node1; got %v", pod1["pod"])
+	}
+	if labels := pod1["namespace"].(map[string]interface{})["labels"].([]interface{}); len(labels) != 1 {
+		t.Fatalf("expected the labels of ns1; got %v", labels)
+	}
+	if pod2 := allocs[1].(map[string]interface{}); pod2["pod"] != nil {
+		t.Fatalf("expected no pod for a deleted pod; got %v", pod2["pod"])
+	}
+
+	for _, query := range []string{
+		`{ allocations(window: "invalid") { name } }`,
+		`{ allocations(window: "` + testWindow + `", aggregate: ["invalid"]) { name } }`,
+		`{ allocations(window: "` + testWindow + `", filter: "namespace:") { name } }`,
+	} {
+		if result := gs.Do(context.Background(), Request{Query: query}); !result.HasErrors() {
+			t.Errorf("%s: expected error", query)
+		}
+	}
+}
+
+func TestGraphQL_Assets(t *testing.T) {
+	gs, _, _ := newTestService(t, 0)
+
+	data := do(t, gs, `query($w: String!) {
+		all: assets(window: $w) { type properties { name } totalCost }
+		disks: assets(window: $w, filter: {type: "Disk"}) { properties { name } }
+		byType: assets(window: $w, aggregate: ["type"]) { type totalCost }
+	}`, map[string]interface{}{"w": testWindow})
+
+	if all := data["all"].([]interface{}); len(all) != 2 {
+		t.Fatalf("expected 2 assets; got %v", all)
+	}
+	disks := data["disks"].([]interface{})
+	if len(disks) != 1 || disks[0].(map[string]interface{})["properties"].(map[string]interface{})["name"] != "disk1" {
+		t.Fatalf("expected disk1; got %v", disks)
+	}
+	if byType := data["byType"].([]interface{}); len(byType) != 2 {
+		t.Fatalf("expected 2 asset types; got %v", byType)
+	}
+}
+
+func TestGraphQL_Complexity(t *testing.T) {
+	gs, allocations, _ := newTestService(t, 100)
+
+	// 1 + 10 * 1
+	do(t, gs, `{ namespaces { name } }`, nil)
+
+	// 25 + 10 * (1 + 2 + (1 + 10 * 1)) = 165
+	query := `query($w: String!) { allocations(window: $w) { name ...joins } }
+		fragment joins on Allocation { pod { labels { key } } }`
+	result := gs.Do(context.Background(), Request{Query: query, Variables: map[string]interface{}{"w": testWindow}})
+	if !result.HasErrors() || !strings.Contains(result.Errors[0].Message, "complexity 165") {
+		t.Fatalf("expected query of complexity 165 to be rejected; got %v", result.Errors)
+	}
+	if allocations.calls != 0 {
+		t.Fatalf("expected rejected query not to compute allocations")
+	}
+}
+
+func TestGraphQLHTTPService(t *testing.T) {
+	gs, _, _ := newTestService(t, 0)
+
+	router := httprouter.New()
+	gs.Register(router)
+
+	body, _ := json.Marshal(Request{Query: `{ clusterInfo { id } }`})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200; got %d", w.Code)
+	}
+
+	var result gql.Result
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id := result.Data.(map[string]interface{})["clusterInfo"].(map[string]interface{})["id"]; id != "cluster1" {
+		t.Fatalf("expected cluster id \"cluster1\"; got %v", id)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/graphql?query=%7B%20nodes%20%7B%20name%20%7D%20%7D", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"node1"`) {
+		t.Fatalf("expected node1; got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for a missing query; got %d", w.Code)
+	}
+}
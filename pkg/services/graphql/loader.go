@@ -0,0 +1,363 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubecost/opencost/pkg/clustercache"
+	"github.com/kubecost/opencost/pkg/costmodel/clusters"
+	"github.com/kubecost/opencost/pkg/kubecost"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AllocationComputer computes the unaggregated AllocationSet of a window.
+type AllocationComputer interface {
+	ComputeAllocation(start, end time.Time, resolution time.Duration) (*kubecost.AllocationSet, error)
+}
+
+// AssetComputer computes the AssetSet of a window.
+type AssetComputer interface {
+	ComputeAssets(start, end time.Time) (*kubecost.AssetSet, error)
+}
+
+// allocation is an Allocation resolved by a query, with the window of the
+// query, which is used to join it with the assets of the same window.
+type allocation struct {
+	*kubecost.Allocation
+	window kubecost.Window
+}
+
+// controller is a Deployment, StatefulSet or DaemonSet of the ClusterCache.
+type controller struct {
+	metav1.Object
+	kind     string
+	replicas int32
+}
+
+// label is a key/value pair of labels, annotations or other string maps,
+// which GraphQL cannot represent.
+type label struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// resourceQuantity is a quantity of a resource, e.g. a node's capacity, or
+// the cost of a resource.
+type resourceQuantity struct {
+	Resource string  `json:"resource"`
+	Value    float64 `json:"value"`
+}
+
+// loaderKey is the context key of the loader of a request.
+type loaderKey struct{}
+
+// loader lazily computes and caches the data of a single GraphQL request,
+// so that only the data of the requested fields is computed, and only once,
+// however many times the fields are resolved.
+type loader struct {
+	sync.Mutex
+	allocations    AllocationComputer
+	assets         AssetComputer
+	clusterInfo    clusters.ClusterInfoProvider
+	clusterCache   clustercache.ClusterCache
+	resolution     time.Duration
+	allocationSets map[string]*kubecost.AllocationSet
+	assetSets      map[string]*kubecost.AssetSet
+	nodeAssets     map[string]map[string]*kubecost.Node
+	info           map[string]string
+	kube           *kubeIndex
+}
+
+// kubeIndex indexes the objects of the ClusterCache by name.
+type kubeIndex struct {
+	namespaces map[string]*v1.Namespace
+	nodes      map[string]*v1.Node
+	pods       map[string]*v1.Pod
+}
+
+func withLoader(ctx context.Context, l *loader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, l)
+}
+
+func loaderFrom(ctx context.Context) (*loader, error) {
+	l, ok := ctx.Value(loaderKey{}).(*loader)
+	if !ok || l == nil {
+		return nil, fmt.Errorf("missing request loader")
+	}
+	return l, nil
+}
+
+// allocationSet returns a copy of the unaggregated AllocationSet of the
+// window, computing it on first use.
+func (l *loader) allocationSet(window kubecost.Window) (*kubecost.AllocationSet, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.allocations == nil {
+		return nil, fmt.Errorf("allocations are not available")
+	}
+
+	key := window.String()
+	if as, ok := l.allocationSets[key]; ok {
+		return as.Clone(), nil
+	}
+
+	as, err := l.allocations.ComputeAllocation(*window.Start(), *window.End(), l.resolution)
+	if err != nil {
+		return nil, err
+	}
+	l.allocationSets[key] = as
+
+	return as.Clone(), nil
+}
+
+// assetSet returns a copy of the AssetSet of the window, computing it on
+// first use.
+func (l *loader) assetSet(window kubecost.Window) (*kubecost.AssetSet, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.assetSetLocked(window)
+}
+
+func (l *loader) assetSetLocked(window kubecost.Window) (*kubecost.AssetSet, error) {
+	if l.assets == nil {
+		return nil, fmt.Errorf("assets are not available")
+	}
+
+	key := window.String()
+	if as, ok := l.assetSets[key]; ok {
+		return as.Clone(), nil
+	}
+
+	as, err := l.assets.ComputeAssets(*window.Start(), *window.End())
+	if err != nil {
+		return nil, err
+	}
+	l.assetSets[key] = as
+
+	return as.Clone(), nil
+}
+
+// nodeAsset returns the Node asset of the given cluster and node in the
+// window, if there is one.
+func (l *loader) nodeAsset(window kubecost.Window, cluster, node string) (*kubecost.Node, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	key := window.String()
+	if _, ok := l.nodeAssets[key]; !ok {
+		as, err := l.assetSetLocked(window)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes := map[string]*kubecost.Node{}
+		as.Each(func(_ string, a kubecost.Asset) {
+			if n, ok := a.(*kubecost.Node); ok {
+				nodes[n.Properties().Cluster+"/"+n.Properties().Name] = n
+			}
+		})
+		l.nodeAssets[key] = nodes
+	}
+
+	return l.nodeAssets[key][cluster+"/"+node], nil
+}
+
+// clusterInfoMap returns the info of the local cluster.
+func (l *loader) clusterInfoMap() map[string]string {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.info == nil {
+		l.info = map[string]string{}
+		if l.clusterInfo != nil {
+			for k, v := range l.clusterInfo.GetClusterInfo() {
+				l.info[k] = v
+			}
+		}
+	}
+
+	return l.info
+}
+
+// isLocalCluster returns true if the given cluster is the local cluster, of
+// which the ClusterCache holds the objects.
+func (l *loader) isLocalCluster(cluster string) bool {
+	id := l.clusterInfoMap()["id"]
+	return id == "" || cluster == "" || cluster == id
+}
+
+// kubeObjects returns the index of the objects of the ClusterCache, building
+// it on first use.
+func (l *loader) kubeObjects() *kubeIndex {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.kube != nil {
+		return l.kube
+	}
+
+	l.kube = &kubeIndex{
+		namespaces: map[string]*v1.Namespace{},
+		nodes:      map[string]*v1.Node{},
+		pods:       map[string]*v1.Pod{},
+	}
+	if l.clusterCache == nil {
+		return l.kube
+	}
+
+	for _, ns := range l.clusterCache.GetAllNamespaces() {
+		l.kube.namespaces[ns.Name] = ns
+	}
+	for _, node := range l.clusterCache.GetAllNodes() {
+		l.kube.nodes[node.Name] = node
+	}
+	for _, pod := range l.clusterCache.GetAllPods() {
+		l.kube.pods[pod.Namespace+"/"+pod.Name] = pod
+	}
+
+	return l.kube
+}
+
+// namespaces returns the namespaces of the ClusterCache, by name.
+func (l *loader) namespaces() []*v1.Namespace {
+	if l.clusterCache == nil {
+		return []*v1.Namespace{}
+	}
+
+	namespaces := l.clusterCache.GetAllNamespaces()
+	sort.Slice(namespaces, func(i, j int) bool {
+		return namespaces[i].Name < namespaces[j].Name
+	})
+	return namespaces
+}
+
+// nodes returns the nodes of the ClusterCache, by name.
+func (l *loader) nodes() []*v1.Node {
+	if l.clusterCache == nil {
+		return []*v1.Node{}
+	}
+
+	nodes := l.clusterCache.GetAllNodes()
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+	return nodes
+}
+
+// pods returns the pods of the ClusterCache in the given namespace, or in all
+// namespaces, by namespace and name.
+func (l *loader) pods(namespace string) []*v1.Pod {
+	pods := []*v1.Pod{}
+	if l.clusterCache == nil {
+		return pods
+	}
+
+	for _, pod := range l.clusterCache.GetAllPods() {
+		if namespace == "" || pod.Namespace == namespace {
+			pods = append(pods, pod)
+		}
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+	return pods
+}
+
+// controllers returns the Deployments, StatefulSets and DaemonSets of the
+// ClusterCache in the given namespace and of the given kind, or of all, by
+// kind, namespace and name.
+func (l *loader) controllers(namespace, kind string) []*controller {
+	controllers := []*controller{}
+	if l.clusterCache == nil {
+		return controllers
+	}
+
+	add := func(obj metav1.Object, k string, replicas int32) {
+		if namespace != "" && obj.GetNamespace() != namespace {
+			return
+		}
+		if kind != "" && !strings.EqualFold(kind, k) {
+			return
+		}
+		controllers = append(controllers, &controller{Object: obj, kind: k, replicas: replicas})
+	}
+
+	for _, d := range l.clusterCache.GetAllDeployments() {
+		add(d, "deployment", replicasOf(d))
+	}
+	for _, ss := range l.clusterCache.GetAllStatefulSets() {
+		replicas := int32(1)
+		if ss.Spec.Replicas != nil {
+			replicas = *ss.Spec.Replicas
+		}
+		add(ss, "statefulset", replicas)
+	}
+	for _, ds := range l.clusterCache.GetAllDaemonSets() {
+		add(ds, "daemonset", ds.Status.DesiredNumberScheduled)
+	}
+
+	sort.Slice(controllers, func(i, j int) bool {
+		if controllers[i].kind != controllers[j].kind {
+			return controllers[i].kind < controllers[j].kind
+		}
+		if controllers[i].GetNamespace() != controllers[j].GetNamespace() {
+			return controllers[i].GetNamespace() < controllers[j].GetNamespace()
+		}
+		return controllers[i].GetName() < controllers[j].GetName()
+	})
+	return controllers
+}
+
+func replicasOf(d *appsv1.Deployment) int32 {
+	if d.Spec.Replicas == nil {
+		return 1
+	}
+	return *d.Spec.Replicas
+}
+
+// toLabels converts the given map to labels, by key.
+func toLabels(m map[string]string) []label {
+	labels := make([]label, 0, len(m))
+	for k, v := range m {
+		labels = append(labels, label{Key: k, Value: v})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Key < labels[j].Key
+	})
+	return labels
+}
+
+// toResourceQuantities converts the given map to resource quantities, by
+// resource.
+func toResourceQuantities(m map[string]float64) []resourceQuantity {
+	quantities := make([]resourceQuantity, 0, len(m))
+	for r, v := range m {
+		quantities = append(quantities, resourceQuantity{Resource: r, Value: v})
+	}
+	sort.Slice(quantities, func(i, j int) bool {
+		return quantities[i].Resource < quantities[j].Resource
+	})
+	return quantities
+}
+
+// toResourceList converts the given Kubernetes ResourceList to resource
+// quantities, by resource.
+func toResourceList(rl v1.ResourceList) []resourceQuantity {
+	m := make(map[string]float64, len(rl))
+	for name, q := range rl {
+		m[string(name)] = q.AsApproximateFloat64()
+	}
+	return toResourceQuantities(m)
+}
@@ -0,0 +1,715 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	gql "github.com/graphql-go/graphql"
+
+	"github.com/kubecost/opencost/pkg/env"
+	"github.com/kubecost/opencost/pkg/kubecost"
+	allocationfilterutil "github.com/kubecost/opencost/pkg/util/allocationfilterutil/v2"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// NewSchema returns the GraphQL schema of allocations, assets and cluster
+// metadata. Resolvers read their data from the loader of the request context,
+// so that data is only computed for the fields that a query selects.
+func NewSchema() (gql.Schema, error) {
+	labelType := gql.NewObject(gql.ObjectConfig{
+		Name:        "Label",
+		Description: "A key/value pair of a string map, e.g. labels or annotations.",
+		Fields: gql.Fields{
+			"key":   &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"value": &gql.Field{Type: gql.NewNonNull(gql.String)},
+		},
+	})
+
+	resourceQuantityType := gql.NewObject(gql.ObjectConfig{
+		Name:        "ResourceQuantity",
+		Description: "A quantity, or cost, of a resource.",
+		Fields: gql.Fields{
+			"resource": &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"value":    &gql.Field{Type: gql.NewNonNull(gql.Float)},
+		},
+	})
+
+	labelsField := func(f func(interface{}) map[string]string) *gql.Field {
+		return &gql.Field{
+			Type: gql.NewList(gql.NewNonNull(labelType)),
+			Resolve: func(p gql.ResolveParams) (interface{}, error) {
+				return toLabels(f(p.Source)), nil
+			},
+		}
+	}
+
+	clusterInfoType := gql.NewObject(gql.ObjectConfig{
+		Name:        "ClusterInfo",
+		Description: "The metadata of the local cluster.",
+		Fields: gql.Fields{
+			"id":          clusterInfoField("id"),
+			"name":        clusterInfoField("name"),
+			"provider":    clusterInfoField("provider"),
+			"account":     clusterInfoField("account"),
+			"project":     clusterInfoField("project"),
+			"region":      clusterInfoField("region"),
+			"provisioner": clusterInfoField("provisioner"),
+			"version":     clusterInfoField("version"),
+			"entries": labelsField(func(src interface{}) map[string]string {
+				return src.(map[string]string)
+			}),
+		},
+	})
+
+	podType := gql.NewObject(gql.ObjectConfig{
+		Name:        "Pod",
+		Description: "A pod of the local cluster.",
+		Fields: gql.Fields{
+			"name":      podField(func(p *v1.Pod) interface{} { return p.Name }),
+			"namespace": podField(func(p *v1.Pod) interface{} { return p.Namespace }),
+			"node":      podField(func(p *v1.Pod) interface{} { return p.Spec.NodeName }),
+			"phase":     podField(func(p *v1.Pod) interface{} { return string(p.Status.Phase) }),
+			"qosClass":  podField(func(p *v1.Pod) interface{} { return string(p.Status.QOSClass) }),
+			"ownerKind": podField(func(p *v1.Pod) interface{} {
+				if len(p.OwnerReferences) == 0 {
+					return nil
+				}
+				return p.OwnerReferences[0].Kind
+			}),
+			"ownerName": podField(func(p *v1.Pod) interface{} {
+				if len(p.OwnerReferences) == 0 {
+					return nil
+				}
+				return p.OwnerReferences[0].Name
+			}),
+			"labels": labelsField(func(src interface{}) map[string]string {
+				return src.(*v1.Pod).Labels
+			}),
+			"annotations": labelsField(func(src interface{}) map[string]string {
+				return src.(*v1.Pod).Annotations
+			}),
+		},
+	})
+
+	namespaceType := gql.NewObject(gql.ObjectConfig{
+		Name:        "Namespace",
+		Description: "A namespace of the local cluster.",
+		Fields: gql.Fields{
+			"name": &gql.Field{
+				Type: gql.NewNonNull(gql.String),
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return p.Source.(*v1.Namespace).Name, nil
+				},
+			},
+			"phase": &gql.Field{
+				Type: gql.String,
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return string(p.Source.(*v1.Namespace).Status.Phase), nil
+				},
+			},
+			"labels": labelsField(func(src interface{}) map[string]string {
+				return src.(*v1.Namespace).Labels
+			}),
+			"annotations": labelsField(func(src interface{}) map[string]string {
+				return src.(*v1.Namespace).Annotations
+			}),
+			"pods": &gql.Field{
+				Type: gql.NewList(gql.NewNonNull(podType)),
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					l, err := loaderFrom(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					return l.pods(p.Source.(*v1.Namespace).Name), nil
+				},
+			},
+		},
+	})
+
+	nodeType := gql.NewObject(gql.ObjectConfig{
+		Name:        "Node",
+		Description: "A node of the local cluster.",
+		Fields: gql.Fields{
+			"name": &gql.Field{
+				Type: gql.NewNonNull(gql.String),
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return p.Source.(*v1.Node).Name, nil
+				},
+			},
+			"providerID": &gql.Field{
+				Type: gql.String,
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return p.Source.(*v1.Node).Spec.ProviderID, nil
+				},
+			},
+			"instanceType": &gql.Field{
+				Type: gql.String,
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					node := p.Source.(*v1.Node)
+					if it, ok := node.Labels[v1.LabelInstanceTypeStable]; ok {
+						return it, nil
+					}
+					return node.Labels[v1.LabelInstanceType], nil
+				},
+			},
+			"unschedulable": &gql.Field{
+				Type: gql.Boolean,
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return p.Source.(*v1.Node).Spec.Unschedulable, nil
+				},
+			},
+			"capacity": &gql.Field{
+				Type: gql.NewList(gql.NewNonNull(resourceQuantityType)),
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return toResourceList(p.Source.(*v1.Node).Status.Capacity), nil
+				},
+			},
+			"allocatable": &gql.Field{
+				Type: gql.NewList(gql.NewNonNull(resourceQuantityType)),
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return toResourceList(p.Source.(*v1.Node).Status.Allocatable), nil
+				},
+			},
+			"labels": labelsField(func(src interface{}) map[string]string {
+				return src.(*v1.Node).Labels
+			}),
+		},
+	})
+
+	controllerType := gql.NewObject(gql.ObjectConfig{
+		Name:        "Controller",
+		Description: "A Deployment, StatefulSet or DaemonSet of the local cluster.",
+		Fields: gql.Fields{
+			"kind": &gql.Field{
+				Type: gql.NewNonNull(gql.String),
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return p.Source.(*controller).kind, nil
+				},
+			},
+			"name": &gql.Field{
+				Type: gql.NewNonNull(gql.String),
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return p.Source.(*controller).GetName(), nil
+				},
+			},
+			"namespace": &gql.Field{
+				Type: gql.NewNonNull(gql.String),
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return p.Source.(*controller).GetNamespace(), nil
+				},
+			},
+			"replicas": &gql.Field{
+				Type: gql.Int,
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return int(p.Source.(*controller).replicas), nil
+				},
+			},
+			"labels": labelsField(func(src interface{}) map[string]string {
+				return src.(*controller).GetLabels()
+			}),
+		},
+	})
+
+	assetPropertiesType := gql.NewObject(gql.ObjectConfig{
+		Name: "AssetProperties",
+		Fields: gql.Fields{
+			"category":   &gql.Field{Type: gql.String},
+			"provider":   &gql.Field{Type: gql.String},
+			"account":    &gql.Field{Type: gql.String},
+			"project":    &gql.Field{Type: gql.String},
+			"service":    &gql.Field{Type: gql.String},
+			"cluster":    &gql.Field{Type: gql.String},
+			"name":       &gql.Field{Type: gql.String},
+			"providerID": &gql.Field{Type: gql.String},
+		},
+	})
+
+	assetType := gql.NewObject(gql.ObjectConfig{
+		Name:        "Asset",
+		Description: "A cost-bearing asset, e.g. a node, disk or load balancer. Node costs are only set on nodes.",
+		Fields: gql.Fields{
+			"type":       assetField(gql.NewNonNull(gql.String), func(a kubecost.Asset) interface{} { return a.Type().String() }),
+			"properties": assetField(assetPropertiesType, func(a kubecost.Asset) interface{} { return a.Properties() }),
+			"labels": labelsField(func(src interface{}) map[string]string {
+				return src.(kubecost.Asset).Labels()
+			}),
+			"start":      assetField(gql.NewNonNull(gql.DateTime), func(a kubecost.Asset) interface{} { return a.Start() }),
+			"end":        assetField(gql.NewNonNull(gql.DateTime), func(a kubecost.Asset) interface{} { return a.End() }),
+			"minutes":    assetField(gql.NewNonNull(gql.Float), func(a kubecost.Asset) interface{} { return a.Minutes() }),
+			"adjustment": assetField(gql.NewNonNull(gql.Float), func(a kubecost.Asset) interface{} { return a.Adjustment() }),
+			"totalCost":  assetField(gql.NewNonNull(gql.Float), func(a kubecost.Asset) interface{} { return a.TotalCost() }),
+			"nodeType":   nodeAssetField(gql.String, func(n *kubecost.Node) interface{} { return n.NodeType }),
+			"cpuCost":    nodeAssetField(gql.Float, func(n *kubecost.Node) interface{} { return n.CPUCost }),
+			"gpuCost":    nodeAssetField(gql.Float, func(n *kubecost.Node) interface{} { return n.GPUCost }),
+			"ramCost":    nodeAssetField(gql.Float, func(n *kubecost.Node) interface{} { return n.RAMCost }),
+			"discount":   nodeAssetField(gql.Float, func(n *kubecost.Node) interface{} { return n.Discount }),
+		},
+	})
+
+	allocationPropertiesType := gql.NewObject(gql.ObjectConfig{
+		Name: "AllocationProperties",
+		Fields: gql.Fields{
+			"cluster":        &gql.Field{Type: gql.String},
+			"node":           &gql.Field{Type: gql.String},
+			"container":      &gql.Field{Type: gql.String},
+			"controller":     &gql.Field{Type: gql.String},
+			"controllerKind": &gql.Field{Type: gql.String},
+			"namespace":      &gql.Field{Type: gql.String},
+			"pod":            &gql.Field{Type: gql.String},
+			"services":       &gql.Field{Type: gql.NewList(gql.NewNonNull(gql.String))},
+			"providerID":     &gql.Field{Type: gql.String},
+			"labels": labelsField(func(src interface{}) map[string]string {
+				return src.(*kubecost.AllocationProperties).Labels
+			}),
+			"annotations": labelsField(func(src interface{}) map[string]string {
+				return src.(*kubecost.AllocationProperties).Annotations
+			}),
+		},
+	})
+
+	allocationType := gql.NewObject(gql.ObjectConfig{
+		Name:        "Allocation",
+		Description: "The costs and resources of a workload, or of an aggregate of workloads, over the queried window.",
+		Fields: gql.Fields{
+			"name":                  allocationField(gql.NewNonNull(gql.String), func(a *allocation) interface{} { return a.Name }),
+			"properties":            allocationField(allocationPropertiesType, func(a *allocation) interface{} { return a.Properties }),
+			"start":                 allocationField(gql.NewNonNull(gql.DateTime), func(a *allocation) interface{} { return a.Start }),
+			"end":                   allocationField(gql.NewNonNull(gql.DateTime), func(a *allocation) interface{} { return a.End }),
+			"minutes":               allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.Minutes() }),
+			"cpuCores":              allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.CPUCores() }),
+			"cpuCoreHours":          allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.CPUCoreHours }),
+			"cpuCoreRequestAverage": allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.CPUCoreRequestAverage }),
+			"cpuCoreUsageAverage":   allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.CPUCoreUsageAverage }),
+			"cpuCost":               allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.CPUTotalCost() }),
+			"gpus":                  allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.GPUs() }),
+			"gpuHours":              allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.GPUHours }),
+			"gpuCost":               allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.GPUTotalCost() }),
+			"ramBytes":              allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.RAMBytes() }),
+			"ramByteHours":          allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.RAMByteHours }),
+			"ramBytesRequestAverage": allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} {
+				return a.RAMBytesRequestAverage
+			}),
+			"ramBytesUsageAverage": allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.RAMBytesUsageAverage }),
+			"ramCost":              allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.RAMTotalCost() }),
+			"pvBytes":              allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.PVBytes() }),
+			"pvByteHours":          allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.PVByteHours() }),
+			"pvCost":               allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.PVTotalCost() }),
+			"networkCost":          allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.NetworkTotalCost() }),
+			"loadBalancerCost":     allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.LoadBalancerTotalCost() }),
+			"sharedCost":           allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.SharedTotalCost() }),
+			"externalCost":         allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.ExternalCost }),
+			"extendedResourceCosts": allocationField(gql.NewList(gql.NewNonNull(resourceQuantityType)), func(a *allocation) interface{} {
+				return toResourceQuantities(a.ExtendedResourceCosts)
+			}),
+			"totalCost":       allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.TotalCost() }),
+			"cpuEfficiency":   allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.CPUEfficiency() }),
+			"ramEfficiency":   allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.RAMEfficiency() }),
+			"totalEfficiency": allocationField(gql.NewNonNull(gql.Float), func(a *allocation) interface{} { return a.TotalEfficiency() }),
+			"nodeAsset": &gql.Field{
+				Type:        assetType,
+				Description: "The Node asset, over the same window, of the node of the allocation.",
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					a := p.Source.(*allocation)
+					if a.Properties == nil || a.Properties.Node == "" {
+						return nil, nil
+					}
+					l, err := loaderFrom(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					n, err := l.nodeAsset(a.window, a.Properties.Cluster, a.Properties.Node)
+					if err != nil || n == nil {
+						return nil, err
+					}
+					return n, nil
+				},
+			},
+			"node": kubeJoinField(nodeType, "The current node of the allocation, if it is in the local cluster.", func(idx *kubeIndex, props *kubecost.AllocationProperties) interface{} {
+				if n, ok := idx.nodes[props.Node]; ok {
+					return n
+				}
+				return nil
+			}),
+			"namespace": kubeJoinField(namespaceType, "The current namespace of the allocation, if it is in the local cluster.", func(idx *kubeIndex, props *kubecost.AllocationProperties) interface{} {
+				if ns, ok := idx.namespaces[props.Namespace]; ok {
+					return ns
+				}
+				return nil
+			}),
+			"pod": kubeJoinField(podType, "The current pod of the allocation, if it is in the local cluster.", func(idx *kubeIndex, props *kubecost.AllocationProperties) interface{} {
+				if pod, ok := idx.pods[props.Namespace+"/"+props.Pod]; ok {
+					return pod
+				}
+				return nil
+			}),
+		},
+	})
+
+	assetFilterType := gql.NewInputObject(gql.InputObjectConfig{
+		Name:        "AssetFilter",
+		Description: "Matches the assets of which every given property equals the given value.",
+		Fields: gql.InputObjectConfigFieldMap{
+			"cluster":    &gql.InputObjectFieldConfig{Type: gql.String},
+			"type":       &gql.InputObjectFieldConfig{Type: gql.String},
+			"category":   &gql.InputObjectFieldConfig{Type: gql.String},
+			"service":    &gql.InputObjectFieldConfig{Type: gql.String},
+			"providerID": &gql.InputObjectFieldConfig{Type: gql.String},
+			"name":       &gql.InputObjectFieldConfig{Type: gql.String},
+		},
+	})
+
+	queryType := gql.NewObject(gql.ObjectConfig{
+		Name: "Query",
+		Fields: gql.Fields{
+			"allocations": &gql.Field{
+				Type:        gql.NewList(gql.NewNonNull(allocationType)),
+				Description: "The allocations of the window, e.g. \"24h\" or \"2022-06-01T00:00:00Z,2022-06-02T00:00:00Z\", matching the filter, aggregated by the given properties, e.g. \"namespace\" or \"label:app\".",
+				Args: gql.FieldConfigArgument{
+					"window":    &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+					"aggregate": &gql.ArgumentConfig{Type: gql.NewList(gql.NewNonNull(gql.String))},
+					"filter":    &gql.ArgumentConfig{Type: gql.String},
+				},
+				Resolve: resolveAllocations,
+			},
+			"assets": &gql.Field{
+				Type:        gql.NewList(gql.NewNonNull(assetType)),
+				Description: "The assets of the window matching the filter, aggregated by the given properties, e.g. \"type\" or \"cluster\".",
+				Args: gql.FieldConfigArgument{
+					"window":    &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+					"aggregate": &gql.ArgumentConfig{Type: gql.NewList(gql.NewNonNull(gql.String))},
+					"filter":    &gql.ArgumentConfig{Type: assetFilterType},
+				},
+				Resolve: resolveAssets,
+			},
+			"clusterInfo": &gql.Field{
+				Type: gql.NewNonNull(clusterInfoType),
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					l, err := loaderFrom(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					return l.clusterInfoMap(), nil
+				},
+			},
+			"namespaces": &gql.Field{
+				Type: gql.NewList(gql.NewNonNull(namespaceType)),
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					l, err := loaderFrom(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					return l.namespaces(), nil
+				},
+			},
+			"nodes": &gql.Field{
+				Type: gql.NewList(gql.NewNonNull(nodeType)),
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					l, err := loaderFrom(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					return l.nodes(), nil
+				},
+			},
+			"pods": &gql.Field{
+				Type: gql.NewList(gql.NewNonNull(podType)),
+				Args: gql.FieldConfigArgument{
+					"namespace": &gql.ArgumentConfig{Type: gql.String},
+				},
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					l, err := loaderFrom(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					namespace, _ := p.Args["namespace"].(string)
+					return l.pods(namespace), nil
+				},
+			},
+			"controllers": &gql.Field{
+				Type: gql.NewList(gql.NewNonNull(controllerType)),
+				Args: gql.FieldConfigArgument{
+					"namespace": &gql.ArgumentConfig{Type: gql.String},
+					"kind":      &gql.ArgumentConfig{Type: gql.String, Description: "deployment, statefulset or daemonset"},
+				},
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					l, err := loaderFrom(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					namespace, _ := p.Args["namespace"].(string)
+					kind, _ := p.Args["kind"].(string)
+					return l.controllers(namespace, kind), nil
+				},
+			},
+		},
+	})
+
+	return gql.NewSchema(gql.SchemaConfig{Query: queryType})
+}
+
+func clusterInfoField(key string) *gql.Field {
+	return &gql.Field{
+		Type: gql.String,
+		Resolve: func(p gql.ResolveParams) (interface{}, error) {
+			if v, ok := p.Source.(map[string]string)[key]; ok {
+				return v, nil
+			}
+			return nil, nil
+		},
+	}
+}
+
+func podField(f func(*v1.Pod) interface{}) *gql.Field {
+	return &gql.Field{
+		Type: gql.String,
+		Resolve: func(p gql.ResolveParams) (interface{}, error) {
+			return f(p.Source.(*v1.Pod)), nil
+		},
+	}
+}
+
+func assetField(t gql.Output, f func(kubecost.Asset) interface{}) *gql.Field {
+	return &gql.Field{
+		Type: t,
+		Resolve: func(p gql.ResolveParams) (interface{}, error) {
+			return f(p.Source.(kubecost.Asset)), nil
+		},
+	}
+}
+
+func nodeAssetField(t gql.Output, f func(*kubecost.Node) interface{}) *gql.Field {
+	return &gql.Field{
+		Type: t,
+		Resolve: func(p gql.ResolveParams) (interface{}, error) {
+			if n, ok := p.Source.(*kubecost.Node); ok {
+				return f(n), nil
+			}
+			return nil, nil
+		},
+	}
+}
+
+func allocationField(t gql.Output, f func(*allocation) interface{}) *gql.Field {
+	return &gql.Field{
+		Type: t,
+		Resolve: func(p gql.ResolveParams) (interface{}, error) {
+			return f(p.Source.(*allocation)), nil
+		},
+	}
+}
+
+// kubeJoinField returns a field joining an allocation with the current object
+// of the ClusterCache found by the given function. Allocations of other
+// clusters, or of objects which no longer exist, join with null.
+func kubeJoinField(t gql.Output, description string, find func(*kubeIndex, *kubecost.AllocationProperties) interface{}) *gql.Field {
+	return &gql.Field{
+		Type:        t,
+		Description: description,
+		Resolve: func(p gql.ResolveParams) (interface{}, error) {
+			a := p.Source.(*allocation)
+			if a.Properties == nil {
+				return nil, nil
+			}
+			l, err := loaderFrom(p.Context)
+			if err != nil {
+				return nil, err
+			}
+			if !l.isLocalCluster(a.Properties.Cluster) {
+				return nil, nil
+			}
+			return find(l.kubeObjects(), a.Properties), nil
+		},
+	}
+}
+
+func resolveAllocations(p gql.ResolveParams) (interface{}, error) {
+	l, err := loaderFrom(p.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	window, err := parseWindow(p.Args["window"])
+	if err != nil {
+		return nil, err
+	}
+
+	aggregateBy, err := parseAllocationAggregate(p.Args["aggregate"])
+	if err != nil {
+		return nil, err
+	}
+
+	var filter kubecost.AllocationFilter
+	if text, ok := p.Args["filter"].(string); ok && strings.TrimSpace(text) != "" {
+		filter, err = allocationfilterutil.ParseAllocationFilter(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %s", err)
+		}
+	}
+
+	as, err := l.allocationSet(window)
+	if err != nil {
+		return nil, fmt.Errorf("error computing allocations for %s: %s", window, err)
+	}
+
+	// Filter before aggregating, rather than by way of the aggregation
+	// options, so that filtered but unaggregated queries are not collapsed
+	// into a single allocation.
+	if filter != nil {
+		as.Each(func(name string, a *kubecost.Allocation) {
+			if !filter.Matches(a) {
+				as.Delete(name)
+			}
+		})
+	}
+
+	if len(aggregateBy) > 0 {
+		err = as.AggregateBy(aggregateBy, &kubecost.AllocationAggregationOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error aggregating allocations by %s: %s", strings.Join(aggregateBy, ","), err)
+		}
+	}
+
+	allocations := []*allocation{}
+	as.Each(func(_ string, a *kubecost.Allocation) {
+		allocations = append(allocations, &allocation{Allocation: a, window: window})
+	})
+	sort.Slice(allocations, func(i, j int) bool {
+		return allocations[i].Name < allocations[j].Name
+	})
+
+	return allocations, nil
+}
+
+func resolveAssets(p gql.ResolveParams) (interface{}, error) {
+	l, err := loaderFrom(p.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	window, err := parseWindow(p.Args["window"])
+	if err != nil {
+		return nil, err
+	}
+
+	aggregateBy := []string{}
+	if aggs, ok := p.Args["aggregate"].([]interface{}); ok {
+		for _, agg := range aggs {
+			prop, err := kubecost.ParseAssetProperty(fmt.Sprintf("%v", agg))
+			if err != nil {
+				return nil, err
+			}
+			aggregateBy = append(aggregateBy, string(prop))
+		}
+	}
+
+	opts := &kubecost.AssetAggregationOptions{}
+	if filter, ok := p.Args["filter"].(map[string]interface{}); ok && len(filter) > 0 {
+		opts.FilterFuncs = append(opts.FilterFuncs, assetFilterFunc(filter))
+	}
+
+	as, err := l.assetSet(window)
+	if err != nil {
+		return nil, fmt.Errorf("error computing assets for %s: %s", window, err)
+	}
+
+	// Aggregating by no properties collapses every asset into one, so
+	// unaggregated queries are only filtered.
+	if len(aggregateBy) > 0 {
+		err = as.AggregateBy(aggregateBy, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error aggregating assets by %s: %s", strings.Join(aggregateBy, ","), err)
+		}
+		opts.FilterFuncs = nil
+	}
+
+	keys := []string{}
+	assets := map[string]kubecost.Asset{}
+	as.Each(func(key string, a kubecost.Asset) {
+		for _, ff := range opts.FilterFuncs {
+			if !ff(a) {
+				return
+			}
+		}
+		keys = append(keys, key)
+		assets[key] = a
+	})
+	sort.Strings(keys)
+
+	result := make([]kubecost.Asset, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, assets[key])
+	}
+
+	return result, nil
+}
+
+// assetFilterFunc returns an AssetMatchFunc matching the assets of which each
+// property of the given AssetFilter input equals the given value.
+func assetFilterFunc(filter map[string]interface{}) kubecost.AssetMatchFunc {
+	return func(a kubecost.Asset) bool {
+		props := a.Properties()
+		if props == nil {
+			props = &kubecost.AssetProperties{}
+		}
+		for field, value := range filter {
+			var actual string
+			switch field {
+			case "cluster":
+				actual = props.Cluster
+			case "type":
+				actual = a.Type().String()
+			case "category":
+				actual = props.Category
+			case "service":
+				actual = props.Service
+			case "providerID":
+				actual = props.ProviderID
+			case "name":
+				actual = props.Name
+			}
+			if !strings.EqualFold(actual, fmt.Sprintf("%v", value)) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// parseWindow parses the window argument of a query. Open windows are rejected
+// because allocations and assets can only be computed over closed ones.
+func parseWindow(arg interface{}) (kubecost.Window, error) {
+	text, _ := arg.(string)
+	window, err := kubecost.ParseWindowWithOffset(text, env.GetParsedUTCOffset())
+	if err != nil {
+		return window, fmt.Errorf("invalid window \"%s\": %s", text, err)
+	}
+	if window.IsOpen() {
+		return window, fmt.Errorf("invalid window \"%s\": window must be closed", text)
+	}
+	return window, nil
+}
+
+// parseAllocationAggregate parses the aggregate argument of an allocations
+// query, as the aggregate parameter of the allocation API.
+func parseAllocationAggregate(arg interface{}) ([]string, error) {
+	aggregateBy := []string{}
+	aggs, ok := arg.([]interface{})
+	if !ok {
+		return aggregateBy, nil
+	}
+
+	for _, agg := range aggs {
+		aggregate := strings.TrimSpace(fmt.Sprintf("%v", agg))
+		if prop, err := kubecost.ParseProperty(aggregate); err == nil {
+			aggregateBy = append(aggregateBy, string(prop))
+		} else if strings.HasPrefix(aggregate, "label:") || strings.HasPrefix(aggregate, "annotation:") {
+			aggregateBy = append(aggregateBy, aggregate)
+		} else {
+			return nil, fmt.Errorf("invalid aggregate \"%s\"", aggregate)
+		}
+	}
+
+	return aggregateBy, nil
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"github.com/kubecost/opencost/pkg/clustercache"
+	"github.com/kubecost/opencost/pkg/costmodel/clusters"
+	"github.com/kubecost/opencost/pkg/env"
+	"github.com/kubecost/opencost/pkg/log"
+	"github.com/kubecost/opencost/pkg/services/graphql"
+)
+
+// NewGraphQLService creates a new HTTPService implementation serving a GraphQL API over the allocations and assets
+// computed by the given computers and the cluster metadata of the given providers. Returns nil if the schema fails
+// to build, which Add ignores.
+func NewGraphQLService(allocations graphql.AllocationComputer, assets graphql.AssetComputer, clusterInfo clusters.ClusterInfoProvider, cache clustercache.ClusterCache) HTTPService {
+	svc, err := graphql.NewGraphQLHTTPService(allocations, assets, clusterInfo, cache, env.GetETLResolution(), env.GetGraphQLMaxComplexity())
+	if err != nil {
+		log.Errorf("Failed to create GraphQL service: %s", err)
+		return nil
+	}
+
+	return svc
+}
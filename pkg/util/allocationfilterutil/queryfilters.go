@@ -212,6 +212,20 @@ func AllocationFilterFromParamsV1(
 		log.Debugf("No label config is available. Not creating filters for label-mapped 'fields'.")
 	}
 
+	// Helm releases and GitOps applications are labels with a fixed label key,
+	// like label-mapped filters, but do not depend on the label config.
+	if raw := qp.GetList("filterHelmReleases", ","); len(raw) > 0 {
+		filter.Filters = append(filter.Filters, filterV1LabelMappedFromList(raw, kubecost.HelmReleaseLabel))
+	}
+
+	if raw := qp.GetList("filterGitOpsApps", ","); len(raw) > 0 {
+		filter.Filters = append(filter.Filters, filterV1LabelMappedFromList(raw, kubecost.GitOpsAppLabel))
+	}
+
+	if raw := qp.GetList("filterAcceleratorTypes", ","); len(raw) > 0 {
+		filter.Filters = append(filter.Filters, filterV1LabelMappedFromList(raw, kubecost.AcceleratorTypeLabel))
+	}
+
 	if raw := qp.GetList("filterAnnotations", ","); len(raw) > 0 {
 		filter.Filters = append(filter.Filters, filterV1DoubleValueFromList(raw, kubecost.FilterAnnotation))
 	}
@@ -404,6 +404,51 @@ func TestFiltersFromParamsV1(t *testing.T) {
 				}),
 			},
 		},
+		{
+			name: "single helm release",
+			qp: map[string]string{
+				"filterHelmReleases": "cost-analyzer",
+			},
+			shouldMatch: []kubecost.Allocation{
+				allocGenerator(kubecost.AllocationProperties{
+					Labels: map[string]string{
+						kubecost.HelmReleaseLabel: "cost-analyzer",
+					},
+				}),
+			},
+			shouldNotMatch: []kubecost.Allocation{
+				allocGenerator(kubecost.AllocationProperties{
+					Labels: map[string]string{
+						kubecost.HelmReleaseLabel: "prometheus",
+					},
+				}),
+				allocGenerator(kubecost.AllocationProperties{
+					Labels: map[string]string{
+						kubecost.GitOpsAppLabel: "cost-analyzer",
+					},
+				}),
+			},
+		},
+		{
+			name: "wildcard gitops app",
+			qp: map[string]string{
+				"filterGitOpsApps": "payments-*",
+			},
+			shouldMatch: []kubecost.Allocation{
+				allocGenerator(kubecost.AllocationProperties{
+					Labels: map[string]string{
+						kubecost.GitOpsAppLabel: "payments-api",
+					},
+				}),
+			},
+			shouldNotMatch: []kubecost.Allocation{
+				allocGenerator(kubecost.AllocationProperties{
+					Labels: map[string]string{
+						kubecost.GitOpsAppLabel: "billing-api",
+					},
+				}),
+			},
+		},
 		{
 			name: "single label",
 			qp: map[string]string{
@@ -303,9 +303,10 @@ type FakeClusterMap struct {
 }
 
 func TestNodePriceFromCSVWithBadConfig(t *testing.T) {
-	os.Setenv("CONFIG_PATH", "../config")
+	// The provider config writes the missing file, so it is kept out of the tree
+	t.Setenv("CONFIG_PATH", t.TempDir())
 	confMan := config.NewConfigFileManager(&config.ConfigFileManagerOpts{
-		LocalConfigPath: "./",
+		LocalConfigPath: "/",
 	})
 
 	c := &cloud.CSVProvider{